@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey creates a new API key for the authenticated user. The key is only returned once.
+func (ar *AuthRoutes) CreateAPIKey(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		msg := "Failed to get authenticated user claims from context"
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	apiKey, err := ar.authService.CreateAPIKey(c.Context(), claims.Sub)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to create API key for user ID %s: %v", claims.Sub, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.CreatedWithMessage(c, "Store this API key now, it will not be shown again", apiKey)
+}
+
+// RevokeAPIKey revokes one of the authenticated user's API keys
+func (ar *AuthRoutes) RevokeAPIKey(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		msg := "Failed to get authenticated user claims from context"
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		msg := fmt.Sprintf("Invalid API key ID: %s", c.Params("id"))
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+
+	if err := ar.authService.RevokeAPIKey(c.Context(), claims.Sub, keyID); err != nil {
+		msg := fmt.Sprintf("Failed to revoke API key %s for user ID %s: %v", keyID, claims.Sub, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.Message(c, "API key revoked")
+}
+
+// RotateAPIKey revokes an API key and issues a replacement in one transaction
+func (ar *AuthRoutes) RotateAPIKey(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		msg := "Failed to get authenticated user claims from context"
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		msg := fmt.Sprintf("Invalid API key ID: %s", c.Params("id"))
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+
+	apiKey, err := ar.authService.RotateAPIKey(c.Context(), claims.Sub, keyID)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to rotate API key %s for user ID %s: %v", keyID, claims.Sub, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.CreatedWithMessage(c, "Store this API key now, it will not be shown again", apiKey)
+}
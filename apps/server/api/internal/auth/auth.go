@@ -4,99 +4,88 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/MonkyMars/PWS/api/middleware"
 	"github.com/MonkyMars/PWS/api/response"
 	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/types"
+	"github.com/MonkyMars/PWS/validate"
 	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
 )
 
 // Login handles user authentication and returns JWT tokens
 func (ar *AuthRoutes) Login(c fiber.Ctx) error {
-	// Get validated request from context (validation middleware has already processed it)
-	authRequest, err := middleware.GetValidatedRequest[types.AuthRequest](c)
-	if err != nil {
-		msg := fmt.Sprintf("Failed to get validated request: %v", err)
-		return lib.HandleServiceError(c, err, msg)
+	var authRequest types.AuthRequest
+	if err := c.Bind().Body(&authRequest); err != nil {
+		msg := fmt.Sprintf("Failed to bind login request body: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
 	}
+	authRequest.Email = validate.NormalizeEmail(authRequest.Email)
 
-	// Attempt login using injected service
-	user, err := ar.authService.Login(authRequest)
-	if err != nil {
-		msg := fmt.Sprintf("Login failed for email %s: %v", authRequest.Email, err)
-		return lib.HandleServiceError(c, err, msg)
+	if errs := validate.ValidateStruct(authRequest); len(errs) > 0 {
+		return response.SendValidationError(c, errs)
 	}
 
-	// Generate tokens using injected service
-	accessToken, err := ar.authService.GenerateAccessToken(user)
+	// Attempt login using injected service
+	user, err := ar.authService.Login(c.Context(), &authRequest)
 	if err != nil {
-		msg := fmt.Sprintf("Failed to generate access token for user ID %s: %v", user.Id, err)
+		msg := fmt.Sprintf("Login failed for email %s: %v", authRequest.Email, err)
 		return lib.HandleServiceError(c, err, msg)
 	}
 
-	refreshToken, err := ar.authService.GenerateRefreshToken(user)
+	// Start a session and issue its tokens using injected service
+	tokens, err := ar.authService.StartSession(user)
 	if err != nil {
-		msg := fmt.Sprintf("Failed to generate refresh token for user ID %s: %v", user.Id, err)
+		msg := fmt.Sprintf("Failed to start session for user ID %s: %v", user.Id, err)
 		return lib.HandleServiceError(c, err, msg)
 	}
 
-	ar.cookieService.SetAuthCookies(c, accessToken, refreshToken)
+	ar.cookieService.SetAuthCookies(c, tokens.AccessToken, tokens.RefreshToken)
 
 	return response.Success(c, user)
 }
 
 // Register handles user registration and returns JWT tokens
 func (ar *AuthRoutes) Register(c fiber.Ctx) error {
-	// Get validated request from context (validation middleware has already processed it)
-	registerRequest, err := middleware.GetValidatedRequest[types.RegisterRequest](c)
-	if err != nil {
-		msg := fmt.Sprintf("Failed to get validated register request: %v", err)
+	var registerRequest types.RegisterRequest
+	if err := c.Bind().Body(&registerRequest); err != nil {
+		msg := fmt.Sprintf("Failed to bind register request body: %v", err)
 		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
 	}
+	registerRequest.Email = validate.NormalizeEmail(registerRequest.Email)
 
-	// Validate password confirmation
-	if registerRequest.Password != registerRequest.ConfirmPassword {
-		msg := "Password and confirm password do not match"
-		return lib.HandleServiceError(c, lib.ErrPasswordMismatch, msg)
-	}
-
-	// Validate password strength
-	if err := lib.ValidatePasswordStrength(registerRequest.Password); err != nil {
-		msg := "Password does not meet strength requirements"
-		return lib.HandleServiceError(c, err, msg)
+	// Report every invalid field and every unmet password requirement at once,
+	// the password policy only runs on a password that passed its tags
+	v := validate.Struct(registerRequest).
+		Password(c.Context(), "password", registerRequest.Password)
+	if !v.Valid() {
+		return response.SendValidationError(c, v.Errors())
 	}
 
 	// Attempt registration using injected service
-	user, err := ar.authService.Register(registerRequest)
+	user, err := ar.authService.Register(c.Context(), &registerRequest)
 	if err != nil {
 		msg := fmt.Sprintf("Registration failed for email %s, username %s: %v", registerRequest.Email, registerRequest.Username, err)
 		return lib.HandleServiceError(c, err, msg)
 	}
 
-	// Generate tokens for the new user using injected service
-	accessToken, err := ar.authService.GenerateAccessToken(user)
-	if err != nil {
-		msg := fmt.Sprintf("Failed to generate access token for user ID %s: %v", user.Id, err)
-		return lib.HandleServiceError(c, err, msg)
-	}
-
-	refreshToken, err := ar.authService.GenerateRefreshToken(user)
+	// Start a session for the new user using injected service
+	tokens, err := ar.authService.StartSession(user)
 	if err != nil {
-		msg := fmt.Sprintf("Failed to generate refresh token for user ID %s: %v", user.Id, err)
+		msg := fmt.Sprintf("Failed to start session for user ID %s: %v", user.Id, err)
 		return lib.HandleServiceError(c, err, msg)
 	}
 
-	ar.cookieService.SetAuthCookies(c, accessToken, refreshToken)
+	ar.cookieService.SetAuthCookies(c, tokens.AccessToken, tokens.RefreshToken)
 
 	return response.Success(c, user)
 }
 
 // RefreshToken handles token refresh using refresh tokens
 func (ar *AuthRoutes) RefreshToken(c fiber.Ctx) error {
-	token := c.Cookies(lib.RefreshTokenCookieName)
+	token := lib.GetRefreshToken(c)
 
 	// Refresh tokens with rotation using injected service
-	authResponse, err := ar.authService.RefreshToken(token)
+	authResponse, err := ar.authService.RefreshToken(c.Context(), token)
 	if err != nil {
 		// Check if this might be a token reuse attack
 		if strings.Contains(err.Error(), "revoked") || strings.Contains(err.Error(), "blacklisted") {
@@ -122,7 +111,7 @@ func (ar *AuthRoutes) Me(c fiber.Ctx) error {
 	}
 
 	// Fetch user info using injected service
-	user, err := ar.authService.GetUserByID(claims.Sub)
+	user, err := ar.authService.GetUserByID(c.Context(), claims.Sub)
 	if err != nil {
 		msg := fmt.Sprintf("Failed to retrieve user info for user ID %s: %v", claims.Sub, err)
 		return lib.HandleServiceError(c, err, msg)
@@ -139,26 +128,27 @@ func (ar *AuthRoutes) Me(c fiber.Ctx) error {
 // Logout handles user logout with graceful handling of missing/invalid tokens
 func (ar *AuthRoutes) Logout(c fiber.Ctx) error {
 	// Extract values from context before spawning goroutine to avoid race conditions
-	accessToken := c.Cookies(lib.AccessTokenCookieName)
-	refreshToken := c.Cookies(lib.RefreshTokenCookieName)
+	accessToken := lib.GetAccessToken(c)
+	refreshToken := lib.GetRefreshToken(c)
 	user := lib.GetUserFromContext(c)
 
-	// Blacklist access token if present using injected service
+	// Blacklist the access token so the auth middleware rejects it right away instead of when it expires.
+	// BlacklistToken validates the token itself, an invalid one cannot be used anyway.
 	if strings.TrimSpace(accessToken) != "" {
-		// Validate and blacklist access token
-		_, err := ar.authService.GetUserFromToken(accessToken)
-		if err != nil {
-			lib.HandleServiceWarning(c, "Invalid access token during logout, clearing anyway", "error", err)
-		} else {
-			// Token is valid, blacklist it
-			if err := ar.authService.BlacklistToken(accessToken, true); err != nil {
-				lib.HandleServiceWarning(c, "Failed to blacklist access token during logout", "error", err)
-				// Don't return error, continue with logout process
-			}
+		if err := ar.authService.BlacklistToken(accessToken, true); err != nil {
+			lib.HandleServiceWarning(c, "Failed to blacklist access token during logout, clearing anyway", "error", err)
+			// Don't return error, continue with logout process
 		}
 	}
 	// Process refresh token if present using injected service
 	if strings.TrimSpace(refreshToken) != "" {
+		// End the session so no token issued for it keeps working
+		if claims, err := ar.authService.ParseToken(refreshToken, false); err == nil && claims.Sid != uuid.Nil {
+			if err := ar.authService.EndSession(claims.Sid); err != nil {
+				lib.HandleServiceWarning(c, "Failed to end session during logout", "error", err)
+			}
+		}
+
 		// Try to blacklist refresh token (may be invalid, but that's okay)
 		if err := ar.authService.BlacklistToken(refreshToken, false); err != nil {
 			lib.HandleServiceWarning(c, "Failed to blacklist refresh token, may already be invalid", "error", err)
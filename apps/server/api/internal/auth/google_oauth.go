@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/MonkyMars/PWS/api/response"
@@ -58,7 +59,7 @@ func (ar *AuthRoutes) GoogleAccessToken(c fiber.Ctx) error {
 	// Get access token
 	tokenData, err := ar.googleService.GetGoogleAccessToken(claims.Sub)
 	if err != nil {
-		if err.Error() == "no linked Google account" {
+		if errors.Is(err, lib.ErrNoLinkedAccount) {
 			msg := fmt.Sprintf("User ID %s has no linked Google account", claims.Sub)
 			return lib.HandleServiceError(c, lib.ErrNoLinkedAccount, msg)
 		}
@@ -4,7 +4,6 @@ import (
 	"github.com/MonkyMars/PWS/api/middleware"
 	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/services"
-	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -15,6 +14,7 @@ type AuthRoutes struct {
 	authService   services.AuthServiceInterface
 	cookieService services.CookieServiceInterface
 	googleService services.GoogleServiceInterface
+	oauthService  services.OAuthServiceInterface
 	logger        *config.Logger
 	middleware    *middleware.Middleware
 }
@@ -27,40 +27,70 @@ func NewAuthRoutesWithDefaults() *AuthRoutes {
 		authService:   services.NewAuthService(),
 		cookieService: services.NewCookieService(),
 		googleService: services.NewGoogleService(),
+		oauthService:  services.NewOAuthService(),
 		logger:        config.SetupLogger(),
 		middleware:    middleware.NewMiddleware(),
 	}
 }
 
+// NewAuthRoutesWithAuthService creates an AuthRoutes instance that uses the given auth service
+// and the default implementations of everything else, e.g. to test the handlers with a fake one.
+func NewAuthRoutesWithAuthService(authService services.AuthServiceInterface) *AuthRoutes {
+	ar := NewAuthRoutesWithDefaults()
+	ar.authService = authService
+	return ar
+}
+
+// NewAuthRoutesWithOAuthService creates an AuthRoutes instance that runs the OAuth linking flow with the
+// given service and the default implementations of everything else, e.g. to test it with a fake provider.
+func NewAuthRoutesWithOAuthService(oauthService services.OAuthServiceInterface) *AuthRoutes {
+	ar := NewAuthRoutesWithDefaults()
+	ar.oauthService = oauthService
+	return ar
+}
+
+// authBodyLimit caps auth request bodies, which only carry credentials, tokens and API key names
+const authBodyLimit = 16 << 10
+
 // RegisterRoutes registers all auth-related routes with the Fiber application.
 // This method organizes routes logically and follows RESTful conventions.
 // It groups related functionality and applies appropriate middleware.
 func (ar *AuthRoutes) RegisterRoutes(app *fiber.App) {
 	// Auth API group - handles user authentication and management
-	auth := app.Group("/auth")
+	// Tokens and account details must never be cached, request bodies must be JSON
+	auth := app.Group("/auth",
+		ar.middleware.NoStoreMiddleware(),
+		ar.middleware.BodyLimitMiddleware(authBodyLimit),
+		ar.middleware.RequireJSONMiddleware(),
+	)
+
+	// Provider OAuth routes are registered before the protected auth group,
+	// otherwise its middleware would also guard the public callback
+	oauth := auth.Group("/oauth/:provider")
+	ar.registerProviderOAuthRoutes(oauth)
+
 	ar.registerAuthRoutes(auth)
 
-	google := app.Group("/google")
+	google := app.Group("/google", ar.middleware.NoStoreMiddleware())
 	ar.registerOAuthRoutes(google)
 }
 
 // registerAuthRoutes sets up all auth-related endpoints with proper middleware and handlers
 func (ar *AuthRoutes) registerAuthRoutes(router fiber.Router) {
-	// Public auth endpoints with validation middleware
-	router.Post("/login",
-		middleware.ValidateRequest[types.AuthRequest](middleware.AuthRequestValidation),
-		ar.Login,
-	)
-	router.Post("/register",
-		middleware.ValidateRequest[types.RegisterRequest](middleware.RegisterRequestValidation),
-		ar.Register,
-	)
+	// Public auth endpoints, the handlers validate their request bodies
+	router.Post("/login", ar.Login)
+	router.Post("/register", ar.Register)
 	router.Post("/refresh", ar.RefreshToken)
 
 	// Authenticated endpoints (require valid access token)
 	protected := router.Group("/", ar.middleware.AuthMiddleware())
-	protected.Get("/me", ar.Me)
+	protected.Get("/me", ar.middleware.ReadAuditMiddleware("profile"), ar.Me)
 	protected.Post("/logout", ar.Logout)
+
+	// API keys for service-to-service access
+	protected.Post("/api-keys", ar.CreateAPIKey)
+	protected.Delete("/api-keys/:id", ar.RevokeAPIKey)
+	protected.Post("/api-keys/:id/rotate", ar.RotateAPIKey)
 }
 
 func (ar *AuthRoutes) registerOAuthRoutes(router fiber.Router) {
@@ -74,3 +104,10 @@ func (ar *AuthRoutes) registerOAuthRoutes(router fiber.Router) {
 	router.Get("/url", ar.GoogleAuthURL)
 	router.Get("/access-token", ar.GoogleAccessToken)
 }
+
+// registerProviderOAuthRoutes sets up the provider-agnostic OAuth linking endpoints
+func (ar *AuthRoutes) registerProviderOAuthRoutes(router fiber.Router) {
+	// The callback is reached through a redirect from the provider and must stay public
+	router.Get("/callback", ar.OAuthCallback)
+	router.Get("/url", ar.middleware.AuthMiddleware(), ar.OAuthURL)
+}
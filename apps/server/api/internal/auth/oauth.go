@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/gofiber/fiber/v3"
+)
+
+// OAuthURL handles getting the authorization URL for the requested OAuth provider
+// GET /auth/oauth/:provider/url
+func (ar *AuthRoutes) OAuthURL(c fiber.Ctx) error {
+	provider := c.Params("provider")
+
+	// Get user from auth middleware
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to get authenticated user claims for %s OAuth URL generation", provider)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	authURL, err := ar.oauthService.GenerateAuthURL(provider, claims.Sub)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to generate %s OAuth URL for user ID %s: %v", provider, claims.Sub, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.Success(c, authURL)
+}
+
+// OAuthCallback handles the OAuth callback from the requested provider
+// GET /auth/oauth/:provider/callback
+func (ar *AuthRoutes) OAuthCallback(c fiber.Ctx) error {
+	provider := c.Params("provider")
+	state := c.Query("state")
+	code := c.Query("code")
+
+	// Handle OAuth callback (this includes state validation and token exchange)
+	redirectURL, err := ar.oauthService.HandleCallback(provider, state, code)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to handle %s OAuth callback: %v", provider, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	// Redirect to frontend success page
+	return c.Redirect().To(redirectURL)
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/MonkyMars/PWS/services"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+// fakeOAuthProvider is an OAuth provider whose token endpoint is served by the test
+type fakeOAuthProvider struct {
+	name     string
+	tokenURL string
+}
+
+func (p fakeOAuthProvider) Name() string {
+	return p.name
+}
+
+func (p fakeOAuthProvider) OAuthConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://provider.example.com/authorize",
+			TokenURL: p.tokenURL,
+		},
+		RedirectURL: "http://localhost/auth/oauth/" + p.name + "/callback",
+	}
+}
+
+func (p fakeOAuthProvider) AuthCodeOptions() []oauth2.AuthCodeOption {
+	return nil
+}
+
+// newOAuthTestApp serves the auth routes with the providers fake and other, whose token endpoint
+// rejects every code
+func newOAuthTestApp(t *testing.T) (*fiber.App, *services.OAuthService) {
+	t.Helper()
+	loadTestConfig(t)
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Bad code"}`))
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	oauthService := services.NewOAuthServiceWithProviders(
+		fakeOAuthProvider{name: "fake", tokenURL: tokenServer.URL},
+		fakeOAuthProvider{name: "other", tokenURL: tokenServer.URL},
+	)
+
+	app := fiber.New()
+	NewAuthRoutesWithOAuthService(oauthService).RegisterRoutes(app)
+	return app, oauthService
+}
+
+// callbackStatus calls the OAuth callback of provider with the given query and returns the response status
+func callbackStatus(t *testing.T, app *fiber.App, provider string, query url.Values) int {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/oauth/"+provider+"/callback?"+query.Encode(), nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+// issueState starts the linking flow for a new user and returns the state the provider would send back
+func issueState(t *testing.T, oauthService *services.OAuthService, provider string) string {
+	t.Helper()
+
+	authURL, err := oauthService.GenerateAuthURL(provider, uuid.New())
+	if err != nil {
+		t.Fatalf("Failed to generate auth URL: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("Failed to parse auth URL: %v", err)
+	}
+	state := parsed.Query().Get("state")
+	if state == "" {
+		t.Fatalf("Expected a state in the auth URL, got %s", authURL)
+	}
+	return state
+}
+
+func TestOAuthCallbackRequiresStateAndCode(t *testing.T) {
+	app, _ := newOAuthTestApp(t)
+
+	tests := []struct {
+		name     string
+		provider string
+		query    url.Values
+		expected int
+	}{
+		{"missing state", "fake", url.Values{"code": {"code"}}, http.StatusBadRequest},
+		{"missing code", "fake", url.Values{"state": {"state"}}, http.StatusBadRequest},
+		{"provider redirected with an error", "fake", url.Values{"state": {"state"}, "error": {"access_denied"}}, http.StatusBadRequest},
+		{"unknown provider", "unknown", url.Values{"state": {"state"}, "code": {"code"}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := callbackStatus(t, app, tt.provider, tt.query); status != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, status)
+			}
+		})
+	}
+}
+
+func TestOAuthCallbackValidatesState(t *testing.T) {
+	app, oauthService := newOAuthTestApp(t)
+
+	// The state is kept in Redis between the auth URL and the callback
+	if err := services.NewCacheService().Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	t.Run("unknown state", func(t *testing.T) {
+		query := url.Values{"state": {"never-issued"}, "code": {"code"}}
+		if status := callbackStatus(t, app, "fake", query); status != http.StatusBadRequest {
+			t.Errorf("Expected status %d for a state that was never issued, got %d", http.StatusBadRequest, status)
+		}
+	})
+
+	t.Run("state of another provider", func(t *testing.T) {
+		query := url.Values{"state": {issueState(t, oauthService, "other")}, "code": {"code"}}
+		if status := callbackStatus(t, app, "fake", query); status != http.StatusBadRequest {
+			t.Errorf("Expected status %d for a state issued to another provider, got %d", http.StatusBadRequest, status)
+		}
+	})
+
+	t.Run("provider rejects the code", func(t *testing.T) {
+		query := url.Values{"state": {issueState(t, oauthService, "fake")}, "code": {"bad-code"}}
+		if status := callbackStatus(t, app, "fake", query); status != http.StatusInternalServerError {
+			t.Errorf("Expected status %d when the token exchange fails, got %d", http.StatusInternalServerError, status)
+		}
+
+		// The state is used up by the first callback, even though the exchange failed
+		if status := callbackStatus(t, app, "fake", query); status != http.StatusBadRequest {
+			t.Errorf("Expected status %d when the state is replayed, got %d", http.StatusBadRequest, status)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+// loadTestConfig loads the application configuration with test secrets when they are not set
+func loadTestConfig(t *testing.T) {
+	t.Helper()
+
+	for key, value := range map[string]string{
+		"ACCESS_TOKEN_SECRET":  "test-access-token-secret",
+		"REFRESH_TOKEN_SECRET": "test-refresh-token-secret",
+	} {
+		if os.Getenv(key) == "" {
+			t.Setenv(key, value)
+		}
+	}
+
+	config.Load()
+}
+
+// rejectingAuthService fails every login and registration, so requests that pass validation
+// end there instead of needing a database
+type rejectingAuthService struct {
+	services.AuthServiceInterface
+	calls int
+}
+
+func (s *rejectingAuthService) Login(ctx context.Context, authRequest *types.AuthRequest) (*types.User, error) {
+	s.calls++
+	return nil, lib.ErrInvalidCredentials
+}
+
+func (s *rejectingAuthService) Register(ctx context.Context, regRequest *types.RegisterRequest) (*types.User, error) {
+	s.calls++
+	return nil, lib.ErrUserAlreadyExists
+}
+
+// postValidation sends body to an auth route and returns the response status, the fields reported
+// in validation_errors and whether the request reached the auth service
+func postValidation(t *testing.T, path, body string) (int, []string, bool) {
+	t.Helper()
+	loadTestConfig(t)
+
+	authService := &rejectingAuthService{}
+	app := fiber.New()
+	NewAuthRoutesWithAuthService(authService).RegisterRoutes(app)
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var payload struct {
+		Error struct {
+			Details struct {
+				ValidationErrors []types.ValidationError `json:"validation_errors"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	var fields []string
+	for _, e := range payload.Error.Details.ValidationErrors {
+		fields = append(fields, e.Field)
+	}
+	return resp.StatusCode, fields, authService.calls > 0
+}
+
+func TestLoginValidationReportsAllFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{"missing email and password", `{}`, []string{"email", "password"}},
+		{"invalid email and missing password", `{"email":"nope"}`, []string{"email", "password"}},
+		{"missing password only", `{"email":"student@example.com"}`, []string{"password"}},
+		{"valid", `{"email":"student@example.com","password":"secret"}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, fields, reached := postValidation(t, "/auth/login", tt.body)
+
+			// A valid request reaches the auth service, which rejects the credentials
+			expectedStatus := http.StatusUnauthorized
+			if len(tt.expected) > 0 {
+				expectedStatus = http.StatusUnprocessableEntity
+			}
+			if status != expectedStatus {
+				t.Errorf("Expected status %d, got %d", expectedStatus, status)
+			}
+			if reached != (len(tt.expected) == 0) {
+				t.Errorf("Expected the auth service to be called only for a valid request, called: %v", reached)
+			}
+			if !slices.Equal(fields, tt.expected) {
+				t.Errorf("Expected errors for %v in one response, got %v", tt.expected, fields)
+			}
+		})
+	}
+}
+
+func TestRegisterValidationReportsAllFields(t *testing.T) {
+	status, fields, reached := postValidation(t, "/auth/register", `{}`)
+
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", status)
+	}
+	if reached {
+		t.Error("Expected an invalid registration not to reach the auth service")
+	}
+	expected := []string{"username", "email", "password", "confirm_password"}
+	if !slices.Equal(fields, expected) {
+		t.Errorf("Expected errors for %v in one response, got %v", expected, fields)
+	}
+}
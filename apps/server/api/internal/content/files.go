@@ -180,12 +180,19 @@ func (cr *ContentRoutes) UploadMultipleFiles(c fiber.Ctx) error {
 		return lib.HandleServiceError(c, err, msg)
 	}
 
-	// Make all files public on Google Drive
+	// Make all files public on Google Drive using a single Drive client
+	fileIDs := make([]string, 0, len(req.Files))
 	for _, file := range req.Files {
-		if err := cr.googleService.MakeFilePublic(claims.Sub, file.FileID); err != nil {
-			cr.logger.AuditError("UploadMultipleFiles: Failed to make file %s public - %v", file.Name, err)
-			// Don't fail the upload if making it public fails, just log the warning
-		}
+		fileIDs = append(fileIDs, file.FileID)
+	}
+
+	// Don't fail the upload if making files public fails, just log the errors
+	failures, err := cr.googleService.MakeFilesPublic(claims.Sub, fileIDs)
+	if err != nil {
+		cr.logger.AuditError("UploadMultipleFiles: Failed to make files public", "user_id", claims.Sub, "error", err)
+	}
+	for fileID, fileErr := range failures {
+		cr.logger.AuditError("UploadMultipleFiles: Failed to make file public", "file_id", fileID, "error", fileErr)
 	}
 
 	return response.Created(c, files)
@@ -0,0 +1,21 @@
+package deadlines
+
+import (
+	"context"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+// authorizeDeadline returns lib.ErrInsufficientPermissions when the user in the claims may not access the deadline
+func (dr *DeadlineRoutes) authorizeDeadline(ctx context.Context, claims *types.AuthClaims, deadlineID uuid.UUID) error {
+	allowed, err := dr.deadlineService.CanAccess(ctx, claims.Sub, claims.Role, deadlineID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return lib.ErrInsufficientPermissions
+	}
+	return nil
+}
@@ -19,9 +19,9 @@ func (dr *DeadlineRoutes) CreateDeadline(c fiber.Ctx) error {
 		return response.NotFound(c, "Data not found")
 	}
 
-	err = dr.deadlineService.CreateDeadline(body)
+	err = dr.deadlineService.CreateDeadline(c.Context(), body)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to create deadline: "+err.Error())
+		return lib.HandleServiceError(c, err, "Failed to create deadline")
 	}
 	return response.Accepted(c, "Deadline creation accepted")
 }
@@ -28,6 +28,10 @@ func (dr *DeadlineRoutes) CreateOrUpdateSubmission(c fiber.Ctx) error {
 		return lib.HandleServiceError(c, err, "invalid deadline id")
 	}
 
+	if err := dr.authorizeDeadline(c.Context(), claims, deadlineID); err != nil {
+		return lib.HandleServiceError(c, err, "not allowed to submit to this deadline")
+	}
+
 	var req types.CreateSubmissionRequest
 	if err := c.Bind().Body(&req); err != nil {
 		return lib.HandleServiceError(c, err, "failed to parse submission request")
@@ -45,12 +49,12 @@ func (dr *DeadlineRoutes) CreateOrUpdateSubmission(c fiber.Ctx) error {
 	now := time.Now().UTC().Format(time.RFC3339)
 
 	// Call service to create or update submission
-	submission, err := dr.deadlineService.CreateOrUpdateSubmission(deadlineID, claims.Sub, req, now)
+	submission, err := dr.deadlineService.CreateOrUpdateSubmission(c.Context(), deadlineID, claims.Sub, req, now)
 	if err != nil {
 		return lib.HandleServiceError(c, err, "failed to create or update submission")
 	}
 
 	// TODO: Notify teachers/admins of new/updated submission
 
-	return c.Status(http.StatusAccepted).JSON(submission)
+	return c.Status(http.StatusAccepted).JSON(lib.SubmissionForRole(submission, claims.Role))
 }
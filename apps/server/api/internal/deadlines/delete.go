@@ -3,6 +3,7 @@ package deadlines
 import (
 	"github.com/MonkyMars/PWS/api/response"
 	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 )
@@ -10,12 +11,26 @@ import (
 // DeleteDeadline handles deleting a specific deadline by ID
 // DELETE /deadlines/:id
 func (dr *DeadlineRoutes) DeleteDeadlineById(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "failed to get user claims")
+	}
+
 	deadlineId := c.Params("id")
 	if deadlineId == "" {
 		return lib.HandleServiceError(c, nil, "deadline id parameter is required")
 	}
 
-	err := dr.deadlineService.DeleteDeadlineById(deadlineId)
+	deadlineUuid, err := uuid.Parse(deadlineId)
+	if err != nil {
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, "invalid deadline id")
+	}
+
+	if err := dr.authorizeDeadline(c.Context(), claims, deadlineUuid); err != nil {
+		return lib.HandleServiceError(c, err, "not allowed to delete this deadline")
+	}
+
+	err = dr.deadlineService.DeleteDeadlineById(c.Context(), deadlineId)
 	if err != nil {
 		return lib.HandleServiceError(c, err, "failed to delete deadline")
 	}
@@ -26,6 +41,11 @@ func (dr *DeadlineRoutes) DeleteDeadlineById(c fiber.Ctx) error {
 // DeleteDeadlinesByUser handles deleting all deadlines for a specific user
 // DELETE /deadlines/user/:user_id
 func (dr *DeadlineRoutes) DeleteDeadlinesByUser(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "failed to get user claims")
+	}
+
 	userId := c.Params("user_id")
 	if userId == "" {
 		return lib.HandleServiceError(c, nil, "user_id parameter is required")
@@ -33,10 +53,14 @@ func (dr *DeadlineRoutes) DeleteDeadlinesByUser(c fiber.Ctx) error {
 
 	userUuid, err := uuid.Parse(userId)
 	if err != nil {
-		return lib.HandleServiceError(c, err, "invalid user_id parameter")
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, "invalid user_id parameter")
+	}
+
+	if !services.CanManageUserDeadlines(claims.Sub, claims.Role, userUuid) {
+		return lib.HandleServiceError(c, lib.ErrInsufficientPermissions, "not allowed to delete the deadlines of this user")
 	}
 
-	err = dr.deadlineService.DeleteDeadlinesFromUser(userUuid)
+	err = dr.deadlineService.DeleteDeadlinesFromUser(c.Context(), userUuid)
 	if err != nil {
 		return lib.HandleServiceError(c, err, "failed to delete deadlines for user")
 	}
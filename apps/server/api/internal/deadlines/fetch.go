@@ -6,7 +6,8 @@ import (
 	"github.com/gofiber/fiber/v3"
 )
 
-// FetchDeadlines handles fetching all deadlines
+// FetchDeadlines handles fetching all deadlines, sorted by due date unless the sort
+// parameter asks for due_date, created_at or title with an optional :asc or :desc
 // GET /deadlines/me
 func (dr *DeadlineRoutes) FetchDeadlinesForUser(c fiber.Ctx) error {
 	claims, err := lib.GetValidatedClaims(c)
@@ -18,26 +19,30 @@ func (dr *DeadlineRoutes) FetchDeadlinesForUser(c fiber.Ctx) error {
 		"due_date_from": false,
 		"due_date_to":   false,
 		"subject_id":    false,
+		"tag":           false,
 	})
 	if err != nil {
 		return lib.HandleServiceError(c, err, "failed to get filter options")
 	}
+	if sort := c.Query("sort"); sort != "" {
+		filterOptions["sort"] = sort
+	}
 
 	dr.logger.Info("Fetching deadlines for user", "userID", claims.Sub, "role", claims.Role)
 
 	if claims.Role == "student" {
-		deadlines, err := dr.deadlineService.FetchDeadlinesByUser(claims.Sub, filterOptions)
+		deadlines, err := dr.deadlineService.FetchDeadlinesByUser(c.Context(), claims.Sub, filterOptions)
 		if err != nil {
 			return lib.HandleServiceError(c, err, "failed to fetch deadlines for user")
 		}
 
-		return response.Success(c, deadlines)
+		return response.SuccessWithETag(c, deadlines)
 	}
 
-	deadlines, err := dr.deadlineService.FetchAllDeadlines(filterOptions)
+	deadlines, err := dr.deadlineService.FetchAllDeadlines(c.Context(), filterOptions)
 	if err != nil {
 		return lib.HandleServiceError(c, err, "failed to fetch deadlines")
 	}
 
-	return response.Success(c, deadlines)
+	return response.SuccessWithETag(c, deadlines)
 }
@@ -24,12 +24,16 @@ func (dr *DeadlineRoutes) GetOwnSubmission(c fiber.Ctx) error {
 		return lib.HandleServiceError(c, err, "invalid deadline id")
 	}
 
-	submission, err := dr.deadlineService.GetSubmissionByStudent(deadlineID, claims.Sub)
+	if err := dr.authorizeDeadline(c.Context(), claims, deadlineID); err != nil {
+		return lib.HandleServiceError(c, err, "not allowed to access this deadline")
+	}
+
+	submission, err := dr.deadlineService.GetSubmissionByStudent(c.Context(), deadlineID, claims.Sub)
 	if err != nil {
 		return lib.HandleServiceError(c, err, "failed to fetch submission")
 	}
 
-	return response.Success(c, submission)
+	return response.Success(c, lib.SubmissionForRole(submission, claims.Role))
 }
 
 // GetAllSubmissions handles fetching all student submissions for a specific deadline
@@ -39,9 +43,6 @@ func (dr *DeadlineRoutes) GetAllSubmissions(c fiber.Ctx) error {
 	if err != nil {
 		return lib.HandleServiceError(c, err, "failed to get user claims")
 	}
-	if claims.Role != "teacher" && claims.Role != "admin" {
-		return lib.HandleServiceError(c, lib.ErrInsufficientPermissions, "only teachers or admins can fetch all submissions")
-	}
 
 	deadlineIDStr := c.Params("id")
 	deadlineID, err := uuid.Parse(deadlineIDStr)
@@ -49,10 +50,14 @@ func (dr *DeadlineRoutes) GetAllSubmissions(c fiber.Ctx) error {
 		return lib.HandleServiceError(c, err, "invalid deadline id")
 	}
 
-	submissions, err := dr.deadlineService.GetAllSubmissionsForDeadline(deadlineID)
+	if err := dr.authorizeDeadline(c.Context(), claims, deadlineID); err != nil {
+		return lib.HandleServiceError(c, err, "not allowed to access this deadline")
+	}
+
+	submissions, err := dr.deadlineService.GetAllSubmissionsForDeadline(c.Context(), deadlineID)
 	if err != nil {
 		return lib.HandleServiceError(c, err, "failed to fetch submissions")
 	}
 
-	return response.Success(c, submissions)
+	return response.Success(c, lib.SubmissionsForRole(submissions, claims.Role))
 }
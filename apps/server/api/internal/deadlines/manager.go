@@ -34,15 +34,22 @@ func NewDeadlineRoutesWithDefaults() *DeadlineRoutes {
 // It groups related functionality and applies appropriate middleware.
 func (dr *DeadlineRoutes) RegisterRoutes(app *fiber.App) {
 	deadlines := app.Group("/deadlines", dr.middleware.AuthMiddleware())
+	requireJSON := dr.middleware.RequireJSONMiddleware()
 
-	deadlines.Post("/", dr.middleware.RoleMiddleware(lib.RoleAdmin, lib.RoleTeacher), dr.CreateDeadline)
+	deadlines.Post("/", requireJSON, dr.middleware.RoleMiddleware(lib.RoleAdmin, lib.RoleTeacher), dr.CreateDeadline)
 	deadlines.Get("/me", dr.FetchDeadlinesForUser)
-	deadlines.Put("/:id", dr.UpdateDeadlineById)
-	deadlines.Delete("/:id", dr.DeleteDeadlineById)
-	deadlines.Delete("/user/:user_id", dr.DeleteDeadlinesByUser)
+	deadlines.Put("/:id", requireJSON, dr.middleware.RoleMiddleware(lib.RoleAdmin, lib.RoleTeacher), dr.UpdateDeadlineById)
+	deadlines.Delete("/:id", dr.middleware.RoleMiddleware(lib.RoleAdmin, lib.RoleTeacher), dr.DeleteDeadlineById)
+	deadlines.Delete("/user/:user_id", dr.middleware.RoleMiddleware(lib.RoleAdmin, lib.RoleTeacher), dr.DeleteDeadlinesByUser)
 
-	// Submission endpoints
-	deadlines.Post("/:id/submission", dr.CreateOrUpdateSubmission)
-	deadlines.Get("/:id/submission", dr.GetOwnSubmission)
-	deadlines.Get("/:id/submissions", dr.middleware.RoleMiddleware(lib.RoleAdmin, lib.RoleTeacher), dr.GetAllSubmissions)
+	// Submission endpoints, their responses must never be cached
+	noStore := dr.middleware.NoStoreMiddleware()
+	deadlines.Post("/:id/submission", noStore, requireJSON, dr.CreateOrUpdateSubmission)
+	deadlines.Get("/:id/submission", noStore, dr.GetOwnSubmission)
+	deadlines.Get("/:id/submissions",
+		noStore,
+		dr.middleware.RequirePermission(lib.PermSubmissionsGrade),
+		dr.middleware.ReadAuditMiddleware("submissions"),
+		dr.GetAllSubmissions,
+	)
 }
@@ -5,20 +5,37 @@ import (
 	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
 )
 
+// UpdateDeadlineById handles updating a specific deadline by ID
+// PUT /deadlines/:id
 func (dr *DeadlineRoutes) UpdateDeadlineById(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "failed to get user claims")
+	}
+
 	deadlineId := c.Params("id")
 	if deadlineId == "" {
 		return lib.HandleServiceError(c, nil, "deadline id parameter is required")
 	}
 
+	deadlineUuid, err := uuid.Parse(deadlineId)
+	if err != nil {
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, "invalid deadline id")
+	}
+
+	if err := dr.authorizeDeadline(c.Context(), claims, deadlineUuid); err != nil {
+		return lib.HandleServiceError(c, err, "not allowed to update this deadline")
+	}
+
 	var updateData types.Deadline
 	if err := c.Bind().Body(&updateData); err != nil {
 		return lib.HandleServiceError(c, err, "failed to parse request body")
 	}
 
-	err := dr.deadlineService.UpdateDeadlineById(deadlineId, updateData)
+	err = dr.deadlineService.UpdateDeadlineById(c.Context(), deadlineId, updateData)
 	if err != nil {
 		return lib.HandleServiceError(c, err, "failed to update deadline")
 	}
@@ -2,10 +2,12 @@ package health
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/MonkyMars/PWS/api/response"
 	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -18,3 +20,110 @@ func (hr *HealthRoutes) GetLogs(c fiber.Ctx) error {
 	}
 	return response.Success(c, logs)
 }
+
+// SearchLogs returns a page of audit logs filtered by level, source, time range and message text.
+// Query parameters: level, source, q, from, to (RFC 3339), page and limit.
+func (hr *HealthRoutes) SearchLogs(c fiber.Ctx) error {
+	filter, err := parseAuditLogFilter(c)
+	if err != nil {
+		msg := fmt.Sprintf("Invalid audit log search parameters: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidInput, msg)
+	}
+
+	logs, err := hr.auditService.QueryAuditLogs(filter)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to search audit logs: %v", err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	total, err := hr.auditService.CountAuditLogs(filter)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to count audit logs: %v", err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	items := make([]any, len(logs))
+	for i, log := range logs {
+		items[i] = log
+	}
+
+	return response.Paginated(c, items, filter.Page, filter.Limit, total)
+}
+
+// defaultHealthHistoryRange is the series returned when no time range is requested
+const defaultHealthHistoryRange = 24 * time.Hour
+
+// GetServiceHistory returns the health log series of a service for charting, oldest first.
+// Query parameters: from and to (RFC 3339), defaulting to the last 24 hours.
+func (hr *HealthRoutes) GetServiceHistory(c fiber.Ctx) error {
+	service := c.Params("service")
+
+	from, to, err := parseHealthHistoryRange(c, time.Now())
+	if err != nil {
+		msg := fmt.Sprintf("Invalid health history parameters: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidInput, msg)
+	}
+
+	logs, err := hr.healthService.QueryServiceHistory(service, from, to)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to retrieve health history for %s: %v", service, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.Success(c, logs)
+}
+
+// parseHealthHistoryRange reads the from and to query parameters. A missing to defaults to now,
+// a missing from to 24 hours before to.
+func parseHealthHistoryRange(c fiber.Ctx, now time.Time) (time.Time, time.Time, error) {
+	to := now
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be an RFC 3339 timestamp: %w", err)
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultHealthHistoryRange)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be an RFC 3339 timestamp: %w", err)
+		}
+		from = parsed
+	}
+
+	return from, to, nil
+}
+
+// parseAuditLogFilter builds an audit log filter from the request query parameters
+func parseAuditLogFilter(c fiber.Ctx) (types.AuditLogFilter, error) {
+	filter := types.AuditLogFilter{
+		Level:  c.Query("level"),
+		Source: c.Query("source"),
+		Search: c.Query("q"),
+		Page:   fiber.Query[int](c, "page", 1),
+		Limit:  fiber.Query[int](c, "limit", types.DefaultAuditLogLimit),
+	}
+
+	for name, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 timestamp: %w", name, err)
+		}
+		*target = parsed
+	}
+
+	filter.Normalize()
+	if err := filter.Validate(); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
@@ -3,7 +3,10 @@ package health
 import (
 	"time"
 
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/workers"
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -16,7 +19,13 @@ var (
 // It follows clean architecture principles by depending on interfaces rather than concrete implementations.
 // This makes the code more testable and maintainable.
 type HealthRoutes struct {
-	auditService services.AuditServiceInterface
+	auditService  services.AuditServiceInterface
+	healthService services.HealthServiceInterface
+	middleware    *middleware.Middleware
+
+	readOnlyService services.ReadOnlyServiceInterface
+	cacheService    *services.CacheService
+	workerManager   workers.WorkerManagerInterface
 }
 
 // NewAuthRoutesWithDefaults creates an AuthRoutes instance with default dependencies.
@@ -24,15 +33,34 @@ type HealthRoutes struct {
 // the default implementations of all services.
 func NewHealthRoutesWithDefaults() *HealthRoutes {
 	return &HealthRoutes{
-		auditService: services.NewAuditService(),
+		auditService:  services.NewAuditService(),
+		healthService: services.NewHealthService(),
+		middleware:    middleware.NewMiddleware(),
+
+		readOnlyService: services.NewReadOnlyService(),
+		cacheService:    services.NewCacheService(),
+		workerManager:   workers.GetGlobalManager(),
 	}
 }
 
 // This method organizes routes logically and follows RESTful conventions.
 // It groups related functionality and applies appropriate middleware.
 func (hr *HealthRoutes) RegisterRoutes(app *fiber.App) {
+	// Kubernetes probes, liveness only checks the process while readiness checks its dependencies
+	app.Get("/healthz", Liveness)
+	app.Get("/readyz", Readiness(hr.readinessChecks()))
+
 	health := app.Group("/health")
 	health.Get("/", hr.GetSystemHealth)
 	health.Get("/database", hr.GetDatabaseHealth)
-	health.Get("/logs", hr.GetLogs)
+
+	// Log reads require the audit:read permission and are themselves audited
+	auth := hr.middleware.AuthMiddleware()
+	auditRead := hr.middleware.RequirePermission(lib.PermAuditRead)
+	health.Get("/logs", auth, auditRead, hr.middleware.ReadAuditMiddleware("audit_logs"), hr.GetLogs)
+	health.Get("/logs/search", auth, auditRead, hr.middleware.ReadAuditMiddleware("audit_logs"), hr.SearchLogs)
+	health.Get("/history/:service", auth, auditRead, hr.middleware.ReadAuditMiddleware("health_logs"), hr.GetServiceHistory)
+
+	health.Get("/read-only", hr.GetReadOnlyMode)
+	health.Put("/read-only", hr.middleware.AdminMiddleware(), hr.SetReadOnlyMode)
 }
@@ -0,0 +1,87 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+// DependencyCheck returns an error when a dependency the API needs to serve requests is unavailable
+type DependencyCheck func() error
+
+// Liveness reports that the process is up and able to handle requests.
+// It never touches dependencies, so a database outage does not get the pod restarted.
+// GET /healthz
+func Liveness(c fiber.Ctx) error {
+	return response.Success(c, types.ProbeResponse{Status: "ok"})
+}
+
+// Readiness runs every check concurrently and responds with 503 when any of them fails,
+// so the pod is taken out of the load balancer until its dependencies recover.
+// GET /readyz
+func Readiness(checks map[string]DependencyCheck) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		statuses := make(map[string]types.DependencyStatus, len(checks))
+		ready := true
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				start := time.Now()
+				err := check()
+
+				status := types.DependencyStatus{Status: "ok", Elapsed: time.Since(start).String()}
+				if err != nil {
+					status.Status = "unavailable"
+					status.Error = err.Error()
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				statuses[name] = status
+				if err != nil {
+					ready = false
+				}
+			}()
+		}
+		wg.Wait()
+
+		if !ready {
+			return response.CustomErrorWithDetails(c, fiber.StatusServiceUnavailable, response.ErrCodeServiceUnavail,
+				"One or more dependencies are unavailable", map[string]any{"checks": statuses})
+		}
+
+		return response.Success(c, types.ProbeResponse{Status: "ok", Checks: statuses})
+	}
+}
+
+// readinessChecks returns the dependencies that must be available before the API accepts traffic
+func (hr *HealthRoutes) readinessChecks() map[string]DependencyCheck {
+	return map[string]DependencyCheck{
+		"database": services.Ping,
+		"redis":    hr.cacheService.Ping,
+		"workers":  hr.checkWorkers,
+	}
+}
+
+// checkWorkers fails when the worker manager reports one of its workers as unhealthy
+func (hr *HealthRoutes) checkWorkers() error {
+	status := hr.workerManager.HealthStatus()
+	if healthy, _ := status["is_healthy"].(bool); healthy {
+		return nil
+	}
+	if message, ok := status["error"].(string); ok {
+		return errors.New(message)
+	}
+	return fmt.Errorf("one or more workers are unhealthy")
+}
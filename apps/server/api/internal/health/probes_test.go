@@ -0,0 +1,94 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestLivenessProbe(t *testing.T) {
+	app := fiber.New()
+	app.Get("/healthz", Liveness)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestReadinessProbe(t *testing.T) {
+	ok := func() error { return nil }
+	down := func() error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name       string
+		checks     map[string]DependencyCheck
+		statusCode int
+		failed     string
+	}{
+		{"all dependencies up", map[string]DependencyCheck{"database": ok, "redis": ok, "workers": ok}, http.StatusOK, ""},
+		{"database down", map[string]DependencyCheck{"database": down, "redis": ok, "workers": ok}, http.StatusServiceUnavailable, "database"},
+		{"redis down", map[string]DependencyCheck{"database": ok, "redis": down, "workers": ok}, http.StatusServiceUnavailable, "redis"},
+		{"workers unhealthy", map[string]DependencyCheck{"database": ok, "redis": ok, "workers": down}, http.StatusServiceUnavailable, "workers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/readyz", Readiness(tt.checks))
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.statusCode {
+				t.Fatalf("Expected status %d, got %d", tt.statusCode, resp.StatusCode)
+			}
+
+			var body types.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			var checks map[string]any
+			if tt.failed == "" {
+				if !body.Success {
+					t.Fatalf("Expected a successful response, got %+v", body)
+				}
+				data, _ := body.Data.(map[string]any)
+				checks, _ = data["checks"].(map[string]any)
+			} else {
+				if body.Error == nil || body.Error.Code != "SERVICE_UNAVAILABLE" {
+					t.Fatalf("Expected a SERVICE_UNAVAILABLE error, got %+v", body.Error)
+				}
+				checks, _ = body.Error.Details["checks"].(map[string]any)
+			}
+
+			if len(checks) != len(tt.checks) {
+				t.Fatalf("Expected %d checks in the response, got %v", len(tt.checks), checks)
+			}
+			for name := range tt.checks {
+				check, _ := checks[name].(map[string]any)
+				expected := "ok"
+				if name == tt.failed {
+					expected = "unavailable"
+				}
+				if check["status"] != expected {
+					t.Errorf("Expected %s status %q, got %v", name, expected, check["status"])
+				}
+			}
+		})
+	}
+}
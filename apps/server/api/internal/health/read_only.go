@@ -0,0 +1,35 @@
+package health
+
+import (
+	"fmt"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+// GetReadOnlyMode reports whether the API currently rejects writes, so clients can show a maintenance notice
+func (hr *HealthRoutes) GetReadOnlyMode(c fiber.Ctx) error {
+	return response.Success(c, fiber.Map{"read_only": hr.readOnlyService.IsReadOnly()})
+}
+
+// SetReadOnlyMode switches read-only mode on or off for every replica.
+// Body: {"enabled": true}
+func (hr *HealthRoutes) SetReadOnlyMode(c fiber.Ctx) error {
+	var req types.ReadOnlyModeRequest
+	if err := c.Bind().Body(&req); err != nil {
+		msg := fmt.Sprintf("Failed to parse read-only mode request body: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+	if req.Enabled == nil {
+		return lib.HandleServiceError(c, lib.ErrMissingField, "Missing enabled field in read-only mode request")
+	}
+
+	if err := hr.readOnlyService.SetReadOnly(*req.Enabled); err != nil {
+		msg := fmt.Sprintf("Failed to change read-only mode: %v", err)
+		return lib.HandleServiceError(c, lib.ErrServiceUnavailable, msg)
+	}
+
+	return response.SuccessWithMessage(c, "Read-only mode updated", fiber.Map{"read_only": *req.Enabled})
+}
@@ -1,12 +1,18 @@
 package subjects
 
 import (
+	"time"
+
 	"github.com/MonkyMars/PWS/api/middleware"
 	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/services"
 	"github.com/gofiber/fiber/v3"
 )
 
+// subjectsCacheMaxAge is how long clients may reuse subject responses before fetching them again
+const subjectsCacheMaxAge = 5 * time.Minute
+
 // HealthRoutes handles HTTP routing for health-related endpoints.
 // It follows clean architecture principles by depending on interfaces rather than concrete implementations.
 // This makes the code more testable and maintainable.
@@ -32,8 +38,22 @@ func NewSubjectRoutesWithDefaults() *SubjectRoutes {
 func (sr *SubjectRoutes) RegisterRoutes(app *fiber.App) {
 	subjects := app.Group("/subjects", sr.middleware.AuthMiddleware())
 
-	subjects.Get("/", sr.GetAllSubjects)
-	subjects.Get("/me", sr.GetUserSubjects)
-	subjects.Get("/:subjectId", sr.GetSubjectByID)
-	subjects.Get("/:subjectId/teachers", sr.GetSubjectTeachers)
+	// Subjects rarely change, so clients may reuse them for a while. The responses depend on the
+	// signed in user, so only the client caches them and shared caches do not.
+	cached := sr.middleware.CacheControlMiddleware(middleware.CacheControlOptions{MaxAge: subjectsCacheMaxAge, Private: true})
+
+	subjects.Get("/", cached, sr.GetAllSubjects)
+	subjects.Get("/me", cached, sr.GetUserSubjects)
+	subjects.Get("/mine", cached, sr.middleware.RoleMiddleware(lib.RoleTeacher, lib.RoleAdmin), sr.GetTeacherSubjects)
+	subjects.Get("/:subjectId", cached, sr.GetSubjectByID)
+	subjects.Get("/:subjectId/teachers", cached, sr.GetSubjectTeachers)
+	subjects.Delete("/:subjectId", sr.middleware.RoleMiddleware(lib.RoleAdmin), sr.PurgeSubject)
+
+	// Managing subjects and their teachers is limited to admins
+	admin := sr.middleware.RoleMiddleware(lib.RoleAdmin)
+	subjects.Post("/", admin, sr.CreateSubject)
+	subjects.Put("/:subjectId", admin, sr.UpdateSubject)
+	subjects.Post("/:subjectId/deactivate", admin, sr.DeactivateSubject)
+	subjects.Post("/:subjectId/teachers", admin, sr.AssignTeacher)
+	subjects.Delete("/:subjectId/teachers/:userId", admin, sr.RemoveTeacher)
 }
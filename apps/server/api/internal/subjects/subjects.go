@@ -7,6 +7,7 @@ import (
 	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
 )
 
 // GetSubjectByID retrieves a subject by its ID
@@ -69,6 +70,24 @@ func (sr *SubjectRoutes) GetUserSubjects(c fiber.Ctx) error {
 	return response.Success(c, subjects)
 }
 
+// GetTeacherSubjects returns the subjects the signed in teacher is assigned to
+// GET /subjects/mine (teachers and admins)
+func (sr *SubjectRoutes) GetTeacherSubjects(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		msg := "Failed to get authenticated user claims for teacher subjects retrieval"
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	subjects, err := sr.subjectService.GetSubjectsForTeacher(claims.Sub)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to retrieve subjects for teacher ID %s: %v", claims.Sub.String(), err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.Success(c, subjects)
+}
+
 func (sr *SubjectRoutes) GetSubjectTeachers(c fiber.Ctx) error {
 	subjectId, err := lib.GetParams(c, map[string]bool{
 		"subjectId": true,
@@ -86,3 +105,154 @@ func (sr *SubjectRoutes) GetSubjectTeachers(c fiber.Ctx) error {
 
 	return response.Success(c, teachers)
 }
+
+// PurgeSubject permanently deletes a subject with its deadlines, submissions and teacher mappings
+// DELETE /subjects/:subjectId (admin only)
+func (sr *SubjectRoutes) PurgeSubject(c fiber.Ctx) error {
+	subjectID, err := uuid.Parse(c.Params("subjectId"))
+	if err != nil {
+		msg := fmt.Sprintf("Invalid subjectId parameter %q in request", c.Params("subjectId"))
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+
+	if err := sr.subjectService.PurgeSubject(subjectID); err != nil {
+		msg := fmt.Sprintf("Failed to purge subject for subject ID %s: %v", subjectID, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.NoContent(c)
+}
+
+// CreateSubject creates a subject, optionally with its main teacher
+// POST /subjects (admin only)
+// Body: {"name": "Mathematics", "code": "MATH", "color": "#1E88E5", "teacher_id": "optional"}
+func (sr *SubjectRoutes) CreateSubject(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "Failed to get validated claims in CreateSubject")
+	}
+
+	var req types.CreateSubjectRequest
+	if err := c.Bind().Body(&req); err != nil {
+		msg := fmt.Sprintf("Failed to parse create subject request body: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+
+	subject, err := sr.subjectService.CreateSubject(claims.Sub, &req)
+	if err != nil {
+		return lib.HandleServiceError(c, err, fmt.Sprintf("Failed to create subject %q: %v", req.Name, err))
+	}
+
+	return response.Created(c, subject)
+}
+
+// UpdateSubject changes the name, code, color, main teacher or active flag of a subject
+// PUT /subjects/:subjectId (admin only)
+// Body: any of {"name": "...", "code": "...", "color": "...", "teacher_id": "...", "is_active": true}
+func (sr *SubjectRoutes) UpdateSubject(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "Failed to get validated claims in UpdateSubject")
+	}
+
+	subjectID, err := parseUUIDParam(c, "subjectId")
+	if err != nil {
+		return err
+	}
+
+	var req types.UpdateSubjectRequest
+	if err := c.Bind().Body(&req); err != nil {
+		msg := fmt.Sprintf("Failed to parse update subject request body: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+
+	subject, err := sr.subjectService.UpdateSubject(claims.Sub, subjectID, &req)
+	if err != nil {
+		return lib.HandleServiceError(c, err, fmt.Sprintf("Failed to update subject %s: %v", subjectID, err))
+	}
+
+	return response.SuccessWithMessage(c, "Subject updated", subject)
+}
+
+// DeactivateSubject hides a subject while keeping its deadlines and submissions
+// POST /subjects/:subjectId/deactivate (admin only)
+func (sr *SubjectRoutes) DeactivateSubject(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "Failed to get validated claims in DeactivateSubject")
+	}
+
+	subjectID, err := parseUUIDParam(c, "subjectId")
+	if err != nil {
+		return err
+	}
+
+	if err := sr.subjectService.DeactivateSubject(claims.Sub, subjectID); err != nil {
+		return lib.HandleServiceError(c, err, fmt.Sprintf("Failed to deactivate subject %s: %v", subjectID, err))
+	}
+
+	return response.SuccessWithMessage(c, "Subject deactivated", nil)
+}
+
+// AssignTeacher assigns a teacher or admin to a subject
+// POST /subjects/:subjectId/teachers (admin only)
+// Body: {"user_id": "..."}
+func (sr *SubjectRoutes) AssignTeacher(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "Failed to get validated claims in AssignTeacher")
+	}
+
+	subjectID, err := parseUUIDParam(c, "subjectId")
+	if err != nil {
+		return err
+	}
+
+	var req types.AssignTeacherRequest
+	if err := c.Bind().Body(&req); err != nil || req.UserID == uuid.Nil {
+		msg := fmt.Sprintf("Failed to parse assign teacher request body: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+
+	if err := sr.subjectService.AssignTeacher(claims.Sub, subjectID, req.UserID); err != nil {
+		msg := fmt.Sprintf("Failed to assign teacher %s to subject %s: %v", req.UserID, subjectID, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.SuccessWithMessage(c, "Teacher assigned", nil)
+}
+
+// RemoveTeacher removes a teacher from a subject
+// DELETE /subjects/:subjectId/teachers/:userId (admin only)
+func (sr *SubjectRoutes) RemoveTeacher(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "Failed to get validated claims in RemoveTeacher")
+	}
+
+	subjectID, err := parseUUIDParam(c, "subjectId")
+	if err != nil {
+		return err
+	}
+	teacherID, err := parseUUIDParam(c, "userId")
+	if err != nil {
+		return err
+	}
+
+	if err := sr.subjectService.RemoveTeacher(claims.Sub, subjectID, teacherID); err != nil {
+		msg := fmt.Sprintf("Failed to remove teacher %s from subject %s: %v", teacherID, subjectID, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.SuccessWithMessage(c, "Teacher removed", nil)
+}
+
+// parseUUIDParam reads a UUID route parameter, writing the error response when it is not a UUID
+func parseUUIDParam(c fiber.Ctx, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params(name))
+	if err != nil {
+		msg := fmt.Sprintf("Invalid %s parameter %q in request", name, c.Params(name))
+		return uuid.Nil, lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+	return id, nil
+}
@@ -0,0 +1,33 @@
+package users
+
+import (
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// UserRoutes handles HTTP routing for user management endpoints.
+// It depends on interfaces rather than concrete implementations, so tests can inject mocks.
+type UserRoutes struct {
+	userService services.UserServiceInterface
+	middleware  *middleware.Middleware
+	logger      *config.Logger
+}
+
+// NewUserRoutesWithDefaults creates a UserRoutes instance with the default service implementations
+func NewUserRoutesWithDefaults() *UserRoutes {
+	return &UserRoutes{
+		userService: services.NewUserService(),
+		middleware:  middleware.NewMiddleware(),
+		logger:      config.SetupLogger(),
+	}
+}
+
+// RegisterRoutes registers the user management routes, all of them are admin only
+func (ur *UserRoutes) RegisterRoutes(app *fiber.App) {
+	users := app.Group("/users", ur.middleware.AdminMiddleware())
+
+	users.Get("/search", ur.middleware.ReadAuditMiddleware("users"), ur.SearchUsers)
+	users.Put("/:userId/role", ur.UpdateUserRole)
+}
@@ -0,0 +1,44 @@
+package users
+
+import (
+	"fmt"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// UpdateUserRole promotes or demotes a user
+// PUT /users/:userId/role (admin only)
+// Body: {"role": "teacher"}
+func (ur *UserRoutes) UpdateUserRole(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "Failed to get validated claims in UpdateUserRole")
+	}
+
+	targetID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		msg := fmt.Sprintf("Invalid userId parameter %q in request", c.Params("userId"))
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+
+	var req types.UpdateUserRoleRequest
+	if err := c.Bind().Body(&req); err != nil {
+		msg := fmt.Sprintf("Failed to parse update role request body: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+	if req.Role == "" {
+		return lib.HandleServiceError(c, lib.ErrMissingField, "Missing role field in update role request")
+	}
+
+	user, err := ur.userService.UpdateUserRole(c.Context(), claims.Sub, targetID, req.Role)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to update role of user %s to %q: %v", targetID, req.Role, err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	return response.SuccessWithMessage(c, "User role updated", user)
+}
@@ -0,0 +1,32 @@
+package users
+
+import (
+	"fmt"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/gofiber/fiber/v3"
+)
+
+// SearchUsers finds users whose username or email contains the search text
+// GET /users/search?q=jan&page=1&limit=10 (admin only)
+func (ur *UserRoutes) SearchUsers(c fiber.Ctx) error {
+	page, limit, err := response.ParsePaginationParams(c)
+	if err != nil {
+		msg := fmt.Sprintf("Invalid pagination in user search: %v", err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	users, total, err := ur.userService.SearchUsers(c.Context(), c.Query("q"), page, limit)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to search users for %q: %v", c.Query("q"), err)
+		return lib.HandleServiceError(c, err, msg)
+	}
+
+	items := make([]any, len(users))
+	for i, user := range users {
+		items[i] = user
+	}
+
+	return response.Paginated(c, items, page, limit, total)
+}
@@ -0,0 +1,36 @@
+package webhooks
+
+import (
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// WebhookRoutes handles HTTP routing for the webhook subscription endpoints.
+// It depends on interfaces rather than concrete implementations, so tests can inject mocks.
+type WebhookRoutes struct {
+	webhookService services.WebhookServiceInterface
+	middleware     *middleware.Middleware
+	logger         *config.Logger
+}
+
+// NewWebhookRoutesWithDefaults creates a WebhookRoutes instance with the default service implementations
+func NewWebhookRoutesWithDefaults() *WebhookRoutes {
+	return &WebhookRoutes{
+		webhookService: services.NewWebhookService(),
+		middleware:     middleware.NewMiddleware(),
+		logger:         config.SetupLogger(),
+	}
+}
+
+// RegisterRoutes registers the webhook subscription routes, all of them are admin only
+func (wr *WebhookRoutes) RegisterRoutes(app *fiber.App) {
+	webhooks := app.Group("/webhooks", wr.middleware.AdminMiddleware())
+
+	webhooks.Get("/", wr.ListWebhooks)
+	webhooks.Post("/", wr.CreateWebhook)
+	webhooks.Get("/:webhookId", wr.GetWebhook)
+	webhooks.Put("/:webhookId", wr.UpdateWebhook)
+	webhooks.Delete("/:webhookId", wr.DeleteWebhook)
+}
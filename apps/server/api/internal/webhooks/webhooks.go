@@ -0,0 +1,119 @@
+package webhooks
+
+import (
+	"fmt"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// ListWebhooks returns every webhook subscription, without their secrets
+// GET /webhooks (admin only)
+func (wr *WebhookRoutes) ListWebhooks(c fiber.Ctx) error {
+	webhooks, err := wr.webhookService.ListWebhooks()
+	if err != nil {
+		return lib.HandleServiceError(c, err, fmt.Sprintf("Failed to list webhooks: %v", err))
+	}
+
+	return response.Success(c, webhooks)
+}
+
+// CreateWebhook subscribes a URL to event types. The secret is only included in this response.
+// POST /webhooks (admin only)
+// Body: {"url": "https://example.com/hook", "event_types": ["submission.created"], "secret": "optional"}
+func (wr *WebhookRoutes) CreateWebhook(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "Failed to get validated claims in CreateWebhook")
+	}
+
+	var req types.CreateWebhookRequest
+	if err := c.Bind().Body(&req); err != nil {
+		msg := fmt.Sprintf("Failed to parse create webhook request body: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+
+	webhook, err := wr.webhookService.CreateWebhook(claims.Sub, &req)
+	if err != nil {
+		return lib.HandleServiceError(c, err, fmt.Sprintf("Failed to create webhook for %q: %v", req.URL, err))
+	}
+
+	return response.CreatedWithMessage(c, "Webhook created, store the secret now as it is not shown again", webhook)
+}
+
+// GetWebhook returns a single webhook subscription
+// GET /webhooks/:webhookId (admin only)
+func (wr *WebhookRoutes) GetWebhook(c fiber.Ctx) error {
+	webhookID, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := wr.webhookService.GetWebhook(webhookID)
+	if err != nil {
+		return lib.HandleServiceError(c, err, fmt.Sprintf("Failed to get webhook %s: %v", webhookID, err))
+	}
+
+	return response.Success(c, webhook)
+}
+
+// UpdateWebhook changes the URL, event types, secret or active flag of a webhook
+// PUT /webhooks/:webhookId (admin only)
+// Body: any of {"url": "...", "event_types": [...], "secret": "...", "active": false}
+func (wr *WebhookRoutes) UpdateWebhook(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "Failed to get validated claims in UpdateWebhook")
+	}
+
+	webhookID, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	var req types.UpdateWebhookRequest
+	if err := c.Bind().Body(&req); err != nil {
+		msg := fmt.Sprintf("Failed to parse update webhook request body: %v", err)
+		return lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+
+	webhook, err := wr.webhookService.UpdateWebhook(claims.Sub, webhookID, &req)
+	if err != nil {
+		return lib.HandleServiceError(c, err, fmt.Sprintf("Failed to update webhook %s: %v", webhookID, err))
+	}
+
+	return response.SuccessWithMessage(c, "Webhook updated", webhook)
+}
+
+// DeleteWebhook removes a webhook subscription
+// DELETE /webhooks/:webhookId (admin only)
+func (wr *WebhookRoutes) DeleteWebhook(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "Failed to get validated claims in DeleteWebhook")
+	}
+
+	webhookID, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := wr.webhookService.DeleteWebhook(claims.Sub, webhookID); err != nil {
+		return lib.HandleServiceError(c, err, fmt.Sprintf("Failed to delete webhook %s: %v", webhookID, err))
+	}
+
+	return response.SuccessWithMessage(c, "Webhook deleted", nil)
+}
+
+// parseWebhookID reads the webhookId route parameter, writing the error response when it is not a UUID
+func parseWebhookID(c fiber.Ctx) (uuid.UUID, error) {
+	webhookID, err := uuid.Parse(c.Params("webhookId"))
+	if err != nil {
+		msg := fmt.Sprintf("Invalid webhookId parameter %q in request", c.Params("webhookId"))
+		return uuid.Nil, lib.HandleServiceError(c, lib.ErrInvalidRequest, msg)
+	}
+	return webhookID, nil
+}
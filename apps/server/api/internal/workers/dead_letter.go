@@ -0,0 +1,93 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/gofiber/fiber/v3"
+)
+
+// deadLetterRetryTimeout bounds a manual retry run so a database that is still down cannot hold the request
+const deadLetterRetryTimeout = 30 * time.Second
+
+// GetAuditDeadLetters returns the statistics and entries of the audit log dead letter queue
+// GET /workers/audit/dead-letter
+func (wr *WorkerRoutes) GetAuditDeadLetters(c fiber.Ctx) error {
+	if wr.manager == nil {
+		msg := "Worker manager not available for dead letter queue retrieval"
+		return lib.HandleServiceError(c, lib.ErrWorkerUnavailable, msg)
+	}
+
+	stats := wr.manager.AuditDeadLetterStats()
+	if stats == nil {
+		msg := "Audit dead letter queue not available"
+		return lib.HandleServiceError(c, lib.ErrWorkerUnavailable, msg)
+	}
+
+	return response.SuccessWithMessage(c, "Audit dead letter queue retrieved", map[string]any{
+		"stats":   stats,
+		"entries": wr.manager.AuditDeadLetterEntries(),
+	})
+}
+
+// RetryAuditDeadLetters re-attempts every audit log in the dead letter queue once
+// POST /workers/audit/dead-letter/retry
+func (wr *WorkerRoutes) RetryAuditDeadLetters(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "failed to get user claims")
+	}
+	if wr.manager == nil || wr.manager.AuditDeadLetterStats() == nil {
+		msg := "Audit dead letter queue not available for retry"
+		return lib.HandleServiceError(c, lib.ErrWorkerUnavailable, msg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterRetryTimeout)
+	defer cancel()
+
+	// An error means the run timed out, entries that were not attempted stay queued
+	recovered, err := wr.manager.RetryAuditDeadLetters(ctx)
+	remaining := len(wr.manager.AuditDeadLetterEntries())
+
+	wr.logger.WithRequest(c).AuditInfo("Audit dead letter retry triggered",
+		"actor_id", claims.Sub.String(),
+		"recovered", recovered,
+		"remaining", remaining,
+		"completed", err == nil)
+
+	return response.SuccessWithMessage(c, "Audit dead letter retry completed", map[string]any{
+		"recovered": recovered,
+		"remaining": remaining,
+		"completed": err == nil,
+	})
+}
+
+// ClearAuditDeadLetters discards every audit log in the dead letter queue
+// DELETE /workers/audit/dead-letter
+func (wr *WorkerRoutes) ClearAuditDeadLetters(c fiber.Ctx) error {
+	claims, err := lib.GetValidatedClaims(c)
+	if err != nil {
+		return lib.HandleServiceError(c, err, "failed to get user claims")
+	}
+	if wr.manager == nil {
+		msg := "Worker manager not available for clearing the dead letter queue"
+		return lib.HandleServiceError(c, lib.ErrWorkerUnavailable, msg)
+	}
+
+	cleared, err := wr.manager.ClearAuditDeadLetters()
+	if err != nil {
+		msg := fmt.Sprintf("Failed to clear audit dead letter queue: %v", err)
+		return lib.HandleServiceError(c, lib.ErrWorkerUnavailable, msg)
+	}
+
+	wr.logger.WithRequest(c).AuditWarn("Audit dead letter queue cleared",
+		"actor_id", claims.Sub.String(),
+		"cleared", cleared)
+
+	return response.SuccessWithMessage(c, "Audit dead letter queue cleared", map[string]any{
+		"cleared": cleared,
+	})
+}
@@ -2,8 +2,14 @@ package workers
 
 import (
 	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/workers"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // WorkerRoutes defines routes related to worker management and monitoring.
@@ -12,21 +18,49 @@ import (
 type WorkerRoutes struct {
 	manager    workers.WorkerManagerInterface
 	middleware *middleware.Middleware
+	registry   *prometheus.Registry
+	logger     *config.Logger
 }
 
 // NewWorkerRoutes creates a new WorkerRoutes instance with dependency injection.
 // In production, this will use the real services, but in tests,
 // it can use mock implementations for better unit testing.
 func NewWorkerRoutesWithDefaults() *WorkerRoutes {
+	manager := workers.GetGlobalManager()
+
+	// Prometheus registry with the application metrics plus the standard Go runtime metrics
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		workers.NewMetricsCollector(manager),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
 	return &WorkerRoutes{
-		manager:    workers.GetGlobalManager(),
+		manager:    manager,
 		middleware: middleware.NewMiddleware(),
+		registry:   registry,
+		logger:     config.SetupLogger(),
 	}
 }
 
 // This method organizes routes logically and follows RESTful conventions.
 // It groups related functionality and applies appropriate middleware.
 func (wr *WorkerRoutes) RegisterRoutes(app *fiber.App) {
+	// Prometheus scrape endpoint, admin only since it exposes queue, pool and per-route traffic data.
+	// Scrapers authenticate with an admin API key as their bearer token.
+	if wr.registry != nil {
+		app.Get("/metrics", wr.middleware.AdminMiddleware(), adaptor.HTTPHandler(promhttp.HandlerFor(wr.registry, promhttp.HandlerOpts{})))
+	}
+
+	// Administrative actions gated by a permission instead of the admin role. Registered before the admin
+	// group, its middleware applies to every route under /workers that is matched after it.
+	app.Post("/workers/cleanup/trigger",
+		wr.middleware.AuthMiddleware(),
+		wr.middleware.RequirePermission(lib.PermCleanupTrigger),
+		wr.TriggerCleanup,
+	)
+
 	// Worker health monitoring routes
 	workerGroup := app.Group("/workers", wr.middleware.AdminMiddleware())
 
@@ -47,6 +81,8 @@ func (wr *WorkerRoutes) RegisterRoutes(app *fiber.App) {
 	workerGroup.Get("/health-monitor/services", wr.GetMonitoredServices)
 	workerGroup.Get("/health-monitor/services/:service", wr.GetServiceStatistics)
 
-	// Administrative actions
-	workerGroup.Post("/cleanup/trigger", wr.TriggerCleanup)
+	// Audit log dead letter queue
+	workerGroup.Get("/audit/dead-letter", wr.middleware.ReadAuditMiddleware("audit_logs"), wr.GetAuditDeadLetters)
+	workerGroup.Post("/audit/dead-letter/retry", wr.RetryAuditDeadLetters)
+	workerGroup.Delete("/audit/dead-letter", wr.ClearAuditDeadLetters)
 }
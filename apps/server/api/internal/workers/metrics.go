@@ -55,6 +55,7 @@ func (wr *WorkerRoutes) GetAuditWorkerMetrics(c fiber.Ctx) error {
 	metrics := map[string]any{
 		"processed_total": healthStatus["total_processed"],
 		"dropped_total":   healthStatus["total_dropped"],
+		"deduped_total":   healthStatus["total_deduped"],
 		"failure_count":   healthStatus["failure_count"],
 		"queue_size":      healthStatus["queue_size"],
 		"queue_capacity":  healthStatus["queue_capacity"],
@@ -75,6 +76,7 @@ func (wr *WorkerRoutes) GetHealthWorkerMetrics(c fiber.Ctx) error {
 		"queue_capacity":  healthStatus["queue_capacity"],
 		"last_flush_time": healthStatus["last_flush_time"],
 		"configuration":   healthStatus["configuration"],
+		"routes":          workers.GetRouteMetrics(),
 	}
 
 	return response.SuccessWithMessage(c, "Health worker metrics retrieved", metrics)
@@ -6,6 +6,8 @@ import (
 	"github.com/MonkyMars/PWS/api/internal/deadlines"
 	"github.com/MonkyMars/PWS/api/internal/health"
 	"github.com/MonkyMars/PWS/api/internal/subjects"
+	"github.com/MonkyMars/PWS/api/internal/users"
+	"github.com/MonkyMars/PWS/api/internal/webhooks"
 	"github.com/MonkyMars/PWS/api/internal/workers"
 )
 
@@ -18,6 +20,8 @@ type router struct {
 	WorkerRoutes   *workers.WorkerRoutes
 	SubjectRoutes  *subjects.SubjectRoutes
 	DeadlineRoutes *deadlines.DeadlineRoutes
+	UserRoutes     *users.UserRoutes
+	WebhookRoutes  *webhooks.WebhookRoutes
 }
 
 // NewRouter creates a new Router instance with default dependencies
@@ -31,6 +35,8 @@ func newRouter() *router {
 		WorkerRoutes:   workers.NewWorkerRoutesWithDefaults(),
 		SubjectRoutes:  subjects.NewSubjectRoutesWithDefaults(),
 		DeadlineRoutes: deadlines.NewDeadlineRoutesWithDefaults(),
+		UserRoutes:     users.NewUserRoutesWithDefaults(),
+		WebhookRoutes:  webhooks.NewWebhookRoutesWithDefaults(),
 	}
 }
 
@@ -43,6 +49,8 @@ func NewRouterWithDependencies(
 	workerRoutes *workers.WorkerRoutes,
 	subjectRoutes *subjects.SubjectRoutes,
 	deadlineRoutes *deadlines.DeadlineRoutes,
+	userRoutes *users.UserRoutes,
+	webhookRoutes *webhooks.WebhookRoutes,
 ) *router {
 	return &router{
 		HealthRoutes:   healthRoutes,
@@ -51,5 +59,7 @@ func NewRouterWithDependencies(
 		WorkerRoutes:   workerRoutes,
 		SubjectRoutes:  subjectRoutes,
 		DeadlineRoutes: deadlineRoutes,
+		UserRoutes:     userRoutes,
+		WebhookRoutes:  webhookRoutes,
 	}
 }
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+// APIKeyAuthenticator resolves the user that owns an API key.
+// It is satisfied by services.AuthService and can be replaced in tests.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*types.User, error)
+}
+
+// APIKeyMiddleware authenticates service-to-service requests using an
+// `Authorization: Bearer <apikey>` header. On success the key owner's claims are
+// stored in the context the same way AuthMiddleware does, so handlers work unchanged.
+// AuthMiddleware and AdminMiddleware accept API keys as well, this one accepts nothing else.
+func (mw *Middleware) APIKeyMiddleware() fiber.Handler {
+	return NewAPIKeyAuth(mw.authService)
+}
+
+// NewAPIKeyAuth creates an API key authentication handler backed by the given authenticator
+func NewAPIKeyAuth(authenticator APIKeyAuthenticator) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		key, ok := lib.BearerToken(c.Get(fiber.HeaderAuthorization))
+		if !ok {
+			msg := "No bearer API key found in Authorization header during API key authentication"
+			return lib.HandleServiceError(c, lib.ErrInvalidAPIKey, msg)
+		}
+
+		claims, err := apiKeyClaims(c.Context(), authenticator, key)
+		if err != nil {
+			msg := fmt.Sprintf("API key authentication failed - client_ip: %s, user_agent: %s, error: %v", c.IP(), c.Get("User-Agent"), err)
+			return lib.HandleServiceError(c, err, msg)
+		}
+
+		// Store user claims in context locals for downstream handlers
+		c.Locals("claims", claims)
+
+		return c.Next()
+	}
+}
+
+// bearerAPIKey returns the bearer token of the request when it is an API key rather than a JWT
+func bearerAPIKey(c fiber.Ctx) (string, bool) {
+	key, ok := lib.BearerToken(c.Get(fiber.HeaderAuthorization))
+	return key, ok && strings.HasPrefix(key, services.APIKeyPrefix)
+}
+
+// apiKeyClaims authenticates an API key and returns claims for its owner. Keys have no session
+// or token ID, so the blacklist and session checks of JWTs do not apply to them.
+func apiKeyClaims(ctx context.Context, authenticator APIKeyAuthenticator, key string) (*types.AuthClaims, error) {
+	user, err := authenticator.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.AuthClaims{
+		Sub:   user.Id,
+		Email: user.Email,
+		Role:  user.Role,
+	}, nil
+}
@@ -1,20 +1,29 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 
 	"github.com/MonkyMars/PWS/lib"
-	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
 )
 
 func (mw *Middleware) AuthMiddleware() fiber.Handler {
+	apiKeyAuth := NewAPIKeyAuth(mw.authService)
+
 	return func(c fiber.Ctx) error {
-		token := c.Cookies(lib.AccessTokenCookieName)
+		// Service-to-service callers send an API key as their bearer token instead of a JWT
+		if _, ok := bearerAPIKey(c); ok {
+			return apiKeyAuth(c)
+		}
+
+		token := lib.GetAccessToken(c)
 
 		if token == "" {
-			msg := "No access token found in cookies during authentication middleware"
+			msg := "No access token found in Authorization header or cookies during authentication middleware"
 			return lib.HandleServiceError(c, lib.ErrInvalidToken, msg)
 		}
 
@@ -24,20 +33,13 @@ func (mw *Middleware) AuthMiddleware() fiber.Handler {
 			return lib.HandleServiceError(c, err, msg)
 		}
 
-		// Initialize Cache service
-		cacheService := services.NewCacheService()
+		if err := mw.checkBlacklist(c, claims, "auth middleware"); err != nil {
+			msg := fmt.Sprintf("Blacklisted token access attempt in auth middleware - %v, client_ip: %s, user_agent: %s", err, c.IP(), c.Get("User-Agent"))
+			return lib.HandleServiceError(c, err, msg)
+		}
 
-		// Check if token is blacklisted with graceful Redis failure handling
-		blacklisted, err := cacheService.IsTokenBlacklisted(claims.Jti)
-		if err != nil {
-			lib.HandleServiceWarning(c, "Redis blacklist check failed in auth middleware", "error", err, "jti", claims.Jti.String())
-			// Do not return faulty Redis errors to the client, let the request through if Redis is down
-		} else if blacklisted {
-			// SECURITY: This could indicate a token reuse attack
-			msg := fmt.Sprintf("Blacklisted token access attempt - jti: %s, user_id: %s, user_email: %s, client_ip: %s, user_agent: %s",
-				claims.Jti.String(), claims.Sub, claims.Email, c.IP(), c.Get("User-Agent"))
-			// TODO: Invalidate all tokens for this user as a precaution
-			return lib.HandleServiceError(c, lib.ErrTokenRevoked, msg)
+		if err := mw.touchSession(c, claims); err != nil {
+			return lib.HandleServiceError(c, err, "Session expired during authentication middleware")
 		}
 
 		// Store user claims in context locals for downstream handlers
@@ -49,30 +51,39 @@ func (mw *Middleware) AuthMiddleware() fiber.Handler {
 
 func (mw *Middleware) AdminMiddleware() fiber.Handler {
 	return func(c fiber.Ctx) error {
-		token := c.Cookies(lib.AccessTokenCookieName)
-
-		if token == "" {
-			msg := "No access token found in cookies during admin middleware authentication"
-			return lib.HandleServiceError(c, lib.ErrInvalidToken, msg)
-		}
-
-		claims, err := mw.authService.ParseToken(token, true)
-		if err != nil {
-			msg := fmt.Sprintf("Failed to parse access token in admin middleware: %v", err)
-			return lib.HandleServiceError(c, err, msg)
-		}
-
-		// Check if token is blacklisted with graceful Redis failure handling
-		blacklisted, err := mw.cacheService.IsTokenBlacklisted(claims.Jti)
-		if err != nil {
-			lib.HandleServiceWarning(c, "Redis blacklist check failed in admin middleware", "error", err, "jti", claims.Jti.String())
-			// Do not return faulty Redis errors to the client, let the request through if Redis is down
-		} else if blacklisted {
-			// SECURITY: This could indicate a token reuse attack
-			msg := fmt.Sprintf("Blacklisted token access attempt in admin middleware - jti: %s, user_id: %s, user_email: %s, client_ip: %s, user_agent: %s",
-				claims.Jti.String(), claims.Sub, claims.Email, c.IP(), c.Get("User-Agent"))
-			// TODO: Invalidate all tokens for this user as a precaution
-			return lib.HandleServiceError(c, lib.ErrTokenRevoked, msg)
+		var claims *types.AuthClaims
+
+		// Service-to-service callers send an API key as their bearer token instead of a JWT
+		if key, ok := bearerAPIKey(c); ok {
+			var err error
+			claims, err = apiKeyClaims(c.Context(), mw.authService, key)
+			if err != nil {
+				msg := fmt.Sprintf("API key authentication failed in admin middleware - client_ip: %s, user_agent: %s, error: %v", c.IP(), c.Get("User-Agent"), err)
+				return lib.HandleServiceError(c, err, msg)
+			}
+		} else {
+			token := lib.GetAccessToken(c)
+
+			if token == "" {
+				msg := "No access token found in Authorization header or cookies during admin middleware authentication"
+				return lib.HandleServiceError(c, lib.ErrInvalidToken, msg)
+			}
+
+			var err error
+			claims, err = mw.authService.ParseToken(token, true)
+			if err != nil {
+				msg := fmt.Sprintf("Failed to parse access token in admin middleware: %v", err)
+				return lib.HandleServiceError(c, err, msg)
+			}
+
+			if err := mw.checkBlacklist(c, claims, "admin middleware"); err != nil {
+				msg := fmt.Sprintf("Blacklisted token access attempt in admin middleware - %v, client_ip: %s, user_agent: %s", err, c.IP(), c.Get("User-Agent"))
+				return lib.HandleServiceError(c, err, msg)
+			}
+
+			if err := mw.touchSession(c, claims); err != nil {
+				return lib.HandleServiceError(c, err, "Session expired during admin middleware")
+			}
 		}
 
 		if claims.Role != lib.RoleAdmin {
@@ -88,6 +99,43 @@ func (mw *Middleware) AdminMiddleware() fiber.Handler {
 	}
 }
 
+// checkBlacklist rejects access tokens that were blacklisted, which Logout does, so they stop working
+// right away instead of when they expire. It returns ErrTokenRevoked for the caller to respond with.
+// When Redis is down the request is let through, like touchSession does.
+func (mw *Middleware) checkBlacklist(c fiber.Ctx, claims *types.AuthClaims, where string) error {
+	revoked, err := mw.authService.IsTokenRevoked(claims.Jti)
+	if err != nil {
+		// Do not return faulty Redis errors to the client
+		lib.HandleServiceWarning(c, "Redis blacklist check failed in "+where, "error", err, "jti", claims.Jti.String())
+		return nil
+	}
+	if !revoked {
+		return nil
+	}
+
+	// SECURITY: This could indicate a token reuse attack
+	// TODO: Invalidate all tokens for this user as a precaution
+	return fmt.Errorf("%w - jti: %s, user_id: %s, user_email: %s", lib.ErrTokenRevoked, claims.Jti.String(), claims.Sub, claims.Email)
+}
+
+// touchSession slides the session of the token forward on every authenticated request.
+// It only fails when the session is over; when Redis is down the request is let through,
+// like the blacklist check does.
+func (mw *Middleware) touchSession(c fiber.Ctx, claims *types.AuthClaims) error {
+	if claims.Sid == uuid.Nil {
+		return nil
+	}
+
+	err := mw.authService.TouchSession(claims.Sid)
+	if errors.Is(err, lib.ErrSessionExpired) {
+		return err
+	}
+	if err != nil {
+		lib.HandleServiceWarning(c, "Redis session check failed", "error", err, "session_id", claims.Sid.String())
+	}
+	return nil
+}
+
 func (mw *Middleware) RoleMiddleware(allowedRoles ...string) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		claims, err := lib.GetValidatedClaims(c)
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/gofiber/fiber/v3"
+)
+
+// BodyLimitMiddleware rejects requests with a body over limit bytes with 413 Payload Too Large,
+// so a route group can accept less than MAX_REQUEST_BODY_SIZE. A limit of 0 uses MAX_REQUEST_BODY_SIZE.
+// Bodies over MAX_REQUEST_BODY_SIZE are already rejected by the server before they are read,
+// so a higher limit has no effect.
+func (mw *Middleware) BodyLimitMiddleware(limit int) fiber.Handler {
+	if limit <= 0 {
+		limit = config.Get().Server.MaxRequestBodySize
+	}
+	return NewBodyLimit(limit)
+}
+
+// NewBodyLimit creates a handler that rejects request bodies over limit bytes
+func NewBodyLimit(limit int) fiber.Handler {
+	message := fmt.Sprintf("Request body exceeds the limit of %d bytes", limit)
+
+	return func(c fiber.Ctx) error {
+		// The declared length rejects the request before the body is touched, the
+		// actual length covers chunked requests that do not declare one
+		if c.Request().Header.ContentLength() > limit || len(c.Request().Body()) > limit {
+			return response.PayloadTooLarge(c, message)
+		}
+		return c.Next()
+	}
+}
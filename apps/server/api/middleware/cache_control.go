@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// cacheControlNoStore forbids clients and shared caches from storing a response
+const cacheControlNoStore = "no-store"
+
+// CacheControlOptions describes how long clients and caches may reuse a response
+type CacheControlOptions struct {
+	MaxAge  time.Duration // How long the response stays fresh, zero or less means no-store
+	Private bool          // Only the client may cache the response, shared caches like CDNs may not
+}
+
+// CacheControlMiddleware lets clients cache successful responses of the route for the configured time
+func (mw *Middleware) CacheControlMiddleware(opts CacheControlOptions) fiber.Handler {
+	return NewCacheControl(opts)
+}
+
+// NoStoreMiddleware keeps responses of sensitive routes such as auth and submissions out of every cache
+func (mw *Middleware) NoStoreMiddleware() fiber.Handler {
+	return NewNoStore()
+}
+
+// NewCacheControl creates a handler that sets Cache-Control with a max-age on successful GET and HEAD
+// responses. Other methods and error responses are sent with no-store, so a failure is never cached.
+func NewCacheControl(opts CacheControlOptions) fiber.Handler {
+	value := cacheControlValue(opts)
+
+	return func(c fiber.Ctx) error {
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		cacheable := (c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead) &&
+			err == nil && status >= fiber.StatusOK && status < fiber.StatusMultipleChoices
+		if cacheable {
+			c.Set(fiber.HeaderCacheControl, value)
+		} else {
+			c.Set(fiber.HeaderCacheControl, cacheControlNoStore)
+		}
+
+		return err
+	}
+}
+
+// NewNoStore creates a handler that sends every response with Cache-Control: no-store.
+// The header is set after the handler ran so it cannot be overridden by accident.
+func NewNoStore() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		err := c.Next()
+		c.Set(fiber.HeaderCacheControl, cacheControlNoStore)
+		return err
+	}
+}
+
+// cacheControlValue builds the Cache-Control header value for the options
+func cacheControlValue(opts CacheControlOptions) string {
+	seconds := int(opts.MaxAge / time.Second)
+	if seconds <= 0 {
+		return cacheControlNoStore
+	}
+
+	scope := "public"
+	if opts.Private {
+		scope = "private"
+	}
+	return fmt.Sprintf("%s, max-age=%d", scope, seconds)
+}
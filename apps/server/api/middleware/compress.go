@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// incompressibleTypes lists content types that are already compressed, so compressing them again only costs CPU
+var incompressibleTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/pdf",
+	"application/octet-stream",
+}
+
+// CompressionConfig configures the response compression handler
+type CompressionConfig struct {
+	// Level is the gzip/deflate level, from 1 (fastest) to 9 (smallest)
+	Level int
+	// MinSize is the smallest body in bytes that is compressed
+	MinSize int
+}
+
+// CompressionMiddleware compresses responses using the server compression settings.
+// It is a no-op when compression is disabled.
+func (mw *Middleware) CompressionMiddleware() fiber.Handler {
+	cfg := config.Get()
+	if !cfg.Server.CompressionEnabled {
+		return func(c fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return NewCompression(CompressionConfig{
+		Level:   cfg.Server.CompressionLevel,
+		MinSize: cfg.Server.CompressionMinSize,
+	})
+}
+
+// NewCompression creates a handler that gzip or deflate compresses the response body,
+// picking the encoding the client prefers in Accept-Encoding. Bodies smaller than MinSize,
+// already compressed content types and responses without a body are sent as is.
+//
+// An ETag set by the handler describes the uncompressed body, so it is kept and marked
+// weak when the body is compressed. Conditional requests compare tags weakly and keep matching.
+func NewCompression(cfg CompressionConfig) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		if !compressible(c, cfg.MinSize) {
+			return nil
+		}
+
+		// The response depends on Accept-Encoding even when this client gets it uncompressed
+		appendVary(c, fiber.HeaderAcceptEncoding)
+
+		encoding := negotiateEncoding(c.Get(fiber.HeaderAcceptEncoding))
+		if encoding == "" {
+			return nil
+		}
+
+		compressed, err := compressBody(c.Response().Body(), encoding, cfg.Level)
+		if err != nil {
+			// Sending the original body is always correct
+			return nil
+		}
+
+		c.Response().SetBodyRaw(compressed)
+		c.Set(fiber.HeaderContentEncoding, encoding)
+		c.Set(fiber.HeaderContentLength, strconv.Itoa(len(compressed)))
+		if etag := c.GetRespHeader(fiber.HeaderETag); etag != "" && !strings.HasPrefix(etag, "W/") {
+			c.Set(fiber.HeaderETag, "W/"+etag)
+		}
+
+		return nil
+	}
+}
+
+// compressible reports whether the response is worth compressing
+func compressible(c fiber.Ctx, minSize int) bool {
+	if c.Method() == fiber.MethodHead || c.Get(fiber.HeaderRange) != "" {
+		return false
+	}
+
+	status := c.Response().StatusCode()
+	if status < fiber.StatusOK || status == fiber.StatusNoContent || status == fiber.StatusNotModified ||
+		status == fiber.StatusPartialContent {
+		return false
+	}
+
+	body := c.Response().Body()
+	if len(body) == 0 || len(body) < minSize {
+		return false
+	}
+
+	if c.GetRespHeader(fiber.HeaderContentEncoding) != "" {
+		return false
+	}
+
+	contentType := strings.ToLower(c.GetRespHeader(fiber.HeaderContentType))
+	if strings.HasPrefix(contentType, "image/svg+xml") {
+		return true
+	}
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// negotiateEncoding returns the supported encoding with the highest quality in the Accept-Encoding
+// header, preferring gzip on a tie. It returns an empty string when no supported encoding is acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	wildcard := -1.0
+	for part := range strings.SplitSeq(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(key) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				q = 0
+			}
+			quality = q
+		}
+
+		if name == "*" {
+			wildcard = quality
+			continue
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range []string{encodingGzip, encodingDeflate} {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = wildcard
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// compressBody compresses the body with the given encoding and level
+func compressBody(body []byte, encoding string, level int) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var w io.WriteCloser
+	var err error
+	switch encoding {
+	case encodingGzip:
+		w, err = gzip.NewWriterLevel(&buf, level)
+	default:
+		w, err = flate.NewWriter(&buf, level)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// appendVary adds a header to the Vary response header unless it is already listed
+func appendVary(c fiber.Ctx, header string) {
+	vary := c.GetRespHeader(fiber.HeaderVary)
+	if vary == "" {
+		c.Set(fiber.HeaderVary, header)
+		return
+	}
+
+	for part := range strings.SplitSeq(vary, ",") {
+		part = strings.TrimSpace(part)
+		if part == "*" || strings.EqualFold(part, header) {
+			return
+		}
+	}
+	c.Set(fiber.HeaderVary, vary+", "+header)
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"mime"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/gofiber/fiber/v3"
+)
+
+// RequireJSONMiddleware answers requests with a non-JSON body with 415 Unsupported Media Type,
+// instead of letting the body parser fail on them with a confusing error
+func (mw *Middleware) RequireJSONMiddleware() fiber.Handler {
+	return NewRequireJSON()
+}
+
+// NewRequireJSON creates a handler that requires Content-Type: application/json on every request with
+// a body. Parameters such as charset=utf-8 are allowed. Requests without a body pass, so routes where
+// the body is optional, like refresh and logout, keep working without a Content-Type.
+func NewRequireJSON() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if c.Request().Header.ContentLength() == 0 || len(c.Request().Body()) == 0 {
+			return c.Next()
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+		if err != nil || mediaType != fiber.MIMEApplicationJSON {
+			return response.UnsupportedMediaType(c, "Content-Type must be application/json")
+		}
+
+		return c.Next()
+	}
+}
@@ -8,16 +8,43 @@ package middleware
 
 import (
 	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
 )
 
 func (mw *Middleware) SetupCORS() fiber.Handler {
-	cfg := config.Get()
-	return cors.New(cors.Config{
-		AllowOrigins:     cfg.Cors.AllowOrigins,
-		AllowMethods:     cfg.Cors.AllowMethods,
-		AllowHeaders:     cfg.Cors.AllowHeaders,
-		AllowCredentials: cfg.Cors.AllowCredentials,
+	return NewCORS(config.Get().Cors, mw.logger)
+}
+
+// NewCORS creates the CORS handler for the configured origins, methods and headers.
+// Origins are validated at startup, see CorsConfig.Validate. Requests whose Origin is not
+// allowed are logged with the allowed origins, so a misconfigured frontend URL is easy to spot.
+func NewCORS(cfg types.CorsConfig, logger *config.Logger) fiber.Handler {
+	handler := cors.New(cors.Config{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		ExposeHeaders:    []string{config.RequestIDHeader, lib.CSRFTokenHeader},
 	})
+
+	return func(c fiber.Ctx) error {
+		err := handler(c)
+
+		// The CORS handler only sets Access-Control-Allow-Origin for allowed origins
+		origin := c.Get(fiber.HeaderOrigin)
+		if origin != "" && c.GetRespHeader(fiber.HeaderAccessControlAllowOrigin) == "" {
+			logger.WithRequest(c).Warn("Rejected CORS request from origin that is not allowed",
+				"origin", origin,
+				"method", c.Method(),
+				"path", c.Path(),
+				"preflight", c.Method() == fiber.MethodOptions,
+				"allowed_origins", cfg.AllowOrigins,
+			)
+		}
+
+		return err
+	}
 }
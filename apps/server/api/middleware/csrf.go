@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// CSRFMiddleware protects cookie authenticated requests with a double submit CSRF token,
+// unless CSRF_ENABLED is false
+func (mw *Middleware) CSRFMiddleware() fiber.Handler {
+	cfg := config.Get().Cookie
+	if !cfg.CSRFEnabled {
+		return func(c fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return NewCSRFProtection(services.NewCookieService(), cfg.CSRFExemptPaths)
+}
+
+// NewCSRFProtection creates a handler that answers unsafe requests carrying the auth cookies with
+// 403 Forbidden unless the X-CSRF-Token header matches the csrf_token cookie. A cross-site form can
+// make the browser send the cookies, but cannot read the token to put in the header.
+// Requests without the auth cookies or with a bearer token, such as API key clients, are never checked.
+// Safe requests of a cookie session get the token in the response header, and a new token when
+// the session has none yet.
+func NewCSRFProtection(cookies services.CookieServiceInterface, exemptPaths []string) fiber.Handler {
+	exempt := make(map[string]bool, len(exemptPaths))
+	for _, path := range exemptPaths {
+		exempt[path] = true
+	}
+
+	return func(c fiber.Ctx) error {
+		if c.Cookies(lib.AccessTokenCookieName) == "" && c.Cookies(lib.RefreshTokenCookieName) == "" {
+			return c.Next()
+		}
+
+		// A bearer token takes precedence over the cookies, and a cross-site form cannot set it
+		if _, ok := lib.BearerToken(c.Get(fiber.HeaderAuthorization)); ok {
+			return c.Next()
+		}
+
+		token := c.Cookies(lib.CSRFTokenCookieName)
+
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace:
+			if token == "" {
+				cookies.SetCSRFCookie(c, rand.Text())
+			} else {
+				c.Set(lib.CSRFTokenHeader, token)
+			}
+			return c.Next()
+		}
+
+		if exempt[c.Path()] {
+			return c.Next()
+		}
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(c.Get(lib.CSRFTokenHeader))) != 1 {
+			return response.Forbidden(c, "Missing or invalid CSRF token")
+		}
+
+		return c.Next()
+	}
+}
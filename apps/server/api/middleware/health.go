@@ -1,68 +1,56 @@
 package middleware
 
 import (
-	"strings"
 	"time"
 
+	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/workers"
 	"github.com/gofiber/fiber/v3"
 )
 
-// CreateHealthMiddleware returns a middleware that tracks route metrics
+// RequestMetricsRecorder receives the request metrics of the health middleware,
+// the worker manager in production
+type RequestMetricsRecorder interface {
+	RecordRouteMetric(method, path string, statusCode int, latency time.Duration)
+	RecordHealthMetric(serviceName string, statusCode int, latency time.Duration)
+}
+
+// CreateHealthMiddleware returns a middleware that feeds every request into the health worker,
+// so the health endpoints and metrics reflect live traffic. See NewHealthMetrics.
 func (mw *Middleware) CreateHealthMiddleware() fiber.Handler {
-	manager := workers.GetGlobalManager()
-	if manager == nil {
-		// Return no-op middleware if health logging is disabled
+	if !config.Get().Health.Enabled {
+		// Return no-op middleware if health monitoring is disabled
 		return func(c fiber.Ctx) error {
 			return c.Next()
 		}
 	}
 
+	return NewHealthMetrics(workers.GetGlobalManager())
+}
+
+// NewHealthMetrics creates a handler that records the status and latency of every request.
+// Requests that reached a route handler are recorded under the route template and the service that
+// route was discovered under. Requests that never reached one, because a group or global middleware
+// rejected them or nothing matched, are only counted for the service of their base path.
+// Routes and services that were not discovered, such as the health routes themselves, are not recorded.
+func NewHealthMetrics(recorder RequestMetricsRecorder) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		start := time.Now()
 
-		// Extract service name from path
-		serviceName := extractBasePath(c.Path())
-		if serviceName == "" {
-			return c.Next()
-		}
-
-		// Record metrics using the worker manager
 		err := c.Next()
 
-		// Record metrics
 		latency := time.Since(start)
 		statusCode := c.Response().StatusCode()
-		manager.RecordHealthMetric(serviceName, statusCode, latency)
-
-		return err
-	}
-}
-
-// extractBasePath extracts the base path from a route path
-func extractBasePath(path string) string {
-	// Remove leading slash and split by slash
-	if len(path) <= 1 {
-		return ""
-	}
-
-	trimmed := path[1:] // Remove leading slash
-	segments := strings.Split(trimmed, "/")
-	if len(segments) == 0 {
-		return ""
-	}
 
-	basePath := segments[0]
-
-	// Skip health and system routes
-	if basePath == "health" || basePath == "metrics" || basePath == "logs" {
-		return ""
-	}
+		// The matched route is only known after routing. Without a matched handler route, c.Route()
+		// is the Use route of the middleware that answered, e.g. /deadlines for a 401 on /deadlines/me
+		if c.Matched() {
+			route := c.Route()
+			recorder.RecordRouteMetric(route.Method, route.Path, statusCode, latency)
+		} else if service := workers.ServiceFromPath(c.Path()); service != "" {
+			recorder.RecordHealthMetric(service, statusCode, latency)
+		}
 
-	// Handle parameterized routes (remove :param)
-	if strings.HasPrefix(basePath, ":") {
-		return ""
+		return err
 	}
-
-	return basePath
 }
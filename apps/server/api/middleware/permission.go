@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/gofiber/fiber/v3"
+)
+
+// RequirePermission only lets the request through when the role of the authenticated user is granted perm.
+// It reads the claims stored by AuthMiddleware, so it must run after it.
+func (mw *Middleware) RequirePermission(perm string) fiber.Handler {
+	return NewRequirePermission(perm)
+}
+
+// NewRequirePermission creates a handler that rejects requests whose claims lack the permission
+func NewRequirePermission(perm string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		claims, err := lib.GetValidatedClaims(c)
+		if err != nil {
+			return lib.HandleServiceError(c, err, "Failed to get validated claims in RequirePermission")
+		}
+
+		if !lib.HasPermission(claims, perm) {
+			msg := fmt.Sprintf("Insufficient permissions. User with role '%s' tried to access a route that requires permission '%s'", claims.Role, perm)
+			return lib.HandleServiceError(c, lib.ErrInsufficientPermissions, msg)
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+// RateLimitExemptKeyHeader is the header trusted internal callers use to present their exemption key
+const RateLimitExemptKeyHeader = "X-Internal-API-Key"
+
+// Headers set on every rate limited response
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRateLimitWarning   = "X-RateLimit-Warning"
+)
+
+// RateLimitCounter is the subset of the cache service used by the rate limiter.
+// It is satisfied by services.CacheService and can be replaced in tests.
+type RateLimitCounter interface {
+	IncrementRateLimit(ip, endpoint string, ttl time.Duration) (int, error)
+}
+
+// RateLimitTTLReader is optionally implemented by a RateLimitCounter to report how long the
+// current window of a client has left. Without it throttled clients are told to retry after a full window.
+type RateLimitTTLReader interface {
+	RateLimitTTL(ip, endpoint string) (time.Duration, error)
+}
+
+// RateLimitOptions configures the rate limiting middleware
+type RateLimitOptions struct {
+	// Max is the number of requests allowed per client and endpoint within Window
+	Max int
+	// Window is the duration of a rate limit window
+	Window time.Duration
+	// ExemptCIDRs lists networks (or single IPs) whose requests are never throttled
+	ExemptCIDRs []string
+	// ExemptKeys lists internal API keys that bypass rate limiting when sent in RateLimitExemptKeyHeader
+	ExemptKeys []string
+	// SoftThreshold is the fraction of Max after which responses carry HeaderRateLimitWarning, 0 disables it
+	SoftThreshold float64
+	// SoftThresholdRoutes overrides SoftThreshold for request paths starting with a key, the longest key wins
+	SoftThresholdRoutes map[string]float64
+	// OnCounterError is called when the counter backend fails. Requests are let through regardless.
+	OnCounterError func(c fiber.Ctx, err error)
+}
+
+// RateLimitMiddleware returns a middleware that limits requests per client IP and endpoint
+// using the settings from the centralized configuration.
+// Requests from exempt CIDRs or carrying an exempt internal API key are never throttled.
+func (mw *Middleware) RateLimitMiddleware() fiber.Handler {
+	cfg := config.Get()
+	if !cfg.RateLimit.Enabled {
+		return func(c fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	handler, err := NewRateLimiter(mw.cacheService, RateLimitOptions{
+		Max:         cfg.RateLimit.Max,
+		Window:      cfg.RateLimit.Window,
+		ExemptCIDRs: cfg.RateLimit.ExemptCIDRs,
+		ExemptKeys:  cfg.RateLimit.ExemptKeys,
+
+		SoftThreshold:       cfg.RateLimit.SoftThreshold,
+		SoftThresholdRoutes: cfg.RateLimit.SoftThresholdRoutes,
+
+		OnCounterError: func(c fiber.Ctx, err error) {
+			mw.logger.Warn("Rate limit counter failed, allowing request",
+				"error", err,
+				"path", c.Path(),
+				"ip", c.IP(),
+			)
+		},
+	})
+	if err != nil {
+		// Configuration is validated on load, so this should never happen
+		log.Fatalf("Invalid rate limit configuration: %v", err)
+	}
+
+	return handler
+}
+
+// NewRateLimiter creates a rate limiting handler backed by the given counter.
+// Returns an error if any of the exempt CIDRs cannot be parsed.
+func NewRateLimiter(counter RateLimitCounter, opts RateLimitOptions) (fiber.Handler, error) {
+	prefixes, err := parseExemptCIDRs(opts.ExemptCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	exemptKeys := make([][]byte, 0, len(opts.ExemptKeys))
+	for _, key := range opts.ExemptKeys {
+		if key != "" {
+			exemptKeys = append(exemptKeys, []byte(key))
+		}
+	}
+
+	return func(c fiber.Ctx) error {
+		if isExemptIP(c.IP(), prefixes) || isExemptKey(c.Get(RateLimitExemptKeyHeader), exemptKeys) {
+			return c.Next()
+		}
+
+		count, err := counter.IncrementRateLimit(c.IP(), c.Path(), opts.Window)
+		if err != nil {
+			if opts.OnCounterError != nil {
+				opts.OnCounterError(c, err)
+			}
+			// Do not block clients when the counter backend is unavailable
+			return c.Next()
+		}
+
+		reset := windowRemaining(counter, c.IP(), c.Path(), count, opts.Window)
+		status := types.NewRateLimitStatus(count, opts.Max, reset)
+		setRateLimitHeaders(c, status)
+
+		// Still allowed, but close enough to the limit that the client should slow down
+		if status.Approaching(opts.softThresholdFor(c.Path())) {
+			c.Set(HeaderRateLimitWarning, fmt.Sprintf("%d of %d requests used, the limit resets in %d seconds",
+				status.Count, status.Limit, status.Reset))
+		}
+
+		if count > opts.Max {
+			return response.TooManyRequestsWithRetryAfter(c, "Too many requests, please try again later", reset)
+		}
+
+		return c.Next()
+	}, nil
+}
+
+// softThresholdFor returns the soft threshold of the longest SoftThresholdRoutes prefix of path,
+// falling back to SoftThreshold. A prefix matches whole path segments, so /auth does not match /authors.
+func (opts RateLimitOptions) softThresholdFor(path string) float64 {
+	threshold, longest := opts.SoftThreshold, -1
+	for prefix, routeThreshold := range opts.SoftThresholdRoutes {
+		trimmed := strings.TrimSuffix(prefix, "/")
+		if path != trimmed && !strings.HasPrefix(path, trimmed+"/") {
+			continue
+		}
+		if len(trimmed) > longest {
+			threshold, longest = routeThreshold, len(trimmed)
+		}
+	}
+	return threshold
+}
+
+// setRateLimitHeaders tells the client its limit, how many requests it has left
+// and how many seconds until its window resets
+func setRateLimitHeaders(c fiber.Ctx, status types.RateLimitStatus) {
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(status.Limit))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(status.Remaining))
+	c.Set(HeaderRateLimitReset, strconv.Itoa(status.Reset))
+}
+
+// windowRemaining returns the time until the client's rate limit window resets,
+// falling back to the full window when the counter cannot tell.
+// The first request of a window has just started it, so the counter is not asked.
+func windowRemaining(counter RateLimitCounter, ip, endpoint string, count int, window time.Duration) time.Duration {
+	if count <= 1 {
+		return window
+	}
+
+	reader, ok := counter.(RateLimitTTLReader)
+	if !ok {
+		return window
+	}
+
+	ttl, err := reader.RateLimitTTL(ip, endpoint)
+	if err != nil || ttl <= 0 {
+		return window
+	}
+	return ttl
+}
+
+// parseExemptCIDRs parses CIDR ranges, treating plain IP addresses as single-host ranges
+func parseExemptCIDRs(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if cidr == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(cidr)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid exempt CIDR %q: %w", cidr, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// isExemptIP reports whether the client IP falls within any of the exempt prefixes
+func isExemptIP(ip string, prefixes []netip.Prefix) bool {
+	if len(prefixes) == 0 {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExemptKey reports whether the presented key matches an exempt key using constant-time comparison
+func isExemptKey(presented string, keys [][]byte) bool {
+	if presented == "" {
+		return false
+	}
+
+	match := false
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(presented), key) == 1 {
+			match = true
+		}
+	}
+	return match
+}
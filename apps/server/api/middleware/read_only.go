@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// readOnlyAllowedWrites are the write routes that keep working in read-only mode:
+// refreshing a session, so users stay signed in, and switching the mode off again
+var readOnlyAllowedWrites = map[string]bool{
+	"/auth/refresh":      true,
+	"/health/read-only":  true,
+	"/health/read-only/": true,
+}
+
+// ReadOnlyMiddleware rejects writes while the API is in read-only mode
+func (mw *Middleware) ReadOnlyMiddleware() fiber.Handler {
+	return NewReadOnlyGuard(services.NewReadOnlyService())
+}
+
+// NewReadOnlyGuard creates a handler that answers POST, PUT, PATCH and DELETE requests with
+// 503 Service Unavailable while read-only mode is enabled. Reads are always served and the
+// mode is only looked up for writes.
+func NewReadOnlyGuard(readOnly services.ReadOnlyServiceInterface) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		default:
+			return c.Next()
+		}
+
+		if readOnlyAllowedWrites[c.Path()] || !readOnly.IsReadOnly() {
+			return c.Next()
+		}
+
+		return response.ServiceUnavailable(c, "The API is in read-only mode for maintenance, changes cannot be saved right now")
+	}
+}
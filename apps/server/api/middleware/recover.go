@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/gofiber/fiber/v3"
+)
+
+// RecoverMiddleware turns a panicking handler into an audited 500 response instead of a dropped connection.
+// Register it directly after RequestIDMiddleware so it protects every later middleware and route.
+func (mw *Middleware) RecoverMiddleware() fiber.Handler {
+	return NewRecoverer(mw.logger)
+}
+
+// NewRecoverer creates the panic recovery handler. The panic value, stack trace and request ID
+// are written to the audit log, the client only receives a generic internal server error.
+func NewRecoverer(logger *config.Logger) fiber.Handler {
+	return func(c fiber.Ctx) (err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			logger.WithRequest(c).AuditError("Recovered from panic in request handler",
+				"panic", fmt.Sprint(recovered),
+				"stack", string(debug.Stack()),
+				"method", c.Method(),
+				"path", c.Path(),
+				"ip", c.IP(),
+			)
+
+			// Discard any body written before the panic, but keep headers such as CORS and X-Request-ID
+			c.Response().ResetBody()
+			err = response.InternalServerError(c, "An unexpected error occurred")
+		}()
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"github.com/MonkyMars/PWS/config"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength bounds client supplied request IDs so they cannot bloat logs
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware assigns every request an ID for correlating logs and audit entries.
+// It should be registered before any other middleware so every log line can include it.
+func (mw *Middleware) RequestIDMiddleware() fiber.Handler {
+	return NewRequestID()
+}
+
+// NewRequestID creates the request ID handler. A well-formed X-Request-ID sent by the client
+// or a proxy is reused, otherwise a new UUID is generated. The ID is stored in the
+// config.RequestIDLocal local and echoed in the X-Request-ID response header.
+func NewRequestID() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		id := c.Get(config.RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		c.Locals(config.RequestIDLocal, id)
+		c.Set(config.RequestIDHeader, id)
+
+		return c.Next()
+	}
+}
+
+// validRequestID reports whether a client supplied request ID is safe to log.
+// Only letters, digits and a few separators are accepted to prevent log injection.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
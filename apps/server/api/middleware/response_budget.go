@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ResponseBudgetMiddleware reports handlers that exceed the configured response time budget
+func (mw *Middleware) ResponseBudgetMiddleware() fiber.Handler {
+	return NewResponseBudget(config.Get().Server, mw.logger)
+}
+
+// NewResponseBudget creates a handler that measures the time spent in the rest of the chain and
+// writes an audit warning with the route and duration when it exceeds the route's budget.
+// Budgets are looked up by the registered route path, so /deadlines/:id shares one budget.
+// Routes without a budget, or with a zero global budget, are not checked.
+func NewResponseBudget(cfg types.ServerConfig, logger *config.Logger) fiber.Handler {
+	if cfg.ResponseBudget <= 0 && len(cfg.ResponseBudgetRoutes) == 0 {
+		return func(c fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		duration := time.Since(start)
+
+		// After the chain has run the context points at the route that handled the request
+		route := c.Route().Path
+		budget := cfg.ResponseBudgetFor(c.Method(), route)
+		if budget > 0 && duration > budget {
+			logger.WithRequest(c).AuditWarn("Handler exceeded response time budget",
+				"method", c.Method(),
+				"route", route,
+				"path", c.Path(),
+				"status", c.Response().StatusCode(),
+				"duration", duration.String(),
+				"budget", budget.String(),
+			)
+		}
+
+		return err
+	}
+}
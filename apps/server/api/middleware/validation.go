@@ -141,57 +141,6 @@ func validateField(rule ValidationRule, value any, fieldName string) *types.Vali
 
 // Common validation configurations for reuse
 
-// AuthRequestValidation validates authentication requests
-var AuthRequestValidation = ValidationConfig{
-	Rules: []ValidationRule{
-		{
-			Field:    "Email",
-			Required: true,
-			Validator: func(value any) error {
-				email := fmt.Sprintf("%v", value)
-				if !strings.Contains(email, "@") {
-					return fmt.Errorf("email must be a valid email address")
-				}
-				return nil
-			},
-		},
-		{
-			Field:     "Password",
-			Required:  true,
-			MinLength: 1,
-		},
-	},
-}
-
-// RegisterRequestValidation validates user registration requests
-var RegisterRequestValidation = ValidationConfig{
-	Rules: []ValidationRule{
-		{
-			Field:     "Username",
-			Required:  true,
-			MinLength: 3,
-			MaxLength: 50,
-		},
-		{
-			Field:    "Email",
-			Required: true,
-			Validator: func(value any) error {
-				email := fmt.Sprintf("%v", value)
-				if !strings.Contains(email, "@") {
-					return fmt.Errorf("email must be a valid email address")
-				}
-				return nil
-			},
-		},
-		{
-			Field:     "Password",
-			Required:  true,
-			MinLength: 6,
-			MaxLength: 128,
-		},
-	},
-}
-
 // FileUploadValidation validates file upload requests
 var FileUploadValidation = ValidationConfig{
 	Rules: []ValidationRule{
@@ -1,6 +1,10 @@
 package response
 
 import (
+	"math"
+	"strconv"
+	"time"
+
 	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
 )
@@ -91,6 +95,25 @@ func NotFound(c fiber.Ctx, message string) error {
 		Send(c, fiber.StatusNotFound)
 }
 
+// MethodNotAllowed sends a 405 Method Not Allowed response when the route exists for other methods.
+// This function should be used together with an Allow header listing the supported methods,
+// which Fiber sets before it reports the error.
+//
+// Parameters:
+//   - c: Fiber context for sending the response
+//   - message: Custom error message (uses default if empty)
+//
+// Returns an error if the response cannot be sent.
+func MethodNotAllowed(c fiber.Ctx, message string) error {
+	if message == "" {
+		message = "Method not allowed"
+	}
+	return NewResponse().
+		Error(message).
+		WithError(ErrCodeMethodNotAllowed, message).
+		Send(c, fiber.StatusMethodNotAllowed)
+}
+
 // Conflict sends a 409 Conflict response for resource state conflicts.
 // This function should be used when the request conflicts with the current resource state.
 //
@@ -106,6 +129,42 @@ func Conflict(c fiber.Ctx, message string) error {
 		Send(c, fiber.StatusConflict)
 }
 
+// PayloadTooLarge sends a 413 Payload Too Large response for request bodies over the size limit.
+// This function should be used when the client sends more data than the route accepts.
+//
+// Parameters:
+//   - c: Fiber context for sending the response
+//   - message: Custom error message (uses default if empty)
+//
+// Returns an error if the response cannot be sent.
+func PayloadTooLarge(c fiber.Ctx, message string) error {
+	if message == "" {
+		message = "Request body too large"
+	}
+	return NewResponse().
+		Error(message).
+		WithError(ErrCodePayloadTooLarge, message).
+		Send(c, fiber.StatusRequestEntityTooLarge)
+}
+
+// UnsupportedMediaType sends a 415 Unsupported Media Type response for request bodies in an unaccepted format.
+// This function should be used when the Content-Type of the request is not one the route can parse.
+//
+// Parameters:
+//   - c: Fiber context for sending the response
+//   - message: Custom error message (uses default if empty)
+//
+// Returns an error if the response cannot be sent.
+func UnsupportedMediaType(c fiber.Ctx, message string) error {
+	if message == "" {
+		message = "Unsupported media type"
+	}
+	return NewResponse().
+		Error(message).
+		WithError(ErrCodeUnsupportedMedia, message).
+		Send(c, fiber.StatusUnsupportedMediaType)
+}
+
 // SendValidationError sends a 422 Unprocessable Entity response for validation errors.
 // This function should be used when request data fails validation rules.
 //
@@ -165,6 +224,33 @@ func TooManyRequests(c fiber.Ctx, message string) error {
 		Send(c, fiber.StatusTooManyRequests)
 }
 
+// TooManyRequestsWithRetryAfter sends a 429 Too Many Requests response that tells the client
+// when to retry. The delay is rounded up to whole seconds (at least 1) and sent both in the
+// Retry-After header and as retry_after in the error details.
+//
+// Parameters:
+//   - c: Fiber context for sending the response
+//   - message: Custom error message (uses default if empty)
+//   - retryAfter: Time until the client may retry
+//
+// Returns an error if the response cannot be sent.
+func TooManyRequestsWithRetryAfter(c fiber.Ctx, message string, retryAfter time.Duration) error {
+	if message == "" {
+		message = "Too many requests"
+	}
+
+	seconds := max(int(math.Ceil(retryAfter.Seconds())), 1)
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+
+	return NewResponse().
+		Error(message).
+		WithError(ErrCodeTooManyReq, message).
+		WithErrorDetails(map[string]any{
+			"retry_after": seconds,
+		}).
+		Send(c, fiber.StatusTooManyRequests)
+}
+
 // InternalServerError sends a 500 Internal Server Error response for server-side errors.
 // This function should be used when an unexpected server error occurs.
 //
@@ -203,6 +289,59 @@ func InternalServerErrorWithDetails(c fiber.Ctx, message string, details map[str
 		Send(c, fiber.StatusInternalServerError)
 }
 
+// InternalServerErrorForEnvironment sends a 500 response whose details depend on the environment.
+// Outside production the error chain and the request ID are included to speed up debugging,
+// in production the response stays opaque and only carries the message.
+//
+// Parameters:
+//   - c: Fiber context for sending the response
+//   - environment: Value of ENVIRONMENT, details are only hidden for "production"
+//   - message: Custom error message (uses default if empty)
+//   - err: The underlying error, may be nil
+//
+// Returns an error if the response cannot be sent.
+func InternalServerErrorForEnvironment(c fiber.Ctx, environment, message string, err error) error {
+	if environment == "production" {
+		return InternalServerError(c, message)
+	}
+
+	details := map[string]any{}
+	if err != nil {
+		details["error_chain"] = ErrorChain(err)
+	}
+	// The request ID middleware echoes the ID in the response header
+	if requestID := c.GetRespHeader(fiber.HeaderXRequestID); requestID != "" {
+		details["request_id"] = requestID
+	}
+
+	return InternalServerErrorWithDetails(c, message, details)
+}
+
+// ErrorChain returns the messages of err and every error it wraps, outermost first.
+// Errors joined with errors.Join are walked in order.
+func ErrorChain(err error) []string {
+	var chain []string
+	var walk func(error)
+	walk = func(err error) {
+		for err != nil {
+			chain = append(chain, err.Error())
+			switch wrapped := err.(type) {
+			case interface{ Unwrap() []error }:
+				for _, inner := range wrapped.Unwrap() {
+					walk(inner)
+				}
+				return
+			case interface{ Unwrap() error }:
+				err = wrapped.Unwrap()
+			default:
+				return
+			}
+		}
+	}
+	walk(err)
+	return chain
+}
+
 // ServiceUnavailable sends a 503 Service Unavailable response for temporary outages.
 // This function should be used when the service is temporarily unable to handle requests.
 //
@@ -0,0 +1,84 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// ETag returns a strong entity tag for the given response body
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ETagMatches reports whether an If-None-Match header value matches the entity tag.
+// It accepts "*" and comma separated lists and compares weakly, ignoring a W/ prefix, as required for GET requests.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	ifNoneMatch = strings.TrimSpace(ifNoneMatch)
+	if ifNoneMatch == "" {
+		return false
+	}
+	if ifNoneMatch == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for candidate := range strings.SplitSeq(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// SendWithETag sends the response with the given data as 200 OK together with an ETag header.
+// The tag is a hash of the response without its timestamp, so it only changes when the content does.
+// A GET or HEAD request whose If-None-Match matches the tag receives 304 Not Modified without a body.
+//
+// Parameters:
+//   - c: Fiber context for sending the response
+//   - data: The data to include in the response
+//
+// Returns an error if the response cannot be sent.
+func (rb *ResponseBuilder) SendWithETag(c fiber.Ctx, data any) error {
+	rb.WithData(data)
+
+	// Hash a copy without the timestamp, which differs on every request
+	content := *rb.response
+	content.Timestamp = time.Time{}
+	body, err := json.Marshal(content)
+	if err != nil {
+		// Without a tag the client simply receives the full response
+		return rb.Send(c, fiber.StatusOK)
+	}
+
+	etag := ETag(body)
+	c.Set(fiber.HeaderETag, etag)
+
+	method := c.Method()
+	if (method == fiber.MethodGet || method == fiber.MethodHead) && ETagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return rb.Send(c, fiber.StatusOK)
+}
+
+// SuccessWithETag sends a successful response with data and an ETag header,
+// answering a matching conditional GET with 304 Not Modified.
+// Use it for read-heavy endpoints that clients poll, such as the deadline list.
+//
+// Parameters:
+//   - c: Fiber context for sending the response
+//   - data: The data to include in the response
+//
+// Returns an error if the response cannot be sent.
+func SuccessWithETag(c fiber.Ctx, data any) error {
+	return NewResponse().
+		Success("Request successful").
+		SendWithETag(c, data)
+}
@@ -0,0 +1,62 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Pagination defaults and bounds of ParsePaginationParams
+const (
+	DefaultPage  = 1
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
+// ErrInvalidPagination is returned by ParsePaginationParams for a page or limit that is not a positive number
+var ErrInvalidPagination = errors.New("invalid pagination parameters")
+
+// ParsePaginationParams extracts the page and limit query parameters.
+// Missing parameters default to page 1 and a limit of 10, a limit above 100 is capped at 100.
+// A page or limit that is not a positive number returns an error wrapping ErrInvalidPagination,
+// so the client learns about the mistake instead of silently getting the first page.
+func ParsePaginationParams(c fiber.Ctx) (page, limit int, err error) {
+	page, err = parsePositiveQuery(c, "page", DefaultPage)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	limit, err = parsePositiveQuery(c, "limit", DefaultLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	return page, limit, nil
+}
+
+// CalculateOffset converts a 1-based page number to the offset of its first row
+func CalculateOffset(page, limit int) int {
+	if page < 1 {
+		return 0
+	}
+	return (page - 1) * limit
+}
+
+// parsePositiveQuery reads an integer query parameter that must be at least 1, returning fallback when it is missing
+func parsePositiveQuery(c fiber.Ctx, name string, fallback int) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("%w: %s must be a positive whole number, got %q", ErrInvalidPagination, name, raw)
+	}
+	return value, nil
+}
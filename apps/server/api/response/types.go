@@ -184,6 +184,12 @@ const (
 	ErrCodeForbidden = "FORBIDDEN"
 	// ErrCodeConflict indicates a conflict with the current resource state
 	ErrCodeConflict = "CONFLICT"
+	// ErrCodeMethodNotAllowed indicates the route does not support the request method
+	ErrCodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	// ErrCodePayloadTooLarge indicates the request body exceeds the size limit
+	ErrCodePayloadTooLarge = "PAYLOAD_TOO_LARGE"
+	// ErrCodeUnsupportedMedia indicates the request body has an unsupported content type
+	ErrCodeUnsupportedMedia = "UNSUPPORTED_MEDIA_TYPE"
 	// ErrCodeInternal indicates an internal server error
 	ErrCodeInternal = "INTERNAL_ERROR"
 	// ErrCodeBadRequest indicates malformed or invalid request data
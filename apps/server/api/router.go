@@ -12,13 +12,17 @@ import (
 )
 
 // App initializes and starts the main application server.
-// It loads configuration, sets up logging, creates the Fiber application with
-// appropriate middleware, configures routes, and starts the HTTP server.
+// It creates the application with NewApp and listens on the configured address until
+// the server fails or is shut down. Use NewApp to keep a reference for a graceful shutdown.
 // Returns an error if the server fails to start or encounters a configuration issue.
 func App() error {
-	// Get centralized configuration
-	cfg := config.Get()
+	return NewApp().Listen(config.Get().GetServerAddress())
+}
 
+// NewApp creates the Fiber application with appropriate middleware and routes and starts
+// the logging workers, without listening yet. The caller starts the server with Listen
+// and drains in-flight requests on shutdown with ShutdownWithTimeout.
+func NewApp() *fiber.App {
 	// Setup logger with centralized config
 	logger := config.SetupLogger()
 
@@ -27,14 +31,35 @@ func App() error {
 
 	mw := middleware.NewMiddleware()
 
+	// Assign a request ID first so every log line and audit entry can be correlated
+	app.Use(mw.RequestIDMiddleware())
+
+	// Recover from panics in every later middleware and handler, including the auth routes
+	app.Use(mw.RecoverMiddleware())
+
+	// Add health monitoring middleware, before the middleware that rejects requests so those are counted too
+	app.Use(mw.CreateHealthMiddleware())
+
 	// Add CORS middleware
 	app.Use(mw.SetupCORS())
 
+	// Compress responses for clients that accept it
+	app.Use(mw.CompressionMiddleware())
+
+	// Reject cross-site writes on cookie sessions that lack the CSRF token
+	app.Use(mw.CSRFMiddleware())
+
+	// Reject writes while the API is in read-only mode for maintenance
+	app.Use(mw.ReadOnlyMiddleware())
+
+	// Add rate limiting middleware (trusted internal callers are exempt)
+	app.Use(mw.RateLimitMiddleware())
+
 	// Add logging middleware
 	app.Use(logger.HTTPMiddleware())
 
-	// Add health monitoring middleware
-	app.Use(mw.CreateHealthMiddleware())
+	// Report handlers that exceed their response time budget
+	app.Use(mw.ResponseBudgetMiddleware())
 
 	// Log server startup
 	logger.ServerStart()
@@ -54,8 +79,7 @@ func App() error {
 	// Log server ready
 	logger.ServerReady()
 
-	// Start server
-	return app.Listen(cfg.GetServerAddress())
+	return app
 }
 
 // SetupRoutes configures all application routes by delegating to specific route handlers.
@@ -90,6 +114,12 @@ func SetupRoutes(app *fiber.App, logger *config.Logger) {
 	// Deadline routes
 	router.DeadlineRoutes.RegisterRoutes(app)
 
+	// User management routes
+	router.UserRoutes.RegisterRoutes(app)
+
+	// Webhook subscription routes
+	router.WebhookRoutes.RegisterRoutes(app)
+
 	// Catch-all for undefined routes
 	app.Use(func(c fiber.Ctx) error {
 		return lib.HandleServiceError(c, fiber.ErrBadRequest, "undefined route: "+c.OriginalURL())
@@ -6,7 +6,9 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -26,7 +28,12 @@ type Config struct {
 	Environment string
 	Port        string
 	LogLevel    string
+	LogFormat   string
 	FrontendURL string
+	LogSource   bool
+
+	// ReadOnlyMode is the read-only state used until an admin sets it at runtime
+	ReadOnlyMode bool
 
 	// Auth Settings
 	Auth types.AuthConfig
@@ -34,9 +41,15 @@ type Config struct {
 	// Google OAuth Settings
 	Google types.GoogleConfig
 
+	// Microsoft OAuth Settings
+	Microsoft types.MicrosoftConfig
+
 	// Database Settings
 	Database types.DatabaseConfig
 
+	// Dedicated Audit Database Settings
+	AuditDatabase types.AuditDatabaseConfig
+
 	// Server Settings
 	Server types.ServerConfig
 
@@ -46,12 +59,24 @@ type Config struct {
 	// CORS Settings
 	Cors types.CorsConfig
 
+	// Auth Cookie Settings
+	Cookie types.CookieConfig
+
 	// Audit Settings
 	Audit types.AuditConfig
 
 	// Health Check Settings
 	Health types.HealthConfig
 
+	// Rate Limit Settings
+	RateLimit types.RateLimitConfig
+
+	// Notification Settings
+	Notification types.NotificationConfig
+
+	// Outbound Webhook Settings
+	Webhook types.WebhookConfig
+
 	// Domain configs for better organization
 	domains *DomainConfigs
 }
@@ -252,6 +277,69 @@ func (c *Config) PrintConfig() {
 	// Enable specific log lines below if needed for debugging
 }
 
+// FeatureSummary reports the enabled state of each optional subsystem together with
+// a few non-sensitive settings. Secrets such as OAuth client secrets, rate limit
+// exempt keys and webhook paths are never included.
+func (c *Config) FeatureSummary() map[string]map[string]any {
+	webhookHost := ""
+	if c.Database.CircuitAlertWebhookURL != "" {
+		if parsed, err := url.Parse(c.Database.CircuitAlertWebhookURL); err == nil {
+			webhookHost = parsed.Host
+		}
+	}
+
+	policies := make([]string, 0, len(c.Audit.OverflowPolicies))
+	for level, policy := range c.Audit.OverflowPolicies {
+		policies = append(policies, level+":"+string(policy))
+	}
+	slices.Sort(policies)
+
+	return map[string]map[string]any{
+		"audit": {
+			"enabled":           c.Audit.Enabled,
+			"read_access":       c.Audit.ReadAccess,
+			"retention_days":    c.Audit.RetentionDays,
+			"overflow_policies": strings.Join(policies, ","),
+			"database_host":     c.AuditDatabase.Host,
+		},
+		"health": {
+			"enabled":         c.Health.Enabled,
+			"report_interval": c.Health.ReportInterval.String(),
+		},
+		"google_oauth": {
+			"enabled":      c.Google.ClientID != "" && c.Google.ClientSecret != "",
+			"redirect_url": c.Google.RedirectURL,
+		},
+		"microsoft_oauth": {
+			"enabled":      c.Microsoft.ClientID != "" && c.Microsoft.ClientSecret != "",
+			"redirect_url": c.Microsoft.RedirectURL,
+			"tenant":       c.Microsoft.Tenant,
+		},
+		"rate_limit": {
+			"enabled":      c.RateLimit.Enabled,
+			"max":          c.RateLimit.Max,
+			"window":       c.RateLimit.Window.String(),
+			"exempt_cidrs": len(c.RateLimit.ExemptCIDRs),
+			"soft_limit":   c.RateLimit.SoftThreshold,
+			"exempt_keys":  len(c.RateLimit.ExemptKeys),
+		},
+		"circuit_alerts": {
+			"enabled":      webhookHost != "",
+			"webhook_host": webhookHost,
+		},
+		"notifications": {
+			"enabled":        c.Notification.WebhookURL != "",
+			"retry_enabled":  c.Notification.RetryEnabled,
+			"retry_interval": c.Notification.RetryInterval.String(),
+		},
+		"webhooks": {
+			"enabled":      c.Webhook.Enabled,
+			"max_attempts": c.Webhook.MaxAttempts,
+			"timeout":      c.Webhook.Timeout.String(),
+		},
+	}
+}
+
 // Helper functions for environment variables
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -273,6 +361,16 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+		log.Printf("Invalid float value for %s: %s, using default: %v", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -339,6 +437,11 @@ func GetDatabaseConfig() *DatabaseConfig {
 	return GetDomains().Database
 }
 
+// GetAuditDatabaseConfig returns the dedicated audit database configuration domain
+func GetAuditDatabaseConfig() *AuditDatabaseConfig {
+	return GetDomains().AuditDatabase
+}
+
 // GetServerConfig returns the server configuration domain
 func GetServerConfig() *ServerConfig {
 	return GetDomains().Server
@@ -354,6 +457,11 @@ func GetCorsConfig() *CorsConfig {
 	return GetDomains().Cors
 }
 
+// GetCookieConfig returns the auth cookie configuration domain
+func GetCookieConfig() *CookieConfig {
+	return GetDomains().Cookie
+}
+
 // GetAuditConfig returns the audit configuration domain
 func GetAuditConfig() *AuditConfig {
 	return GetDomains().Audit
@@ -364,6 +472,22 @@ func GetHealthConfig() *HealthConfig {
 	return GetDomains().Health
 }
 
+// GetMicrosoftConfig returns the Microsoft OAuth configuration domain
+func GetMicrosoftConfig() *types.MicrosoftConfig {
+	microsoft := GetDomains().Microsoft
+	return &types.MicrosoftConfig{
+		ClientID:     microsoft.ClientID,
+		ClientSecret: microsoft.ClientSecret,
+		RedirectURL:  microsoft.RedirectURL,
+		Tenant:       microsoft.Tenant,
+	}
+}
+
+// GetRateLimitConfig returns the rate limit configuration domain
+func GetRateLimitConfig() *RateLimitConfig {
+	return GetDomains().RateLimit
+}
+
 // GetGoogleConfig returns the Google OAuth configuration domain
 func GetGoogleConfig() *types.GoogleConfig {
 	google := GetDomains().Google
@@ -5,6 +5,13 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/MonkyMars/PWS/types"
@@ -12,15 +19,22 @@ import (
 
 // DomainConfigs holds all domain-specific configurations
 type DomainConfigs struct {
-	App      *AppConfig
-	Auth     *AuthConfig
-	Database *DatabaseConfig
-	Server   *ServerConfig
-	Cache    *CacheConfig
-	Cors     *CorsConfig
-	Audit    *AuditConfig
-	Health   *HealthConfig
-	Google   *GoogleOAuthConfig
+	App       *AppConfig
+	Auth      *AuthConfig
+	Database  *DatabaseConfig
+	Server    *ServerConfig
+	Cache     *CacheConfig
+	Cors      *CorsConfig
+	Cookie    *CookieConfig
+	Audit     *AuditConfig
+	Health    *HealthConfig
+	Google    *GoogleOAuthConfig
+	Microsoft *MicrosoftOAuthConfig
+	RateLimit *RateLimitConfig
+
+	Notification  *NotificationConfig
+	Webhook       *WebhookConfig
+	AuditDatabase *AuditDatabaseConfig
 }
 
 // AppConfig holds application-level configuration
@@ -29,7 +43,14 @@ type AppConfig struct {
 	Environment string
 	Port        string
 	LogLevel    string
+	LogFormat   string
 	FrontendURL string
+
+	// LogSource adds the file:line of the call site to log lines and audit entries
+	LogSource bool
+
+	// ReadOnlyMode rejects writes until an admin changes the mode at runtime
+	ReadOnlyMode bool
 }
 
 // AuthConfig holds authentication configuration
@@ -40,6 +61,29 @@ type AuthConfig struct {
 	RefreshTokenExpiry time.Duration
 	CacheUserTTL       time.Duration
 	BlacklistCacheTTL  time.Duration
+	// PasswordPepper is a server side secret mixed into passwords before hashing, empty disables it
+	PasswordPepper string
+	// RolePermissions maps roles to permissions, e.g. "admin=*;teacher=submissions:grade"
+	RolePermissions string
+	// SessionIdleTimeout ends a session after this long without an authenticated request
+	SessionIdleTimeout time.Duration
+	// SessionMaxLifetime ends a session this long after login, however active it is
+	SessionMaxLifetime time.Duration
+
+	// Password policy for new passwords. The maximum length bounds the cost of hashing a password.
+	PasswordMinLength      int
+	PasswordMaxLength      int
+	PasswordRequireUpper   bool
+	PasswordRequireLower   bool
+	PasswordRequireDigit   bool
+	PasswordRequireSpecial bool
+	// PasswordBlocklistFile lists common passwords that are rejected, one per line, empty disables it
+	PasswordBlocklistFile string
+	// CheckPwned rejects passwords found in Have I Been Pwned. Only the first five characters of the
+	// SHA-1 hash are sent to PwnedAPIURL, and passwords are allowed when the service is unreachable.
+	CheckPwned   bool
+	PwnedAPIURL  string
+	PwnedTimeout time.Duration
 }
 
 // DatabaseConfig holds database configuration
@@ -55,6 +99,30 @@ type DatabaseConfig struct {
 	MaxLifetime  time.Duration
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+
+	CircuitAlertWebhookURL string
+	CircuitAlertDebounce   time.Duration
+
+	// MaxInsertEntries caps the rows of a single bulk insert, larger batches are rejected
+	MaxInsertEntries int
+
+	// ConnWaitWarnThreshold logs a warning when a query waits longer for a pooled connection, zero disables it
+	ConnWaitWarnThreshold time.Duration
+
+	// StatementTimeout cancels queries that do not set their own timeout after this long, zero disables it
+	StatementTimeout time.Duration
+}
+
+// AuditDatabaseConfig holds the optional dedicated database for audit and health logs.
+// Without AUDIT_DB_HOST the logs are written to the primary database.
+type AuditDatabaseConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	MaxConns int
+	MinConns int
 }
 
 // ServerConfig holds HTTP server configuration
@@ -62,6 +130,23 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+
+	MaxFilterConditions int
+
+	// MaxRequestBodySize caps request bodies in bytes, larger ones get 413 Payload Too Large.
+	// Route groups can set a lower limit with the body limit middleware, never a higher one.
+	MaxRequestBodySize int
+
+	// Responses of at least CompressionMinSize bytes are gzip or deflate compressed
+	// at CompressionLevel (1 fastest to 9 smallest) when the client accepts it
+	CompressionEnabled bool
+	CompressionLevel   int
+	CompressionMinSize int
+
+	// Handlers taking longer than ResponseBudget are reported, ResponseBudgetRoutes overrides
+	// the budget per route, e.g. "GET /health/history/:service=5s,/health=100ms"
+	ResponseBudget       time.Duration
+	ResponseBudgetRoutes string
 }
 
 // CacheConfig holds Redis cache configuration
@@ -81,6 +166,11 @@ type CacheConfig struct {
 	MaxRetries      int
 	MinRetryBackoff time.Duration
 	MaxRetryBackoff time.Duration
+
+	// Submissions per student and deadline are serialized with a lock held at most LockTTL,
+	// a competing request waits up to LockWait for it
+	SubmissionLockTTL  time.Duration
+	SubmissionLockWait time.Duration
 }
 
 // CorsConfig holds CORS configuration
@@ -91,6 +181,20 @@ type CorsConfig struct {
 	AllowCredentials bool
 }
 
+// CookieConfig holds the attributes of the auth cookies
+type CookieConfig struct {
+	// Domain is left out of the cookies when empty, so they only apply to the API host
+	Domain   string
+	Secure   bool
+	SameSite string
+	HTTPOnly bool
+
+	// CSRFEnabled requires a CSRF token on unsafe requests authenticated by cookies,
+	// except on CSRFExemptPaths
+	CSRFEnabled     bool
+	CSRFExemptPaths []string
+}
+
 // AuditConfig holds audit logging configuration
 type AuditConfig struct {
 	BatchSize     int
@@ -101,6 +205,20 @@ type AuditConfig struct {
 	MaxRetries    int
 	RetentionDays int
 	RetryDelay    time.Duration
+	DLQSize       int
+	DLQInterval   time.Duration
+
+	// Cleanup runs every CleanupInterval, starting CleanupOffset after midnight in CleanupTimezone
+	CleanupInterval time.Duration
+	CleanupOffset   time.Duration
+	CleanupTimezone string
+
+	// ReadAccess records reads of sensitive resources by teachers and admins
+	ReadAccess bool
+
+	// OverflowPolicy decides per level whether a full queue drops or briefly blocks, e.g. "ERROR:block,WARN:drop"
+	OverflowPolicy  string
+	OverflowTimeout time.Duration
 }
 
 // HealthConfig holds health monitoring configuration
@@ -115,6 +233,10 @@ type HealthConfig struct {
 	RetentionDays  int
 	Services       []string
 	RetryDelay     time.Duration
+
+	// ProbeFailureThreshold is the number of consecutive failed database or Redis
+	// probes the health worker tolerates, it reports itself unhealthy after more than that
+	ProbeFailureThreshold int
 }
 
 // GoogleOAuthConfig holds Google OAuth configuration
@@ -124,18 +246,88 @@ type GoogleOAuthConfig struct {
 	RedirectURL  string
 }
 
+// MicrosoftOAuthConfig holds Microsoft (Entra ID) OAuth configuration
+type MicrosoftOAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Tenant       string
+}
+
+// RateLimitConfig holds request rate limiting configuration
+type RateLimitConfig struct {
+	Enabled     bool
+	Max         int
+	Window      time.Duration
+	ExemptCIDRs []string
+	ExemptKeys  []string
+
+	// Clients that used SoftThreshold of Max in a window are warned, SoftThresholdRoutes overrides it per path prefix
+	SoftThreshold       float64
+	SoftThresholdRoutes string
+}
+
+// NotificationConfig holds notification delivery configuration
+type NotificationConfig struct {
+	// WebhookURL receives notifications as JSON, notifications are only logged when it is empty
+	WebhookURL string
+
+	// Failed deliveries are kept in a dead letter queue of DLQSize entries and retried
+	// every RetryInterval, up to MaxRetries times
+	RetryEnabled  bool
+	DLQSize       int
+	RetryInterval time.Duration
+	MaxRetries    int
+
+	// DLQPersistent keeps the dead letter queue in Redis, so queued notifications survive a restart
+	DLQPersistent bool
+}
+
+// WebhookConfig holds the configuration of the outbound webhook subscriptions
+type WebhookConfig struct {
+	Enabled bool
+
+	// Events wait in a queue of ChannelSize until the webhook worker delivers them
+	ChannelSize int
+
+	// Timeout bounds a single delivery, a failed delivery is attempted MaxAttempts times
+	// in total, waiting RetryBackoff after the first failure and doubling it after each next one
+	Timeout      time.Duration
+	MaxAttempts  int
+	RetryBackoff time.Duration
+
+	// At most MaxConcurrency deliveries run at once, so one slow receiver does not hold up the others
+	MaxConcurrency int
+
+	// Deliveries that still fail are kept in a dead letter queue of DLQSize entries and
+	// retried every RetryInterval, up to DLQMaxRetries times
+	DLQSize       int
+	RetryInterval time.Duration
+	DLQMaxRetries int
+}
+
 // LoadDomainConfigs loads all domain-specific configurations
 func LoadDomainConfigs() *DomainConfigs {
+	app := loadAppConfig()
+	database := loadDatabaseConfig()
+
 	return &DomainConfigs{
-		App:      loadAppConfig(),
-		Auth:     loadAuthConfig(),
-		Database: loadDatabaseConfig(),
-		Server:   loadServerConfig(),
-		Cache:    loadCacheConfig(),
-		Cors:     loadCorsConfig(),
-		Audit:    loadAuditConfig(),
-		Health:   loadHealthConfig(),
-		Google:   loadGoogleConfig(),
+		App:       app,
+		Auth:      loadAuthConfig(),
+		Database:  database,
+		Server:    loadServerConfig(),
+		Cache:     loadCacheConfig(),
+		Cors:      loadCorsConfig(),
+		Cookie:    loadCookieConfig(app.Environment),
+		Audit:     loadAuditConfig(),
+		Health:    loadHealthConfig(),
+		Google:    loadGoogleConfig(),
+		Microsoft: loadMicrosoftConfig(),
+		RateLimit: loadRateLimitConfig(),
+
+		Notification:  loadNotificationConfig(),
+		Webhook:       loadWebhookConfig(),
+		AuditDatabase: loadAuditDatabaseConfig(database),
 	}
 }
 
@@ -143,14 +335,21 @@ func LoadDomainConfigs() *DomainConfigs {
 func (dc *DomainConfigs) Validate() error {
 	validators := []func() error{
 		dc.App.Validate,
-		dc.Auth.Validate,
+		func() error { return dc.Auth.Validate(dc.App.Environment) },
 		dc.Database.Validate,
 		dc.Server.Validate,
 		dc.Cache.Validate,
-		dc.Cors.Validate,
+		func() error { return dc.Cors.Validate(dc.App.Environment) },
+		func() error { return dc.Cookie.Validate(dc.App.Environment) },
 		dc.Audit.Validate,
 		dc.Health.Validate,
 		dc.Google.Validate,
+		dc.Microsoft.Validate,
+		dc.RateLimit.Validate,
+		dc.Notification.Validate,
+		dc.Webhook.Validate,
+		dc.AuditDatabase.Validate,
+		dc.validateBatchSizes,
 	}
 
 	for _, validate := range validators {
@@ -162,6 +361,18 @@ func (dc *DomainConfigs) Validate() error {
 	return nil
 }
 
+// validateBatchSizes checks that the audit and health workers never flush more logs at once than
+// a single bulk insert accepts, otherwise every full batch would be rejected by DB_MAX_INSERT_ENTRIES
+func (dc *DomainConfigs) validateBatchSizes() error {
+	if dc.Audit.Enabled && dc.Audit.BatchSize > dc.Database.MaxInsertEntries {
+		return fmt.Errorf("AUDIT_BATCH_SIZE (%d) cannot be greater than DB_MAX_INSERT_ENTRIES (%d)", dc.Audit.BatchSize, dc.Database.MaxInsertEntries)
+	}
+	if dc.Health.Enabled && dc.Health.BatchSize > dc.Database.MaxInsertEntries {
+		return fmt.Errorf("HEALTH_BATCH_SIZE (%d) cannot be greater than DB_MAX_INSERT_ENTRIES (%d)", dc.Health.BatchSize, dc.Database.MaxInsertEntries)
+	}
+	return nil
+}
+
 // ToLegacyConfig converts domain configs to the legacy Config struct for backward compatibility
 func (dc *DomainConfigs) ToLegacyConfig() *Config {
 	return &Config{
@@ -169,7 +380,12 @@ func (dc *DomainConfigs) ToLegacyConfig() *Config {
 		Environment: dc.App.Environment,
 		Port:        dc.App.Port,
 		LogLevel:    dc.App.LogLevel,
+		LogFormat:   dc.App.LogFormat,
 		FrontendURL: dc.App.FrontendURL,
+		LogSource:   dc.App.LogSource,
+
+		ReadOnlyMode: dc.App.ReadOnlyMode,
+
 		Auth: types.AuthConfig{
 			AccessTokenSecret:  dc.Auth.AccessTokenSecret,
 			AccessTokenExpiry:  dc.Auth.AccessTokenExpiry,
@@ -177,12 +393,34 @@ func (dc *DomainConfigs) ToLegacyConfig() *Config {
 			RefreshTokenExpiry: dc.Auth.RefreshTokenExpiry,
 			CacheUserTTL:       dc.Auth.CacheUserTTL,
 			BlacklistCacheTTL:  dc.Auth.BlacklistCacheTTL,
+			PasswordPepper:     dc.Auth.PasswordPepper,
+			RolePermissions:    mustParseRolePermissions(dc.Auth.RolePermissions),
+			SessionIdleTimeout: dc.Auth.SessionIdleTimeout,
+			SessionMaxLifetime: dc.Auth.SessionMaxLifetime,
+			PasswordPolicy: types.PasswordPolicy{
+				MinLength:      dc.Auth.PasswordMinLength,
+				MaxLength:      dc.Auth.PasswordMaxLength,
+				RequireUpper:   dc.Auth.PasswordRequireUpper,
+				RequireLower:   dc.Auth.PasswordRequireLower,
+				RequireDigit:   dc.Auth.PasswordRequireDigit,
+				RequireSpecial: dc.Auth.PasswordRequireSpecial,
+				Blocklist:      mustLoadPasswordBlocklist(dc.Auth.PasswordBlocklistFile),
+				CheckPwned:     dc.Auth.CheckPwned,
+				PwnedAPIURL:    dc.Auth.PwnedAPIURL,
+				PwnedTimeout:   dc.Auth.PwnedTimeout,
+			},
 		},
 		Google: types.GoogleConfig{
 			ClientID:     dc.Google.ClientID,
 			ClientSecret: dc.Google.ClientSecret,
 			RedirectURL:  dc.Google.RedirectURL,
 		},
+		Microsoft: types.MicrosoftConfig{
+			ClientID:     dc.Microsoft.ClientID,
+			ClientSecret: dc.Microsoft.ClientSecret,
+			RedirectURL:  dc.Microsoft.RedirectURL,
+			Tenant:       dc.Microsoft.Tenant,
+		},
 		Database: types.DatabaseConfig{
 			Host:         dc.Database.Host,
 			Port:         dc.Database.Port,
@@ -195,11 +433,39 @@ func (dc *DomainConfigs) ToLegacyConfig() *Config {
 			MaxLifetime:  dc.Database.MaxLifetime,
 			ReadTimeout:  dc.Database.ReadTimeout,
 			WriteTimeout: dc.Database.WriteTimeout,
+
+			CircuitAlertWebhookURL: dc.Database.CircuitAlertWebhookURL,
+			CircuitAlertDebounce:   dc.Database.CircuitAlertDebounce,
+
+			MaxInsertEntries: dc.Database.MaxInsertEntries,
+
+			ConnWaitWarnThreshold: dc.Database.ConnWaitWarnThreshold,
+
+			StatementTimeout: dc.Database.StatementTimeout,
+		},
+		AuditDatabase: types.AuditDatabaseConfig{
+			Host:     dc.AuditDatabase.Host,
+			Port:     dc.AuditDatabase.Port,
+			User:     dc.AuditDatabase.User,
+			Password: dc.AuditDatabase.Password,
+			Name:     dc.AuditDatabase.Name,
+			MaxConns: dc.AuditDatabase.MaxConns,
+			MinConns: dc.AuditDatabase.MinConns,
 		},
 		Server: types.ServerConfig{
 			ReadTimeout:  dc.Server.ReadTimeout,
 			WriteTimeout: dc.Server.WriteTimeout,
 			IdleTimeout:  dc.Server.IdleTimeout,
+
+			MaxFilterConditions: dc.Server.MaxFilterConditions,
+			MaxRequestBodySize:  dc.Server.MaxRequestBodySize,
+
+			CompressionEnabled: dc.Server.CompressionEnabled,
+			CompressionLevel:   dc.Server.CompressionLevel,
+			CompressionMinSize: dc.Server.CompressionMinSize,
+
+			ResponseBudget:       dc.Server.ResponseBudget,
+			ResponseBudgetRoutes: mustParseResponseBudgetRoutes(dc.Server.ResponseBudgetRoutes),
 		},
 		Cache: types.CacheConfig{
 			Address:         dc.Cache.Address,
@@ -217,6 +483,9 @@ func (dc *DomainConfigs) ToLegacyConfig() *Config {
 			MaxRetries:      dc.Cache.MaxRetries,
 			MinRetryBackoff: dc.Cache.MinRetryBackoff,
 			MaxRetryBackoff: dc.Cache.MaxRetryBackoff,
+
+			SubmissionLockTTL:  dc.Cache.SubmissionLockTTL,
+			SubmissionLockWait: dc.Cache.SubmissionLockWait,
 		},
 		Cors: types.CorsConfig{
 			AllowOrigins:     dc.Cors.AllowOrigins,
@@ -224,6 +493,15 @@ func (dc *DomainConfigs) ToLegacyConfig() *Config {
 			AllowHeaders:     dc.Cors.AllowHeaders,
 			AllowCredentials: dc.Cors.AllowCredentials,
 		},
+		Cookie: types.CookieConfig{
+			Domain:   dc.Cookie.Domain,
+			Secure:   dc.Cookie.Secure,
+			SameSite: dc.Cookie.SameSite,
+			HTTPOnly: dc.Cookie.HTTPOnly,
+
+			CSRFEnabled:     dc.Cookie.CSRFEnabled,
+			CSRFExemptPaths: dc.Cookie.CSRFExemptPaths,
+		},
 		Audit: types.AuditConfig{
 			BatchSize:     dc.Audit.BatchSize,
 			ChannelSize:   dc.Audit.ChannelSize,
@@ -233,6 +511,17 @@ func (dc *DomainConfigs) ToLegacyConfig() *Config {
 			MaxRetries:    dc.Audit.MaxRetries,
 			RetentionDays: dc.Audit.RetentionDays,
 			RetryDelay:    dc.Audit.RetryDelay,
+			DLQSize:       dc.Audit.DLQSize,
+			DLQInterval:   dc.Audit.DLQInterval,
+
+			CleanupInterval: dc.Audit.CleanupInterval,
+			CleanupOffset:   dc.Audit.CleanupOffset,
+			CleanupTimezone: dc.Audit.CleanupTimezone,
+
+			ReadAccess: dc.Audit.ReadAccess,
+
+			OverflowPolicies: mustParseAuditOverflowPolicy(dc.Audit.OverflowPolicy),
+			OverflowTimeout:  dc.Audit.OverflowTimeout,
 		},
 		Health: types.HealthConfig{
 			BatchSize:      dc.Health.BatchSize,
@@ -245,6 +534,37 @@ func (dc *DomainConfigs) ToLegacyConfig() *Config {
 			RetentionDays:  dc.Health.RetentionDays,
 			Services:       dc.Health.Services,
 			RetryDelay:     dc.Health.RetryDelay,
+
+			ProbeFailureThreshold: dc.Health.ProbeFailureThreshold,
+		},
+		RateLimit: types.RateLimitConfig{
+			Enabled:     dc.RateLimit.Enabled,
+			Max:         dc.RateLimit.Max,
+			Window:      dc.RateLimit.Window,
+			ExemptCIDRs: dc.RateLimit.ExemptCIDRs,
+			ExemptKeys:  dc.RateLimit.ExemptKeys,
+
+			SoftThreshold:       dc.RateLimit.SoftThreshold,
+			SoftThresholdRoutes: mustParseSoftThresholdRoutes(dc.RateLimit.SoftThresholdRoutes),
+		},
+		Notification: types.NotificationConfig{
+			WebhookURL:    dc.Notification.WebhookURL,
+			RetryEnabled:  dc.Notification.RetryEnabled,
+			DLQSize:       dc.Notification.DLQSize,
+			RetryInterval: dc.Notification.RetryInterval,
+			MaxRetries:    dc.Notification.MaxRetries,
+			DLQPersistent: dc.Notification.DLQPersistent,
+		},
+		Webhook: types.WebhookConfig{
+			Enabled:        dc.Webhook.Enabled,
+			ChannelSize:    dc.Webhook.ChannelSize,
+			Timeout:        dc.Webhook.Timeout,
+			MaxAttempts:    dc.Webhook.MaxAttempts,
+			RetryBackoff:   dc.Webhook.RetryBackoff,
+			MaxConcurrency: dc.Webhook.MaxConcurrency,
+			DLQSize:        dc.Webhook.DLQSize,
+			RetryInterval:  dc.Webhook.RetryInterval,
+			DLQMaxRetries:  dc.Webhook.DLQMaxRetries,
 		},
 	}
 }
@@ -256,7 +576,11 @@ func loadAppConfig() *AppConfig {
 		Environment: getEnv("ENVIRONMENT", "development"),
 		Port:        getEnv("PORT", "8082"),
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
+		LogFormat:   getEnv("LOG_FORMAT", LogFormatText),
 		FrontendURL: getEnv("FRONTEND_URL", ""),
+		LogSource:   getEnvBool("LOG_SOURCE", true),
+
+		ReadOnlyMode: getEnvBool("READ_ONLY_MODE", false),
 	}
 }
 
@@ -266,8 +590,24 @@ func loadAuthConfig() *AuthConfig {
 		AccessTokenExpiry:  getEnvDuration("ACCESS_TOKEN_EXPIRY", 15*time.Minute),
 		RefreshTokenSecret: getEnv("REFRESH_TOKEN_SECRET", ""),
 		RefreshTokenExpiry: getEnvDuration("REFRESH_TOKEN_EXPIRY", 7*24*time.Hour),
-		CacheUserTTL:       getEnvDuration("CACHE_USER_TTL", 30*time.Minute),
+		CacheUserTTL:       getEnvDuration("CACHE_USER_TTL", 5*time.Minute),
 		BlacklistCacheTTL:  getEnvDuration("BLACKLIST_CACHE_TTL", 7*24*time.Hour),
+		PasswordPepper:     getEnv("AUTH_PASSWORD_PEPPER", ""),
+		RolePermissions:    getEnv("AUTH_ROLE_PERMISSIONS", DefaultRolePermissions),
+		SessionIdleTimeout: getEnvDuration("AUTH_SESSION_IDLE_TIMEOUT", 24*time.Hour),
+		SessionMaxLifetime: getEnvDuration("AUTH_SESSION_MAX_LIFETIME", 30*24*time.Hour),
+
+		PasswordMinLength:      getEnvInt("AUTH_PASSWORD_MIN_LENGTH", 8),
+		PasswordMaxLength:      getEnvInt("AUTH_PASSWORD_MAX_LENGTH", 128),
+		PasswordRequireUpper:   getEnvBool("AUTH_PASSWORD_REQUIRE_UPPER", true),
+		PasswordRequireLower:   getEnvBool("AUTH_PASSWORD_REQUIRE_LOWER", true),
+		PasswordRequireDigit:   getEnvBool("AUTH_PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSpecial: getEnvBool("AUTH_PASSWORD_REQUIRE_SPECIAL", true),
+		PasswordBlocklistFile:  getEnv("AUTH_PASSWORD_BLOCKLIST_FILE", ""),
+
+		CheckPwned:   getEnvBool("AUTH_CHECK_PWNED", false),
+		PwnedAPIURL:  getEnv("AUTH_PWNED_API_URL", DefaultPwnedAPIURL),
+		PwnedTimeout: getEnvDuration("AUTH_PWNED_TIMEOUT", 3*time.Second),
 	}
 }
 
@@ -284,7 +624,42 @@ func loadDatabaseConfig() *DatabaseConfig {
 		MaxLifetime:  getEnvDuration("DB_MAX_LIFETIME", 1*time.Hour),
 		ReadTimeout:  getEnvDuration("DB_READ_TIMEOUT", 30*time.Second),
 		WriteTimeout: getEnvDuration("DB_WRITE_TIMEOUT", 30*time.Second),
+
+		CircuitAlertWebhookURL: getEnv("DB_CIRCUIT_ALERT_WEBHOOK_URL", ""),
+		CircuitAlertDebounce:   getEnvDuration("DB_CIRCUIT_ALERT_DEBOUNCE", 5*time.Minute),
+
+		MaxInsertEntries: getEnvInt("DB_MAX_INSERT_ENTRIES", 1000),
+
+		ConnWaitWarnThreshold: getEnvDuration("DB_CONN_WAIT_WARN_THRESHOLD", 100*time.Millisecond),
+
+		StatementTimeout: getEnvDuration("DB_STATEMENT_TIMEOUT", 10*time.Second),
+	}
+}
+
+// loadAuditDatabaseConfig falls back to the primary database settings for everything but the host,
+// so pointing AUDIT_DB_HOST at another server of the same cluster is enough
+func loadAuditDatabaseConfig(primary *DatabaseConfig) *AuditDatabaseConfig {
+	adc := &AuditDatabaseConfig{
+		Host:     getEnv("AUDIT_DB_HOST", ""),
+		Port:     getEnvInt("AUDIT_DB_PORT", primary.Port),
+		User:     getEnv("AUDIT_DB_USER", ""),
+		Password: getEnv("AUDIT_DB_PASSWORD", ""),
+		Name:     getEnv("AUDIT_DB_NAME", ""),
+		MaxConns: getEnvInt("AUDIT_DB_MAX_CONNS", 5),
+		MinConns: getEnvInt("AUDIT_DB_MIN_CONNS", 1),
+	}
+
+	// Not passed to getEnv as defaults, which would log the primary password
+	if adc.User == "" {
+		adc.User = primary.User
+	}
+	if adc.Password == "" {
+		adc.Password = primary.Password
+	}
+	if adc.Name == "" {
+		adc.Name = primary.Name
 	}
+	return adc
 }
 
 func loadServerConfig() *ServerConfig {
@@ -292,6 +667,16 @@ func loadServerConfig() *ServerConfig {
 		ReadTimeout:  getEnvDuration("SERVER_READ_TIMEOUT", 30*time.Second),
 		WriteTimeout: getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
 		IdleTimeout:  getEnvDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
+
+		MaxFilterConditions: getEnvInt("SERVER_MAX_FILTER_CONDITIONS", 10),
+		MaxRequestBodySize:  getEnvInt("MAX_REQUEST_BODY_SIZE", 1<<20),
+
+		CompressionEnabled: getEnvBool("SERVER_COMPRESSION_ENABLED", true),
+		CompressionLevel:   getEnvInt("SERVER_COMPRESSION_LEVEL", 6),
+		CompressionMinSize: getEnvInt("SERVER_COMPRESSION_MIN_SIZE", 1024),
+
+		ResponseBudget:       getEnvDuration("SERVER_RESPONSE_BUDGET", 2*time.Second),
+		ResponseBudgetRoutes: getEnv("SERVER_RESPONSE_BUDGET_ROUTES", ""),
 	}
 }
 
@@ -312,6 +697,9 @@ func loadCacheConfig() *CacheConfig {
 		MaxRetries:      getEnvInt("CACHE_MAX_RETRIES", 3),
 		MinRetryBackoff: getEnvDuration("CACHE_MIN_RETRY_BACKOFF", 8*time.Millisecond),
 		MaxRetryBackoff: getEnvDuration("CACHE_MAX_RETRY_BACKOFF", 512*time.Millisecond),
+
+		SubmissionLockTTL:  getEnvDuration("CACHE_SUBMISSION_LOCK_TTL", 10*time.Second),
+		SubmissionLockWait: getEnvDuration("CACHE_SUBMISSION_LOCK_WAIT", 5*time.Second),
 	}
 }
 
@@ -319,11 +707,45 @@ func loadCorsConfig() *CorsConfig {
 	return &CorsConfig{
 		AllowOrigins:     getEnvSlice("CORS_ALLOW_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000"}),
 		AllowMethods:     getEnvSlice("CORS_ALLOW_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		AllowHeaders:     getEnvSlice("CORS_ALLOW_HEADERS", []string{"Origin", "Content-Type", "Accept", "Authorization"}),
+		AllowHeaders:     getEnvSlice("CORS_ALLOW_HEADERS", []string{"Origin", "Content-Type", "Accept", "Authorization", "X-CSRF-Token"}),
 		AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", true),
 	}
 }
 
+func loadCookieConfig(environment string) *CookieConfig {
+	// Local development runs over plain HTTP, production defaults to the strictest settings
+	production := environment == "production"
+	sameSite := "Lax"
+	if production {
+		sameSite = "Strict"
+	}
+
+	return &CookieConfig{
+		Domain:   getEnv("COOKIE_DOMAIN", ""),
+		Secure:   getEnvBool("COOKIE_SECURE", production),
+		SameSite: normalizeSameSite(getEnv("COOKIE_SAMESITE", sameSite)),
+		HTTPOnly: getEnvBool("COOKIE_HTTP_ONLY", true),
+
+		CSRFEnabled:     getEnvBool("CSRF_ENABLED", true),
+		CSRFExemptPaths: getEnvSlice("CSRF_EXEMPT_PATHS", []string{"/auth/login", "/auth/register", "/auth/refresh"}),
+	}
+}
+
+// sameSiteModes are the accepted COOKIE_SAMESITE values
+var sameSiteModes = []string{"Lax", "Strict", "None"}
+
+// normalizeSameSite returns the canonical spelling of a SameSite mode, e.g. "strict" becomes "Strict".
+// Unknown values are returned unchanged so validation can report them.
+func normalizeSameSite(value string) string {
+	value = strings.TrimSpace(value)
+	for _, mode := range sameSiteModes {
+		if strings.EqualFold(value, mode) {
+			return mode
+		}
+	}
+	return value
+}
+
 func loadAuditConfig() *AuditConfig {
 	return &AuditConfig{
 		BatchSize:     getEnvInt("AUDIT_BATCH_SIZE", 50),
@@ -334,6 +756,17 @@ func loadAuditConfig() *AuditConfig {
 		MaxRetries:    getEnvInt("AUDIT_MAX_RETRIES", 3),
 		RetentionDays: getEnvInt("AUDIT_RETENTION_DAYS", 90),
 		RetryDelay:    getEnvDuration("AUDIT_RETRY_DELAY", 3*time.Second),
+		DLQSize:       getEnvInt("AUDIT_DLQ_SIZE", 10000),
+		DLQInterval:   getEnvDuration("AUDIT_DLQ_RETRY_INTERVAL", 5*time.Minute),
+
+		CleanupInterval: getEnvDuration("AUDIT_CLEANUP_INTERVAL", 24*time.Hour),
+		CleanupOffset:   getEnvDuration("AUDIT_CLEANUP_OFFSET", 0),
+		CleanupTimezone: getEnv("AUDIT_CLEANUP_TIMEZONE", "Local"),
+
+		ReadAccess: getEnvBool("AUDIT_READ_ACCESS", false),
+
+		OverflowPolicy:  getEnv("AUDIT_OVERFLOW_POLICY", "ERROR:block"),
+		OverflowTimeout: getEnvDuration("AUDIT_OVERFLOW_TIMEOUT", 100*time.Millisecond),
 	}
 }
 
@@ -348,6 +781,8 @@ func loadHealthConfig() *HealthConfig {
 		MaxRetries:     getEnvInt("HEALTH_MAX_RETRIES", 3),
 		RetentionDays:  getEnvInt("HEALTH_RETENTION_DAYS", 21),
 		RetryDelay:     getEnvDuration("HEALTH_RETRY_DELAY", 1*time.Minute),
+
+		ProbeFailureThreshold: getEnvInt("HEALTH_PROBE_FAILURE_THRESHOLD", 3),
 	}
 }
 
@@ -359,6 +794,53 @@ func loadGoogleConfig() *GoogleOAuthConfig {
 	}
 }
 
+func loadMicrosoftConfig() *MicrosoftOAuthConfig {
+	return &MicrosoftOAuthConfig{
+		ClientID:     getEnv("MICROSOFT_OAUTH_CLIENT_ID", ""),
+		ClientSecret: getEnv("MICROSOFT_OAUTH_CLIENT_SECRET", ""),
+		RedirectURL:  getEnv("MICROSOFT_OAUTH_REDIRECT_URL", ""),
+		Tenant:       getEnv("MICROSOFT_OAUTH_TENANT", "common"),
+	}
+}
+
+func loadRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		Enabled:     getEnvBool("RATE_LIMIT_ENABLED", true),
+		Max:         getEnvInt("RATE_LIMIT_MAX", 100),
+		Window:      getEnvDuration("RATE_LIMIT_WINDOW", 1*time.Minute),
+		ExemptCIDRs: getEnvSlice("RATE_LIMIT_EXEMPT_CIDRS", []string{}),
+		ExemptKeys:  getEnvSlice("RATE_LIMIT_EXEMPT_KEYS", []string{}),
+
+		SoftThreshold:       getEnvFloat("RATE_LIMIT_SOFT_THRESHOLD", 0.8),
+		SoftThresholdRoutes: getEnv("RATE_LIMIT_SOFT_THRESHOLD_ROUTES", ""),
+	}
+}
+
+func loadNotificationConfig() *NotificationConfig {
+	return &NotificationConfig{
+		WebhookURL:    getEnv("NOTIFICATION_WEBHOOK_URL", ""),
+		RetryEnabled:  getEnvBool("NOTIFICATION_RETRY_ENABLED", true),
+		DLQSize:       getEnvInt("NOTIFICATION_DLQ_SIZE", 1000),
+		RetryInterval: getEnvDuration("NOTIFICATION_RETRY_INTERVAL", 1*time.Minute),
+		MaxRetries:    getEnvInt("NOTIFICATION_MAX_RETRIES", 10),
+		DLQPersistent: getEnvBool("NOTIFICATION_DLQ_PERSISTENT", true),
+	}
+}
+
+func loadWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		Enabled:        getEnvBool("WEBHOOK_ENABLED", true),
+		ChannelSize:    getEnvInt("WEBHOOK_CHANNEL_SIZE", 1000),
+		Timeout:        getEnvDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+		MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 3),
+		RetryBackoff:   getEnvDuration("WEBHOOK_RETRY_BACKOFF", 500*time.Millisecond),
+		MaxConcurrency: getEnvInt("WEBHOOK_MAX_CONCURRENCY", 10),
+		DLQSize:        getEnvInt("WEBHOOK_DLQ_SIZE", 1000),
+		RetryInterval:  getEnvDuration("WEBHOOK_RETRY_INTERVAL", 1*time.Minute),
+		DLQMaxRetries:  getEnvInt("WEBHOOK_DLQ_MAX_RETRIES", 10),
+	}
+}
+
 // Domain-specific validation methods
 func (ac *AppConfig) Validate() error {
 	if ac.Name == "" {
@@ -370,10 +852,14 @@ func (ac *AppConfig) Validate() error {
 	if ac.Environment != "development" && ac.Environment != "production" && ac.Environment != "staging" {
 		return fmt.Errorf("ENVIRONMENT must be one of: development, production, staging")
 	}
+	if ac.LogFormat != LogFormatText && ac.LogFormat != LogFormatJSON {
+		return fmt.Errorf("LOG_FORMAT must be one of: %s, %s", LogFormatText, LogFormatJSON)
+	}
 	return nil
 }
 
-func (ac *AuthConfig) Validate() error {
+// Validate checks the auth settings, requiring longer secrets when environment is production
+func (ac *AuthConfig) Validate(environment string) error {
 	if ac.AccessTokenSecret == "" {
 		return fmt.Errorf("ACCESS_TOKEN_SECRET is required")
 	}
@@ -382,7 +868,7 @@ func (ac *AuthConfig) Validate() error {
 	}
 
 	// Environment-specific validation
-	if getEnv("ENVIRONMENT", "development") == "production" {
+	if environment == "production" {
 		if len(ac.AccessTokenSecret) < 32 {
 			return fmt.Errorf("ACCESS_TOKEN_SECRET must be at least 32 characters in production")
 		}
@@ -397,9 +883,145 @@ func (ac *AuthConfig) Validate() error {
 			return fmt.Errorf("REFRESH_TOKEN_SECRET must be at least 16 characters")
 		}
 	}
+	// Cached users are only invalidated on role changes and logout, a short TTL bounds other stale data
+	if ac.CacheUserTTL <= 0 {
+		return fmt.Errorf("CACHE_USER_TTL must be positive")
+	}
+	// The pepper is optional, but a short one adds little against an offline attack
+	if ac.PasswordPepper != "" && len(ac.PasswordPepper) < 16 {
+		return fmt.Errorf("AUTH_PASSWORD_PEPPER must be at least 16 characters when set")
+	}
+	if _, err := parseRolePermissions(ac.RolePermissions); err != nil {
+		return fmt.Errorf("AUTH_ROLE_PERMISSIONS is invalid: %w", err)
+	}
+	if ac.SessionIdleTimeout <= 0 {
+		return fmt.Errorf("AUTH_SESSION_IDLE_TIMEOUT must be positive")
+	}
+	if ac.SessionMaxLifetime < ac.SessionIdleTimeout {
+		return fmt.Errorf("AUTH_SESSION_MAX_LIFETIME cannot be shorter than AUTH_SESSION_IDLE_TIMEOUT")
+	}
+	if ac.PasswordMinLength < 1 {
+		return fmt.Errorf("AUTH_PASSWORD_MIN_LENGTH must be positive")
+	}
+	if ac.PasswordMaxLength < ac.PasswordMinLength {
+		return fmt.Errorf("AUTH_PASSWORD_MAX_LENGTH cannot be shorter than AUTH_PASSWORD_MIN_LENGTH")
+	}
+	if ac.PasswordMaxLength > MaxPasswordLength {
+		return fmt.Errorf("AUTH_PASSWORD_MAX_LENGTH cannot exceed %d", MaxPasswordLength)
+	}
+	if _, err := loadPasswordBlocklist(ac.PasswordBlocklistFile); err != nil {
+		return fmt.Errorf("AUTH_PASSWORD_BLOCKLIST_FILE is invalid: %w", err)
+	}
+	if ac.CheckPwned {
+		u, err := url.Parse(ac.PwnedAPIURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("AUTH_PWNED_API_URL must be an absolute http or https URL")
+		}
+		if ac.PwnedTimeout <= 0 {
+			return fmt.Errorf("AUTH_PWNED_TIMEOUT must be positive when AUTH_CHECK_PWNED is enabled")
+		}
+	}
 	return nil
 }
 
+// DefaultPwnedAPIURL is the range API of Have I Been Pwned, the hash prefix is appended to it
+const DefaultPwnedAPIURL = "https://api.pwnedpasswords.com/range/"
+
+// MaxPasswordLength is the highest AUTH_PASSWORD_MAX_LENGTH, longer passwords only make hashing more expensive
+const MaxPasswordLength = 1024
+
+// loadPasswordBlocklist reads a blocklist of one password per line into a set of lowercased passwords.
+// Blank lines and lines starting with # are skipped. An empty path returns an empty blocklist.
+func loadPasswordBlocklist(path string) (map[string]struct{}, error) {
+	blocklist := make(map[string]struct{})
+	if path == "" {
+		return blocklist, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	return blocklist, nil
+}
+
+// mustLoadPasswordBlocklist loads a blocklist that already passed AuthConfig.Validate
+func mustLoadPasswordBlocklist(path string) map[string]struct{} {
+	blocklist, err := loadPasswordBlocklist(path)
+	if err != nil {
+		panic(fmt.Sprintf("AUTH_PASSWORD_BLOCKLIST_FILE: %v", err))
+	}
+	return blocklist
+}
+
+// Permissions that AUTH_ROLE_PERMISSIONS may grant, checked through the lib.Perm* constants
+const (
+	PermSubmissionsGrade = "submissions:grade"
+	PermAuditRead        = "audit:read"
+	PermCleanupTrigger   = "cleanup:trigger"
+
+	// PermissionWildcard grants every permission to a role
+	PermissionWildcard = "*"
+)
+
+// knownPermissions are the values accepted in AUTH_ROLE_PERMISSIONS, so a typo fails at startup
+// instead of silently granting nothing
+var knownPermissions = []string{PermSubmissionsGrade, PermAuditRead, PermCleanupTrigger, PermissionWildcard}
+
+// DefaultRolePermissions grants admins everything and lets teachers grade submissions
+const DefaultRolePermissions = "admin=*;teacher=submissions:grade"
+
+// parseRolePermissions parses a semicolon separated list of role=perm,perm entries.
+// A role listed without permissions is known but granted nothing.
+func parseRolePermissions(value string) (map[string][]string, error) {
+	mapping := make(map[string][]string)
+	for entry := range strings.SplitSeq(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		role, perms, found := strings.Cut(entry, "=")
+		role = strings.ToLower(strings.TrimSpace(role))
+		if !found || role == "" {
+			return nil, fmt.Errorf("entry %q must have the form role=permission,permission", entry)
+		}
+		if _, exists := mapping[role]; exists {
+			return nil, fmt.Errorf("role %q is listed more than once", role)
+		}
+
+		granted := []string{}
+		for perm := range strings.SplitSeq(perms, ",") {
+			perm = strings.TrimSpace(perm)
+			if perm != "" && !slices.Contains(knownPermissions, perm) {
+				return nil, fmt.Errorf("role %q is granted unknown permission %q, known permissions are %s",
+					role, perm, strings.Join(knownPermissions, ", "))
+			}
+			if perm != "" && !slices.Contains(granted, perm) {
+				granted = append(granted, perm)
+			}
+		}
+		mapping[role] = granted
+	}
+	return mapping, nil
+}
+
+// mustParseRolePermissions parses a mapping that already passed AuthConfig.Validate
+func mustParseRolePermissions(value string) map[string][]string {
+	mapping, err := parseRolePermissions(value)
+	if err != nil {
+		panic(fmt.Sprintf("AUTH_ROLE_PERMISSIONS: %v", err))
+	}
+	return mapping
+}
+
 func (dc *DatabaseConfig) Validate() error {
 	if dc.Host == "" {
 		return fmt.Errorf("DB_HOST is required")
@@ -419,6 +1041,49 @@ func (dc *DatabaseConfig) Validate() error {
 	if dc.MinConns > dc.MaxConns {
 		return fmt.Errorf("DB_MIN_CONNS cannot be greater than DB_MAX_CONNS")
 	}
+	if dc.CircuitAlertDebounce < 0 {
+		return fmt.Errorf("DB_CIRCUIT_ALERT_DEBOUNCE cannot be negative")
+	}
+	if dc.MaxInsertEntries < 1 {
+		return fmt.Errorf("DB_MAX_INSERT_ENTRIES must be at least 1")
+	}
+	if dc.ConnWaitWarnThreshold < 0 {
+		return fmt.Errorf("DB_CONN_WAIT_WARN_THRESHOLD cannot be negative")
+	}
+	if dc.StatementTimeout < 0 {
+		return fmt.Errorf("DB_STATEMENT_TIMEOUT cannot be negative")
+	}
+	if dc.CircuitAlertWebhookURL != "" {
+		u, err := url.Parse(dc.CircuitAlertWebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("DB_CIRCUIT_ALERT_WEBHOOK_URL must be an absolute http or https URL")
+		}
+	}
+	return nil
+}
+
+func (adc *AuditDatabaseConfig) Validate() error {
+	if adc.Host == "" {
+		return nil // Logs go to the primary database
+	}
+	if adc.Port < 1 || adc.Port > 65535 {
+		return fmt.Errorf("AUDIT_DB_PORT must be between 1 and 65535")
+	}
+	if adc.User == "" {
+		return fmt.Errorf("AUDIT_DB_USER is required when AUDIT_DB_HOST is set")
+	}
+	if adc.Name == "" {
+		return fmt.Errorf("AUDIT_DB_NAME is required when AUDIT_DB_HOST is set")
+	}
+	if adc.MaxConns < 1 {
+		return fmt.Errorf("AUDIT_DB_MAX_CONNS must be at least 1")
+	}
+	if adc.MinConns < 0 {
+		return fmt.Errorf("AUDIT_DB_MIN_CONNS cannot be negative")
+	}
+	if adc.MinConns > adc.MaxConns {
+		return fmt.Errorf("AUDIT_DB_MIN_CONNS cannot be greater than AUDIT_DB_MAX_CONNS")
+	}
 	return nil
 }
 
@@ -432,9 +1097,72 @@ func (sc *ServerConfig) Validate() error {
 	if sc.IdleTimeout <= 0 {
 		return fmt.Errorf("SERVER_IDLE_TIMEOUT must be positive")
 	}
+	if sc.MaxFilterConditions < 1 {
+		return fmt.Errorf("SERVER_MAX_FILTER_CONDITIONS must be at least 1")
+	}
+	if sc.MaxRequestBodySize < 1 {
+		return fmt.Errorf("MAX_REQUEST_BODY_SIZE must be positive")
+	}
+	if sc.CompressionEnabled {
+		if sc.CompressionLevel < 1 || sc.CompressionLevel > 9 {
+			return fmt.Errorf("SERVER_COMPRESSION_LEVEL must be between 1 and 9")
+		}
+		if sc.CompressionMinSize < 0 {
+			return fmt.Errorf("SERVER_COMPRESSION_MIN_SIZE must not be negative")
+		}
+	}
+	if sc.ResponseBudget < 0 {
+		return fmt.Errorf("SERVER_RESPONSE_BUDGET must not be negative")
+	}
+	if _, err := parseResponseBudgetRoutes(sc.ResponseBudgetRoutes); err != nil {
+		return fmt.Errorf("SERVER_RESPONSE_BUDGET_ROUTES is invalid: %w", err)
+	}
 	return nil
 }
 
+// parseResponseBudgetRoutes parses a comma separated list of route=duration pairs.
+// A route is a registered path, optionally prefixed with a method, e.g. "GET /health/history/:service".
+func parseResponseBudgetRoutes(value string) (map[string]time.Duration, error) {
+	budgets := make(map[string]time.Duration)
+	for part := range strings.SplitSeq(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		route, budget, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("entry %q must have the form route=duration", part)
+		}
+
+		method, path, hasMethod := strings.Cut(strings.TrimSpace(route), " ")
+		if !hasMethod {
+			method, path = "", method
+		}
+		path = strings.TrimSpace(path)
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("route %q must start with /", route)
+		}
+
+		duration, err := time.ParseDuration(strings.TrimSpace(budget))
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("budget %q for route %q must be a positive duration", budget, route)
+		}
+
+		budgets[types.ResponseBudgetKey(method, path)] = duration
+	}
+	return budgets, nil
+}
+
+// mustParseResponseBudgetRoutes parses route budgets that already passed ServerConfig.Validate
+func mustParseResponseBudgetRoutes(value string) map[string]time.Duration {
+	budgets, err := parseResponseBudgetRoutes(value)
+	if err != nil {
+		panic(fmt.Sprintf("SERVER_RESPONSE_BUDGET_ROUTES: %v", err))
+	}
+	return budgets
+}
+
 func (cc *CacheConfig) Validate() error {
 	if cc.PoolSize < 1 {
 		return fmt.Errorf("CACHE_POOL_SIZE must be at least 1")
@@ -445,20 +1173,99 @@ func (cc *CacheConfig) Validate() error {
 	if cc.MaxIdleConns < cc.MinIdleConns {
 		return fmt.Errorf("CACHE_MAX_IDLE_CONNS cannot be less than CACHE_MIN_IDLE_CONNS")
 	}
+	if cc.SubmissionLockTTL <= 0 {
+		return fmt.Errorf("CACHE_SUBMISSION_LOCK_TTL must be positive")
+	}
+	if cc.SubmissionLockWait < 0 {
+		return fmt.Errorf("CACHE_SUBMISSION_LOCK_WAIT cannot be negative")
+	}
 	return nil
 }
 
-func (cc *CorsConfig) Validate() error {
+// Validate checks the CORS settings, applying the stricter production rules when environment is production
+func (cc *CorsConfig) Validate(environment string) error {
 	if len(cc.AllowOrigins) == 0 {
 		return fmt.Errorf("CORS_ALLOW_ORIGINS cannot be empty")
 	}
 	if len(cc.AllowMethods) == 0 {
 		return fmt.Errorf("CORS_ALLOW_METHODS cannot be empty")
 	}
+
+	production := environment == "production"
+	for _, origin := range cc.AllowOrigins {
+		if origin == "*" {
+			// Browsers refuse credentials for a literal wildcard, so the combination never works
+			if cc.AllowCredentials {
+				return fmt.Errorf("CORS_ALLOW_ORIGINS cannot contain * when CORS_ALLOW_CREDENTIALS is true")
+			}
+			continue
+		}
+		if err := validateCorsOrigin(origin); err != nil {
+			return err
+		}
+		// A subdomain wildcard would let any subdomain, including a compromised one, make credentialed requests
+		if production && cc.AllowCredentials && strings.Contains(origin, "*") {
+			return fmt.Errorf("CORS_ALLOW_ORIGINS cannot contain wildcard origin %q in production when CORS_ALLOW_CREDENTIALS is true", origin)
+		}
+	}
+	return nil
+}
+
+// Validate checks the cookie settings, applying the stricter production rules when environment is production
+func (cc *CookieConfig) Validate(environment string) error {
+	if !slices.Contains(sameSiteModes, cc.SameSite) {
+		return fmt.Errorf("COOKIE_SAMESITE must be one of: Lax, Strict, None")
+	}
+	// Browsers drop SameSite=None cookies that are not Secure
+	if cc.SameSite == "None" && !cc.Secure {
+		return fmt.Errorf("COOKIE_SECURE must be true when COOKIE_SAMESITE is None")
+	}
+	if strings.ContainsAny(cc.Domain, "/:; ") {
+		return fmt.Errorf("COOKIE_DOMAIN must be a host name such as example.com, got %q", cc.Domain)
+	}
+	for _, path := range cc.CSRFExemptPaths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("CSRF_EXEMPT_PATHS entry %q must start with /", path)
+		}
+	}
+
+	if environment == "production" {
+		if !cc.Secure {
+			return fmt.Errorf("COOKIE_SECURE must be true in production")
+		}
+		// Auth cookies readable from JavaScript would hand the session to any XSS payload
+		if !cc.HTTPOnly {
+			return fmt.Errorf("COOKIE_HTTP_ONLY must be true in production")
+		}
+		if cc.SameSite == "None" {
+			return fmt.Errorf("COOKIE_SAMESITE must be Lax or Strict in production")
+		}
+	}
+	return nil
+}
+
+// validateCorsOrigin checks that origin is an absolute http or https origin such as
+// https://app.example.com, optionally with a port or a *. subdomain wildcard, and nothing after the host
+func validateCorsOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("CORS_ALLOW_ORIGINS entry %q must be an absolute http or https URL", origin)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("CORS_ALLOW_ORIGINS entry %q must be an origin without path, query or credentials", origin)
+	}
+	if strings.Contains(u.Host, "*") && (!strings.HasPrefix(u.Host, "*.") || strings.Count(u.Host, "*") > 1) {
+		return fmt.Errorf("CORS_ALLOW_ORIGINS entry %q may only use a wildcard as the first subdomain, like https://*.example.com", origin)
+	}
 	return nil
 }
 
 func (ac *AuditConfig) Validate() error {
+	// The policy is parsed even when audit is disabled, since ToLegacyConfig always reads it
+	policies, err := parseAuditOverflowPolicy(ac.OverflowPolicy)
+	if err != nil {
+		return fmt.Errorf("AUDIT_OVERFLOW_POLICY is invalid: %w", err)
+	}
 	if ac.Enabled {
 		if ac.BatchSize <= 0 {
 			return fmt.Errorf("AUDIT_BATCH_SIZE must be positive when audit is enabled")
@@ -469,6 +1276,80 @@ func (ac *AuditConfig) Validate() error {
 		if ac.FlushTime <= 0 {
 			return fmt.Errorf("AUDIT_FLUSH_TIME must be positive when audit is enabled")
 		}
+		if ac.DLQSize <= 0 {
+			return fmt.Errorf("AUDIT_DLQ_SIZE must be positive when audit is enabled")
+		}
+		if ac.DLQInterval <= 0 {
+			return fmt.Errorf("AUDIT_DLQ_RETRY_INTERVAL must be positive when audit is enabled")
+		}
+		if ac.RetentionDays > 0 {
+			if err := ac.validateCleanupSchedule(); err != nil {
+				return err
+			}
+		}
+		if slices.Contains(slices.Collect(maps.Values(policies)), types.AuditOverflowBlock) && ac.OverflowTimeout <= 0 {
+			return fmt.Errorf("AUDIT_OVERFLOW_TIMEOUT must be positive when a level uses the block policy")
+		}
+	}
+	return nil
+}
+
+// parseAuditOverflowPolicy parses a comma separated list of LEVEL:policy pairs.
+// A policy without a level applies to every level that has no policy of its own.
+func parseAuditOverflowPolicy(value string) (map[string]types.AuditOverflowPolicy, error) {
+	policies := make(map[string]types.AuditOverflowPolicy)
+	for part := range strings.SplitSeq(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		level, policy, found := strings.Cut(part, ":")
+		if !found {
+			level, policy = types.AuditOverflowAllLevels, part
+		}
+		level = strings.ToUpper(strings.TrimSpace(level))
+		policy = strings.ToLower(strings.TrimSpace(policy))
+
+		switch level {
+		case "ERROR", "WARN", "INFO", types.AuditOverflowAllLevels:
+		default:
+			return nil, fmt.Errorf("unknown level %q", level)
+		}
+
+		switch p := types.AuditOverflowPolicy(policy); p {
+		case types.AuditOverflowDrop, types.AuditOverflowBlock:
+			policies[level] = p
+		default:
+			return nil, fmt.Errorf("unknown policy %q for level %s, expected drop or block", policy, level)
+		}
+	}
+	return policies, nil
+}
+
+// mustParseAuditOverflowPolicy parses an overflow policy that already passed AuditConfig.Validate
+func mustParseAuditOverflowPolicy(value string) map[string]types.AuditOverflowPolicy {
+	policies, err := parseAuditOverflowPolicy(value)
+	if err != nil {
+		panic(fmt.Sprintf("AUDIT_OVERFLOW_POLICY: %v", err))
+	}
+	return policies
+}
+
+// validateCleanupSchedule ensures the cleanup schedule repeats at the same times every day
+func (ac *AuditConfig) validateCleanupSchedule() error {
+	day := 24 * time.Hour
+	if ac.CleanupInterval <= 0 || ac.CleanupInterval > day {
+		return fmt.Errorf("AUDIT_CLEANUP_INTERVAL must be positive and at most 24h, got %s", ac.CleanupInterval)
+	}
+	if day%ac.CleanupInterval != 0 {
+		return fmt.Errorf("AUDIT_CLEANUP_INTERVAL must divide 24h evenly (e.g. 1h, 6h, 12h, 24h), got %s", ac.CleanupInterval)
+	}
+	if ac.CleanupOffset < 0 || ac.CleanupOffset >= ac.CleanupInterval {
+		return fmt.Errorf("AUDIT_CLEANUP_OFFSET must be at least 0 and less than AUDIT_CLEANUP_INTERVAL (%s), got %s", ac.CleanupInterval, ac.CleanupOffset)
+	}
+	if _, err := time.LoadLocation(ac.CleanupTimezone); err != nil {
+		return fmt.Errorf("AUDIT_CLEANUP_TIMEZONE is not a valid timezone: %w", err)
 	}
 	return nil
 }
@@ -487,6 +1368,9 @@ func (hc *HealthConfig) Validate() error {
 		if hc.ReportInterval <= 0 {
 			return fmt.Errorf("HEALTH_REPORT_INTERVAL must be positive when health monitoring is enabled")
 		}
+		if hc.ProbeFailureThreshold <= 0 {
+			return fmt.Errorf("HEALTH_PROBE_FAILURE_THRESHOLD must be positive when health monitoring is enabled")
+		}
 	}
 	return nil
 }
@@ -507,6 +1391,144 @@ func (gc *GoogleOAuthConfig) Validate() error {
 	return nil
 }
 
+func (mc *MicrosoftOAuthConfig) Validate() error {
+	// Only validate if any Microsoft OAuth field is set
+	if mc.ClientID != "" || mc.ClientSecret != "" || mc.RedirectURL != "" {
+		if mc.ClientID == "" {
+			return fmt.Errorf("MICROSOFT_OAUTH_CLIENT_ID is required when Microsoft OAuth is configured")
+		}
+		if mc.ClientSecret == "" {
+			return fmt.Errorf("MICROSOFT_OAUTH_CLIENT_SECRET is required when Microsoft OAuth is configured")
+		}
+		if mc.RedirectURL == "" {
+			return fmt.Errorf("MICROSOFT_OAUTH_REDIRECT_URL is required when Microsoft OAuth is configured")
+		}
+	}
+	return nil
+}
+
+func (rc *RateLimitConfig) Validate() error {
+	if rc.Enabled {
+		if rc.Max <= 0 {
+			return fmt.Errorf("RATE_LIMIT_MAX must be positive when rate limiting is enabled")
+		}
+		if rc.Window <= 0 {
+			return fmt.Errorf("RATE_LIMIT_WINDOW must be positive when rate limiting is enabled")
+		}
+	}
+	for _, cidr := range rc.ExemptCIDRs {
+		// Plain IP addresses are accepted as single-host ranges
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			if _, addrErr := netip.ParseAddr(cidr); addrErr != nil {
+				return fmt.Errorf("RATE_LIMIT_EXEMPT_CIDRS contains an invalid entry %q: %w", cidr, err)
+			}
+		}
+	}
+	for _, key := range rc.ExemptKeys {
+		if len(key) < 16 {
+			return fmt.Errorf("RATE_LIMIT_EXEMPT_KEYS entries must be at least 16 characters")
+		}
+	}
+	if rc.SoftThreshold < 0 || rc.SoftThreshold > 1 {
+		return fmt.Errorf("RATE_LIMIT_SOFT_THRESHOLD must be between 0 and 1")
+	}
+	if _, err := parseSoftThresholdRoutes(rc.SoftThresholdRoutes); err != nil {
+		return fmt.Errorf("RATE_LIMIT_SOFT_THRESHOLD_ROUTES is invalid: %w", err)
+	}
+	return nil
+}
+
+// parseSoftThresholdRoutes parses a comma separated list of path=fraction pairs.
+// The limiter runs before routing, so a path is a prefix of the request path such as /auth/login.
+func parseSoftThresholdRoutes(value string) (map[string]float64, error) {
+	thresholds := make(map[string]float64)
+	for part := range strings.SplitSeq(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		path, fraction, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("entry %q must have the form path=fraction", part)
+		}
+
+		path = strings.TrimSpace(path)
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("path %q must start with /", path)
+		}
+
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(fraction), 64)
+		if err != nil || threshold < 0 || threshold > 1 {
+			return nil, fmt.Errorf("threshold %q for path %q must be between 0 and 1", fraction, path)
+		}
+
+		thresholds[path] = threshold
+	}
+	return thresholds, nil
+}
+
+// mustParseSoftThresholdRoutes parses route thresholds that already passed RateLimitConfig.Validate
+func mustParseSoftThresholdRoutes(value string) map[string]float64 {
+	thresholds, err := parseSoftThresholdRoutes(value)
+	if err != nil {
+		panic(fmt.Sprintf("RATE_LIMIT_SOFT_THRESHOLD_ROUTES: %v", err))
+	}
+	return thresholds
+}
+
+func (nc *NotificationConfig) Validate() error {
+	if nc.WebhookURL != "" {
+		u, err := url.Parse(nc.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("NOTIFICATION_WEBHOOK_URL must be an absolute http or https URL")
+		}
+	}
+	if nc.RetryEnabled {
+		if nc.DLQSize <= 0 {
+			return fmt.Errorf("NOTIFICATION_DLQ_SIZE must be positive when notification retry is enabled")
+		}
+		if nc.RetryInterval <= 0 {
+			return fmt.Errorf("NOTIFICATION_RETRY_INTERVAL must be positive when notification retry is enabled")
+		}
+		if nc.MaxRetries <= 0 {
+			return fmt.Errorf("NOTIFICATION_MAX_RETRIES must be positive when notification retry is enabled")
+		}
+	}
+	return nil
+}
+
+func (wc *WebhookConfig) Validate() error {
+	if !wc.Enabled {
+		return nil
+	}
+	if wc.ChannelSize <= 0 {
+		return fmt.Errorf("WEBHOOK_CHANNEL_SIZE must be positive when webhooks are enabled")
+	}
+	if wc.Timeout <= 0 {
+		return fmt.Errorf("WEBHOOK_TIMEOUT must be positive when webhooks are enabled")
+	}
+	if wc.MaxAttempts <= 0 {
+		return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be positive when webhooks are enabled")
+	}
+	if wc.RetryBackoff < 0 {
+		return fmt.Errorf("WEBHOOK_RETRY_BACKOFF cannot be negative")
+	}
+	if wc.MaxConcurrency <= 0 {
+		return fmt.Errorf("WEBHOOK_MAX_CONCURRENCY must be positive when webhooks are enabled")
+	}
+	if wc.DLQSize <= 0 {
+		return fmt.Errorf("WEBHOOK_DLQ_SIZE must be positive when webhooks are enabled")
+	}
+	if wc.RetryInterval <= 0 {
+		return fmt.Errorf("WEBHOOK_RETRY_INTERVAL must be positive when webhooks are enabled")
+	}
+	if wc.DLQMaxRetries <= 0 {
+		return fmt.Errorf("WEBHOOK_DLQ_MAX_RETRIES must be positive when webhooks are enabled")
+	}
+	return nil
+}
+
 // Helper methods for domain configs
 func (ac *AppConfig) IsProduction() bool {
 	return ac.Environment == "production"
@@ -31,6 +31,7 @@ func SetupFiber() fiber.Config {
 		ReadTimeout:      cfg.Server.ReadTimeout,
 		WriteTimeout:     cfg.Server.WriteTimeout,
 		IdleTimeout:      cfg.Server.IdleTimeout,
+		BodyLimit:        cfg.Server.MaxRequestBodySize,
 		ErrorHandler:     setupErrorHandler(cfg),
 		DisableKeepalive: false,
 	}
@@ -47,6 +48,18 @@ func setupErrorHandler(cfg *Config) fiber.ErrorHandler {
 			code = e.Code
 		}
 
+		// Client errors Fiber raises itself get their own response instead of the generic error below
+		switch code {
+		case fiber.StatusMethodNotAllowed:
+			// Fiber has already set the Allow header with the methods of the route
+			return response.MethodNotAllowed(c, "")
+		case fiber.StatusRequestEntityTooLarge:
+			// Bodies over BodyLimit are rejected before routing, answer them like the body limit middleware does
+			return response.PayloadTooLarge(c, "")
+		case fiber.StatusUnsupportedMediaType:
+			return response.UnsupportedMediaType(c, "")
+		}
+
 		// In development, return detailed error information
 		if cfg.IsDevelopment() {
 			return response.InternalServerErrorWithDetails(c, err.Error(), map[string]any{
@@ -72,6 +85,9 @@ func setupErrorHandler(cfg *Config) fiber.ErrorHandler {
 			message = "Internal server error"
 		}
 
+		if code >= fiber.StatusInternalServerError {
+			return response.InternalServerErrorForEnvironment(c, cfg.Environment, message, err)
+		}
 		return response.InternalServerError(c, message)
 	}
 }
@@ -5,9 +5,13 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
+	"path"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -21,15 +25,68 @@ import (
 // specific to HTTP request logging and application-specific log formatting.
 type Logger struct {
 	*slog.Logger
+
+	// requestID is attached to audit entries written through this logger, see WithRequest
+	requestID string
+	// noSource leaves the source out of audit entries, set when LOG_SOURCE is disabled
+	noSource bool
+}
+
+const (
+	// RequestIDHeader is the header carrying the request ID between clients, proxies and the API
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDLocal is the fiber.Ctx locals key under which the request ID middleware stores the ID
+	RequestIDLocal = "request_id"
+)
+
+// Supported LOG_FORMAT values
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// RequestID returns the ID of the current request, or an empty string when the request ID middleware did not run
+func RequestID(c fiber.Ctx) string {
+	id, _ := c.Locals(RequestIDLocal).(string)
+	return id
+}
+
+// WithRequest returns a logger that adds the ID of the current request to every log line and audit entry.
+// The logger is returned unchanged when the request has no ID.
+func (l *Logger) WithRequest(c fiber.Ctx) *Logger {
+	id := RequestID(c)
+	if id == "" {
+		return l
+	}
+	return &Logger{Logger: l.With("request_id", id), requestID: id, noSource: l.noSource}
 }
 
-// SetupLogger creates and configures a new Logger instance based on the centralized configuration.
-// It sets up structured logging with appropriate log levels and custom formatting for timestamps.
+// Shared logger returned by SetupLogger
+var (
+	sharedLogger     *Logger
+	sharedLoggerOnce sync.Once
+)
+
+// SetupLogger returns the application logger for the centralized configuration.
+// The logger is built once on the first call and shared afterwards, so calling it from
+// constructors and handlers is cheap. The configuration is loaded once as well, so the
+// shared logger always reflects the configured log level and format.
 //
 // Returns a configured Logger instance ready for use throughout the application.
 func SetupLogger() *Logger {
+	// Resolve the config outside the Once, a panic for a config that is not loaded yet
+	// must not leave the shared logger unset for every later call
 	cfg := Get()
 
+	sharedLoggerOnce.Do(func() {
+		sharedLogger = NewLogger(cfg)
+	})
+	return sharedLogger
+}
+
+// NewLogger creates a new Logger for the given configuration with the configured log level
+// and format and a compact timestamp. Most code should use the shared SetupLogger instead.
+func NewLogger(cfg *Config) *Logger {
 	var level slog.Level
 	switch cfg.LogLevel {
 	case "debug":
@@ -54,16 +111,61 @@ func SetupLogger() *Logger {
 					Value: slog.StringValue(time.Now().Format("15:04:05")),
 				}
 			}
-			// Add app name to all log entries
+			// Render the source relative to the module so files with the same name stay apart
+			if a.Key == slog.SourceKey {
+				if src, ok := a.Value.Any().(*slog.Source); ok {
+					return slog.String(slog.SourceKey, FormatSource(src.File, src.Line))
+				}
+			}
 			return a
 		},
-		AddSource: true,
+		AddSource: cfg.LogSource,
 	}
 
-	handler := slog.NewTextHandler(os.Stdout, opts)
+	handler := NewLogHandler(os.Stdout, cfg.LogFormat, opts)
 	logger := slog.New(handler).With("app", cfg.AppName)
 
-	return &Logger{logger}
+	return &Logger{Logger: logger, noSource: !cfg.LogSource}
+}
+
+// moduleRoot is the directory of the Go module, source paths are rendered relative to it.
+// This file lives in <module>/config, which also holds when building with -trimpath.
+var moduleRoot = func() string {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return ""
+	}
+	return path.Dir(path.Dir(file))
+}()
+
+// FormatSource renders a source location relative to the module, e.g. services/auth_service.go:128.
+// Files outside the module keep their parent directory, e.g. fiber/app.go:42.
+func FormatSource(file string, line int) string {
+	if moduleRoot != "" {
+		if rel, ok := strings.CutPrefix(file, moduleRoot+"/"); ok {
+			return fmt.Sprintf("%s:%d", rel, line)
+		}
+	}
+	return fmt.Sprintf("%s/%s:%d", path.Base(path.Dir(file)), path.Base(file), line)
+}
+
+// CallerSource returns the formatted source of a caller, where skip 0 is the function calling CallerSource.
+// Returns an empty string when the caller cannot be determined.
+func CallerSource(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	return FormatSource(file, line)
+}
+
+// NewLogHandler creates the slog handler for the configured LOG_FORMAT.
+// "json" produces one JSON object per line for log aggregation, anything else falls back to text.
+func NewLogHandler(w io.Writer, format string, opts *slog.HandlerOptions) slog.Handler {
+	if format == LogFormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
 }
 
 // HTTPMiddleware returns a Fiber middleware handler for HTTP request logging.
@@ -116,6 +218,9 @@ func (l *Logger) HTTPMiddleware() fiber.Handler {
 			slog.Duration("duration", duration),
 			slog.String("ip", ip),
 		}
+		if id := RequestID(c); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
 
 		l.LogAttrs(context.TODO(), logLevel, message, attrs...)
 
@@ -195,6 +300,27 @@ func (l *Logger) ConfigLoaded() {
 	)
 }
 
+// StartupSummary logs a single structured entry listing which optional features are enabled.
+// Each feature is logged as a group so operators can filter on e.g. rate_limit.enabled.
+func (l *Logger) StartupSummary(cfg *Config) {
+	summary := cfg.FeatureSummary()
+
+	features := slices.Sorted(maps.Keys(summary))
+	attrs := make([]any, 0, len(features)+1)
+	attrs = append(attrs, slog.String("environment", cfg.Environment))
+	for _, feature := range features {
+		settings := summary[feature]
+		keys := slices.Sorted(maps.Keys(settings))
+		group := make([]any, 0, len(keys))
+		for _, key := range keys {
+			group = append(group, slog.Any(key, settings[key]))
+		}
+		attrs = append(attrs, slog.Group(feature, group...))
+	}
+
+	l.Info("Startup summary", attrs...)
+}
+
 // Shutdown logs application shutdown
 func (l *Logger) Shutdown(reason string) {
 	l.Info("Application shutting down",
@@ -220,32 +346,43 @@ func (l *Logger) Performance(operation string, duration time.Duration) {
 func (l *Logger) AuditError(message string, attrs ...any) {
 	// Log to standard logger first
 	l.Error(message, attrs...)
+	l.sendAuditLog("ERROR", message, attrs)
+}
 
-	// Create audit log entry with validation
-	auditAttrs := make(map[string]any)
+// AuditWarn logs warning messages to both the standard logger and the audit system
+func (l *Logger) AuditWarn(message string, attrs ...any) {
+	// Log to standard logger first
+	l.Warn(message, attrs...)
+	l.sendAuditLog("WARN", message, attrs)
+}
 
-	// Process attrs in pairs (key, value)
-	for i := 0; i < len(attrs)-1; i += 2 {
-		if key, ok := attrs[i].(string); ok && key != "" {
-			auditAttrs[key] = attrs[i+1]
-		}
-	}
+// AuditInfo logs informational events that must be kept for compliance, such as
+// reads of sensitive resources, to both the standard logger and the audit system
+func (l *Logger) AuditInfo(message string, attrs ...any) {
+	// Log to standard logger first
+	l.Info(message, attrs...)
+	l.sendAuditLog("INFO", message, attrs)
+}
+
+// sendAuditLog builds an audit log entry and hands it to the audit worker.
+// It must be called directly from one of the exported Audit* methods so the
+// recorded source points at their caller.
+func (l *Logger) sendAuditLog(level, message string, attrs []any) {
+	auditAttrs := auditAttrsFromArgs(attrs)
 
-	// Capture source information
+	// Capture source information of the Audit* caller
 	source := ""
-	if _, file, line, ok := runtime.Caller(1); ok {
-		if idx := strings.LastIndex(file, "/"); idx >= 0 {
-			file = file[idx+1:]
-		}
-		source = fmt.Sprintf("%s:%d", file, line)
+	if !l.noSource {
+		source = CallerSource(2)
 	}
 
 	auditLog := types.AuditLog{
 		Timestamp: time.Now(),
-		Level:     "ERROR",
+		Level:     level,
 		Message:   message,
 		Attrs:     auditAttrs,
 		Source:    source,
+		RequestID: l.requestID,
 	}
 
 	entryHash := generateEntryHash(auditLog)
@@ -258,45 +395,52 @@ func (l *Logger) AuditError(message string, attrs ...any) {
 	}
 }
 
-func (l *Logger) AuditWarn(message string, attrs ...any) {
-	// Log to standard logger first
-	l.Warn(message, attrs...)
-
-	// Create audit log entry with validation
-	auditAttrs := make(map[string]any)
+// Placeholders used by auditAttrsFromArgs for malformed attribute lists, mirroring slog
+const (
+	auditMissingValue = "!MISSING"
+	auditBadKey       = "!BADKEY"
+)
 
-	// Process attrs in pairs (key, value)
-	for i := 0; i < len(attrs)-1; i += 2 {
-		if key, ok := attrs[i].(string); ok && key != "" {
-			auditAttrs[key] = attrs[i+1]
+// auditAttrsFromArgs converts the alternating key/value arguments of the Audit* methods to a map,
+// pairing them the same way slog does so the audit log matches the standard log line:
+//   - a string followed by a value is a key/value pair
+//   - a slog.Attr is used as is
+//   - a dangling string key at the end is kept with the auditMissingValue placeholder
+//   - any other argument is stored under auditBadKey instead of shifting the remaining pairs
+func auditAttrsFromArgs(args []any) map[string]any {
+	attrs := make(map[string]any, len(args)/2)
+
+	badKeys := 0
+	addBadKey := func(value any) {
+		key := auditBadKey
+		if badKeys > 0 {
+			key = fmt.Sprintf("%s%d", auditBadKey, badKeys)
 		}
+		badKeys++
+		attrs[key] = value
 	}
 
-	// Capture source information
-	source := ""
-	if _, file, line, ok := runtime.Caller(1); ok {
-		if idx := strings.LastIndex(file, "/"); idx >= 0 {
-			file = file[idx+1:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i].(type) {
+		case slog.Attr:
+			attrs[arg.Key] = arg.Value.Any()
+		case string:
+			if i == len(args)-1 {
+				attrs[arg] = auditMissingValue
+				continue
+			}
+			if arg == "" {
+				addBadKey(args[i+1])
+			} else {
+				attrs[arg] = args[i+1]
+			}
+			i++
+		default:
+			addBadKey(arg)
 		}
-		source = fmt.Sprintf("%s:%d", file, line)
 	}
 
-	auditLog := types.AuditLog{
-		Timestamp: time.Now(),
-		Level:     "WARN",
-		Message:   message,
-		Attrs:     auditAttrs,
-		Source:    source,
-	}
-
-	entryHash := generateEntryHash(auditLog)
-	auditLog.EntryHash = entryHash
-
-	// Send to audit worker (non-blocking)
-	addAuditLogFunc := getAddAuditLogFunc()
-	if addAuditLogFunc != nil {
-		addAuditLogFunc(auditLog)
-	}
+	return attrs
 }
 
 // getAddAuditLogFunc returns the AddAuditLog function to avoid circular imports
@@ -348,6 +492,9 @@ func generateEntryHash(entry types.AuditLog) string {
 		"message":   entry.Message,
 		"attrs":     attrs,
 	}
+	if entry.RequestID != "" {
+		data["request_id"] = entry.RequestID
+	}
 
 	// Convert to JSON for consistent hashing
 	jsonData, err := json.Marshal(data)
@@ -3,11 +3,16 @@ package database
 import (
 	"context"
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/types"
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 )
 
 // DB executes database operations based on QueryParams and returns typed results.
@@ -18,12 +23,22 @@ import (
 //	result, err := ExecuteQuery[User](query.NewQuery().SetOperation("select").AddWhere("id", 1))
 //	result, err := ExecuteQuery[User](query.NewQuery().SetOperation("insert").AddData("name", "John"))
 //	result, err := ExecuteQuery[Product](query.NewQuery().SetRawSQL("SELECT * FROM products WHERE price > ?", 100))
+//
+// Queries with a transaction set through WithTx run on that transaction instead of the shared connection pool.
 func ExecuteQuery[T any](query *types.QueryParams) (*types.QueryResult[T], error) {
 	start := time.Now()
 	result := &types.QueryResult[T]{
 		Success: false,
 	}
 
+	// Bulk inserts without their own cap use the configured DB_MAX_INSERT_ENTRIES,
+	// set on a copy so the caller's query keeps its own value
+	if len(query.Entries) > 0 && query.MaxEntries == 0 {
+		capped := *query
+		capped.MaxEntries = config.Get().Database.MaxInsertEntries
+		query = &capped
+	}
+
 	// Validate the query
 	if err := query.Validate(); err != nil {
 		result.Error = err
@@ -31,26 +46,51 @@ func ExecuteQuery[T any](query *types.QueryParams) (*types.QueryResult[T], error
 		return result, err
 	}
 
-	// Get database instance
-	db := GetInstance()
-	if db == nil {
-		err := fmt.Errorf("database instance not initialized")
+	// Table and column names are interpolated into the SQL, so they must be plain identifiers
+	if err := validateIdentifiers(query); err != nil {
 		result.Error = err
 		result.ExecutionTime = time.Since(start)
 		return result, err
 	}
 
+	// Run on the caller's transaction if there is one, then on the requested database,
+	// otherwise on the primary database instance
+	var db orm.DB
+	if query.Tx != nil {
+		db = query.Tx
+	} else if query.DB != nil {
+		db = query.DB
+	} else {
+		instance := GetInstance()
+		if instance == nil {
+			err := fmt.Errorf("database instance not initialized")
+			result.Error = err
+			result.ExecutionTime = time.Since(start)
+			return result, err
+		}
+		db = instance
+	}
+
 	// Set up context
 	ctx := query.Context
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	// Apply timeout if specified
-	if query.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, query.Timeout)
-		defer cancel()
+	// Apply the query's own timeout, or DB_STATEMENT_TIMEOUT when it has none, so a slow
+	// query cannot hold a connection forever
+	ctx, cancel := withStatementTimeout(ctx, query.Timeout)
+	defer cancel()
+
+	// Queries on the primary pool wait for a free connection slot, a transaction already holds its connection
+	if query.Tx == nil && query.DB == nil {
+		release, err := acquireConn(ctx)
+		if err != nil {
+			result.Error = err
+			result.ExecutionTime = time.Since(start)
+			return result, err
+		}
+		defer release()
 	}
 
 	// Execute operation based on type
@@ -80,8 +120,33 @@ func ExecuteQuery[T any](query *types.QueryParams) (*types.QueryResult[T], error
 	return result, err
 }
 
+// withStatementTimeout applies timeout to ctx, or DB_STATEMENT_TIMEOUT when timeout is not positive.
+// The returned cancel function must always be called.
+func withStatementTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = config.Get().Database.StatementTimeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// acquireConn waits for a free connection slot of the primary database and returns the function
+// that frees it again. Before Initialize sets up the guard there is nothing to wait for.
+func acquireConn(ctx context.Context) (func(), error) {
+	if connGuard == nil {
+		return func() {}, nil
+	}
+	release, err := connGuard.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for a database connection: %w", err)
+	}
+	return release, nil
+}
+
 // executeSelect handles SELECT operations
-func executeSelect[T any](ctx context.Context, db *DB, query *types.QueryParams, result *types.QueryResult[T]) error {
+func executeSelect[T any](ctx context.Context, db orm.DB, query *types.QueryParams, result *types.QueryResult[T]) error {
 	var data []T
 	var single T
 
@@ -96,6 +161,12 @@ func executeSelect[T any](ctx context.Context, db *DB, query *types.QueryParams,
 	// Apply SELECT columns with table prefix to avoid ambiguity
 	if len(query.Select) > 0 {
 		for _, col := range query.Select {
+			// Aliased columns and expressions such as "s.name AS subject__name" are used as is
+			if strings.ContainsAny(col, " (") {
+				pgQuery = pgQuery.ColumnExpr(col)
+				continue
+			}
+
 			// Automatically prefix columns with table name if not already prefixed
 			if query.Table != "" && !strings.Contains(col, ".") {
 				// Use table-qualified column name to avoid ambiguity
@@ -141,8 +212,15 @@ func executeSelect[T any](ctx context.Context, db *DB, query *types.QueryParams,
 		pgQuery = pgQuery.Offset(query.Offset)
 	}
 
-	// Use DISTINCT
-	pgQuery = pgQuery.Distinct()
+	// Lock the selected rows, PostgreSQL does not allow DISTINCT together with FOR UPDATE
+	if query.ForUpdate {
+		pgQuery = pgQuery.For("UPDATE")
+	} else {
+		pgQuery = pgQuery.Distinct()
+	}
+
+	// Store query for debugging
+	result.Query = orm.NewSelectQuery(pgQuery).String()
 
 	// Execute query
 	err := pgQuery.Select()
@@ -165,6 +243,9 @@ func executeSelect[T any](ctx context.Context, db *DB, query *types.QueryParams,
 
 		// Apply same conditions
 		singleQuery = applyWhereConditions(singleQuery, query)
+		if query.ForUpdate {
+			singleQuery = singleQuery.For("UPDATE")
+		}
 
 		err = singleQuery.First()
 		if err != nil && err != pg.ErrNoRows {
@@ -182,54 +263,29 @@ func executeSelect[T any](ctx context.Context, db *DB, query *types.QueryParams,
 }
 
 // executeInsert handles INSERT operations for both single and bulk inserts
-func executeInsert[T any](ctx context.Context, db *DB, query *types.QueryParams, result *types.QueryResult[T]) error {
+func executeInsert[T any](ctx context.Context, db orm.DB, query *types.QueryParams, result *types.QueryResult[T]) error {
 	if query.Table == "" {
 		return fmt.Errorf("table name is required for insert operation")
 	}
 
-	var sql string
-	var values []any
-
-	// Handle bulk insert if Entries are provided
-	if len(query.Entries) > 0 {
-		// Extract columns from all entries
-		columns, err := ExtractColumnsFromEntries(query.Entries)
-		if err != nil {
-			return fmt.Errorf("failed to extract columns from entries: %w", err)
-		}
-
-		// Build bulk insert SQL
-		sql, values, err = BuildBulkInsertSQL(query.Table, query.Entries, columns, query.Returning, query.OnConflict)
-		if err != nil {
-			return fmt.Errorf("failed to build bulk insert SQL: %w", err)
-		}
-	} else {
-		// Handle single insert with Data field
-		var columns []string
-		for key, value := range query.Data {
-			columns = append(columns, key)
-			values = append(values, value)
-		}
-
-		if len(columns) == 0 {
+	// A single insert is a bulk insert of one entry, so both build the same statement
+	// and scan the RETURNING rows the same way
+	entries := query.Entries
+	if len(entries) == 0 {
+		if len(query.Data) == 0 {
 			return fmt.Errorf("no data provided for insert")
 		}
+		entries = []any{query.Data}
+	}
 
-		// Build the SQL for single insert
-		sql = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-			query.Table,
-			joinStrings(columns, ", "),
-			buildPlaceholders(len(values)))
-
-		// Add RETURNING clause if specified
-		if len(query.Returning) > 0 {
-			sql += " RETURNING " + joinStrings(query.Returning, ", ")
-		}
+	columns, err := ExtractColumnsFromEntries(entries)
+	if err != nil {
+		return fmt.Errorf("failed to extract columns from entries: %w", err)
+	}
 
-		// Add ON CONFLICT clause if specified
-		if query.OnConflict != "" {
-			sql += " ON CONFLICT " + query.OnConflict
-		}
+	sql, values, err := BuildBulkInsertSQL(query.Table, entries, columns, query.Returning, query.OnConflict)
+	if err != nil {
+		return fmt.Errorf("failed to build insert SQL: %w", err)
 	}
 
 	// Store query for debugging
@@ -238,7 +294,7 @@ func executeInsert[T any](ctx context.Context, db *DB, query *types.QueryParams,
 
 	// Execute the query
 	if len(query.Returning) > 0 {
-		// If RETURNING is specified, we expect data back
+		// If RETURNING is specified, we expect one row back for every inserted entry
 		var returnedData []T
 		_, err := db.QueryContext(ctx, &returnedData, sql, values...)
 		if err != nil {
@@ -262,7 +318,7 @@ func executeInsert[T any](ctx context.Context, db *DB, query *types.QueryParams,
 }
 
 // executeUpdate handles UPDATE operations
-func executeUpdate[T any](ctx context.Context, db *DB, query *types.QueryParams, result *types.QueryResult[T]) error {
+func executeUpdate[T any](ctx context.Context, db orm.DB, query *types.QueryParams, result *types.QueryResult[T]) error {
 	var data T
 
 	// Build the query
@@ -300,7 +356,7 @@ func executeUpdate[T any](ctx context.Context, db *DB, query *types.QueryParams,
 }
 
 // executeDelete handles DELETE operations
-func executeDelete[T any](ctx context.Context, db *DB, query *types.QueryParams, result *types.QueryResult[T]) error {
+func executeDelete[T any](ctx context.Context, db orm.DB, query *types.QueryParams, result *types.QueryResult[T]) error {
 	var data T
 
 	// Build the query
@@ -333,7 +389,7 @@ func executeDelete[T any](ctx context.Context, db *DB, query *types.QueryParams,
 }
 
 // executeRaw handles raw SQL operations
-func executeRaw[T any](ctx context.Context, db *DB, query *types.QueryParams, result *types.QueryResult[T]) error {
+func executeRaw[T any](ctx context.Context, db orm.DB, query *types.QueryParams, result *types.QueryResult[T]) error {
 	var data []T
 
 	// Store the actual query for debugging
@@ -361,15 +417,20 @@ func executeRaw[T any](ctx context.Context, db *DB, query *types.QueryParams, re
 func applyWhereConditions(pgQuery *pg.Query, query *types.QueryParams) *pg.Query {
 	// Apply simple WHERE conditions
 	for key, value := range query.Where {
-		// Handle table-prefixed columns (e.g., "public.users.id" or "users.id")
-		// to avoid ambiguous column reference errors in JOINs
-		if strings.Contains(key, ".") {
-			// Key already contains table prefix, use as raw SQL identifier
-			pgQuery = pgQuery.Where(fmt.Sprintf("%s = ?", key), value)
-		} else {
-			// No prefix, use pg.Ident for proper escaping
-			pgQuery = pgQuery.Where("? = ?", pg.Ident(key), value)
-		}
+		condition, args := whereCondition(key, value)
+		pgQuery = pgQuery.Where(condition, args...)
+	}
+
+	// Apply OR groups, keys are sorted so the same query always produces the same SQL
+	for _, group := range query.WhereAny {
+		keys := slices.Sorted(maps.Keys(group))
+		pgQuery = pgQuery.WhereGroup(func(q *pg.Query) (*pg.Query, error) {
+			for _, key := range keys {
+				condition, args := whereCondition(key, group[key])
+				q = q.WhereOr(condition, args...)
+			}
+			return q, nil
+		})
 	}
 
 	// Apply raw WHERE conditions
@@ -380,13 +441,36 @@ func applyWhereConditions(pgQuery *pg.Query, query *types.QueryParams) *pg.Query
 	return pgQuery
 }
 
-// Transaction executes multiple operations in a single transaction
+// whereCondition turns a Where key and its value into a condition and its arguments
+func whereCondition(key string, value any) (string, []any) {
+	column, operator := types.ParseWhereKey(key)
+
+	// Handle table-prefixed columns (e.g., "public.users.id" or "users.id")
+	// to avoid ambiguous column reference errors in JOINs
+	if strings.Contains(column, ".") {
+		// Key already contains table prefix, used as is after validateIdentifiers checked it
+		return fmt.Sprintf("%s %s ?", column, operator), []any{value}
+	}
+
+	// No prefix, use pg.Ident for proper escaping
+	return fmt.Sprintf("? %s ?", operator), []any{pg.Ident(column), value}
+}
+
+// Transaction executes multiple operations in a single transaction.
+// Builder queries join the transaction through QueryParams.WithTx.
+// The transaction holds a connection slot from begin to commit or rollback.
 func Transaction(ctx context.Context, operations ...func(*pg.Tx) error) error {
 	db := GetInstance()
 	if db == nil {
 		return fmt.Errorf("database instance not initialized")
 	}
 
+	release, err := acquireConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	return db.RunInTransaction(ctx, func(tx *pg.Tx) error {
 		for _, operation := range operations {
 			if err := operation(tx); err != nil {
@@ -446,6 +530,38 @@ func Update[T any](table string, data map[string]any, where map[string]any) (*ty
 	return ExecuteQuery[T](query)
 }
 
+// BulkUpdate updates many rows of a table to different values in a single statement.
+// updates maps the key column value of each row to the columns to set on it; a column that is
+// not set for a row keeps its current value. It returns the number of updated rows.
+// Like ExecuteQuery it runs under DB_STATEMENT_TIMEOUT and waits for a free connection slot.
+func BulkUpdate[T any](ctx context.Context, table, keyColumn string, updates map[any]map[string]any) (int64, error) {
+	sql, args, err := BuildBulkUpdateSQL(table, keyColumn, updates)
+	if err != nil {
+		return 0, err
+	}
+
+	db := GetInstance()
+	if db == nil {
+		return 0, fmt.Errorf("database instance not initialized")
+	}
+
+	ctx, cancel := withStatementTimeout(ctx, 0)
+	defer cancel()
+
+	release, err := acquireConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	res, err := db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute bulk update query: %w", err)
+	}
+
+	return int64(res.RowsAffected()), nil
+}
+
 // Delete executes a DELETE query
 func Delete[T any](table string, where map[string]any) (*types.QueryResult[T], error) {
 	query := types.NewQuery().
@@ -507,6 +623,19 @@ func BuildBulkInsertSQL(table string, entries []any, columns []string, returning
 	if table == "" {
 		return "", nil, fmt.Errorf("table name is required for bulk insert")
 	}
+	if !validIdentifier(table) {
+		return "", nil, fmt.Errorf("%w: table %q", types.ErrInvalidIdentifier, table)
+	}
+	for _, col := range columns {
+		if !validIdentifier(col) {
+			return "", nil, fmt.Errorf("%w: column %q", types.ErrInvalidIdentifier, col)
+		}
+	}
+	for _, col := range returning {
+		if !validColumnExpression(col) {
+			return "", nil, fmt.Errorf("%w: returning column %q", types.ErrInvalidIdentifier, col)
+		}
+	}
 
 	// Build the base INSERT statement
 	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES ",
@@ -542,17 +671,276 @@ func BuildBulkInsertSQL(table string, entries []any, columns []string, returning
 	// Combine all value rows
 	sql += joinStrings(valueRows, ", ")
 
+	// Add ON CONFLICT clause if specified, it must precede RETURNING
+	if onConflict != "" {
+		sql += " ON CONFLICT " + onConflict
+	}
+
 	// Add RETURNING clause if specified
 	if len(returning) > 0 {
 		sql += " RETURNING " + joinStrings(returning, ", ")
 	}
 
-	// Add ON CONFLICT clause if specified
-	if onConflict != "" {
-		sql += " ON CONFLICT " + onConflict
+	return sql, allValues, nil
+}
+
+// BuildBulkUpdateSQL builds a single UPDATE that sets every column with a CASE on the key column:
+//
+//	UPDATE "t" SET "c" = CASE "k" WHEN ? THEN ? ELSE "c" END WHERE "k" IN (?)
+//
+// Identifiers are quoted, values are passed as arguments. Rows and columns are ordered
+// so the same updates always produce the same statement.
+func BuildBulkUpdateSQL(table, keyColumn string, updates map[any]map[string]any) (string, []any, error) {
+	if len(updates) == 0 {
+		return "", nil, fmt.Errorf("no updates provided for bulk update")
+	}
+	quotedTable, err := quoteIdentifier(table)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid table name for bulk update: %w", err)
+	}
+	quotedKey, err := quoteIdentifier(keyColumn)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid key column for bulk update: %w", err)
 	}
 
-	return sql, allValues, nil
+	keys := make([]any, 0, len(updates))
+	columnSet := make(map[string]bool)
+	for key, columns := range updates {
+		if key == nil {
+			return "", nil, fmt.Errorf("bulk update key must not be nil")
+		}
+		if len(columns) == 0 {
+			return "", nil, fmt.Errorf("no columns provided for key %v", key)
+		}
+		keys = append(keys, key)
+		for column := range columns {
+			columnSet[column] = true
+		}
+	}
+	slices.SortFunc(keys, func(a, b any) int {
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	})
+	columns := slices.Sorted(maps.Keys(columnSet))
+
+	var args []any
+	setClauses := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quotedColumn, err := quoteIdentifier(column)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid column for bulk update: %w", err)
+		}
+
+		var clause strings.Builder
+		fmt.Fprintf(&clause, "%s = CASE %s", quotedColumn, quotedKey)
+		for _, key := range keys {
+			value, ok := updates[key][column]
+			if !ok {
+				continue
+			}
+			clause.WriteString(" WHEN ? THEN ?")
+			args = append(args, key, value)
+		}
+		fmt.Fprintf(&clause, " ELSE %s END", quotedColumn)
+		setClauses = append(setClauses, clause.String())
+	}
+
+	args = append(args, keys...)
+	sql := fmt.Sprintf("UPDATE %s SET %s WHERE %s IN (%s)",
+		quotedTable,
+		joinStrings(setClauses, ", "),
+		quotedKey,
+		buildPlaceholders(len(keys)))
+
+	return sql, args, nil
+}
+
+// validateIdentifiers checks the table, column, where and returning names of a query with validIdentifier.
+// Select and returning columns may also be aliased expressions, see validColumnExpression, and order
+// clauses may add a direction, see validOrderClause.
+// Bulk insert entries are checked when their statement is built.
+func validateIdentifiers(query *types.QueryParams) error {
+	if query.Table != "" && !validIdentifier(query.Table) {
+		return fmt.Errorf("%w: table %q", types.ErrInvalidIdentifier, query.Table)
+	}
+	for _, col := range query.Select {
+		if !validColumnExpression(col) {
+			return fmt.Errorf("%w: select column %q", types.ErrInvalidIdentifier, col)
+		}
+	}
+	for _, col := range query.GroupBy {
+		if !validIdentifier(col) {
+			return fmt.Errorf("%w: group by column %q", types.ErrInvalidIdentifier, col)
+		}
+	}
+	for _, col := range query.Returning {
+		if !validColumnExpression(col) {
+			return fmt.Errorf("%w: returning column %q", types.ErrInvalidIdentifier, col)
+		}
+	}
+	for _, order := range query.Order {
+		if !validOrderClause(order) {
+			return fmt.Errorf("%w: order %q", types.ErrInvalidIdentifier, order)
+		}
+	}
+	// Where keys with a table prefix are interpolated into the condition, see whereCondition
+	for key := range query.Where {
+		if column, _ := types.ParseWhereKey(key); !validIdentifier(column) {
+			return fmt.Errorf("%w: where column %q", types.ErrInvalidIdentifier, key)
+		}
+	}
+	for _, group := range query.WhereAny {
+		for key := range group {
+			if column, _ := types.ParseWhereKey(key); !validIdentifier(column) {
+				return fmt.Errorf("%w: where column %q", types.ErrInvalidIdentifier, key)
+			}
+		}
+	}
+	// Update data is set through pg.Ident, inserts interpolate the column names
+	if strings.EqualFold(query.Operation, "insert") {
+		for col := range query.Data {
+			if !validIdentifier(col) {
+				return fmt.Errorf("%w: column %q", types.ErrInvalidIdentifier, col)
+			}
+		}
+	}
+	return nil
+}
+
+// validIdentifier reports whether a name is safe to interpolate into SQL as a table or column.
+// It accepts up to three dot separated parts such as public.users.id, each either a plain
+// identifier of letters, digits and underscores not starting with a digit, or a double quoted
+// identifier without embedded quotes.
+func validIdentifier(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return false
+	}
+
+	for _, part := range parts {
+		if len(part) >= 2 && part[0] == '"' && part[len(part)-1] == '"' {
+			if strings.ContainsRune(part[1:len(part)-1], '"') || len(part) == 2 {
+				return false
+			}
+			continue
+		}
+		if part == "" || len(part) > maxIdentifierLength {
+			return false
+		}
+		for i, r := range part {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			case r >= '0' && r <= '9' && i > 0:
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// selectKeyword finds subqueries in column expressions
+var selectKeyword = regexp.MustCompile(`(?i)\bselect\b`)
+
+// functionCall finds the names of function calls in column expressions
+var functionCall = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_.]*)\s*\(`)
+
+// stringLiteral finds single quoted literals, which may contain anything without being a call
+var stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// columnFunctions are the functions column expressions may call, anything else such as
+// pg_sleep is rejected so a caller cannot run arbitrary functions through a column name
+var columnFunctions = []string{"count", "coalesce", "to_char", "lower", "upper", "min", "max", "sum", "avg"}
+
+// validColumnExpression reports whether a select or returning column is a plain identifier, or an
+// identifier, single function call or parenthesized expression aliased with AS, such as
+// "s.name AS subject__name", "COUNT(*) AS count" or "(xmax = 0) AS inserted". Only the columnFunctions
+// may be called. Function arguments and parenthesized expressions must not contain statement
+// separators, comments or subqueries.
+func validColumnExpression(s string) bool {
+	if validIdentifier(s) {
+		return true
+	}
+
+	idx := strings.LastIndex(strings.ToUpper(s), " AS ")
+	if idx <= 0 || !validIdentifier(strings.TrimSpace(s[idx+4:])) {
+		return false
+	}
+	expr := strings.TrimSpace(s[:idx])
+	if validIdentifier(expr) {
+		return true
+	}
+
+	// A parenthesized expression is a call without a function name
+	open := strings.Index(expr, "(")
+	if open < 0 || !strings.HasSuffix(expr, ")") || (open > 0 && !validIdentifier(expr[:open])) {
+		return false
+	}
+	args := expr[open+1 : len(expr)-1]
+	if strings.Contains(args, ";") || strings.Contains(args, "--") || strings.Contains(args, "/*") || selectKeyword.MatchString(args) {
+		return false
+	}
+
+	// The function and every call nested in its arguments must be allowed
+	if open > 0 && !slices.Contains(columnFunctions, strings.ToLower(expr[:open])) {
+		return false
+	}
+	for _, call := range functionCall.FindAllStringSubmatch(stringLiteral.ReplaceAllString(args, "''"), -1) {
+		if !slices.Contains(columnFunctions, strings.ToLower(call[1])) {
+			return false
+		}
+	}
+
+	// The call or parentheses must close exactly at the end, so nothing can follow them
+	depth := 0
+	for _, r := range args {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// validOrderClause reports whether an order clause is an identifier optionally followed by
+// ASC or DESC and NULLS FIRST or NULLS LAST, such as "d.due_date DESC"
+func validOrderClause(s string) bool {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || !validIdentifier(fields[0]) {
+		return false
+	}
+	rest := fields[1:]
+	if len(rest) > 0 && (strings.EqualFold(rest[0], "ASC") || strings.EqualFold(rest[0], "DESC")) {
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return true
+	}
+	return len(rest) == 2 && strings.EqualFold(rest[0], "NULLS") &&
+		(strings.EqualFold(rest[1], "FIRST") || strings.EqualFold(rest[1], "LAST"))
+}
+
+// maxIdentifierLength is PostgreSQL's limit for a single identifier
+const maxIdentifierLength = 63
+
+// quoteIdentifier double quotes a possibly schema qualified identifier such as public.users
+func quoteIdentifier(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("identifier must not be empty")
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "" {
+			return "", fmt.Errorf("identifier %q has an empty part", name)
+		}
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return joinStrings(parts, "."), nil
 }
 
 // convertToMap safely converts an any to map[string]any
@@ -589,11 +977,8 @@ func ExtractColumnsFromEntries(entries []any) ([]string, error) {
 		}
 	}
 
-	// Convert set to slice
-	var columns []string
-	for col := range columnSet {
-		columns = append(columns, col)
-	}
+	// Sort the columns so the same entries always produce the same statement
+	columns := slices.Sorted(maps.Keys(columnSet))
 
 	return columns, nil
 }
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+)
+
+// ConnGuard caps the number of queries and transactions running on the primary database at once.
+// Sized to the connection pool, the time spent waiting for a slot is the time a request
+// queues for a pooled connection, which go-pg does not report itself.
+type ConnGuard struct {
+	slots     chan struct{}
+	threshold time.Duration
+	logger    *config.Logger
+
+	waits     atomic.Int64
+	slowWaits atomic.Int64
+	waitNanos atomic.Int64
+	maxWait   atomic.Int64
+}
+
+// ConnWaitStats is a snapshot of the connection wait statistics
+type ConnWaitStats struct {
+	Capacity  int           `json:"capacity"`
+	InUse     int           `json:"in_use"`
+	Waits     int64         `json:"waits"`      // Acquisitions that found every slot taken
+	SlowWaits int64         `json:"slow_waits"` // Waits longer than the warning threshold
+	TotalWait time.Duration `json:"total_wait"`
+	MaxWait   time.Duration `json:"max_wait"`
+}
+
+// connGuard limits the queries on the primary database, nil until Initialize runs
+var connGuard *ConnGuard
+
+// NewConnGuard creates a guard that lets size queries run at once. A wait longer than threshold
+// is logged as a warning, since it means the pool is too small for the load; zero disables the warning.
+func NewConnGuard(size int, threshold time.Duration, logger *config.Logger) *ConnGuard {
+	return &ConnGuard{
+		slots:     make(chan struct{}, max(size, 1)),
+		threshold: threshold,
+		logger:    logger,
+	}
+}
+
+// Acquire waits for a free slot and returns the function that frees it again.
+// It gives up with the context error when ctx is done before a slot frees up.
+func (g *ConnGuard) Acquire(ctx context.Context) (func(), error) {
+	release := func() { <-g.slots }
+
+	// Fast path, a free slot is not counted as a wait
+	select {
+	case g.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	start := time.Now()
+	select {
+	case g.slots <- struct{}{}:
+	case <-ctx.Done():
+		g.recordWait(time.Since(start))
+		return nil, ctx.Err()
+	}
+
+	g.recordWait(time.Since(start))
+	return release, nil
+}
+
+// recordWait adds a wait to the statistics and warns when it exceeded the threshold
+func (g *ConnGuard) recordWait(wait time.Duration) {
+	g.waits.Add(1)
+	g.waitNanos.Add(int64(wait))
+	for {
+		current := g.maxWait.Load()
+		if int64(wait) <= current || g.maxWait.CompareAndSwap(current, int64(wait)) {
+			break
+		}
+	}
+
+	if g.threshold <= 0 || wait <= g.threshold {
+		return
+	}
+	g.slowWaits.Add(1)
+	if g.logger != nil {
+		g.logger.Warn("Query waited long for a database connection, consider raising DB_MAX_CONNS",
+			"wait", wait, "threshold", g.threshold, "pool_size", cap(g.slots))
+	}
+}
+
+// Stats returns a snapshot of the connection wait statistics
+func (g *ConnGuard) Stats() ConnWaitStats {
+	return ConnWaitStats{
+		Capacity:  cap(g.slots),
+		InUse:     len(g.slots),
+		Waits:     g.waits.Load(),
+		SlowWaits: g.slowWaits.Load(),
+		TotalWait: time.Duration(g.waitNanos.Load()),
+		MaxWait:   time.Duration(g.maxWait.Load()),
+	}
+}
+
+// GetConnGuard returns the guard of the primary database, or nil before Initialize
+func GetConnGuard() *ConnGuard {
+	return connGuard
+}
+
+// SetConnGuard replaces the guard of the primary database, such as with a smaller one in tests
+func SetConnGuard(guard *ConnGuard) {
+	connGuard = guard
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -15,7 +16,12 @@ type DB struct {
 	*pg.DB
 }
 
-var instance *DB
+var (
+	instance *DB
+
+	// auditInstance is the dedicated audit database, nil when the logs go to the primary database
+	auditInstance *DB
+)
 
 // Connect establishes a connection to the database using centralized configuration
 func Connect() (*DB, error) {
@@ -60,6 +66,10 @@ func Initialize() error {
 	}
 
 	instance = db
+
+	// Queries wait for one of DB_MAX_CONNS slots, so the wait for a pooled connection can be measured
+	cfg := config.Get().Database
+	connGuard = NewConnGuard(cfg.MaxConns, cfg.ConnWaitWarnThreshold, config.SetupLogger())
 	return nil
 }
 
@@ -76,14 +86,74 @@ func (db *DB) Close() error {
 	return db.DB.Close()
 }
 
-// CloseInstance closes the global database instance
+// CloseInstance closes the global database instance and the dedicated audit database
 func CloseInstance() error {
+	var errs []error
 	if instance != nil {
-		return instance.Close()
+		errs = append(errs, instance.Close())
+	}
+	if auditInstance != nil {
+		errs = append(errs, auditInstance.Close())
+		auditInstance = nil
+	}
+	return errors.Join(errs...)
+}
+
+// OpenAudit opens a connection pool to the dedicated audit database configured with AUDIT_DB_*.
+// It returns nil when no dedicated database is configured. The pool connects lazily,
+// so this never fails, use Health to check that the database is reachable.
+func OpenAudit(cfg *config.Config) *DB {
+	auditCfg := cfg.AuditDatabase
+	if !auditCfg.Enabled() {
+		return nil
+	}
+
+	// Timeouts and connection age follow the primary database
+	return &DB{pg.Connect(&pg.Options{
+		Addr:         fmt.Sprintf("%s:%d", auditCfg.Host, auditCfg.Port),
+		User:         auditCfg.User,
+		Password:     auditCfg.Password,
+		Database:     auditCfg.Name,
+		PoolSize:     auditCfg.MaxConns,
+		MinIdleConns: auditCfg.MinConns,
+		MaxConnAge:   cfg.Database.MaxLifetime,
+		ReadTimeout:  cfg.Database.ReadTimeout,
+		WriteTimeout: cfg.Database.WriteTimeout,
+	})}
+}
+
+// InitializeAudit sets up the dedicated audit database when one is configured.
+// The pool is kept when the database cannot be reached yet, so audit and health logs
+// are written once it is back; the returned error only reports the failed ping.
+func InitializeAudit() error {
+	auditInstance = OpenAudit(config.Get())
+	if auditInstance == nil {
+		return nil
 	}
+
+	if err := auditInstance.Health(); err != nil {
+		return fmt.Errorf("failed to ping audit database: %w", err)
+	}
+
+	config.SetupLogger().Info("Connected to audit database successfully")
 	return nil
 }
 
+// GetAuditInstance returns the dedicated audit database, or nil when audit and health logs
+// are stored in the primary database
+func GetAuditInstance() *DB {
+	return auditInstance
+}
+
+// Conn returns the underlying connection pool for QueryParams.WithDB.
+// It is nil for a nil DB, so queries fall back to the primary database.
+func (db *DB) Conn() *pg.DB {
+	if db == nil {
+		return nil
+	}
+	return db.DB
+}
+
 // Health checks the database connection health
 func (db *DB) Health() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -102,3 +172,12 @@ func GetConfig() types.DatabaseConfig {
 	cfg := config.Get()
 	return cfg.Database
 }
+
+// uniqueViolation is the PostgreSQL error code for a violated unique constraint or index
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports whether err, or an error it wraps, is a PostgreSQL unique violation
+func IsUniqueViolation(err error) bool {
+	var pgErr pg.Error
+	return errors.As(err, &pgErr) && pgErr.Field('C') == uniqueViolation
+}
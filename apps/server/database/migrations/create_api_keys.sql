@@ -0,0 +1,21 @@
+-- Create api_keys table for per-user service-to-service credentials
+-- Keys are shown to the user once on creation; only their SHA-256 hash is stored
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ NULL,
+    revoked_at TIMESTAMPTZ NULL
+);
+
+-- Create index for listing a user's keys
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE api_keys IS 'Stores hashed API keys used for service-to-service authentication';
+COMMENT ON COLUMN api_keys.prefix IS 'First characters of the key, safe to display so users can identify it';
+COMMENT ON COLUMN api_keys.key_hash IS 'Hex encoded SHA-256 hash of the full key';
+COMMENT ON COLUMN api_keys.revoked_at IS 'Set when the key is revoked; revoked keys can no longer authenticate';
@@ -11,6 +11,8 @@ create table public.audit_logs (
   created_at timestamp with time zone not null default now(),
   id uuid not null default gen_random_uuid (),
   entry_hash character varying(64) null,
+  source text null,
+  request_id text null,
   constraint audit_logs_pkey primary key (id),
   constraint chk_audit_logs_entry_hash_not_empty check (
     (
@@ -55,6 +57,10 @@ create table public.audit_logs (
 
 create index IF not exists idx_audit_logs_timestamp on public.audit_logs using btree ("timestamp") TABLESPACE pg_default;
 
+create index IF not exists idx_audit_logs_source on public.audit_logs using btree (source) TABLESPACE pg_default;
+
+create index IF not exists idx_audit_logs_request_id on public.audit_logs using btree (request_id) TABLESPACE pg_default;
+
 create index IF not exists idx_audit_logs_level on public.audit_logs using btree (level) TABLESPACE pg_default;
 
 create index IF not exists idx_audit_logs_created_at on public.audit_logs using btree (created_at) TABLESPACE pg_default;
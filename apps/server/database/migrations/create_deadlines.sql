@@ -5,6 +5,7 @@ create table if not exists public.deadlines (
 	title text not null,
 	description text null,
 	due_date timestamp with time zone not null,
+	tags text[] not null default '{}', -- Lowercase categories such as exam, homework or project
 	updated_at timestamp with time zone not null default now(),
 	created_at timestamp with time zone not null default now(),
 	constraint deadlines_pkey primary key (id),
@@ -16,6 +17,8 @@ create index IF not exists idx_deadlines_owner_id on public.deadlines using btre
 
 create index IF not exists idx_deadlines_due_date on public.deadlines using btree (due_date) TABLESPACE pg_default;
 
+create index IF not exists idx_deadlines_tags on public.deadlines using gin (tags) TABLESPACE pg_default;
+
 create index IF not exists idx_deadlines_subject_id on public.deadlines using btree (subject_id) TABLESPACE pg_default;
 
 create index IF not exists idx_deadlines_created_at on public.deadlines using btree (created_at) TABLESPACE pg_default;
@@ -7,6 +7,9 @@ create table public.health_logs (
   error_count bigint not null default 0,
   time_span bigint not null,
   average_latency double precision null,
+  p50_latency double precision null,
+  p95_latency double precision null,
+  p99_latency double precision null,
   constraint health_logs_pkey primary key (id)
 ) TABLESPACE pg_default;
 
@@ -3,5 +3,11 @@ create table public.subjects (
   created_at timestamp with time zone not null default now(),
   updated_at timestamp with time zone not null default now(),
   name text not null,
-  constraint subjects_pkey primary key (id)
+  code text null,
+  color text null, -- Hex color such as #1E88E5
+  teacher_id uuid null, -- The main teacher, every assigned teacher is in subject_teachers
+  teacher_name text null,
+  is_active boolean not null default true, -- Deactivated subjects are left out of the subjects of students and teachers
+  constraint subjects_pkey primary key (id),
+  constraint subjects_teacher_id_fkey foreign key (teacher_id) references users (id) on delete set null
 ) TABLESPACE pg_default;
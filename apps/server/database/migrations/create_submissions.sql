@@ -4,6 +4,8 @@ CREATE TABLE IF NOT EXISTS public.submissions (
     student_id uuid NOT NULL,
     file_ids text[] NOT NULL, -- Google Drive file IDs
     message text,
+    feedback text, -- Feedback published to the student
+    draft_feedback text, -- Teacher-only feedback that is not visible to the student yet
     created_at timestamp with time zone NOT NULL DEFAULT now(),
     updated_at timestamp with time zone NOT NULL DEFAULT now(),
     CONSTRAINT submissions_pkey PRIMARY KEY (id),
@@ -38,3 +40,5 @@ COMMENT ON COLUMN public.submissions.file_ids IS 'Array of Google Drive file IDs
 COMMENT ON COLUMN public.submissions.message IS 'Plain text message submitted by the student';
 COMMENT ON COLUMN public.submissions.deadline_id IS 'Reference to the deadline for this submission';
 COMMENT ON COLUMN public.submissions.student_id IS 'Reference to the student (user) who made the submission';
+COMMENT ON COLUMN public.submissions.feedback IS 'Feedback from the teacher that is visible to the student';
+COMMENT ON COLUMN public.submissions.draft_feedback IS 'Work-in-progress teacher feedback, never returned to students';
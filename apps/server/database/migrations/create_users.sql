@@ -7,3 +7,7 @@ create table public.users (
   password_hash text null,
   constraint users_pkey primary key (id)
 ) TABLESPACE pg_default;
+
+-- Emails are compared case-insensitively, the application stores them trimmed and lowercase.
+-- Existing rows must be normalized first: update public.users set email = lower(trim(email));
+create unique index if not exists idx_users_email_lower_unique on public.users using btree (lower(email)) TABLESPACE pg_default;
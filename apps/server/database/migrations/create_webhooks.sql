@@ -0,0 +1,23 @@
+-- Create webhooks table for outbound event subscriptions of external systems
+-- Every delivery is signed with the subscription secret using HMAC-SHA256
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhooks_event_types_not_empty CHECK (cardinality(event_types) > 0)
+);
+
+-- Create index for finding the subscriptions of an event type
+CREATE INDEX IF NOT EXISTS idx_webhooks_event_types ON webhooks USING gin(event_types) WHERE active;
+
+-- Add comments for documentation
+COMMENT ON TABLE webhooks IS 'Stores outbound webhook subscriptions for deadline and submission events';
+COMMENT ON COLUMN webhooks.event_types IS 'Event types delivered to the URL, e.g. submission.created';
+COMMENT ON COLUMN webhooks.secret IS 'Shared secret used to sign deliveries with HMAC-SHA256, sent in X-PWS-Signature';
+COMMENT ON COLUMN webhooks.active IS 'Inactive subscriptions are kept but receive no deliveries';
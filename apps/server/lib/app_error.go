@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"errors"
+	"maps"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+// AppError is an error that knows how it is rendered: the HTTP status, the machine readable code
+// and the message shown to the user. HandleServiceError renders it directly, so a new error
+// category is a single declaration instead of another case in the central switch:
+//
+//	var ErrQuotaExceeded = lib.NewAppError(fiber.StatusTooManyRequests, "QUOTA_EXCEEDED", "Upload quota exceeded")
+//
+//	return lib.ErrQuotaExceeded.Wrap(err)
+//
+// The wrapped error is logged but never shown to the user.
+type AppError struct {
+	Status  int
+	Code    string
+	Message string
+	Details map[string]any
+	Err     error
+}
+
+// ErrValidationFailed is returned by services for input they reject, rendered as a 422.
+// Use NewValidationError to report the offending field.
+var ErrValidationFailed = NewAppError(fiber.StatusUnprocessableEntity, response.ErrCodeValidation, "Validation failed")
+
+// ErrInvalidSort is returned for a sort parameter outside the allowlist of the listing
+var ErrInvalidSort = NewAppError(fiber.StatusBadRequest, response.ErrCodeBadRequest, "Invalid sort parameter")
+
+// NewValidationError returns ErrValidationFailed for a single field. The details have the same
+// shape as the request validation errors, so clients handle both the same way.
+func NewValidationError(field, message string) *AppError {
+	return ErrValidationFailed.
+		WithDetails(map[string]any{
+			"validation_errors": []types.ValidationError{{Field: field, Message: message}},
+		}).
+		Wrap(errors.New(message))
+}
+
+// NewAppError creates an AppError, typically declared once as a package level variable
+func NewAppError(status int, code, message string) *AppError {
+	return &AppError{Status: status, Code: code, Message: message}
+}
+
+// Error returns the user message followed by the wrapped error, if any
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the wrapped error, so errors.Is still finds sentinel errors it wraps
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an AppError of the same category, so errors.Is(err, ErrQuotaExceeded)
+// matches every error created from ErrQuotaExceeded with Wrap or WithDetails
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Status == e.Status && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error that wraps err as its cause
+func (e *AppError) Wrap(err error) *AppError {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// WithDetails returns a copy of the error with details added to the response
+func (e *AppError) WithDetails(details map[string]any) *AppError {
+	detailed := *e
+	detailed.Details = maps.Clone(e.Details)
+	if detailed.Details == nil {
+		detailed.Details = make(map[string]any, len(details))
+	}
+	maps.Copy(detailed.Details, details)
+	return &detailed
+}
+
+// renderAppError sends the response for an AppError. Server errors go through the internal server
+// error response, which only shows the error chain outside production, and never include details.
+func (eh *ErrorHandler) renderAppError(c fiber.Ctx, appErr *AppError) error {
+	if appErr.Status >= fiber.StatusInternalServerError {
+		if appErr.Status == fiber.StatusInternalServerError {
+			return eh.internalServerError(c, appErr, appErr.Message)
+		}
+		return response.CustomError(c, appErr.Status, appErr.Code, appErr.Message)
+	}
+
+	if len(appErr.Details) > 0 {
+		return response.CustomErrorWithDetails(c, appErr.Status, appErr.Code, appErr.Message, appErr.Details)
+	}
+	return response.CustomError(c, appErr.Status, appErr.Code, appErr.Message)
+}
@@ -246,9 +246,13 @@ func (cb *CircuitBreaker) setState(newState CircuitState) {
 	oldState := CircuitState(cb.state.Swap(int32(newState)))
 	cb.lastStateChange.Store(time.Now().UnixNano())
 
+	cb.mu.RLock()
+	onStateChange := cb.onStateChange
+	cb.mu.RUnlock()
+
 	// Call state change callback if set
-	if cb.onStateChange != nil && oldState != newState {
-		go cb.onStateChange(oldState, newState)
+	if onStateChange != nil && oldState != newState {
+		go onStateChange(oldState, newState)
 	}
 }
 
@@ -330,6 +334,43 @@ func (cb *CircuitBreaker) IsHalfOpen() bool {
 	return cb.getState() == StateHalfOpen
 }
 
+// CircuitStateChange describes a single circuit breaker state transition
+type CircuitStateChange struct {
+	Name      string       `json:"name"`
+	From      CircuitState `json:"-"`
+	To        CircuitState `json:"-"`
+	Failures  int64        `json:"failures"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// CircuitAlertHandler is called for every state transition of a database circuit breaker
+type CircuitAlertHandler func(change CircuitStateChange)
+
+var (
+	circuitAlertHandler CircuitAlertHandler
+	circuitAlertMu      sync.RWMutex
+)
+
+// RegisterCircuitBreakerAlertHandler sets the handler that is notified when a database circuit
+// breaker changes state. Passing nil removes the handler.
+// This lets the application wire logging and alerting without lib importing the config package.
+func RegisterCircuitBreakerAlertHandler(handler CircuitAlertHandler) {
+	circuitAlertMu.Lock()
+	defer circuitAlertMu.Unlock()
+	circuitAlertHandler = handler
+}
+
+// notifyCircuitAlertHandler forwards a state change to the registered handler, if any
+func notifyCircuitAlertHandler(change CircuitStateChange) {
+	circuitAlertMu.RLock()
+	handler := circuitAlertHandler
+	circuitAlertMu.RUnlock()
+
+	if handler != nil {
+		handler(change)
+	}
+}
+
 // DatabaseCircuitBreaker is a specialized circuit breaker for database operations
 type DatabaseCircuitBreaker struct {
 	*CircuitBreaker
@@ -345,10 +386,15 @@ func NewDatabaseCircuitBreaker(name string, config CircuitBreakerConfig) *Databa
 		name:           name,
 	}
 
-	// Set up logging for state changes
+	// Forward state changes to the handler registered by the application
 	cb.SetOnStateChange(func(from, to CircuitState) {
-		// Note: We avoid importing config/logger here to prevent circular dependencies
-		// The actual logging should be set up by the caller
+		notifyCircuitAlertHandler(CircuitStateChange{
+			Name:      name,
+			From:      from,
+			To:        to,
+			Failures:  cb.Failures(),
+			Timestamp: time.Now(),
+		})
 	})
 
 	return dbCb
@@ -3,6 +3,13 @@ package lib
 const (
 	AccessTokenCookieName  = "access_token"
 	RefreshTokenCookieName = "refresh_token"
+
+	// RefreshTokenHeader carries the refresh token of clients that do not keep cookies
+	RefreshTokenHeader = "X-Refresh-Token"
+
+	// CSRFTokenCookieName holds the double submit CSRF token, which unsafe requests repeat in CSRFTokenHeader
+	CSRFTokenCookieName = "csrf_token"
+	CSRFTokenHeader     = "X-CSRF-Token"
 )
 
 const (
@@ -11,6 +18,9 @@ const (
 	RoleStudent = "student"
 )
 
+// Roles lists every role a user can have
+var Roles = []string{RoleStudent, RoleTeacher, RoleAdmin}
+
 const (
 	TableUsers           = "users"
 	TableFiles           = "files"
@@ -22,4 +32,7 @@ const (
 	TableAuditLogs       = "audit_logs"
 	TableHealthLogs      = "health_logs"
 	TableDeadlines       = "deadlines"
+	TableSubmissions     = "submissions"
+	TableAPIKeys         = "api_keys"
+	TableWebhooks        = "webhooks"
 )
@@ -13,22 +13,27 @@ import (
 // Common application errors
 var (
 	// Authentication & Authorization errors
-	ErrInvalidCredentials      = errors.New("invalid credentials")
-	ErrInvalidToken            = errors.New("invalid token")
-	ErrExpiredToken            = errors.New("expired token")
-	ErrTokenGeneration         = errors.New("error generating token")
-	ErrGeneratingToken         = errors.New("error generating token") // Alias for backwards compatibility
-	ErrTokenValidation         = errors.New("error validating token")
-	ErrValidatingToken         = errors.New("error validating token") // Alias for backwards compatibility
-	ErrTokenRefresh            = errors.New("failed to refresh token")
-	ErrFailedToRefreshToken    = errors.New("failed to refresh token") // Alias for backwards compatibility
-	ErrTokenDeletion           = errors.New("failed to delete token")
-	ErrFailedToDeleteToken     = errors.New("failed to delete token") // Alias for backwards compatibility
-	ErrUnauthorized            = errors.New("unauthorized access")
-	ErrInsufficientPermissions = errors.New("insufficient permissions")
-	ErrTokenRevoked            = errors.New("token has been revoked")
-	ErrTokenReuse              = errors.New("possible token reuse detected")
-	ErrInvalidClaims           = errors.New("invalid authentication claims")
+	ErrInvalidCredentials       = errors.New("invalid credentials")
+	ErrInvalidToken             = errors.New("invalid token")
+	ErrExpiredToken             = errors.New("expired token")
+	ErrTokenGeneration          = errors.New("error generating token")
+	ErrGeneratingToken          = errors.New("error generating token") // Alias for backwards compatibility
+	ErrTokenValidation          = errors.New("error validating token")
+	ErrValidatingToken          = errors.New("error validating token") // Alias for backwards compatibility
+	ErrTokenRefresh             = errors.New("failed to refresh token")
+	ErrFailedToRefreshToken     = errors.New("failed to refresh token") // Alias for backwards compatibility
+	ErrTokenDeletion            = errors.New("failed to delete token")
+	ErrFailedToDeleteToken      = errors.New("failed to delete token") // Alias for backwards compatibility
+	ErrEmptyRefreshToken        = errors.New("refresh token is empty")
+	ErrUnauthorized             = errors.New("unauthorized access")
+	ErrInsufficientPermissions  = errors.New("insufficient permissions")
+	ErrTokenRevoked             = errors.New("token has been revoked")
+	ErrSessionExpired           = errors.New("session expired")
+	ErrTokenReuse               = errors.New("possible token reuse detected")
+	ErrUnexpectedBlacklistValue = errors.New("unexpected token blacklist value")
+	ErrInvalidClaims            = errors.New("invalid authentication claims")
+	ErrInvalidAPIKey            = errors.New("invalid API key")
+	ErrAPIKeyNotFound           = errors.New("API key not found")
 
 	// User management errors
 	ErrUserNotFound      = errors.New("user not found")
@@ -39,16 +44,21 @@ var (
 	ErrUserCreation      = errors.New("error creating user")
 	ErrCreateUser        = errors.New("error creating user") // Alias for backwards compatibility
 	ErrPasswordMismatch  = errors.New("password and confirmation do not match")
-	ErrWeakPassword      = errors.New("password does not meet strength requirements")
+	ErrPepperMissing     = errors.New("password hash requires a pepper but AUTH_PASSWORD_PEPPER is not set")
+	ErrInvalidRole       = errors.New("invalid role")
+	ErrLastAdmin         = errors.New("cannot remove the admin role from the last admin")
 
 	// Content management errors
-	ErrFileNotFound    = errors.New("file not found")
-	ErrFileUpload      = errors.New("file upload failed")
-	ErrFileAccess      = errors.New("file access denied")
-	ErrFolderNotFound  = errors.New("folder not found")
-	ErrFolderCreation  = errors.New("folder creation failed")
-	ErrSubjectNotFound = errors.New("subject not found")
-	ErrServiceNotFound = errors.New("service not found")
+	ErrFileNotFound       = errors.New("file not found")
+	ErrFileUpload         = errors.New("file upload failed")
+	ErrFileAccess         = errors.New("file access denied")
+	ErrFolderNotFound     = errors.New("folder not found")
+	ErrFolderCreation     = errors.New("folder creation failed")
+	ErrSubjectNotFound    = errors.New("subject not found")
+	ErrDeadlineNotFound   = errors.New("deadline not found")
+	ErrServiceNotFound    = errors.New("service not found")
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrTeacherNotAssigned = errors.New("teacher not assigned to subject")
 
 	// Validation errors
 	ErrInvalidInput     = errors.New("invalid input data")
@@ -58,30 +68,38 @@ var (
 	ErrValidation       = errors.New("validation error")
 	ErrMissingFile      = errors.New("Missing file(s)")
 	ErrMissingParameter = errors.New("Missing file(s)")
+	ErrTooManyFilters   = errors.New("too many filter conditions")
+	ErrInvalidWebhook   = errors.New("invalid webhook subscription")
 
 	// Access control errors
 	ErrForbidden = errors.New("forbidden access")
 
 	// External service errors
-	ErrNoLinkedAccount = errors.New("no linked account")
+	ErrNoLinkedAccount        = errors.New("no linked account")
+	ErrUnsupportedProvider    = errors.New("unsupported OAuth provider")
+	ErrOAuthReconsentRequired = errors.New("no refresh token returned by OAuth provider, reconsent required")
 
 	// Service errors
 	ErrServiceUnavailable = errors.New("service temporarily unavailable")
+	ErrLockTimeout        = errors.New("timed out waiting for lock")
 	ErrDatabaseConnection = errors.New("database connection failed")
 	ErrExternalService    = errors.New("external service error")
 	ErrWorkerUnavailable  = errors.New("worker unavailable")
 	ErrNotFound           = errors.New("resource not found")
+	ErrCacheKeyNotFound   = errors.New("cache key not found")
 )
 
 // ErrorHandler provides centralized error handling with consistent responses
 type ErrorHandler struct {
-	logger *config.Logger
+	logger      *config.Logger
+	environment string
 }
 
 // NewErrorHandler creates a new error handler instance
 func NewErrorHandler() *ErrorHandler {
 	return &ErrorHandler{
-		logger: config.SetupLogger(),
+		logger:      config.SetupLogger(),
+		environment: config.Get().Environment,
 	}
 }
 
@@ -100,7 +118,7 @@ func HandleServiceWarning(c fiber.Ctx, message string, data ...any) {
 	if handler.logger != nil {
 		args := []any{"message", message, "method", c.Method(), "path", c.Path(), "ip", c.IP()}
 		args = append(args, data...)
-		handler.logger.Warn("Service warning", args...)
+		handler.logger.WithRequest(c).Warn("Service warning", args...)
 	}
 }
 
@@ -113,7 +131,13 @@ func (eh *ErrorHandler) Handle(c fiber.Ctx, err error, message string) error {
 	// Log the error with detailed message for developers
 	eh.logErrorWithMessage(c, err, message)
 
-	// Map specific errors to HTTP responses
+	// Errors that carry their own response are rendered directly
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return eh.renderAppError(c, appErr)
+	}
+
+	// Map sentinel errors to HTTP responses
 	switch {
 	// Authentication & Authorization errors (401)
 	case errors.Is(err, ErrInvalidCredentials):
@@ -128,6 +152,10 @@ func (eh *ErrorHandler) Handle(c fiber.Ctx, err error, message string) error {
 		return response.Unauthorized(c, "Unauthorized")
 	case errors.Is(err, ErrTokenRevoked):
 		return response.Unauthorized(c, "Token has been revoked")
+	case errors.Is(err, ErrSessionExpired):
+		return response.Unauthorized(c, "Session expired, please log in again")
+	case errors.Is(err, ErrInvalidAPIKey):
+		return response.Unauthorized(c, "Invalid or revoked API key")
 
 	// Forbidden access (403)
 	case errors.Is(err, ErrInsufficientPermissions):
@@ -146,10 +174,18 @@ func (eh *ErrorHandler) Handle(c fiber.Ctx, err error, message string) error {
 		return response.NotFound(c, "Folder not found")
 	case errors.Is(err, ErrSubjectNotFound):
 		return response.NotFound(c, "Subject not found")
+	case errors.Is(err, ErrDeadlineNotFound):
+		return response.NotFound(c, "Deadline not found")
 	case errors.Is(err, ErrServiceNotFound):
 		return response.NotFound(c, "Service not found")
 	case errors.Is(err, ErrNoLinkedAccount):
 		return response.NotFound(c, "No linked account found")
+	case errors.Is(err, ErrAPIKeyNotFound):
+		return response.NotFound(c, "API key not found")
+	case errors.Is(err, ErrWebhookNotFound):
+		return response.NotFound(c, "Webhook not found")
+	case errors.Is(err, ErrTeacherNotAssigned):
+		return response.NotFound(c, "Teacher is not assigned to this subject")
 	case errors.Is(err, ErrNotFound):
 		return response.NotFound(c, "Resource not found")
 
@@ -158,6 +194,12 @@ func (eh *ErrorHandler) Handle(c fiber.Ctx, err error, message string) error {
 		return response.Conflict(c, "User with this email already exists")
 	case errors.Is(err, ErrUsernameTaken):
 		return response.Conflict(c, "Username is already taken")
+	case errors.Is(err, ErrLastAdmin):
+		return response.Conflict(c, "The last admin cannot lose the admin role")
+	case errors.Is(err, ErrLockTimeout):
+		return response.Conflict(c, "Another request for this resource is still being processed, please try again")
+	case errors.Is(err, ErrOAuthReconsentRequired):
+		return response.Conflict(c, "The provider did not grant offline access. Revoke this app's access in your account's security settings and link your account again")
 
 	// Bad Request errors (400)
 	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrInvalidFormat), errors.Is(err, ErrMissingFile):
@@ -168,6 +210,16 @@ func (eh *ErrorHandler) Handle(c fiber.Ctx, err error, message string) error {
 		return response.BadRequest(c, "Invalid request")
 	case errors.Is(err, ErrValidation):
 		return response.BadRequest(c, "Validation failed")
+	case errors.Is(err, ErrTooManyFilters):
+		return response.BadRequest(c, "Too many filter conditions")
+	case errors.Is(err, response.ErrInvalidPagination):
+		return response.BadRequest(c, err.Error())
+	case errors.Is(err, ErrUnsupportedProvider):
+		return response.BadRequest(c, "Unsupported OAuth provider")
+	case errors.Is(err, ErrInvalidRole):
+		return response.BadRequest(c, "Role must be one of student, teacher or admin")
+	case errors.Is(err, ErrInvalidWebhook):
+		return response.BadRequest(c, "Webhook needs an absolute http or https URL and known event types")
 
 	// Service Unavailable errors (503)
 	case errors.Is(err, ErrServiceUnavailable):
@@ -177,31 +229,33 @@ func (eh *ErrorHandler) Handle(c fiber.Ctx, err error, message string) error {
 
 	// Token generation/management errors (500)
 	case errors.Is(err, ErrTokenGeneration):
-		return response.InternalServerError(c, "Failed to generate authentication token")
+		return eh.internalServerError(c, err, "Failed to generate authentication token")
 	case errors.Is(err, ErrTokenRefresh):
-		return response.InternalServerError(c, "Failed to refresh token")
+		return eh.internalServerError(c, err, "Failed to refresh token")
 	case errors.Is(err, ErrTokenDeletion):
-		return response.InternalServerError(c, "Failed to revoke token")
+		return eh.internalServerError(c, err, "Failed to revoke token")
+	case errors.Is(err, ErrEmptyRefreshToken):
+		return eh.internalServerError(c, err, "Failed to link account")
 
 	// User management errors (500)
 	case errors.Is(err, ErrPasswordHashing), errors.Is(err, ErrUserCreation):
-		return response.InternalServerError(c, "User account creation failed")
+		return eh.internalServerError(c, err, "User account creation failed")
 
 	// File/Content management errors (500)
 	case errors.Is(err, ErrFileUpload):
-		return response.InternalServerError(c, "File upload failed")
+		return eh.internalServerError(c, err, "File upload failed")
 	case errors.Is(err, ErrFolderCreation):
-		return response.InternalServerError(c, "Folder creation failed")
+		return eh.internalServerError(c, err, "Folder creation failed")
 
 	// Database/Infrastructure errors (500)
 	case errors.Is(err, ErrDatabaseConnection):
-		return response.InternalServerError(c, "Database connection error")
+		return eh.internalServerError(c, err, "Database connection error")
 	case errors.Is(err, ErrExternalService):
-		return response.InternalServerError(c, "External service error")
+		return eh.internalServerError(c, err, "External service error")
 
 	// Default case for unknown errors (500)
 	default:
-		return response.InternalServerError(c, "An unexpected error occurred")
+		return eh.internalServerError(c, err, "An unexpected error occurred")
 	}
 }
 
@@ -224,7 +278,7 @@ func GetValidatedClaims(c fiber.Ctx) (*types.AuthClaims, error) {
 // logErrorWithMessage logs errors with detailed message and request context
 func (eh *ErrorHandler) logErrorWithMessage(c fiber.Ctx, err error, message string) {
 	if eh.logger != nil {
-		eh.logger.AuditError(
+		eh.logger.WithRequest(c).AuditError(
 			message,
 			"error", err.Error(),
 			"method", c.Method(),
@@ -235,3 +289,8 @@ func (eh *ErrorHandler) logErrorWithMessage(c fiber.Ctx, err error, message stri
 		log.Printf("Error: %s | %v, Method: %s, Path: %s", message, err, c.Method(), c.Path())
 	}
 }
+
+// internalServerError sends a 500 response, outside production it includes the error chain and request ID
+func (eh *ErrorHandler) internalServerError(c fiber.Ctx, err error, message string) error {
+	return response.InternalServerErrorForEnvironment(c, eh.environment, message, err)
+}
@@ -1,9 +1,12 @@
 package lib
 
 import (
+	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
 )
@@ -26,6 +29,46 @@ func GetUserFromContext(c fiber.Ctx) *types.User {
 	}
 }
 
+// BearerToken extracts the credentials from an `Authorization: Bearer <token>` header value
+func BearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// GetAccessToken returns the access token of the request. An `Authorization: Bearer` header,
+// sent by API clients without a cookie jar, takes precedence over the access token cookie.
+// The cookie is not used as a fallback for an invalid header token.
+func GetAccessToken(c fiber.Ctx) string {
+	if token, ok := BearerToken(c.Get(fiber.HeaderAuthorization)); ok {
+		return token
+	}
+	return c.Cookies(AccessTokenCookieName)
+}
+
+// GetRefreshToken returns the refresh token of the request, taken from the first of
+// the X-Refresh-Token header, the refresh_token field of a JSON body and the refresh token cookie
+func GetRefreshToken(c fiber.Ctx) string {
+	if token := strings.TrimSpace(c.Get(RefreshTokenHeader)); token != "" {
+		return token
+	}
+
+	if body := c.Body(); len(body) > 0 && strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		var request types.RefreshTokenRequest
+		if err := json.Unmarshal(body, &request); err == nil {
+			if token := strings.TrimSpace(request.RefreshToken); token != "" {
+				return token
+			}
+		}
+	}
+
+	return c.Cookies(RefreshTokenCookieName)
+}
+
 func HasPrivileges(c fiber.Ctx) bool {
 	user := GetUserFromContext(c)
 	if user == nil {
@@ -69,7 +112,37 @@ func GetParams(c fiber.Ctx, keys map[string]bool) (map[string]string, error) {
 	return params, nil
 }
 
+// GetQueryParams returns the non-empty query parameters for the given keys.
+// Keys mapped to true are required. The number of query parameters is capped
+// by the SERVER_MAX_FILTER_CONDITIONS setting.
 func GetQueryParams(c fiber.Ctx, keys map[string]bool) (map[string]string, error) {
+	return GetQueryParamsWithLimit(c, keys, config.Get().Server.MaxFilterConditions)
+}
+
+// nonFilterQueryParams are the query parameters that sort or paginate, they do not count towards the filter limit
+var nonFilterQueryParams = map[string]bool{
+	"sort":  true,
+	"page":  true,
+	"limit": true,
+}
+
+// GetQueryParamsWithLimit is GetQueryParams with an explicit cap on the number of filter conditions.
+// Every query parameter of the request counts, including unknown and repeated ones, except those in
+// nonFilterQueryParams. Returns ErrTooManyFilters when there are more than maxConditions, a cap below 1
+// disables the check.
+func GetQueryParamsWithLimit(c fiber.Ctx, keys map[string]bool, maxConditions int) (map[string]string, error) {
+	if maxConditions > 0 {
+		conditions := 0
+		for key := range c.Request().URI().QueryArgs().All() {
+			if !nonFilterQueryParams[string(key)] {
+				conditions++
+			}
+		}
+		if conditions > maxConditions {
+			return nil, fmt.Errorf("%w: %d conditions, at most %d allowed", ErrTooManyFilters, conditions, maxConditions)
+		}
+	}
+
 	params := make(map[string]string)
 
 	for key, required := range keys {
@@ -86,25 +159,20 @@ func GetQueryParams(c fiber.Ctx, keys map[string]bool) (map[string]string, error
 	return params, nil
 }
 
-func ValidatePasswordStrength(password string) error {
-	var hasMinLen, hasUpper, hasLower, hasNumber, hasSpecial bool
-	if len(password) >= 8 {
-		hasMinLen = true
-	}
-	for _, char := range password {
-		switch {
-		case 'A' <= char && char <= 'Z':
-			hasUpper = true
-		case 'a' <= char && char <= 'z':
-			hasLower = true
-		case '0' <= char && char <= '9':
-			hasNumber = true
-		case strings.ContainsRune("!@#$%^&*()-_=+[]{}|;:',.<>?/", char):
-			hasSpecial = true
-		}
+// SubmissionForRole serializes a submission for the given role.
+// Teachers and admins get the full response, every other role gets the student view without teacher-only fields.
+func SubmissionForRole(submission *types.SubmissionResponse, role string) any {
+	if role == RoleTeacher || role == RoleAdmin {
+		return submission
 	}
-	if hasMinLen && hasUpper && hasLower && hasNumber && hasSpecial {
-		return nil
+	return submission.ForStudent()
+}
+
+// SubmissionsForRole serializes a list of submissions for the given role, see SubmissionForRole
+func SubmissionsForRole(submissions []*types.SubmissionResponse, role string) []any {
+	serialized := make([]any, 0, len(submissions))
+	for _, submission := range submissions {
+		serialized = append(serialized, SubmissionForRole(submission, role))
 	}
-	return ErrWeakPassword
+	return serialized
 }
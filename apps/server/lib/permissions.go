@@ -0,0 +1,38 @@
+package lib
+
+import (
+	"slices"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+)
+
+// Permissions that gate actions which used to be checked against a raw role string.
+// Roles are mapped to permissions through AUTH_ROLE_PERMISSIONS.
+// New permissions are added in config, which rejects unknown ones at startup.
+const (
+	PermSubmissionsGrade = config.PermSubmissionsGrade
+	PermAuditRead        = config.PermAuditRead
+	PermCleanupTrigger   = config.PermCleanupTrigger
+)
+
+// PermissionWildcard grants every permission to a role
+const PermissionWildcard = config.PermissionWildcard
+
+// HasPermission reports whether the role in the claims is granted the permission by the configured mapping
+func HasPermission(claims *types.AuthClaims, perm string) bool {
+	if claims == nil {
+		return false
+	}
+	return RoleHasPermission(config.Get().Auth.RolePermissions, claims.Role, perm)
+}
+
+// RoleHasPermission reports whether the mapping grants the permission to the role.
+// Unknown roles have no permissions.
+func RoleHasPermission(mapping map[string][]string, role, perm string) bool {
+	granted, ok := mapping[role]
+	if !ok {
+		return false
+	}
+	return slices.Contains(granted, PermissionWildcard) || slices.Contains(granted, perm)
+}
@@ -12,12 +12,17 @@ import (
 	"github.com/MonkyMars/PWS/api"
 	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/services"
 	"github.com/MonkyMars/PWS/workers"
 
+	"github.com/gofiber/fiber/v3"
 	"github.com/joho/godotenv"
 )
 
+// shutdownTimeout bounds both draining in-flight requests and stopping the workers
+const shutdownTimeout = 30 * time.Second
+
 /*
 * main is the entry point of the application
 * It initializes configuration, logging, database connections,
@@ -37,6 +42,10 @@ func main() {
 	// Setup centralized logger
 	logger := config.SetupLogger()
 	logger.ConfigLoaded()
+	logger.StartupSummary(cfg)
+
+	// Alert on database circuit breaker state changes
+	lib.RegisterCircuitBreakerAlertHandler(services.NewAlertService().HandleCircuitStateChange)
 
 	// Initialize worker manager with dependency injection
 	workerManager := workers.NewWorkerManager(cfg, logger)
@@ -72,6 +81,11 @@ func main() {
 	// Log successful database connection
 	logger.DatabaseConnected()
 
+	// Connect the dedicated audit database, an unreachable one must not keep the API down
+	if err := database.InitializeAudit(); err != nil {
+		logger.Warn("Audit database unreachable, audit and health log writes fail until it is back", "error", err)
+	}
+
 	// Test database connection
 	err = services.Ping()
 	if err != nil {
@@ -86,67 +100,68 @@ func main() {
 		log.Fatalf("Redis connection error: %v", err)
 	}
 
-	// Setup graceful shutdown with coordinated worker manager
-	setupGracefulShutdown(logger, workerManager)
-
-	// Ensure database and Redis connections are closed on exit
-	defer func() {
-		logger.Shutdown("application_exit")
+	// Create the API server, it only starts accepting requests once Listen is called below
+	app := api.NewApp()
 
-		// Stop worker manager with timeout
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	// Setup graceful shutdown of the server, the workers and the connections
+	shutdownDone := setupGracefulShutdown(logger, app, workerManager)
 
-		if err := workerManager.Stop(shutdownCtx); err != nil {
-			logger.Error("Worker manager shutdown error", "error", err)
-		}
-
-		if err := services.CloseDatabase(); err != nil {
-			logger.DatabaseError("close", err)
-		}
-		if err := services.CloseRedisConnection(); err != nil {
-			logger.AuditError("Redis close error", "error", err)
-		}
-	}()
-
-	// Start the API server
-	err = api.App()
+	// Start the API server, Listen returns once the shutdown handler stopped it
+	err = app.Listen(cfg.GetServerAddress())
 	if err != nil {
 		logger.ServerError(err)
 		// Fatal here to ensure the application exits if the server fails to start
 		log.Fatal(err)
 	}
+
+	// Wait for in-flight requests, workers and connections to finish before exiting
+	<-shutdownDone
 }
 
-// setupGracefulShutdown sets up signal handling for graceful application shutdown
-func setupGracefulShutdown(logger *config.Logger, workerManager *workers.WorkerManager) {
+// setupGracefulShutdown sets up signal handling for graceful application shutdown.
+// The returned channel is closed once the shutdown has completed.
+func setupGracefulShutdown(logger *config.Logger, app *fiber.App, workerManager *workers.WorkerManager) <-chan struct{} {
+	done := make(chan struct{})
+
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
+		defer close(done)
+
 		<-c
 		logger.Shutdown("signal_received")
+		shutdown(logger, app, workerManager)
+	}()
 
-		// Stop worker manager with timeout
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	return done
+}
 
-		if err := workerManager.Stop(shutdownCtx); err != nil {
-			logger.Error("Worker manager shutdown error", "error", err)
-		}
+// shutdown stops the application in order: stop accepting new requests and drain the in-flight
+// ones, stop the workers so they flush what those requests logged, then close the database and Redis
+func shutdown(logger *config.Logger, app *fiber.App, workerManager *workers.WorkerManager) {
+	// Close the listeners and wait for active connections, forcefully closing them after the timeout
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		logger.Error("HTTP server shutdown error", "error", err)
+	}
 
-		// Close database connection
-		if err := services.CloseDatabase(); err != nil {
-			logger.DatabaseError("shutdown_close", err)
-		}
+	// Stop worker manager with timeout
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-		// Close Redis connection
-		if err := services.CloseRedisConnection(); err != nil {
-			logger.AuditError("Redis shutdown close error", "error", err)
-		}
+	if err := workerManager.Stop(shutdownCtx); err != nil {
+		logger.Error("Worker manager shutdown error", "error", err)
+	}
 
-		os.Exit(0)
-	}()
+	// Close database connection
+	if err := services.CloseDatabase(); err != nil {
+		logger.DatabaseError("shutdown_close", err)
+	}
+
+	// Close Redis connection
+	if err := services.CloseRedisConnection(); err != nil {
+		logger.AuditError("Redis shutdown close error", "error", err)
+	}
 }
 
 func initializeAuditLogging(workerManager *workers.WorkerManager) {
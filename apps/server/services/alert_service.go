@@ -0,0 +1,159 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+)
+
+// alertWebhookTimeout bounds a single webhook delivery so a slow receiver cannot pile up goroutines
+const alertWebhookTimeout = 5 * time.Second
+
+// CircuitAlertPayload is the JSON body posted to the alert webhook
+type CircuitAlertPayload struct {
+	Breaker    string    `json:"breaker"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Failures   int64     `json:"failures"`
+	Suppressed int       `json:"suppressed"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// AlertService turns circuit breaker state changes into audit logs and optional webhook calls.
+// Repeated transitions into the same state within the debounce window are suppressed
+// so a flapping breaker does not spam alerts.
+type AlertService struct {
+	logger     *config.Logger
+	webhookURL string
+	debounce   time.Duration
+	client     *http.Client
+
+	mu         sync.Mutex
+	lastAlert  map[string]time.Time
+	suppressed map[string]int
+}
+
+func NewAlertService() *AlertService {
+	cfg := config.Get()
+	return NewAlertServiceWithOptions(config.SetupLogger(), cfg.Database.CircuitAlertWebhookURL, cfg.Database.CircuitAlertDebounce)
+}
+
+// NewAlertServiceWithOptions creates an alert service with an explicit webhook URL and debounce window.
+// An empty webhook URL disables webhook delivery, a zero debounce window disables debouncing.
+func NewAlertServiceWithOptions(logger *config.Logger, webhookURL string, debounce time.Duration) *AlertService {
+	return &AlertService{
+		logger:     logger,
+		webhookURL: webhookURL,
+		debounce:   debounce,
+		client:     &http.Client{Timeout: alertWebhookTimeout},
+		lastAlert:  make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+// HandleCircuitStateChange logs a circuit breaker transition and posts it to the webhook.
+// It satisfies lib.CircuitAlertHandler so it can be passed to lib.RegisterCircuitBreakerAlertHandler.
+func (as *AlertService) HandleCircuitStateChange(change lib.CircuitStateChange) {
+	suppressed, ok := as.allow(change)
+	if !ok {
+		return
+	}
+
+	attrs := []any{
+		"breaker", change.Name,
+		"from", change.From.String(),
+		"to", change.To.String(),
+		"failures", change.Failures,
+		"suppressed", suppressed,
+	}
+
+	switch change.To {
+	case lib.StateOpen:
+		as.logger.AuditError("Circuit breaker opened", attrs...)
+	case lib.StateHalfOpen:
+		as.logger.AuditWarn("Circuit breaker half-open", attrs...)
+	default:
+		as.logger.AuditInfo("Circuit breaker closed", attrs...)
+	}
+
+	if as.webhookURL == "" {
+		return
+	}
+
+	payload := CircuitAlertPayload{
+		Breaker:    change.Name,
+		From:       change.From.String(),
+		To:         change.To.String(),
+		Failures:   change.Failures,
+		Suppressed: suppressed,
+		Timestamp:  change.Timestamp,
+	}
+	if err := as.postWebhook(payload); err != nil {
+		as.logger.Warn("Failed to deliver circuit breaker alert", "breaker", change.Name, "error", err)
+	}
+}
+
+// allow reports whether an alert should be sent for the change and how many
+// transitions into the same state were suppressed since the previous alert
+func (as *AlertService) allow(change lib.CircuitStateChange) (int, bool) {
+	key := change.Name + ":" + change.To.String()
+	now := change.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if last, ok := as.lastAlert[key]; ok && as.debounce > 0 && now.Sub(last) < as.debounce {
+		as.suppressed[key]++
+		return 0, false
+	}
+
+	suppressed := as.suppressed[key]
+	as.lastAlert[key] = now
+	delete(as.suppressed, key)
+	return suppressed, true
+}
+
+// postWebhook sends the payload to the configured webhook URL as JSON
+func (as *AlertService) postWebhook(payload CircuitAlertPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), alertWebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, as.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := as.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+var _ AlertServiceInterface = (*AlertService)(nil)
+
+type AlertServiceInterface interface {
+	HandleCircuitStateChange(change lib.CircuitStateChange)
+}
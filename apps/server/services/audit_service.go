@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/database"
@@ -24,7 +25,8 @@ func (as *AuditService) GetLogs() (*[]types.AuditLog, error) {
 		SetOperation("select").
 		SetTable(lib.TableAuditLogs).
 		SetSelect([]string{"id", "timestamp", "level", "message", "attrs", "entry_hash"}).
-		AddOrder(fmt.Sprintf("%s.timestamp DESC", lib.TableAuditLogs))
+		AddOrder(fmt.Sprintf("%s.timestamp DESC", lib.TableAuditLogs)).
+		WithDB(database.GetAuditInstance().Conn())
 	result, err := database.ExecuteQuery[types.AuditLog](query)
 	if err != nil {
 		as.Logger.AuditError("Failed to retrieve audit logs", "error", err)
@@ -39,6 +41,95 @@ func (as *AuditService) GetLogs() (*[]types.AuditLog, error) {
 	return &result.Data, nil
 }
 
+// QueryAuditLogs returns one page of audit logs matching the filter, newest first
+func (as *AuditService) QueryAuditLogs(filter types.AuditLogFilter) ([]types.AuditLog, error) {
+	filter.Normalize()
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", lib.ErrInvalidInput, err)
+	}
+
+	clause, args := BuildAuditLogConditions(filter)
+	query := Query().
+		SetOperation("select").
+		SetTable(lib.TableAuditLogs).
+		SetSelect([]string{"id", "timestamp", "level", "message", "attrs", "entry_hash", "source", "request_id"}).
+		AddOrder(fmt.Sprintf("%s.timestamp DESC", lib.TableAuditLogs)).
+		SetLimit(filter.Limit).
+		SetOffset((filter.Page - 1) * filter.Limit).
+		WithDB(database.GetAuditInstance().Conn())
+	if clause != "" {
+		query.SetWhereRaw(clause, args...)
+	}
+
+	result, err := database.ExecuteQuery[types.AuditLog](query)
+	if err != nil {
+		as.Logger.AuditError("Failed to query audit logs", "error", err)
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// CountAuditLogs returns the total number of audit logs matching the filter, ignoring pagination
+func (as *AuditService) CountAuditLogs(filter types.AuditLogFilter) (int, error) {
+	filter.Normalize()
+	if err := filter.Validate(); err != nil {
+		return 0, fmt.Errorf("%w: %v", lib.ErrInvalidInput, err)
+	}
+
+	clause, args := BuildAuditLogConditions(filter)
+	sql := fmt.Sprintf("SELECT COUNT(*) AS count FROM %s", lib.TableAuditLogs)
+	if clause != "" {
+		sql += " WHERE " + clause
+	}
+
+	query := Query().SetRawSQL(sql, args...).WithDB(database.GetAuditInstance().Conn())
+	result, err := database.ExecuteQuery[struct{ Count int }](query)
+	if err != nil {
+		as.Logger.AuditError("Failed to count audit logs", "error", err)
+		return 0, err
+	}
+	if result.Single == nil {
+		return 0, nil
+	}
+
+	return result.Single.Count, nil
+}
+
+// BuildAuditLogConditions translates a filter into a parameterized WHERE clause.
+// Returns an empty clause when the filter matches every log.
+func BuildAuditLogConditions(filter types.AuditLogFilter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	if filter.Level != "" {
+		conditions = append(conditions, fmt.Sprintf("%s.level = ?", lib.TableAuditLogs))
+		args = append(args, filter.Level)
+	}
+	if filter.Source != "" {
+		conditions = append(conditions, fmt.Sprintf("%s.source = ?", lib.TableAuditLogs))
+		args = append(args, filter.Source)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("%s.timestamp >= ?", lib.TableAuditLogs))
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("%s.timestamp <= ?", lib.TableAuditLogs))
+		args = append(args, filter.To)
+	}
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf(`%s.message ILIKE ? ESCAPE '\'`, lib.TableAuditLogs))
+		args = append(args, "%"+types.EscapeLikePattern(filter.Search)+"%")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+var _ AuditServiceInterface = (*AuditService)(nil)
+
 type AuditServiceInterface interface {
 	GetLogs() (*[]types.AuditLog, error)
+	QueryAuditLogs(filter types.AuditLogFilter) ([]types.AuditLog, error)
+	CountAuditLogs(filter types.AuditLogFilter) (int, error)
 }
@@ -1,8 +1,14 @@
 package services
 
 import (
+	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -12,7 +18,9 @@ import (
 	"github.com/MonkyMars/PWS/database"
 	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/types"
+	"github.com/MonkyMars/PWS/validate"
 
+	"github.com/go-pg/pg/v10"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/argon2"
@@ -43,36 +51,84 @@ func subtleCompare(a, b []byte) bool {
 	return res == 0
 }
 
+// pepperParam marks argon2 hashes whose password was mixed with the pepper before hashing
+const pepperParam = "pv=1"
+
 // AuthService provides authentication-related services
 type AuthService struct {
 	Logger       *config.Logger
 	config       *config.Config
 	cacheService *CacheService
+	pepper       []byte
 }
 
 func NewAuthService() *AuthService {
+	cfg := config.Get()
 	return &AuthService{
 		Logger:       config.SetupLogger(),
-		config:       config.Get(),
+		config:       cfg,
 		cacheService: NewCacheService(),
+		pepper:       []byte(cfg.Auth.PasswordPepper),
 	}
 }
 
-// HashPassword hashes a plain-text password and returns a string and possible error
+// NewAuthServiceWithPepper creates an AuthService that hashes passwords with the given pepper
+// instead of AUTH_PASSWORD_PEPPER, an empty pepper disables it
+func NewAuthServiceWithPepper(pepper string) *AuthService {
+	a := NewAuthService()
+	a.pepper = []byte(pepper)
+	return a
+}
+
+// HashPassword hashes a plain-text password and returns a string and possible error.
+// With a pepper configured the password is HMAC-ed with it first and the hash is marked with pv=1.
 func (a *AuthService) HashPassword(password string, p *types.ArgonParams) (string, error) {
 	salt, err := generateSalt(p.SaltLen)
 	if err != nil {
 		return "", err
 	}
-	hash := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	hash := argon2.IDKey(a.pepperPassword(password, len(a.pepper) > 0), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
 	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
 	b64Hash := base64.RawStdEncoding.EncodeToString(hash)
-	// format: $argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>
+	// format: $argon2id$v=19$m=65536,t=1,p=4[,pv=1]$<salt>$<hash>
 	params := fmt.Sprintf("m=%d,t=%d,p=%d", p.Memory, p.Time, p.Threads)
+	if len(a.pepper) > 0 {
+		params += "," + pepperParam
+	}
 	encoded := fmt.Sprintf("$argon2id$v=19$%s$%s$%s", params, b64Salt, b64Hash)
 	return encoded, nil
 }
 
+// NeedsRehash reports whether a stored hash should be replaced on the next successful login,
+// which is the case for hashes made without a pepper while one is configured
+func (a *AuthService) NeedsRehash(encoded string) bool {
+	return len(a.pepper) > 0 && !hashUsesPepper(encoded)
+}
+
+// pepperPassword returns the bytes fed to argon2, the HMAC-SHA256 of the password with the pepper when peppered
+func (a *AuthService) pepperPassword(password string, peppered bool) []byte {
+	if !peppered {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, a.pepper)
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
+}
+
+// hashUsesPepper reports whether an argon2 hash carries the pepper marker in its parameters
+func hashUsesPepper(encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false
+	}
+	for p := range strings.SplitSeq(parts[3], ",") {
+		if p == pepperParam {
+			return true
+		}
+	}
+	return false
+}
+
 // ComparePasswordAndHash compares a plain-text password with a hashed password
 // Returns true if they match, false otherwise + possible error
 // Supports both bcrypt (legacy) and argon2 (new) password hashes
@@ -138,7 +194,14 @@ func (a *AuthService) compareArgon2Hash(password, encoded string) (bool, error)
 	if expectedLen > 0x7FFFFFFF {
 		return false, fmt.Errorf("invalid hash length: %d", expectedLen)
 	}
-	hash := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(expectedLen))
+
+	// Hashes made before the pepper was introduced are verified without it
+	peppered := hashUsesPepper(encoded)
+	if peppered && len(a.pepper) == 0 {
+		return false, lib.ErrPepperMissing
+	}
+
+	hash := argon2.IDKey(a.pepperPassword(password, peppered), salt, time, memory, threads, uint32(expectedLen))
 	return subtleCompare(hash, expected), nil
 }
 
@@ -154,55 +217,130 @@ func (a *AuthService) GetAccessTokenExpiration() time.Time {
 
 // GenerateAccessToken generates a JWT access token for the given user
 func (a *AuthService) GenerateAccessToken(user *types.User) (string, error) {
-	secret := a.config.Auth.AccessTokenSecret
-
-	now := time.Now()
-	exp := a.GetAccessTokenExpiration()
-
-	claims := &types.AuthClaims{
-		Sub:   user.Id,
-		Email: user.Username,
-		Role:  user.Role,
-		Iat:   now,
-		Exp:   exp,
-		Jti:   uuid.New(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   claims.Sub.String(),
-		"email": claims.Email,
-		"role":  claims.Role,
-		"iat":   claims.Iat.Unix(),
-		"exp":   claims.Exp.Unix(),
-		"jti":   claims.Jti.String(),
-	})
-	return token.SignedString([]byte(secret))
+	return SignClaims(NewClaims(user, a.GetAccessTokenExpiration()), a.config.Auth.AccessTokenSecret)
 }
 
 // GenerateRefreshToken generates a JWT refresh token for the given user
 func (a *AuthService) GenerateRefreshToken(user *types.User) (string, error) {
-	secret := a.config.Auth.RefreshTokenSecret
+	return SignClaims(NewClaims(user, a.GetRefreshTokenExpiration()), a.config.Auth.RefreshTokenSecret)
+}
+
+// StartSession creates a session for the user and issues an access and refresh token bound to it.
+// Every authenticated request extends the session, see TouchSession.
+func (a *AuthService) StartSession(user *types.User) (*types.AuthResponse, error) {
+	sessionID := uuid.New()
+	session := &types.UserSession{UserID: user.Id, CreatedAt: time.Now()}
+	if err := a.cacheService.SetUserSession(sessionID, session, a.sessionTTL(session)); err != nil {
+		a.Logger.AuditError("Failed to store session", "error", err, "user_id", user.Id.String())
+		return nil, lib.ErrGeneratingToken
+	}
+
+	return a.issueSessionTokens(user, sessionID)
+}
+
+// issueSessionTokens signs an access and a refresh token for the session
+func (a *AuthService) issueSessionTokens(user *types.User, sessionID uuid.UUID) (*types.AuthResponse, error) {
+	accessClaims := NewClaims(user, a.GetAccessTokenExpiration())
+	accessClaims.Sid = sessionID
+	accessToken, err := SignClaims(accessClaims, a.config.Auth.AccessTokenSecret)
+	if err != nil {
+		return nil, lib.ErrGeneratingToken
+	}
+
+	refreshClaims := NewClaims(user, a.GetRefreshTokenExpiration())
+	refreshClaims.Sid = sessionID
+	refreshToken, err := SignClaims(refreshClaims, a.config.Auth.RefreshTokenSecret)
+	if err != nil {
+		return nil, lib.ErrGeneratingToken
+	}
+
+	return &types.AuthResponse{
+		User:         user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// sessionTTL is how long the session lives from now without further activity:
+// the idle timeout, cut short by the maximum lifetime counted from the login
+func (a *AuthService) sessionTTL(session *types.UserSession) time.Duration {
+	remaining := time.Until(session.CreatedAt.Add(a.config.Auth.SessionMaxLifetime))
+	return min(a.config.Auth.SessionIdleTimeout, remaining)
+}
+
+// TouchSession extends the session by the idle timeout, never past its maximum lifetime.
+// Returns lib.ErrSessionExpired when the session idled out, reached its maximum lifetime or was ended.
+// Other errors mean Redis could not be reached.
+func (a *AuthService) TouchSession(sessionID uuid.UUID) error {
+	session, err := a.cacheService.GetUserSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return lib.ErrSessionExpired
+	}
+
+	ttl := a.sessionTTL(session)
+	if ttl <= 0 {
+		if err := a.cacheService.DeleteUserSession(sessionID); err != nil {
+			a.Logger.Warn("Failed to delete session past its maximum lifetime", "error", err, "session_id", sessionID.String())
+		}
+		return lib.ErrSessionExpired
+	}
+
+	if err := a.cacheService.ExpireUserSession(sessionID, ttl); err != nil {
+		// The session expired between reading and extending it
+		if errors.Is(err, lib.ErrCacheKeyNotFound) {
+			return lib.ErrSessionExpired
+		}
+		return err
+	}
+	return nil
+}
+
+// EndSession removes the session so its tokens stop working, used on logout
+func (a *AuthService) EndSession(sessionID uuid.UUID) error {
+	return a.cacheService.DeleteUserSession(sessionID)
+}
 
+// NewClaims builds the claims for a new token of the current claims version
+func NewClaims(user *types.User, exp time.Time) *types.AuthClaims {
 	now := time.Now()
-	exp := a.GetRefreshTokenExpiration()
-
-	claims := &types.AuthClaims{
-		Sub:   user.Id,
-		Email: user.Username,
-		Role:  user.Role,
-		Iat:   now,
-		Exp:   exp,
-		Jti:   uuid.New(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   claims.Sub.String(),
-		"email": claims.Email,
-		"role":  claims.Role,
-		"iat":   claims.Iat.Unix(),
-		"exp":   claims.Exp.Unix(),
-		"jti":   claims.Jti.String(),
-	})
+	return &types.AuthClaims{
+		Sub:            user.Id,
+		Email:          user.Username,
+		Role:           user.Role,
+		Iat:            now,
+		Exp:            exp,
+		Jti:            uuid.New(),
+		Ver:            types.ClaimsVersionCurrent,
+		Nbf:            now,
+		Family:         uuid.New(),
+		TwoFactorLevel: types.TwoFactorLevelNone,
+	}
+}
+
+// SignClaims encodes the claims as an HS256 signed JWT
+func SignClaims(claims *types.AuthClaims, secret string) (string, error) {
+	mapClaims := jwt.MapClaims{
+		"sub":    claims.Sub.String(),
+		"email":  claims.Email,
+		"role":   claims.Role,
+		"iat":    claims.Iat.Unix(),
+		"exp":    claims.Exp.Unix(),
+		"jti":    claims.Jti.String(),
+		"ver":    claims.Ver,
+		"nbf":    claims.Nbf.Unix(),
+		"epoch":  claims.Epoch,
+		"family": claims.Family.String(),
+		"2fa":    claims.TwoFactorLevel,
+	}
+	// Tokens without a session leave the claim out, so they parse exactly as before sessions existed
+	if claims.Sid != uuid.Nil {
+		mapClaims["sid"] = claims.Sid.String()
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims)
 	return token.SignedString([]byte(secret))
 }
 
@@ -210,15 +348,21 @@ func (a *AuthService) GenerateRefreshToken(user *types.User) (string, error) {
 func (a *AuthService) ParseToken(tokenStr string, isAccessToken bool) (*types.AuthClaims, error) {
 	secret := a.config.Auth.AccessTokenSecret
 	if !isAccessToken {
-		secret = config.Get().Auth.RefreshTokenSecret
+		secret = a.config.Auth.RefreshTokenSecret
 	}
 
+	return ParseClaims(tokenStr, secret)
+}
+
+// ParseClaims verifies a JWT token signed with the given HMAC secret and extracts the claims.
+// Numeric claims are decoded as json.Number so timestamps convert to int64 exactly.
+func ParseClaims(tokenStr, secret string) (*types.AuthClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrTokenMalformed
 		}
 		return []byte(secret), nil
-	})
+	}, jwt.WithJSONNumber())
 	if err != nil {
 		return nil, err
 	}
@@ -245,14 +389,14 @@ func (a *AuthService) ParseToken(tokenStr string, isAccessToken bool) (*types.Au
 			return nil, fmt.Errorf("invalid role claim")
 		}
 
-		iat, ok := claims["iat"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("invalid iat claim")
+		iat, err := unixClaim(claims, "iat")
+		if err != nil {
+			return nil, err
 		}
 
-		exp, ok := claims["exp"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("invalid exp claim")
+		exp, err := unixClaim(claims, "exp")
+		if err != nil {
+			return nil, err
 		}
 
 		jtiStr, ok := claims["jti"].(string)
@@ -265,25 +409,124 @@ func (a *AuthService) ParseToken(tokenStr string, isAccessToken bool) (*types.Au
 			return nil, fmt.Errorf("invalid UUID in jti claim: %w", err)
 		}
 
-		return &types.AuthClaims{
+		parsed := &types.AuthClaims{
 			Sub:   sub,
 			Email: email,
 			Role:  role,
-			Iat:   time.Unix(int64(iat), 0),
-			Exp:   time.Unix(int64(exp), 0),
+			Iat:   time.Unix(iat, 0),
+			Exp:   time.Unix(exp, 0),
 			Jti:   jti,
-		}, nil
+		}
+		if err := parseVersionedClaims(claims, parsed); err != nil {
+			return nil, err
+		}
+		return parsed, nil
 	}
 	return nil, jwt.ErrInvalidKey
 }
 
+// parseVersionedClaims fills the claims added after the first token format.
+// Tokens issued before a claim existed still validate during rollout: a missing claim gets
+// the least privileged default, while a claim that is present but malformed is rejected.
+func parseVersionedClaims(claims jwt.MapClaims, parsed *types.AuthClaims) error {
+	ver, err := optionalIntClaim(claims, "ver", types.ClaimsVersionLegacy)
+	if err != nil {
+		return err
+	}
+	if ver < types.ClaimsVersionLegacy || ver > types.ClaimsVersionCurrent {
+		return fmt.Errorf("unsupported claims version %d", ver)
+	}
+	parsed.Ver = int(ver)
+
+	// Legacy tokens were valid from the moment they were issued
+	nbf := parsed.Iat.Unix()
+	if _, ok := claims["nbf"]; ok {
+		if nbf, err = unixClaim(claims, "nbf"); err != nil {
+			return err
+		}
+	}
+	parsed.Nbf = time.Unix(nbf, 0)
+
+	if parsed.Epoch, err = optionalIntClaim(claims, "epoch", 0); err != nil {
+		return err
+	}
+
+	// A legacy token is its own family so revoking a family never affects other sessions
+	parsed.Family = parsed.Jti
+	if raw, ok := claims["family"]; ok {
+		familyStr, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("invalid family claim")
+		}
+		if parsed.Family, err = uuid.Parse(familyStr); err != nil {
+			return fmt.Errorf("invalid UUID in family claim: %w", err)
+		}
+	}
+
+	level, err := optionalIntClaim(claims, "2fa", types.TwoFactorLevelNone)
+	if err != nil {
+		return err
+	}
+	if level < types.TwoFactorLevelNone {
+		return fmt.Errorf("invalid 2fa claim")
+	}
+	parsed.TwoFactorLevel = int(level)
+
+	// The session claim is optional in every version, tokens without it are not bound to a session
+	if raw, ok := claims["sid"]; ok {
+		sidStr, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("invalid sid claim")
+		}
+		if parsed.Sid, err = uuid.Parse(sidStr); err != nil {
+			return fmt.Errorf("invalid UUID in sid claim: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// optionalIntClaim reads an integer claim, returning the default when the claim is absent
+func optionalIntClaim(claims jwt.MapClaims, name string, defaultValue int64) (int64, error) {
+	if _, ok := claims[name]; !ok {
+		return defaultValue, nil
+	}
+
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("invalid %s claim", name)
+	}
+
+	value, err := number.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s claim: must be an integer", name)
+	}
+	return value, nil
+}
+
+// unixClaim reads a timestamp claim that must be a JSON integer of whole seconds
+func unixClaim(claims jwt.MapClaims, name string) (int64, error) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("invalid %s claim", name)
+	}
+
+	value, err := number.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s claim: must be an integer timestamp", name)
+	}
+
+	return value, nil
+}
+
 // Login authenticates a user and returns the user object if successful
-func (a *AuthService) Login(authRequest *types.AuthRequest) (*types.User, error) {
-	query := Query().SetOperation("SELECT").SetTable(lib.TableUsers).SetSelect([]string{"id", "username", "email", "password_hash", "role"}).SetLimit(1)
-	query.Where["public.users.email"] = authRequest.Email
+func (a *AuthService) Login(ctx context.Context, authRequest *types.AuthRequest) (*types.User, error) {
+	// Emails are unique regardless of case, lower() matches the idx_users_email_lower_unique index
+	query := Query().SetOperation("SELECT").SetTable(lib.TableUsers).SetSelect([]string{"id", "username", "email", "password_hash", "role"}).SetLimit(1).
+		SetWhereRaw("lower(public.users.email) = ?", validate.NormalizeEmail(authRequest.Email))
 
 	// Execute the query and get the user
-	user, err := database.ExecuteQuery[types.User](query)
+	user, err := database.ExecuteQuery[types.User](query.SetContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -301,26 +544,48 @@ func (a *AuthService) Login(authRequest *types.AuthRequest) (*types.User, error)
 		return nil, lib.ErrInvalidCredentials
 	}
 
-	// Remove password hash before returning user object
-	user.Single.PasswordHash = ""
+	// Upgrade hashes made without the pepper now that the plain password is known
+	if a.NeedsRehash(user.Single.PasswordHash) {
+		a.upgradePasswordHash(context.WithoutCancel(ctx), user.Single.Id, authRequest.Password)
+	}
 
-	go func() {
-		err = a.cacheService.SetUserInCache(user.Single)
-		if err != nil {
-			a.Logger.Warn("Failed to cache user after login", "error", err, "user_id", user.Single.Id.String())
-		}
-	}()
+	// Remove password hash before returning user object. The user is cached by GetUserByID, which
+	// knows the user id before it reads the database and so can guard against a concurrent role change.
+	user.Single.PasswordHash = ""
 
 	return user.Single, nil
 }
 
+// upgradePasswordHash replaces the stored hash of a user with a peppered one.
+// Failures are only logged, the old hash keeps working and is upgraded on a later login.
+func (a *AuthService) upgradePasswordHash(ctx context.Context, userID uuid.UUID, password string) {
+	hashedPassword, err := a.HashPassword(password, defaultParams)
+	if err != nil {
+		a.Logger.Warn("Failed to rehash password with pepper", "error", err, "user_id", userID.String())
+		return
+	}
+
+	query := Query().SetOperation("update").SetTable(lib.TableUsers).
+		SetData(map[string]any{"password_hash": hashedPassword}).
+		SetWhereRaw("public.users.id = ?", userID)
+	if _, err := database.ExecuteQuery[any](query.SetContext(ctx)); err != nil {
+		a.Logger.Warn("Failed to store peppered password hash", "error", err, "user_id", userID.String())
+		return
+	}
+
+	a.Logger.Info("Upgraded password hash with pepper", "user_id", userID.String())
+}
+
 // Register creates a new user account and returns the user object if successful
-func (a *AuthService) Register(registerRequest *types.RegisterRequest) (*types.User, error) {
+func (a *AuthService) Register(ctx context.Context, registerRequest *types.RegisterRequest) (*types.User, error) {
+	// Emails are stored trimmed and lowercase so accounts cannot differ only by case
+	email := validate.NormalizeEmail(registerRequest.Email)
+
 	// Check if user already with the same email exists. Same username is fine since students across different schools may share usernames.
-	query := Query().SetOperation("SELECT").SetTable(lib.TableUsers).SetSelect([]string{"public.users.id"}).SetLimit(1)
-	query.Where["public.users.email"] = registerRequest.Email
+	query := Query().SetOperation("SELECT").SetTable(lib.TableUsers).SetSelect([]string{"public.users.id"}).SetLimit(1).
+		SetWhereRaw("lower(public.users.email) = ?", email)
 
-	existingUser, err := database.ExecuteQuery[types.User](query)
+	existingUser, err := database.ExecuteQuery[types.User](query.SetContext(ctx))
 	if err == nil && existingUser.Single != nil {
 		return nil, lib.ErrUserAlreadyExists
 	}
@@ -338,13 +603,17 @@ func (a *AuthService) Register(registerRequest *types.RegisterRequest) (*types.U
 	insertQuery.Data = map[string]any{
 		"id":            newUserID,
 		"username":      registerRequest.Username,
-		"email":         registerRequest.Email,
+		"email":         email,
 		"password_hash": hashedPassword,
 		"role":          "student",
 	}
 	insertQuery.Returning = []string{"id", "username", "email", "role"}
 
-	result, err := database.ExecuteQuery[types.User](insertQuery)
+	result, err := database.ExecuteQuery[types.User](insertQuery.SetContext(ctx))
+	if database.IsUniqueViolation(err) {
+		// A concurrent registration with the same email won the race
+		return nil, lib.ErrUserAlreadyExists
+	}
 	if err != nil {
 		a.Logger.AuditError("Failed to create user during registration", "error", err)
 		return nil, lib.ErrCreateUser
@@ -358,7 +627,7 @@ func (a *AuthService) Register(registerRequest *types.RegisterRequest) (*types.U
 }
 
 // RefreshToken validates a refresh token and returns new JWT tokens with rotation for security
-func (a *AuthService) RefreshToken(refreshTokenStr string) (*types.AuthResponse, error) {
+func (a *AuthService) RefreshToken(ctx context.Context, refreshTokenStr string) (*types.AuthResponse, error) {
 	// Parse and validate refresh token
 	claims, err := a.ParseToken(refreshTokenStr, false)
 	if err != nil {
@@ -386,14 +655,21 @@ func (a *AuthService) RefreshToken(refreshTokenStr string) (*types.AuthResponse,
 		return nil, lib.ErrInvalidToken
 	}
 
+	// An idle or too old session cannot be refreshed, the user has to log in again
+	if claims.Sid != uuid.Nil {
+		if err := a.TouchSession(claims.Sid); errors.Is(err, lib.ErrSessionExpired) {
+			return nil, err
+		} else if err != nil {
+			a.Logger.Warn("Failed to extend session during refresh", "error", err, "session_id", claims.Sid.String())
+		}
+	}
+
 	// Get user from database to ensure they still exist
-	user, err := a.GetUserByID(claims.Sub)
+	user, err := a.GetUserByID(ctx, claims.Sub)
 	if err != nil || user == nil {
 		return nil, lib.ErrUserNotFound
 	}
 
-	fmt.Println("User found during token refresh:", user.Id)
-
 	// SECURITY: Immediately blacklist the old refresh token to prevent reuse
 	err = a.BlacklistToken(refreshTokenStr, false)
 	if err != nil {
@@ -405,27 +681,31 @@ func (a *AuthService) RefreshToken(refreshTokenStr string) (*types.AuthResponse,
 		// But log this as it could indicate Redis issues
 	}
 
-	// Generate new access token
-	accessToken, err := a.GenerateAccessToken(user)
-	if err != nil {
-		return nil, lib.ErrGeneratingToken
-	}
+	// Tokens without a session are rotated as they are
+	if claims.Sid == uuid.Nil {
+		accessToken, err := a.GenerateAccessToken(user)
+		if err != nil {
+			return nil, lib.ErrGeneratingToken
+		}
 
-	// Generate new refresh token (token rotation)
-	newRefreshToken, err := a.GenerateRefreshToken(user)
-	if err != nil {
-		return nil, lib.ErrGeneratingToken
+		newRefreshToken, err := a.GenerateRefreshToken(user)
+		if err != nil {
+			return nil, lib.ErrGeneratingToken
+		}
+
+		return &types.AuthResponse{
+			User:         user,
+			AccessToken:  accessToken,
+			RefreshToken: newRefreshToken,
+		}, nil
 	}
 
-	return &types.AuthResponse{
-		User:         user,
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-	}, nil
+	// Rotated tokens stay in the same session, which must still be alive
+	return a.issueSessionTokens(user, claims.Sid)
 }
 
 // GetUserFromToken extracts the user information from a valid JWT access token
-func (a *AuthService) GetUserFromToken(tokenStr string) (*types.User, error) {
+func (a *AuthService) GetUserFromToken(ctx context.Context, tokenStr string) (*types.User, error) {
 	// Parse and validate access token
 	claims, err := a.ParseToken(tokenStr, true)
 	if err != nil {
@@ -437,37 +717,49 @@ func (a *AuthService) GetUserFromToken(tokenStr string) (*types.User, error) {
 		return nil, fmt.Errorf("access token expired")
 	}
 
+	// Logged out tokens are rejected before they expire, when Redis is down the token is accepted like the middleware does
+	if revoked, err := a.IsTokenRevoked(claims.Jti); err != nil {
+		a.Logger.Warn("Failed to check token blacklist", "error", err, "jti", claims.Jti.String())
+	} else if revoked {
+		return nil, lib.ErrTokenRevoked
+	}
+
 	// Get user from database
-	user, err := a.GetUserByID(claims.Sub)
+	user, err := a.GetUserByID(ctx, claims.Sub)
 	if err != nil || user == nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, lib.ErrUserNotFound
 	}
 
 	return user, nil
 }
 
-func (a *AuthService) GetUserByID(userID uuid.UUID) (*types.User, error) {
+func (a *AuthService) GetUserByID(ctx context.Context, userID uuid.UUID) (*types.User, error) {
 	cachedUser, err := a.cacheService.GetUserFromCache(userID)
 	if err == nil && cachedUser != nil {
 		return cachedUser, nil
 	}
 
+	// The version is read before the database, so an invalidation after this point keeps the read below out of the cache
+	version, versionErr := a.cacheService.UserCacheVersion(userID)
+
 	// Get user from database
 	query := Query().SetOperation("SELECT").SetTable(lib.TableUsers).SetSelect([]string{"id", "username", "email", "role", "created_at"}).SetLimit(1)
 	query.Where["public.users.id"] = userID
 
-	user, err := database.ExecuteQuery[types.User](query)
-	if err != nil || user.Single == nil {
-		return nil, fmt.Errorf("user not found")
+	user, err := database.ExecuteQuery[types.User](query.SetContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if user.Single == nil {
+		return nil, lib.ErrUserNotFound
 	}
 
 	// Cache the user for subsequent requests
-	go func() {
-		err := a.cacheService.SetUserInCache(user.Single)
-		if err != nil {
-			a.Logger.AuditWarn("Failed to cache user after database fetch", "error", err, "user_id", userID.String())
-		}
-	}()
+	if versionErr != nil {
+		a.Logger.Warn("Failed to read user cache version, not caching user", "error", versionErr, "user_id", userID.String())
+	} else if err := a.cacheService.SetUserInCache(user.Single, version); err != nil {
+		a.Logger.AuditWarn("Failed to cache user after database fetch", "error", err, "user_id", userID.String())
+	}
 
 	return user.Single, nil
 }
@@ -480,31 +772,206 @@ func (a *AuthService) BlacklistToken(tokenStr string, isAccessToken bool) error
 	}
 
 	// Store the token's JTI in Redis until it expires
-	return a.cacheService.BlacklistToken(claims.Jti.String(), claims.Exp)
+	return a.cacheService.BlacklistToken(claims.Jti, claims.Exp)
+}
+
+// IsTokenRevoked reports whether the token with the given JTI was blacklisted, for example on logout
+func (a *AuthService) IsTokenRevoked(jti uuid.UUID) (bool, error) {
+	return a.cacheService.IsTokenBlacklisted(jti)
 }
 
 func (a *AuthService) ClearUserCache(userID uuid.UUID) error {
 	return a.cacheService.DeleteUserFromCache(userID)
 }
 
+// APIKeyPrefix marks API keys so they can be told apart from JWTs in an Authorization header
+const APIKeyPrefix = "pws_"
+
+// apiKeyDisplayLength is the number of leading key characters stored for display purposes
+const apiKeyDisplayLength = 12
+
+// GenerateAPIKey creates a new random API key and returns it together with its hash.
+// The key is only returned once; callers must store the hash, never the key itself.
+func GenerateAPIKey() (key string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of an API key.
+// API keys carry 256 bits of entropy, so a fast hash is sufficient and keeps lookups cheap.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey generates a new API key for the user and stores its hash.
+// The returned key is shown to the user once and cannot be retrieved again.
+func (a *AuthService) CreateAPIKey(ctx context.Context, userID uuid.UUID) (*types.CreatedAPIKey, error) {
+	return a.insertAPIKey(ctx, nil, userID)
+}
+
+// RotateAPIKey revokes one of the user's API keys and issues its replacement in one transaction,
+// so the user is never left without a key when storing the new one fails
+func (a *AuthService) RotateAPIKey(ctx context.Context, userID, keyID uuid.UUID) (*types.CreatedAPIKey, error) {
+	var created *types.CreatedAPIKey
+	err := database.Transaction(ctx, func(tx *pg.Tx) error {
+		if err := a.revokeAPIKey(ctx, tx, userID, keyID); err != nil {
+			return err
+		}
+
+		var err error
+		created, err = a.insertAPIKey(ctx, tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.Logger.Info("API key rotated", "user_id", userID.String(), "api_key_id", keyID.String(), "new_api_key_id", created.Id.String())
+	return created, nil
+}
+
+// insertAPIKey generates and stores a new API key for the user, inside tx when it is not nil
+func (a *AuthService) insertAPIKey(ctx context.Context, tx *pg.Tx, userID uuid.UUID) (*types.CreatedAPIKey, error) {
+	key, hash, err := GenerateAPIKey()
+	if err != nil {
+		a.Logger.AuditError("Failed to generate API key", "error", err, "user_id", userID.String())
+		return nil, lib.ErrGeneratingToken
+	}
+
+	query := Query().SetOperation("insert").SetTable(lib.TableAPIKeys).SetReturning("id", "prefix", "created_at")
+	query.Data = map[string]any{
+		"user_id":  userID,
+		"prefix":   key[:apiKeyDisplayLength],
+		"key_hash": hash,
+	}
+
+	result, err := database.ExecuteQuery[types.APIKey](query.WithTx(tx).SetContext(ctx))
+	if err != nil {
+		a.Logger.AuditError("Failed to store API key", "error", err, "user_id", userID.String())
+		return nil, lib.ErrGeneratingToken
+	}
+	if result.Single == nil {
+		return nil, lib.ErrGeneratingToken
+	}
+
+	a.Logger.Info("API key created", "user_id", userID.String(), "api_key_id", result.Single.Id.String())
+
+	return &types.CreatedAPIKey{
+		Id:        result.Single.Id,
+		Key:       key,
+		Prefix:    result.Single.Prefix,
+		CreatedAt: result.Single.CreatedAt,
+	}, nil
+}
+
+// RevokeAPIKey revokes one of the user's API keys so it can no longer authenticate
+func (a *AuthService) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	return a.revokeAPIKey(ctx, nil, userID, keyID)
+}
+
+// revokeAPIKey revokes one of the user's API keys, inside tx when it is not nil
+func (a *AuthService) revokeAPIKey(ctx context.Context, tx *pg.Tx, userID, keyID uuid.UUID) error {
+	query := Query().SetOperation("update").SetTable(lib.TableAPIKeys).
+		AddData("revoked_at", time.Now()).
+		AddWhere("id", keyID).
+		AddWhere("user_id", userID).
+		SetWhereRaw("revoked_at IS NULL")
+
+	result, err := database.ExecuteQuery[types.APIKey](query.WithTx(tx).SetContext(ctx))
+	if err != nil {
+		return err
+	}
+	if result.Count == 0 {
+		return lib.ErrAPIKeyNotFound
+	}
+
+	a.Logger.Info("API key revoked", "user_id", userID.String(), "api_key_id", keyID.String())
+	return nil
+}
+
+// AuthenticateAPIKey resolves the user owning a valid, unrevoked API key
+func (a *AuthService) AuthenticateAPIKey(ctx context.Context, key string) (*types.User, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, lib.ErrInvalidAPIKey
+	}
+
+	hash := HashAPIKey(key)
+	query := Query().SetOperation("select").SetTable(lib.TableAPIKeys).
+		SetSelect([]string{"id", "user_id", "key_hash"}).
+		AddWhere("key_hash", hash).
+		SetWhereRaw("revoked_at IS NULL").
+		SetLimit(1)
+
+	result, err := database.ExecuteQuery[types.APIKey](query.SetContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if result.Single == nil || !subtleCompare([]byte(result.Single.KeyHash), []byte(hash)) {
+		return nil, lib.ErrInvalidAPIKey
+	}
+
+	user, err := a.GetUserByID(ctx, result.Single.UserId)
+	if err != nil || user == nil {
+		return nil, lib.ErrInvalidAPIKey
+	}
+
+	a.touchAPIKey(ctx, result.Single.Id)
+
+	return user, nil
+}
+
+// apiKeyTouchInterval limits how often last_used_at is written for a key that is used on every request
+const apiKeyTouchInterval = time.Minute
+
+// touchAPIKey records that an API key was used. A failure is only logged, it does not fail the request.
+func (a *AuthService) touchAPIKey(ctx context.Context, keyID uuid.UUID) {
+	now := time.Now()
+	query := Query().SetOperation("update").SetTable(lib.TableAPIKeys).
+		AddData("last_used_at", now).
+		AddWhere("id", keyID).
+		SetWhereRaw("(last_used_at IS NULL OR last_used_at < ?)", now.Add(-apiKeyTouchInterval))
+
+	if _, err := database.ExecuteQuery[types.APIKey](query.SetContext(ctx)); err != nil {
+		a.Logger.Warn("Failed to update API key last use", "error", err, "api_key_id", keyID.String())
+	}
+}
+
+var _ AuthServiceInterface = (*AuthService)(nil)
+
 // AuthServiceInterface defines the methods that any auth service implementation must provide.
 type AuthServiceInterface interface {
 	// Authentication methods
-	Login(authRequest *types.AuthRequest) (*types.User, error)
-	Register(regRequest *types.RegisterRequest) (*types.User, error)
-	RefreshToken(refreshTokenStr string) (*types.AuthResponse, error)
+	Login(ctx context.Context, authRequest *types.AuthRequest) (*types.User, error)
+	Register(ctx context.Context, regRequest *types.RegisterRequest) (*types.User, error)
+	RefreshToken(ctx context.Context, refreshTokenStr string) (*types.AuthResponse, error)
 
 	// Token generation and management
 	GenerateAccessToken(user *types.User) (string, error)
 	GenerateRefreshToken(user *types.User) (string, error)
+	StartSession(user *types.User) (*types.AuthResponse, error)
+	TouchSession(sessionID uuid.UUID) error
+	EndSession(sessionID uuid.UUID) error
 	ParseToken(tokenStr string, isAccessToken bool) (*types.AuthClaims, error)
 	BlacklistToken(tokenStr string, isAccessToken bool) error
+	IsTokenRevoked(jti uuid.UUID) (bool, error)
 	GetAccessTokenExpiration() time.Time
 	GetRefreshTokenExpiration() time.Time
 
+	// API key management
+	CreateAPIKey(ctx context.Context, userID uuid.UUID) (*types.CreatedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error
+	RotateAPIKey(ctx context.Context, userID, keyID uuid.UUID) (*types.CreatedAPIKey, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (*types.User, error)
+
 	// User management
-	GetUserByID(userID uuid.UUID) (*types.User, error)
-	GetUserFromToken(tokenStr string) (*types.User, error)
+	GetUserByID(ctx context.Context, userID uuid.UUID) (*types.User, error)
+	GetUserFromToken(ctx context.Context, tokenStr string) (*types.User, error)
 
 	// Cache management
 	ClearUserCache(userID uuid.UUID) error
@@ -512,4 +979,5 @@ type AuthServiceInterface interface {
 	// Password management
 	HashPassword(password string, p *types.ArgonParams) (string, error)
 	ComparePasswordAndHash(password, encoded string) (bool, error)
+	NeedsRehash(encoded string) bool
 }
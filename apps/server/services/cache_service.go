@@ -5,11 +5,13 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/types"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
@@ -191,6 +193,99 @@ func (cs *CacheService) Get(key string) (string, error) {
 	return result, resultErr
 }
 
+// SetJSON stores value encoded as JSON with TTL, a zero TTL keeps the key until it is deleted
+func (cs *CacheService) SetJSON(key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache value for %s: %w", key, err)
+	}
+	return cs.Set(key, data, ttl)
+}
+
+// GetJSON decodes the JSON stored under key into target and reports whether the key was found.
+// A missing key leaves target untouched and is not an error.
+func (cs *CacheService) GetJSON(key string, target any) (bool, error) {
+	val, err := cs.Get(key)
+	if err != nil {
+		return false, err
+	}
+	if val == "" {
+		return false, nil
+	}
+
+	if err := json.Unmarshal([]byte(val), target); err != nil {
+		return false, fmt.Errorf("failed to decode cache value for %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// CacheEntry is a value to store with SetMany, a zero TTL keeps the key until it is deleted
+type CacheEntry struct {
+	Value any
+	TTL   time.Duration
+}
+
+// SetMany stores several keys in one pipelined round trip.
+// The whole pipeline is retried on connection errors, setting a key twice is harmless.
+func (cs *CacheService) SetMany(entries map[string]CacheEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	client := GetRedisClient()
+
+	return cs.withRetry(func() error {
+		_, err := client.Pipelined(redisCtx, func(pipe redis.Pipeliner) error {
+			for key, entry := range entries {
+				pipe.Set(redisCtx, key, entry.Value, entry.TTL)
+			}
+			return nil
+		})
+		return err
+	}, 3)
+}
+
+// GetMany retrieves several keys in one pipelined round trip.
+// Keys that do not exist are left out of the returned map.
+func (cs *CacheService) GetMany(keys []string) (map[string]string, error) {
+	result := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	client := GetRedisClient()
+
+	err := cs.withRetry(func() error {
+		cmds := make([]*redis.StringCmd, len(keys))
+		// A missing key makes Exec return redis.Nil, the per key results are checked below
+		_, err := client.Pipelined(redisCtx, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = pipe.Get(redisCtx, key)
+			}
+			return nil
+		})
+		if err != nil && err != redis.Nil {
+			return err
+		}
+
+		clear(result)
+		for i, cmd := range cmds {
+			val, err := cmd.Result()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			result[keys[i]] = val
+		}
+		return nil
+	}, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // Delete removes a key with automatic retry logic
 func (cs *CacheService) Delete(key string) error {
 	client := GetRedisClient()
@@ -217,64 +312,206 @@ func (cs *CacheService) Exists(key string) (bool, error) {
 	return result, err
 }
 
-// BlacklistToken adds a token's jti to the blacklist with expiration and retry logic
-func (cs *CacheService) BlacklistToken(jti string, exp time.Time) error {
-	ttl := cs.config.Auth.BlacklistCacheTTL
+// blacklistKey is the Redis key marking a token's jti as blacklisted
+func blacklistKey(jti uuid.UUID) string {
+	return fmt.Sprintf("blacklist:%s", jti.String())
+}
+
+// blacklistTTL keeps a blacklist entry until the token expires, or for BlacklistCacheTTL
+// when the expiry has already passed
+func (cs *CacheService) blacklistTTL(exp time.Time) time.Duration {
 	if exp.After(time.Now()) {
-		ttl = time.Until(exp)
+		return time.Until(exp)
 	}
+	return cs.config.Auth.BlacklistCacheTTL
+}
+
+// BlacklistToken adds a token's jti to the blacklist with expiration and retry logic
+func (cs *CacheService) BlacklistToken(jti uuid.UUID, exp time.Time) error {
+	return cs.Set(blacklistKey(jti), BlacklistMarker, cs.blacklistTTL(exp))
+}
 
-	key := fmt.Sprintf("blacklist:%s", jti)
-	return cs.Set(key, "true", ttl)
+// BlacklistEntry is a token to blacklist with BlacklistTokens
+type BlacklistEntry struct {
+	JTI uuid.UUID
+	Exp time.Time
 }
 
-// IsTokenBlacklisted checks if a JTI exists in Redis with retry logic
+// BlacklistTokens blacklists several tokens in one pipelined round trip, for example when all
+// sessions of a user are revoked. Each entry expires with its own token, like BlacklistToken.
+func (cs *CacheService) BlacklistTokens(entries []BlacklistEntry) error {
+	keys := make(map[string]CacheEntry, len(entries))
+	for _, entry := range entries {
+		keys[blacklistKey(entry.JTI)] = CacheEntry{Value: BlacklistMarker, TTL: cs.blacklistTTL(entry.Exp)}
+	}
+	return cs.SetMany(keys)
+}
+
+// BlacklistMarker is the value stored under a blacklisted token's key
+const BlacklistMarker = "true"
+
+// IsTokenBlacklisted checks if a JTI exists in Redis with retry logic.
+// Unexpected values are treated as blacklisted so a corrupted entry can never let a revoked token through.
 func (cs *CacheService) IsTokenBlacklisted(jti uuid.UUID) (bool, error) {
-	key := fmt.Sprintf("blacklist:%s", jti.String())
-	val, err := cs.Get(key)
+	val, err := cs.Get(blacklistKey(jti))
 	if err != nil {
 		return false, err
 	}
 
-	return val == "true", nil
+	blacklisted, err := ParseBlacklistValue(val)
+	if err != nil {
+		cs.logger.AuditWarn("Unexpected token blacklist value, treating token as blacklisted",
+			"jti", jti.String(),
+			"error", err)
+	}
+
+	return blacklisted, nil
 }
 
-// Get UserFromCache retrieves a user object from cache using userID
-func (cs *CacheService) GetUserFromCache(userID uuid.UUID) (*types.User, error) {
-	key := fmt.Sprintf("user:%s", userID.String())
-	val, err := cs.Get(key)
-	if err != nil {
-		return nil, err
+// ParseBlacklistValue interprets a stored blacklist value. A missing key is not blacklisted
+// and the marker is. Any other value is reported as blacklisted together with
+// lib.ErrUnexpectedBlacklistValue so callers can fail safe and log the anomaly.
+func ParseBlacklistValue(val string) (bool, error) {
+	switch val {
+	case "":
+		return false, nil
+	case BlacklistMarker:
+		return true, nil
+	default:
+		return true, fmt.Errorf("%w: %q", lib.ErrUnexpectedBlacklistValue, val)
 	}
+}
 
-	if val == "" {
-		return nil, nil // not found in cache
+// userCacheKey is the Redis key of a cached user record
+func userCacheKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user:%s", userID.String())
+}
+
+// userCacheVersionKey is the Redis key of the version DeleteUserFromCache raises on every invalidation
+func userCacheVersionKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user_version:%s", userID.String())
+}
+
+// userCacheVersionTTL keeps a version well past any lookup still in flight when the user was invalidated
+const userCacheVersionTTL = 24 * time.Hour
+
+// setUserScript stores the user in KEYS[1] for ARGV[3] milliseconds, but only while the version
+// in KEYS[2] still equals ARGV[2], a missing version counting as 0. Returns 1 when it was stored.
+var setUserScript = redis.NewScript(`
+local version = redis.call("GET", KEYS[2]) or "0"
+if version ~= ARGV[2] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+return 1
+`)
+
+// invalidateUserScript raises the version in KEYS[2] and deletes the user in KEYS[1]
+var invalidateUserScript = redis.NewScript(`
+redis.call("INCR", KEYS[2])
+redis.call("PEXPIRE", KEYS[2], ARGV[1])
+redis.call("DEL", KEYS[1])
+return 1
+`)
+
+// UserCacheVersion returns the cache version of a user. Read it before loading the user from the
+// database and pass it to SetUserInCache, so an invalidation in between is not overwritten.
+func (cs *CacheService) UserCacheVersion(userID uuid.UUID) (int64, error) {
+	val, err := cs.Get(userCacheVersionKey(userID))
+	if err != nil || val == "" {
+		return 0, err
 	}
 
-	user := &types.User{}
-	err = json.Unmarshal([]byte(val), user)
+	version, err := strconv.ParseInt(val, 10, 64)
 	if err != nil {
+		return 0, fmt.Errorf("invalid user cache version %q: %w", val, err)
+	}
+	return version, nil
+}
+
+// GetUserFromCache retrieves a user object from cache using userID, nil when it is not cached
+func (cs *CacheService) GetUserFromCache(userID uuid.UUID) (*types.User, error) {
+	user := &types.User{}
+	found, err := cs.GetJSON(userCacheKey(userID), user)
+	if err != nil || !found {
 		return nil, err
 	}
 
 	return user, nil
 }
 
-// SetUserInCache stores a user object in cache with TTL
-func (cs *CacheService) SetUserInCache(user *types.User) error {
-	key := fmt.Sprintf("user:%s", user.Id.String())
-	data, err := json.Marshal(user)
+// SetUserInCache stores a user object in cache for CACHE_USER_TTL. The password hash is never cached.
+// The user is only stored while its cache version still equals version, see UserCacheVersion,
+// so a record read before a role change cannot replace the invalidation.
+func (cs *CacheService) SetUserInCache(user *types.User, version int64) error {
+	cached := *user
+	cached.PasswordHash = ""
+
+	data, err := json.Marshal(cached)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to encode cached user %s: %w", user.Id, err)
 	}
+	client := GetRedisClient()
+	keys := []string{userCacheKey(user.Id), userCacheVersionKey(user.Id)}
 
-	return cs.Set(key, data, cs.config.Auth.CacheUserTTL)
+	return cs.withRetry(func() error {
+		return setUserScript.Run(redisCtx, client, keys, data, version, cs.config.Auth.CacheUserTTL.Milliseconds()).Err()
+	}, 3)
 }
 
-// DeleteUserFromCache removes a user object from cache
+// DeleteUserFromCache removes a user object from cache and raises its cache version,
+// the next lookup reads the user from the database
 func (cs *CacheService) DeleteUserFromCache(userID uuid.UUID) error {
-	key := fmt.Sprintf("user:%s", userID.String())
-	return cs.Delete(key)
+	client := GetRedisClient()
+	keys := []string{userCacheKey(userID), userCacheVersionKey(userID)}
+
+	return cs.withRetry(func() error {
+		return invalidateUserScript.Run(redisCtx, client, keys, userCacheVersionTTL.Milliseconds()).Err()
+	}, 3)
+}
+
+// sessionKey is the Redis key of a user session
+func sessionKey(sessionID uuid.UUID) string {
+	return fmt.Sprintf("session:%s", sessionID.String())
+}
+
+// SetUserSession stores a session that expires after ttl unless it is extended
+func (cs *CacheService) SetUserSession(sessionID uuid.UUID, session *types.UserSession, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return cs.Set(sessionKey(sessionID), data, ttl)
+}
+
+// GetUserSession retrieves a session, nil when it does not exist or has expired
+func (cs *CacheService) GetUserSession(sessionID uuid.UUID) (*types.UserSession, error) {
+	val, err := cs.Get(sessionKey(sessionID))
+	if err != nil {
+		return nil, err
+	}
+
+	if val == "" {
+		return nil, nil
+	}
+
+	session := &types.UserSession{}
+	if err := json.Unmarshal([]byte(val), session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ExpireUserSession sets the remaining lifetime of a session, lib.ErrCacheKeyNotFound when it already expired
+func (cs *CacheService) ExpireUserSession(sessionID uuid.UUID, ttl time.Duration) error {
+	return cs.Expire(sessionKey(sessionID), ttl)
+}
+
+// DeleteUserSession ends a session
+func (cs *CacheService) DeleteUserSession(sessionID uuid.UUID) error {
+	return cs.Delete(sessionKey(sessionID))
 }
 
 // SetRateLimit sets a rate limit counter for an IP/endpoint combination
@@ -332,12 +569,209 @@ func (cs *CacheService) IncrementRateLimit(ip, endpoint string, ttl time.Duratio
 	return int(result), err
 }
 
+// RateLimitTTL returns the time left in the current rate limit window of a client and endpoint
+func (cs *CacheService) RateLimitTTL(ip, endpoint string) (time.Duration, error) {
+	client := GetRedisClient()
+	key := fmt.Sprintf("ratelimit:%s:%s", ip, endpoint)
+
+	var ttl time.Duration
+	err := cs.withRetry(func() error {
+		val, err := client.TTL(redisCtx, key).Result()
+		if err != nil {
+			return err
+		}
+		ttl = val
+		return nil
+	}, 3)
+
+	return ttl, err
+}
+
+// NoExpiry is the TTL reported for a key that is kept until it is deleted
+const NoExpiry time.Duration = -1
+
+// TTL returns how long the key lives before it expires, NoExpiry for a key without a TTL,
+// or lib.ErrCacheKeyNotFound when the key does not exist
+func (cs *CacheService) TTL(key string) (time.Duration, error) {
+	client := GetRedisClient()
+
+	var ttl time.Duration
+	err := cs.withRetry(func() error {
+		val, err := client.PTTL(redisCtx, key).Result()
+		if err != nil {
+			return err
+		}
+		ttl = val
+		return nil
+	}, 3)
+	if err != nil {
+		return 0, err
+	}
+
+	// Redis reports -2 for a missing key and -1 for a key without a TTL
+	switch ttl {
+	case -2:
+		return 0, lib.ErrCacheKeyNotFound
+	case -1:
+		return NoExpiry, nil
+	}
+	return ttl, nil
+}
+
+// Expire sets the TTL of an existing key, shortening or extending it.
+// Returns lib.ErrCacheKeyNotFound when the key does not exist.
+func (cs *CacheService) Expire(key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("expire %s: ttl must be positive, got %s", key, ttl)
+	}
+	client := GetRedisClient()
+
+	var found bool
+	err := cs.withRetry(func() error {
+		ok, err := client.PExpire(redisCtx, key, ttl).Result()
+		if err != nil {
+			return err
+		}
+		found = ok
+		return nil
+	}, 3)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return lib.ErrCacheKeyNotFound
+	}
+	return nil
+}
+
+// touchScript raises the TTL of a key to ARGV[1] milliseconds without ever shortening it.
+// Keys without a TTL are left alone. Returns 0 when the key does not exist.
+var touchScript = redis.NewScript(`
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl == -2 then
+	return 0
+end
+if ttl ~= -1 and ttl < tonumber(ARGV[1]) then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// Touch extends the TTL of an existing key to at least ttl without rewriting its value,
+// so an active session keeps sliding forward. It reports false when the key no longer exists.
+func (cs *CacheService) Touch(key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, fmt.Errorf("touch %s: ttl must be positive, got %s", key, ttl)
+	}
+	client := GetRedisClient()
+
+	var found bool
+	err := cs.withRetry(func() error {
+		res, err := touchScript.Run(redisCtx, client, []string{key}, ttl.Milliseconds()).Int()
+		if err != nil {
+			return err
+		}
+		found = res == 1
+		return nil
+	}, 3)
+
+	return found, err
+}
+
+// releaseLockScript deletes a lock only if it is still held by the caller's token,
+// so an expired lock that was taken over by another request is never released by mistake
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireLock tries to take the lock stored under key for at most ttl.
+// Returns the token needed to release the lock and whether the lock was acquired.
+func (cs *CacheService) AcquireLock(key string, ttl time.Duration) (string, bool, error) {
+	client := GetRedisClient()
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", false, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	token := fmt.Sprintf("%x", tokenBytes)
+
+	var acquired bool
+	err := cs.withRetry(func() error {
+		ok, err := client.SetNX(redisCtx, "lock:"+key, token, ttl).Result()
+		if err != nil {
+			return err
+		}
+		acquired = ok
+		return nil
+	}, 3)
+	if err != nil {
+		return "", false, err
+	}
+
+	return token, acquired, nil
+}
+
+// ReleaseLock releases the lock stored under key if it is still held with the given token
+func (cs *CacheService) ReleaseLock(key, token string) error {
+	client := GetRedisClient()
+
+	return cs.withRetry(func() error {
+		return releaseLockScript.Run(redisCtx, client, []string{"lock:" + key}, token).Err()
+	}, 3)
+}
+
+// lockPollInterval is how often WithLock retries a lock that is held elsewhere
+const lockPollInterval = 50 * time.Millisecond
+
+// DistributedLocker is the subset of the cache service used for short-lived locks.
+// It is satisfied by CacheService and can be replaced in tests.
+type DistributedLocker interface {
+	AcquireLock(key string, ttl time.Duration) (string, bool, error)
+	ReleaseLock(key, token string) error
+}
+
+// WithLock runs fn while holding the lock for key, so concurrent callers with the same key run one at a time.
+// A caller that finds the lock taken retries until wait elapses and then gives up with lib.ErrLockTimeout.
+// The lock expires after ttl, even if the holder never releases it.
+func WithLock(locker DistributedLocker, key string, ttl, wait time.Duration, fn func() error) error {
+	deadline := time.Now().Add(wait)
+	for {
+		token, acquired, err := locker.AcquireLock(key, ttl)
+		if err != nil {
+			return fmt.Errorf("%w: failed to acquire lock %s: %v", lib.ErrServiceUnavailable, key, err)
+		}
+
+		if acquired {
+			// A failed release is harmless, the lock expires after ttl
+			defer locker.ReleaseLock(key, token)
+			return fn()
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%w: %s", lib.ErrLockTimeout, key)
+		}
+		time.Sleep(min(lockPollInterval, remaining))
+	}
+}
+
 // Ping tests the Redis connection
 func (cs *CacheService) Ping() error {
+	return cs.PingContext(redisCtx)
+}
+
+// PingContext tests the Redis connection like Ping, giving up once ctx is done
+func (cs *CacheService) PingContext(ctx context.Context) error {
 	client := GetRedisClient()
 
 	return cs.withRetry(func() error {
-		return client.Ping(redisCtx).Err()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return client.Ping(ctx).Err()
 	}, 3)
 }
 
@@ -457,65 +891,64 @@ func (cs *CacheService) GetBlacklistedTokensCount() (int, error) {
 	return count, err
 }
 
-// GetRateLimitStatus returns current rate limit information for debugging
-func (cs *CacheService) GetRateLimitStatus(ip, endpoint string) (map[string]any, error) {
+// GetRateLimitStatus returns where a client stands in its current rate limit window for an endpoint,
+// given the number of requests allowed per window
+func (cs *CacheService) GetRateLimitStatus(ip, endpoint string, limit int) (types.RateLimitStatus, error) {
 	key := fmt.Sprintf("ratelimit:%s:%s", ip, endpoint)
 
 	client := GetRedisClient()
-	var result map[string]any
+	var status types.RateLimitStatus
 
 	err := cs.withRetry(func() error {
-		// Get current count
-		val, err := client.Get(redisCtx, key).Result()
+		count, err := client.Get(redisCtx, key).Int()
 		if err == redis.Nil {
-			result = map[string]any{
-				"count": 0,
-				"ttl":   0,
-			}
+			status = types.NewRateLimitStatus(0, limit, 0)
 			return nil
 		}
 		if err != nil {
 			return err
 		}
 
-		// Get TTL
 		ttl, err := client.TTL(redisCtx, key).Result()
 		if err != nil {
 			return err
 		}
 
-		// Parse count
-		count := 0
-		for _, char := range val {
-			if char >= '0' && char <= '9' {
-				count = count*10 + int(char-'0')
-			} else {
-				break
-			}
-		}
-
-		result = map[string]any{
-			"count": count,
-			"ttl":   int(ttl.Seconds()),
-		}
+		status = types.NewRateLimitStatus(count, limit, ttl)
 		return nil
 	}, 3)
 
-	return result, err
+	return status, err
 }
 
+var (
+	_ CacheServiceInterface = (*CacheService)(nil)
+	_ DistributedLocker     = (*CacheService)(nil)
+	_ ReadOnlyStore         = (*CacheService)(nil)
+)
+
 type CacheServiceInterface interface {
 	Set(key string, value any, ttl time.Duration) error
 	Get(key string) (string, error)
 	Delete(key string) error
 	Exists(key string) (bool, error)
+	SetMany(entries map[string]CacheEntry) error
+	GetMany(keys []string) (map[string]string, error)
+	TTL(key string) (time.Duration, error)
+	Expire(key string, ttl time.Duration) error
+	Touch(key string, ttl time.Duration) (bool, error)
 
 	BlacklistToken(jti uuid.UUID, exp time.Time) error
+	BlacklistTokens(entries []BlacklistEntry) error
 	IsTokenBlacklisted(jti uuid.UUID) (bool, error)
 
 	SetRateLimit(ip, endpoint string, count int, ttl time.Duration) error
 	GetRateLimit(ip, endpoint string) (int, error)
 	IncrementRateLimit(ip, endpoint string, ttl time.Duration) (int, error)
+	RateLimitTTL(ip, endpoint string) (time.Duration, error)
+
+	AcquireLock(key string, ttl time.Duration) (string, bool, error)
+	ReleaseLock(key, token string) error
 
 	Ping() error
 	GetConnectionStats() map[string]any
@@ -524,5 +957,5 @@ type CacheServiceInterface interface {
 
 	FlushBlacklistedTokens() error
 	GetBlacklistedTokensCount() (int, error)
-	GetRateLimitStatus(ip, endpoint string) (map[string]any, error)
+	GetRateLimitStatus(ip, endpoint string, limit int) (types.RateLimitStatus, error)
 }
@@ -16,7 +16,7 @@ func NewContentService() *ContentService {
 
 func (cs *ContentService) GetFileByID(fileID string) (*types.File, error) {
 	if fileID == "" {
-		return nil, fmt.Errorf("fileID parameter is required")
+		return nil, lib.NewValidationError("fileId", "fileId parameter is required")
 	}
 
 	query := Query().SetOperation("select").SetTable("files").SetLimit(1).SetSelect([]string{
@@ -124,6 +124,8 @@ func (cs *ContentService) CreateMultipleFiles(filesData []map[string]any) ([]*ty
 	return files, nil
 }
 
+var _ ContentServiceInterface = (*ContentService)(nil)
+
 // ContentServiceInterface defines the methods that any content service implementation must provide.
 type ContentServiceInterface interface {
 	// File operations
@@ -1,96 +1,106 @@
 package services
 
 import (
+	"crypto/rand"
 	"time"
 
 	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
 )
 
 type CookieService struct {
 	AccessTokenExpiry  time.Duration
 	RefreshTokenExpiry time.Duration
+
+	// Cookie overrides the cookie attributes of the configuration when set
+	Cookie *types.CookieConfig
 }
 
 func NewCookieService() *CookieService {
 	return &CookieService{}
 }
 
+// NewCookieServiceWithConfig creates a CookieService that sets the given cookie attributes
+func NewCookieServiceWithConfig(cookie types.CookieConfig) *CookieService {
+	return &CookieService{Cookie: &cookie}
+}
+
 func (co *CookieService) GetCookieOptions() *CookieService {
 	cfg := config.Get()
 	return &CookieService{
 		AccessTokenExpiry:  cfg.Auth.AccessTokenExpiry,
 		RefreshTokenExpiry: cfg.Auth.RefreshTokenExpiry,
+		Cookie:             co.Cookie,
 	}
 }
 
-func (co *CookieService) SetAuthCookies(c fiber.Ctx, accessToken, refreshToken string) {
-	co = co.GetCookieOptions()
-	cfg := config.Get()
-
-	// Only use Secure cookies in production
-	isSecure := cfg.IsProduction()
-	// Use Lax SameSite in development for better compatibility
-	sameSite := "Lax"
-	if cfg.IsProduction() {
-		sameSite = "Strict"
+// attributes returns the cookie attributes of the service, falling back to the configuration
+func (co *CookieService) attributes() types.CookieConfig {
+	if co.Cookie != nil {
+		return *co.Cookie
 	}
+	return config.Get().Cookie
+}
+
+// authCookie builds an auth cookie with the configured domain, Secure, SameSite and HttpOnly attributes.
+// Clearing a cookie only works with the same attributes it was set with, so both paths use this.
+func (co *CookieService) authCookie(name, value string, expires time.Time) *fiber.Cookie {
+	attrs := co.attributes()
 
-	accessCookie := fiber.Cookie{
-		Name:     lib.AccessTokenCookieName,
-		Value:    accessToken,
-		HTTPOnly: true,
-		Secure:   isSecure,
-		SameSite: sameSite,
-		Expires:  time.Now().Add(co.AccessTokenExpiry),
+	return &fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   attrs.Domain,
+		HTTPOnly: attrs.HTTPOnly,
+		Secure:   attrs.Secure,
+		SameSite: attrs.SameSite,
+		Expires:  expires,
 	}
-	refreshCookie := fiber.Cookie{
-		Name:     lib.RefreshTokenCookieName,
-		Value:    refreshToken,
-		HTTPOnly: true,
-		Secure:   isSecure,
-		SameSite: sameSite,
-		Expires:  time.Now().Add(co.RefreshTokenExpiry),
+}
+
+func (co *CookieService) SetAuthCookies(c fiber.Ctx, accessToken, refreshToken string) {
+	opts := co.GetCookieOptions()
+
+	c.Cookie(co.authCookie(lib.AccessTokenCookieName, accessToken, time.Now().Add(opts.AccessTokenExpiry)))
+	c.Cookie(co.authCookie(lib.RefreshTokenCookieName, refreshToken, time.Now().Add(opts.RefreshTokenExpiry)))
+
+	// Every new session gets a new CSRF token
+	if co.attributes().CSRFEnabled {
+		co.SetCSRFCookie(c, rand.Text())
 	}
-	c.Cookie(&accessCookie)
-	c.Cookie(&refreshCookie)
+}
+
+// SetCSRFCookie sets the CSRF token cookie, which lives as long as the session, and sends the token
+// in the X-CSRF-Token response header. The frontend runs on another origin and cannot read the
+// cookie, it repeats the token from the header instead.
+func (co *CookieService) SetCSRFCookie(c fiber.Ctx, token string) {
+	cookie := co.authCookie(lib.CSRFTokenCookieName, token, time.Now().Add(co.GetCookieOptions().RefreshTokenExpiry))
+	cookie.HTTPOnly = false
+	c.Cookie(cookie)
+	c.Set(lib.CSRFTokenHeader, token)
 }
 
 func (co *CookieService) ClearAuthCookies(c fiber.Ctx) {
-	cfg := config.Get()
+	expired := time.Now().Add(-time.Hour)
 
-	// Only use Secure cookies in production
-	isSecure := cfg.IsProduction()
-	// Use Lax SameSite in development for better compatibility
-	sameSite := "Lax"
-	if cfg.IsProduction() {
-		sameSite = "Strict"
-	}
+	c.Cookie(co.authCookie(lib.AccessTokenCookieName, "", expired))
+	c.Cookie(co.authCookie(lib.RefreshTokenCookieName, "", expired))
 
-	accessCookie := fiber.Cookie{
-		Name:     lib.AccessTokenCookieName,
-		Value:    "",
-		HTTPOnly: true,
-		Secure:   isSecure,
-		SameSite: sameSite,
-		Expires:  time.Now().Add(-time.Hour),
+	if co.attributes().CSRFEnabled {
+		csrfCookie := co.authCookie(lib.CSRFTokenCookieName, "", expired)
+		csrfCookie.HTTPOnly = false
+		c.Cookie(csrfCookie)
 	}
-	refreshCookie := fiber.Cookie{
-		Name:     lib.RefreshTokenCookieName,
-		Value:    "",
-		HTTPOnly: true,
-		Secure:   isSecure,
-		SameSite: sameSite,
-		Expires:  time.Now().Add(-time.Hour),
-	}
-	c.Cookie(&accessCookie)
-	c.Cookie(&refreshCookie)
 }
 
+var _ CookieServiceInterface = (*CookieService)(nil)
+
 // CookieServiceInterface defines the methods for cookie management
 type CookieServiceInterface interface {
 	SetAuthCookies(c fiber.Ctx, accessToken, refreshToken string)
+	SetCSRFCookie(c fiber.Ctx, token string)
 	ClearAuthCookies(c fiber.Ctx)
 	GetCookieOptions() *CookieService
 }
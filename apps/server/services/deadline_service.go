@@ -1,44 +1,67 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/go-pg/pg/v10"
 	"github.com/google/uuid"
 
 	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/types"
 )
 
 type DeadlineService struct {
-	Logger *config.Logger
+	Logger   *config.Logger
+	config   *config.Config
+	locker   DistributedLocker
+	notifier NotificationServiceInterface
 }
 
 func NewDeadlineService() *DeadlineService {
 	return &DeadlineService{
-		Logger: config.SetupLogger(),
+		Logger:   config.SetupLogger(),
+		config:   config.Get(),
+		locker:   NewCacheService(),
+		notifier: NewNotificationService(),
 	}
 }
 
-func (ds *DeadlineService) CreateDeadline(req *types.CreateDeadlineRequest) error {
+// NewDeadlineServiceWithLocker creates a DeadlineService that serializes submissions with the given locker
+func NewDeadlineServiceWithLocker(locker DistributedLocker) *DeadlineService {
+	ds := NewDeadlineService()
+	ds.locker = locker
+	return ds
+}
+
+func (ds *DeadlineService) CreateDeadline(ctx context.Context, req *types.CreateDeadlineRequest) error {
 	if req.SubjectID == uuid.Nil {
-		return fmt.Errorf("subject_id is required")
+		return lib.NewValidationError("subject_id", "subject_id is required")
 	}
 	if req.OwnerID == uuid.Nil {
-		return fmt.Errorf("owner_id is required")
+		return lib.NewValidationError("owner_id", "owner_id is required")
 	}
 	if req.Title == "" {
-		return fmt.Errorf("title is required")
+		return lib.NewValidationError("title", "title is required")
 	}
 	if req.Description == "" {
-		return fmt.Errorf("description is required")
+		return lib.NewValidationError("description", "description is required")
 	}
 	if req.DueDate == "" {
-		return fmt.Errorf("due_date is required")
+		return lib.NewValidationError("due_date", "due_date is required")
 	}
 	if req.CreatedAt == "" {
-		return fmt.Errorf("created_at is required")
+		return lib.NewValidationError("created_at", "created_at is required")
+	}
+	tags, err := NormalizeDeadlineTags(req.Tags)
+	if err != nil {
+		return err
 	}
 
 	query := Query().SetOperation("insert").SetTable("deadlines")
@@ -49,9 +72,10 @@ func (ds *DeadlineService) CreateDeadline(req *types.CreateDeadlineRequest) erro
 		"description": req.Description,
 		"due_date":    req.DueDate,
 		"created_at":  req.CreatedAt,
+		"tags":        pg.Array(tags),
 	}
 
-	_, err := database.ExecuteQuery[any](query)
+	_, err = database.ExecuteQuery[any](query.SetContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -59,48 +83,123 @@ func (ds *DeadlineService) CreateDeadline(req *types.CreateDeadlineRequest) erro
 	return nil
 }
 
-func (ds *DeadlineService) FetchDeadlinesByUser(userId uuid.UUID, filterOptions map[string]string) ([]types.DeadlineWithSubject, error) {
-	var (
-		query = `
-			SELECT
-				d.id, d.owner_id, d.title, d.description, d.due_date, d.created_at, d.updated_at,
-				s.id AS subject__id, s.name AS subject__name, s.code AS subject__code, s.color AS subject__color,
-				s.created_at AS subject__created_at, s.updated_at AS subject__updated_at,
-				s.teacher_id AS subject__teacher_id, s.teacher_name AS subject__teacher_name, s.is_active AS subject__is_active
-			FROM deadlines d
-			LEFT JOIN subjects s ON d.subject_id = s.id
-		`
-		conditions []string
-		args       []any
-	)
-
-	// Always filter by owner_id
-	conditions = append(conditions, "d.owner_id = ?")
-	args = append(args, userId)
-
-	if subjectID, ok := filterOptions["subject_id"]; ok && subjectID != "" {
-		conditions = append(conditions, "s.id = ?")
-		args = append(args, subjectID)
-	}
-	if dueDateFrom, ok := filterOptions["due_date_from"]; ok && dueDateFrom != "" {
-		conditions = append(conditions, "d.due_date >= ?")
-		args = append(args, dueDateFrom)
-	}
-	if dueDateTo, ok := filterOptions["due_date_to"]; ok && dueDateTo != "" {
-		conditions = append(conditions, "d.due_date <= ?")
-		args = append(args, dueDateTo)
-	}
-
-	if len(conditions) > 0 {
-		query += " WHERE " + conditions[0]
-		for i := 1; i < len(conditions); i++ {
-			query += " AND " + conditions[i]
+// NormalizeDeadlineTags trims and lower-cases tags, dropping empty and duplicate ones, and returns them sorted.
+// Returns a validation error when there are more than types.MaxDeadlineTags tags or one is longer than types.MaxDeadlineTagLength.
+func NormalizeDeadlineTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || slices.Contains(normalized, tag) {
+			continue
+		}
+		if len([]rune(tag)) > types.MaxDeadlineTagLength {
+			return nil, lib.NewValidationError("tags", fmt.Sprintf("tag %q is longer than %d characters", tag, types.MaxDeadlineTagLength))
 		}
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > types.MaxDeadlineTags {
+		return nil, lib.NewValidationError("tags", fmt.Sprintf("a deadline can have at most %d tags", types.MaxDeadlineTags))
+	}
+
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+// Row limits of the deadline listings
+const (
+	maxUserDeadlines = 50
+	maxDeadlines     = 100
+)
+
+// deadlineWithSubjectColumns selects a deadline with its subject, the subject__ aliases fill DeadlineWithSubject.Subject
+var deadlineWithSubjectColumns = []string{
+	"d.id", "d.owner_id", "d.title", "d.description", "d.due_date", "d.tags", "d.created_at", "d.updated_at",
+	"s.id AS subject__id", "s.name AS subject__name", "s.code AS subject__code", "s.color AS subject__color",
+	"s.created_at AS subject__created_at", "s.updated_at AS subject__updated_at",
+	"s.teacher_id AS subject__teacher_id", "s.teacher_name AS subject__teacher_name", "s.is_active AS subject__is_active",
+}
+
+// deadlineSortColumns maps the values of the sort parameter to the columns they order by.
+// The order clause is interpolated into the query, so only these columns can be used.
+var deadlineSortColumns = map[string]string{
+	"due_date":   "d.due_date",
+	"created_at": "d.created_at",
+	"title":      "d.title",
+}
+
+// DeadlineSortOrder translates a sort parameter such as "title" or "due_date:desc" into the ORDER BY columns,
+// one "column DIRECTION" per element. An empty value sorts by due date ascending.
+// Returns lib.ErrInvalidSort for anything outside the allowlist.
+func DeadlineSortOrder(sort string) ([]string, error) {
+	if sort == "" {
+		sort = "due_date"
+	}
+
+	field, direction, _ := strings.Cut(sort, ":")
+	column, ok := deadlineSortColumns[field]
+	direction = strings.ToUpper(direction)
+	if direction == "" {
+		direction = "ASC"
+	}
+	if !ok || (direction != "ASC" && direction != "DESC") {
+		return nil, lib.ErrInvalidSort.WithDetails(map[string]any{
+			"sort":       sort,
+			"fields":     slices.Sorted(maps.Keys(deadlineSortColumns)),
+			"directions": []string{"asc", "desc"},
+		})
+	}
+
+	// Ties keep a stable order across pages and refreshes
+	order := []string{column + " " + direction}
+	if column != "d.due_date" {
+		order = append(order, "d.due_date ASC")
+	}
+	return append(order, "d.id ASC"), nil
+}
+
+// DeadlinesQuery builds the select of deadlines with their subject, ordered by due date unless the sort
+// option asks otherwise. The filter options subject_id, due_date_from, due_date_to and tag narrow the result when set.
+// Returns lib.ErrInvalidSort when the sort option is not allowed, see DeadlineSortOrder.
+func DeadlinesQuery(filterOptions map[string]string, limit int) (*types.QueryParams, error) {
+	order, err := DeadlineSortOrder(filterOptions["sort"])
+	if err != nil {
+		return nil, err
 	}
 
-	query += " LIMIT 50;"
+	query := Query().
+		SetOperation("select").
+		SetTable(lib.TableDeadlines).
+		SetSelect(deadlineWithSubjectColumns).
+		AddJoin(fmt.Sprintf("LEFT JOIN %s AS s ON s.id = d.subject_id", lib.TableSubjects)).
+		SetLimit(limit)
+	for _, column := range order {
+		query.AddOrder(column)
+	}
+
+	if subjectID := filterOptions["subject_id"]; subjectID != "" {
+		query.AddWhere("d.subject_id", subjectID)
+	}
+	if dueDateFrom := filterOptions["due_date_from"]; dueDateFrom != "" {
+		query.AddWhere("d.due_date >=", dueDateFrom)
+	}
+	if dueDateTo := filterOptions["due_date_to"]; dueDateTo != "" {
+		query.AddWhere("d.due_date <=", dueDateTo)
+	}
+	if tag := strings.ToLower(strings.TrimSpace(filterOptions["tag"])); tag != "" {
+		query.AddWhereArrayContains("d.tags", tag)
+	}
+
+	return query, nil
+}
+
+func (ds *DeadlineService) FetchDeadlinesByUser(ctx context.Context, userId uuid.UUID, filterOptions map[string]string) ([]types.DeadlineWithSubject, error) {
+	query, err := DeadlinesQuery(filterOptions, maxUserDeadlines)
+	if err != nil {
+		return nil, err
+	}
 
-	deadlines, err := database.Raw[types.DeadlineWithSubject](query, args...)
+	deadlines, err := database.ExecuteQuery[types.DeadlineWithSubject](query.AddWhere("d.owner_id", userId).SetContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -112,46 +211,13 @@ func (ds *DeadlineService) FetchDeadlinesByUser(userId uuid.UUID, filterOptions
 	return deadlines.Data, nil
 }
 
-func (ds *DeadlineService) FetchAllDeadlines(filterOptions map[string]string) ([]types.DeadlineWithSubject, error) {
-	var (
-		query = `
-			SELECT
-				d.id, d.owner_id, d.title, d.description, d.due_date, d.created_at, d.updated_at,
-				s.id AS subject__id, s.name AS subject__name, s.code AS subject__code, s.color AS subject__color,
-				s.created_at AS subject__created_at, s.updated_at AS subject__updated_at,
-				s.teacher_id AS subject__teacher_id, s.teacher_name AS subject__teacher_name, s.is_active AS subject__is_active
-			FROM deadlines d
-			LEFT JOIN subjects s ON d.subject_id = s.id
-		`
-		conditions []string
-		args       []any
-	)
-
-	fmt.Println("Filter Options:", filterOptions)
-
-	if subjectID, ok := filterOptions["subject_id"]; ok && subjectID != "" {
-		conditions = append(conditions, "s.id = ?")
-		args = append(args, subjectID)
-	}
-	if dueDateFrom, ok := filterOptions["due_date_from"]; ok && dueDateFrom != "" {
-		conditions = append(conditions, "d.due_date >= ?")
-		args = append(args, dueDateFrom)
-	}
-	if dueDateTo, ok := filterOptions["due_date_to"]; ok && dueDateTo != "" {
-		conditions = append(conditions, "d.due_date <= ?")
-		args = append(args, dueDateTo)
-	}
-
-	if len(conditions) > 0 {
-		query += " WHERE " + conditions[0]
-		for i := 1; i < len(conditions); i++ {
-			query += " AND " + conditions[i]
-		}
+func (ds *DeadlineService) FetchAllDeadlines(ctx context.Context, filterOptions map[string]string) ([]types.DeadlineWithSubject, error) {
+	query, err := DeadlinesQuery(filterOptions, maxDeadlines)
+	if err != nil {
+		return nil, err
 	}
 
-	query += " LIMIT 100;"
-
-	deadlines, err := database.Raw[types.DeadlineWithSubject](query, args...)
+	deadlines, err := database.ExecuteQuery[types.DeadlineWithSubject](query.SetContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -163,13 +229,13 @@ func (ds *DeadlineService) FetchAllDeadlines(filterOptions map[string]string) ([
 	return deadlines.Data, nil
 }
 
-func (ds *DeadlineService) DeleteDeadlineById(deadlineId string) error {
+func (ds *DeadlineService) DeleteDeadlineById(ctx context.Context, deadlineId string) error {
 	query := Query().SetOperation("delete").SetTable("deadlines")
 	query.Where = map[string]any{
 		"id": deadlineId,
 	}
 
-	_, err := database.ExecuteQuery[any](query)
+	_, err := database.ExecuteQuery[any](query.SetContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -177,13 +243,13 @@ func (ds *DeadlineService) DeleteDeadlineById(deadlineId string) error {
 	return nil
 }
 
-func (ds *DeadlineService) DeleteDeadlinesFromUser(userId uuid.UUID) error {
+func (ds *DeadlineService) DeleteDeadlinesFromUser(ctx context.Context, userId uuid.UUID) error {
 	query := Query().SetOperation("delete").SetTable("deadlines")
 	query.Where = map[string]any{
 		"owner_id": userId,
 	}
 
-	_, err := database.ExecuteQuery[any](query)
+	_, err := database.ExecuteQuery[any](query.SetContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -191,7 +257,7 @@ func (ds *DeadlineService) DeleteDeadlinesFromUser(userId uuid.UUID) error {
 	return nil
 }
 
-func (ds *DeadlineService) UpdateDeadlineById(deadlineId string, updateData types.Deadline) error {
+func (ds *DeadlineService) UpdateDeadlineById(ctx context.Context, deadlineId string, updateData types.Deadline) error {
 	query := Query().SetOperation("update").SetTable("deadlines")
 	query.Where = map[string]any{
 		"id": deadlineId,
@@ -207,8 +273,15 @@ func (ds *DeadlineService) UpdateDeadlineById(deadlineId string, updateData type
 	if updateData.DueDate != "" {
 		data["due_date"] = updateData.DueDate
 	}
+	if updateData.Tags != nil {
+		tags, err := NormalizeDeadlineTags(updateData.Tags)
+		if err != nil {
+			return err
+		}
+		data["tags"] = pg.Array(tags)
+	}
 
-	_, err := database.ExecuteQuery[any](query.SetData(data))
+	_, err := database.ExecuteQuery[any](query.SetData(data).SetContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -216,102 +289,192 @@ func (ds *DeadlineService) UpdateDeadlineById(deadlineId string, updateData type
 	return nil
 }
 
+// CanAccess reports whether a user with the given role may access a deadline.
+// Returns lib.ErrDeadlineNotFound when the deadline does not exist.
+func (ds *DeadlineService) CanAccess(ctx context.Context, userID uuid.UUID, role string, deadlineID uuid.UUID) (bool, error) {
+	query := Query().SetRawSQL(`
+		SELECT d.owner_id,
+			EXISTS (SELECT 1 FROM subject_teachers st WHERE st.subject_id = d.subject_id AND st.user_id = ?) AS assigned_teacher,
+			EXISTS (SELECT 1 FROM user_subjects us WHERE us.subject_id = d.subject_id AND us.user_id = ?) AS enrolled
+		FROM deadlines d
+		WHERE d.id = ?`, userID, userID, deadlineID)
+
+	result, err := database.ExecuteQuery[types.DeadlineAccess](query.SetContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	if result.Single == nil {
+		return false, lib.ErrDeadlineNotFound
+	}
+
+	return DeadlineAccessAllowed(userID, role, *result.Single), nil
+}
+
+// DeadlineAccessAllowed applies the deadline authorization rules:
+// admins may access every deadline, teachers the deadlines they own or whose subject they teach,
+// and students the deadlines of subjects they are enrolled in.
+func DeadlineAccessAllowed(userID uuid.UUID, role string, access types.DeadlineAccess) bool {
+	switch role {
+	case lib.RoleAdmin:
+		return true
+	case lib.RoleTeacher:
+		return access.OwnerID == userID || access.AssignedTeacher
+	case lib.RoleStudent:
+		return access.Enrolled
+	default:
+		return false
+	}
+}
+
+// CanManageUserDeadlines reports whether the actor may delete every deadline owned by ownerID:
+// admins may do so for anyone, other users only for their own deadlines.
+func CanManageUserDeadlines(actorID uuid.UUID, role string, ownerID uuid.UUID) bool {
+	return role == lib.RoleAdmin || (role == lib.RoleTeacher && actorID == ownerID)
+}
+
+var _ DeadlineServiceInterface = (*DeadlineService)(nil)
+
 // DeadlineServiceInterface defines the methods that the DeadlineService must implement.
 // This interface is used for dependency injection and to facilitate testing.
 type DeadlineServiceInterface interface {
-	CreateDeadline(req *types.CreateDeadlineRequest) error
-	FetchDeadlinesByUser(userId uuid.UUID, filterOptions map[string]string) ([]types.DeadlineWithSubject, error)
-	DeleteDeadlineById(deadlineId string) error
-	DeleteDeadlinesFromUser(userId uuid.UUID) error
-	FetchAllDeadlines(filterOptions map[string]string) ([]types.DeadlineWithSubject, error)
-	UpdateDeadlineById(deadlineId string, updateData types.Deadline) error
+	CreateDeadline(ctx context.Context, req *types.CreateDeadlineRequest) error
+	FetchDeadlinesByUser(ctx context.Context, userId uuid.UUID, filterOptions map[string]string) ([]types.DeadlineWithSubject, error)
+	DeleteDeadlineById(ctx context.Context, deadlineId string) error
+	DeleteDeadlinesFromUser(ctx context.Context, userId uuid.UUID) error
+	FetchAllDeadlines(ctx context.Context, filterOptions map[string]string) ([]types.DeadlineWithSubject, error)
+	UpdateDeadlineById(ctx context.Context, deadlineId string, updateData types.Deadline) error
+	CanAccess(ctx context.Context, userID uuid.UUID, role string, deadlineID uuid.UUID) (bool, error)
 	// Submission-related
-	CreateOrUpdateSubmission(deadlineID, studentID uuid.UUID, req types.CreateSubmissionRequest, now string) (*types.SubmissionResponse, error)
-	GetSubmissionByStudent(deadlineID, studentID uuid.UUID) (*types.SubmissionResponse, error)
-	GetAllSubmissionsForDeadline(deadlineID uuid.UUID) ([]*types.SubmissionResponse, error)
+	CreateOrUpdateSubmission(ctx context.Context, deadlineID, studentID uuid.UUID, req types.CreateSubmissionRequest, now string) (*types.SubmissionResponse, error)
+	GetSubmissionByStudent(ctx context.Context, deadlineID, studentID uuid.UUID) (*types.SubmissionResponse, error)
+	GetAllSubmissionsForDeadline(ctx context.Context, deadlineID uuid.UUID) ([]*types.SubmissionResponse, error)
+}
+
+// CreateOrUpdateSubmission creates or updates a student's submission for a deadline.
+// Concurrent calls for the same student and deadline are serialized with a lock, so the
+// existence check and the insert cannot race and every caller sees the latest submission.
+func (ds *DeadlineService) CreateOrUpdateSubmission(ctx context.Context, deadlineID, studentID uuid.UUID, req types.CreateSubmissionRequest, now string) (*types.SubmissionResponse, error) {
+	var resp *types.SubmissionResponse
+	key := submissionLockKey(deadlineID, studentID)
+
+	err := WithLock(ds.locker, key, ds.config.Cache.SubmissionLockTTL, ds.config.Cache.SubmissionLockWait, func() error {
+		var err error
+		resp, err = ds.createOrUpdateSubmission(ctx, deadlineID, studentID, req, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
 }
 
-// CreateOrUpdateSubmission creates or updates a student's submission for a deadline
-func (ds *DeadlineService) CreateOrUpdateSubmission(deadlineID, studentID uuid.UUID, req types.CreateSubmissionRequest, now string) (*types.SubmissionResponse, error) {
+// submissionLockKey identifies the submission of a student for a deadline in the distributed and database locks
+func submissionLockKey(deadlineID, studentID uuid.UUID) string {
+	return fmt.Sprintf("submission:%s:%s", deadlineID, studentID)
+}
+
+// deadlineIDRow is the deadline row checked by createOrUpdateSubmission
+type deadlineIDRow struct {
+	tableName struct{} `pg:"deadlines,alias:deadlines"`
+
+	ID uuid.UUID
+}
+
+// submissionUpsertRow is a submission returned by the upsert in createOrUpdateSubmission
+type submissionUpsertRow struct {
+	types.Submission
+	Inserted bool
+}
+
+// submissionUpsertReturning lists the columns the submission upsert returns, with timestamps in RFC 3339
+var submissionUpsertReturning = []string{
+	"id", "deadline_id", "student_id", "file_ids", "message",
+	`to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"') AS created_at`,
+	`to_char(updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"') AS updated_at`,
+	"COALESCE(feedback, '') AS feedback",
+	"COALESCE(draft_feedback, '') AS draft_feedback",
+	"(xmax = 0) AS inserted",
+}
+
+// SubmissionUpsertQuery builds the insert of a submission that updates the existing one instead.
+// The unique constraint on (deadline_id, student_id) makes this atomic, so concurrent submissions
+// can never create a second row even if the distributed lock expires mid-request.
+// xmax is 0 only for a freshly inserted row, which tells an insert from an update.
+func SubmissionUpsertQuery(deadlineID, studentID uuid.UUID, req types.CreateSubmissionRequest, now string) *types.QueryParams {
+	return Query().
+		SetOperation("insert").
+		SetTable("submissions").
+		SetData(map[string]any{
+			"id":          uuid.New(),
+			"deadline_id": deadlineID,
+			"student_id":  studentID,
+			"file_ids":    pg.Array(req.FileIDs),
+			"message":     req.Message,
+			"created_at":  now,
+			"updated_at":  now,
+		}).
+		SetOnConflict("(deadline_id, student_id) DO UPDATE SET " +
+			"file_ids = EXCLUDED.file_ids, message = EXCLUDED.message, updated_at = EXCLUDED.updated_at").
+		SetReturning(submissionUpsertReturning...)
+}
+
+// createOrUpdateSubmission does the work for CreateOrUpdateSubmission and must only be called while holding the submission lock
+func (ds *DeadlineService) createOrUpdateSubmission(ctx context.Context, deadlineID, studentID uuid.UUID, req types.CreateSubmissionRequest, now string) (*types.SubmissionResponse, error) {
 	// Fetch the deadline to get due_date
-	deadline, err := ds.getDeadlineByID(deadlineID)
+	deadline, err := ds.getDeadlineByID(ctx, deadlineID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch deadline: %w", err)
 	}
 	if deadline == nil {
-		return nil, fmt.Errorf("deadline not found")
+		return nil, lib.ErrDeadlineNotFound
 	}
 
-	// Check if a submission already exists
-	query := Query().
-		SetOperation("select").
-		SetTable("submissions").
-		SetLimit(1)
-	query.Where = map[string]any{
-		"deadline_id": deadlineID,
-		"student_id":  studentID,
-	}
+	upsert := SubmissionUpsertQuery(deadlineID, studentID, req, now)
 
-	result, err := database.ExecuteQuery[types.Submission](query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query submission: %w", err)
-	}
-
-	var submission types.Submission
-	isUpdate := false
-	if len(result.Data) > 0 {
-		// Update existing submission
-		isUpdate = true
-		submission = result.Data[0]
-		updateQuery := Query().
-			SetOperation("update").
-			SetTable("submissions").
-			SetData(map[string]any{
-				"file_ids":   req.FileIDs,
-				"message":    req.Message,
-				"updated_at": now,
-			})
-		updateQuery.Where = map[string]any{
-			"public.submissions.id": submission.ID,
+	var saved *submissionUpsertRow
+	err = database.Transaction(ctx, func(tx *pg.Tx) error {
+		// Serialize submissions of this student to this deadline in the database as well, even when the
+		// distributed lock expired or was skipped. The submission row itself cannot be locked, since
+		// FOR UPDATE locks nothing before the first submission exists, so a transaction-scoped advisory
+		// lock on the (deadline, student) pair is taken instead. Other students are not held up by it.
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext(?))", deadlineID.String()+":"+studentID.String()); err != nil {
+			return fmt.Errorf("failed to lock submission: %w", err)
 		}
-		_, err := database.ExecuteQuery[types.Submission](updateQuery)
+
+		// The foreign key check of the upsert takes a key share lock on the deadline, which keeps it
+		// from being deleted until the submission is saved
+		check := Query().
+			SetOperation("select").
+			SetSelect([]string{"id"}).
+			SetWhereRaw("deadlines.id = ?", deadlineID).
+			WithTx(tx)
+		found, err := database.ExecuteQuery[deadlineIDRow](check.SetContext(ctx))
 		if err != nil {
-			return nil, fmt.Errorf("failed to update submission: %w", err)
+			return fmt.Errorf("failed to fetch deadline: %w", err)
 		}
-		// Update local struct for response
-		submission.FileIDs = req.FileIDs
-		submission.Message = req.Message
-		submission.UpdatedAt = now
-	} else {
-		// Insert new submission
-		newID := uuid.New()
-		insertQuery := Query().
-			SetOperation("insert").
-			SetTable("submissions").
-			SetData(map[string]any{
-				"id":          newID,
-				"deadline_id": deadlineID,
-				"student_id":  studentID,
-				"file_ids":    req.FileIDs,
-				"message":     req.Message,
-				"created_at":  now,
-				"updated_at":  now,
-			})
-		_, err := database.ExecuteQuery[types.Submission](insertQuery)
+		if len(found.Data) == 0 {
+			return lib.ErrDeadlineNotFound
+		}
+
+		result, err := database.ExecuteQuery[submissionUpsertRow](upsert.WithTx(tx).SetContext(ctx))
 		if err != nil {
-			return nil, fmt.Errorf("failed to insert submission: %w", err)
+			return fmt.Errorf("failed to save submission: %w", err)
 		}
-		submission = types.Submission{
-			ID:         newID,
-			DeadlineID: deadlineID,
-			StudentID:  studentID,
-			FileIDs:    req.FileIDs,
-			Message:    req.Message,
-			CreatedAt:  now,
-			UpdatedAt:  now,
+		if result.Single == nil {
+			return fmt.Errorf("failed to save submission: no row returned")
 		}
+		saved = result.Single
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	submission := saved.Submission
+	created := saved.Inserted
+	isUpdate := !created
+
 	// Calculate late/updated flags
 	isLate := false
 	isUpdated := false
@@ -337,39 +500,69 @@ func (ds *DeadlineService) CreateOrUpdateSubmission(deadlineID, studentID uuid.U
 		UpdatedAt:  submission.UpdatedAt,
 		IsLate:     isLate,
 		IsUpdated:  isUpdated,
+		Created:    created,
+
+		Feedback:      submission.Feedback,
+		DraftFeedback: submission.DraftFeedback,
 	}
 
 	// --- Notification logic for teachers/admins ---
-	// Find all teachers/admins for the subject of this deadline
-	// For now, just log the notification; replace with your actual notification system as needed
-
-	// Fetch subject teachers
-	subjectTeachers, err := ds.getTeachersForSubject(deadline.SubjectID)
-	if err == nil {
+	// Notify all teachers of the subject of this deadline. Delivery runs in the background so a
+	// notification outage does not fail the submission, failed deliveries are queued for retry.
+	subjectTeachers, err := ds.getTeachersForSubject(ctx, deadline.SubjectID)
+	if err == nil && len(subjectTeachers) > 0 {
+		notifications := make([]types.Notification, 0, len(subjectTeachers))
 		for _, teacher := range subjectTeachers {
-			ds.Logger.Info("Notify teacher of new/updated submission",
-				"teacher_id", teacher.Id,
-				"student_id", studentID,
-				"deadline_id", deadlineID,
-				"is_update", isUpdate,
-			)
-			// TODO: Integrate with actual notification system (email, in-app, etc.)
+			notifications = append(notifications, types.Notification{
+				Type:        types.NotificationSubmissionReceived,
+				RecipientID: teacher.Id,
+				Data: map[string]any{
+					"student_id":    studentID,
+					"deadline_id":   deadlineID,
+					"submission_id": submission.ID,
+					"is_update":     isUpdate,
+					"is_late":       isLate,
+				},
+			})
 		}
+		go func() {
+			for _, notification := range notifications {
+				// Failures are logged and queued by the notification service
+				_ = ds.notifier.Send(context.Background(), notification)
+			}
+		}()
 	}
 	// Optionally, notify admins as well (not implemented here, but can be added similarly)
 
+	// Let subscribed external systems know, the webhook worker delivers the event in the background
+	eventType := types.WebhookEventSubmissionCreated
+	if isUpdate {
+		eventType = types.WebhookEventSubmissionUpdated
+	}
+	PublishWebhookEvent(eventType, map[string]any{
+		"submission_id": submission.ID,
+		"deadline_id":   deadlineID,
+		"subject_id":    deadline.SubjectID,
+		"student_id":    studentID,
+		"file_ids":      submission.FileIDs,
+		"is_late":       isLate,
+		"created_at":    submission.CreatedAt,
+		"updated_at":    submission.UpdatedAt,
+	})
+
 	return resp, nil
 }
 
-// GetAllSubmissionsForDeadline fetches all student submissions for a specific deadline
-func (ds *DeadlineService) GetAllSubmissionsForDeadline(deadlineID uuid.UUID) ([]*types.SubmissionResponse, error) {
+// GetAllSubmissionsForDeadline fetches all student submissions for a specific deadline.
+// Returns lib.ErrDeadlineNotFound when the deadline does not exist.
+func (ds *DeadlineService) GetAllSubmissionsForDeadline(ctx context.Context, deadlineID uuid.UUID) ([]*types.SubmissionResponse, error) {
 	// Fetch the deadline to get due_date
-	deadline, err := ds.getDeadlineByID(deadlineID)
+	deadline, err := ds.getDeadlineByID(ctx, deadlineID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch deadline: %w", err)
 	}
 	if deadline == nil {
-		return []*types.SubmissionResponse{}, nil
+		return nil, lib.ErrDeadlineNotFound
 	}
 
 	query := Query().
@@ -378,7 +571,7 @@ func (ds *DeadlineService) GetAllSubmissionsForDeadline(deadlineID uuid.UUID) ([
 	query.Where = map[string]any{
 		"submissions.deadline_id": deadlineID,
 	}
-	result, err := database.ExecuteQuery[types.Submission](query)
+	result, err := database.ExecuteQuery[types.Submission](query.SetContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch submissions: %w", err)
 	}
@@ -406,20 +599,24 @@ func (ds *DeadlineService) GetAllSubmissionsForDeadline(deadlineID uuid.UUID) ([
 			UpdatedAt:  s.UpdatedAt,
 			IsLate:     isLate,
 			IsUpdated:  isUpdated,
+
+			Feedback:      s.Feedback,
+			DraftFeedback: s.DraftFeedback,
 		})
 	}
 	return responses, nil
 }
 
-// GetSubmissionByStudent fetches a student's submission for a specific deadline
-func (ds *DeadlineService) GetSubmissionByStudent(deadlineID, studentID uuid.UUID) (*types.SubmissionResponse, error) {
+// GetSubmissionByStudent fetches a student's submission for a specific deadline, nil when the student
+// has not submitted yet. Returns lib.ErrDeadlineNotFound when the deadline does not exist.
+func (ds *DeadlineService) GetSubmissionByStudent(ctx context.Context, deadlineID, studentID uuid.UUID) (*types.SubmissionResponse, error) {
 	// Fetch the deadline to get due_date
-	deadline, err := ds.getDeadlineByID(deadlineID)
+	deadline, err := ds.getDeadlineByID(ctx, deadlineID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch deadline: %w", err)
 	}
 	if deadline == nil {
-		return nil, nil
+		return nil, lib.ErrDeadlineNotFound
 	}
 
 	query := Query().
@@ -431,7 +628,7 @@ func (ds *DeadlineService) GetSubmissionByStudent(deadlineID, studentID uuid.UUI
 		"submissions.deadline_id": deadlineID,
 		"student_id":              studentID,
 	}
-	result, err := database.ExecuteQuery[types.Submission](query)
+	result, err := database.ExecuteQuery[types.Submission](query.SetContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch submission: %w", err)
 	}
@@ -462,11 +659,14 @@ func (ds *DeadlineService) GetSubmissionByStudent(deadlineID, studentID uuid.UUI
 		UpdatedAt:  s.UpdatedAt,
 		IsLate:     isLate,
 		IsUpdated:  isUpdated,
+
+		Feedback:      s.Feedback,
+		DraftFeedback: s.DraftFeedback,
 	}
 	return resp, nil
 }
 
-func (ds *DeadlineService) getDeadlineByID(deadlineID uuid.UUID) (*types.Deadline, error) {
+func (ds *DeadlineService) getDeadlineByID(ctx context.Context, deadlineID uuid.UUID) (*types.Deadline, error) {
 	query := Query().
 		SetOperation("select").
 		SetTable("deadlines").
@@ -474,7 +674,7 @@ func (ds *DeadlineService) getDeadlineByID(deadlineID uuid.UUID) (*types.Deadlin
 	query.Where = map[string]any{
 		"public.deadlines.id": deadlineID,
 	}
-	result, err := database.ExecuteQuery[types.Deadline](query)
+	result, err := database.ExecuteQuery[types.Deadline](query.SetContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -484,7 +684,7 @@ func (ds *DeadlineService) getDeadlineByID(deadlineID uuid.UUID) (*types.Deadlin
 	return &result.Data[0], nil
 }
 
-func (ds *DeadlineService) getTeachersForSubject(subjectID uuid.UUID) ([]types.User, error) {
+func (ds *DeadlineService) getTeachersForSubject(ctx context.Context, subjectID uuid.UUID) ([]types.User, error) {
 	query := Query().
 		SetOperation("select").
 		SetTable("users")
@@ -498,7 +698,7 @@ func (ds *DeadlineService) getTeachersForSubject(subjectID uuid.UUID) ([]types.U
 	subjectTeacherQuery.Where = map[string]any{
 		"subject_id": subjectID,
 	}
-	subjectTeachersResult, err := database.ExecuteQuery[types.Teacher](subjectTeacherQuery)
+	subjectTeachersResult, err := database.ExecuteQuery[types.Teacher](subjectTeacherQuery.SetContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -511,7 +711,7 @@ func (ds *DeadlineService) getTeachersForSubject(subjectID uuid.UUID) ([]types.U
 	}
 	query.Where["id"] = teacherIDs
 
-	result, err := database.ExecuteQuery[types.User](query)
+	result, err := database.ExecuteQuery[types.User](query.SetContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+)
+
+// MaxHealthHistoryRange bounds a single history query so a chart request cannot scan the whole table
+const MaxHealthHistoryRange = 31 * 24 * time.Hour
+
+type HealthService struct {
+	Logger *config.Logger
+}
+
+func NewHealthService() *HealthService {
+	return &HealthService{
+		Logger: config.SetupLogger(),
+	}
+}
+
+// healthLogRow is a health_logs row as stored by the health worker:
+// latencies in milliseconds and the time span in seconds
+type healthLogRow struct {
+	Timestamp      time.Time
+	Service        string
+	StatusCode     int
+	RequestCount   int64
+	ErrorCount     int64
+	AverageLatency float64
+	P50Latency     float64
+	P95Latency     float64
+	P99Latency     float64
+	TimeSpan       int64
+}
+
+// toHealthLog converts the stored units back to durations
+func (row healthLogRow) toHealthLog() types.HealthLog {
+	return types.HealthLog{
+		Timestamp:      row.Timestamp,
+		Service:        row.Service,
+		StatusCode:     row.StatusCode,
+		RequestCount:   row.RequestCount,
+		ErrorCount:     row.ErrorCount,
+		AverageLatency: millisecondsToDuration(row.AverageLatency),
+		P50Latency:     millisecondsToDuration(row.P50Latency),
+		P95Latency:     millisecondsToDuration(row.P95Latency),
+		P99Latency:     millisecondsToDuration(row.P99Latency),
+		TimeSpan:       time.Duration(row.TimeSpan) * time.Second,
+	}
+}
+
+func millisecondsToDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// QueryServiceHistory returns the health logs of a service between from and to (inclusive), oldest first
+func (hs *HealthService) QueryServiceHistory(service string, from, to time.Time) ([]types.HealthLog, error) {
+	service = strings.TrimSpace(service)
+	if err := ValidateHealthHistoryRange(service, from, to); err != nil {
+		return nil, fmt.Errorf("%w: %v", lib.ErrInvalidInput, err)
+	}
+
+	// Raw SQL so the row struct does not add its own table to the FROM clause
+	clause, args := BuildHealthHistoryConditions(service, from, to)
+	sql := fmt.Sprintf(`SELECT timestamp, service, status_code, request_count, error_count,
+		COALESCE(average_latency, 0) AS average_latency, COALESCE(p50_latency, 0) AS p50_latency,
+		COALESCE(p95_latency, 0) AS p95_latency, COALESCE(p99_latency, 0) AS p99_latency, time_span
+		FROM %s WHERE %s ORDER BY %s.timestamp ASC`, lib.TableHealthLogs, clause, lib.TableHealthLogs)
+
+	query := Query().SetRawSQL(sql, args...).WithDB(database.GetAuditInstance().Conn())
+	result, err := database.ExecuteQuery[healthLogRow](query)
+	if err != nil {
+		hs.Logger.AuditError("Failed to query health log history", "service", service, "error", err)
+		return nil, err
+	}
+
+	logs := make([]types.HealthLog, len(result.Data))
+	for i, row := range result.Data {
+		logs[i] = row.toHealthLog()
+	}
+	return logs, nil
+}
+
+// ValidateHealthHistoryRange checks that a service is given and the range is ordered and not too long
+func ValidateHealthHistoryRange(service string, from, to time.Time) error {
+	if service == "" {
+		return lib.NewValidationError("service", "service is required")
+	}
+	if from.IsZero() || to.IsZero() {
+		return lib.NewValidationError("from", "from and to are required")
+	}
+	if from.After(to) {
+		return lib.NewValidationError("from", "from must be before to")
+	}
+	if to.Sub(from) > MaxHealthHistoryRange {
+		return lib.NewValidationError("to", fmt.Sprintf("time range cannot exceed %s", MaxHealthHistoryRange))
+	}
+	return nil
+}
+
+// BuildHealthHistoryConditions translates a service and time range into a parameterized WHERE clause
+func BuildHealthHistoryConditions(service string, from, to time.Time) (string, []any) {
+	conditions := []string{
+		fmt.Sprintf("%s.service = ?", lib.TableHealthLogs),
+		fmt.Sprintf("%s.timestamp >= ?", lib.TableHealthLogs),
+		fmt.Sprintf("%s.timestamp <= ?", lib.TableHealthLogs),
+	}
+	return strings.Join(conditions, " AND "), []any{service, from, to}
+}
+
+var _ HealthServiceInterface = (*HealthService)(nil)
+
+type HealthServiceInterface interface {
+	QueryServiceHistory(service string, from, to time.Time) ([]types.HealthLog, error)
+}
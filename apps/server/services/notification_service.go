@@ -0,0 +1,173 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+// notificationWebhookTimeout bounds a single webhook delivery
+const notificationWebhookTimeout = 5 * time.Second
+
+// Notifier delivers a notification to its recipient
+type Notifier interface {
+	Notify(ctx context.Context, notification types.Notification) error
+}
+
+// NewNotifier returns the notifier for the configuration: the webhook when
+// NOTIFICATION_WEBHOOK_URL is set, otherwise a notifier that only logs
+func NewNotifier(cfg *config.Config, logger *config.Logger) Notifier {
+	if cfg.Notification.WebhookURL != "" {
+		return NewWebhookNotifier(cfg.Notification.WebhookURL)
+	}
+	return NewLogNotifier(logger)
+}
+
+// LogNotifier writes notifications to the log, it is used when no delivery channel is configured
+type LogNotifier struct {
+	logger *config.Logger
+}
+
+func NewLogNotifier(logger *config.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+// Notify logs the notification, it never fails
+func (ln *LogNotifier) Notify(ctx context.Context, notification types.Notification) error {
+	ln.logger.Info("Notification",
+		"id", notification.ID,
+		"type", notification.Type,
+		"recipient_id", notification.RecipientID,
+		"data", notification.Data,
+	)
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a webhook
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: notificationWebhookTimeout},
+	}
+}
+
+// Notify posts the notification to the webhook. Any non-2xx response is a failed delivery.
+func (wn *WebhookNotifier) Notify(ctx context.Context, notification types.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wn.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+var (
+	_ Notifier = (*LogNotifier)(nil)
+	_ Notifier = (*WebhookNotifier)(nil)
+)
+
+// NotificationFailureHandler is called with every notification whose delivery failed
+type NotificationFailureHandler func(notification types.Notification, err error)
+
+var (
+	notificationFailureHandler NotificationFailureHandler
+	notificationFailureMu      sync.RWMutex
+)
+
+// RegisterNotificationFailureHandler sets the handler that receives failed notifications,
+// the worker manager registers its dead letter queue here. Passing nil removes the handler.
+func RegisterNotificationFailureHandler(handler NotificationFailureHandler) {
+	notificationFailureMu.Lock()
+	defer notificationFailureMu.Unlock()
+	notificationFailureHandler = handler
+}
+
+// handleNotificationFailure forwards a failed notification to the registered handler, if any
+func handleNotificationFailure(notification types.Notification, err error) {
+	notificationFailureMu.RLock()
+	handler := notificationFailureHandler
+	notificationFailureMu.RUnlock()
+
+	if handler != nil {
+		handler(notification, err)
+	}
+}
+
+// NotificationService sends notifications and hands failed deliveries to the
+// registered failure handler so they can be retried later
+type NotificationService struct {
+	notifier Notifier
+	logger   *config.Logger
+}
+
+func NewNotificationService() *NotificationService {
+	logger := config.SetupLogger()
+	return NewNotificationServiceWithNotifier(NewNotifier(config.Get(), logger), logger)
+}
+
+// NewNotificationServiceWithNotifier creates a notification service that delivers through the given notifier
+func NewNotificationServiceWithNotifier(notifier Notifier, logger *config.Logger) *NotificationService {
+	return &NotificationService{
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+// Send delivers a notification, assigning an ID and creation time when missing.
+// A failed delivery is logged and passed to the failure handler before the error is returned.
+func (ns *NotificationService) Send(ctx context.Context, notification types.Notification) error {
+	if notification.ID == uuid.Nil {
+		notification.ID = uuid.New()
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+
+	if err := ns.notifier.Notify(ctx, notification); err != nil {
+		ns.logger.Warn("Notification delivery failed",
+			"id", notification.ID,
+			"type", notification.Type,
+			"recipient_id", notification.RecipientID,
+			"error", err,
+		)
+		handleNotificationFailure(notification, err)
+		return err
+	}
+
+	return nil
+}
+
+var _ NotificationServiceInterface = (*NotificationService)(nil)
+
+type NotificationServiceInterface interface {
+	Send(ctx context.Context, notification types.Notification) error
+}
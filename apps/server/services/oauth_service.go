@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/microsoft"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// OAuthProviderGoogle is the provider name used for Google accounts
+	OAuthProviderGoogle = "google"
+	// OAuthProviderMicrosoft is the provider name used for Microsoft accounts
+	OAuthProviderMicrosoft = "microsoft"
+
+	// oauthStateTTL is how long an OAuth state token stays valid (the flow should complete quickly)
+	oauthStateTTL = 10 * time.Minute
+)
+
+// OAuthProvider describes an external OAuth provider that users can link to their account.
+// Implementations supply the provider-specific OAuth configuration; the shared flow
+// (state handling, code exchange and token storage) is handled by OAuthService.
+type OAuthProvider interface {
+	// Name returns the provider name used in routes and as the user_oauth_tokens.provider value
+	Name() string
+	// OAuthConfig returns the oauth2 configuration for the provider
+	OAuthConfig() *oauth2.Config
+	// AuthCodeOptions returns the options needed to obtain a refresh token from the provider
+	AuthCodeOptions() []oauth2.AuthCodeOption
+}
+
+// googleOAuthProvider implements OAuthProvider for Google accounts
+type googleOAuthProvider struct{}
+
+func (googleOAuthProvider) Name() string {
+	return OAuthProviderGoogle
+}
+
+func (googleOAuthProvider) OAuthConfig() *oauth2.Config {
+	return getGoogleOAuthConfig()
+}
+
+func (googleOAuthProvider) AuthCodeOptions() []oauth2.AuthCodeOption {
+	// request offline access to get refresh_token. prompt=consent ensures refresh token is returned
+	return []oauth2.AuthCodeOption{oauth2.AccessTypeOffline, oauth2.ApprovalForce}
+}
+
+// microsoftOAuthProvider implements OAuthProvider for Microsoft (Entra ID) accounts
+type microsoftOAuthProvider struct{}
+
+func (microsoftOAuthProvider) Name() string {
+	return OAuthProviderMicrosoft
+}
+
+func (microsoftOAuthProvider) OAuthConfig() *oauth2.Config {
+	cfg := config.Get()
+	return &oauth2.Config{
+		ClientID:     cfg.Microsoft.ClientID,
+		ClientSecret: cfg.Microsoft.ClientSecret,
+		Scopes: []string{
+			// offline_access is required for Microsoft to issue a refresh token
+			"offline_access",
+			"openid",
+			"email",
+			"Files.ReadWrite",
+		},
+		Endpoint:    microsoft.AzureADEndpoint(cfg.Microsoft.Tenant),
+		RedirectURL: cfg.Microsoft.RedirectURL,
+	}
+}
+
+func (microsoftOAuthProvider) AuthCodeOptions() []oauth2.AuthCodeOption {
+	// Microsoft issues refresh tokens through the offline_access scope; consent forces the prompt
+	return []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("prompt", "consent")}
+}
+
+var (
+	_ OAuthProvider = googleOAuthProvider{}
+	_ OAuthProvider = microsoftOAuthProvider{}
+)
+
+// getGoogleOAuthConfig returns the OAuth config using values from the centralized config
+func getGoogleOAuthConfig() *oauth2.Config {
+	cfg := config.Get()
+	return &oauth2.Config{
+		ClientID:     cfg.Google.ClientID,
+		ClientSecret: cfg.Google.ClientSecret,
+		Scopes: []string{
+			// Full drive access for changing permissions and viewing.
+			"https://www.googleapis.com/auth/drive",
+		},
+		Endpoint:    google.Endpoint,
+		RedirectURL: cfg.Google.RedirectURL,
+	}
+}
+
+// OAuthService runs the OAuth linking flow for every configured provider
+type OAuthService struct {
+	logger       *config.Logger
+	cacheService *CacheService
+	providers    map[string]OAuthProvider
+}
+
+// TokenRefreshGroup deduplicates concurrent access token refreshes.
+// Callers refreshing the same provider and user at the same time share a single
+// call to the provider instead of each spending quota and racing to store the result.
+// The zero value is ready to use.
+type TokenRefreshGroup struct {
+	group singleflight.Group
+}
+
+// Do runs refresh for the provider and user unless a refresh for the same pair is
+// already in flight, in which case it waits for that one and returns its result.
+func (g *TokenRefreshGroup) Do(provider string, userID uuid.UUID, refresh func() (*oauth2.Token, error)) (*oauth2.Token, error) {
+	token, err, _ := g.group.Do(provider+":"+userID.String(), func() (any, error) {
+		return refresh()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token.(*oauth2.Token), nil
+}
+
+// tokenRefreshes is shared by every OAuthService. The auth routes and each GoogleService create
+// their own, so a group per instance would let them refresh the same token at the same time.
+var tokenRefreshes TokenRefreshGroup
+
+// NewOAuthService creates an OAuthService with Google and every other provider that has credentials configured.
+// Google is always registered because the /auth/google routes depend on it, like they did before providers were pluggable.
+func NewOAuthService() *OAuthService {
+	cfg := config.Get()
+	providers := map[string]OAuthProvider{
+		OAuthProviderGoogle: googleOAuthProvider{},
+	}
+
+	if cfg.Microsoft.ClientID != "" {
+		providers[OAuthProviderMicrosoft] = microsoftOAuthProvider{}
+	}
+
+	return &OAuthService{
+		logger:       config.SetupLogger(),
+		cacheService: NewCacheService(),
+		providers:    providers,
+	}
+}
+
+// NewOAuthServiceWithProviders creates an OAuthService with only the given providers, e.g. to test
+// the linking flow against a fake provider
+func NewOAuthServiceWithProviders(providers ...OAuthProvider) *OAuthService {
+	oas := NewOAuthService()
+	oas.providers = make(map[string]OAuthProvider, len(providers))
+	for _, provider := range providers {
+		oas.providers[provider.Name()] = provider
+	}
+	return oas
+}
+
+// Provider returns the provider registered under the given name
+func (oas *OAuthService) Provider(name string) (OAuthProvider, error) {
+	provider, ok := oas.providers[name]
+	if !ok {
+		return nil, lib.ErrUnsupportedProvider
+	}
+	return provider, nil
+}
+
+// Providers returns the names of all configured providers in alphabetical order
+func (oas *OAuthService) Providers() []string {
+	names := make([]string, 0, len(oas.providers))
+	for name := range oas.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// generateState creates a CSRF state token
+func (oas *OAuthService) generateState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// stateKey scopes a state token to its provider so a state issued for one provider
+// cannot be replayed against another provider's callback
+func stateKey(provider, state string) string {
+	return fmt.Sprintf("oauth_state:%s:%s", provider, state)
+}
+
+// saveOAuthState saves the OAuth state mapped to user ID in cache with expiry
+func (oas *OAuthService) saveOAuthState(provider string, userID uuid.UUID, state string) error {
+	return oas.cacheService.Set(stateKey(provider, state), userID.String(), oauthStateTTL)
+}
+
+// getUserFromState retrieves and validates the user ID from OAuth state
+func (oas *OAuthService) getUserFromState(provider, state string) (uuid.UUID, error) {
+	key := stateKey(provider, state)
+
+	userIDStr, err := oas.cacheService.Get(key)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to retrieve state: %w", err)
+	}
+	if userIDStr == "" {
+		return uuid.Nil, fmt.Errorf("%w: invalid or expired state", lib.ErrInvalidRequest)
+	}
+
+	// Delete the state after use (one-time use)
+	_ = oas.cacheService.Delete(key)
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user ID in state: %w", err)
+	}
+
+	return userID, nil
+}
+
+// GenerateAuthURL generates an authorization URL for the given provider and authenticated user
+func (oas *OAuthService) GenerateAuthURL(providerName string, userID uuid.UUID) (string, error) {
+	provider, err := oas.Provider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	// create state and persist it server-side mapped to the user ID
+	state, err := oas.generateState()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	if err := oas.saveOAuthState(provider.Name(), userID, state); err != nil {
+		return "", fmt.Errorf("failed to save OAuth state: %w", err)
+	}
+
+	return provider.OAuthConfig().AuthCodeURL(state, provider.AuthCodeOptions()...), nil
+}
+
+// HandleCallback validates the state, exchanges the code for a token, stores the refresh token
+// and returns the frontend URL to redirect to
+func (oas *OAuthService) HandleCallback(providerName, state, code string) (string, error) {
+	ctx := context.Background()
+
+	provider, err := oas.Provider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	if state == "" || code == "" {
+		return "", fmt.Errorf("%w: state and code are required", lib.ErrMissingParameter)
+	}
+
+	// Verify state maps to an authenticated user and is not expired
+	userID, err := oas.getUserFromState(provider.Name(), state)
+	if err != nil {
+		return "", fmt.Errorf("invalid or expired OAuth state: %w", err)
+	}
+
+	// Exchange the code for token
+	token, err := provider.OAuthConfig().Exchange(ctx, code)
+	if err != nil {
+		oas.logger.Error("OAuth token exchange failed", "provider", provider.Name(), "error", err)
+		return "", fmt.Errorf("%w: failed to exchange token: %v", lib.ErrExternalService, err)
+	}
+
+	// token.RefreshToken will be non-empty when offline access was requested and the user consented.
+	// Without one, keep an existing link, or ask the user to revoke access and link again.
+	if token.RefreshToken == "" {
+		existing, err := oas.LoadUserRefreshToken(provider.Name(), userID)
+		if err != nil {
+			return "", fmt.Errorf("failed to check existing link: %w", err)
+		}
+
+		if _, err := ResolveRefreshToken(token, existing); err != nil {
+			oas.logger.Warn("No refresh token returned and no existing link, reconsent required",
+				"provider", provider.Name(),
+				"user_id", userID,
+			)
+			return "", err
+		}
+	} else {
+		// IMPORTANT: Save refresh token securely server-side (encrypt at rest)
+		if err := oas.SaveUserRefreshToken(provider.Name(), userID, token.RefreshToken); err != nil {
+			return "", fmt.Errorf("failed to save token: %w", err)
+		}
+	}
+
+	// Return redirect URL for frontend
+	cfg := config.Get()
+	return cfg.FrontendURL + "/dashboard", nil
+}
+
+// ResolveRefreshToken decides which refresh token should back the user's link after a token exchange.
+// A newly issued refresh token always wins. When the provider returns none (consent was granted before),
+// an existing stored token keeps the link working. If neither is available, lib.ErrOAuthReconsentRequired
+// is returned so the user can be told to revoke the app's access and link the account again.
+func ResolveRefreshToken(token *oauth2.Token, existing string) (string, error) {
+	if token != nil && token.RefreshToken != "" {
+		return token.RefreshToken, nil
+	}
+	if existing != "" {
+		return existing, nil
+	}
+	return "", lib.ErrOAuthReconsentRequired
+}
+
+// GetAccessToken gets a fresh access token for the user from the given provider.
+// Concurrent calls for the same provider and user share a single refresh.
+func (oas *OAuthService) GetAccessToken(providerName string, userID uuid.UUID) (*oauth2.Token, error) {
+	provider, err := oas.Provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokenRefreshes.Do(provider.Name(), userID, func() (*oauth2.Token, error) {
+		return oas.refreshAccessToken(provider, userID)
+	})
+}
+
+// refreshAccessToken exchanges the user's stored refresh token for a new access token
+func (oas *OAuthService) refreshAccessToken(provider OAuthProvider, userID uuid.UUID) (*oauth2.Token, error) {
+	ctx := context.Background()
+
+	refreshToken, err := oas.LoadUserRefreshToken(provider.Name(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load refresh token: %w", err)
+	}
+
+	if refreshToken == "" {
+		return nil, lib.ErrNoLinkedAccount
+	}
+
+	ts := provider.OAuthConfig().TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken})
+	newToken, err := ts.Token()
+	if err != nil {
+		oas.logger.Error("OAuth token refresh failed", "provider", provider.Name(), "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	return newToken, nil
+}
+
+// SaveUserRefreshToken stores the user's refresh token for the given provider.
+// Empty tokens are rejected so a valid link is never replaced by an unusable one.
+func (oas *OAuthService) SaveUserRefreshToken(provider string, userID uuid.UUID, refreshToken string) error {
+	query, err := BuildRefreshTokenUpsert(provider, userID, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	if _, err := database.ExecuteQuery[types.UserOAuthToken](query); err != nil {
+		oas.logger.Error("Failed to save refresh token", "provider", provider, "user_id", userID, "error", err)
+		return fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// BuildRefreshTokenUpsert builds the query that stores a refresh token for a user and provider.
+// Returns lib.ErrEmptyRefreshToken if the token is empty.
+func BuildRefreshTokenUpsert(provider string, userID uuid.UUID, refreshToken string) (*types.QueryParams, error) {
+	if strings.TrimSpace(refreshToken) == "" {
+		return nil, lib.ErrEmptyRefreshToken
+	}
+
+	query := Query().SetOperation("insert").SetTable(lib.TableUserOAuthTokens).SetData(map[string]any{
+		"user_id":       userID,
+		"provider":      provider,
+		"refresh_token": refreshToken,
+	})
+	// On conflict (same user_id and provider), update the refresh_token and updated_at timestamp.
+	// The WHERE guard keeps an existing token should an empty one ever reach the database.
+	query.OnConflict = "(user_id, provider) DO UPDATE SET refresh_token = EXCLUDED.refresh_token, updated_at = NOW() " +
+		"WHERE EXCLUDED.refresh_token <> ''"
+
+	return query, nil
+}
+
+// LoadUserRefreshToken loads the user's refresh token for the given provider.
+// Returns an empty string if the user has not linked the provider.
+func (oas *OAuthService) LoadUserRefreshToken(provider string, userID uuid.UUID) (string, error) {
+	query := Query().SetOperation("select").SetTable(lib.TableUserOAuthTokens).SetSelect([]string{"refresh_token", "id"}).SetLimit(1)
+	query.Where["user_oauth_tokens.user_id"] = userID
+	query.Where["user_oauth_tokens.provider"] = provider
+
+	result, err := database.ExecuteQuery[types.GoogleRefreshTokenResponse](query)
+	if err != nil {
+		oas.logger.Error("Failed to load refresh token", "provider", provider, "user_id", userID, "error", err)
+		return "", lib.ErrFailedToRefreshToken
+	}
+
+	if len(result.Data) == 0 {
+		return "", nil
+	}
+
+	return result.Single.RefreshToken, nil
+}
+
+// DeleteUserRefreshToken unlinks the provider from the user's account
+func (oas *OAuthService) DeleteUserRefreshToken(provider string, userID uuid.UUID) error {
+	query := Query().SetOperation("delete").SetTable(lib.TableUserOAuthTokens)
+	query.Where["user_id"] = userID
+	query.Where["provider"] = provider
+
+	if _, err := database.ExecuteQuery[struct{}](query); err != nil {
+		return lib.ErrFailedToDeleteToken
+	}
+
+	return nil
+}
+
+var _ OAuthServiceInterface = (*OAuthService)(nil)
+
+type OAuthServiceInterface interface {
+	Provider(name string) (OAuthProvider, error)
+	Providers() []string
+	GenerateAuthURL(providerName string, userID uuid.UUID) (string, error)
+	HandleCallback(providerName, state, code string) (string, error)
+	GetAccessToken(providerName string, userID uuid.UUID) (*oauth2.Token, error)
+	SaveUserRefreshToken(provider string, userID uuid.UUID, refreshToken string) error
+	LoadUserRefreshToken(provider string, userID uuid.UUID) (string, error)
+	DeleteUserRefreshToken(provider string, userID uuid.UUID) error
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+)
+
+// readOnlyModeKey is the Redis key that holds the read-only mode shared by all replicas
+const readOnlyModeKey = "maintenance:read_only"
+
+// ReadOnlyStore is the key value store the read-only mode is kept in, implemented by CacheService
+type ReadOnlyStore interface {
+	Get(key string) (string, error)
+	Set(key string, value any, ttl time.Duration) error
+}
+
+// ReadOnlyService switches the whole API between normal and read-only operation.
+// The mode is stored in Redis so a change made on one replica applies to all of them.
+// Until an admin sets it, or while Redis is unreachable, READ_ONLY_MODE decides.
+type ReadOnlyService struct {
+	store    ReadOnlyStore
+	fallback bool
+	logger   *config.Logger
+}
+
+func NewReadOnlyService() *ReadOnlyService {
+	return NewReadOnlyServiceWithStore(NewCacheService(), config.Get().ReadOnlyMode, config.SetupLogger())
+}
+
+// NewReadOnlyServiceWithStore creates a ReadOnlyService on the given store, using fallback
+// when the store has no mode or cannot be reached
+func NewReadOnlyServiceWithStore(store ReadOnlyStore, fallback bool, logger *config.Logger) *ReadOnlyService {
+	return &ReadOnlyService{
+		store:    store,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+// IsReadOnly reports whether the API currently rejects writes
+func (ros *ReadOnlyService) IsReadOnly() bool {
+	value, err := ros.store.Get(readOnlyModeKey)
+	if err != nil {
+		ros.logger.Warn("Failed to read read-only mode, using configured default", "error", err, "read_only", ros.fallback)
+		return ros.fallback
+	}
+
+	switch value {
+	case "1":
+		return true
+	case "0":
+		return false
+	default:
+		return ros.fallback
+	}
+}
+
+// SetReadOnly enables or disables read-only mode for every replica
+func (ros *ReadOnlyService) SetReadOnly(enabled bool) error {
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+
+	// The mode never expires, it stays until an admin changes it again
+	if err := ros.store.Set(readOnlyModeKey, value, 0); err != nil {
+		return fmt.Errorf("failed to store read-only mode: %w", err)
+	}
+
+	ros.logger.AuditWarn("Read-only mode changed", "read_only", enabled)
+	return nil
+}
+
+var _ ReadOnlyServiceInterface = (*ReadOnlyService)(nil)
+
+type ReadOnlyServiceInterface interface {
+	IsReadOnly() bool
+	SetReadOnly(enabled bool) error
+}
@@ -1,18 +1,31 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/database"
 	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/types"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
 )
 
 type SubjectService struct {
 	Logger *config.Logger
 }
 
+// subjectColumns are the columns returned for a subject
+var subjectColumns = []string{
+	"id", "name", "code", "color", "created_at", "updated_at", "teacher_id", "teacher_name", "is_active",
+}
+
+// subjectColorPattern matches the hex colors the frontend renders subjects with, e.g. "#1E88E5"
+var subjectColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
 func NewSubjectService() *SubjectService {
 	return &SubjectService{
 		Logger: config.SetupLogger(),
@@ -20,9 +33,7 @@ func NewSubjectService() *SubjectService {
 }
 
 func (ss *SubjectService) GetSubjectByID(subjectID string) (any, error) {
-	query := Query().SetOperation("select").SetTable("subjects").SetLimit(1).SetSelect([]string{
-		"id", "name", "code", "color", "created_at", "updated_at", "teacher_id", "teacher_name",
-	})
+	query := Query().SetOperation("select").SetTable("subjects").SetLimit(1).SetSelect(subjectColumns)
 	query.Where[fmt.Sprintf("public.%s.id", lib.TableSubjects)] = subjectID
 
 	data, err := database.ExecuteQuery[types.Subject](query)
@@ -39,9 +50,7 @@ func (ss *SubjectService) GetSubjectByID(subjectID string) (any, error) {
 }
 
 func (ss *SubjectService) GetAllSubjects() ([]types.Subject, error) {
-	query := Query().SetOperation("select").SetTable(lib.TableSubjects).SetSelect([]string{
-		"id", "name", "code", "color", "created_at", "updated_at",
-	})
+	query := Query().SetOperation("select").SetTable(lib.TableSubjects).SetSelect(subjectColumns).AddOrder("name ASC")
 
 	data, err := database.ExecuteQuery[types.Subject](query)
 	if err != nil {
@@ -75,6 +84,29 @@ func (ss *SubjectService) GetUserSubjects(userID string) ([]types.Subject, error
 	return userSubjects.Data, nil
 }
 
+// GetSubjectsForTeacher returns the active subjects the teacher is assigned to through subject_teachers
+func (ss *SubjectService) GetSubjectsForTeacher(teacherID uuid.UUID) ([]types.Subject, error) {
+	query := Query().SetRawSQL(`
+		SELECT s.id, s.name, s.code, s.color, s.created_at, s.updated_at
+		FROM subjects s
+		JOIN subject_teachers st ON s.id = st.subject_id
+		WHERE st.user_id = ? AND s.is_active = true
+		ORDER BY s.name ASC
+	`, teacherID)
+
+	teacherSubjects, err := database.ExecuteQuery[types.Subject](query)
+	if err != nil {
+		ss.Logger.Error("Failed to retrieve teacher subjects", "teacher_id", teacherID.String(), "error", err)
+		return nil, err
+	}
+
+	if len(teacherSubjects.Data) == 0 {
+		return []types.Subject{}, nil
+	}
+
+	return teacherSubjects.Data, nil
+}
+
 func (ss *SubjectService) GetSubjectTeachers(subjectID string) ([]types.User, error) {
 	query := Query().SetRawSQL(`
 			SELECT u.id, u.username, u.email, u.role, u.created_at
@@ -92,9 +124,337 @@ func (ss *SubjectService) GetSubjectTeachers(subjectID string) ([]types.User, er
 	return data.Data, nil
 }
 
+// PurgeSubject permanently deletes a subject together with the submissions for its deadlines,
+// the deadlines and the teacher mappings. Everything is deleted in one transaction, so either
+// all rows are removed or none are. Only admins may purge subjects, the route enforces this.
+func (ss *SubjectService) PurgeSubject(subjectID uuid.UUID) error {
+	steps := []struct {
+		table string
+		where string
+	}{
+		{lib.TableSubmissions, "submissions.deadline_id IN (SELECT id FROM deadlines WHERE subject_id = ?)"},
+		{lib.TableDeadlines, "deadlines.subject_id = ?"},
+		{lib.TableSubjectTeachers, "subject_teachers.subject_id = ?"},
+		// Enrollments in user_subjects are removed by their cascading foreign key
+		{lib.TableSubjects, "subjects.id = ?"},
+	}
+
+	deleted := make(map[string]int64, len(steps))
+	err := database.Transaction(context.Background(), func(tx *pg.Tx) error {
+		for _, step := range steps {
+			query := Query().SetOperation("delete").SetTable(step.table).SetWhereRaw(step.where, subjectID).WithTx(tx)
+			result, err := database.ExecuteQuery[any](query)
+			if err != nil {
+				return fmt.Errorf("failed to delete %s: %w", step.table, err)
+			}
+			deleted[step.table] = result.Count
+		}
+
+		// Returning an error rolls back the deletes above when the subject did not exist
+		if deleted[lib.TableSubjects] == 0 {
+			return lib.ErrSubjectNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		ss.Logger.Error("Failed to purge subject", "subject_id", subjectID.String(), "error", err)
+		return err
+	}
+
+	ss.Logger.AuditWarn("Subject purged",
+		"subject_id", subjectID.String(),
+		"deadlines_deleted", deleted[lib.TableDeadlines],
+		"submissions_deleted", deleted[lib.TableSubmissions],
+		"teachers_unassigned", deleted[lib.TableSubjectTeachers],
+	)
+	return nil
+}
+
+// ValidateSubjectFields checks the name, code and color of a subject and returns a validation error for the first invalid field.
+// The name is required, the code and color are optional.
+func ValidateSubjectFields(name, code, color string) error {
+	if strings.TrimSpace(name) == "" {
+		return lib.NewValidationError("name", "name is required")
+	}
+	if len([]rune(name)) > types.MaxSubjectNameLength {
+		return lib.NewValidationError("name", fmt.Sprintf("name cannot be longer than %d characters", types.MaxSubjectNameLength))
+	}
+	if len([]rune(code)) > types.MaxSubjectCodeLength {
+		return lib.NewValidationError("code", fmt.Sprintf("code cannot be longer than %d characters", types.MaxSubjectCodeLength))
+	}
+	if color != "" && !subjectColorPattern.MatchString(color) {
+		return lib.NewValidationError("color", "color must be a hex color such as #1E88E5")
+	}
+	return nil
+}
+
+// CreateSubject creates an active subject on behalf of the admin actorID.
+// A teacher in the request becomes the main teacher of the subject and is assigned to it in the same transaction.
+func (ss *SubjectService) CreateSubject(actorID uuid.UUID, req *types.CreateSubjectRequest) (*types.Subject, error) {
+	name, code := strings.TrimSpace(req.Name), strings.TrimSpace(req.Code)
+	if err := ValidateSubjectFields(name, code, req.Color); err != nil {
+		return nil, err
+	}
+
+	var subject *types.Subject
+	err := database.Transaction(context.Background(), func(tx *pg.Tx) error {
+		query := Query().SetOperation("insert").SetTable(lib.TableSubjects).SetReturning(subjectColumns...).WithTx(tx)
+		query.Data = map[string]any{
+			"name":  name,
+			"code":  code,
+			"color": req.Color,
+		}
+		if req.TeacherID != nil {
+			teacher, err := getTeacher(tx, *req.TeacherID)
+			if err != nil {
+				return err
+			}
+			query.Data["teacher_id"] = teacher.Id
+			query.Data["teacher_name"] = teacher.Username
+		}
+
+		result, err := database.ExecuteQuery[types.Subject](query)
+		if err != nil {
+			return fmt.Errorf("failed to insert subject: %w", err)
+		}
+		if result.Single == nil {
+			return fmt.Errorf("failed to create subject: no row returned")
+		}
+		subject = result.Single
+
+		if req.TeacherID != nil {
+			if _, err := assignTeacher(tx, subject.Id, *req.TeacherID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		ss.Logger.Error("Failed to create subject", "actor_id", actorID.String(), "error", err)
+		return nil, err
+	}
+
+	ss.Logger.AuditInfo("Subject created",
+		"subject_id", subject.Id.String(),
+		"actor_id", actorID.String(),
+		"teacher_id", subject.TeacherId.String(),
+	)
+	return subject, nil
+}
+
+// UpdateSubject applies the set fields of the request to a subject and returns the updated subject.
+// A new main teacher is assigned to the subject as well, the previous one stays assigned until removed.
+func (ss *SubjectService) UpdateSubject(actorID, subjectID uuid.UUID, req *types.UpdateSubjectRequest) (*types.Subject, error) {
+	var subject *types.Subject
+	err := database.Transaction(context.Background(), func(tx *pg.Tx) error {
+		query := Query().SetRawSQL(`SELECT `+strings.Join(subjectColumns, ", ")+` FROM subjects WHERE id = ? FOR UPDATE`, subjectID).WithTx(tx)
+		result, err := database.ExecuteQuery[types.Subject](query)
+		if err != nil {
+			return fmt.Errorf("failed to fetch subject: %w", err)
+		}
+		if result.Single == nil {
+			return lib.ErrSubjectNotFound
+		}
+		subject = result.Single
+
+		if req.Name != nil {
+			subject.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.Code != nil {
+			subject.Code = strings.TrimSpace(*req.Code)
+		}
+		if req.Color != nil {
+			subject.Color = *req.Color
+		}
+		if req.IsActive != nil {
+			subject.IsActive = *req.IsActive
+		}
+		if err := ValidateSubjectFields(subject.Name, subject.Code, subject.Color); err != nil {
+			return err
+		}
+
+		// A nil teacher ID keeps the current main teacher
+		var teacherID *uuid.UUID
+		if subject.TeacherId != uuid.Nil {
+			teacherID = &subject.TeacherId
+		}
+		var teacherName *string
+		if subject.TeacherName != "" {
+			teacherName = &subject.TeacherName
+		}
+		if req.TeacherID != nil {
+			teacher, err := getTeacher(tx, *req.TeacherID)
+			if err != nil {
+				return err
+			}
+			if _, err := assignTeacher(tx, subjectID, teacher.Id); err != nil {
+				return err
+			}
+			teacherID, teacherName = &teacher.Id, &teacher.Username
+		}
+
+		update := Query().SetRawSQL(`UPDATE subjects SET name = ?, code = ?, color = ?, is_active = ?, teacher_id = ?, teacher_name = ?, updated_at = NOW()
+			WHERE id = ? RETURNING `+strings.Join(subjectColumns, ", "),
+			subject.Name, subject.Code, subject.Color, subject.IsActive, teacherID, teacherName, subjectID).WithTx(tx)
+		updated, err := database.ExecuteQuery[types.Subject](update)
+		if err != nil {
+			return fmt.Errorf("failed to update subject: %w", err)
+		}
+		if updated.Single == nil {
+			return lib.ErrSubjectNotFound
+		}
+		subject = updated.Single
+		return nil
+	})
+	if err != nil {
+		ss.Logger.Error("Failed to update subject", "subject_id", subjectID.String(), "actor_id", actorID.String(), "error", err)
+		return nil, err
+	}
+
+	ss.Logger.AuditInfo("Subject updated",
+		"subject_id", subjectID.String(),
+		"actor_id", actorID.String(),
+		"is_active", subject.IsActive,
+	)
+	return subject, nil
+}
+
+// DeactivateSubject hides a subject from students and teachers while keeping its deadlines and submissions.
+// Deactivating an inactive subject is not an error, UpdateSubject activates it again.
+func (ss *SubjectService) DeactivateSubject(actorID, subjectID uuid.UUID) error {
+	query := Query().SetRawSQL(`UPDATE subjects SET is_active = false, updated_at = NOW() WHERE id = ? RETURNING id`, subjectID)
+
+	result, err := database.ExecuteQuery[types.Subject](query)
+	if err != nil {
+		ss.Logger.Error("Failed to deactivate subject", "subject_id", subjectID.String(), "error", err)
+		return err
+	}
+	if result.Count == 0 {
+		return lib.ErrSubjectNotFound
+	}
+
+	ss.Logger.AuditWarn("Subject deactivated", "subject_id", subjectID.String(), "actor_id", actorID.String())
+	return nil
+}
+
+// AssignTeacher assigns a teacher to a subject. Assigning a teacher twice is not an error.
+// Returns a validation error when the user is not a teacher or admin.
+func (ss *SubjectService) AssignTeacher(actorID, subjectID, teacherID uuid.UUID) error {
+	var assigned bool
+	err := database.Transaction(context.Background(), func(tx *pg.Tx) error {
+		if err := subjectExists(tx, subjectID); err != nil {
+			return err
+		}
+		if _, err := getTeacher(tx, teacherID); err != nil {
+			return err
+		}
+
+		var err error
+		assigned, err = assignTeacher(tx, subjectID, teacherID)
+		return err
+	})
+	if err != nil {
+		ss.Logger.Error("Failed to assign teacher", "subject_id", subjectID.String(), "teacher_id", teacherID.String(), "error", err)
+		return err
+	}
+
+	if assigned {
+		ss.Logger.AuditInfo("Teacher assigned to subject",
+			"subject_id", subjectID.String(),
+			"teacher_id", teacherID.String(),
+			"actor_id", actorID.String(),
+		)
+	}
+	return nil
+}
+
+// RemoveTeacher removes a teacher from a subject. When the teacher was the main teacher the subject is left without one.
+// Returns lib.ErrTeacherNotAssigned when the teacher was not assigned to the subject.
+func (ss *SubjectService) RemoveTeacher(actorID, subjectID, teacherID uuid.UUID) error {
+	err := database.Transaction(context.Background(), func(tx *pg.Tx) error {
+		query := Query().SetOperation("delete").SetTable(lib.TableSubjectTeachers).
+			SetWhereRaw("subject_teachers.subject_id = ? AND subject_teachers.user_id = ?", subjectID, teacherID).
+			WithTx(tx)
+		result, err := database.ExecuteQuery[any](query)
+		if err != nil {
+			return fmt.Errorf("failed to remove teacher: %w", err)
+		}
+		if result.Count == 0 {
+			return lib.ErrTeacherNotAssigned
+		}
+
+		clear := Query().SetRawSQL(`UPDATE subjects SET teacher_id = NULL, teacher_name = NULL, updated_at = NOW()
+			WHERE id = ? AND teacher_id = ? RETURNING id`, subjectID, teacherID).WithTx(tx)
+		if _, err := database.ExecuteQuery[types.Subject](clear); err != nil {
+			return fmt.Errorf("failed to clear main teacher: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		ss.Logger.Error("Failed to remove teacher", "subject_id", subjectID.String(), "teacher_id", teacherID.String(), "error", err)
+		return err
+	}
+
+	ss.Logger.AuditInfo("Teacher removed from subject",
+		"subject_id", subjectID.String(),
+		"teacher_id", teacherID.String(),
+		"actor_id", actorID.String(),
+	)
+	return nil
+}
+
+// subjectExists returns lib.ErrSubjectNotFound when the subject does not exist
+func subjectExists(tx *pg.Tx, subjectID uuid.UUID) error {
+	query := Query().SetRawSQL(`SELECT id FROM subjects WHERE id = ?`, subjectID).WithTx(tx)
+	result, err := database.ExecuteQuery[types.Subject](query)
+	if err != nil {
+		return fmt.Errorf("failed to fetch subject: %w", err)
+	}
+	if result.Single == nil {
+		return lib.ErrSubjectNotFound
+	}
+	return nil
+}
+
+// getTeacher returns the user with the given ID when they may teach subjects, which teachers and admins can
+func getTeacher(tx *pg.Tx, userID uuid.UUID) (*types.User, error) {
+	query := Query().SetRawSQL(`SELECT id, username, email, role, created_at FROM users WHERE id = ?`, userID).WithTx(tx)
+	result, err := database.ExecuteQuery[types.User](query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch teacher: %w", err)
+	}
+	if result.Single == nil {
+		return nil, lib.ErrUserNotFound
+	}
+	if result.Single.Role != lib.RoleTeacher && result.Single.Role != lib.RoleAdmin {
+		return nil, lib.NewValidationError("teacher_id", "user must be a teacher or admin")
+	}
+	return result.Single, nil
+}
+
+// assignTeacher adds the subject_teachers mapping and reports whether it was new
+func assignTeacher(tx *pg.Tx, subjectID, teacherID uuid.UUID) (bool, error) {
+	query := Query().SetRawSQL(`INSERT INTO subject_teachers (subject_id, user_id) VALUES (?, ?)
+		ON CONFLICT (subject_id, user_id) DO NOTHING RETURNING id`, subjectID, teacherID).WithTx(tx)
+	result, err := database.ExecuteQuery[types.Subject](query)
+	if err != nil {
+		return false, fmt.Errorf("failed to assign teacher: %w", err)
+	}
+	return result.Count > 0, nil
+}
+
+var _ SubjectServiceInterface = (*SubjectService)(nil)
+
 type SubjectServiceInterface interface {
 	GetSubjectByID(subjectID string) (any, error)
 	GetAllSubjects() ([]types.Subject, error)
 	GetUserSubjects(userID string) ([]types.Subject, error)
+	GetSubjectsForTeacher(teacherID uuid.UUID) ([]types.Subject, error)
 	GetSubjectTeachers(subjectID string) ([]types.User, error)
+	PurgeSubject(subjectID uuid.UUID) error
+	CreateSubject(actorID uuid.UUID, req *types.CreateSubjectRequest) (*types.Subject, error)
+	UpdateSubject(actorID, subjectID uuid.UUID, req *types.UpdateSubjectRequest) (*types.Subject, error)
+	DeactivateSubject(actorID, subjectID uuid.UUID) error
+	AssignTeacher(actorID, subjectID, teacherID uuid.UUID) error
+	RemoveTeacher(actorID, subjectID, teacherID uuid.UUID) error
 }
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+)
+
+type UserService struct {
+	Logger       *config.Logger
+	cacheService *CacheService
+}
+
+func NewUserService() *UserService {
+	return &UserService{
+		Logger:       config.SetupLogger(),
+		cacheService: NewCacheService(),
+	}
+}
+
+// UpdateUserRole changes the role of the target user on behalf of the admin actorID and returns the updated user.
+// The last admin cannot lose the admin role, so the application always keeps someone who can manage roles.
+// Access tokens issued before the change keep the old role until they expire.
+func (us *UserService) UpdateUserRole(ctx context.Context, actorID, targetUserID uuid.UUID, role string) (*types.User, error) {
+	if !slices.Contains(lib.Roles, role) {
+		return nil, lib.ErrInvalidRole
+	}
+
+	var user *types.User
+	var oldRole string
+	err := database.Transaction(ctx, func(tx *pg.Tx) error {
+		// Lock the target so concurrent role changes are applied one after the other
+		query := Query().SetRawSQL(`SELECT id, username, email, role, created_at FROM users WHERE id = ? FOR UPDATE`, targetUserID).WithTx(tx).SetContext(ctx)
+		result, err := database.ExecuteQuery[types.User](query)
+		if err != nil {
+			return fmt.Errorf("failed to fetch user: %w", err)
+		}
+		if result.Single == nil {
+			return lib.ErrUserNotFound
+		}
+		user = result.Single
+		oldRole = user.Role
+
+		if oldRole == role {
+			return nil
+		}
+
+		if oldRole == lib.RoleAdmin {
+			// Locking every admin row makes two admins demoting each other wait for one another
+			query := Query().SetRawSQL(`SELECT id FROM users WHERE role = ? FOR UPDATE`, lib.RoleAdmin).WithTx(tx).SetContext(ctx)
+			admins, err := database.ExecuteQuery[types.User](query)
+			if err != nil {
+				return fmt.Errorf("failed to count admins: %w", err)
+			}
+			if len(admins.Data) <= 1 {
+				return lib.ErrLastAdmin
+			}
+		}
+
+		update := Query().SetOperation("update").SetTable(lib.TableUsers).
+			SetData(map[string]any{"role": role}).
+			SetWhereRaw("public.users.id = ?", targetUserID).
+			WithTx(tx).
+			SetContext(ctx)
+		if _, err := database.ExecuteQuery[any](update); err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		user.Role = role
+		return nil
+	})
+	if err != nil {
+		us.Logger.Error("Failed to update user role", "actor_id", actorID.String(), "target_id", targetUserID.String(), "role", role, "error", err)
+		return nil, err
+	}
+
+	if oldRole == role {
+		return user, nil
+	}
+
+	// The cached user still has the old role
+	if err := us.cacheService.DeleteUserFromCache(targetUserID); err != nil {
+		us.Logger.Warn("Failed to clear cached user after role change", "user_id", targetUserID.String(), "error", err)
+	}
+
+	us.Logger.AuditWarn("User role changed",
+		"actor_id", actorID.String(),
+		"target_id", targetUserID.String(),
+		"old_role", oldRole,
+		"new_role", role,
+	)
+	return user, nil
+}
+
+// maxUserSearchLength caps the search text of SearchUsers
+const maxUserSearchLength = 100
+
+// userSearchRow is a user found by SearchUsers. The select names its table,
+// so the model must not add the users table a second time.
+type userSearchRow struct {
+	tableName struct{} `pg:"_,alias:users"`
+
+	types.User
+}
+
+// SearchUsers returns one page of the users whose username or email contains search, ignoring case,
+// newest first, along with the number of matching users across all pages.
+// An empty search matches every user. Password hashes are never selected.
+func (us *UserService) SearchUsers(ctx context.Context, search string, page, limit int) ([]types.User, int, error) {
+	search = strings.TrimSpace(search)
+	if utf8.RuneCountInString(search) > maxUserSearchLength {
+		return nil, 0, lib.NewValidationError("q", fmt.Sprintf("must be at most %d characters", maxUserSearchLength))
+	}
+	if page < 1 || limit < 1 {
+		return nil, 0, fmt.Errorf("%w: page and limit must be positive", lib.ErrInvalidInput)
+	}
+
+	filter := Query().SetOperation("select").SetTable(lib.TableUsers).SetContext(ctx)
+	if search != "" {
+		filter.AddWhereContainsAny(search, "users.username", "users.email")
+	}
+
+	query := filter.Clone().
+		SetSelect([]string{"id", "username", "email", "role", "created_at"}).
+		AddOrder("users.created_at DESC").
+		AddOrder("users.id").
+		SetLimit(limit).
+		SetOffset((page - 1) * limit)
+	result, err := database.ExecuteQuery[userSearchRow](query)
+	if err != nil {
+		us.Logger.Error("Failed to search users", "error", err)
+		return nil, 0, err
+	}
+
+	countQuery := filter.SetSelect([]string{"COUNT(*) AS count"})
+	count, err := database.ExecuteQuery[struct{ Count int }](countQuery)
+	if err != nil {
+		us.Logger.Error("Failed to count users", "error", err)
+		return nil, 0, err
+	}
+
+	users := make([]types.User, len(result.Data))
+	for i, row := range result.Data {
+		users[i] = row.User
+	}
+
+	total := 0
+	if count.Single != nil {
+		total = count.Single.Count
+	}
+	return users, total, nil
+}
+
+var _ UserServiceInterface = (*UserService)(nil)
+
+type UserServiceInterface interface {
+	UpdateUserRole(ctx context.Context, actorID, targetUserID uuid.UUID, role string) (*types.User, error)
+	SearchUsers(ctx context.Context, search string, page, limit int) ([]types.User, int, error)
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookSignatureHeader = "X-PWS-Signature"
+	WebhookEventHeader     = "X-PWS-Event"
+	WebhookDeliveryHeader  = "X-PWS-Delivery"
+)
+
+// webhookSignaturePrefix names the algorithm in the signature header, e.g. "sha256=<hex>"
+const webhookSignaturePrefix = "sha256="
+
+// webhookColumns are the columns returned for a webhook, including the secret the worker signs with
+var webhookColumns = []string{"id", "url", "event_types", "secret", "active", "created_by", "created_at", "updated_at"}
+
+// SignWebhookPayload returns the signature header value of a delivery body.
+// Receivers verify a delivery by computing the HMAC-SHA256 of the raw body with their secret.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature reports whether the signature header value matches the body
+func VerifyWebhookSignature(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhookPayload(secret, body)), []byte(signature))
+}
+
+// GenerateWebhookSecret creates a random secret for a webhook subscription
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// WebhookSender delivers a webhook event to a single subscriber
+type WebhookSender interface {
+	Deliver(ctx context.Context, delivery types.WebhookDelivery) error
+}
+
+// WebhookDeliverer posts webhook events to their subscribers over HTTP
+type WebhookDeliverer struct {
+	client *http.Client
+}
+
+// NewWebhookDeliverer creates a deliverer that gives up on a single delivery after timeout
+func NewWebhookDeliverer(timeout time.Duration) *WebhookDeliverer {
+	return &WebhookDeliverer{client: &http.Client{Timeout: timeout}}
+}
+
+// Deliver posts the event of the delivery as signed JSON. Any non-2xx response is a failed delivery.
+func (wd *WebhookDeliverer) Deliver(ctx context.Context, delivery types.WebhookDelivery) error {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event.Type)
+	req.Header.Set(WebhookDeliveryHeader, delivery.Event.ID.String())
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Secret, body))
+
+	resp, err := wd.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+var _ WebhookSender = (*WebhookDeliverer)(nil)
+
+// WebhookEventHandler receives every published webhook event
+type WebhookEventHandler func(event types.WebhookEvent)
+
+var (
+	webhookEventHandler WebhookEventHandler
+	webhookEventMu      sync.RWMutex
+)
+
+// RegisterWebhookEventHandler sets the handler that receives published events, the worker
+// manager registers the webhook worker here. Passing nil removes the handler.
+func RegisterWebhookEventHandler(handler WebhookEventHandler) {
+	webhookEventMu.Lock()
+	defer webhookEventMu.Unlock()
+	webhookEventHandler = handler
+}
+
+// PublishWebhookEvent hands an event to the registered handler for delivery to its subscribers.
+// Events are dropped when no handler is registered, e.g. when webhooks are disabled.
+func PublishWebhookEvent(eventType string, data map[string]any) {
+	webhookEventMu.RLock()
+	handler := webhookEventHandler
+	webhookEventMu.RUnlock()
+
+	if handler == nil {
+		return
+	}
+
+	handler(types.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	})
+}
+
+// ValidateWebhookSubscription checks that a webhook has an absolute http(s) URL and only known event types
+func ValidateWebhookSubscription(rawURL string, eventTypes []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", lib.ErrInvalidWebhook)
+	}
+	if len(eventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", lib.ErrInvalidWebhook)
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(types.WebhookEventTypes, eventType) {
+			return fmt.Errorf("%w: unknown event type %q", lib.ErrInvalidWebhook, eventType)
+		}
+	}
+	return nil
+}
+
+// WebhookService manages the webhook subscriptions
+type WebhookService struct {
+	Logger *config.Logger
+}
+
+func NewWebhookService() *WebhookService {
+	return &WebhookService{
+		Logger: config.SetupLogger(),
+	}
+}
+
+// CreateWebhook subscribes a URL to event types on behalf of actorID.
+// The returned webhook includes the secret, it is not returned again afterwards.
+func (ws *WebhookService) CreateWebhook(actorID uuid.UUID, req *types.CreateWebhookRequest) (*types.CreatedWebhook, error) {
+	if err := ValidateWebhookSubscription(req.URL, req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = GenerateWebhookSecret(); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+
+	query := Query().SetOperation("insert").SetTable(lib.TableWebhooks).SetReturning(webhookColumns...)
+	query.Data = map[string]any{
+		"url":         req.URL,
+		"event_types": pg.Array(slices.Compact(slices.Sorted(slices.Values(req.EventTypes)))),
+		"secret":      secret,
+		"created_by":  actorID,
+	}
+
+	result, err := database.ExecuteQuery[types.Webhook](query)
+	if err != nil {
+		ws.Logger.Error("Failed to create webhook", "actor_id", actorID.String(), "error", err)
+		return nil, err
+	}
+	if result.Single == nil {
+		return nil, fmt.Errorf("failed to create webhook: no row returned")
+	}
+
+	ws.Logger.AuditInfo("Webhook created",
+		"webhook_id", result.Single.ID.String(),
+		"actor_id", actorID.String(),
+		"event_types", result.Single.EventTypes)
+
+	return &types.CreatedWebhook{Webhook: *result.Single, Secret: secret}, nil
+}
+
+// ListWebhooks returns every webhook subscription, oldest first
+func (ws *WebhookService) ListWebhooks() ([]types.Webhook, error) {
+	query := Query().SetOperation("select").SetTable(lib.TableWebhooks).
+		SetSelect(webhookColumns).
+		AddOrder("created_at ASC")
+
+	result, err := database.ExecuteQuery[types.Webhook](query)
+	if err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		return []types.Webhook{}, nil
+	}
+	return result.Data, nil
+}
+
+// GetWebhook returns a single webhook subscription
+func (ws *WebhookService) GetWebhook(webhookID uuid.UUID) (*types.Webhook, error) {
+	query := Query().SetOperation("select").SetTable(lib.TableWebhooks).
+		SetSelect(webhookColumns).
+		AddWhere("id", webhookID).
+		SetLimit(1)
+
+	result, err := database.ExecuteQuery[types.Webhook](query)
+	if err != nil {
+		return nil, err
+	}
+	if result.Single == nil {
+		return nil, lib.ErrWebhookNotFound
+	}
+	return result.Single, nil
+}
+
+// UpdateWebhook applies the set fields of the request to a webhook and returns the updated webhook
+func (ws *WebhookService) UpdateWebhook(actorID, webhookID uuid.UUID, req *types.UpdateWebhookRequest) (*types.Webhook, error) {
+	webhook, err := ws.GetWebhook(webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		webhook.EventTypes = slices.Compact(slices.Sorted(slices.Values(*req.EventTypes)))
+	}
+	if req.Secret != nil {
+		if *req.Secret == "" {
+			return nil, fmt.Errorf("%w: secret cannot be empty", lib.ErrInvalidWebhook)
+		}
+		webhook.Secret = *req.Secret
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	if err := ValidateWebhookSubscription(webhook.URL, webhook.EventTypes); err != nil {
+		return nil, err
+	}
+
+	query := Query().SetRawSQL(`UPDATE webhooks SET url = ?, event_types = ?, secret = ?, active = ?, updated_at = NOW()
+		WHERE id = ? RETURNING id, url, event_types, secret, active, created_by, created_at, updated_at`,
+		webhook.URL, pg.Array(webhook.EventTypes), webhook.Secret, webhook.Active, webhookID)
+	result, err := database.ExecuteQuery[types.Webhook](query)
+	if err != nil {
+		ws.Logger.Error("Failed to update webhook", "webhook_id", webhookID.String(), "error", err)
+		return nil, err
+	}
+	if result.Single == nil {
+		return nil, lib.ErrWebhookNotFound
+	}
+
+	ws.Logger.AuditInfo("Webhook updated",
+		"webhook_id", webhookID.String(),
+		"actor_id", actorID.String(),
+		"secret_rotated", req.Secret != nil)
+
+	return result.Single, nil
+}
+
+// DeleteWebhook removes a webhook subscription. Deliveries that are already queued are still attempted.
+func (ws *WebhookService) DeleteWebhook(actorID, webhookID uuid.UUID) error {
+	query := Query().SetOperation("delete").SetTable(lib.TableWebhooks).AddWhere("id", webhookID)
+
+	result, err := database.ExecuteQuery[types.Webhook](query)
+	if err != nil {
+		return err
+	}
+	if result.Count == 0 {
+		return lib.ErrWebhookNotFound
+	}
+
+	ws.Logger.AuditInfo("Webhook deleted", "webhook_id", webhookID.String(), "actor_id", actorID.String())
+	return nil
+}
+
+// GetWebhookSubscribers returns the active webhooks subscribed to an event type
+func GetWebhookSubscribers(eventType string) ([]types.Webhook, error) {
+	query := Query().SetOperation("select").SetTable(lib.TableWebhooks).
+		SetSelect(webhookColumns).
+		SetWhereRaw("active AND ? = ANY(event_types)", eventType)
+
+	result, err := database.ExecuteQuery[types.Webhook](query)
+	if err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+var _ WebhookServiceInterface = (*WebhookService)(nil)
+
+type WebhookServiceInterface interface {
+	CreateWebhook(actorID uuid.UUID, req *types.CreateWebhookRequest) (*types.CreatedWebhook, error)
+	ListWebhooks() ([]types.Webhook, error)
+	GetWebhook(webhookID uuid.UUID) (*types.Webhook, error)
+	UpdateWebhook(actorID, webhookID uuid.UUID, req *types.UpdateWebhookRequest) (*types.Webhook, error)
+	DeleteWebhook(actorID, webhookID uuid.UUID) error
+}
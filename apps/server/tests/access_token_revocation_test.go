@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api"
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+func TestLogoutRevokesAccessTokenImmediately(t *testing.T) {
+	loadTestConfig(t)
+
+	if err := services.NewCacheService().Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	as := services.NewAuthService()
+	user := &types.User{Id: uuid.New(), Username: "revocation-test", Email: "revocation-test@example.com", Role: lib.RoleStudent}
+	accessToken, err := as.GenerateAccessToken(user)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+
+	// The protected route is registered before the real routes, which end in a catch-all
+	app := fiber.New()
+	app.Get("/protected", middleware.NewMiddleware().AuthMiddleware(), func(c fiber.Ctx) error {
+		return c.SendStatus(http.StatusNoContent)
+	})
+	api.SetupRoutes(app, config.SetupLogger())
+
+	request := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{Name: lib.AccessTokenCookieName, Value: accessToken})
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := request(http.MethodGet, "/protected"); status != http.StatusNoContent {
+		t.Fatalf("Expected the access token to be accepted before logout, got status %d", status)
+	}
+
+	if status := request(http.MethodPost, "/auth/logout"); status != http.StatusOK {
+		t.Fatalf("Expected logout to succeed, got status %d", status)
+	}
+
+	if status := request(http.MethodGet, "/protected"); status != http.StatusUnauthorized {
+		t.Errorf("Expected the access token to be rejected right after logout, got status %d", status)
+	}
+	if _, err := as.GetUserFromToken(context.Background(), accessToken); !errors.Is(err, lib.ErrTokenRevoked) {
+		t.Errorf("Expected ErrTokenRevoked from GetUserFromToken, got %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// memoryAPIKeyStore mimics the api_keys table, storing only key hashes
+type memoryAPIKeyStore struct {
+	mu      sync.Mutex
+	keys    map[string]*types.APIKey // keyed by hash
+	users   map[uuid.UUID]*types.User
+	revoked map[uuid.UUID]bool
+}
+
+func newMemoryAPIKeyStore() *memoryAPIKeyStore {
+	return &memoryAPIKeyStore{
+		keys:    make(map[string]*types.APIKey),
+		users:   make(map[uuid.UUID]*types.User),
+		revoked: make(map[uuid.UUID]bool),
+	}
+}
+
+func (s *memoryAPIKeyStore) create(t *testing.T, user *types.User) (string, uuid.UUID) {
+	t.Helper()
+
+	key, hash, err := services.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("Failed to generate API key: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := uuid.New()
+	s.keys[hash] = &types.APIKey{Id: id, UserId: user.Id, KeyHash: hash}
+	s.users[user.Id] = user
+	return key, id
+}
+
+func (s *memoryAPIKeyStore) revoke(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[id] = true
+}
+
+func (s *memoryAPIKeyStore) AuthenticateAPIKey(_ context.Context, key string) (*types.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.keys[services.HashAPIKey(key)]
+	if !ok || s.revoked[stored.Id] {
+		return nil, lib.ErrInvalidAPIKey
+	}
+	return s.users[stored.UserId], nil
+}
+
+func newAPIKeyTestApp(store *memoryAPIKeyStore) *fiber.App {
+	app := fiber.New()
+	app.Get("/internal", middleware.NewAPIKeyAuth(store), func(c fiber.Ctx) error {
+		claims, err := lib.GetValidatedClaims(c)
+		if err != nil {
+			return err
+		}
+		return c.SendString(claims.Sub.String() + ":" + claims.Role)
+	})
+	return app
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, hash, err := services.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(key, services.APIKeyPrefix) {
+		t.Errorf("Expected key to start with %q, got %q", services.APIKeyPrefix, key)
+	}
+	if hash == key || strings.Contains(hash, key) {
+		t.Error("Expected the stored hash not to contain the key")
+	}
+	if hash != services.HashAPIKey(key) {
+		t.Error("Expected hash to match HashAPIKey of the key")
+	}
+
+	other, _, err := services.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if other == key {
+		t.Error("Expected generated keys to be unique")
+	}
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	loadTestConfig(t)
+
+	store := newMemoryAPIKeyStore()
+	user := &types.User{Id: uuid.New(), Email: "service@example.com", Role: lib.RoleTeacher}
+	key, keyID := store.create(t, user)
+	revokedKey, revokedID := store.create(t, user)
+	store.revoke(revokedID)
+
+	app := newAPIKeyTestApp(store)
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+	}{
+		{"valid key", "Bearer " + key, fiber.StatusOK},
+		{"case-insensitive scheme", "bearer " + key, fiber.StatusOK},
+		{"missing header", "", fiber.StatusUnauthorized},
+		{"wrong scheme", "Basic " + key, fiber.StatusUnauthorized},
+		{"empty bearer", "Bearer ", fiber.StatusUnauthorized},
+		{"unknown key", "Bearer " + services.APIKeyPrefix + "unknown", fiber.StatusUnauthorized},
+		{"revoked key", "Bearer " + revokedKey, fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/internal", nil)
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	// Revoking the active key blocks further requests with it
+	store.revoke(keyID)
+	req := httptest.NewRequest("GET", "/internal", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+key)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("Expected status 401 after revocation, got %d", resp.StatusCode)
+	}
+}
+
+// TestAPIKeyThroughAuthMiddleware authenticates with real keys against a database
+func TestAPIKeyThroughAuthMiddleware(t *testing.T) {
+	requireDatabase(t)
+
+	userID := createTestUser(t, lib.RoleTeacher)
+	authService := services.NewAuthService()
+	ctx := context.Background()
+
+	app := fiber.New()
+	mw := middleware.NewMiddleware()
+	app.Get("/protected", mw.AuthMiddleware(), func(c fiber.Ctx) error {
+		claims, err := lib.GetValidatedClaims(c)
+		if err != nil {
+			return err
+		}
+		return c.SendString(claims.Sub.String())
+	})
+	app.Get("/admin", mw.AdminMiddleware(), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	request := func(path, key string) int {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+key)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	created, err := authService.CreateAPIKey(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	if status := request("/protected", created.Key); status != fiber.StatusOK {
+		t.Fatalf("Expected the API key to pass the auth middleware, got %d", status)
+	}
+	if status := request("/admin", created.Key); status != fiber.StatusForbidden {
+		t.Errorf("Expected a teacher's API key to be refused by the admin middleware, got %d", status)
+	}
+
+	query := services.Query().SetOperation("select").SetTable(lib.TableAPIKeys).
+		SetSelect([]string{"id", "last_used_at"}).
+		AddWhere("id", created.Id)
+	result, err := database.ExecuteQuery[types.APIKey](query)
+	if err != nil || result.Single == nil {
+		t.Fatalf("Failed to read API key: %v", err)
+	}
+	if result.Single.LastUsedAt == nil {
+		t.Error("Expected last_used_at to be set after authenticating")
+	}
+
+	rotated, err := authService.RotateAPIKey(ctx, userID, created.Id)
+	if err != nil {
+		t.Fatalf("Failed to rotate API key: %v", err)
+	}
+	if status := request("/protected", created.Key); status != fiber.StatusUnauthorized {
+		t.Errorf("Expected the rotated key to be rejected, got %d", status)
+	}
+	if status := request("/protected", rotated.Key); status != fiber.StatusOK {
+		t.Errorf("Expected the replacement key to pass the auth middleware, got %d", status)
+	}
+
+	// A failed rotation leaves no replacement behind
+	if _, err := authService.RotateAPIKey(ctx, userID, created.Id); !errors.Is(err, lib.ErrAPIKeyNotFound) {
+		t.Errorf("Expected ErrAPIKeyNotFound when rotating a revoked key, got %v", err)
+	}
+}
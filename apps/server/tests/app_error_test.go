@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+var errTestQuota = lib.NewAppError(fiber.StatusTooManyRequests, "QUOTA_EXCEEDED", "Upload quota exceeded")
+
+func TestAppErrorMatching(t *testing.T) {
+	cause := errors.New("disk full")
+	err := fmt.Errorf("upload: %w", errTestQuota.Wrap(cause).WithDetails(map[string]any{"limit": 10}))
+
+	if !errors.Is(err, errTestQuota) {
+		t.Error("Expected a wrapped copy to match its category")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the cause to stay reachable through Unwrap")
+	}
+	if errors.Is(err, lib.NewAppError(fiber.StatusTooManyRequests, "RATE_LIMITED", "Slow down")) {
+		t.Error("Expected another code not to match")
+	}
+	if errTestQuota.Err != nil || errTestQuota.Details != nil {
+		t.Error("Expected Wrap and WithDetails to leave the declared error untouched")
+	}
+	if got := errTestQuota.Wrap(cause).Error(); got != "Upload quota exceeded: disk full" {
+		t.Errorf("Unexpected error text %q", got)
+	}
+}
+
+func TestHandleServiceErrorRendersAppError(t *testing.T) {
+	loadTestConfig(t)
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+		wantDetails bool
+	}{
+		{"app error", errTestQuota, http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Upload quota exceeded", false},
+		{"wrapped with details", fmt.Errorf("upload: %w", errTestQuota.WithDetails(map[string]any{"limit": 10})),
+			http.StatusTooManyRequests, "QUOTA_EXCEEDED", "Upload quota exceeded", true},
+		{"app error wrapping a sentinel wins", lib.NewAppError(http.StatusGone, "DEADLINE_CLOSED", "Deadline is closed").Wrap(lib.ErrDeadlineNotFound),
+			http.StatusGone, "DEADLINE_CLOSED", "Deadline is closed", false},
+		{"sentinel fallback", lib.ErrDeadlineNotFound, http.StatusNotFound, "NOT_FOUND", "Deadline not found", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/fail", func(c fiber.Ctx) error {
+				return lib.HandleServiceError(c, tt.err, "test failure")
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/fail", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			var body types.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Error == nil || body.Error.Code != tt.wantCode || body.Error.Message != tt.wantMessage {
+				t.Fatalf("Expected %s %q, got %+v", tt.wantCode, tt.wantMessage, body.Error)
+			}
+			if tt.wantDetails && body.Error.Details["limit"] != float64(10) {
+				t.Errorf("Expected the details in the response, got %v", body.Error.Details)
+			}
+		})
+	}
+}
+
+func TestServiceValidationErrors(t *testing.T) {
+	loadTestConfig(t)
+
+	now := time.Now()
+	ds := &services.DeadlineService{}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantField string
+	}{
+		{"deadline without subject", ds.CreateDeadline(context.Background(), &types.CreateDeadlineRequest{}), "subject_id"},
+		{"health history without service", services.ValidateHealthHistoryRange("", now.Add(-time.Hour), now), "service"},
+		{"reversed health history range", services.ValidateHealthHistoryRange("auth", now, now.Add(-time.Hour)), "from"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, lib.ErrValidationFailed) {
+				t.Fatalf("Expected a validation error, got %v", tt.err)
+			}
+
+			app := fiber.New()
+			app.Get("/fail", func(c fiber.Ctx) error {
+				return lib.HandleServiceError(c, tt.err, "test failure")
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/fail", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusUnprocessableEntity {
+				t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, resp.StatusCode)
+			}
+
+			var body struct {
+				Error struct {
+					Code    string `json:"code"`
+					Details struct {
+						ValidationErrors []types.ValidationError `json:"validation_errors"`
+					} `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Error.Code != "VALIDATION_ERROR" {
+				t.Errorf("Expected VALIDATION_ERROR, got %q", body.Error.Code)
+			}
+			if errs := body.Error.Details.ValidationErrors; len(errs) != 1 || errs[0].Field != tt.wantField {
+				t.Errorf("Expected one error for %s, got %+v", tt.wantField, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/MonkyMars/PWS/config"
+)
+
+func TestAuditAttrsPairing(t *testing.T) {
+	capture := &auditCapture{}
+	config.SetAuditLogFunc(capture.add)
+
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	tests := []struct {
+		name     string
+		attrs    []any
+		expected map[string]any
+	}{
+		{
+			name:     "even pairs",
+			attrs:    []any{"user", "alice", "count", 3},
+			expected: map[string]any{"user": "alice", "count": 3},
+		},
+		{
+			name:     "dangling key",
+			attrs:    []any{"user", "alice", "deadline_id"},
+			expected: map[string]any{"user": "alice", "deadline_id": "!MISSING"},
+		},
+		{
+			name:     "slog attributes",
+			attrs:    []any{slog.String("path", "/api"), "status", 500, slog.Int("retries", 2)},
+			expected: map[string]any{"path": "/api", "status": 500, "retries": int64(2)},
+		},
+		{
+			name:     "non-string key does not shift later pairs",
+			attrs:    []any{42, "user", "alice", "ip", "10.0.0.1"},
+			expected: map[string]any{"!BADKEY": 42, "user": "alice", "ip": "10.0.0.1"},
+		},
+		{
+			name:     "multiple bad keys are all kept",
+			attrs:    []any{1, 2, "", "empty-key-value"},
+			expected: map[string]any{"!BADKEY": 1, "!BADKEY1": 2, "!BADKEY2": "empty-key-value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for level, log := range map[string]func(string, ...any){
+				"ERROR": logger.AuditError,
+				"WARN":  logger.AuditWarn,
+				"INFO":  logger.AuditInfo,
+			} {
+				log("Audit attrs pairing test", tt.attrs...)
+
+				logs := capture.take("Audit attrs pairing test")
+				if len(logs) != 1 {
+					t.Fatalf("%s: expected 1 audit log, got %d", level, len(logs))
+				}
+				if !reflect.DeepEqual(logs[0].Attrs, tt.expected) {
+					t.Errorf("%s: expected attrs %v, got %v", level, tt.expected, logs[0].Attrs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+)
+
+func TestAuditOverflowPolicyValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		timeout  time.Duration
+		wantErr  bool
+		expected map[string]types.AuditOverflowPolicy
+	}{
+		{"empty keeps dropping", "", 0, false, map[string]types.AuditOverflowPolicy{"ERROR": types.AuditOverflowDrop}},
+		{"block errors", "ERROR:block", 100 * time.Millisecond, false, map[string]types.AuditOverflowPolicy{"ERROR": types.AuditOverflowBlock, "WARN": types.AuditOverflowDrop}},
+		{"default for all levels", "block, warn:drop", time.Second, false, map[string]types.AuditOverflowPolicy{"INFO": types.AuditOverflowBlock, "WARN": types.AuditOverflowDrop}},
+		{"block without timeout", "ERROR:block", 0, true, nil},
+		{"unknown policy", "ERROR:wait", time.Second, true, nil},
+		{"unknown level", "DEBUG:block", time.Second, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := config.LoadDomainConfigs()
+			domains.Audit.OverflowPolicy = tt.policy
+			domains.Audit.OverflowTimeout = tt.timeout
+
+			err := domains.Audit.Validate()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected validation error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected validation error: %v", err)
+			}
+
+			audit := domains.ToLegacyConfig().Audit
+			for level, want := range tt.expected {
+				if got := audit.OverflowPolicyFor(level); got != want {
+					t.Errorf("Expected %s policy for %s, got %s", want, level, got)
+				}
+			}
+		})
+	}
+}
@@ -229,3 +229,48 @@ func TestSchedulerMultipleStarts(t *testing.T) {
 	// Stop scheduler
 	workers.StopAuditCleanupScheduler()
 }
+
+func TestAuditCleanupScheduleValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		offset   time.Duration
+		timezone string
+		wantErr  bool
+	}{
+		{"default midnight", 24 * time.Hour, 0, "Local", false},
+		{"every six hours with offset", 6 * time.Hour, 90 * time.Minute, "UTC", false},
+		{"named timezone", 24 * time.Hour, 3 * time.Hour, "Europe/Amsterdam", false},
+		{"zero interval", 0, 0, "Local", true},
+		{"longer than a day", 48 * time.Hour, 0, "Local", true},
+		{"does not divide a day", 7 * time.Hour, 0, "Local", true},
+		{"negative offset", 24 * time.Hour, -time.Hour, "Local", true},
+		{"offset not less than interval", 6 * time.Hour, 6 * time.Hour, "Local", true},
+		{"unknown timezone", 24 * time.Hour, 0, "Mars/Olympus_Mons", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ac := &config.AuditConfig{
+				BatchSize:       50,
+				ChannelSize:     1000,
+				Enabled:         true,
+				FlushTime:       30 * time.Second,
+				RetentionDays:   90,
+				DLQSize:         100,
+				DLQInterval:     time.Minute,
+				CleanupInterval: tt.interval,
+				CleanupOffset:   tt.offset,
+				CleanupTimezone: tt.timezone,
+			}
+
+			err := ac.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
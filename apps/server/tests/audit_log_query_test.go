@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+)
+
+func TestBuildAuditLogConditions(t *testing.T) {
+	from := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		filter       types.AuditLogFilter
+		expectedSQL  string
+		expectedArgs []any
+	}{
+		{
+			name:         "no filter",
+			filter:       types.AuditLogFilter{},
+			expectedSQL:  "",
+			expectedArgs: nil,
+		},
+		{
+			name:         "level and source",
+			filter:       types.AuditLogFilter{Level: "ERROR", Source: "auth.go:42"},
+			expectedSQL:  "audit_logs.level = ? AND audit_logs.source = ?",
+			expectedArgs: []any{"ERROR", "auth.go:42"},
+		},
+		{
+			name:         "time range",
+			filter:       types.AuditLogFilter{From: from, To: to},
+			expectedSQL:  "audit_logs.timestamp >= ? AND audit_logs.timestamp <= ?",
+			expectedArgs: []any{from, to},
+		},
+		{
+			name:         "message search escapes wildcards",
+			filter:       types.AuditLogFilter{Search: `50%_off\`},
+			expectedSQL:  `audit_logs.message ILIKE ? ESCAPE '\'`,
+			expectedArgs: []any{`%50\%\_off\\%`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := services.BuildAuditLogConditions(tt.filter)
+			if sql != tt.expectedSQL {
+				t.Errorf("Expected SQL %q, got %q", tt.expectedSQL, sql)
+			}
+			if !reflect.DeepEqual(args, tt.expectedArgs) {
+				t.Errorf("Expected args %v, got %v", tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestAuditLogFilterNormalizeAndValidate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		filter        types.AuditLogFilter
+		expectedLevel string
+		expectedPage  int
+		expectedLimit int
+		wantErr       bool
+	}{
+		{"defaults", types.AuditLogFilter{}, "", 1, types.DefaultAuditLogLimit, false},
+		{"lowercase level", types.AuditLogFilter{Level: " warn "}, "WARN", 1, types.DefaultAuditLogLimit, false},
+		{"limit capped", types.AuditLogFilter{Page: 3, Limit: 10000}, "", 3, types.MaxAuditLogLimit, false},
+		{"unknown level", types.AuditLogFilter{Level: "fatal"}, "FATAL", 1, types.DefaultAuditLogLimit, true},
+		{"reversed range", types.AuditLogFilter{From: now, To: now.Add(-time.Hour)}, "", 1, types.DefaultAuditLogLimit, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			filter.Normalize()
+
+			if filter.Level != tt.expectedLevel {
+				t.Errorf("Expected level %q, got %q", tt.expectedLevel, filter.Level)
+			}
+			if filter.Page != tt.expectedPage || filter.Limit != tt.expectedLimit {
+				t.Errorf("Expected page %d and limit %d, got %d and %d", tt.expectedPage, tt.expectedLimit, filter.Page, filter.Limit)
+			}
+
+			err := filter.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
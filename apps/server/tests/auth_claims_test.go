@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const claimsTestSecret = "claims-test-secret"
+
+// signClaims signs the claims with the test secret, replacing the defaults with the given overrides
+func signClaims(t *testing.T, overrides jwt.MapClaims) string {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"sub":   uuid.New().String(),
+		"email": "student@example.com",
+		"role":  "student",
+		"iat":   int64(1700000000),
+		"exp":   int64(4102444800), // 2100-01-01
+		"jti":   uuid.New().String(),
+	}
+	for key, value := range overrides {
+		claims[key] = value
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(claimsTestSecret))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestParseClaimsTimestampRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		iat  int64
+		exp  int64
+	}{
+		{"unix epoch", 0, 4102444800},
+		{"typical timestamps", 1700000000, 4102444800},
+		{"largest int32", math.MaxInt32, math.MaxInt32 + 1},
+		{"year 9999", 1700000000, 253402300799},
+		{"beyond float64 precision", 9007199254740993, 9007199254740995},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signClaims(t, jwt.MapClaims{"iat": tt.iat, "exp": tt.exp})
+
+			claims, err := services.ParseClaims(token, claimsTestSecret)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := claims.Iat.Unix(); got != tt.iat {
+				t.Errorf("Expected iat %d, got %d", tt.iat, got)
+			}
+			if got := claims.Exp.Unix(); got != tt.exp {
+				t.Errorf("Expected exp %d, got %d", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestParseClaimsRejectsInvalidTimestamps(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides jwt.MapClaims
+	}{
+		{"fractional iat", jwt.MapClaims{"iat": 1700000000.5}},
+		{"string iat", jwt.MapClaims{"iat": "1700000000"}},
+		{"missing iat", jwt.MapClaims{"iat": nil}},
+		{"fractional exp", jwt.MapClaims{"exp": 4102444800.25}},
+		{"expired", jwt.MapClaims{"exp": int64(1000000000)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signClaims(t, tt.overrides)
+
+			if _, err := services.ParseClaims(token, claimsTestSecret); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseClaimsRejectsWrongSecret(t *testing.T) {
+	token := signClaims(t, nil)
+
+	if _, err := services.ParseClaims(token, "another-secret"); err == nil {
+		t.Error("Expected an error for a token signed with another secret")
+	}
+}
+
+func TestParseClaimsLegacyTokenDefaults(t *testing.T) {
+	jti := uuid.New()
+	token := signClaims(t, jwt.MapClaims{"jti": jti.String()})
+
+	claims, err := services.ParseClaims(token, claimsTestSecret)
+	if err != nil {
+		t.Fatalf("Expected a legacy token to parse, got: %v", err)
+	}
+
+	if claims.Ver != types.ClaimsVersionLegacy {
+		t.Errorf("Expected legacy version %d, got %d", types.ClaimsVersionLegacy, claims.Ver)
+	}
+	if !claims.Nbf.Equal(claims.Iat) {
+		t.Errorf("Expected nbf to default to iat %v, got %v", claims.Iat, claims.Nbf)
+	}
+	if claims.Epoch != 0 {
+		t.Errorf("Expected epoch 0, got %d", claims.Epoch)
+	}
+	if claims.Family != jti {
+		t.Errorf("Expected family to default to jti %s, got %s", jti, claims.Family)
+	}
+	if claims.TwoFactorLevel != types.TwoFactorLevelNone {
+		t.Errorf("Expected no 2fa level, got %d", claims.TwoFactorLevel)
+	}
+}
+
+func TestNewTokenCarriesVersionedClaims(t *testing.T) {
+	user := &types.User{Id: uuid.New(), Username: "student", Role: "student"}
+	issued := services.NewClaims(user, time.Now().Add(time.Hour))
+	issued.Epoch = 3
+	issued.TwoFactorLevel = 1
+
+	token, err := services.SignClaims(issued, claimsTestSecret)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	claims, err := services.ParseClaims(token, claimsTestSecret)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if claims.Ver != types.ClaimsVersionCurrent {
+		t.Errorf("Expected version %d, got %d", types.ClaimsVersionCurrent, claims.Ver)
+	}
+	if claims.Nbf.Unix() != issued.Nbf.Unix() {
+		t.Errorf("Expected nbf %d, got %d", issued.Nbf.Unix(), claims.Nbf.Unix())
+	}
+	if claims.Epoch != 3 || claims.TwoFactorLevel != 1 {
+		t.Errorf("Expected epoch 3 and 2fa level 1, got %d and %d", claims.Epoch, claims.TwoFactorLevel)
+	}
+	if claims.Family != issued.Family || claims.Family == claims.Jti {
+		t.Errorf("Expected family %s distinct from jti, got %s", issued.Family, claims.Family)
+	}
+}
+
+func TestSessionClaimRoundTrip(t *testing.T) {
+	user := &types.User{Id: uuid.New(), Username: "student", Role: "student"}
+
+	withoutSession := services.NewClaims(user, time.Now().Add(time.Hour))
+	withSession := services.NewClaims(user, time.Now().Add(time.Hour))
+	withSession.Sid = uuid.New()
+
+	for _, issued := range []*types.AuthClaims{withoutSession, withSession} {
+		token, err := services.SignClaims(issued, claimsTestSecret)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+
+		claims, err := services.ParseClaims(token, claimsTestSecret)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if claims.Sid != issued.Sid {
+			t.Errorf("Expected sid %s, got %s", issued.Sid, claims.Sid)
+		}
+	}
+}
+
+func TestParseClaimsRejectsInvalidVersionedClaims(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides jwt.MapClaims
+	}{
+		{"future version", jwt.MapClaims{"ver": types.ClaimsVersionCurrent + 1}},
+		{"string version", jwt.MapClaims{"ver": "1"}},
+		{"not yet valid", jwt.MapClaims{"ver": 1, "nbf": int64(4000000000)}},
+		{"fractional epoch", jwt.MapClaims{"ver": 1, "epoch": 1.5}},
+		{"invalid family", jwt.MapClaims{"ver": 1, "family": "not-a-uuid"}},
+		{"invalid sid", jwt.MapClaims{"ver": 1, "sid": "not-a-uuid"}},
+		{"numeric sid", jwt.MapClaims{"ver": 1, "sid": 1}},
+		{"negative 2fa level", jwt.MapClaims{"ver": 1, "2fa": -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signClaims(t, tt.overrides)
+
+			if _, err := services.ParseClaims(token, claimsTestSecret); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
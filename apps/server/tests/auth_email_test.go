@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+// TestAuthEmailCaseInsensitive registers with a mixed case email against a real database
+// and checks that login and duplicate detection ignore case
+func TestAuthEmailCaseInsensitive(t *testing.T) {
+	requireDatabase(t)
+
+	local := "Case-" + uuid.NewString()[:8]
+	email := local + "@Bar.com"
+	normalized := strings.ToLower(email)
+	t.Cleanup(func() {
+		query := services.Query().SetOperation("delete").SetTable(lib.TableUsers).
+			SetWhereRaw("lower(public.users.email) = ?", normalized)
+		if _, err := database.ExecuteQuery[any](query); err != nil {
+			t.Logf("Failed to clean up user: %v", err)
+		}
+	})
+
+	authService := services.NewAuthService()
+	const password = "Sup3r-secret!"
+
+	user, err := authService.Register(context.Background(), &types.RegisterRequest{
+		Username:        "case-test",
+		Email:           email,
+		Password:        password,
+		ConfirmPassword: password,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if user.Email != normalized {
+		t.Errorf("Expected the email to be stored as %q, got %q", normalized, user.Email)
+	}
+
+	loggedIn, err := authService.Login(context.Background(), &types.AuthRequest{Email: normalized, Password: password})
+	if err != nil {
+		t.Fatalf("Expected login with a lowercase email to work, got %v", err)
+	}
+	if loggedIn.Id != user.Id {
+		t.Errorf("Expected to log in as %s, got %s", user.Id, loggedIn.Id)
+	}
+
+	_, err = authService.Register(context.Background(), &types.RegisterRequest{
+		Username:        "case-test-2",
+		Email:           strings.ToUpper(local) + "@BAR.COM",
+		Password:        password,
+		ConfirmPassword: password,
+	})
+	if !errors.Is(err, lib.ErrUserAlreadyExists) {
+		t.Errorf("Expected a second registration with different casing to conflict, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestGetAccessTokenPrecedence(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		return c.SendString(lib.GetAccessToken(c))
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		cookie   string
+		expected string
+	}{
+		{name: "cookie only", cookie: "cookie-token", expected: "cookie-token"},
+		{name: "header only", header: "Bearer header-token", expected: "header-token"},
+		{name: "header wins over cookie", header: "Bearer header-token", cookie: "cookie-token", expected: "header-token"},
+		{name: "scheme is case-insensitive", header: "bearer header-token", expected: "header-token"},
+		{name: "other scheme falls back to cookie", header: "Basic dXNlcjpwYXNz", cookie: "cookie-token", expected: "cookie-token"},
+		{name: "empty bearer falls back to cookie", header: "Bearer ", cookie: "cookie-token", expected: "cookie-token"},
+		{name: "neither", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: lib.AccessTokenCookieName, Value: tt.cookie})
+			}
+
+			if got := readBody(t, app, req); got != tt.expected {
+				t.Errorf("Expected token %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetRefreshTokenPrecedence(t *testing.T) {
+	app := fiber.New()
+	app.Post("/", func(c fiber.Ctx) error {
+		return c.SendString(lib.GetRefreshToken(c))
+	})
+
+	tests := []struct {
+		name        string
+		header      string
+		body        string
+		contentType string
+		cookie      string
+		expected    string
+	}{
+		{name: "cookie only", cookie: "cookie-token", expected: "cookie-token"},
+		{name: "body only", body: `{"refresh_token":"body-token"}`, contentType: fiber.MIMEApplicationJSON, expected: "body-token"},
+		{name: "header only", header: "header-token", expected: "header-token"},
+		{
+			name:        "header wins over body and cookie",
+			header:      "header-token",
+			body:        `{"refresh_token":"body-token"}`,
+			contentType: fiber.MIMEApplicationJSON,
+			cookie:      "cookie-token",
+			expected:    "header-token",
+		},
+		{
+			name:        "body wins over cookie",
+			body:        `{"refresh_token":"body-token"}`,
+			contentType: fiber.MIMEApplicationJSON + "; charset=utf-8",
+			cookie:      "cookie-token",
+			expected:    "body-token",
+		},
+		{name: "non-JSON body is ignored", body: "refresh_token=body-token", contentType: fiber.MIMEApplicationForm, cookie: "cookie-token", expected: "cookie-token"},
+		{name: "malformed body falls back to cookie", body: `{"refresh_token":`, contentType: fiber.MIMEApplicationJSON, cookie: "cookie-token", expected: "cookie-token"},
+		{name: "empty body token falls back to cookie", body: `{"refresh_token":""}`, contentType: fiber.MIMEApplicationJSON, cookie: "cookie-token", expected: "cookie-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			}
+			if tt.header != "" {
+				req.Header.Set(lib.RefreshTokenHeader, tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: lib.RefreshTokenCookieName, Value: tt.cookie})
+			}
+
+			if got := readBody(t, app, req); got != tt.expected {
+				t.Errorf("Expected token %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCSRFSkipsBearerRequests(t *testing.T) {
+	loadTestConfig(t)
+
+	cookieService := services.NewCookieServiceWithConfig(types.CookieConfig{SameSite: "Lax", HTTPOnly: true, CSRFEnabled: true})
+
+	app := fiber.New()
+	app.Use(middleware.NewCSRFProtection(cookieService, nil))
+	app.Post("/subjects", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/subjects", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer header-token")
+	req.AddCookie(&http.Cookie{Name: lib.AccessTokenCookieName, Value: "cookie-token"})
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("Expected status %d for a bearer request, got %d", fiber.StatusOK, resp.StatusCode)
+	}
+}
+
+func readBody(t *testing.T, app *fiber.App, req *http.Request) string {
+	t.Helper()
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return string(body)
+}
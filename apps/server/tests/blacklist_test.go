@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/google/uuid"
+)
+
+func TestParseBlacklistValue(t *testing.T) {
+	tests := []struct {
+		name                string
+		value               string
+		expectedBlacklisted bool
+		expectedErr         error
+	}{
+		{"missing key", "", false, nil},
+		{"expected marker", services.BlacklistMarker, true, nil},
+		{"unexpected value", "false", true, lib.ErrUnexpectedBlacklistValue},
+		{"corrupted value", "\x00garbage", true, lib.ErrUnexpectedBlacklistValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blacklisted, err := services.ParseBlacklistValue(tt.value)
+			if blacklisted != tt.expectedBlacklisted {
+				t.Errorf("Expected blacklisted=%v, got %v", tt.expectedBlacklisted, blacklisted)
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestBlacklistTokenRoundTrip(t *testing.T) {
+	loadTestConfig(t)
+
+	cs := services.NewCacheService()
+	if err := cs.Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	jti := uuid.New()
+	t.Cleanup(func() { _ = cs.Delete("blacklist:" + jti.String()) })
+
+	if blacklisted, err := cs.IsTokenBlacklisted(jti); err != nil || blacklisted {
+		t.Fatalf("Expected a fresh jti not to be blacklisted, got %v (err %v)", blacklisted, err)
+	}
+	if err := cs.BlacklistToken(jti, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("BlacklistToken failed: %v", err)
+	}
+	if blacklisted, err := cs.IsTokenBlacklisted(jti); err != nil || !blacklisted {
+		t.Errorf("Expected the jti to be blacklisted, got %v (err %v)", blacklisted, err)
+	}
+}
+
+func TestBlacklistTokens(t *testing.T) {
+	loadTestConfig(t)
+
+	cs := services.NewCacheService()
+	if err := cs.Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	if err := cs.BlacklistTokens(nil); err != nil {
+		t.Fatalf("Expected no error for an empty batch, got %v", err)
+	}
+
+	entries := []services.BlacklistEntry{
+		{JTI: uuid.New(), Exp: time.Now().Add(time.Minute)},
+		{JTI: uuid.New(), Exp: time.Now().Add(time.Hour)},
+		// Already expired, kept for BlacklistCacheTTL
+		{JTI: uuid.New(), Exp: time.Now().Add(-time.Minute)},
+	}
+	t.Cleanup(func() {
+		for _, entry := range entries {
+			_ = cs.Delete("blacklist:" + entry.JTI.String())
+		}
+	})
+
+	if err := cs.BlacklistTokens(entries); err != nil {
+		t.Fatalf("BlacklistTokens failed: %v", err)
+	}
+
+	for _, entry := range entries {
+		if blacklisted, err := cs.IsTokenBlacklisted(entry.JTI); err != nil || !blacklisted {
+			t.Errorf("Expected %s to be blacklisted, got %v (err %v)", entry.JTI, blacklisted, err)
+		}
+	}
+
+	// Every entry expires with its own token
+	short, err := cs.TTL("blacklist:" + entries[0].JTI.String())
+	if err != nil {
+		t.Fatalf("TTL failed: %v", err)
+	}
+	long, err := cs.TTL("blacklist:" + entries[1].JTI.String())
+	if err != nil {
+		t.Fatalf("TTL failed: %v", err)
+	}
+	if short <= 0 || short > time.Minute || long <= time.Minute || long > time.Hour {
+		t.Errorf("Expected TTLs of about a minute and an hour, got %v and %v", short, long)
+	}
+}
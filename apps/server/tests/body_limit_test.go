@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestBodyLimit(t *testing.T) {
+	app := fiber.New()
+	app.Post("/small", middleware.NewBodyLimit(16), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		chunked        bool
+		expectedStatus int
+	}{
+		{name: "empty body", body: "", expectedStatus: fiber.StatusOK},
+		{name: "body at the limit", body: strings.Repeat("a", 16), expectedStatus: fiber.StatusOK},
+		{name: "oversized body", body: strings.Repeat("a", 17), expectedStatus: fiber.StatusRequestEntityTooLarge},
+		{name: "oversized chunked body", body: strings.Repeat("a", 1024), chunked: true, expectedStatus: fiber.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/small", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus == fiber.StatusRequestEntityTooLarge {
+				assertErrorCode(t, resp, fiber.StatusRequestEntityTooLarge, response.ErrCodePayloadTooLarge)
+			}
+		})
+	}
+}
+
+func TestMaxRequestBodySize(t *testing.T) {
+	cfg := loadTestConfig(t)
+
+	fiberConfig := config.SetupFiber()
+	if fiberConfig.BodyLimit != cfg.Server.MaxRequestBodySize {
+		t.Errorf("Expected body limit %d, got %d", cfg.Server.MaxRequestBodySize, fiberConfig.BodyLimit)
+	}
+
+	// The config is loaded once per test binary, so the limit is lowered here instead of through the environment
+	fiberConfig.BodyLimit = 64
+	app := fiber.New(fiberConfig)
+	app.Post("/submissions", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	// The server rejects oversized bodies while reading them, which app.Test reports as an error
+	// instead of a response, so this runs against a real listener
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go app.Listener(ln, fiber.ListenConfig{DisableStartupMessage: true})
+	t.Cleanup(func() { app.Shutdown() })
+
+	url := "http://" + ln.Addr().String() + "/submissions"
+
+	resp, err := http.Post(url, fiber.MIMETextPlain, strings.NewReader(strings.Repeat("a", 64)))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Errorf("Expected status %d for a body at the limit, got %d", fiber.StatusCreated, resp.StatusCode)
+	}
+
+	resp, err = http.Post(url, fiber.MIMETextPlain, strings.NewReader(strings.Repeat("a", 4096)))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status %d for an oversized body, got %d", fiber.StatusRequestEntityTooLarge, resp.StatusCode)
+	}
+	assertErrorCode(t, resp, fiber.StatusRequestEntityTooLarge, response.ErrCodePayloadTooLarge)
+}
+
+func TestMaxRequestBodySizeValidation(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("MAX_REQUEST_BODY_SIZE", "")
+
+		server := config.LoadDomainConfigs().Server
+		if err := server.Validate(); err != nil || server.MaxRequestBodySize != 1<<20 {
+			t.Errorf("Expected a valid 1 MiB default, got %d: %v", server.MaxRequestBodySize, err)
+		}
+	})
+
+	for _, value := range []string{"0", "-1"} {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("MAX_REQUEST_BODY_SIZE", value)
+
+			if err := config.LoadDomainConfigs().Server.Validate(); err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+		})
+	}
+}
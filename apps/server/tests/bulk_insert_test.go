@@ -1,11 +1,18 @@
 package tests
 
 import (
+	"errors"
+	"fmt"
+	"slices"
 	"testing"
 
+	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/database"
 	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
 	"github.com/MonkyMars/PWS/types"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
 )
 
 func TestBulkInsertSQL(t *testing.T) {
@@ -71,6 +78,17 @@ func TestBulkInsertSQL(t *testing.T) {
 		t.Errorf("Expected SQL with ON CONFLICT: %s, got: %s", expectedSQL, sql)
 	}
 
+	// Test with ON CONFLICT and RETURNING, which must follow the conflict clause
+	sql, _, err = database.BuildBulkInsertSQL("users", entries, columns, []string{"id"}, "(email) DO NOTHING")
+	if err != nil {
+		t.Fatalf("buildBulkInsertSQL with ON CONFLICT and RETURNING failed: %v", err)
+	}
+
+	expectedSQL = "INSERT INTO users (id, username, email) VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT (email) DO NOTHING RETURNING id"
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL with ON CONFLICT and RETURNING: %s, got: %s", expectedSQL, sql)
+	}
+
 	// Test error cases
 	_, _, err = database.BuildBulkInsertSQL("", entries, columns, nil, "")
 	if err == nil {
@@ -130,6 +148,58 @@ func TestExtractColumnsFromEntries(t *testing.T) {
 			t.Errorf("Unexpected column: %s", col)
 		}
 	}
+
+	if !slices.IsSorted(columns) {
+		t.Errorf("Expected sorted columns, got %v", columns)
+	}
+}
+
+func TestBulkInsertReturning(t *testing.T) {
+	requireDatabase(t)
+
+	type insertedUser struct {
+		ID       uuid.UUID `pg:"id"`
+		Username string    `pg:"username"`
+	}
+
+	prefix := "bulk-returning-" + uuid.NewString()[:8]
+	entries := make([]any, 3)
+	for i := range entries {
+		username := fmt.Sprintf("%s-%d", prefix, i)
+		entries[i] = map[string]any{"username": username, "email": username + "@test.local", "role": lib.RoleStudent}
+	}
+	t.Cleanup(func() {
+		query := services.Query().SetRawSQL("DELETE FROM users WHERE username LIKE ?", prefix+"-%")
+		if _, err := database.ExecuteQuery[any](query); err != nil {
+			t.Logf("Failed to clean up users: %v", err)
+		}
+	})
+
+	query := services.Query().
+		SetOperation("insert").
+		SetTable(lib.TableUsers).
+		SetEntries(entries).
+		SetReturning("id", "username")
+
+	result, err := database.ExecuteQuery[insertedUser](query)
+	if err != nil {
+		t.Fatalf("Failed to bulk insert: %v", err)
+	}
+
+	if len(result.Data) != len(entries) || result.Count != int64(len(entries)) {
+		t.Fatalf("Expected %d returned rows, got %d (count %d)", len(entries), len(result.Data), result.Count)
+	}
+	for i, user := range result.Data {
+		if user.ID == uuid.Nil {
+			t.Errorf("Expected a generated id for row %d", i)
+		}
+		if expected := fmt.Sprintf("%s-%d", prefix, i); user.Username != expected {
+			t.Errorf("Expected username %q for row %d, got %q", expected, i, user.Username)
+		}
+	}
+	if result.Single == nil || result.Single.ID != result.Data[0].ID {
+		t.Error("Expected Single to be the first returned row")
+	}
 }
 
 func TestQueryValidation(t *testing.T) {
@@ -179,3 +249,113 @@ func TestQueryValidation(t *testing.T) {
 		t.Error("Query with neither Data nor Entries should fail validation")
 	}
 }
+
+func TestQueryValidationMaxEntries(t *testing.T) {
+	entries := func(n int) []any {
+		out := make([]any, n)
+		for i := range out {
+			out[i] = map[string]any{"id": i}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name       string
+		entries    int
+		maxEntries int
+		expectErr  bool
+	}{
+		{"at the default cap", types.DefaultMaxEntries, 0, false},
+		{"over the default cap", types.DefaultMaxEntries + 1, 0, true},
+		{"at a custom cap", 5, 5, false},
+		{"over a custom cap", 6, 5, true},
+		{"custom cap above the default", types.DefaultMaxEntries + 1, types.DefaultMaxEntries * 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := types.NewQuery().
+				SetOperation("insert").
+				SetTable(lib.TableUsers).
+				SetEntries(entries(tt.entries)).
+				SetMaxEntries(tt.maxEntries)
+
+			err := query.Validate()
+			if tt.expectErr {
+				if !errors.Is(err, types.ErrTooManyEntries) {
+					t.Errorf("Expected ErrTooManyEntries, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestExecuteQueryRejectsOversizedBatch(t *testing.T) {
+	cfg := loadTestConfig(t)
+
+	query := types.NewQuery().
+		SetOperation("insert").
+		SetTable(lib.TableUsers).
+		SetEntries(make([]any, cfg.Database.MaxInsertEntries+1))
+
+	// Validation fails before a database connection is needed
+	if _, err := database.ExecuteQuery[any](query); !errors.Is(err, types.ErrTooManyEntries) {
+		t.Errorf("Expected ErrTooManyEntries, got %v", err)
+	}
+}
+
+func TestExecuteQueryKeepsCallerMaxEntries(t *testing.T) {
+	loadTestConfig(t)
+
+	query := types.NewQuery().
+		SetOperation("insert").
+		SetTable(lib.TableUsers).
+		SetEntries([]any{map[string]any{"username": "john"}})
+
+	// Nothing listens on this address, the query fails after the cap was applied
+	db := pg.Connect(&pg.Options{Addr: "127.0.0.1:1", MaxRetries: 0})
+	defer db.Close()
+	_, _ = database.ExecuteQuery[any](query.WithDB(db))
+
+	if query.MaxEntries != 0 {
+		t.Errorf("Expected the caller's MaxEntries to stay 0, got %d", query.MaxEntries)
+	}
+}
+
+func TestBatchSizesWithinMaxInsertEntries(t *testing.T) {
+	loadTestConfig(t)
+
+	tests := []struct {
+		name        string
+		auditBatch  int
+		healthBatch int
+		wantErr     bool
+	}{
+		{"at the limit", 100, 100, false},
+		{"audit batch too large", 101, 50, true},
+		{"health batch too large", 50, 101, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := config.LoadDomainConfigs()
+			domains.Database.MaxInsertEntries = 100
+			domains.Audit.Enabled = true
+			domains.Audit.BatchSize = tt.auditBatch
+			domains.Health.Enabled = true
+			domains.Health.BatchSize = tt.healthBatch
+
+			err := domains.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/google/uuid"
+)
+
+func TestBuildBulkUpdateSQL(t *testing.T) {
+	updates := map[any]map[string]any{
+		"sub-2": {"grade": 7.5},
+		"sub-1": {"grade": 9.0, "feedback": "Well done"},
+	}
+
+	sql, args, err := database.BuildBulkUpdateSQL("public.submissions", "id", updates)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedSQL := `UPDATE "public"."submissions" SET ` +
+		`"feedback" = CASE "id" WHEN ? THEN ? ELSE "feedback" END, ` +
+		`"grade" = CASE "id" WHEN ? THEN ? WHEN ? THEN ? ELSE "grade" END ` +
+		`WHERE "id" IN (?, ?)`
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL:\n%s\ngot:\n%s", expectedSQL, sql)
+	}
+
+	expectedArgs := []any{"sub-1", "Well done", "sub-1", 9.0, "sub-2", 7.5, "sub-1", "sub-2"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+func TestBuildBulkUpdateSQLQuotesIdentifiers(t *testing.T) {
+	sql, _, err := database.BuildBulkUpdateSQL(`users"; DROP TABLE users; --`, "id", map[any]map[string]any{
+		1: {`name" = 'x`: "y"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedSQL := `UPDATE "users""; DROP TABLE users; --" SET "name"" = 'x" = CASE "id" WHEN ? THEN ? ELSE "name"" = 'x" END WHERE "id" IN (?)`
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL:\n%s\ngot:\n%s", expectedSQL, sql)
+	}
+}
+
+func TestBuildBulkUpdateSQLValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		table     string
+		keyColumn string
+		updates   map[any]map[string]any
+	}{
+		{"no updates", "users", "id", map[any]map[string]any{}},
+		{"empty table", "", "id", map[any]map[string]any{1: {"name": "x"}}},
+		{"empty key column", "users", "", map[any]map[string]any{1: {"name": "x"}}},
+		{"empty identifier part", "public.", "id", map[any]map[string]any{1: {"name": "x"}}},
+		{"no columns for a row", "users", "id", map[any]map[string]any{1: {}}},
+		{"nil key", "users", "id", map[any]map[string]any{nil: {"name": "x"}}},
+		{"empty column", "users", "id", map[any]map[string]any{1: {"": "x"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := database.BuildBulkUpdateSQL(tt.table, tt.keyColumn, tt.updates); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+// TestBulkUpdate updates several rows to distinct values in one statement against a real database
+func TestBulkUpdate(t *testing.T) {
+	requireDatabase(t)
+
+	service := "bulk-update-test-" + uuid.NewString()
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for _, id := range ids {
+		query := services.Query().SetOperation("insert").SetTable(lib.TableHealthLogs).SetData(map[string]any{
+			"id":            id,
+			"timestamp":     time.Now().UTC(),
+			"service":       service,
+			"status_code":   200,
+			"request_count": 1,
+			"error_count":   0,
+			"time_span":     60,
+		})
+		if _, err := database.ExecuteQuery[any](query); err != nil {
+			t.Skipf("Failed to create health log fixture: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		query := services.Query().SetOperation("delete").SetTable(lib.TableHealthLogs).
+			SetWhereRaw("health_logs.service = ?", service)
+		if _, err := database.ExecuteQuery[any](query); err != nil {
+			t.Logf("Failed to clean up health logs: %v", err)
+		}
+	})
+
+	updated, err := database.BulkUpdate[any](context.Background(), lib.TableHealthLogs, "id", map[any]map[string]any{
+		ids[0]: {"request_count": 10, "error_count": 2},
+		ids[1]: {"request_count": 20},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated != 2 {
+		t.Fatalf("Expected 2 updated rows, got %d", updated)
+	}
+
+	type counts struct {
+		ID           uuid.UUID
+		RequestCount int64
+		ErrorCount   int64
+	}
+	query := services.Query().SetRawSQL(
+		"SELECT id, request_count, error_count FROM health_logs WHERE service = ?", service)
+	result, err := database.ExecuteQuery[counts](query)
+	if err != nil {
+		t.Fatalf("Failed to read health logs: %v", err)
+	}
+
+	expected := map[uuid.UUID]counts{
+		ids[0]: {ids[0], 10, 2},
+		ids[1]: {ids[1], 20, 0},
+		ids[2]: {ids[2], 1, 0},
+	}
+	if len(result.Data) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d", len(expected), len(result.Data))
+	}
+	for _, row := range result.Data {
+		if row != expected[row.ID] {
+			t.Errorf("Expected %+v, got %+v", expected[row.ID], row)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/services"
+	"github.com/google/uuid"
+)
+
+func TestCacheSetManyGetMany(t *testing.T) {
+	loadTestConfig(t)
+
+	cs := services.NewCacheService()
+	if err := cs.Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	prefix := "cache-batch-test:" + uuid.NewString() + ":"
+	entries := map[string]services.CacheEntry{
+		prefix + "a": {Value: "1", TTL: time.Minute},
+		prefix + "b": {Value: 2, TTL: time.Minute},
+		prefix + "c": {Value: "three"},
+	}
+	t.Cleanup(func() {
+		for key := range entries {
+			if err := cs.Delete(key); err != nil {
+				t.Logf("Failed to clean up %s: %v", key, err)
+			}
+		}
+	})
+
+	if err := cs.SetMany(entries); err != nil {
+		t.Fatalf("SetMany failed: %v", err)
+	}
+
+	got, err := cs.GetMany([]string{prefix + "a", prefix + "b", prefix + "missing", prefix + "c"})
+	if err != nil {
+		t.Fatalf("GetMany failed: %v", err)
+	}
+
+	expected := map[string]string{prefix + "a": "1", prefix + "b": "2", prefix + "c": "three"}
+	if !maps.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestCacheBatchEmpty(t *testing.T) {
+	loadTestConfig(t)
+
+	// Empty batches return without a round trip, so they work without Redis
+	cs := services.NewCacheService()
+	if err := cs.SetMany(nil); err != nil {
+		t.Errorf("Expected SetMany without entries to succeed, got %v", err)
+	}
+	got, err := cs.GetMany(nil)
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty map, got %v (err %v)", got, err)
+	}
+}
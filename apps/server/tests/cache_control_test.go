@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestCacheControlMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     middleware.CacheControlOptions
+		method   string
+		status   int
+		expected string
+	}{
+		{"private cacheable list", middleware.CacheControlOptions{MaxAge: 5 * time.Minute, Private: true}, http.MethodGet, http.StatusOK, "private, max-age=300"},
+		{"public cacheable list", middleware.CacheControlOptions{MaxAge: time.Hour}, http.MethodGet, http.StatusOK, "public, max-age=3600"},
+		{"head request", middleware.CacheControlOptions{MaxAge: time.Minute}, http.MethodHead, http.StatusOK, "public, max-age=60"},
+		{"zero max age", middleware.CacheControlOptions{}, http.MethodGet, http.StatusOK, "no-store"},
+		{"sub-second max age", middleware.CacheControlOptions{MaxAge: 500 * time.Millisecond}, http.MethodGet, http.StatusOK, "no-store"},
+		{"error response", middleware.CacheControlOptions{MaxAge: time.Minute}, http.MethodGet, http.StatusNotFound, "no-store"},
+		{"write request", middleware.CacheControlOptions{MaxAge: time.Minute}, http.MethodPost, http.StatusOK, "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(middleware.NewCacheControl(tt.opts))
+			app.All("/subjects", func(c fiber.Ctx) error {
+				return c.SendStatus(tt.status)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, "/subjects", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if got := resp.Header.Get(fiber.HeaderCacheControl); got != tt.expected {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCacheControlOnHandlerError(t *testing.T) {
+	app := fiber.New()
+	app.Use(middleware.NewCacheControl(middleware.CacheControlOptions{MaxAge: time.Minute}))
+	app.Get("/subjects", func(c fiber.Ctx) error {
+		return fiber.ErrInternalServerError
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/subjects", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if got := resp.Header.Get(fiber.HeaderCacheControl); got != "no-store" {
+		t.Errorf("Expected a failed request to be sent with no-store, got %q", got)
+	}
+}
+
+func TestNoStoreMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler fiber.Handler
+	}{
+		{"successful response", func(c fiber.Ctx) error {
+			return c.JSON(fiber.Map{"access_token": "secret"})
+		}},
+		{"handler tries to allow caching", func(c fiber.Ctx) error {
+			c.Set(fiber.HeaderCacheControl, "public, max-age=600")
+			return c.SendStatus(http.StatusOK)
+		}},
+		{"error response", func(c fiber.Ctx) error {
+			return fiber.ErrUnauthorized
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(middleware.NewNoStore())
+			app.Get("/auth/me", tt.handler)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/auth/me", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if got := resp.Header.Get(fiber.HeaderCacheControl); got != "no-store" {
+				t.Errorf("Expected Cache-Control no-store, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/google/uuid"
+)
+
+func TestCacheTTLHelpers(t *testing.T) {
+	loadTestConfig(t)
+
+	cs := services.NewCacheService()
+	if err := cs.Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	key := "cache-ttl-test:" + uuid.NewString()
+	persistent := key + ":persistent"
+	missing := key + ":missing"
+	t.Cleanup(func() {
+		for _, k := range []string{key, persistent} {
+			if err := cs.Delete(k); err != nil {
+				t.Logf("Failed to clean up %s: %v", k, err)
+			}
+		}
+	})
+
+	if err := cs.Set(key, "session", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := cs.Set(persistent, "kept", 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	ttl, err := cs.TTL(key)
+	if err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected a TTL of at most a minute, got %v (err %v)", ttl, err)
+	}
+	if ttl, err := cs.TTL(persistent); err != nil || ttl != services.NoExpiry {
+		t.Errorf("Expected NoExpiry for a key without TTL, got %v (err %v)", ttl, err)
+	}
+	if _, err := cs.TTL(missing); !errors.Is(err, lib.ErrCacheKeyNotFound) {
+		t.Errorf("Expected ErrCacheKeyNotFound for a missing key, got %v", err)
+	}
+
+	// Expire may shorten the TTL
+	if err := cs.Expire(key, 30*time.Second); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if ttl, _ := cs.TTL(key); ttl > 30*time.Second {
+		t.Errorf("Expected Expire to shorten the TTL to 30s, got %v", ttl)
+	}
+	if err := cs.Expire(missing, time.Minute); !errors.Is(err, lib.ErrCacheKeyNotFound) {
+		t.Errorf("Expected ErrCacheKeyNotFound when expiring a missing key, got %v", err)
+	}
+
+	// Touch extends but never shortens, and keeps the value
+	if found, err := cs.Touch(key, time.Hour); err != nil || !found {
+		t.Fatalf("Touch failed: found %v, err %v", found, err)
+	}
+	if ttl, _ := cs.TTL(key); ttl <= 30*time.Minute {
+		t.Errorf("Expected Touch to extend the TTL to an hour, got %v", ttl)
+	}
+	if _, err := cs.Touch(key, time.Second); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if ttl, _ := cs.TTL(key); ttl <= 30*time.Minute {
+		t.Errorf("Expected Touch not to shorten the TTL, got %v", ttl)
+	}
+	if value, _ := cs.Get(key); value != "session" {
+		t.Errorf("Expected the value to be kept, got %q", value)
+	}
+
+	if _, err := cs.Touch(persistent, time.Minute); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if ttl, _ := cs.TTL(persistent); ttl != services.NoExpiry {
+		t.Errorf("Expected Touch to leave a key without TTL alone, got %v", ttl)
+	}
+
+	if found, err := cs.Touch(missing, time.Minute); err != nil || found {
+		t.Errorf("Expected Touch to report a missing key, got found %v, err %v", found, err)
+	}
+}
+
+func TestCacheTTLRejectsNonPositive(t *testing.T) {
+	loadTestConfig(t)
+
+	// Invalid TTLs are rejected before reaching Redis
+	cs := services.NewCacheService()
+	if err := cs.Expire("any", 0); err == nil {
+		t.Error("Expected Expire with a zero TTL to fail")
+	}
+	if _, err := cs.Touch("any", -time.Second); err == nil {
+		t.Error("Expected Touch with a negative TTL to fail")
+	}
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+)
+
+// webhookRecorder collects the alert payloads posted to a test webhook
+type webhookRecorder struct {
+	mu       sync.Mutex
+	payloads []services.CircuitAlertPayload
+}
+
+func (wr *webhookRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload services.CircuitAlertPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	wr.mu.Lock()
+	wr.payloads = append(wr.payloads, payload)
+	wr.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (wr *webhookRecorder) all() []services.CircuitAlertPayload {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return append([]services.CircuitAlertPayload(nil), wr.payloads...)
+}
+
+func TestCircuitAlertDebounce(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	capture := &auditCapture{}
+	config.SetAuditLogFunc(capture.add)
+
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	alerts := services.NewAlertServiceWithOptions(logger, server.URL, time.Minute)
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	transitions := []struct {
+		to     lib.CircuitState
+		offset time.Duration
+	}{
+		{lib.StateOpen, 0},
+		{lib.StateHalfOpen, 30 * time.Second},
+		{lib.StateOpen, 31 * time.Second}, // suppressed, within a minute of the first open
+		{lib.StateOpen, 50 * time.Second}, // suppressed
+		{lib.StateOpen, 2 * time.Minute},  // sent, reports two suppressed transitions
+		{lib.StateClosed, 2*time.Minute + 10*time.Second},
+	}
+
+	from := lib.StateClosed
+	for i, tr := range transitions {
+		alerts.HandleCircuitStateChange(lib.CircuitStateChange{
+			Name:      "database",
+			From:      from,
+			To:        tr.to,
+			Failures:  int64(5 + i),
+			Timestamp: start.Add(tr.offset),
+		})
+		from = tr.to
+	}
+
+	payloads := recorder.all()
+	wantTo := []string{"open", "half-open", "open", "closed"}
+	if len(payloads) != len(wantTo) {
+		t.Fatalf("expected %d webhook calls, got %d: %+v", len(wantTo), len(payloads), payloads)
+	}
+	for i, want := range wantTo {
+		if payloads[i].To != want {
+			t.Errorf("payload %d: expected to=%q, got %q", i, want, payloads[i].To)
+		}
+		if payloads[i].Breaker != "database" {
+			t.Errorf("payload %d: expected breaker name to be included, got %q", i, payloads[i].Breaker)
+		}
+	}
+
+	if payloads[0].From != "closed" || payloads[0].Failures != 5 {
+		t.Errorf("unexpected first payload: %+v", payloads[0])
+	}
+	if payloads[2].Suppressed != 2 || payloads[2].Failures != 9 {
+		t.Errorf("expected the resent open alert to report 2 suppressed transitions and 9 failures, got %+v", payloads[2])
+	}
+
+	if opened := capture.take("Circuit breaker opened"); len(opened) != 2 {
+		t.Errorf("expected 2 audit logs for opened transitions, got %d", len(opened))
+	}
+}
+
+func TestCircuitAlertWithoutWebhook(t *testing.T) {
+	capture := &auditCapture{}
+	config.SetAuditLogFunc(capture.add)
+
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	alerts := services.NewAlertServiceWithOptions(logger, "", 0)
+
+	for range 3 {
+		alerts.HandleCircuitStateChange(lib.CircuitStateChange{
+			Name:      "database",
+			From:      lib.StateClosed,
+			To:        lib.StateOpen,
+			Failures:  5,
+			Timestamp: time.Now(),
+		})
+	}
+
+	// Without a debounce window every transition is logged
+	if opened := capture.take("Circuit breaker opened"); len(opened) != 3 {
+		t.Errorf("expected 3 audit logs, got %d", len(opened))
+	}
+}
+
+func TestDatabaseCircuitBreakerNotifiesAlertHandler(t *testing.T) {
+	changes := make(chan lib.CircuitStateChange, 1)
+	lib.RegisterCircuitBreakerAlertHandler(func(change lib.CircuitStateChange) {
+		changes <- change
+	})
+	defer lib.RegisterCircuitBreakerAlertHandler(nil)
+
+	cb := lib.NewDatabaseCircuitBreaker("test-db", lib.CircuitBreakerConfig{MaxFailures: 1})
+	cb.ForceOpen()
+
+	select {
+	case change := <-changes:
+		if change.Name != "test-db" || change.From != lib.StateClosed || change.To != lib.StateOpen {
+			t.Errorf("unexpected state change: %+v", change)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("alert handler was not called")
+	}
+}
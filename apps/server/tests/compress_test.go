@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/gofiber/fiber/v3"
+)
+
+func newCompressionApp(minSize int) *fiber.App {
+	largeBody := strings.Repeat("deadline ", 200)
+
+	app := fiber.New()
+	app.Use(middleware.NewCompression(middleware.CompressionConfig{Level: 6, MinSize: minSize}))
+	app.Get("/large", func(c fiber.Ctx) error {
+		return c.SendString(largeBody)
+	})
+	app.Get("/small", func(c fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	app.Get("/image", func(c fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.SendString(largeBody)
+	})
+	app.Get("/etag", func(c fiber.Ctx) error {
+		items := make([]string, 100)
+		for i := range items {
+			items[i] = "deadline"
+		}
+		return response.SuccessWithETag(c, items)
+	})
+	return app
+}
+
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Invalid gzip body: %v", err)
+		}
+		r = gr
+	case "deflate":
+		r = flate.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to decompress %s body: %v", encoding, err)
+	}
+	return string(decoded)
+}
+
+func TestCompressionNegotiation(t *testing.T) {
+	app := newCompressionApp(256)
+	expected := strings.Repeat("deadline ", 200)
+
+	tests := []struct {
+		name             string
+		path             string
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{"gzip", "/large", "gzip", "gzip"},
+		{"deflate", "/large", "deflate", "deflate"},
+		{"gzip preferred on tie", "/large", "deflate, gzip", "gzip"},
+		{"quality decides", "/large", "gzip;q=0.5, deflate;q=0.8", "deflate"},
+		{"wildcard", "/large", "*", "gzip"},
+		{"refused encoding", "/large", "gzip;q=0, deflate;q=0", ""},
+		{"unsupported encoding", "/large", "br", ""},
+		{"no accept-encoding", "/large", "", ""},
+		{"below minimum size", "/small", "gzip", ""},
+		{"already compressed type", "/image", "gzip", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set(fiber.HeaderAcceptEncoding, tt.acceptEncoding)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+
+			encoding := resp.Header.Get(fiber.HeaderContentEncoding)
+			if encoding != tt.expectedEncoding {
+				t.Fatalf("Expected Content-Encoding %q, got %q", tt.expectedEncoding, encoding)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.path == "/large" {
+				if got := decompress(t, encoding, body); got != expected {
+					t.Errorf("Decompressed body does not match the original (%d bytes)", len(got))
+				}
+				if vary := resp.Header.Get(fiber.HeaderVary); !strings.Contains(vary, fiber.HeaderAcceptEncoding) {
+					t.Errorf("Expected Vary to include Accept-Encoding, got %q", vary)
+				}
+			}
+		})
+	}
+}
+
+func TestCompressionKeepsETagOfUncompressedBody(t *testing.T) {
+	app := newCompressionApp(256)
+
+	// The tag is computed on the uncompressed response
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/etag", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	etag := resp.Header.Get(fiber.HeaderETag)
+	if etag == "" || resp.Header.Get(fiber.HeaderContentEncoding) != "" {
+		t.Fatalf("Expected an uncompressed response with an ETag, got %q", etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/etag", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.Header.Get(fiber.HeaderContentEncoding) != "gzip" {
+		t.Fatal("Expected the response to be compressed")
+	}
+	compressedTag := resp.Header.Get(fiber.HeaderETag)
+	if compressedTag != "W/"+etag {
+		t.Fatalf("Expected the uncompressed tag marked weak %q, got %q", "W/"+etag, compressedTag)
+	}
+
+	// A client revalidating with the weak tag gets 304 without a body
+	req = httptest.NewRequest(http.MethodGet, "/etag", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+	req.Header.Set(fiber.HeaderIfNoneMatch, compressedTag)
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("Expected 304, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get(fiber.HeaderContentEncoding) != "" {
+		t.Error("Expected the 304 response not to be compressed")
+	}
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/go-pg/pg/v10"
+)
+
+func TestConnGuardRecordsWaitWhenSaturated(t *testing.T) {
+	// A pool of one connection, held by the first query
+	guard := database.NewConnGuard(1, 10*time.Millisecond, nil)
+	release, err := guard.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+
+	if stats := guard.Stats(); stats.Waits != 0 || stats.InUse != 1 {
+		t.Fatalf("Expected a free slot to be taken without waiting, got %+v", stats)
+	}
+
+	const hold = 50 * time.Millisecond
+	go func() {
+		time.Sleep(hold)
+		release()
+	}()
+
+	second, err := guard.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("second acquire failed: %v", err)
+	}
+	second()
+
+	stats := guard.Stats()
+	if stats.Waits != 1 {
+		t.Errorf("Expected 1 wait, got %d", stats.Waits)
+	}
+	if stats.SlowWaits != 1 {
+		t.Errorf("Expected the wait to exceed the threshold, got %d slow waits", stats.SlowWaits)
+	}
+	if stats.TotalWait < hold/2 || stats.MaxWait != stats.TotalWait {
+		t.Errorf("Expected a recorded wait of about %v, got total %v and max %v", hold, stats.TotalWait, stats.MaxWait)
+	}
+	if stats.InUse != 0 || stats.Capacity != 1 {
+		t.Errorf("Expected all slots to be released, got %+v", stats)
+	}
+}
+
+func TestConnGuardGivesUpWithContext(t *testing.T) {
+	guard := database.NewConnGuard(1, 0, nil)
+	release, err := guard.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := guard.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+
+	stats := guard.Stats()
+	if stats.Waits != 1 || stats.TotalWait <= 0 {
+		t.Errorf("Expected the failed wait to be recorded, got %+v", stats)
+	}
+	if stats.SlowWaits != 0 {
+		t.Errorf("Expected no slow waits with the warning disabled, got %d", stats.SlowWaits)
+	}
+}
+
+func TestTransactionHoldsConnSlot(t *testing.T) {
+	requireDatabase(t)
+
+	// A pool of one connection, which the transaction keeps until it commits
+	guard := database.NewConnGuard(1, 0, nil)
+	previous := database.GetConnGuard()
+	database.SetConnGuard(guard)
+	t.Cleanup(func() { database.SetConnGuard(previous) })
+
+	inTx, commit := make(chan struct{}), make(chan struct{})
+	txDone := make(chan error, 1)
+	go func() {
+		txDone <- database.Transaction(context.Background(), func(tx *pg.Tx) error {
+			close(inTx)
+			<-commit
+			return nil
+		})
+	}()
+	<-inTx
+
+	queryDone := make(chan error, 1)
+	go func() {
+		_, err := database.ExecuteQuery[any](services.Query().SetRawSQL("SELECT 1"))
+		queryDone <- err
+	}()
+
+	select {
+	case err := <-queryDone:
+		t.Fatalf("Expected the query to wait for the transaction, it finished with %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if stats := guard.Stats(); stats.InUse != 1 {
+		t.Errorf("Expected the transaction to hold the only slot, got %+v", stats)
+	}
+
+	close(commit)
+	if err := <-txDone; err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if err := <-queryDone; err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if stats := guard.Stats(); stats.Waits != 1 || stats.InUse != 0 {
+		t.Errorf("Expected one recorded wait and all slots released, got %+v", stats)
+	}
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestRequireJSON(t *testing.T) {
+	app := fiber.New()
+	app.Post("/deadlines", middleware.NewRequireJSON(), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		expectedStatus int
+	}{
+		{name: "json", body: `{"title":"Essay"}`, contentType: "application/json", expectedStatus: fiber.StatusCreated},
+		{name: "json with charset", body: `{"title":"Essay"}`, contentType: "application/json; charset=utf-8", expectedStatus: fiber.StatusCreated},
+		{name: "media type is case-insensitive", body: `{"title":"Essay"}`, contentType: "Application/JSON", expectedStatus: fiber.StatusCreated},
+		{name: "empty body without content type", expectedStatus: fiber.StatusCreated},
+		{name: "form body", body: "title=Essay", contentType: "application/x-www-form-urlencoded", expectedStatus: fiber.StatusUnsupportedMediaType},
+		{name: "plain text body", body: `{"title":"Essay"}`, contentType: "text/plain", expectedStatus: fiber.StatusUnsupportedMediaType},
+		{name: "json suffix type", body: `{"title":"Essay"}`, contentType: "application/problem+json", expectedStatus: fiber.StatusUnsupportedMediaType},
+		{name: "body without content type", body: `{"title":"Essay"}`, expectedStatus: fiber.StatusUnsupportedMediaType},
+		{name: "malformed content type", body: `{"title":"Essay"}`, contentType: "application/json; charset", expectedStatus: fiber.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/deadlines", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if tt.expectedStatus == fiber.StatusUnsupportedMediaType {
+				assertErrorCode(t, resp, tt.expectedStatus, response.ErrCodeUnsupportedMedia)
+			} else if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
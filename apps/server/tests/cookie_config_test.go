@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestCookieConfigValidation(t *testing.T) {
+	tests := []struct {
+		name             string
+		env              map[string]string
+		wantErr          bool
+		expectedSecure   bool
+		expectedSameSite string
+	}{
+		{name: "development defaults", env: map[string]string{}, expectedSecure: false, expectedSameSite: "Lax"},
+		{name: "production defaults", env: map[string]string{"ENVIRONMENT": "production"}, expectedSecure: true, expectedSameSite: "Strict"},
+		{name: "same site is case-insensitive", env: map[string]string{"COOKIE_SAMESITE": "strict"}, expectedSecure: false, expectedSameSite: "Strict"},
+		{name: "none with secure", env: map[string]string{"COOKIE_SAMESITE": "None", "COOKIE_SECURE": "true"}, expectedSecure: true, expectedSameSite: "None"},
+		{name: "production lax", env: map[string]string{"ENVIRONMENT": "production", "COOKIE_SAMESITE": "Lax"}, expectedSecure: true, expectedSameSite: "Lax"},
+		{name: "unknown same site", env: map[string]string{"COOKIE_SAMESITE": "Loose"}, wantErr: true},
+		{name: "none without secure", env: map[string]string{"COOKIE_SAMESITE": "None", "COOKIE_SECURE": "false"}, wantErr: true},
+		{name: "production without secure", env: map[string]string{"ENVIRONMENT": "production", "COOKIE_SECURE": "false"}, wantErr: true},
+		{name: "production without http only", env: map[string]string{"ENVIRONMENT": "production", "COOKIE_HTTP_ONLY": "false"}, wantErr: true},
+		{name: "production with none", env: map[string]string{"ENVIRONMENT": "production", "COOKIE_SAMESITE": "None", "COOKIE_SECURE": "true"}, wantErr: true},
+		{name: "domain with scheme", env: map[string]string{"COOKIE_DOMAIN": "https://example.com"}, wantErr: true},
+		{name: "csrf exempt path without slash", env: map[string]string{"CSRF_EXEMPT_PATHS": "auth/login"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", "development")
+			t.Setenv("COOKIE_SECURE", "")
+			t.Setenv("COOKIE_SAMESITE", "")
+			t.Setenv("COOKIE_DOMAIN", "")
+			t.Setenv("COOKIE_HTTP_ONLY", "")
+			t.Setenv("CSRF_EXEMPT_PATHS", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			domains := config.LoadDomainConfigs()
+			err := domains.Cookie.Validate(domains.App.Environment)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected validation error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected validation error: %v", err)
+			}
+
+			cookie := domains.ToLegacyConfig().Cookie
+			if cookie.Secure != tt.expectedSecure || cookie.SameSite != tt.expectedSameSite || !cookie.HTTPOnly {
+				t.Errorf("Expected Secure=%v SameSite=%s HttpOnly=true, got %+v", tt.expectedSecure, tt.expectedSameSite, cookie)
+			}
+		})
+	}
+}
+
+func TestAuthCookieAttributes(t *testing.T) {
+	loadTestConfig(t)
+
+	cookieService := services.NewCookieServiceWithConfig(types.CookieConfig{
+		Domain:   "example.com",
+		Secure:   true,
+		SameSite: "Lax",
+		HTTPOnly: true,
+	})
+
+	app := fiber.New()
+	app.Get("/login", func(c fiber.Ctx) error {
+		cookieService.SetAuthCookies(c, "access", "refresh")
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/logout", func(c fiber.Ctx) error {
+		cookieService.ClearAuthCookies(c)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, path := range []string{"/login", "/logout"} {
+		t.Run(path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			cookies := resp.Cookies()
+			if len(cookies) != 2 {
+				t.Fatalf("Expected 2 cookies, got %d", len(cookies))
+			}
+			for _, cookie := range cookies {
+				if cookie.Name != lib.AccessTokenCookieName && cookie.Name != lib.RefreshTokenCookieName {
+					t.Errorf("Unexpected cookie %q", cookie.Name)
+				}
+				if cookie.Domain != "example.com" || !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+					t.Errorf("Expected cookie %s on example.com with Secure, HttpOnly and SameSite=Lax, got %+v", cookie.Name, cookie)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestCorsConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		origins     []string
+		credentials bool
+		expectErr   bool
+	}{
+		{"explicit origins", "production", []string{"https://app.example.com", "http://localhost:5173"}, true, false},
+		{"trailing slash", "production", []string{"https://app.example.com/"}, true, false},
+		{"wildcard without credentials", "production", []string{"*"}, false, false},
+		{"wildcard with credentials", "development", []string{"*"}, true, true},
+		{"subdomain wildcard in development", "development", []string{"https://*.example.com"}, true, false},
+		{"subdomain wildcard in production", "production", []string{"https://*.example.com"}, true, true},
+		{"subdomain wildcard in production without credentials", "production", []string{"https://*.example.com"}, false, false},
+		{"missing scheme", "development", []string{"app.example.com"}, false, true},
+		{"unsupported scheme", "development", []string{"ftp://app.example.com"}, false, true},
+		{"with path", "development", []string{"https://app.example.com/login"}, false, true},
+		{"misplaced wildcard", "development", []string{"https://app.*.example.com"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.CorsConfig{
+				AllowOrigins:     tt.origins,
+				AllowMethods:     []string{"GET", "POST"},
+				AllowCredentials: tt.credentials,
+			}
+			err := cfg.Validate(tt.environment)
+			if tt.expectErr && err == nil {
+				t.Error("Expected an error, got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+
+	app := fiber.New()
+	app.Use(middleware.NewCORS(types.CorsConfig{
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowMethods:     []string{"GET", "POST"},
+		AllowHeaders:     []string{"Content-Type"},
+		AllowCredentials: true,
+	}, logger))
+	app.Get("/deadlines", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		allowOrigin string
+		logged      bool
+	}{
+		{"allowed origin", http.MethodGet, "https://app.example.com", "https://app.example.com", false},
+		{"allowed preflight", http.MethodOptions, "https://app.example.com", "https://app.example.com", false},
+		{"rejected origin", http.MethodGet, "https://evil.example.com", "", true},
+		{"rejected preflight", http.MethodOptions, "https://evil.example.com", "", true},
+		{"no origin", http.MethodGet, "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+
+			req := httptest.NewRequest(tt.method, "/deadlines", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if got := resp.Header.Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.allowOrigin, got)
+			}
+
+			logged := strings.Contains(logs.String(), "Rejected CORS request")
+			if logged != tt.logged {
+				t.Errorf("Expected rejection logged %v, got %v: %s", tt.logged, logged, logs.String())
+			}
+			if tt.logged && !strings.Contains(logs.String(), tt.origin) {
+				t.Errorf("Expected the rejected origin in the log, got %s", logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestCSRFProtection(t *testing.T) {
+	loadTestConfig(t)
+
+	cookieService := services.NewCookieServiceWithConfig(types.CookieConfig{
+		SameSite:    "Lax",
+		HTTPOnly:    true,
+		CSRFEnabled: true,
+	})
+
+	app := fiber.New()
+	app.Use(middleware.NewCSRFProtection(cookieService, []string{"/auth/login"}))
+	app.All("/*", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		cookies        map[string]string
+		header         string
+		expectedStatus int
+	}{
+		{name: "write without cookies", method: http.MethodPost, path: "/subjects", expectedStatus: fiber.StatusOK},
+		{
+			name:           "write without token header",
+			method:         http.MethodPost,
+			path:           "/subjects",
+			cookies:        map[string]string{lib.AccessTokenCookieName: "access", lib.CSRFTokenCookieName: "token"},
+			expectedStatus: fiber.StatusForbidden,
+		},
+		{
+			name:           "write with matching token",
+			method:         http.MethodDelete,
+			path:           "/subjects/1",
+			cookies:        map[string]string{lib.AccessTokenCookieName: "access", lib.CSRFTokenCookieName: "token"},
+			header:         "token",
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "write with mismatched token",
+			method:         http.MethodPatch,
+			path:           "/subjects/1",
+			cookies:        map[string]string{lib.RefreshTokenCookieName: "refresh", lib.CSRFTokenCookieName: "token"},
+			header:         "other",
+			expectedStatus: fiber.StatusForbidden,
+		},
+		{
+			name:           "write without token cookie",
+			method:         http.MethodPost,
+			path:           "/subjects",
+			cookies:        map[string]string{lib.AccessTokenCookieName: "access"},
+			header:         "token",
+			expectedStatus: fiber.StatusForbidden,
+		},
+		{
+			name:           "exempt path",
+			method:         http.MethodPost,
+			path:           "/auth/login",
+			cookies:        map[string]string{lib.AccessTokenCookieName: "access"},
+			expectedStatus: fiber.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for name, value := range tt.cookies {
+				req.AddCookie(&http.Cookie{Name: name, Value: value})
+			}
+			if tt.header != "" {
+				req.Header.Set(lib.CSRFTokenHeader, tt.header)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	t.Run("read issues a token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/subjects", nil)
+		req.AddCookie(&http.Cookie{Name: lib.AccessTokenCookieName, Value: "access"})
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		header := resp.Header.Get(lib.CSRFTokenHeader)
+		if header == "" {
+			t.Fatal("Expected a CSRF token header")
+		}
+
+		var issued *http.Cookie
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == lib.CSRFTokenCookieName {
+				issued = cookie
+			}
+		}
+		if issued == nil {
+			t.Fatal("Expected a CSRF token cookie")
+		}
+		if issued.Value != header || issued.HttpOnly {
+			t.Errorf("Expected a readable cookie matching the header %q, got %+v", header, issued)
+		}
+	})
+
+	t.Run("read echoes the token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/subjects", nil)
+		req.AddCookie(&http.Cookie{Name: lib.AccessTokenCookieName, Value: "access"})
+		req.AddCookie(&http.Cookie{Name: lib.CSRFTokenCookieName, Value: "token"})
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		if got := resp.Header.Get(lib.CSRFTokenHeader); got != "token" {
+			t.Errorf("Expected CSRF token header %q, got %q", "token", got)
+		}
+		if len(resp.Cookies()) != 0 {
+			t.Errorf("Expected no new cookies, got %d", len(resp.Cookies()))
+		}
+	})
+}
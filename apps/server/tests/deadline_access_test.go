@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+func TestDeadlineAccessAllowed(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+
+	tests := []struct {
+		name     string
+		role     string
+		access   types.DeadlineAccess
+		expected bool
+	}{
+		{"admin without relation", lib.RoleAdmin, types.DeadlineAccess{OwnerID: otherID}, true},
+		{"admin owner", lib.RoleAdmin, types.DeadlineAccess{OwnerID: userID}, true},
+		{"teacher owner", lib.RoleTeacher, types.DeadlineAccess{OwnerID: userID}, true},
+		{"teacher assigned to subject", lib.RoleTeacher, types.DeadlineAccess{OwnerID: otherID, AssignedTeacher: true}, true},
+		{"teacher owner and assigned", lib.RoleTeacher, types.DeadlineAccess{OwnerID: userID, AssignedTeacher: true}, true},
+		{"teacher without relation", lib.RoleTeacher, types.DeadlineAccess{OwnerID: otherID}, false},
+		{"teacher enrolled only", lib.RoleTeacher, types.DeadlineAccess{OwnerID: otherID, Enrolled: true}, false},
+		{"student enrolled", lib.RoleStudent, types.DeadlineAccess{OwnerID: otherID, Enrolled: true}, true},
+		{"student not enrolled", lib.RoleStudent, types.DeadlineAccess{OwnerID: otherID}, false},
+		{"student owner not enrolled", lib.RoleStudent, types.DeadlineAccess{OwnerID: userID}, false},
+		{"student assigned as teacher", lib.RoleStudent, types.DeadlineAccess{OwnerID: otherID, AssignedTeacher: true}, false},
+		{"unknown role", "guest", types.DeadlineAccess{OwnerID: userID, AssignedTeacher: true, Enrolled: true}, false},
+		{"empty role", "", types.DeadlineAccess{OwnerID: userID}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := services.DeadlineAccessAllowed(userID, tt.role, tt.access); got != tt.expected {
+				t.Errorf("Expected access %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCanManageUserDeadlines(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+
+	tests := []struct {
+		name     string
+		role     string
+		ownerID  uuid.UUID
+		expected bool
+	}{
+		{"teacher own deadlines", lib.RoleTeacher, userID, true},
+		{"teacher other teacher's deadlines", lib.RoleTeacher, otherID, false},
+		{"admin other teacher's deadlines", lib.RoleAdmin, otherID, true},
+		{"student own id", lib.RoleStudent, userID, false},
+		{"unknown role own id", "guest", userID, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := services.CanManageUserDeadlines(userID, tt.role, tt.ownerID); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCanAccessDeadline(t *testing.T) {
+	requireDatabase(t)
+	ds := services.NewDeadlineService()
+
+	// The deadline is owned by one teacher of the subject, the second teacher does not own it
+	ownerID, assignedID := createTestUser(t, lib.RoleTeacher), createTestUser(t, lib.RoleTeacher)
+	deadlineID := createTestDeadline(t, createTestSubject(t, "Deadline access test", ownerID, assignedID), ownerID)
+
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		role     string
+		expected bool
+	}{
+		{"owning teacher", ownerID, lib.RoleTeacher, true},
+		{"assigned teacher", assignedID, lib.RoleTeacher, true},
+		{"unrelated teacher", uuid.New(), lib.RoleTeacher, false},
+		{"unenrolled student", uuid.New(), lib.RoleStudent, false},
+		{"admin", uuid.New(), lib.RoleAdmin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, err := ds.CanAccess(context.Background(), tt.userID, tt.role, deadlineID)
+			if err != nil {
+				t.Fatalf("Failed to check access: %v", err)
+			}
+			if allowed != tt.expected {
+				t.Errorf("Expected access %v, got %v", tt.expected, allowed)
+			}
+		})
+	}
+}
+
+func TestCanAccessDeadlineNotFound(t *testing.T) {
+	requireDatabase(t)
+
+	_, err := services.NewDeadlineService().CanAccess(context.Background(), uuid.New(), lib.RoleAdmin, uuid.New())
+	if !errors.Is(err, lib.ErrDeadlineNotFound) {
+		t.Errorf("Expected ErrDeadlineNotFound, got %v", err)
+	}
+}
+
+func TestCreateSubmissionRequiresEnrollment(t *testing.T) {
+	requireDatabase(t)
+
+	// The student exists but is not enrolled in the subject of the deadline
+	teacherID, studentID := createTestUser(t, lib.RoleTeacher), createTestUser(t, lib.RoleStudent)
+	deadlineID := createTestDeadline(t, createTestSubject(t, "Submission access test", teacherID), teacherID)
+
+	user := &types.User{Id: studentID, Username: "submission-access-test", Email: "submission-access-test@example.com", Role: lib.RoleStudent}
+	accessToken, err := services.NewAuthService().GenerateAccessToken(user)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+
+	app := fiber.New()
+	api.SetupRoutes(app, config.SetupLogger())
+
+	req := httptest.NewRequest(http.MethodPost, "/deadlines/"+deadlineID.String()+"/submission", strings.NewReader(`{"file_ids":["file-1"]}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+accessToken)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d for a student outside the subject, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+
+	submission, err := services.NewDeadlineService().GetSubmissionByStudent(context.Background(), deadlineID, studentID)
+	if err != nil {
+		t.Fatalf("Failed to fetch submission: %v", err)
+	}
+	if submission != nil {
+		t.Error("Expected no submission to be stored for a forbidden request")
+	}
+}
+
+func TestTeacherCannotModifyOtherTeachersDeadlines(t *testing.T) {
+	requireDatabase(t)
+
+	// The acting teacher exists but neither owns the deadline nor teaches its subject
+	ownerID, otherID := createTestUser(t, lib.RoleTeacher), createTestUser(t, lib.RoleTeacher)
+	deadlineID := createTestDeadline(t, createTestSubject(t, "Deadline forbidden test", ownerID), ownerID)
+
+	user := &types.User{Id: otherID, Username: "deadline-forbidden-test", Email: "deadline-forbidden-test@example.com", Role: lib.RoleTeacher}
+	accessToken, err := services.NewAuthService().GenerateAccessToken(user)
+	if err != nil {
+		t.Fatalf("Failed to generate access token: %v", err)
+	}
+
+	app := fiber.New()
+	api.SetupRoutes(app, config.SetupLogger())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"update deadline", http.MethodPut, "/deadlines/" + deadlineID.String(), `{"title":"Taken over"}`},
+		{"delete deadline", http.MethodDelete, "/deadlines/" + deadlineID.String(), ""},
+		{"delete deadlines of owner", http.MethodDelete, "/deadlines/user/" + ownerID.String(), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			}
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+accessToken)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusForbidden {
+				t.Errorf("Expected status %d for another teacher's deadline, got %d", http.StatusForbidden, resp.StatusCode)
+			}
+		})
+	}
+
+	// None of the forbidden requests may have touched the deadline
+	allowed, err := services.NewDeadlineService().CanAccess(context.Background(), ownerID, lib.RoleTeacher, deadlineID)
+	if err != nil {
+		t.Fatalf("Expected the deadline to still exist, got %v", err)
+	}
+	if !allowed {
+		t.Error("Expected the owner to keep access to the deadline")
+	}
+}
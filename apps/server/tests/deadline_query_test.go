@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/go-pg/pg/v10"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestDeadlinesQueryFilters(t *testing.T) {
+	const (
+		subjectID = "5f0c6f7e-8a7b-4c1d-9e2f-3a4b5c6d7e8f"
+		from      = "2025-01-01"
+		to        = "2025-02-01"
+	)
+
+	tests := []struct {
+		name          string
+		filterOptions map[string]string
+		expectedWhere map[string]any
+	}{
+		{"no filters", map[string]string{}, map[string]any{}},
+		{"subject", map[string]string{"subject_id": subjectID}, map[string]any{"d.subject_id": subjectID}},
+		{"due date from", map[string]string{"due_date_from": from}, map[string]any{"d.due_date >=": from}},
+		{"due date to", map[string]string{"due_date_to": to}, map[string]any{"d.due_date <=": to}},
+		{"date range", map[string]string{"due_date_from": from, "due_date_to": to},
+			map[string]any{"d.due_date >=": from, "d.due_date <=": to}},
+		{"all filters", map[string]string{"subject_id": subjectID, "due_date_from": from, "due_date_to": to},
+			map[string]any{"d.subject_id": subjectID, "d.due_date >=": from, "d.due_date <=": to}},
+		{"empty values are ignored", map[string]string{"subject_id": "", "due_date_from": ""}, map[string]any{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := services.DeadlinesQuery(tt.filterOptions, 50)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(query.Where, tt.expectedWhere) {
+				t.Errorf("Expected where %v, got %v", tt.expectedWhere, query.Where)
+			}
+			if query.Table != "deadlines" || query.Limit != 50 {
+				t.Errorf("Expected 50 rows from deadlines, got %d from %q", query.Limit, query.Table)
+			}
+			if len(query.Join) != 1 || !slices.Equal(query.Order, []string{"d.due_date ASC", "d.id ASC"}) {
+				t.Errorf("Expected the subject join and due date order, got %v and %v", query.Join, query.Order)
+			}
+			if err := query.Validate(); err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDeadlinesQueryTagFilter(t *testing.T) {
+	query, err := services.DeadlinesQuery(map[string]string{"tag": "  Exam "}, 50)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	value, ok := query.Where["d.tags @>"]
+	if !ok || len(query.Where) != 1 {
+		t.Fatalf("Expected an array contains condition on d.tags, got %v", query.Where)
+	}
+	appender, ok := value.(interface {
+		AppendValue(b []byte, flags int) ([]byte, error)
+	})
+	if !ok {
+		t.Fatalf("Expected a pg array value, got %T", value)
+	}
+	b, err := appender.AppendValue(nil, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(b) != `'{"exam"}'` {
+		t.Errorf("Expected the trimmed lower-case tag, got %s", b)
+	}
+
+	query, err = services.DeadlinesQuery(map[string]string{"tag": "  "}, 50)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(query.Where) != 0 {
+		t.Errorf("Expected a blank tag to be ignored, got %v", query.Where)
+	}
+}
+
+func TestNormalizeDeadlineTags(t *testing.T) {
+	tooLong := strings.Repeat("a", types.MaxDeadlineTagLength+1)
+	tooMany := make([]string, types.MaxDeadlineTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+
+	tests := []struct {
+		name         string
+		tags         []string
+		expectedTags []string
+		wantErr      bool
+	}{
+		{"nil", nil, []string{}, false},
+		{"trimmed and lower-cased", []string{" Exam", "HOMEWORK "}, []string{"exam", "homework"}, false},
+		{"empty and duplicates dropped", []string{"project", "", "  ", "Project", "exam"}, []string{"exam", "project"}, false},
+		{"maximum length", []string{strings.Repeat("a", types.MaxDeadlineTagLength)}, []string{strings.Repeat("a", types.MaxDeadlineTagLength)}, false},
+		{"too long", []string{tooLong}, nil, true},
+		{"too many", tooMany, nil, true},
+		{"duplicates do not count towards the maximum", slices.Repeat([]string{"exam"}, types.MaxDeadlineTags+1), []string{"exam"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := services.NormalizeDeadlineTags(tt.tags)
+			if tt.wantErr {
+				var appErr *lib.AppError
+				if !errors.As(err, &appErr) || appErr.Status != http.StatusUnprocessableEntity {
+					t.Fatalf("Expected a validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tags, tt.expectedTags) {
+				t.Errorf("Expected tags %v, got %v", tt.expectedTags, tags)
+			}
+		})
+	}
+}
+
+func TestDeadlineSortOrder(t *testing.T) {
+	loadTestConfig(t)
+
+	// Nothing listens on this address, the query fails to connect after its SQL was built
+	db := pg.Connect(&pg.Options{Addr: "127.0.0.1:1", MaxRetries: 0})
+	defer db.Close()
+
+	tests := []struct {
+		sort          string
+		expectedOrder string
+		wantErr       bool
+	}{
+		{"", `ORDER BY "d"."due_date" ASC, "d"."id" ASC`, false},
+		{"due_date", `ORDER BY "d"."due_date" ASC, "d"."id" ASC`, false},
+		{"due_date:desc", `ORDER BY "d"."due_date" DESC, "d"."id" ASC`, false},
+		{"created_at:asc", `ORDER BY "d"."created_at" ASC, "d"."due_date" ASC, "d"."id" ASC`, false},
+		{"title:DESC", `ORDER BY "d"."title" DESC, "d"."due_date" ASC, "d"."id" ASC`, false},
+		{"owner_id", "", true},
+		{"title:sideways", "", true},
+		{"due_date; DROP TABLE deadlines", "", true},
+		{"d.due_date", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			query, err := services.DeadlinesQuery(map[string]string{"sort": tt.sort}, 50)
+			if tt.wantErr {
+				if !errors.Is(err, lib.ErrInvalidSort) {
+					t.Errorf("Expected ErrInvalidSort, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			result, _ := database.ExecuteQuery[types.DeadlineWithSubject](query.WithDB(db))
+			if !strings.Contains(result.Query, tt.expectedOrder+" LIMIT") {
+				t.Errorf("Expected the SQL to contain %q, got %q", tt.expectedOrder, result.Query)
+			}
+		})
+	}
+}
+
+func TestDeadlinesQueryInvalidSortIsBadRequest(t *testing.T) {
+	loadTestConfig(t)
+
+	app := fiber.New()
+	app.Get("/deadlines/me", func(c fiber.Ctx) error {
+		if _, err := services.DeadlinesQuery(map[string]string{"sort": c.Query("sort")}, 50); err != nil {
+			return lib.HandleServiceError(c, err, "failed to fetch deadlines")
+		}
+		return c.SendStatus(http.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/deadlines/me?sort=password", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestParseWhereKey(t *testing.T) {
+	tests := []struct {
+		key              string
+		expectedColumn   string
+		expectedOperator string
+	}{
+		{"id", "id", "="},
+		{"public.users.id", "public.users.id", "="},
+		{"d.due_date >=", "d.due_date", ">="},
+		{"due_date <", "due_date", "<"},
+		{"name ilike", "name", "ILIKE"},
+		{"status <>", "status", "<>"},
+		{"d.tags @>", "d.tags", "@>"},
+		{"tags &&", "tags", "&&"},
+		{"weird column", "weird column", "="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			column, operator := types.ParseWhereKey(tt.key)
+			if column != tt.expectedColumn || operator != tt.expectedOperator {
+				t.Errorf("Expected %q %q, got %q %q", tt.expectedColumn, tt.expectedOperator, column, operator)
+			}
+		})
+	}
+}
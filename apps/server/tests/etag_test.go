@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"abc123"`
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{"empty header", "", false},
+		{"exact match", `"abc123"`, true},
+		{"weak match", `W/"abc123"`, true},
+		{"match in list", `"other", "abc123"`, true},
+		{"wildcard", "*", true},
+		{"no match", `"other"`, false},
+		{"unquoted", "abc123", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := response.ETagMatches(tt.ifNoneMatch, etag); got != tt.expected {
+				t.Errorf("Expected %v for If-None-Match %q, got %v", tt.expected, tt.ifNoneMatch, got)
+			}
+		})
+	}
+}
+
+func TestSuccessWithETag(t *testing.T) {
+	data := []string{"deadline-1", "deadline-2"}
+
+	app := fiber.New()
+	app.Get("/deadlines", func(c fiber.Ctx) error {
+		return response.SuccessWithETag(c, data)
+	})
+
+	// First request returns the full body and a tag
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/deadlines", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	etag := resp.Header.Get(fiber.HeaderETag)
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("Expected 200 with an ETag, got %d and %q", resp.StatusCode, etag)
+	}
+
+	tests := []struct {
+		name           string
+		ifNoneMatch    string
+		change         bool
+		expectedStatus int
+	}{
+		{"matching tag", etag, false, http.StatusNotModified},
+		{"stale tag", `"stale"`, false, http.StatusOK},
+		{"content changed", etag, true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.change {
+				data = append(data, "deadline-3")
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/deadlines", nil)
+			req.Header.Set(fiber.HeaderIfNoneMatch, tt.ifNoneMatch)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedStatus == http.StatusNotModified {
+				if len(body) != 0 {
+					t.Errorf("Expected an empty body for 304, got %q", body)
+				}
+				if resp.Header.Get(fiber.HeaderETag) != etag {
+					t.Errorf("Expected the 304 to repeat the ETag %q", etag)
+				}
+				return
+			}
+
+			if len(body) == 0 {
+				t.Error("Expected the full body")
+			}
+			if tt.change && resp.Header.Get(fiber.HeaderETag) == etag {
+				t.Error("Expected the ETag to change with the content")
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestGetQueryParamsFilterLimit(t *testing.T) {
+	loadTestConfig(t)
+
+	keys := map[string]bool{
+		"subject_id":    false,
+		"due_date_from": false,
+		"due_date_to":   false,
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		maxConditions  int
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"no filters", "", 2, http.StatusOK, 0},
+		{"within limit", "?subject_id=1&due_date_from=2025-01-01", 2, http.StatusOK, 2},
+		{"unknown keys are counted", "?subject_id=1&foo=bar&baz=qux", 2, http.StatusBadRequest, 0},
+		{"repeated keys are counted", "?subject_id=1&subject_id=2&subject_id=3", 2, http.StatusBadRequest, 0},
+		{"sorting and pagination are not counted", "?subject_id=1&sort=title&page=2&limit=10", 1, http.StatusOK, 1},
+		{"too many filters", "?subject_id=1&due_date_from=2025-01-01&due_date_to=2025-02-01", 2, http.StatusBadRequest, 0},
+		{"limit disabled", "?subject_id=1&due_date_from=2025-01-01&due_date_to=2025-02-01", 0, http.StatusOK, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := 0
+			app := fiber.New()
+			app.Get("/deadlines", func(c fiber.Ctx) error {
+				params, err := lib.GetQueryParamsWithLimit(c, keys, tt.maxConditions)
+				if err != nil {
+					return lib.HandleServiceError(c, err, "failed to get filter options")
+				}
+				count = len(params)
+				return c.SendStatus(http.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/deadlines"+tt.query, nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if resp.StatusCode == http.StatusOK && count != tt.expectedCount {
+				t.Errorf("expected %d applied filters, got %d", tt.expectedCount, count)
+			}
+		})
+	}
+}
+
+func TestGetQueryParamsUsesConfiguredLimit(t *testing.T) {
+	cfg := loadTestConfig(t)
+
+	keys := make(map[string]bool)
+	query := "?"
+	for i := range cfg.Server.MaxFilterConditions + 1 {
+		key := string(rune('a' + i))
+		keys[key] = false
+		query += key + "=1&"
+	}
+
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		_, err := lib.GetQueryParams(c, keys)
+		if !errors.Is(err, lib.ErrTooManyFilters) {
+			t.Errorf("expected ErrTooManyFilters, got %v", err)
+		}
+		return c.SendStatus(http.StatusNoContent)
+	})
+
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/"+query, nil)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+}
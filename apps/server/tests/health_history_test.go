@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/google/uuid"
+)
+
+func TestBuildHealthHistoryConditions(t *testing.T) {
+	from := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(6 * time.Hour)
+
+	sql, args := services.BuildHealthHistoryConditions("auth", from, to)
+
+	expectedSQL := "health_logs.service = ? AND health_logs.timestamp >= ? AND health_logs.timestamp <= ?"
+	if sql != expectedSQL {
+		t.Errorf("Expected SQL %q, got %q", expectedSQL, sql)
+	}
+	if expectedArgs := []any{"auth", from, to}; !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+func TestValidateHealthHistoryRange(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		service string
+		from    time.Time
+		to      time.Time
+		wantErr bool
+	}{
+		{"valid range", "auth", now.Add(-time.Hour), now, false},
+		{"longest range", "auth", now.Add(-services.MaxHealthHistoryRange), now, false},
+		{"missing service", "", now.Add(-time.Hour), now, true},
+		{"missing from", "auth", time.Time{}, now, true},
+		{"reversed range", "auth", now, now.Add(-time.Hour), true},
+		{"range too long", "auth", now.Add(-services.MaxHealthHistoryRange - time.Second), now, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := services.ValidateHealthHistoryRange(tt.service, tt.from, tt.to)
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestQueryServiceHistoryRejectsInvalidRange(t *testing.T) {
+	hs := &services.HealthService{}
+	now := time.Now()
+
+	if _, err := hs.QueryServiceHistory("auth", now, now.Add(-time.Hour)); !errors.Is(err, lib.ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+// TestQueryServiceHistory filters stored health logs by service and time range against a real database
+func TestQueryServiceHistory(t *testing.T) {
+	requireDatabase(t)
+
+	// Unique service names keep the test independent of existing rows
+	target := "history-test-" + uuid.NewString()
+	other := "history-test-" + uuid.NewString()
+	base := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+
+	rows := []struct {
+		service string
+		offset  time.Duration
+	}{
+		{target, 0},                // before the range
+		{target, 10 * time.Minute}, // in range
+		{target, 20 * time.Minute}, // in range
+		{other, 15 * time.Minute},  // in range, other service
+		{target, 40 * time.Minute}, // after the range
+	}
+	for i, row := range rows {
+		query := services.Query().SetOperation("insert").SetTable(lib.TableHealthLogs).SetData(map[string]any{
+			"timestamp":       base.Add(row.offset),
+			"service":         row.service,
+			"status_code":     200,
+			"request_count":   i + 1,
+			"error_count":     0,
+			"average_latency": 12.5,
+			"time_span":       60,
+		})
+		if _, err := database.ExecuteQuery[any](query); err != nil {
+			t.Skipf("Failed to create health log fixture: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		query := services.Query().SetOperation("delete").SetTable(lib.TableHealthLogs).
+			SetWhereRaw("health_logs.service IN (?, ?)", target, other)
+		if _, err := database.ExecuteQuery[any](query); err != nil {
+			t.Logf("Failed to clean up health logs: %v", err)
+		}
+	})
+
+	hs := services.NewHealthService()
+	logs, err := hs.QueryServiceHistory(target, base.Add(5*time.Minute), base.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(logs) != 2 {
+		t.Fatalf("Expected 2 health logs in range for the service, got %d", len(logs))
+	}
+	for i, expected := range []int64{2, 3} {
+		if logs[i].Service != target || logs[i].RequestCount != expected {
+			t.Errorf("Expected log %d of %s with request count %d, got %s with %d", i, target, expected, logs[i].Service, logs[i].RequestCount)
+		}
+	}
+	if logs[0].AverageLatency != 12500*time.Microsecond || logs[0].TimeSpan != time.Minute {
+		t.Errorf("Expected stored units to convert back to durations, got latency %v and span %v", logs[0].AverageLatency, logs[0].TimeSpan)
+	}
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/api"
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// recordedMetric is a request recorded by the health middleware, Path is empty for service rollups
+type recordedMetric struct {
+	Method  string
+	Path    string
+	Service string
+	Status  int
+}
+
+type metricsRecorder struct {
+	mu      sync.Mutex
+	metrics []recordedMetric
+}
+
+func (r *metricsRecorder) RecordRouteMetric(method, path string, statusCode int, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, recordedMetric{Method: method, Path: path, Status: statusCode})
+}
+
+func (r *metricsRecorder) RecordHealthMetric(serviceName string, statusCode int, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, recordedMetric{Service: serviceName, Status: statusCode})
+}
+
+func TestHealthMetricsMiddlewareRejections(t *testing.T) {
+	recorder := &metricsRecorder{}
+
+	app := fiber.New()
+	app.Use(middleware.NewHealthMetrics(recorder))
+	// Stands in for the global rate limiter
+	app.Use(func(c fiber.Ctx) error {
+		if c.Get("X-Limited") != "" {
+			return c.SendStatus(fiber.StatusTooManyRequests)
+		}
+		return c.Next()
+	})
+
+	// Stands in for the auth middleware of a route group
+	deadlines := app.Group("/deadlines", func(c fiber.Ctx) error {
+		if c.Get(fiber.HeaderAuthorization) == "" {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		return c.Next()
+	})
+	deadlines.Get("/", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	deadlines.Get("/me", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	// Stands in for a group mounted at the root, like the subject routes
+	protected := app.Group("/", func(c fiber.Ctx) error {
+		if c.Get(fiber.HeaderAuthorization) == "" {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		return c.Next()
+	})
+	protected.Get("/subjects/:id", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	tests := []struct {
+		name     string
+		path     string
+		headers  map[string]string
+		expected recordedMetric
+	}{
+		{
+			name:     "handler route",
+			path:     "/deadlines/me",
+			headers:  map[string]string{fiber.HeaderAuthorization: "Bearer token"},
+			expected: recordedMetric{Method: http.MethodGet, Path: "/deadlines/me", Status: fiber.StatusOK},
+		},
+		{
+			name:     "group middleware rejection",
+			path:     "/deadlines/me",
+			expected: recordedMetric{Service: "deadlines", Status: fiber.StatusUnauthorized},
+		},
+		{
+			name:     "root group rejection",
+			path:     "/subjects/1",
+			expected: recordedMetric{Service: "subjects", Status: fiber.StatusUnauthorized},
+		},
+		{
+			name:     "global middleware rejection",
+			path:     "/deadlines",
+			headers:  map[string]string{"X-Limited": "1"},
+			expected: recordedMetric{Service: "deadlines", Status: fiber.StatusTooManyRequests},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.mu.Lock()
+			recorder.metrics = nil
+			recorder.mu.Unlock()
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			recorder.mu.Lock()
+			defer recorder.mu.Unlock()
+			if len(recorder.metrics) != 1 || recorder.metrics[0] != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, recorder.metrics)
+			}
+		})
+	}
+}
+
+func TestPrometheusMetricsRequireAdmin(t *testing.T) {
+	loadTestConfig(t)
+
+	app := fiber.New()
+	api.SetupRoutes(app, config.SetupLogger())
+
+	request := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := request(""); status != http.StatusUnauthorized {
+		t.Errorf("Expected anonymous scrapes to be rejected with %d, got %d", http.StatusUnauthorized, status)
+	}
+
+	// The admin middleware checks the token blacklist, which retries for longer than a test request without Redis
+	if err := services.NewCacheService().Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	as := services.NewAuthService()
+	for role, expected := range map[string]int{lib.RoleStudent: http.StatusForbidden, lib.RoleAdmin: http.StatusOK} {
+		user := &types.User{Id: uuid.New(), Username: "metrics-" + role, Email: "metrics-" + role + "@example.com", Role: role}
+		accessToken, err := as.GenerateAccessToken(user)
+		if err != nil {
+			t.Fatalf("Failed to generate access token: %v", err)
+		}
+		if status := request(accessToken); status != expected {
+			t.Errorf("Expected status %d for a %s, got %d", expected, role, status)
+		}
+	}
+}
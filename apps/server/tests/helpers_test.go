@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+)
+
+// loadTestConfig loads the application configuration, providing the required
+// secrets when they are not set so tests do not depend on a local .env file
+func loadTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	for key, value := range map[string]string{
+		"ACCESS_TOKEN_SECRET":  "test-access-token-secret",
+		"REFRESH_TOKEN_SECRET": "test-refresh-token-secret",
+	} {
+		if os.Getenv(key) == "" {
+			t.Setenv(key, value)
+		}
+	}
+
+	return config.Load()
+}
+
+// requireDatabase loads the configuration and connects to the database, skipping the test when it is not available
+func requireDatabase(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := loadTestConfig(t)
+
+	if err := database.Initialize(); err != nil {
+		t.Skipf("Database not available: %v", err)
+	}
+	if err := services.Ping(); err != nil {
+		t.Skipf("Database not reachable: %v", err)
+	}
+	return cfg
+}
+
+// insertFixture inserts a row for a test and fails the test when the insert does
+func insertFixture(t *testing.T, table string, data map[string]any) {
+	t.Helper()
+
+	query := services.Query().SetOperation("insert").SetTable(table).SetData(data)
+	if _, err := database.ExecuteQuery[any](query); err != nil {
+		t.Fatalf("Failed to create %s fixture: %v", table, err)
+	}
+}
+
+// deleteFixture removes a row by id when the test ends
+func deleteFixture(t *testing.T, table string, id uuid.UUID) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		query := services.Query().SetOperation("delete").SetTable(table).SetWhereRaw(table+".id = ?", id)
+		if _, err := database.ExecuteQuery[any](query); err != nil {
+			t.Logf("Failed to clean up %s %s: %v", table, id, err)
+		}
+	})
+}
+
+// createTestUser inserts a user named test-user-<id> with the given role, removed when the test ends
+func createTestUser(t *testing.T, role string) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	insertFixture(t, lib.TableUsers, map[string]any{
+		"id":            id,
+		"username":      "test-user-" + id.String()[:8],
+		"email":         "test-user-" + id.String()[:8] + "@example.com",
+		"role":          role,
+		"password_hash": "unused",
+	})
+	deleteFixture(t, lib.TableUsers, id)
+	return id
+}
+
+// createTestSubject inserts an active subject taught by the given teachers, removed when the test ends.
+// Deleting the subject cascades to its teacher mappings, deadlines and submissions.
+func createTestSubject(t *testing.T, name string, teacherIDs ...uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	insertFixture(t, lib.TableSubjects, map[string]any{"id": id, "name": name, "is_active": true})
+	deleteFixture(t, lib.TableSubjects, id)
+	for _, teacherID := range teacherIDs {
+		insertFixture(t, lib.TableSubjectTeachers, map[string]any{"subject_id": id, "user_id": teacherID})
+	}
+	return id
+}
+
+// createTestDeadline inserts a deadline on the subject owned by the teacher, due in an hour
+func createTestDeadline(t *testing.T, subjectID, ownerID uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	id := uuid.New()
+	insertFixture(t, lib.TableDeadlines, map[string]any{
+		"id":         id,
+		"subject_id": subjectID,
+		"owner_id":   ownerID,
+		"title":      "Test deadline",
+		"due_date":   time.Now().Add(time.Hour),
+	})
+	deleteFixture(t, lib.TableDeadlines, id)
+	return id
+}
+
+// createTestSubmission inserts a submission of the student on the deadline
+func createTestSubmission(t *testing.T, deadlineID, studentID uuid.UUID) {
+	t.Helper()
+
+	insertFixture(t, lib.TableSubmissions, map[string]any{
+		"deadline_id": deadlineID,
+		"student_id":  studentID,
+		"file_ids":    pg.Array([]string{"file-1"}),
+	})
+}
@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+)
+
+func TestBulkInsertRejectsInvalidIdentifiers(t *testing.T) {
+	entries := []any{map[string]any{"username": "john"}}
+
+	tests := []struct {
+		name      string
+		table     string
+		columns   []string
+		returning []string
+		wantErr   bool
+	}{
+		{"plain table", "users", []string{"username"}, nil, false},
+		{"schema qualified table", "public.users", []string{"username"}, nil, false},
+		{"quoted table", `"Users"`, []string{"username"}, nil, false},
+		{"underscores and digits", "user_subjects2", []string{"user_id"}, []string{"id"}, false},
+		{"statement injection in table", "users; DROP TABLE users", []string{"username"}, nil, true},
+		{"comment in table", "users--", []string{"username"}, nil, true},
+		{"quote breaking out of identifier", `"users"" ; DROP TABLE users; --"`, []string{"username"}, nil, true},
+		{"empty quoted identifier", `""`, []string{"username"}, nil, true},
+		{"too many parts", "a.b.c.d", []string{"username"}, nil, true},
+		{"empty part", "public..users", []string{"username"}, nil, true},
+		{"leading digit", "1users", []string{"username"}, nil, true},
+		{"whitespace", "users u", []string{"username"}, nil, true},
+		{"injection in column", "users", []string{"username) VALUES ('x'); DROP TABLE users; --"}, nil, true},
+		{"injection in returning", "users", []string{"username"}, []string{"id; DROP TABLE users"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := database.BuildBulkInsertSQL(tt.table, entries, tt.columns, tt.returning, "")
+			if tt.wantErr {
+				if !errors.Is(err, types.ErrInvalidIdentifier) {
+					t.Errorf("Expected ErrInvalidIdentifier, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Expected %q to be accepted, got %v", tt.table, err)
+			}
+		})
+	}
+}
+
+func TestExecuteQueryRejectsInvalidIdentifiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *types.QueryParams
+	}{
+		{"select table", types.NewQuery().SetOperation("select").SetTable("users; DROP TABLE users")},
+		{"select column", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"id, (SELECT password_hash FROM users)"})},
+		{"group by column", &types.QueryParams{Operation: "select", Table: "users", GroupBy: []string{"role; DROP TABLE users"}}},
+		{"insert table", types.NewQuery().SetOperation("insert").SetTable("users; DROP TABLE users").SetData(map[string]any{"username": "john"})},
+		{"insert column", types.NewQuery().SetOperation("insert").SetTable("users").SetData(map[string]any{"username) VALUES ('x'); --": "john"})},
+		{"returning column", types.NewQuery().SetOperation("insert").SetTable("users").SetData(map[string]any{"username": "john"}).SetReturning("id; DROP TABLE users")},
+		{"delete table", types.NewQuery().SetOperation("delete").SetTable("users; DROP TABLE users").AddWhere("id", 1)},
+		{"subquery behind alias", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"(SELECT password_hash FROM users) AS x"})},
+		{"subquery in function", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"lower((select password_hash from users)) AS x"})},
+		{"statement after function", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"COUNT(*); DROP TABLE users; -- AS count"})},
+		{"text after function", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"lower(email) || password_hash AS email"})},
+		{"invalid alias", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"email AS e; DROP TABLE users"})},
+		{"text between parentheses", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"(role = 'admin') OR (password_hash <> '') AS x"})},
+		{"comment in parentheses", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"(xmax = 0 /* x */) AS inserted"})},
+		{"function outside the allowlist", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"pg_sleep(10) AS x"})},
+		{"call nested in allowed function", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"COALESCE(pg_sleep(10), 0) AS x"})},
+		{"call in parentheses", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"(pg_sleep(10)) AS x"})},
+		{"function in order", types.NewQuery().SetOperation("select").SetTable("users").AddOrder("pg_sleep(1)")},
+		{"injection after order direction", types.NewQuery().SetOperation("select").SetTable("users").AddOrder("id ASC; DROP TABLE users")},
+		{"injection in prefixed where key", types.NewQuery().SetOperation("select").SetTable("users").AddWhere("users.id = 1 OR users.id", 2)},
+		{"injection in where any key", types.NewQuery().SetOperation("select").SetTable("users").AddWhereAny(map[string]any{"users.email ILIKE": "x", "1=1; --": "y"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Identifiers are checked before a connection is used, so no database is needed
+			_, err := database.ExecuteQuery[any](tt.query)
+			if !errors.Is(err, types.ErrInvalidIdentifier) {
+				t.Errorf("Expected ErrInvalidIdentifier, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExecuteQueryAcceptsColumnExpressions(t *testing.T) {
+	loadTestConfig(t)
+
+	// Nothing listens on this address, a query that passes validation fails to connect instead
+	db := pg.Connect(&pg.Options{Addr: "127.0.0.1:1", MaxRetries: 0})
+	defer db.Close()
+
+	tests := []struct {
+		name  string
+		query *types.QueryParams
+	}{
+		{"aliased column", types.NewQuery().SetOperation("select").SetTable("deadlines").SetSelect([]string{"s.name AS subject__name"})},
+		{"aggregate", types.NewQuery().SetOperation("select").SetTable("users").SetSelect([]string{"COUNT(*) AS count"})},
+		{"function in returning", types.NewQuery().SetOperation("insert").SetTable("submissions").SetData(map[string]any{"message": "hi"}).
+			SetReturning(`to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"') AS created_at`)},
+		{"parenthesized expression in returning", types.NewQuery().SetOperation("insert").SetTable("submissions").SetData(map[string]any{"message": "hi"}).
+			SetReturning("(xmax = 0) AS inserted")},
+		{"coalesce", types.NewQuery().SetOperation("select").SetTable("submissions").SetSelect([]string{"COALESCE(feedback, '') AS feedback"})},
+		{"order with direction", types.NewQuery().SetOperation("select").SetTable("deadlines").AddOrder("d.due_date DESC").AddOrder("d.id")},
+		{"order with nulls last", types.NewQuery().SetOperation("select").SetTable("deadlines").AddOrder("due_date asc nulls last")},
+		{"prefixed where keys", types.NewQuery().SetOperation("select").SetTable("users").AddWhere("public.users.id", 1).
+			AddWhereContainsAny("x", "users.username", "users.email")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := database.ExecuteQuery[any](tt.query.WithDB(db))
+			if err == nil {
+				t.Fatal("Expected the query to fail without a database")
+			}
+			if errors.Is(err, types.ErrInvalidIdentifier) {
+				t.Errorf("Expected the column expression to be accepted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDeadlinesQueryPassesIdentifierValidation(t *testing.T) {
+	loadTestConfig(t)
+
+	// Nothing listens on this address, a query that passes validation fails to connect instead
+	db := pg.Connect(&pg.Options{Addr: "127.0.0.1:1", MaxRetries: 0})
+	defer db.Close()
+
+	filterOptions := map[string]string{
+		"subject_id":    "00000000-0000-0000-0000-000000000001",
+		"due_date_from": "2025-01-01",
+		"due_date_to":   "2025-12-31",
+		"tag":           "exam",
+		"sort":          "due_date:desc",
+	}
+
+	query, err := services.DeadlinesQuery(filterOptions, 50)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err = database.ExecuteQuery[types.DeadlineWithSubject](query.WithDB(db))
+	if err == nil {
+		t.Fatal("Expected the query to fail without a database")
+	}
+	if errors.Is(err, types.ErrInvalidIdentifier) {
+		t.Errorf("Expected the deadline listing to pass identifier validation, got %v", err)
+	}
+}
+
+func TestSubmissionUpsertPassesIdentifierValidation(t *testing.T) {
+	loadTestConfig(t)
+
+	// Nothing listens on this address, a query that passes validation fails to connect instead
+	db := pg.Connect(&pg.Options{Addr: "127.0.0.1:1", MaxRetries: 0})
+	defer db.Close()
+
+	req := types.CreateSubmissionRequest{FileIDs: []string{"file-1"}, Message: "hi"}
+	query := services.SubmissionUpsertQuery(uuid.New(), uuid.New(), req, time.Now().UTC().Format(time.RFC3339))
+
+	_, err := database.ExecuteQuery[types.Submission](query.WithDB(db))
+	if err == nil {
+		t.Fatal("Expected the query to fail without a database")
+	}
+	if errors.Is(err, types.ErrInvalidIdentifier) {
+		t.Errorf("Expected the submission upsert to pass identifier validation, got %v", err)
+	}
+}
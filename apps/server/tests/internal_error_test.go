@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestErrorChain(t *testing.T) {
+	base := errors.New("connection refused")
+	wrapped := fmt.Errorf("query deadlines: %w", base)
+
+	expected := []string{"query deadlines: connection refused", "connection refused"}
+	if chain := response.ErrorChain(wrapped); !reflect.DeepEqual(chain, expected) {
+		t.Errorf("Expected chain %v, got %v", expected, chain)
+	}
+
+	joined := errors.Join(errors.New("first"), wrapped)
+	expected = []string{joined.Error(), "first", "query deadlines: connection refused", "connection refused"}
+	if chain := response.ErrorChain(joined); !reflect.DeepEqual(chain, expected) {
+		t.Errorf("Expected chain %v, got %v", expected, chain)
+	}
+}
+
+func TestInternalServerErrorForEnvironment(t *testing.T) {
+	tests := []struct {
+		environment string
+		withDetails bool
+	}{
+		{"development", true},
+		{"staging", true},
+		{"production", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.environment, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(middleware.NewRequestID())
+			app.Get("/fail", func(c fiber.Ctx) error {
+				err := fmt.Errorf("load deadline: %w", errors.New("database unavailable"))
+				return response.InternalServerErrorForEnvironment(c, tt.environment, "Something went wrong", err)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+			req.Header.Set("X-Request-ID", "req-123")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusInternalServerError {
+				t.Fatalf("Expected status 500, got %d", resp.StatusCode)
+			}
+
+			var body types.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Error == nil || body.Error.Message != "Something went wrong" {
+				t.Fatalf("Expected the error message in the response, got %+v", body.Error)
+			}
+
+			details := body.Error.Details
+			if !tt.withDetails {
+				if len(details) != 0 {
+					t.Errorf("Expected no details in production, got %v", details)
+				}
+				return
+			}
+
+			if details["request_id"] != "req-123" {
+				t.Errorf("Expected request_id req-123, got %v", details["request_id"])
+			}
+			chain, _ := details["error_chain"].([]any)
+			if len(chain) != 2 || chain[1] != "database unavailable" {
+				t.Errorf("Expected the error chain in the details, got %v", details["error_chain"])
+			}
+		})
+	}
+}
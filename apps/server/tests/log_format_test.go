@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestLogFormatValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		wantErr bool
+	}{
+		{"text", config.LogFormatText, false},
+		{"json", config.LogFormatJSON, false},
+		{"empty", "", true},
+		{"unknown", "logfmt", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := config.LoadDomainConfigs()
+			domains.App.LogFormat = tt.format
+
+			err := domains.App.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestJSONLogHandlerHTTPMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &config.Logger{Logger: slog.New(config.NewLogHandler(&buf, config.LogFormatJSON, nil)).With("app", "PWS")}
+
+	app := fiber.New()
+	app.Use(middleware.NewRequestID())
+	app.Use(logger.HTTPMiddleware())
+	app.Get("/json", func(c fiber.Ctx) error {
+		return c.SendStatus(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/json", nil)
+	req.Header.Set(config.RequestIDHeader, "json-req-1")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q: %v", buf.String(), err)
+	}
+
+	expected := map[string]any{
+		"app":        "PWS",
+		"method":     http.MethodGet,
+		"path":       "/json",
+		"status":     float64(http.StatusNoContent),
+		"request_id": "json-req-1",
+	}
+	for key, want := range expected {
+		if entry[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, entry[key])
+		}
+	}
+	if _, ok := entry["duration"].(float64); !ok {
+		t.Errorf("expected a numeric duration, got %v", entry["duration"])
+	}
+}
+
+func TestTextLogHandlerFallback(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(config.NewLogHandler(&buf, config.LogFormatText, nil))
+	logger.Info("plain text", "key", "value")
+
+	if strings.HasPrefix(buf.String(), "{") || !strings.Contains(buf.String(), "key=value") {
+		t.Errorf("expected text output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/config"
+)
+
+func TestFormatSource(t *testing.T) {
+	if source := config.CallerSource(0); !strings.HasPrefix(source, "tests/log_source_test.go:") {
+		t.Errorf("Expected a module-relative source, got %q", source)
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"standard library", "/usr/local/go/src/net/http/server.go", "http/server.go:42"},
+		{"dependency", "/go/pkg/mod/github.com/go-pg/pg/v10@v10.11.0/db.go", "v10@v10.11.0/db.go:42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.FormatSource(tt.file, 42); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAuditLogSourceToggle(t *testing.T) {
+	capture := &auditCapture{}
+	config.SetAuditLogFunc(capture.add)
+
+	tests := []struct {
+		name       string
+		logSource  bool
+		wantPrefix string
+	}{
+		{"enabled", true, "tests/log_source_test.go:"},
+		{"disabled", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Error level keeps the standard log line off stdout, the audit entry is still sent
+			logger := config.NewLogger(&config.Config{AppName: "PWS", LogLevel: "error", LogSource: tt.logSource})
+			logger.AuditInfo("Log source toggle test")
+
+			logs := capture.take("Log source toggle test")
+			if len(logs) != 1 {
+				t.Fatalf("Expected 1 audit entry, got %d", len(logs))
+			}
+			if source := logs[0].Source; !strings.HasPrefix(source, tt.wantPrefix) || (tt.wantPrefix == "") != (source == "") {
+				t.Errorf("Expected source with prefix %q, got %q", tt.wantPrefix, source)
+			}
+		})
+	}
+}
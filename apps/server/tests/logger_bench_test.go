@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"os"
+	"testing"
+
+	"github.com/MonkyMars/PWS/config"
+)
+
+func TestSetupLoggerIsShared(t *testing.T) {
+	loadTestConfig(t)
+
+	if config.SetupLogger() != config.SetupLogger() {
+		t.Error("Expected SetupLogger to return the same logger on every call")
+	}
+	if config.NewLogger(config.Get()) == config.SetupLogger() {
+		t.Error("Expected NewLogger to build a new logger")
+	}
+}
+
+// BenchmarkNewLogger measures building a logger on every call, as SetupLogger did before it was shared
+func BenchmarkNewLogger(b *testing.B) {
+	cfg := loadBenchmarkConfig(b)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = config.NewLogger(cfg)
+	}
+}
+
+// BenchmarkSetupLogger measures the shared logger, which should not allocate after the first call
+func BenchmarkSetupLogger(b *testing.B) {
+	loadBenchmarkConfig(b)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = config.SetupLogger()
+	}
+}
+
+// loadBenchmarkConfig loads the application configuration with the required test secrets
+func loadBenchmarkConfig(b *testing.B) *config.Config {
+	b.Helper()
+
+	for key, value := range map[string]string{
+		"ACCESS_TOKEN_SECRET":  "test-access-token-secret",
+		"REFRESH_TOKEN_SECRET": "test-refresh-token-secret",
+	} {
+		if os.Getenv(key) == "" {
+			b.Setenv(key, value)
+		}
+	}
+
+	return config.Load()
+}
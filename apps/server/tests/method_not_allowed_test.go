@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	loadTestConfig(t)
+
+	app := fiber.New(config.SetupFiber())
+	app.Get("/subjects", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Post("/subjects", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/subjects", nil))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != fiber.StatusMethodNotAllowed {
+		t.Fatalf("Expected status %d, got %d", fiber.StatusMethodNotAllowed, resp.StatusCode)
+	}
+
+	allow := resp.Header.Get(fiber.HeaderAllow)
+	if !strings.Contains(allow, http.MethodGet) || !strings.Contains(allow, http.MethodPost) {
+		t.Errorf("Expected the Allow header to list GET and POST, got %q", allow)
+	}
+
+	assertErrorCode(t, resp, fiber.StatusMethodNotAllowed, response.ErrCodeMethodNotAllowed)
+}
+
+func TestClientErrorResponseHelpers(t *testing.T) {
+	tests := []struct {
+		name           string
+		send           func(c fiber.Ctx) error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "method not allowed",
+			send:           func(c fiber.Ctx) error { return response.MethodNotAllowed(c, "") },
+			expectedStatus: fiber.StatusMethodNotAllowed,
+			expectedCode:   response.ErrCodeMethodNotAllowed,
+		},
+		{
+			name:           "payload too large",
+			send:           func(c fiber.Ctx) error { return response.PayloadTooLarge(c, "") },
+			expectedStatus: fiber.StatusRequestEntityTooLarge,
+			expectedCode:   response.ErrCodePayloadTooLarge,
+		},
+		{
+			name:           "unsupported media type",
+			send:           func(c fiber.Ctx) error { return response.UnsupportedMediaType(c, "") },
+			expectedStatus: fiber.StatusUnsupportedMediaType,
+			expectedCode:   response.ErrCodeUnsupportedMedia,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", tt.send)
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			assertErrorCode(t, resp, tt.expectedStatus, tt.expectedCode)
+		})
+	}
+}
+
+// assertErrorCode checks the status and the error code of a JSON error response
+func assertErrorCode(t *testing.T, resp *http.Response, expectedStatus int, expectedCode string) {
+	t.Helper()
+
+	if resp.StatusCode != expectedStatus {
+		t.Errorf("Expected status %d, got %d", expectedStatus, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+
+	var apiResponse types.Response
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		t.Fatalf("Expected a JSON error response, got %q: %v", body, err)
+	}
+	if apiResponse.Success || apiResponse.Error == nil || apiResponse.Error.Code != expectedCode || apiResponse.Error.Message == "" {
+		t.Errorf("Expected error code %s with a message, got %+v", expectedCode, apiResponse.Error)
+	}
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/MonkyMars/PWS/workers"
+	"github.com/google/uuid"
+)
+
+func TestRedisNotificationStore(t *testing.T) {
+	loadTestConfig(t)
+
+	if err := services.NewCacheService().Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	ctx := context.Background()
+	client := services.GetRedisClient()
+	key := "test:dead_letter:notifications:" + uuid.NewString()
+	t.Cleanup(func() { client.Del(context.Background(), key) })
+
+	store := workers.NewRedisNotificationStore(client, key, 2, config.SetupLogger())
+
+	entries := make([]*workers.DeadLetterEntry[types.Notification], 3)
+	for i := range entries {
+		entries[i] = &workers.DeadLetterEntry[types.Notification]{
+			Item:      types.Notification{ID: uuid.New(), Type: types.NotificationSubmissionReceived},
+			LastError: "mail server unavailable",
+			Attempts:  i,
+		}
+	}
+
+	// The oldest entry is dropped to stay within the size limit
+	dropped, err := store.Push(ctx, entries)
+	if err != nil {
+		t.Fatalf("Failed to push entries: %v", err)
+	}
+	if dropped != 1 {
+		t.Errorf("Expected 1 dropped entry, got %d", dropped)
+	}
+	if length, err := store.Len(ctx); err != nil || length != 2 {
+		t.Fatalf("Expected 2 stored entries, got %d (%v)", length, err)
+	}
+
+	popped, err := store.Pop(ctx, 10)
+	if err != nil {
+		t.Fatalf("Failed to pop entries: %v", err)
+	}
+	if len(popped) != 2 || popped[0].Item.ID != entries[1].Item.ID || popped[1].Item.ID != entries[2].Item.ID {
+		t.Fatalf("Expected the two newest entries oldest first, got %+v", popped)
+	}
+	if popped[1].Attempts != 2 || popped[1].LastError != "mail server unavailable" {
+		t.Errorf("Expected the retry state to be stored with the entry, got %+v", popped[1])
+	}
+
+	// An empty queue pops nothing without an error
+	if popped, err := store.Pop(ctx, 10); err != nil || len(popped) != 0 {
+		t.Errorf("Expected nothing from an empty queue, got %d entries (%v)", len(popped), err)
+	}
+}
@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+func TestResolveRefreshToken(t *testing.T) {
+	testCases := []struct {
+		name        string
+		token       *oauth2.Token
+		existing    string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "New refresh token is used",
+			token:    &oauth2.Token{AccessToken: "access", RefreshToken: "new-refresh"},
+			expected: "new-refresh",
+		},
+		{
+			name:     "New refresh token replaces existing one",
+			token:    &oauth2.Token{AccessToken: "access", RefreshToken: "new-refresh"},
+			existing: "old-refresh",
+			expected: "new-refresh",
+		},
+		{
+			name:     "Missing refresh token keeps existing link",
+			token:    &oauth2.Token{AccessToken: "access"},
+			existing: "old-refresh",
+			expected: "old-refresh",
+		},
+		{
+			name:        "Missing refresh token without existing link requires reconsent",
+			token:       &oauth2.Token{AccessToken: "access"},
+			expectedErr: lib.ErrOAuthReconsentRequired,
+		},
+		{
+			name:        "Nil token without existing link requires reconsent",
+			token:       nil,
+			expectedErr: lib.ErrOAuthReconsentRequired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := services.ResolveRefreshToken(tc.token, tc.existing)
+
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tc.expectedErr, err)
+				}
+				if result != "" {
+					t.Errorf("Expected no refresh token to be stored, got %q", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected refresh token %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestBuildRefreshTokenUpsert(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("Empty token is rejected", func(t *testing.T) {
+		for _, token := range []string{"", "   "} {
+			query, err := services.BuildRefreshTokenUpsert(services.OAuthProviderGoogle, userID, token)
+			if !errors.Is(err, lib.ErrEmptyRefreshToken) {
+				t.Errorf("Expected ErrEmptyRefreshToken for %q, got %v", token, err)
+			}
+			if query != nil {
+				t.Errorf("Expected no query for %q", token)
+			}
+		}
+	})
+
+	t.Run("Existing token is preserved on conflict", func(t *testing.T) {
+		query, err := services.BuildRefreshTokenUpsert(services.OAuthProviderGoogle, userID, "refresh-token")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if query.Data["refresh_token"] != "refresh-token" {
+			t.Errorf("Expected refresh_token to be set, got %v", query.Data["refresh_token"])
+		}
+		if query.Data["provider"] != services.OAuthProviderGoogle {
+			t.Errorf("Expected provider %q, got %v", services.OAuthProviderGoogle, query.Data["provider"])
+		}
+
+		// The upsert may only overwrite the stored token with a non-empty one
+		if !strings.Contains(query.OnConflict, "WHERE EXCLUDED.refresh_token <> ''") {
+			t.Errorf("Expected upsert to guard against empty tokens, got %q", query.OnConflict)
+		}
+	})
+}
+
+func TestTokenRefreshGroupSharesConcurrentRefreshes(t *testing.T) {
+	const callers = 20
+
+	var group services.TokenRefreshGroup
+	var refreshes atomic.Int32
+	release := make(chan struct{})
+
+	refresh := func() (*oauth2.Token, error) {
+		refreshes.Add(1)
+		<-release
+		return &oauth2.Token{AccessToken: "shared-access"}, nil
+	}
+
+	userID := uuid.New()
+	var ready, done sync.WaitGroup
+	tokens := make([]*oauth2.Token, callers)
+	errs := make([]error, callers)
+	ready.Add(callers)
+	done.Add(callers)
+	for i := range callers {
+		go func() {
+			defer done.Done()
+			ready.Done()
+			tokens[i], errs[i] = group.Do(services.OAuthProviderGoogle, userID, refresh)
+		}()
+	}
+
+	// Give every caller time to join the in-flight refresh before it completes
+	ready.Wait()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	if got := refreshes.Load(); got != 1 {
+		t.Fatalf("Expected 1 token refresh for %d concurrent callers, got %d", callers, got)
+	}
+	for i := range callers {
+		if errs[i] != nil {
+			t.Fatalf("Caller %d got unexpected error: %v", i, errs[i])
+		}
+		if tokens[i] == nil || tokens[i].AccessToken != "shared-access" {
+			t.Fatalf("Caller %d did not receive the shared token: %+v", i, tokens[i])
+		}
+	}
+}
+
+func TestTokenRefreshGroupKeepsUsersSeparate(t *testing.T) {
+	var group services.TokenRefreshGroup
+	refreshErr := errors.New("invalid_grant")
+
+	token, err := group.Do(services.OAuthProviderGoogle, uuid.New(), func() (*oauth2.Token, error) {
+		return &oauth2.Token{AccessToken: "first"}, nil
+	})
+	if err != nil || token.AccessToken != "first" {
+		t.Fatalf("Expected first user's token, got %+v and %v", token, err)
+	}
+
+	token, err = group.Do(services.OAuthProviderGoogle, uuid.New(), func() (*oauth2.Token, error) {
+		return nil, refreshErr
+	})
+	if !errors.Is(err, refreshErr) || token != nil {
+		t.Errorf("Expected the second user's refresh error, got %+v and %v", token, err)
+	}
+}
+
+func TestOAuthServiceAlwaysRegistersGoogle(t *testing.T) {
+	loadTestConfig(t)
+
+	// The /auth/google routes go through the provider map, so Google must be there without GOOGLE_CLIENT_ID too
+	oas := services.NewOAuthService()
+	if _, err := oas.Provider(services.OAuthProviderGoogle); err != nil {
+		t.Errorf("Expected the Google provider to be registered, got %v", err)
+	}
+	if !slices.Contains(oas.Providers(), services.OAuthProviderGoogle) {
+		t.Errorf("Expected Google in the provider list, got %v", oas.Providers())
+	}
+	if _, err := oas.Provider("unknown"); !errors.Is(err, lib.ErrUnsupportedProvider) {
+		t.Errorf("Expected ErrUnsupportedProvider for an unknown provider, got %v", err)
+	}
+}
+
+func TestListDriveFilesRejectsInvalidFolderID(t *testing.T) {
+	loadTestConfig(t)
+
+	gs := services.NewGoogleService()
+	for _, folderID := range []string{`x' or name contains '`, `x\' in parents or '`, "folder id"} {
+		if _, err := gs.ListDriveFiles(uuid.New(), folderID); !errors.Is(err, lib.ErrInvalidFormat) {
+			t.Errorf("Expected ErrInvalidFormat for folder ID %q, got %v", folderID, err)
+		}
+	}
+}
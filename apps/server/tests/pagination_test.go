@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestParsePaginationParams(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedPage  int
+		expectedLimit int
+		wantErr       bool
+	}{
+		{"defaults", "", response.DefaultPage, response.DefaultLimit, false},
+		{"page and limit", "?page=3&limit=25", 3, 25, false},
+		{"maximum limit", "?limit=100", response.DefaultPage, 100, false},
+		{"limit above the maximum is capped", "?limit=500", response.DefaultPage, response.MaxLimit, false},
+		{"zero limit", "?limit=0", 0, 0, true},
+		{"negative limit", "?limit=-5", 0, 0, true},
+		{"zero page", "?page=0", 0, 0, true},
+		{"negative page", "?page=-1", 0, 0, true},
+		{"text limit", "?limit=ten", 0, 0, true},
+		{"fractional page", "?page=1.5", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/items", func(c fiber.Ctx) error {
+				page, limit, err := response.ParsePaginationParams(c)
+				if tt.wantErr {
+					if !errors.Is(err, response.ErrInvalidPagination) {
+						t.Errorf("Expected ErrInvalidPagination, got %v", err)
+					}
+					return nil
+				}
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				if page != tt.expectedPage || limit != tt.expectedLimit {
+					t.Errorf("Expected page %d and limit %d, got %d and %d", tt.expectedPage, tt.expectedLimit, page, limit)
+				}
+				return nil
+			})
+
+			if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/items"+tt.query, nil)); err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestInvalidPaginationIsBadRequest(t *testing.T) {
+	loadTestConfig(t)
+
+	app := fiber.New()
+	app.Get("/items", func(c fiber.Ctx) error {
+		page, limit, err := response.ParsePaginationParams(c)
+		if err != nil {
+			return lib.HandleServiceError(c, err, "invalid pagination")
+		}
+		return response.Paginated(c, []any{}, page, limit, 0)
+	})
+
+	for _, query := range []string{"?limit=0", "?limit=-5", "?page=0"} {
+		t.Run(query, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/items"+query, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d", resp.StatusCode)
+			}
+
+			var body struct {
+				Message string `json:"message"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Message == "" {
+				t.Error("Expected a message explaining the invalid parameter")
+			}
+		})
+	}
+}
+
+func TestCalculateOffset(t *testing.T) {
+	tests := []struct {
+		page, limit, expected int
+	}{
+		{1, 10, 0},
+		{2, 10, 10},
+		{5, 25, 100},
+		{0, 10, 0},
+	}
+
+	for _, tt := range tests {
+		if got := response.CalculateOffset(tt.page, tt.limit); got != tt.expected {
+			t.Errorf("Expected offset %d for page %d and limit %d, got %d", tt.expected, tt.page, tt.limit, got)
+		}
+	}
+}
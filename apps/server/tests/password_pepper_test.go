@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+)
+
+// testArgonParams keeps the hashing in the tests fast
+var testArgonParams = &types.ArgonParams{Memory: 8 * 1024, Time: 1, Threads: 1, KeyLen: 32, SaltLen: 16}
+
+const (
+	testPassword = "Sup3r-secret!"
+	testPepper   = "a-pepper-of-sixteen-plus-chars"
+)
+
+func TestPasswordHashingWithPepper(t *testing.T) {
+	loadTestConfig(t)
+
+	tests := []struct {
+		name         string
+		hashPepper   string
+		verifyPepper string
+		password     string
+		wantMatch    bool
+		wantErr      error
+	}{
+		{"no pepper", "", "", testPassword, true, nil},
+		{"no pepper wrong password", "", "", "wrong", false, nil},
+		{"pepper", testPepper, testPepper, testPassword, true, nil},
+		{"pepper wrong password", testPepper, testPepper, "wrong", false, nil},
+		{"different pepper", testPepper, "another-pepper-of-16-chars", testPassword, false, nil},
+		{"peppered hash without pepper", testPepper, "", testPassword, false, lib.ErrPepperMissing},
+		{"unpeppered hash with pepper", "", testPepper, testPassword, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := services.NewAuthServiceWithPepper(tt.hashPepper).HashPassword(testPassword, testArgonParams)
+			if err != nil {
+				t.Fatalf("Failed to hash password: %v", err)
+			}
+			if peppered := strings.Contains(hash, ",pv=1$"); peppered != (tt.hashPepper != "") {
+				t.Errorf("Expected pepper marker %v in %q", tt.hashPepper != "", hash)
+			}
+
+			match, err := services.NewAuthServiceWithPepper(tt.verifyPepper).ComparePasswordAndHash(tt.password, hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if match != tt.wantMatch {
+				t.Errorf("Expected match %v, got %v", tt.wantMatch, match)
+			}
+		})
+	}
+}
+
+func TestPasswordHashMigrationToPepper(t *testing.T) {
+	loadTestConfig(t)
+
+	legacy := services.NewAuthServiceWithPepper("")
+	peppered := services.NewAuthServiceWithPepper(testPepper)
+
+	oldHash, err := legacy.HashPassword(testPassword, testArgonParams)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	if legacy.NeedsRehash(oldHash) {
+		t.Error("Expected no rehash while no pepper is configured")
+	}
+	if !peppered.NeedsRehash(oldHash) {
+		t.Error("Expected a hash without pepper to need a rehash once a pepper is configured")
+	}
+
+	// The login flow verifies the old hash, then stores a peppered one
+	match, err := peppered.ComparePasswordAndHash(testPassword, oldHash)
+	if err != nil || !match {
+		t.Fatalf("Expected the old hash to verify with a pepper configured, match=%v err=%v", match, err)
+	}
+	newHash, err := peppered.HashPassword(testPassword, testArgonParams)
+	if err != nil {
+		t.Fatalf("Failed to rehash password: %v", err)
+	}
+
+	if peppered.NeedsRehash(newHash) {
+		t.Error("Expected the peppered hash to not need another rehash")
+	}
+	if match, err := peppered.ComparePasswordAndHash(testPassword, newHash); err != nil || !match {
+		t.Errorf("Expected the peppered hash to verify, match=%v err=%v", match, err)
+	}
+	if _, err := legacy.ComparePasswordAndHash(testPassword, newHash); !errors.Is(err, lib.ErrPepperMissing) {
+		t.Errorf("Expected ErrPepperMissing when the pepper is removed again, got %v", err)
+	}
+}
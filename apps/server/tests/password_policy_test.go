@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/MonkyMars/PWS/validate"
+)
+
+func TestCheckPassword(t *testing.T) {
+	strict := types.PasswordPolicy{
+		MinLength:      8,
+		MaxLength:      20,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+		Blocklist:      map[string]struct{}{"p@ssw0rd!": {}},
+	}
+	lenient := types.PasswordPolicy{MinLength: 4, MaxLength: 20}
+
+	tests := []struct {
+		name     string
+		policy   types.PasswordPolicy
+		password string
+		expected []string
+	}{
+		{"strong password", strict, "Sup3r-secret!", nil},
+		{"unicode letters count", strict, "Ünïcødé-1x", nil},
+		{"every violation at once", strict, "abc", []string{
+			"password must be at least 8 characters long",
+			"password must contain an uppercase letter",
+			"password must contain a digit",
+			"password must contain a special character",
+		}},
+		{"too long", strict, "Sup3r-secret!Sup3r-secret!", []string{"password must not exceed 20 characters"}},
+		{"blocklisted regardless of case", strict, "P@SSW0RD!", []string{
+			"password must contain a lowercase letter",
+			"password is too common, choose a less predictable one",
+		}},
+		{"lenient policy only checks length", lenient, "abcd", nil},
+		{"lenient policy too short", lenient, "abc", []string{"password must be at least 4 characters long"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []string
+			for _, err := range validate.CheckPassword(tt.policy, "password", tt.password) {
+				if err.Field != "password" || err.Value != "" {
+					t.Errorf("Expected a password error without value, got %+v", err)
+				}
+				messages = append(messages, err.Message)
+			}
+			if !slices.Equal(messages, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, messages)
+			}
+		})
+	}
+}
+
+func TestRegisterReportsEveryPasswordViolation(t *testing.T) {
+	loadTestConfig(t)
+
+	req := types.RegisterRequest{
+		Username:        "student",
+		Email:           "student@example.com",
+		Password:        "short",
+		ConfirmPassword: "short",
+	}
+	errs := validate.Struct(req).Password(context.Background(), "password", req.Password).Errors()
+
+	if len(errs) < 2 {
+		t.Fatalf("Expected several password violations in one response, got %v", errs)
+	}
+	for _, err := range errs {
+		if err.Field != "password" {
+			t.Errorf("Expected only password errors, got %+v", err)
+		}
+	}
+}
+
+func TestPasswordPolicyConfig(t *testing.T) {
+	loadTestConfig(t)
+
+	blocklist := filepath.Join(t.TempDir(), "common.txt")
+	if err := os.WriteFile(blocklist, []byte("# common passwords\nPassword1!\n\nqwerty\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*config.AuthConfig)
+		wantErr bool
+	}{
+		{"defaults", func(*config.AuthConfig) {}, false},
+		{"blocklist file", func(ac *config.AuthConfig) { ac.PasswordBlocklistFile = blocklist }, false},
+		{"missing blocklist file", func(ac *config.AuthConfig) { ac.PasswordBlocklistFile = blocklist + ".missing" }, true},
+		{"zero minimum", func(ac *config.AuthConfig) { ac.PasswordMinLength = 0 }, true},
+		{"maximum below minimum", func(ac *config.AuthConfig) { ac.PasswordMinLength, ac.PasswordMaxLength = 12, 10 }, true},
+		{"maximum above the hashing bound", func(ac *config.AuthConfig) { ac.PasswordMaxLength = config.MaxPasswordLength + 1 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := config.LoadDomainConfigs()
+			tt.modify(domains.Auth)
+
+			err := domains.Auth.Validate(domains.App.Environment)
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+
+	t.Run("blocklist is loaded lowercased", func(t *testing.T) {
+		domains := config.LoadDomainConfigs()
+		domains.Auth.PasswordBlocklistFile = blocklist
+
+		policy := domains.ToLegacyConfig().Auth.PasswordPolicy
+		if len(policy.Blocklist) != 2 {
+			t.Fatalf("Expected 2 blocklisted passwords, got %v", policy.Blocklist)
+		}
+		if _, ok := policy.Blocklist["password1!"]; !ok {
+			t.Errorf("Expected the blocklist to hold lowercased passwords, got %v", policy.Blocklist)
+		}
+	})
+}
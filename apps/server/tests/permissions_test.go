@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestRoleHasPermission(t *testing.T) {
+	mapping := map[string][]string{
+		lib.RoleAdmin:   {lib.PermissionWildcard},
+		lib.RoleTeacher: {lib.PermSubmissionsGrade},
+		lib.RoleStudent: {},
+	}
+
+	tests := []struct {
+		name     string
+		role     string
+		perm     string
+		expected bool
+	}{
+		{"admin wildcard grants grading", lib.RoleAdmin, lib.PermSubmissionsGrade, true},
+		{"admin wildcard grants cleanup", lib.RoleAdmin, lib.PermCleanupTrigger, true},
+		{"teacher can grade", lib.RoleTeacher, lib.PermSubmissionsGrade, true},
+		{"teacher cannot read audit logs", lib.RoleTeacher, lib.PermAuditRead, false},
+		{"student has no permissions", lib.RoleStudent, lib.PermSubmissionsGrade, false},
+		{"unknown role has no permissions", "guest", lib.PermSubmissionsGrade, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lib.RoleHasPermission(mapping, tt.role, tt.perm); got != tt.expected {
+				t.Errorf("Expected %v for role %s and permission %s, got %v", tt.expected, tt.role, tt.perm, got)
+			}
+		})
+	}
+}
+
+func TestRolePermissionsValidation(t *testing.T) {
+	loadTestConfig(t)
+
+	tests := []struct {
+		name     string
+		value    string
+		wantErr  bool
+		expected map[string][]string
+	}{
+		{"default mapping", config.DefaultRolePermissions, false, map[string][]string{"admin": {"*"}, "teacher": {lib.PermSubmissionsGrade}}},
+		{"multiple permissions", "teacher=submissions:grade, audit:read;student=", false, map[string][]string{"teacher": {lib.PermSubmissionsGrade, lib.PermAuditRead}, "student": {}}},
+		{"empty grants nothing", "", false, map[string][]string{}},
+		{"missing separator", "teacher", true, nil},
+		{"missing role", "=audit:read", true, nil},
+		{"duplicate role", "admin=*;Admin=audit:read", true, nil},
+		{"unknown permission", "teacher=submissions:grade,audit:raed", true, nil},
+		{"every known permission", "auditor=audit:read,cleanup:trigger,submissions:grade", false, map[string][]string{"auditor": {lib.PermAuditRead, lib.PermCleanupTrigger, lib.PermSubmissionsGrade}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := config.LoadDomainConfigs()
+			domains.Auth.RolePermissions = tt.value
+
+			err := domains.Auth.Validate(domains.App.Environment)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected validation error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected validation error: %v", err)
+			}
+
+			got := domains.ToLegacyConfig().Auth.RolePermissions
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %d roles, got %v", len(tt.expected), got)
+			}
+			for role, want := range tt.expected {
+				if !slices.Equal(got[role], want) {
+					t.Errorf("Expected permissions %v for %s, got %v", want, role, got[role])
+				}
+			}
+		})
+	}
+}
+
+func TestRequirePermissionMiddleware(t *testing.T) {
+	loadTestConfig(t)
+
+	tests := []struct {
+		name     string
+		claims   *types.AuthClaims
+		perm     string
+		expected int
+	}{
+		{"teacher grades submissions", &types.AuthClaims{Role: lib.RoleTeacher}, lib.PermSubmissionsGrade, http.StatusOK},
+		{"student cannot grade", &types.AuthClaims{Role: lib.RoleStudent}, lib.PermSubmissionsGrade, http.StatusForbidden},
+		{"teacher cannot trigger cleanup", &types.AuthClaims{Role: lib.RoleTeacher}, lib.PermCleanupTrigger, http.StatusForbidden},
+		{"admin triggers cleanup", &types.AuthClaims{Role: lib.RoleAdmin}, lib.PermCleanupTrigger, http.StatusOK},
+		{"missing claims", nil, lib.PermAuditRead, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c fiber.Ctx) error {
+				if tt.claims != nil {
+					c.Locals("claims", tt.claims)
+				}
+				return c.Next()
+			})
+			app.Get("/protected", middleware.NewRequirePermission(tt.perm), func(c fiber.Ctx) error {
+				return c.SendStatus(http.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/protected", nil))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, resp.StatusCode)
+			}
+		})
+	}
+}
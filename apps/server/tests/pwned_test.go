@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/validate"
+)
+
+// pwnedRange serves a range API that knows the breached passwords with their counts,
+// recording the hash prefixes it was asked for
+func pwnedRange(t *testing.T, breached map[string]int, prefixes *[]string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := strings.TrimPrefix(r.URL.Path, "/range/")
+		*prefixes = append(*prefixes, prefix)
+
+		for password, count := range breached {
+			sum := sha1.Sum([]byte(password))
+			hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+			if hash[:5] == prefix {
+				fmt.Fprintf(w, "%s:%d\r\n", hash[5:], count)
+			}
+		}
+		// Padding entry that never matches a real password
+		fmt.Fprintf(w, "%s:0\r\n", strings.Repeat("0", 35))
+	}))
+}
+
+func TestPwnedChecker(t *testing.T) {
+	loadTestConfig(t)
+
+	var prefixes []string
+	server := pwnedRange(t, map[string]int{"Password1!": 42, "Padded-Pass1": 0}, &prefixes)
+	defer server.Close()
+
+	checker := validate.NewPwnedChecker(server.URL+"/range/", time.Second, config.SetupLogger())
+
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{"breached password", "Password1!", true},
+		{"unknown password", "Sup3r-secret!", false},
+		{"padding entry with zero count", "Padded-Pass1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pwned, err := checker.IsPwned(context.Background(), tt.password)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if pwned != tt.want {
+				t.Errorf("IsPwned() = %v, want %v", pwned, tt.want)
+			}
+		})
+	}
+
+	// Only the first five characters of the hash may leave the server
+	for _, prefix := range prefixes {
+		if len(prefix) != 5 {
+			t.Errorf("Expected a 5 character hash prefix, got %q", prefix)
+		}
+	}
+
+	if errs := checker.Check(context.Background(), "password", "Password1!"); len(errs) != 1 || errs[0].Field != "password" {
+		t.Errorf("Expected one breach error for password, got %v", errs)
+	}
+}
+
+func TestPwnedCheckerFailsOpen(t *testing.T) {
+	loadTestConfig(t)
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	for name, url := range map[string]string{"error status": down.URL + "/", "unreachable": unreachable.URL + "/"} {
+		t.Run(name, func(t *testing.T) {
+			checker := validate.NewPwnedChecker(url, time.Second, config.SetupLogger())
+
+			if _, err := checker.IsPwned(context.Background(), "Password1!"); err == nil {
+				t.Error("Expected IsPwned to report the failure")
+			}
+			if errs := checker.Check(context.Background(), "password", "Password1!"); len(errs) != 0 {
+				t.Errorf("Expected the password to be allowed when the service fails, got %v", errs)
+			}
+		})
+	}
+}
+
+func TestPwnedConfigValidation(t *testing.T) {
+	loadTestConfig(t)
+
+	tests := []struct {
+		name    string
+		modify  func(*config.AuthConfig)
+		wantErr bool
+	}{
+		{"disabled by default", func(*config.AuthConfig) {}, false},
+		{"enabled with defaults", func(ac *config.AuthConfig) { ac.CheckPwned = true }, false},
+		{"relative url", func(ac *config.AuthConfig) { ac.CheckPwned, ac.PwnedAPIURL = true, "/range/" }, true},
+		{"zero timeout", func(ac *config.AuthConfig) { ac.CheckPwned, ac.PwnedTimeout = true, 0 }, true},
+		{"bad url is ignored when disabled", func(ac *config.AuthConfig) { ac.PwnedAPIURL = "/range/" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := config.LoadDomainConfigs()
+			tt.modify(domains.Auth)
+
+			err := domains.Auth.Validate(domains.App.Environment)
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MonkyMars/PWS/types"
+)
+
+func TestQueryParamsClone(t *testing.T) {
+	original := types.NewQuery().
+		SetOperation("select").
+		SetTable("users").
+		SetSelect([]string{"id", "email"}).
+		AddWhere("role", "student").
+		SetWhereRaw("created_at > ?", "2024-01-01").
+		AddJoin("JOIN subjects ON subjects.id = users.subject_id").
+		AddOrder("created_at DESC").
+		SetLimit(20).
+		SetOffset(40).
+		SetReturning("id").
+		SetEntries([]any{map[string]any{"id": "user1"}})
+	original.Having["count"] = 1
+	original.Params["cache"] = true
+	original.GroupBy = []string{"role"}
+
+	clone := original.Clone()
+	if !reflect.DeepEqual(clone, original) {
+		t.Fatalf("Expected clone to equal original, got %+v", clone)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(q *types.QueryParams)
+		check  func(q *types.QueryParams) bool
+	}{
+		{
+			name:   "select columns",
+			mutate: func(q *types.QueryParams) { q.Select[0] = "COUNT(*)" },
+			check:  func(q *types.QueryParams) bool { return q.Select[0] == "id" },
+		},
+		{
+			name:   "where map",
+			mutate: func(q *types.QueryParams) { q.AddWhere("role", "teacher"); q.AddWhere("active", true) },
+			check:  func(q *types.QueryParams) bool { return q.Where["role"] == "student" && len(q.Where) == 1 },
+		},
+		{
+			name:   "where args",
+			mutate: func(q *types.QueryParams) { q.WhereArgs[0] = "2025-01-01" },
+			check:  func(q *types.QueryParams) bool { return q.WhereArgs[0] == "2024-01-01" },
+		},
+		{
+			name:   "order",
+			mutate: func(q *types.QueryParams) { q.Order = q.Order[:0]; q.AddOrder("id ASC") },
+			check:  func(q *types.QueryParams) bool { return len(q.Order) == 1 && q.Order[0] == "created_at DESC" },
+		},
+		{
+			name:   "joins",
+			mutate: func(q *types.QueryParams) { q.Join[0] = "" },
+			check:  func(q *types.QueryParams) bool { return q.Join[0] != "" },
+		},
+		{
+			name:   "group by",
+			mutate: func(q *types.QueryParams) { q.GroupBy[0] = "email" },
+			check:  func(q *types.QueryParams) bool { return q.GroupBy[0] == "role" },
+		},
+		{
+			name:   "having and params",
+			mutate: func(q *types.QueryParams) { q.Having["count"] = 2; q.Params["cache"] = false },
+			check:  func(q *types.QueryParams) bool { return q.Having["count"] == 1 && q.Params["cache"] == true },
+		},
+		{
+			name:   "entries",
+			mutate: func(q *types.QueryParams) { q.Entries[0].(map[string]any)["id"] = "user2" },
+			check:  func(q *types.QueryParams) bool { return q.Entries[0].(map[string]any)["id"] == "user1" },
+		},
+		{
+			name:   "limit and offset",
+			mutate: func(q *types.QueryParams) { q.SetLimit(0).SetOffset(0) },
+			check:  func(q *types.QueryParams) bool { return q.Limit == 20 && q.Offset == 40 },
+		},
+		{
+			name:   "returning",
+			mutate: func(q *types.QueryParams) { q.Returning[0] = "email" },
+			check:  func(q *types.QueryParams) bool { return q.Returning[0] == "id" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mutate(original.Clone())
+			if !tt.check(original) {
+				t.Errorf("Mutating the clone changed the original %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestQueryParamsCloneNil(t *testing.T) {
+	var q *types.QueryParams
+	if q.Clone() != nil {
+		t.Error("Expected cloning a nil query to return nil")
+	}
+}
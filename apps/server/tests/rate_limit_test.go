@@ -0,0 +1,380 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+// memoryRateLimitCounter is an in-memory stand-in for the Redis-backed counter
+type memoryRateLimitCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newMemoryRateLimitCounter() *memoryRateLimitCounter {
+	return &memoryRateLimitCounter{counts: make(map[string]int)}
+}
+
+func (m *memoryRateLimitCounter) IncrementRateLimit(ip, endpoint string, ttl time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := ip + ":" + endpoint
+	m.counts[key]++
+	return m.counts[key], nil
+}
+
+func newRateLimitedApp(t *testing.T, opts middleware.RateLimitOptions) *fiber.App {
+	t.Helper()
+
+	limiter, err := middleware.NewRateLimiter(newMemoryRateLimitCounter(), opts)
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+
+	// Trust the forwarded client IP from the in-memory test connection
+	app := fiber.New(fiber.Config{
+		ProxyHeader:      fiber.HeaderXForwardedFor,
+		TrustProxy:       true,
+		TrustProxyConfig: fiber.TrustProxyConfig{Proxies: []string{"0.0.0.0"}},
+	})
+	app.Use(limiter)
+	app.Get("/limited", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestRateLimitExemptions(t *testing.T) {
+	const limit = 3
+	const exemptKey = "internal-service-key-0123456789"
+
+	opts := middleware.RateLimitOptions{
+		Max:         limit,
+		Window:      time.Minute,
+		ExemptCIDRs: []string{"10.0.0.0/8", "192.168.1.10"},
+		ExemptKeys:  []string{exemptKey},
+	}
+
+	testCases := []struct {
+		name        string
+		clientIP    string
+		apiKey      string
+		expectLimit bool
+	}{
+		{name: "Exempt CIDR range", clientIP: "10.1.2.3", expectLimit: false},
+		{name: "Exempt single IP", clientIP: "192.168.1.10", expectLimit: false},
+		{name: "Exempt API key", clientIP: "203.0.113.7", apiKey: exemptKey, expectLimit: false},
+		{name: "Non-exempt caller", clientIP: "203.0.113.8", expectLimit: true},
+		{name: "Wrong API key", clientIP: "203.0.113.9", apiKey: "not-the-right-key", expectLimit: true},
+		{name: "Neighbouring IP outside exempt host", clientIP: "192.168.1.11", expectLimit: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newRateLimitedApp(t, opts)
+
+			throttled := 0
+			for range limit * 3 {
+				req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+				req.Header.Set(fiber.HeaderXForwardedFor, tc.clientIP)
+				if tc.apiKey != "" {
+					req.Header.Set(middleware.RateLimitExemptKeyHeader, tc.apiKey)
+				}
+
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatalf("Request failed: %v", err)
+				}
+				resp.Body.Close()
+
+				if resp.StatusCode == fiber.StatusTooManyRequests {
+					throttled++
+				}
+			}
+
+			if tc.expectLimit && throttled != limit*2 {
+				t.Errorf("Expected %d throttled requests, got %d", limit*2, throttled)
+			}
+			if !tc.expectLimit && throttled != 0 {
+				t.Errorf("Expected exempt caller never to be throttled, got %d throttled requests", throttled)
+			}
+		})
+	}
+}
+
+func TestRateLimitInvalidExemptCIDR(t *testing.T) {
+	_, err := middleware.NewRateLimiter(newMemoryRateLimitCounter(), middleware.RateLimitOptions{
+		Max:         1,
+		Window:      time.Minute,
+		ExemptCIDRs: []string{"not-a-cidr"},
+	})
+	if err == nil {
+		t.Error("Expected an error for an invalid exempt CIDR")
+	}
+}
+
+// ttlRateLimitCounter reports a fixed remaining window through middleware.RateLimitTTLReader
+type ttlRateLimitCounter struct {
+	*memoryRateLimitCounter
+	ttl time.Duration
+	err error
+}
+
+func (c *ttlRateLimitCounter) RateLimitTTL(ip, endpoint string) (time.Duration, error) {
+	return c.ttl, c.err
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		counter  middleware.RateLimitCounter
+		expected string
+	}{
+		{name: "Counter without TTL uses the window", counter: newMemoryRateLimitCounter(), expected: "60"},
+		{name: "Remaining TTL is rounded up", counter: &ttlRateLimitCounter{memoryRateLimitCounter: newMemoryRateLimitCounter(), ttl: 12300 * time.Millisecond}, expected: "13"},
+		{name: "Sub-second TTL waits at least a second", counter: &ttlRateLimitCounter{memoryRateLimitCounter: newMemoryRateLimitCounter(), ttl: 200 * time.Millisecond}, expected: "1"},
+		{name: "TTL error falls back to the window", counter: &ttlRateLimitCounter{memoryRateLimitCounter: newMemoryRateLimitCounter(), err: errors.New("redis down")}, expected: "60"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			limiter, err := middleware.NewRateLimiter(tc.counter, middleware.RateLimitOptions{Max: 1, Window: time.Minute})
+			if err != nil {
+				t.Fatalf("Failed to create rate limiter: %v", err)
+			}
+
+			app := fiber.New()
+			app.Use(limiter)
+			app.Get("/limited", func(c fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			var resp *http.Response
+			for range 2 {
+				resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/limited", nil))
+				if err != nil {
+					t.Fatalf("Request failed: %v", err)
+				}
+			}
+
+			if resp.StatusCode != fiber.StatusTooManyRequests {
+				t.Fatalf("Expected status 429, got %d", resp.StatusCode)
+			}
+			if got := resp.Header.Get(fiber.HeaderRetryAfter); got != tc.expected {
+				t.Errorf("Expected Retry-After %q, got %q", tc.expected, got)
+			}
+
+			var body types.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Error == nil || fmt.Sprint(body.Error.Details["retry_after"]) != tc.expected {
+				t.Errorf("Expected retry_after %s in error details, got %+v", tc.expected, body.Error)
+			}
+		})
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	counter := &ttlRateLimitCounter{memoryRateLimitCounter: newMemoryRateLimitCounter(), ttl: 42500 * time.Millisecond}
+	limiter, err := middleware.NewRateLimiter(counter, middleware.RateLimitOptions{
+		Max:        2,
+		Window:     time.Minute,
+		ExemptKeys: []string{"internal-service-key-0123456789"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(limiter)
+	app.Get("/limited", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	testCases := []struct {
+		name              string
+		expectedStatus    int
+		expectedRemaining string
+		expectedReset     string
+	}{
+		{name: "First request starts a full window", expectedStatus: fiber.StatusOK, expectedRemaining: "1", expectedReset: "60"},
+		{name: "Second request uses the remaining TTL", expectedStatus: fiber.StatusOK, expectedRemaining: "0", expectedReset: "43"},
+		{name: "Throttled request keeps the headers", expectedStatus: fiber.StatusTooManyRequests, expectedRemaining: "0", expectedReset: "43"},
+	}
+
+	// The cases run in order against the same counter
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/limited", nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+			if got := resp.Header.Get(middleware.HeaderRateLimitLimit); got != "2" {
+				t.Errorf("Expected %s 2, got %q", middleware.HeaderRateLimitLimit, got)
+			}
+			if got := resp.Header.Get(middleware.HeaderRateLimitRemaining); got != tc.expectedRemaining {
+				t.Errorf("Expected %s %q, got %q", middleware.HeaderRateLimitRemaining, tc.expectedRemaining, got)
+			}
+			if got := resp.Header.Get(middleware.HeaderRateLimitReset); got != tc.expectedReset {
+				t.Errorf("Expected %s %q, got %q", middleware.HeaderRateLimitReset, tc.expectedReset, got)
+			}
+		})
+	}
+
+	t.Run("Exempt request has no headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.Header.Set(middleware.RateLimitExemptKeyHeader, "internal-service-key-0123456789")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		if got := resp.Header.Get(middleware.HeaderRateLimitLimit); got != "" {
+			t.Errorf("Expected no %s header, got %q", middleware.HeaderRateLimitLimit, got)
+		}
+	})
+}
+
+func TestNewRateLimitStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		count    int
+		limit    int
+		ttl      time.Duration
+		expected types.RateLimitStatus
+	}{
+		{name: "No requests yet", count: 0, limit: 5, ttl: 0, expected: types.RateLimitStatus{Count: 0, Limit: 5, Remaining: 5}},
+		{name: "Within the limit", count: 3, limit: 5, ttl: 30 * time.Second, expected: types.RateLimitStatus{Count: 3, Limit: 5, Remaining: 2, Reset: 30}},
+		{name: "At the limit", count: 5, limit: 5, ttl: 1500 * time.Millisecond, expected: types.RateLimitStatus{Count: 5, Limit: 5, Remaining: 0, Reset: 2}},
+		{name: "Over the limit", count: 7, limit: 5, ttl: 10 * time.Second, expected: types.RateLimitStatus{Count: 7, Limit: 5, Remaining: 0, Reset: 10, RetryAfter: 10}},
+		{name: "Over the limit with an expired window", count: 7, limit: 5, ttl: -1, expected: types.RateLimitStatus{Count: 7, Limit: 5, Remaining: 0, Reset: 0, RetryAfter: 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := types.NewRateLimitStatus(tc.count, tc.limit, tc.ttl); got != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitSoftThresholdWarning(t *testing.T) {
+	limiter, err := middleware.NewRateLimiter(newMemoryRateLimitCounter(), middleware.RateLimitOptions{
+		Max:           10,
+		Window:        time.Minute,
+		SoftThreshold: 0.8,
+		SoftThresholdRoutes: map[string]float64{
+			"/auth":       0.5,
+			"/auth/login": 0.3,
+			"/files/":     0,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(limiter)
+	app.Get("/*", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	testCases := []struct {
+		name         string
+		path         string
+		firstWarning int
+	}{
+		{name: "Global threshold", path: "/deadlines", firstWarning: 8},
+		{name: "Route prefix", path: "/auth/register", firstWarning: 5},
+		{name: "Longest prefix wins", path: "/auth/login", firstWarning: 3},
+		{name: "Prefix matches whole segments only", path: "/authors", firstWarning: 8},
+		{name: "Zero threshold disables the warning", path: "/files/upload", firstWarning: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 1; i <= 11; i++ {
+				resp, err := app.Test(httptest.NewRequest(http.MethodGet, tc.path, nil))
+				if err != nil {
+					t.Fatalf("Request failed: %v", err)
+				}
+				resp.Body.Close()
+
+				warning := resp.Header.Get(middleware.HeaderRateLimitWarning)
+				expectWarning := tc.firstWarning > 0 && i >= tc.firstWarning && i <= 10
+				if expectWarning != (warning != "") {
+					t.Errorf("Request %d: expected warning %v, got %q", i, expectWarning, warning)
+				}
+				if i <= 10 && resp.StatusCode != fiber.StatusOK {
+					t.Errorf("Request %d: expected the warning not to block, got status %d", i, resp.StatusCode)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitSoftThresholdRoutesValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold float64
+		routes    string
+		wantErr   bool
+		expected  map[string]float64
+	}{
+		{name: "defaults", threshold: 0.8, expected: map[string]float64{}},
+		{name: "disabled", threshold: 0, expected: map[string]float64{}},
+		{name: "route thresholds", threshold: 0.8, routes: "/auth/login=0.5, /files=0", expected: map[string]float64{"/auth/login": 0.5, "/files": 0}},
+		{name: "threshold above one", threshold: 1.5, wantErr: true},
+		{name: "negative threshold", threshold: -0.1, wantErr: true},
+		{name: "missing fraction", threshold: 0.8, routes: "/auth", wantErr: true},
+		{name: "route fraction out of range", threshold: 0.8, routes: "/auth=2", wantErr: true},
+		{name: "relative path", threshold: 0.8, routes: "auth=0.5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := config.LoadDomainConfigs()
+			domains.RateLimit.SoftThreshold = tt.threshold
+			domains.RateLimit.SoftThresholdRoutes = tt.routes
+
+			err := domains.RateLimit.Validate()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected validation error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected validation error: %v", err)
+			}
+
+			rateLimit := domains.ToLegacyConfig().RateLimit
+			if rateLimit.SoftThreshold != tt.threshold {
+				t.Errorf("Expected soft threshold %v, got %v", tt.threshold, rateLimit.SoftThreshold)
+			}
+			if !maps.Equal(rateLimit.SoftThresholdRoutes, tt.expected) {
+				t.Errorf("Expected route thresholds %v, got %v", tt.expected, rateLimit.SoftThresholdRoutes)
+			}
+		})
+	}
+}
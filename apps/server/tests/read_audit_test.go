@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// auditCapture collects audit logs sent to the audit worker hook
+type auditCapture struct {
+	mu   sync.Mutex
+	logs []types.AuditLog
+}
+
+func (ac *auditCapture) add(log types.AuditLog) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	ac.logs = append(ac.logs, log)
+}
+
+// take returns the captured logs with the given message and clears the capture
+func (ac *auditCapture) take(message string) []types.AuditLog {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	var matched []types.AuditLog
+	for _, log := range ac.logs {
+		if log.Message == message {
+			matched = append(matched, log)
+		}
+	}
+	ac.logs = nil
+	return matched
+}
+
+func newReadAuditTestApp(enabled bool, claims *types.AuthClaims, status int) *fiber.App {
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	app := fiber.New()
+	app.Get("/deadlines/:id/submissions",
+		func(c fiber.Ctx) error {
+			c.Locals("claims", claims)
+			return c.Next()
+		},
+		middleware.NewReadAuditor(enabled, "submissions", logger),
+		func(c fiber.Ctx) error {
+			return c.SendStatus(status)
+		},
+	)
+	return app
+}
+
+func TestReadAuditMiddleware(t *testing.T) {
+	capture := &auditCapture{}
+	config.SetAuditLogFunc(capture.add)
+
+	teacher := &types.AuthClaims{Sub: uuid.New(), Role: lib.RoleTeacher}
+	student := &types.AuthClaims{Sub: uuid.New(), Role: lib.RoleStudent}
+	deadlineID := uuid.New().String()
+
+	tests := []struct {
+		name          string
+		enabled       bool
+		claims        *types.AuthClaims
+		status        int
+		expectedCount int
+	}{
+		{"teacher read is audited when enabled", true, teacher, fiber.StatusOK, 1},
+		{"teacher read is not audited when disabled", false, teacher, fiber.StatusOK, 0},
+		{"student read is not audited", true, student, fiber.StatusOK, 0},
+		{"failed read is not audited", true, teacher, fiber.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newReadAuditTestApp(tt.enabled, tt.claims, tt.status)
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/deadlines/"+deadlineID+"/submissions", nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+
+			logs := capture.take("Sensitive resource read")
+			if len(logs) != tt.expectedCount {
+				t.Fatalf("Expected %d read-audit entries, got %d", tt.expectedCount, len(logs))
+			}
+			if tt.expectedCount == 0 {
+				return
+			}
+
+			entry := logs[0]
+			if entry.Level != "INFO" {
+				t.Errorf("Expected level INFO, got %s", entry.Level)
+			}
+			expectedAttrs := map[string]any{
+				"resource":    "submissions",
+				"resource_id": deadlineID,
+				"actor_id":    teacher.Sub.String(),
+				"actor_role":  lib.RoleTeacher,
+			}
+			for key, value := range expectedAttrs {
+				if entry.Attrs[key] != value {
+					t.Errorf("Expected attr %s=%v, got %v", key, value, entry.Attrs[key])
+				}
+			}
+			if !strings.HasPrefix(entry.Source, "api/middleware/read_audit.go:") {
+				t.Errorf("Expected source to point at the read audit middleware, got %q", entry.Source)
+			}
+			if entry.EntryHash == "" {
+				t.Error("Expected entry hash to be set")
+			}
+		})
+	}
+}
+
+func TestReadAuditMiddlewareRecordsQuery(t *testing.T) {
+	capture := &auditCapture{}
+	config.SetAuditLogFunc(capture.add)
+
+	admin := &types.AuthClaims{Sub: uuid.New(), Role: lib.RoleAdmin}
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	app := fiber.New()
+	app.Get("/users/search",
+		func(c fiber.Ctx) error {
+			c.Locals("claims", admin)
+			return c.Next()
+		},
+		middleware.NewReadAuditor(true, "users", logger),
+		func(c fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		},
+	)
+
+	for _, tt := range []struct {
+		name  string
+		path  string
+		query any
+	}{
+		{"search", "/users/search?q=jan&page=2", "q=jan&page=2"},
+		{"without query", "/users/search", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := app.Test(httptest.NewRequest("GET", tt.path, nil)); err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+
+			logs := capture.take("Sensitive resource read")
+			if len(logs) != 1 {
+				t.Fatalf("Expected 1 read-audit entry, got %d", len(logs))
+			}
+			if logs[0].Attrs["resource"] != "users" || logs[0].Attrs["query"] != tt.query {
+				t.Errorf("Expected resource users with query %v, got %v", tt.query, logs[0].Attrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/gofiber/fiber/v3"
+)
+
+// memoryStore is an in-process ReadOnlyStore for tests
+type memoryStore struct {
+	mu     sync.Mutex
+	values map[string]string
+	err    error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{values: make(map[string]string)}
+}
+
+func (ms *memoryStore) Get(key string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.err != nil {
+		return "", ms.err
+	}
+	return ms.values[key], nil
+}
+
+func (ms *memoryStore) Set(key string, value any, ttl time.Duration) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.err != nil {
+		return ms.err
+	}
+	ms.values[key] = value.(string)
+	return nil
+}
+
+func newReadOnlyTestApp(readOnly services.ReadOnlyServiceInterface) *fiber.App {
+	app := fiber.New()
+	app.Use(middleware.NewReadOnlyGuard(readOnly))
+
+	ok := func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/deadlines", ok)
+	app.Post("/deadlines", ok)
+	app.Put("/deadlines/:id", ok)
+	app.Patch("/deadlines/:id", ok)
+	app.Delete("/deadlines/:id", ok)
+	app.Post("/auth/refresh", ok)
+	app.Put("/health/read-only", ok)
+	return app
+}
+
+func TestReadOnlyGuard(t *testing.T) {
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	readOnly := services.NewReadOnlyServiceWithStore(newMemoryStore(), false, logger)
+	app := newReadOnlyTestApp(readOnly)
+
+	tests := []struct {
+		method             string
+		path               string
+		expectedWhenLocked int
+	}{
+		{http.MethodGet, "/deadlines", http.StatusOK},
+		{http.MethodPost, "/deadlines", http.StatusServiceUnavailable},
+		{http.MethodPut, "/deadlines/1", http.StatusServiceUnavailable},
+		{http.MethodPatch, "/deadlines/1", http.StatusServiceUnavailable},
+		{http.MethodDelete, "/deadlines/1", http.StatusServiceUnavailable},
+		{http.MethodPost, "/auth/refresh", http.StatusOK},
+		{http.MethodPut, "/health/read-only", http.StatusOK},
+	}
+
+	for _, enabled := range []bool{false, true} {
+		if err := readOnly.SetReadOnly(enabled); err != nil {
+			t.Fatalf("Failed to set read-only mode: %v", err)
+		}
+
+		for _, tt := range tests {
+			expected := http.StatusOK
+			if enabled {
+				expected = tt.expectedWhenLocked
+			}
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != expected {
+				t.Errorf("read_only=%v %s %s: expected status %d, got %d", enabled, tt.method, tt.path, expected, resp.StatusCode)
+			}
+		}
+	}
+}
+
+func TestReadOnlyServiceSharesModeAcrossReplicas(t *testing.T) {
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	store := newMemoryStore()
+	first := services.NewReadOnlyServiceWithStore(store, false, logger)
+	second := services.NewReadOnlyServiceWithStore(store, false, logger)
+
+	if second.IsReadOnly() {
+		t.Fatal("Expected read-only mode to start disabled")
+	}
+	if err := first.SetReadOnly(true); err != nil {
+		t.Fatalf("Failed to set read-only mode: %v", err)
+	}
+	if !second.IsReadOnly() {
+		t.Error("Expected a mode set on one replica to apply to the other")
+	}
+}
+
+func TestReadOnlyServiceFallback(t *testing.T) {
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	tests := []struct {
+		name     string
+		storeErr error
+		stored   string
+		fallback bool
+		expected bool
+	}{
+		{"unset uses configured default", nil, "", true, true},
+		{"stored mode overrides default", nil, "0", true, false},
+		{"unreachable store uses configured default", errors.New("redis down"), "1", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			if tt.stored != "" {
+				store.values["maintenance:read_only"] = tt.stored
+			}
+			store.err = tt.storeErr
+
+			readOnly := services.NewReadOnlyServiceWithStore(store, tt.fallback, logger)
+			if got := readOnly.IsReadOnly(); got != tt.expected {
+				t.Errorf("Expected read_only=%v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
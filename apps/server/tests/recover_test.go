@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/api/response"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	capture := &auditCapture{}
+	config.SetAuditLogFunc(capture.add)
+
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	app := fiber.New()
+	app.Use(middleware.NewRecoverer(logger))
+	app.Use(middleware.NewRequestID())
+	app.Get("/panic", func(c fiber.Ctx) error {
+		c.WriteString("partial output")
+		panic("something went very wrong")
+	})
+	app.Get("/panic-error", func(c fiber.Ctx) error {
+		panic(errors.New("nil map write"))
+	})
+	app.Get("/ok", func(c fiber.Ctx) error {
+		return c.SendStatus(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedPanic  string
+	}{
+		{"string panic", "/panic", http.StatusInternalServerError, "something went very wrong"},
+		{"error panic", "/panic-error", http.StatusInternalServerError, "nil map write"},
+		{"no panic", "/ok", http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(config.RequestIDHeader, "panic-req-1")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+
+			logs := capture.take("Recovered from panic in request handler")
+			if tt.expectedPanic == "" {
+				if len(logs) != 0 {
+					t.Errorf("expected no audit logs, got %d", len(logs))
+				}
+				return
+			}
+
+			if got := resp.Header.Get(config.RequestIDHeader); got != "panic-req-1" {
+				t.Errorf("expected request ID header to survive the panic, got %q", got)
+			}
+
+			var body types.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("expected a JSON error response: %v", err)
+			}
+			if body.Success || body.Error == nil || body.Error.Code != response.ErrCodeInternal {
+				t.Errorf("expected an internal error response, got %+v", body)
+			}
+			if strings.Contains(body.Message, tt.expectedPanic) {
+				t.Error("panic value must not be exposed to the client")
+			}
+
+			if len(logs) != 1 {
+				t.Fatalf("expected 1 audit log, got %d", len(logs))
+			}
+			entry := logs[0]
+			if entry.Level != "ERROR" || entry.Attrs["panic"] != tt.expectedPanic {
+				t.Errorf("expected ERROR audit log with panic %q, got level %q attrs %v", tt.expectedPanic, entry.Level, entry.Attrs["panic"])
+			}
+			if stack, _ := entry.Attrs["stack"].(string); !strings.Contains(stack, "goroutine") {
+				t.Errorf("expected a stack trace in the audit log, got %q", stack)
+			}
+			if entry.RequestID != "panic-req-1" {
+				t.Errorf("expected request ID in the audit log, got %q", entry.RequestID)
+			}
+		})
+	}
+}
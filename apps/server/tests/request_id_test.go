@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		incoming   string
+		expectSame bool
+	}{
+		{"generates an ID when absent", "", false},
+		{"reuses a client ID", "client-req-42", true},
+		{"replaces an ID with unsafe characters", "abc\ninjected=1", false},
+		{"replaces an overly long ID", strings.Repeat("a", 200), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var local string
+			app := fiber.New()
+			app.Use(middleware.NewRequestID())
+			app.Get("/", func(c fiber.Ctx) error {
+				local = config.RequestID(c)
+				return c.SendStatus(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(config.RequestIDHeader, tt.incoming)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			header := resp.Header.Get(config.RequestIDHeader)
+			if header == "" || header != local {
+				t.Fatalf("expected response header to match the stored ID, header=%q local=%q", header, local)
+			}
+
+			if tt.expectSame {
+				if header != tt.incoming {
+					t.Errorf("expected client ID %q to be reused, got %q", tt.incoming, header)
+				}
+				return
+			}
+			if _, err := uuid.Parse(header); err != nil {
+				t.Errorf("expected a generated UUID, got %q", header)
+			}
+		})
+	}
+}
+
+func TestRequestIDPropagatesToLogs(t *testing.T) {
+	capture := &auditCapture{}
+	config.SetAuditLogFunc(capture.add)
+
+	var buf bytes.Buffer
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+
+	app := fiber.New()
+	app.Use(middleware.NewRequestID())
+	app.Use(logger.HTTPMiddleware())
+	app.Get("/", func(c fiber.Ctx) error {
+		logger.WithRequest(c).AuditError("Request ID propagation test", "error", errors.New("boom").Error())
+		return c.SendStatus(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(config.RequestIDHeader, "trace-123")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	// Both the audit error and the access log line carry the request ID
+	if count := strings.Count(buf.String(), "request_id=trace-123"); count != 2 {
+		t.Errorf("expected request_id in 2 log lines, found %d in:\n%s", count, buf.String())
+	}
+
+	logs := capture.take("Request ID propagation test")
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 audit log, got %d", len(logs))
+	}
+	if logs[0].RequestID != "trace-123" {
+		t.Errorf("expected audit log request ID %q, got %q", "trace-123", logs[0].RequestID)
+	}
+	if logs[0].EntryHash == "" {
+		t.Error("expected audit log to have an entry hash")
+	}
+}
+
+func TestWithRequestWithoutID(t *testing.T) {
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))}
+
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		if got := logger.WithRequest(c); got != logger {
+			t.Error("expected the logger to be returned unchanged without a request ID")
+		}
+		return c.SendStatus(http.StatusNoContent)
+	})
+
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+}
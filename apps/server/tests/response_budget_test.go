@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/api/middleware"
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/gofiber/fiber/v3"
+)
+
+const budgetWarning = "Handler exceeded response time budget"
+
+func newResponseBudgetApp(cfg types.ServerConfig) *fiber.App {
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	app := fiber.New()
+	app.Use(middleware.NewResponseBudget(cfg, logger))
+	app.Get("/slow/:id", func(c fiber.Ctx) error {
+		time.Sleep(50 * time.Millisecond)
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/fast", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestResponseBudget(t *testing.T) {
+	capture := &auditCapture{}
+	config.SetAuditLogFunc(capture.add)
+
+	tests := []struct {
+		name          string
+		cfg           types.ServerConfig
+		path          string
+		expectedCount int
+	}{
+		{
+			name:          "slow handler exceeds global budget",
+			cfg:           types.ServerConfig{ResponseBudget: 10 * time.Millisecond},
+			path:          "/slow/1",
+			expectedCount: 1,
+		},
+		{
+			name:          "fast handler stays within budget",
+			cfg:           types.ServerConfig{ResponseBudget: 10 * time.Millisecond},
+			path:          "/fast",
+			expectedCount: 0,
+		},
+		{
+			name: "route budget overrides global budget",
+			cfg: types.ServerConfig{
+				ResponseBudget:       10 * time.Millisecond,
+				ResponseBudgetRoutes: map[string]time.Duration{"GET /slow/:id": time.Second},
+			},
+			path:          "/slow/2",
+			expectedCount: 0,
+		},
+		{
+			name: "route budget without method applies",
+			cfg: types.ServerConfig{
+				ResponseBudget:       time.Second,
+				ResponseBudgetRoutes: map[string]time.Duration{"/slow/:id": 10 * time.Millisecond},
+			},
+			path:          "/slow/3",
+			expectedCount: 1,
+		},
+		{
+			name:          "zero budget disables the check",
+			cfg:           types.ServerConfig{},
+			path:          "/slow/4",
+			expectedCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capture.take(budgetWarning)
+
+			app := newResponseBudgetApp(tt.cfg)
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+			}
+
+			logs := capture.take(budgetWarning)
+			if len(logs) != tt.expectedCount {
+				t.Fatalf("Expected %d budget warnings, got %d", tt.expectedCount, len(logs))
+			}
+			if tt.expectedCount == 0 {
+				return
+			}
+
+			log := logs[0]
+			if log.Level != "WARN" {
+				t.Errorf("Expected WARN level, got %s", log.Level)
+			}
+			if log.Attrs["route"] != "/slow/:id" || log.Attrs["path"] != tt.path {
+				t.Errorf("Expected route /slow/:id and path %s, got %v and %v", tt.path, log.Attrs["route"], log.Attrs["path"])
+			}
+			if _, ok := log.Attrs["duration"]; !ok {
+				t.Error("Expected the duration in the warning")
+			}
+		})
+	}
+}
+
+func TestResponseBudgetRoutesValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		routes   string
+		wantErr  bool
+		expected map[string]time.Duration
+	}{
+		{"empty uses global budget", "", false, map[string]time.Duration{"GET /deadlines": 2 * time.Second}},
+		{"method and path", "GET /health/history/:service=5s", false, map[string]time.Duration{"GET /health/history/:service": 5 * time.Second, "POST /health/history/:service": 2 * time.Second}},
+		{"path for every method", "/files/upload=10s, get /health=100ms", false, map[string]time.Duration{"POST /files/upload": 10 * time.Second, "GET /health": 100 * time.Millisecond}},
+		{"missing budget", "/health", true, nil},
+		{"invalid duration", "/health=fast", true, nil},
+		{"non-positive duration", "/health=0s", true, nil},
+		{"relative path", "health=1s", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := config.LoadDomainConfigs()
+			domains.Server.ResponseBudget = 2 * time.Second
+			domains.Server.ResponseBudgetRoutes = tt.routes
+
+			err := domains.Server.Validate()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected validation error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected validation error: %v", err)
+			}
+
+			server := domains.ToLegacyConfig().Server
+			for route, want := range tt.expected {
+				method, path, _ := strings.Cut(route, " ")
+				if got := server.ResponseBudgetFor(method, path); got != want {
+					t.Errorf("Expected budget %v for %s, got %v", want, route, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+func TestSessionConfigValidation(t *testing.T) {
+	loadTestConfig(t)
+
+	tests := []struct {
+		name    string
+		idle    time.Duration
+		max     time.Duration
+		wantErr bool
+	}{
+		{"defaults", 24 * time.Hour, 30 * 24 * time.Hour, false},
+		{"equal idle and max", time.Hour, time.Hour, false},
+		{"zero idle", 0, time.Hour, true},
+		{"max shorter than idle", 2 * time.Hour, time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := config.LoadDomainConfigs()
+			domains.Auth.SessionIdleTimeout = tt.idle
+			domains.Auth.SessionMaxLifetime = tt.max
+
+			err := domains.Auth.Validate(domains.App.Environment)
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+// TestSlidingSession runs the session lifecycle against a real Redis
+func TestSlidingSession(t *testing.T) {
+	cfg := loadTestConfig(t)
+
+	cs := services.NewCacheService()
+	if err := cs.Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+	as := services.NewAuthService()
+	idle := cfg.Auth.SessionIdleTimeout
+	maxLifetime := cfg.Auth.SessionMaxLifetime
+
+	t.Run("login starts a session bound to both tokens", func(t *testing.T) {
+		tokens, err := as.StartSession(&types.User{Id: uuid.New(), Username: "session-test", Role: lib.RoleStudent})
+		if err != nil {
+			t.Fatalf("Failed to start session: %v", err)
+		}
+
+		access, err := as.ParseToken(tokens.AccessToken, true)
+		if err != nil {
+			t.Fatalf("Failed to parse access token: %v", err)
+		}
+		refresh, err := as.ParseToken(tokens.RefreshToken, false)
+		if err != nil {
+			t.Fatalf("Failed to parse refresh token: %v", err)
+		}
+		if access.Sid == uuid.Nil || access.Sid != refresh.Sid {
+			t.Fatalf("Expected both tokens in one session, got %s and %s", access.Sid, refresh.Sid)
+		}
+		t.Cleanup(func() { _ = as.EndSession(access.Sid) })
+
+		if ttl, err := cs.TTL("session:" + access.Sid.String()); err != nil || ttl <= 0 || ttl > idle {
+			t.Errorf("Expected a session TTL of at most %v, got %v (err %v)", idle, ttl, err)
+		}
+
+		if err := as.EndSession(access.Sid); err != nil {
+			t.Fatalf("Failed to end session: %v", err)
+		}
+		if err := as.TouchSession(access.Sid); !errors.Is(err, lib.ErrSessionExpired) {
+			t.Errorf("Expected an ended session to be expired, got %v", err)
+		}
+	})
+
+	t.Run("activity extends the idle timeout", func(t *testing.T) {
+		sessionID := uuid.New()
+		t.Cleanup(func() { _ = as.EndSession(sessionID) })
+
+		session := &types.UserSession{UserID: uuid.New(), CreatedAt: time.Now()}
+		if err := cs.SetUserSession(sessionID, session, time.Minute); err != nil {
+			t.Fatalf("Failed to store session: %v", err)
+		}
+
+		if err := as.TouchSession(sessionID); err != nil {
+			t.Fatalf("Failed to touch session: %v", err)
+		}
+		if ttl, _ := cs.TTL("session:" + sessionID.String()); ttl <= time.Minute {
+			t.Errorf("Expected the session to be extended to the idle timeout %v, got %v", idle, ttl)
+		}
+	})
+
+	t.Run("activity never passes the maximum lifetime", func(t *testing.T) {
+		sessionID := uuid.New()
+		t.Cleanup(func() { _ = as.EndSession(sessionID) })
+
+		// A session that reaches its maximum lifetime in 30 seconds
+		session := &types.UserSession{UserID: uuid.New(), CreatedAt: time.Now().Add(30*time.Second - maxLifetime)}
+		if err := cs.SetUserSession(sessionID, session, time.Second); err != nil {
+			t.Fatalf("Failed to store session: %v", err)
+		}
+
+		if err := as.TouchSession(sessionID); err != nil {
+			t.Fatalf("Failed to touch session: %v", err)
+		}
+		if ttl, _ := cs.TTL("session:" + sessionID.String()); ttl <= time.Second || ttl > 30*time.Second {
+			t.Errorf("Expected the session to be capped at 30s, got %v", ttl)
+		}
+	})
+
+	t.Run("a session past its maximum lifetime is over", func(t *testing.T) {
+		sessionID := uuid.New()
+		t.Cleanup(func() { _ = as.EndSession(sessionID) })
+
+		session := &types.UserSession{UserID: uuid.New(), CreatedAt: time.Now().Add(-maxLifetime - time.Minute)}
+		if err := cs.SetUserSession(sessionID, session, time.Minute); err != nil {
+			t.Fatalf("Failed to store session: %v", err)
+		}
+
+		if err := as.TouchSession(sessionID); !errors.Is(err, lib.ErrSessionExpired) {
+			t.Errorf("Expected ErrSessionExpired, got %v", err)
+		}
+		if stored, _ := cs.GetUserSession(sessionID); stored != nil {
+			t.Error("Expected the expired session to be removed")
+		}
+	})
+}
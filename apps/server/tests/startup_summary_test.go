@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+)
+
+func TestFeatureSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		expected map[string]bool
+	}{
+		{
+			name: "all features disabled",
+			cfg:  &config.Config{},
+			expected: map[string]bool{
+				"audit": false, "health": false, "google_oauth": false,
+				"microsoft_oauth": false, "rate_limit": false, "circuit_alerts": false,
+				"notifications": false, "webhooks": false,
+			},
+		},
+		{
+			name: "all features enabled",
+			cfg: &config.Config{
+				Audit:        types.AuditConfig{Enabled: true},
+				Health:       types.HealthConfig{Enabled: true},
+				Google:       types.GoogleConfig{ClientID: "google-id", ClientSecret: "google-secret"},
+				Microsoft:    types.MicrosoftConfig{ClientID: "ms-id", ClientSecret: "ms-secret"},
+				RateLimit:    types.RateLimitConfig{Enabled: true},
+				Database:     types.DatabaseConfig{CircuitAlertWebhookURL: "https://hooks.example.com/services/secret-token"},
+				Notification: types.NotificationConfig{WebhookURL: "https://notify.example.com/hook"},
+				Webhook:      types.WebhookConfig{Enabled: true},
+			},
+			expected: map[string]bool{
+				"audit": true, "health": true, "google_oauth": true,
+				"microsoft_oauth": true, "rate_limit": true, "circuit_alerts": true,
+				"notifications": true, "webhooks": true,
+			},
+		},
+		{
+			name: "oauth without secret is disabled",
+			cfg: &config.Config{
+				Google:    types.GoogleConfig{ClientID: "google-id"},
+				RateLimit: types.RateLimitConfig{Enabled: true},
+			},
+			expected: map[string]bool{
+				"audit": false, "health": false, "google_oauth": false,
+				"microsoft_oauth": false, "rate_limit": true, "circuit_alerts": false,
+				"notifications": false, "webhooks": false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := tt.cfg.FeatureSummary()
+			if len(summary) != len(tt.expected) {
+				t.Errorf("expected %d features, got %d", len(tt.expected), len(summary))
+			}
+			for feature, enabled := range tt.expected {
+				settings, ok := summary[feature]
+				if !ok {
+					t.Errorf("expected feature %q in summary", feature)
+					continue
+				}
+				if settings["enabled"] != enabled {
+					t.Errorf("expected %s.enabled=%v, got %v", feature, enabled, settings["enabled"])
+				}
+			}
+		})
+	}
+}
+
+func TestStartupSummaryRedactsSecrets(t *testing.T) {
+	cfg := &config.Config{
+		Environment: "production",
+		Google:      types.GoogleConfig{ClientID: "google-id", ClientSecret: "google-secret"},
+		Microsoft:   types.MicrosoftConfig{ClientID: "ms-id", ClientSecret: "ms-secret", Tenant: "common"},
+		RateLimit: types.RateLimitConfig{
+			Enabled:     true,
+			Max:         100,
+			Window:      time.Minute,
+			ExemptCIDRs: []string{"10.0.0.0/8"},
+			ExemptKeys:  []string{"exempt-key-value"},
+		},
+		Database: types.DatabaseConfig{
+			Password:               "db-password",
+			CircuitAlertWebhookURL: "https://hooks.example.com/services/secret-token",
+		},
+		Auth:         types.AuthConfig{AccessTokenSecret: "jwt-secret"},
+		Notification: types.NotificationConfig{WebhookURL: "https://notify.example.com/hook-secret"},
+	}
+
+	var buf bytes.Buffer
+	logger := &config.Logger{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+	logger.StartupSummary(cfg)
+
+	output := buf.String()
+	if strings.Count(output, "\n") != 1 {
+		t.Fatalf("expected a single log line, got:\n%s", output)
+	}
+
+	for _, want := range []string{
+		"Startup summary",
+		"google_oauth.enabled=true",
+		"rate_limit.enabled=true",
+		"rate_limit.exempt_keys=1",
+		"audit.enabled=false",
+		"circuit_alerts.webhook_host=hooks.example.com",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in startup summary:\n%s", want, output)
+		}
+	}
+
+	for _, secret := range []string{"google-secret", "ms-secret", "exempt-key-value", "db-password", "jwt-secret", "secret-token", "hook-secret"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("startup summary leaked secret %q:\n%s", secret, output)
+		}
+	}
+}
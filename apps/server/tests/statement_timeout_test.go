@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/services"
+)
+
+func TestStatementTimeoutConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"default", "", 10 * time.Second, false},
+		{"custom", "2s", 2 * time.Second, false},
+		{"disabled", "0s", 0, false},
+		{"negative", "-1s", -time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_STATEMENT_TIMEOUT", tt.value)
+
+			domains := config.LoadDomainConfigs()
+			if domains.Database.StatementTimeout != tt.expected {
+				t.Errorf("Expected statement timeout %v, got %v", tt.expected, domains.Database.StatementTimeout)
+			}
+
+			err := domains.Database.Validate()
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestExecuteQueryAppliesStatementTimeout(t *testing.T) {
+	cfg := requireDatabase(t)
+
+	previous := cfg.Database.StatementTimeout
+	cfg.Database.StatementTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { cfg.Database.StatementTimeout = previous })
+
+	start := time.Now()
+	_, err := database.ExecuteQuery[any](services.Query().SetRawSQL("SELECT pg_sleep(2)"))
+	if err == nil {
+		t.Fatal("Expected the query to be cancelled by the statement timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the query to be cancelled quickly, it took %v", elapsed)
+	}
+}
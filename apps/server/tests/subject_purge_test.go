@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/google/uuid"
+)
+
+func TestPurgeSubjectRemovesRelatedRows(t *testing.T) {
+	requireDatabase(t)
+
+	// A subject with a teacher mapping, a deadline and a submission on it
+	teacherID, studentID := createTestUser(t, lib.RoleTeacher), createTestUser(t, lib.RoleStudent)
+	subjectID := createTestSubject(t, "Purge test subject", teacherID)
+	createTestSubmission(t, createTestDeadline(t, subjectID, teacherID), studentID)
+
+	if err := services.NewSubjectService().PurgeSubject(subjectID); err != nil {
+		t.Fatalf("Failed to purge subject: %v", err)
+	}
+
+	for table, count := range countPurgeRows(t, subjectID) {
+		if count != 0 {
+			t.Errorf("Expected no %s rows after the purge, got %d", table, count)
+		}
+	}
+}
+
+func TestPurgeSubjectIsAtomic(t *testing.T) {
+	requireDatabase(t)
+
+	// A subject with a teacher mapping, a deadline and a submission on it
+	teacherID, studentID := createTestUser(t, lib.RoleTeacher), createTestUser(t, lib.RoleStudent)
+	subjectID := createTestSubject(t, "Purge test subject", teacherID)
+	createTestSubmission(t, createTestDeadline(t, subjectID, teacherID), studentID)
+
+	// A row referencing the subject without a cascading foreign key makes the final delete fail,
+	// after the submissions, deadlines and teacher mappings were already deleted in the transaction
+	guard := "purge_guard_" + uuid.NewString()[:8]
+	db := database.GetInstance()
+	if _, err := db.Exec(fmt.Sprintf("CREATE TABLE %s (subject_id uuid REFERENCES subjects (id))", guard)); err != nil {
+		t.Skipf("Failed to create guard table: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", guard)); err != nil {
+			t.Logf("Failed to drop guard table: %v", err)
+		}
+	})
+	if _, err := db.Exec(fmt.Sprintf("INSERT INTO %s (subject_id) VALUES (?)", guard), subjectID); err != nil {
+		t.Fatalf("Failed to insert guard row: %v", err)
+	}
+
+	if err := services.NewSubjectService().PurgeSubject(subjectID); err == nil {
+		t.Fatal("Expected the purge to fail while the subject is still referenced")
+	}
+
+	for table, count := range countPurgeRows(t, subjectID) {
+		if count != 1 {
+			t.Errorf("Expected the %s row to be kept after the failed purge, got %d rows", table, count)
+		}
+	}
+}
+
+func TestPurgeSubjectNotFound(t *testing.T) {
+	requireDatabase(t)
+
+	err := services.NewSubjectService().PurgeSubject(uuid.New())
+	if !errors.Is(err, lib.ErrSubjectNotFound) {
+		t.Errorf("Expected ErrSubjectNotFound, got %v", err)
+	}
+}
+
+// countPurgeRows counts the rows of the subject in every table touched by a purge
+func countPurgeRows(t *testing.T, subjectID uuid.UUID) map[string]int64 {
+	t.Helper()
+
+	type rowCount struct {
+		Count int64
+	}
+
+	queries := map[string]string{
+		lib.TableSubjects:        "SELECT count(*) AS count FROM subjects WHERE id = ?",
+		lib.TableSubjectTeachers: "SELECT count(*) AS count FROM subject_teachers WHERE subject_id = ?",
+		lib.TableDeadlines:       "SELECT count(*) AS count FROM deadlines WHERE subject_id = ?",
+		lib.TableSubmissions:     "SELECT count(*) AS count FROM submissions WHERE deadline_id IN (SELECT id FROM deadlines WHERE subject_id = ?)",
+	}
+
+	counts := make(map[string]int64, len(queries))
+	for table, sql := range queries {
+		result, err := database.ExecuteQuery[rowCount](services.Query().SetRawSQL(sql, subjectID))
+		if err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if result.Single == nil {
+			t.Fatalf("Expected a count for %s", table)
+		}
+		counts[table] = result.Single.Count
+	}
+	return counts
+}
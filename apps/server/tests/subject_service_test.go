@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+func TestValidateSubjectFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		subjectName   string
+		code          string
+		color         string
+		expectedField string
+	}{
+		{"valid", "Mathematics", "MATH", "#1E88E5", ""},
+		{"code and color are optional", "Mathematics", "", "", ""},
+		{"lower-case color", "Mathematics", "", "#1e88e5", ""},
+		{"missing name", "", "MATH", "", "name"},
+		{"blank name", "   ", "MATH", "", "name"},
+		{"name too long", strings.Repeat("a", types.MaxSubjectNameLength+1), "", "", "name"},
+		{"code too long", "Mathematics", strings.Repeat("A", types.MaxSubjectCodeLength+1), "", "code"},
+		{"color without hash", "Mathematics", "", "1E88E5", "color"},
+		{"short color", "Mathematics", "", "#FFF", "color"},
+		{"named color", "Mathematics", "", "blue", "color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := services.ValidateSubjectFields(tt.subjectName, tt.code, tt.color)
+			if tt.expectedField == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			var appErr *lib.AppError
+			if !errors.As(err, &appErr) || appErr.Status != http.StatusUnprocessableEntity {
+				t.Fatalf("Expected a validation error, got %v", err)
+			}
+			fields, _ := appErr.Details["validation_errors"].([]types.ValidationError)
+			if len(fields) != 1 || fields[0].Field != tt.expectedField {
+				t.Errorf("Expected a validation error for %s, got %v", tt.expectedField, appErr.Details)
+			}
+		})
+	}
+}
+
+func TestSubjectManagementNotFound(t *testing.T) {
+	requireDatabase(t)
+
+	ss := services.NewSubjectService()
+	actorID, subjectID := uuid.New(), uuid.New()
+	name := "Unknown"
+
+	if _, err := ss.UpdateSubject(actorID, subjectID, &types.UpdateSubjectRequest{Name: &name}); !errors.Is(err, lib.ErrSubjectNotFound) {
+		t.Errorf("Expected ErrSubjectNotFound from UpdateSubject, got %v", err)
+	}
+	if err := ss.DeactivateSubject(actorID, subjectID); !errors.Is(err, lib.ErrSubjectNotFound) {
+		t.Errorf("Expected ErrSubjectNotFound from DeactivateSubject, got %v", err)
+	}
+	if err := ss.AssignTeacher(actorID, subjectID, uuid.New()); !errors.Is(err, lib.ErrSubjectNotFound) {
+		t.Errorf("Expected ErrSubjectNotFound from AssignTeacher, got %v", err)
+	}
+	if err := ss.RemoveTeacher(actorID, subjectID, uuid.New()); !errors.Is(err, lib.ErrTeacherNotAssigned) {
+		t.Errorf("Expected ErrTeacherNotAssigned from RemoveTeacher, got %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+// memoryLocker is an in-process DistributedLocker for tests
+type memoryLocker struct {
+	mu         sync.Mutex
+	held       map[string]string
+	acquireErr error
+}
+
+func newMemoryLocker() *memoryLocker {
+	return &memoryLocker{held: make(map[string]string)}
+}
+
+func (ml *memoryLocker) AcquireLock(key string, ttl time.Duration) (string, bool, error) {
+	if ml.acquireErr != nil {
+		return "", false, ml.acquireErr
+	}
+
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	if _, ok := ml.held[key]; ok {
+		return "", false, nil
+	}
+	token := uuid.NewString()
+	ml.held[key] = token
+	return token, true, nil
+}
+
+func (ml *memoryLocker) ReleaseLock(key, token string) error {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+	if ml.held[key] == token {
+		delete(ml.held, key)
+	}
+	return nil
+}
+
+func TestWithLockSerializesCheckThenInsert(t *testing.T) {
+	locker := newMemoryLocker()
+
+	// Simulates the select-then-insert of CreateOrUpdateSubmission against a table without constraints
+	var rowsMu sync.Mutex
+	rows := 0
+	createOrUpdate := func() error {
+		rowsMu.Lock()
+		exists := rows > 0
+		rowsMu.Unlock()
+
+		time.Sleep(5 * time.Millisecond) // widen the race window
+
+		if !exists {
+			rowsMu.Lock()
+			rows++
+			rowsMu.Unlock()
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- services.WithLock(locker, "submission:d:s", time.Second, 2*time.Second, createOrUpdate)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if rows != 1 {
+		t.Errorf("Expected exactly 1 row after parallel requests, got %d", rows)
+	}
+}
+
+func TestWithLockTimesOut(t *testing.T) {
+	locker := newMemoryLocker()
+	if _, ok, _ := locker.AcquireLock("busy", time.Second); !ok {
+		t.Fatal("Failed to take the lock for the test")
+	}
+
+	called := false
+	err := services.WithLock(locker, "busy", time.Second, 60*time.Millisecond, func() error {
+		called = true
+		return nil
+	})
+
+	if !errors.Is(err, lib.ErrLockTimeout) {
+		t.Errorf("Expected ErrLockTimeout, got %v", err)
+	}
+	if called {
+		t.Error("Expected fn not to run without the lock")
+	}
+}
+
+func TestWithLockBackendFailure(t *testing.T) {
+	locker := newMemoryLocker()
+	locker.acquireErr = errors.New("connection refused")
+
+	err := services.WithLock(locker, "key", time.Second, time.Second, func() error {
+		t.Error("Expected fn not to run when the lock backend fails")
+		return nil
+	})
+
+	if !errors.Is(err, lib.ErrServiceUnavailable) {
+		t.Errorf("Expected ErrServiceUnavailable, got %v", err)
+	}
+}
+
+func TestWithLockReleasesAfterError(t *testing.T) {
+	locker := newMemoryLocker()
+	failure := errors.New("insert failed")
+
+	if err := services.WithLock(locker, "key", time.Second, 0, func() error { return failure }); !errors.Is(err, failure) {
+		t.Fatalf("Expected fn error to be returned, got %v", err)
+	}
+	if err := services.WithLock(locker, "key", time.Second, 0, func() error { return nil }); err != nil {
+		t.Errorf("Expected lock to be released after a failed call, got %v", err)
+	}
+}
+
+// countSubmissions returns the number of submission rows of the student for the deadline
+func countSubmissions(t *testing.T, deadlineID, studentID uuid.UUID) int {
+	t.Helper()
+
+	query := services.Query().SetOperation("select").SetTable("submissions")
+	query.Where = map[string]any{"submissions.deadline_id": deadlineID, "student_id": studentID}
+	result, err := database.ExecuteQuery[types.Submission](query)
+	if err != nil {
+		t.Fatalf("Failed to count submissions: %v", err)
+	}
+	return len(result.Data)
+}
+
+// TestCreateOrUpdateSubmissionConcurrent runs parallel submissions against a real database and Redis
+func TestCreateOrUpdateSubmissionConcurrent(t *testing.T) {
+	requireDatabase(t)
+	teacherID, studentID := createTestUser(t, lib.RoleTeacher), createTestUser(t, lib.RoleStudent)
+	deadlineID := createTestDeadline(t, createTestSubject(t, "Submission test subject", teacherID), teacherID)
+	if err := services.NewCacheService().Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	ds := services.NewDeadlineService()
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := types.CreateSubmissionRequest{FileIDs: []string{"file-" + uuid.NewString()}, Message: "attempt"}
+			_, err := ds.CreateOrUpdateSubmission(context.Background(), deadlineID, studentID, req, time.Now().UTC().Add(time.Duration(i)*time.Millisecond).Format(time.RFC3339))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unexpected submission error: %v", err)
+		}
+	}
+
+	if n := countSubmissions(t, deadlineID, studentID); n != 1 {
+		t.Errorf("Expected exactly 1 submission row, got %d", n)
+	}
+}
+
+// noopLocker grants every lock, leaving concurrent submissions to the database
+type noopLocker struct{}
+
+func (noopLocker) AcquireLock(key string, ttl time.Duration) (string, bool, error) {
+	return "token", true, nil
+}
+
+func (noopLocker) ReleaseLock(key, token string) error {
+	return nil
+}
+
+// TestCreateOrUpdateSubmissionUpsert fires two submissions at once without the distributed lock
+// and checks that the upsert alone keeps a single row and reports exactly one insert
+func TestCreateOrUpdateSubmissionUpsert(t *testing.T) {
+	requireDatabase(t)
+	teacherID, studentID := createTestUser(t, lib.RoleTeacher), createTestUser(t, lib.RoleStudent)
+	deadlineID := createTestDeadline(t, createTestSubject(t, "Submission test subject", teacherID), teacherID)
+
+	ds := services.NewDeadlineServiceWithLocker(noopLocker{})
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	responses := make([]*types.SubmissionResponse, 2)
+	errs := make([]error, 2)
+	for i := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			req := types.CreateSubmissionRequest{FileIDs: []string{"file-" + uuid.NewString()}, Message: "attempt"}
+			responses[i], errs[i] = ds.CreateOrUpdateSubmission(context.Background(), deadlineID, studentID, req, time.Now().UTC().Format(time.RFC3339))
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	created := 0
+	for i := range 2 {
+		if errs[i] != nil {
+			t.Fatalf("Unexpected submission error: %v", errs[i])
+		}
+		if responses[i].Created {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly 1 submission to be reported as created, got %d", created)
+	}
+	if responses[0].ID != responses[1].ID {
+		t.Errorf("Expected both submissions to resolve to the same row, got %s and %s", responses[0].ID, responses[1].ID)
+	}
+	if n := countSubmissions(t, deadlineID, studentID); n != 1 {
+		t.Errorf("Expected exactly 1 submission row, got %d", n)
+	}
+}
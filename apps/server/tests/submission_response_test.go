@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+func TestSubmissionForRole(t *testing.T) {
+	submission := &types.SubmissionResponse{
+		ID:            uuid.New(),
+		DeadlineID:    uuid.New(),
+		StudentID:     uuid.New(),
+		FileIDs:       []string{"file-1"},
+		Message:       "My hand-in",
+		Feedback:      "Well done",
+		DraftFeedback: "Check for plagiarism before publishing",
+	}
+
+	tests := []struct {
+		name         string
+		role         string
+		expectDraft  bool
+		expectedKeys []string
+	}{
+		{"student omits draft feedback", lib.RoleStudent, false, []string{"id", "message", "feedback", "file_ids"}},
+		{"teacher includes draft feedback", lib.RoleTeacher, true, []string{"id", "message", "feedback", "draft_feedback"}},
+		{"admin includes draft feedback", lib.RoleAdmin, true, []string{"draft_feedback"}},
+		{"unknown role gets student view", "guest", false, []string{"feedback"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(lib.SubmissionForRole(submission, tt.role))
+			if err != nil {
+				t.Fatalf("Failed to marshal submission: %v", err)
+			}
+
+			var fields map[string]any
+			if err := json.Unmarshal(body, &fields); err != nil {
+				t.Fatalf("Failed to unmarshal submission: %v", err)
+			}
+
+			for _, key := range tt.expectedKeys {
+				if _, ok := fields[key]; !ok {
+					t.Errorf("Expected field %q in response %s", key, body)
+				}
+			}
+
+			draft, hasDraft := fields["draft_feedback"]
+			if hasDraft != tt.expectDraft {
+				t.Errorf("Expected draft_feedback present=%v, got %v in %s", tt.expectDraft, hasDraft, body)
+			}
+			if hasDraft && draft != submission.DraftFeedback {
+				t.Errorf("Expected draft feedback %q, got %v", submission.DraftFeedback, draft)
+			}
+		})
+	}
+}
+
+func TestSubmissionsForRoleStripsEveryEntry(t *testing.T) {
+	submissions := []*types.SubmissionResponse{
+		{ID: uuid.New(), DraftFeedback: "draft one"},
+		{ID: uuid.New(), DraftFeedback: "draft two"},
+	}
+
+	body, err := json.Marshal(lib.SubmissionsForRole(submissions, lib.RoleStudent))
+	if err != nil {
+		t.Fatalf("Failed to marshal submissions: %v", err)
+	}
+
+	var entries []map[string]any
+	if err := json.Unmarshal(body, &entries); err != nil {
+		t.Fatalf("Failed to unmarshal submissions: %v", err)
+	}
+	if len(entries) != len(submissions) {
+		t.Fatalf("Expected %d submissions, got %d", len(submissions), len(entries))
+	}
+	for i, entry := range entries {
+		if _, ok := entry["draft_feedback"]; ok {
+			t.Errorf("Submission %d leaked draft feedback: %v", i, entry)
+		}
+	}
+
+	var nilSubmission *types.SubmissionResponse
+	if got := nilSubmission.ForStudent(); got != nil {
+		t.Errorf("Expected nil student view for nil submission, got %+v", got)
+	}
+}
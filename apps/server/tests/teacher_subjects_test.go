@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/google/uuid"
+)
+
+func TestGetSubjectsForTeacher(t *testing.T) {
+	requireDatabase(t)
+
+	subjectService := services.NewSubjectService()
+
+	t.Run("teacher with multiple subjects", func(t *testing.T) {
+		teacher := createTestUser(t, lib.RoleTeacher)
+		math := createTestSubject(t, "Teacher subjects test B", teacher)
+		biology := createTestSubject(t, "Teacher subjects test A", teacher)
+
+		// A subject taught by someone else must not show up
+		createTestSubject(t, "Teacher subjects test C", createTestUser(t, lib.RoleTeacher))
+
+		subjects, err := subjectService.GetSubjectsForTeacher(teacher)
+		if err != nil {
+			t.Fatalf("Failed to get teacher subjects: %v", err)
+		}
+
+		ids := make([]uuid.UUID, 0, len(subjects))
+		for _, subject := range subjects {
+			ids = append(ids, subject.Id)
+		}
+		// Subjects are ordered by name
+		if want := []uuid.UUID{biology, math}; !slices.Equal(ids, want) {
+			t.Errorf("Expected subjects %v, got %v", want, ids)
+		}
+	})
+
+	t.Run("teacher without subjects", func(t *testing.T) {
+		teacher := createTestUser(t, lib.RoleTeacher)
+
+		subjects, err := subjectService.GetSubjectsForTeacher(teacher)
+		if err != nil {
+			t.Fatalf("Failed to get teacher subjects: %v", err)
+		}
+		if subjects == nil || len(subjects) != 0 {
+			t.Errorf("Expected an empty list, got %v", subjects)
+		}
+	})
+}
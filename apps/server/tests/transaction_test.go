@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/go-pg/pg/v10"
+	"github.com/google/uuid"
+)
+
+func TestQueryParamsWithTx(t *testing.T) {
+	tx := &pg.Tx{}
+
+	query := types.NewQuery().SetOperation("select").SetTable("submissions").WithTx(tx)
+	if query.Tx != tx {
+		t.Fatal("Expected WithTx to set the transaction")
+	}
+	if clone := query.Clone(); clone.Tx != tx {
+		t.Error("Expected a clone to run on the same transaction")
+	}
+	if types.NewQuery().Tx != nil {
+		t.Error("Expected new queries to use the connection pool")
+	}
+}
+
+// TestExecuteQueryWithTx checks that builder queries on a transaction commit and roll back with it
+func TestExecuteQueryWithTx(t *testing.T) {
+	requireDatabase(t)
+
+	service := "tx-test-" + uuid.NewString()
+	t.Cleanup(func() {
+		query := services.Query().SetOperation("delete").SetTable(lib.TableHealthLogs).
+			SetWhereRaw("health_logs.service = ?", service)
+		if _, err := database.ExecuteQuery[any](query); err != nil {
+			t.Logf("Failed to clean up health logs: %v", err)
+		}
+	})
+
+	insert := func(tx *pg.Tx, requestCount int) error {
+		query := services.Query().SetOperation("insert").SetTable(lib.TableHealthLogs).SetData(map[string]any{
+			"timestamp":       time.Now().UTC(),
+			"service":         service,
+			"status_code":     200,
+			"request_count":   requestCount,
+			"error_count":     0,
+			"average_latency": 1.0,
+			"time_span":       60,
+		}).WithTx(tx)
+		_, err := database.ExecuteQuery[any](query)
+		return err
+	}
+	count := func() int {
+		query := services.Query().SetRawSQL("SELECT COUNT(*) AS count FROM health_logs WHERE service = ?", service)
+		result, err := database.ExecuteQuery[struct{ Count int }](query)
+		if err != nil || result.Single == nil {
+			t.Fatalf("Failed to count health logs: %v", err)
+		}
+		return result.Single.Count
+	}
+
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+	err := database.Transaction(ctx, func(tx *pg.Tx) error {
+		if err := insert(tx, 1); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Expected the transaction error, got %v", err)
+	}
+	if n := count(); n != 0 {
+		t.Fatalf("Expected the insert to be rolled back, found %d rows", n)
+	}
+
+	err = database.Transaction(ctx, func(tx *pg.Tx) error {
+		if err := insert(tx, 1); err != nil {
+			return err
+		}
+		return insert(tx, 2)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected transaction error: %v", err)
+	}
+	if n := count(); n != 2 {
+		t.Errorf("Expected both inserts to be committed, found %d rows", n)
+	}
+}
+
+func TestQueryParamsForUpdateValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    *types.QueryParams
+		expected error
+	}{
+		{"select in a transaction", types.NewQuery().SetOperation("select").SetForUpdate(true).WithTx(&pg.Tx{}), nil},
+		{"select without a transaction", types.NewQuery().SetOperation("select").SetForUpdate(true), types.ErrForUpdateWithoutTx},
+		{"update", types.NewQuery().SetOperation("update").AddData("a", 1).SetWhereRaw("id = ?", 1).SetForUpdate(true).WithTx(&pg.Tx{}), types.ErrForUpdateNotSelect},
+		{"disabled", types.NewQuery().SetOperation("select").SetForUpdate(false), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query.Validate(); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// lockedDeadline is a deadline row selected for update in tests
+type lockedDeadline struct {
+	tableName struct{} `pg:"deadlines,alias:deadlines"`
+
+	ID uuid.UUID
+}
+
+// TestSelectForUpdateSerializesSubmissions holds a row lock on the deadline and checks that the
+// first submission to it waits for the lock, even without the distributed lock
+func TestSelectForUpdateSerializesSubmissions(t *testing.T) {
+	requireDatabase(t)
+	teacherID, studentID := createTestUser(t, lib.RoleTeacher), createTestUser(t, lib.RoleStudent)
+	deadlineID := createTestDeadline(t, createTestSubject(t, "Submission test subject", teacherID), teacherID)
+
+	ds := services.NewDeadlineServiceWithLocker(noopLocker{})
+	done := make(chan error, 1)
+	err := database.Transaction(context.Background(), func(tx *pg.Tx) error {
+		lock := services.Query().
+			SetOperation("select").
+			SetSelect([]string{"id"}).
+			SetWhereRaw("deadlines.id = ?", deadlineID).
+			SetForUpdate(true).
+			WithTx(tx)
+		result, err := database.ExecuteQuery[lockedDeadline](lock)
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(result.Query, "FOR UPDATE") {
+			t.Errorf("Expected a locking select, got %q", result.Query)
+		}
+		if len(result.Data) != 1 {
+			t.Fatalf("Expected to lock 1 deadline, got %d", len(result.Data))
+		}
+
+		// No submission exists yet, so only the deadline lock can hold this one back
+		go func() {
+			req := types.CreateSubmissionRequest{FileIDs: []string{"file-1"}, Message: "first"}
+			_, err := ds.CreateOrUpdateSubmission(context.Background(), deadlineID, studentID, req, time.Now().UTC().Format(time.RFC3339))
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			t.Errorf("Expected the submission to wait for the row lock, it finished with %v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Locking transaction failed: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected submission error after the lock was released: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Submission did not continue after the lock was released")
+	}
+	if n := countSubmissions(t, deadlineID, studentID); n != 1 {
+		t.Errorf("Expected exactly 1 submission row, got %d", n)
+	}
+}
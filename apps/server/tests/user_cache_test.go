@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+func TestCacheUserTTLConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"default", "", 5 * time.Minute, false},
+		{"custom", "90s", 90 * time.Second, false},
+		{"zero", "0s", 0, true},
+		{"negative", "-1m", -time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ACCESS_TOKEN_SECRET", "test-access-token-secret")
+			t.Setenv("REFRESH_TOKEN_SECRET", "test-refresh-token-secret")
+			t.Setenv("CACHE_USER_TTL", tt.value)
+
+			domains := config.LoadDomainConfigs()
+			if domains.Auth.CacheUserTTL != tt.expected {
+				t.Errorf("Expected user cache TTL %v, got %v", tt.expected, domains.Auth.CacheUserTTL)
+			}
+
+			err := domains.Auth.Validate(domains.App.Environment)
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestUserCacheNeverStoresPasswordHash(t *testing.T) {
+	cfg := loadTestConfig(t)
+
+	cs := services.NewCacheService()
+	if err := cs.Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	user := &types.User{
+		Id:           uuid.New(),
+		Username:     "cache-test",
+		Email:        "cache-test@example.com",
+		Role:         lib.RoleStudent,
+		PasswordHash: "$argon2id$v=19$m=65536,t=3,p=2$secret",
+	}
+	key := "user:" + user.Id.String()
+	t.Cleanup(func() {
+		if err := cs.DeleteUserFromCache(user.Id); err != nil {
+			t.Logf("Failed to clean up %s: %v", key, err)
+		}
+	})
+
+	version, err := cs.UserCacheVersion(user.Id)
+	if err != nil {
+		t.Fatalf("UserCacheVersion failed: %v", err)
+	}
+	if err := cs.SetUserInCache(user, version); err != nil {
+		t.Fatalf("SetUserInCache failed: %v", err)
+	}
+	if user.PasswordHash == "" {
+		t.Error("Expected the caller's user to keep its password hash")
+	}
+
+	raw, err := cs.Get(key)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if strings.Contains(raw, "argon2id") || strings.Contains(raw, "password") {
+		t.Errorf("Expected the cached record without the password hash, got %s", raw)
+	}
+	if ttl, err := cs.TTL(key); err != nil || ttl <= 0 || ttl > cfg.Auth.CacheUserTTL {
+		t.Errorf("Expected a TTL of at most %v, got %v (err %v)", cfg.Auth.CacheUserTTL, ttl, err)
+	}
+
+	cached, err := cs.GetUserFromCache(user.Id)
+	if err != nil || cached == nil {
+		t.Fatalf("Expected the cached user, got %v (err %v)", cached, err)
+	}
+	if cached.Email != user.Email || cached.Role != user.Role || cached.PasswordHash != "" {
+		t.Errorf("Unexpected cached user %+v", cached)
+	}
+
+	if err := cs.DeleteUserFromCache(user.Id); err != nil {
+		t.Fatalf("DeleteUserFromCache failed: %v", err)
+	}
+	if cached, err := cs.GetUserFromCache(user.Id); err != nil || cached != nil {
+		t.Errorf("Expected no cached user after invalidation, got %v (err %v)", cached, err)
+	}
+}
+
+func TestUserCacheSkipsRecordReadBeforeInvalidation(t *testing.T) {
+	loadTestConfig(t)
+
+	cs := services.NewCacheService()
+	if err := cs.Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	user := &types.User{Id: uuid.New(), Username: "cache-version-test", Email: "cache-version-test@example.com", Role: lib.RoleStudent}
+	t.Cleanup(func() {
+		_ = cs.Delete("user:" + user.Id.String())
+		_ = cs.Delete("user_version:" + user.Id.String())
+	})
+
+	// A lookup reads the version and then the database, meanwhile the user is invalidated
+	version, err := cs.UserCacheVersion(user.Id)
+	if err != nil {
+		t.Fatalf("UserCacheVersion failed: %v", err)
+	}
+	if err := cs.DeleteUserFromCache(user.Id); err != nil {
+		t.Fatalf("DeleteUserFromCache failed: %v", err)
+	}
+
+	if err := cs.SetUserInCache(user, version); err != nil {
+		t.Fatalf("SetUserInCache failed: %v", err)
+	}
+	if cached, err := cs.GetUserFromCache(user.Id); err != nil || cached != nil {
+		t.Errorf("Expected the record read before the invalidation not to be cached, got %v (err %v)", cached, err)
+	}
+
+	// A lookup that starts after the invalidation caches the user again
+	version, err = cs.UserCacheVersion(user.Id)
+	if err != nil {
+		t.Fatalf("UserCacheVersion failed: %v", err)
+	}
+	if err := cs.SetUserInCache(user, version); err != nil {
+		t.Fatalf("SetUserInCache failed: %v", err)
+	}
+	if cached, err := cs.GetUserFromCache(user.Id); err != nil || cached == nil {
+		t.Errorf("Expected the user to be cached with the current version, got %v (err %v)", cached, err)
+	}
+}
+
+func TestCacheJSONHelpers(t *testing.T) {
+	loadTestConfig(t)
+
+	cs := services.NewCacheService()
+	if err := cs.Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	key := "cache-json-test:" + uuid.NewString()
+	t.Cleanup(func() { _ = cs.Delete(key) })
+
+	var missing map[string]int
+	if found, err := cs.GetJSON(key, &missing); err != nil || found {
+		t.Fatalf("Expected a missing key to be reported as not found, got %v (err %v)", found, err)
+	}
+
+	if err := cs.SetJSON(key, map[string]int{"a": 1}, time.Minute); err != nil {
+		t.Fatalf("SetJSON failed: %v", err)
+	}
+	var value map[string]int
+	if found, err := cs.GetJSON(key, &value); err != nil || !found || value["a"] != 1 {
+		t.Errorf("Expected the stored value, got %v (found %v, err %v)", value, found, err)
+	}
+}
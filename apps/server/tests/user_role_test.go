@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+func TestUpdateUserRoleRejectsUnknownRole(t *testing.T) {
+	loadTestConfig(t)
+
+	userService := services.NewUserService()
+	for _, role := range []string{"", "Admin", "superuser", "teacher "} {
+		if _, err := userService.UpdateUserRole(context.Background(), uuid.New(), uuid.New(), role); !errors.Is(err, lib.ErrInvalidRole) {
+			t.Errorf("Expected ErrInvalidRole for %q, got %v", role, err)
+		}
+	}
+}
+
+// TestUpdateUserRole changes roles against a real database
+func TestUpdateUserRole(t *testing.T) {
+	requireDatabase(t)
+
+	userService := services.NewUserService()
+	admin := createTestUser(t, lib.RoleAdmin)
+	student := createTestUser(t, lib.RoleStudent)
+
+	user, err := userService.UpdateUserRole(context.Background(), admin, student, lib.RoleTeacher)
+	if err != nil {
+		t.Fatalf("Failed to promote student: %v", err)
+	}
+	if user.Id != student || user.Role != lib.RoleTeacher {
+		t.Errorf("Expected %s to be a teacher, got %+v", student, user)
+	}
+
+	if _, err := userService.UpdateUserRole(context.Background(), admin, uuid.New(), lib.RoleTeacher); !errors.Is(err, lib.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound for an unknown user, got %v", err)
+	}
+
+	// Demoting an admin works while another admin remains
+	if _, err := userService.UpdateUserRole(context.Background(), admin, student, lib.RoleAdmin); err != nil {
+		t.Fatalf("Failed to promote teacher to admin: %v", err)
+	}
+	if _, err := userService.UpdateUserRole(context.Background(), admin, student, lib.RoleStudent); err != nil {
+		t.Fatalf("Failed to demote second admin: %v", err)
+	}
+
+	admins, err := database.ExecuteQuery[types.User](services.Query().SetRawSQL(`SELECT id FROM users WHERE role = ?`, lib.RoleAdmin))
+	if err != nil {
+		t.Fatalf("Failed to count admins: %v", err)
+	}
+	if len(admins.Data) != 1 {
+		t.Skipf("Database has %d admins, skipping the last admin check", len(admins.Data))
+	}
+
+	if _, err := userService.UpdateUserRole(context.Background(), admin, admin, lib.RoleStudent); !errors.Is(err, lib.ErrLastAdmin) {
+		t.Errorf("Expected ErrLastAdmin when the last admin demotes themselves, got %v", err)
+	}
+}
+
+func TestUpdateUserRoleInvalidatesCachedUser(t *testing.T) {
+	requireDatabase(t)
+
+	cs := services.NewCacheService()
+	if err := cs.Ping(); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	as := services.NewAuthService()
+	admin := createTestUser(t, lib.RoleAdmin)
+	student := createTestUser(t, lib.RoleStudent)
+	t.Cleanup(func() { _ = cs.DeleteUserFromCache(student) })
+
+	// The first lookup caches the student
+	user, err := as.GetUserByID(context.Background(), student)
+	if err != nil {
+		t.Fatalf("Failed to fetch user: %v", err)
+	}
+	if cached, err := cs.GetUserFromCache(student); err != nil || cached == nil || cached.Role != lib.RoleStudent {
+		t.Fatalf("Expected the student to be cached, got %v (err %v)", cached, err)
+	}
+
+	// A lookup that read the student before the role change tries to cache it afterwards
+	version, err := cs.UserCacheVersion(student)
+	if err != nil {
+		t.Fatalf("UserCacheVersion failed: %v", err)
+	}
+	if _, err := services.NewUserService().UpdateUserRole(context.Background(), admin, student, lib.RoleTeacher); err != nil {
+		t.Fatalf("Failed to change role: %v", err)
+	}
+	if err := cs.SetUserInCache(user, version); err != nil {
+		t.Fatalf("SetUserInCache failed: %v", err)
+	}
+
+	user, err = as.GetUserByID(context.Background(), student)
+	if err != nil {
+		t.Fatalf("Failed to fetch user: %v", err)
+	}
+	if user.Role != lib.RoleTeacher {
+		t.Errorf("Expected the new role after the change, got %s", user.Role)
+	}
+}
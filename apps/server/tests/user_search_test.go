@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+)
+
+func TestEscapeLikePattern(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"jan", "jan"},
+		{"100%", `100\%`},
+		{"first_name", `first\_name`},
+		{`back\slash`, `back\\slash`},
+	}
+
+	for _, tt := range tests {
+		if got := types.EscapeLikePattern(tt.input); got != tt.expected {
+			t.Errorf("EscapeLikePattern(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestAddWhereContainsAny(t *testing.T) {
+	query := types.NewQuery().AddWhere("role", "teacher").AddWhereContainsAny("j_n", "users.username", "users.email")
+
+	if len(query.Where) != 1 {
+		t.Errorf("Expected the OR group to stay out of Where, got %v", query.Where)
+	}
+	if len(query.WhereAny) != 1 {
+		t.Fatalf("Expected one OR group, got %d", len(query.WhereAny))
+	}
+
+	group := query.WhereAny[0]
+	for _, key := range []string{"users.username ILIKE", "users.email ILIKE"} {
+		if group[key] != `%j\_n%` {
+			t.Errorf("Expected %q to match %q, got %v", key, `%j\_n%`, group[key])
+		}
+	}
+
+	column, operator := types.ParseWhereKey("users.email ILIKE")
+	if column != "users.email" || operator != "ILIKE" {
+		t.Errorf("Expected users.email and ILIKE, got %q and %q", column, operator)
+	}
+
+	if query.AddWhereAny(map[string]any{}); len(query.WhereAny) != 1 {
+		t.Errorf("Expected an empty group to be ignored, got %d groups", len(query.WhereAny))
+	}
+}
+
+func TestQueryCloneCopiesWhereAny(t *testing.T) {
+	query := types.NewQuery().AddWhereContainsAny("jan", "username")
+	clone := query.Clone()
+	clone.WhereAny[0]["email ILIKE"] = "%jan%"
+
+	if len(query.WhereAny[0]) != 1 {
+		t.Errorf("Expected the original OR group to be unchanged, got %v", query.WhereAny[0])
+	}
+}
+
+func TestSearchUsersValidation(t *testing.T) {
+	loadTestConfig(t)
+
+	userService := services.NewUserService()
+
+	_, _, err := userService.SearchUsers(context.Background(), strings.Repeat("a", 101), 1, 10)
+	var appErr *lib.AppError
+	if !errors.As(err, &appErr) || appErr.Status != 422 {
+		t.Errorf("Expected a validation error for a long search, got %v", err)
+	}
+
+	for _, tc := range []struct{ page, limit int }{{0, 10}, {1, 0}, {-1, -1}} {
+		if _, _, err := userService.SearchUsers(context.Background(), "jan", tc.page, tc.limit); !errors.Is(err, lib.ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for page %d and limit %d, got %v", tc.page, tc.limit, err)
+		}
+	}
+}
+
+// TestSearchUsers searches users against a real database
+func TestSearchUsers(t *testing.T) {
+	requireDatabase(t)
+
+	first := createTestUser(t, lib.RoleStudent)
+	second := createTestUser(t, lib.RoleTeacher)
+	userService := services.NewUserService()
+
+	// Both users are named test-user-<id>, the search is case-insensitive
+	users, total, err := userService.SearchUsers(context.Background(), "TEST-USER-"+first.String()[:8], 1, 10)
+	if err != nil {
+		t.Fatalf("SearchUsers failed: %v", err)
+	}
+	if total != 1 || len(users) != 1 || users[0].Id != first {
+		t.Fatalf("Expected only user %s, got %d users (total %d)", first, len(users), total)
+	}
+	if users[0].PasswordHash != "" {
+		t.Error("Expected no password hash in search results")
+	}
+
+	// Matches on the email domain too, one per page
+	users, total, err = userService.SearchUsers(context.Background(), "test-user-", 1, 1)
+	if err != nil {
+		t.Fatalf("SearchUsers failed: %v", err)
+	}
+	if total < 2 || len(users) != 1 {
+		t.Errorf("Expected one user on the page and at least 2 in total, got %d and %d", len(users), total)
+	}
+
+	// A wildcard in the search text is matched literally
+	users, _, err = userService.SearchUsers(context.Background(), "test%user", 1, 10)
+	if err != nil {
+		t.Fatalf("SearchUsers failed: %v", err)
+	}
+	for _, user := range users {
+		if user.Id == first || user.Id == second {
+			t.Errorf("Expected %% to be matched literally, got user %s", user.Id)
+		}
+	}
+}
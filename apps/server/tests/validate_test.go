@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/MonkyMars/PWS/types"
+	"github.com/MonkyMars/PWS/validate"
+)
+
+func TestValidateRules(t *testing.T) {
+	digits := regexp.MustCompile(`^[0-9]+$`)
+
+	tests := []struct {
+		name    string
+		value   string
+		rule    validate.Rule
+		message string
+	}{
+		{"required passes", "alice", validate.Required(), ""},
+		{"required rejects blank", "   ", validate.Required(), "name is required"},
+		{"min length passes", "abc", validate.MinLength(3), ""},
+		{"min length counts characters", "éé", validate.MinLength(3), "name must be at least 3 characters long"},
+		{"min length skips empty", "", validate.MinLength(3), ""},
+		{"max length rejects long", "abcd", validate.MaxLength(3), "name must not exceed 3 characters"},
+		{"email passes", "user@example.com", validate.Email(), ""},
+		{"email rejects missing domain", "user@", validate.Email(), "name must be a valid email address"},
+		{"email rejects display name", "User <user@example.com>", validate.Email(), "name must be a valid email address"},
+		{"email rejects spaces", "user name@example.com", validate.Email(), "name must be a valid email address"},
+		{"email accepts subdomain", "user+tag@mail.example.co.uk", validate.Email(), ""},
+		{"email rejects dotless domain", "user@localhost", validate.Email(), "name must have a valid domain, such as example.com"},
+		{"email rejects numeric tld", "user@example.123", validate.Email(), "name must have a valid domain, such as example.com"},
+		{"email rejects hyphen edge", "user@-example.com", validate.Email(), "name must have a valid domain, such as example.com"},
+		{"email rejects empty label", "user@example..com", validate.Email(), "name must be a valid email address"},
+		{"matches passes", "123", validate.Matches(digits, "name must be digits"), ""},
+		{"matches rejects", "12a", validate.Matches(digits, "name must be digits"), "name must be digits"},
+		{"equal to rejects", "a", validate.EqualTo("b", "other"), "name must match other"},
+		{"check reports error", "x", validate.Check(func(string) error { return errors.New("bad value") }), "bad value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if message := tt.rule("name", tt.value); message != tt.message {
+				t.Errorf("Expected message %q, got %q", tt.message, message)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	if email := validate.NormalizeEmail("  Foo.Bar@Example.COM "); email != "foo.bar@example.com" {
+		t.Errorf("Expected foo.bar@example.com, got %q", email)
+	}
+}
+
+func TestValidateStructCollectsAllErrors(t *testing.T) {
+	req := types.RegisterRequest{
+		Username:        "al",
+		Email:           "not-an-email",
+		Password:        "secret",
+		ConfirmPassword: "different",
+	}
+
+	errs := validate.ValidateStruct(req)
+
+	expected := []types.ValidationError{
+		{Field: "username", Message: "username must be at least 3 characters long", Value: "al"},
+		{Field: "email", Message: "email must be a valid email address", Value: "not-an-email"},
+		{Field: "confirm_password", Message: "confirm_password must match password"},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %+v", len(expected), len(errs), errs)
+	}
+	for i := range expected {
+		if errs[i] != expected[i] {
+			t.Errorf("Expected error %+v, got %+v", expected[i], errs[i])
+		}
+	}
+}
+
+func TestValidateStructValid(t *testing.T) {
+	req := &types.AuthRequest{Email: "user@example.com", Password: "hunter2"}
+	if errs := validate.ValidateStruct(req); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %+v", errs)
+	}
+}
+
+func TestValidatorReportsFirstFailurePerField(t *testing.T) {
+	v := validate.Struct(types.AuthRequest{}).
+		Field("password", "", validate.Check(func(string) error { return errors.New("too weak") }))
+
+	expected := []types.ValidationError{
+		{Field: "email", Message: "email is required"},
+		{Field: "password", Message: "password is required"},
+	}
+	errs := v.Errors()
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %+v", len(expected), len(errs), errs)
+	}
+	for i := range expected {
+		if errs[i] != expected[i] {
+			t.Errorf("Expected error %+v, got %+v", expected[i], errs[i])
+		}
+	}
+}
+
+func TestValidateStructUnknownRulePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected an unknown rule to panic")
+		}
+	}()
+
+	validate.ValidateStruct(struct {
+		Name string `validate:"uppercase"`
+	}{Name: "x"})
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/MonkyMars/PWS/workers"
+	"github.com/google/uuid"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"type":"submission.created"}`)
+	signature := services.SignWebhookPayload("secret", body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"matching signature", "secret", body, signature, true},
+		{"other secret", "other", body, signature, false},
+		{"tampered body", "secret", []byte(`{"type":"submission.updated"}`), signature, false},
+		{"missing prefix", "secret", body, signature[len("sha256="):], false},
+		{"empty signature", "secret", body, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := services.VerifyWebhookSignature(tt.secret, tt.body, tt.signature); got != tt.want {
+				t.Errorf("VerifyWebhookSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookSubscription(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []string
+		wantErr    bool
+	}{
+		{"valid", "https://example.com/hook", []string{types.WebhookEventSubmissionCreated}, false},
+		{"http is allowed", "http://lms.internal/hook", types.WebhookEventTypes, false},
+		{"relative url", "/hook", []string{types.WebhookEventSubmissionCreated}, true},
+		{"unsupported scheme", "ftp://example.com/hook", []string{types.WebhookEventSubmissionCreated}, true},
+		{"no event types", "https://example.com/hook", nil, true},
+		{"unknown event type", "https://example.com/hook", []string{"deadline.deleted"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := services.ValidateWebhookSubscription(tt.url, tt.eventTypes)
+			if tt.wantErr && !errors.Is(err, lib.ErrInvalidWebhook) {
+				t.Errorf("Expected ErrInvalidWebhook, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	var received atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !services.VerifyWebhookSignature("secret", body, r.Header.Get(services.WebhookSignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get(services.WebhookEventHeader) != types.WebhookEventSubmissionCreated {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received.Store(true)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	deliverer := services.NewWebhookDeliverer(time.Second)
+	delivery := types.WebhookDelivery{
+		WebhookID: uuid.New(),
+		URL:       server.URL,
+		Secret:    "secret",
+		Event:     types.WebhookEvent{ID: uuid.New(), Type: types.WebhookEventSubmissionCreated, CreatedAt: time.Now()},
+	}
+
+	if err := deliverer.Deliver(context.Background(), delivery); err != nil {
+		t.Fatalf("Unexpected delivery error: %v", err)
+	}
+	if !received.Load() {
+		t.Fatal("Expected the receiver to accept the signed delivery")
+	}
+
+	// A receiver with another secret rejects the delivery, which counts as a failure
+	delivery.Secret = "other"
+	if err := deliverer.Deliver(context.Background(), delivery); err == nil {
+		t.Error("Expected a rejected delivery to fail")
+	}
+}
+
+func TestWebhookDeadLetterQueueRedelivers(t *testing.T) {
+	loadTestConfig(t)
+
+	// The receiver is down for the first delivery and recovers afterwards
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	deliverer := services.NewWebhookDeliverer(time.Second)
+	dlq := workers.NewWebhookDeadLetterQueue(10, 3, deliverer, config.SetupLogger())
+
+	delivery := types.WebhookDelivery{
+		WebhookID: uuid.New(),
+		URL:       server.URL,
+		Secret:    "secret",
+		Event:     types.WebhookEvent{ID: uuid.New(), Type: types.WebhookEventSubmissionUpdated, CreatedAt: time.Now()},
+	}
+	err := deliverer.Deliver(context.Background(), delivery)
+	if err == nil {
+		t.Fatal("Expected the first delivery to fail")
+	}
+	dlq.AddFailedDelivery(delivery, err)
+
+	recovered, err := dlq.RetryFailedDeliveries(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected retry error: %v", err)
+	}
+	if recovered != 1 || dlq.Size() != 0 {
+		t.Errorf("Expected the delivery to be recovered, got %d recovered and %d queued", recovered, dlq.Size())
+	}
+}
+
+func TestPublishWebhookEvent(t *testing.T) {
+	// Without a registered handler events are dropped
+	services.RegisterWebhookEventHandler(nil)
+	services.PublishWebhookEvent(types.WebhookEventSubmissionCreated, nil)
+
+	var published []types.WebhookEvent
+	services.RegisterWebhookEventHandler(func(event types.WebhookEvent) {
+		published = append(published, event)
+	})
+	defer services.RegisterWebhookEventHandler(nil)
+
+	services.PublishWebhookEvent(types.WebhookEventSubmissionCreated, map[string]any{"submission_id": "s1"})
+
+	if len(published) != 1 {
+		t.Fatalf("Expected 1 published event, got %d", len(published))
+	}
+	event := published[0]
+	if event.ID == uuid.Nil || event.CreatedAt.IsZero() {
+		t.Error("Expected the event to get an ID and creation time")
+	}
+	if event.Type != types.WebhookEventSubmissionCreated || event.Data["submission_id"] != "s1" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,6 +29,19 @@ type DatabaseHealthResponse struct {
 	Elapsed string `json:"elapsed,omitempty"`
 }
 
+// ProbeResponse is returned by the liveness and readiness probes
+type ProbeResponse struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyStatus `json:"checks,omitempty"`
+}
+
+// DependencyStatus is the result of a single readiness check
+type DependencyStatus struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Elapsed string `json:"elapsed"`
+}
+
 type AuditLog struct {
 	Id        uuid.UUID      `json:"id" pg:"id,pk,type:uuid,default:gen_random_uuid()"`
 	Timestamp time.Time      `json:"timestamp"`
@@ -34,6 +50,56 @@ type AuditLog struct {
 	Attrs     map[string]any `json:"attrs,omitempty"`
 	EntryHash string         `json:"entry_hash,omitempty"`
 	Source    string         `json:"source,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+// AuditLogFilter narrows down an audit log search. Zero values mean "no filter".
+type AuditLogFilter struct {
+	Level  string    `json:"level,omitempty"`
+	Source string    `json:"source,omitempty"`
+	Search string    `json:"search,omitempty"` // Case-insensitive substring of the message
+	From   time.Time `json:"from,omitempty"`
+	To     time.Time `json:"to,omitempty"`
+	Page   int       `json:"page"`
+	Limit  int       `json:"limit"`
+}
+
+// Audit log search pagination bounds
+const (
+	DefaultAuditLogLimit = 50
+	MaxAuditLogLimit     = 200
+)
+
+// Normalize upper-cases the level and applies the default and maximum page size
+func (f *AuditLogFilter) Normalize() {
+	f.Level = strings.ToUpper(strings.TrimSpace(f.Level))
+	f.Source = strings.TrimSpace(f.Source)
+	f.Search = strings.TrimSpace(f.Search)
+
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.Limit < 1 {
+		f.Limit = DefaultAuditLogLimit
+	}
+	if f.Limit > MaxAuditLogLimit {
+		f.Limit = MaxAuditLogLimit
+	}
+}
+
+// Validate checks that the level is known and the time range is ordered
+func (f *AuditLogFilter) Validate() error {
+	switch f.Level {
+	case "", "ERROR", "WARN", "INFO", "DEBUG":
+	default:
+		return fmt.Errorf("invalid level %q, expected one of ERROR, WARN, INFO, DEBUG", f.Level)
+	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && f.From.After(f.To) {
+		return fmt.Errorf("from must be before to")
+	}
+
+	return nil
 }
 
 type HealthLog struct {
@@ -43,6 +109,45 @@ type HealthLog struct {
 	RequestCount   int64         `json:"request_count"`
 	ErrorCount     int64         `json:"error_count"`
 	AverageLatency time.Duration `json:"average_latency"`
+	P50Latency     time.Duration `json:"p50_latency"`
+	P95Latency     time.Duration `json:"p95_latency"`
+	P99Latency     time.Duration `json:"p99_latency"`
 	TimeSpan       time.Duration `json:"time_span"`
 	Source         string        `json:"source,omitempty"`
 }
+
+// ReadOnlyModeRequest switches the API read-only mode on or off
+type ReadOnlyModeRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// RateLimitStatus describes where a client stands in its current rate limit window.
+// Reset and RetryAfter are in whole seconds, rounded up.
+type RateLimitStatus struct {
+	Count      int `json:"count"`
+	Limit      int `json:"limit"`
+	Remaining  int `json:"remaining"`
+	Reset      int `json:"reset"`
+	RetryAfter int `json:"retry_after"`
+}
+
+// NewRateLimitStatus builds the status of a client that made count requests in a window
+// that resets after ttl. RetryAfter is only set once the client is over the limit.
+func NewRateLimitStatus(count, limit int, ttl time.Duration) RateLimitStatus {
+	status := RateLimitStatus{
+		Count:     count,
+		Limit:     limit,
+		Remaining: max(limit-count, 0),
+		Reset:     max(int(math.Ceil(ttl.Seconds())), 0),
+	}
+	if count > limit {
+		status.RetryAfter = max(status.Reset, 1)
+	}
+	return status
+}
+
+// Approaching reports whether the client used at least threshold (a fraction of the limit)
+// of its window without exceeding the limit yet. A threshold of 0 never warns.
+func (s RateLimitStatus) Approaching(threshold float64) bool {
+	return threshold > 0 && s.Count <= s.Limit && float64(s.Count) >= threshold*float64(s.Limit)
+}
@@ -14,6 +14,16 @@ type ArgonParams struct {
 	SaltLen uint32
 }
 
+// Claims versions carried in the "ver" claim. Tokens issued before versioning have no
+// "ver" claim and are parsed as ClaimsVersionLegacy with defaults for the newer claims.
+const (
+	ClaimsVersionLegacy  = 0
+	ClaimsVersionCurrent = 1
+)
+
+// TwoFactorLevelNone marks a token issued without a second authentication factor
+const TwoFactorLevelNone = 0
+
 type AuthClaims struct {
 	Sub   uuid.UUID `json:"sub"`
 	Email string    `json:"email"`
@@ -21,18 +31,39 @@ type AuthClaims struct {
 	Iat   time.Time `json:"iat"`
 	Exp   time.Time `json:"exp"`
 	Jti   uuid.UUID `json:"jti"`
+
+	// Claims added in ClaimsVersionCurrent
+	Ver            int       `json:"ver"`
+	Nbf            time.Time `json:"nbf"`
+	Epoch          int64     `json:"epoch"`
+	Family         uuid.UUID `json:"family"`
+	TwoFactorLevel int       `json:"2fa"`
+
+	// Sid is the session the token belongs to, uuid.Nil for tokens issued without a session
+	Sid uuid.UUID `json:"sid"`
+}
+
+// UserSession is the server side state of a login, stored in Redis until it idles out or reaches its maximum lifetime
+type UserSession struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type AuthRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,secret"`
 }
 
 type RegisterRequest struct {
-	Username        string `json:"username"`
-	Email           string `json:"email"`
-	Password        string `json:"password"`
-	ConfirmPassword string `json:"confirm_password"`
+	Username        string `json:"username" validate:"required,min=3,max=50"`
+	Email           string `json:"email" validate:"required,email"`
+	Password        string `json:"password" validate:"required,secret"` // Length and strength follow the password policy
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password,secret"`
+}
+
+// UpdateUserRoleRequest changes the role of a user, see lib.Roles for the accepted values
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
 }
 
 type RefreshTokenRequest struct {
@@ -77,3 +108,23 @@ type GoogleRefreshTokenResponse struct {
 	Id           uuid.UUID `json:"id"`
 	RefreshToken string    `json:"refresh_token"`
 }
+
+// APIKey is a long-lived credential for service-to-service access.
+// Only the SHA-256 hash of the key is stored; the key itself is shown once on creation.
+type APIKey struct {
+	Id         uuid.UUID  `json:"id" pg:"id,pk,type:uuid,default:gen_random_uuid()"`
+	UserId     uuid.UUID  `json:"user_id" pg:"user_id,type:uuid,notnull"`
+	Prefix     string     `json:"prefix" pg:"prefix,notnull"`
+	KeyHash    string     `json:"-" pg:"key_hash,unique,notnull"`
+	CreatedAt  time.Time  `json:"created_at" pg:"created_at,notnull,default:now()"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" pg:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" pg:"revoked_at"`
+}
+
+// CreatedAPIKey is returned once when an API key is created. The Key is not retrievable afterwards.
+type CreatedAPIKey struct {
+	Id        uuid.UUID `json:"id"`
+	Key       string    `json:"key"`
+	Prefix    string    `json:"prefix"`
+	CreatedAt time.Time `json:"created_at"`
+}
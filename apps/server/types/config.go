@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // DatabaseConfig holds all database-related configuration
 type DatabaseConfig struct {
@@ -15,6 +18,34 @@ type DatabaseConfig struct {
 	MaxLifetime  time.Duration
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+
+	CircuitAlertWebhookURL string
+	CircuitAlertDebounce   time.Duration
+
+	// MaxInsertEntries caps the rows of a single bulk insert, larger batches are rejected
+	MaxInsertEntries int
+
+	// ConnWaitWarnThreshold logs a warning when a query waits longer for a pooled connection, zero disables it
+	ConnWaitWarnThreshold time.Duration
+
+	// StatementTimeout cancels queries that do not set their own timeout after this long, zero disables it
+	StatementTimeout time.Duration
+}
+
+// AuditDatabaseConfig holds the optional dedicated database for audit and health logs
+type AuditDatabaseConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	MaxConns int
+	MinConns int
+}
+
+// Enabled reports whether audit and health logs go to a dedicated database instead of the primary one
+func (adc AuditDatabaseConfig) Enabled() bool {
+	return adc.Host != ""
 }
 
 // ServerConfig holds server-related configuration
@@ -23,6 +54,36 @@ type ServerConfig struct {
 	WriteTimeout   time.Duration
 	IdleTimeout    time.Duration
 	MaxHeaderBytes int
+
+	MaxFilterConditions int
+	MaxRequestBodySize  int
+
+	CompressionEnabled bool
+	CompressionLevel   int
+	CompressionMinSize int
+
+	ResponseBudget       time.Duration
+	ResponseBudgetRoutes map[string]time.Duration
+}
+
+// ResponseBudgetKey returns the key of a route budget, a budget without a method applies to every method
+func ResponseBudgetKey(method, path string) string {
+	if method == "" {
+		return path
+	}
+	return strings.ToUpper(method) + " " + path
+}
+
+// ResponseBudgetFor returns the response time budget for a route, preferring a budget for
+// the method and path over one for the path and falling back to the global budget
+func (sc ServerConfig) ResponseBudgetFor(method, path string) time.Duration {
+	if budget, ok := sc.ResponseBudgetRoutes[ResponseBudgetKey(method, path)]; ok {
+		return budget
+	}
+	if budget, ok := sc.ResponseBudgetRoutes[path]; ok {
+		return budget
+	}
+	return sc.ResponseBudget
 }
 
 type AuthConfig struct {
@@ -32,6 +93,32 @@ type AuthConfig struct {
 	RefreshTokenExpiry time.Duration
 	CacheUserTTL       time.Duration
 	BlacklistCacheTTL  time.Duration
+	// PasswordPepper is a server side secret mixed into passwords before hashing, empty disables it
+	PasswordPepper string
+	// RolePermissions maps each role to the permissions it is granted, "*" grants every permission
+	RolePermissions map[string][]string
+	// SessionIdleTimeout ends a session after this long without an authenticated request
+	SessionIdleTimeout time.Duration
+	// SessionMaxLifetime ends a session this long after login, however active it is
+	SessionMaxLifetime time.Duration
+	// PasswordPolicy is checked for every new password
+	PasswordPolicy PasswordPolicy
+}
+
+// PasswordPolicy holds the requirements a new password must meet
+type PasswordPolicy struct {
+	MinLength      int
+	MaxLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSpecial bool
+	// Blocklist holds lowercased common passwords that are always rejected
+	Blocklist map[string]struct{}
+	// CheckPwned rejects passwords found in the breach corpus at PwnedAPIURL
+	CheckPwned   bool
+	PwnedAPIURL  string
+	PwnedTimeout time.Duration
 }
 
 type CacheConfig struct {
@@ -50,6 +137,9 @@ type CacheConfig struct {
 	MaxRetries      int
 	MinRetryBackoff time.Duration
 	MaxRetryBackoff time.Duration
+
+	SubmissionLockTTL  time.Duration
+	SubmissionLockWait time.Duration
 }
 
 type CorsConfig struct {
@@ -59,6 +149,17 @@ type CorsConfig struct {
 	AllowCredentials bool
 }
 
+// CookieConfig holds the attributes of the auth cookies, SameSite is Lax, Strict or None
+type CookieConfig struct {
+	Domain   string
+	Secure   bool
+	SameSite string
+	HTTPOnly bool
+
+	CSRFEnabled     bool
+	CSRFExemptPaths []string
+}
+
 type AuditConfig struct {
 	BatchSize     int           `json:"batch_size"`
 	FlushTime     time.Duration `json:"flush_time"`
@@ -68,6 +169,41 @@ type AuditConfig struct {
 	RetentionDays int           `json:"retention_days"`
 	Enabled       bool          `json:"enabled"`
 	RetryDelay    time.Duration `json:"retry_delay"`
+	DLQSize       int           `json:"dlq_size"`
+	DLQInterval   time.Duration `json:"dlq_interval"`
+
+	CleanupInterval time.Duration `json:"cleanup_interval"`
+	CleanupOffset   time.Duration `json:"cleanup_offset"`
+	CleanupTimezone string        `json:"cleanup_timezone"`
+
+	ReadAccess bool `json:"read_access"`
+
+	OverflowPolicies map[string]AuditOverflowPolicy `json:"overflow_policies"`
+	OverflowTimeout  time.Duration                  `json:"overflow_timeout"`
+}
+
+// AuditOverflowPolicy controls what happens to an audit log when the audit queue is full
+type AuditOverflowPolicy string
+
+const (
+	// AuditOverflowDrop drops the entry immediately
+	AuditOverflowDrop AuditOverflowPolicy = "drop"
+	// AuditOverflowBlock waits up to the overflow timeout for room in the queue before dropping
+	AuditOverflowBlock AuditOverflowPolicy = "block"
+)
+
+// AuditOverflowAllLevels is the policy key that applies to levels without their own policy
+const AuditOverflowAllLevels = "*"
+
+// OverflowPolicyFor returns the overflow policy for the given audit level, defaulting to drop
+func (ac AuditConfig) OverflowPolicyFor(level string) AuditOverflowPolicy {
+	if policy, ok := ac.OverflowPolicies[strings.ToUpper(level)]; ok {
+		return policy
+	}
+	if policy, ok := ac.OverflowPolicies[AuditOverflowAllLevels]; ok {
+		return policy
+	}
+	return AuditOverflowDrop
 }
 
 type HealthConfig struct {
@@ -81,6 +217,8 @@ type HealthConfig struct {
 	Enabled        bool          `json:"enabled"`
 	Services       []string      `json:"services"`
 	RetryDelay     time.Duration `json:"retry_delay"`
+
+	ProbeFailureThreshold int `json:"probe_failure_threshold"`
 }
 
 type GoogleConfig struct {
@@ -88,3 +226,42 @@ type GoogleConfig struct {
 	ClientSecret string
 	RedirectURL  string
 }
+
+type MicrosoftConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Tenant       string
+}
+
+type NotificationConfig struct {
+	WebhookURL    string        `json:"-"`
+	RetryEnabled  bool          `json:"retry_enabled"`
+	DLQSize       int           `json:"dlq_size"`
+	RetryInterval time.Duration `json:"retry_interval"`
+	MaxRetries    int           `json:"max_retries"`
+	DLQPersistent bool          `json:"dlq_persistent"`
+}
+
+type WebhookConfig struct {
+	Enabled        bool          `json:"enabled"`
+	ChannelSize    int           `json:"channel_size"`
+	Timeout        time.Duration `json:"timeout"`
+	MaxAttempts    int           `json:"max_attempts"`
+	RetryBackoff   time.Duration `json:"retry_backoff"`
+	MaxConcurrency int           `json:"max_concurrency"`
+	DLQSize        int           `json:"dlq_size"`
+	RetryInterval  time.Duration `json:"retry_interval"`
+	DLQMaxRetries  int           `json:"dlq_max_retries"`
+}
+
+type RateLimitConfig struct {
+	Enabled     bool          `json:"enabled"`
+	Max         int           `json:"max"`
+	Window      time.Duration `json:"window"`
+	ExemptCIDRs []string      `json:"exempt_cidrs"`
+	ExemptKeys  []string      `json:"-"`
+
+	SoftThreshold       float64            `json:"soft_threshold"`
+	SoftThresholdRoutes map[string]float64 `json:"soft_threshold_routes"`
+}
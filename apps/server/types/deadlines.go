@@ -4,6 +4,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// Limits on the tags of a deadline
+const (
+	MaxDeadlineTags      = 10
+	MaxDeadlineTagLength = 32
+)
+
 type CreateDeadlineRequest struct {
 	SubjectID   uuid.UUID `json:"subject_id"`
 	OwnerID     uuid.UUID `json:"owner_id"`
@@ -11,6 +17,7 @@ type CreateDeadlineRequest struct {
 	Description string    `json:"description"`
 	DueDate     string    `json:"due_date"`
 	CreatedAt   string    `json:"created_at"`
+	Tags        []string  `json:"tags"` // Categories such as exam, homework or project
 }
 
 type Deadline struct {
@@ -20,10 +27,18 @@ type Deadline struct {
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	DueDate     string    `json:"due_date"`
+	Tags        []string  `json:"tags" pg:"tags,array"` // Nil leaves the tags unchanged on update
 	CreatedAt   string    `json:"created_at"`
 	UpdatedAt   string    `json:"updated_at"`
 }
 
+// DeadlineAccess holds the facts about a user's relation to a deadline that decide whether they may access it
+type DeadlineAccess struct {
+	OwnerID         uuid.UUID `json:"owner_id"`
+	AssignedTeacher bool      `json:"assigned_teacher"` // The user teaches the subject of the deadline
+	Enrolled        bool      `json:"enrolled"`         // The user is enrolled in the subject of the deadline
+}
+
 type Submission struct {
 	ID         uuid.UUID `json:"id"`
 	DeadlineID uuid.UUID `json:"deadline_id"`
@@ -32,6 +47,9 @@ type Submission struct {
 	Message    string    `json:"message"`
 	CreatedAt  string    `json:"created_at"`
 	UpdatedAt  string    `json:"updated_at"`
+
+	Feedback      string `json:"feedback"`
+	DraftFeedback string `json:"draft_feedback"`
 }
 
 // Used for creating/updating a submission
@@ -40,7 +58,7 @@ type CreateSubmissionRequest struct {
 	Message string   `json:"message"`
 }
 
-// Used for returning a submission to the client
+// Used for returning a submission to teachers and admins
 type SubmissionResponse struct {
 	ID         uuid.UUID `json:"id"`
 	DeadlineID uuid.UUID `json:"deadline_id"`
@@ -51,14 +69,59 @@ type SubmissionResponse struct {
 	UpdatedAt  string    `json:"updated_at"`
 	IsLate     bool      `json:"is_late"`
 	IsUpdated  bool      `json:"is_updated"`
+
+	// Created is set when CreateOrUpdateSubmission inserted the submission rather than updating it
+	Created bool `json:"created,omitempty"`
+
+	Feedback      string `json:"feedback"`
+	DraftFeedback string `json:"draft_feedback"` // Teacher-only
+}
+
+// Used for returning a submission to a student, without teacher-only fields
+type StudentSubmissionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	DeadlineID uuid.UUID `json:"deadline_id"`
+	StudentID  uuid.UUID `json:"student_id"`
+	FileIDs    []string  `json:"file_ids"`
+	Message    string    `json:"message"`
+	CreatedAt  string    `json:"created_at"`
+	UpdatedAt  string    `json:"updated_at"`
+	IsLate     bool      `json:"is_late"`
+	IsUpdated  bool      `json:"is_updated"`
+	Created    bool      `json:"created,omitempty"`
+	Feedback   string    `json:"feedback"`
+}
+
+// ForStudent returns the student view of the submission, dropping teacher-only fields
+func (s *SubmissionResponse) ForStudent() *StudentSubmissionResponse {
+	if s == nil {
+		return nil
+	}
+	return &StudentSubmissionResponse{
+		ID:         s.ID,
+		DeadlineID: s.DeadlineID,
+		StudentID:  s.StudentID,
+		FileIDs:    s.FileIDs,
+		Message:    s.Message,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+		IsLate:     s.IsLate,
+		IsUpdated:  s.IsUpdated,
+		Created:    s.Created,
+		Feedback:   s.Feedback,
+	}
 }
 
 type DeadlineWithSubject struct {
+	// The select names its table, d is the alias the columns and joins refer to
+	tableName struct{} `pg:"_,alias:d"`
+
 	ID          uuid.UUID `json:"id"`
 	OwnerID     uuid.UUID `json:"owner_id"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	DueDate     string    `json:"due_date"`
+	Tags        []string  `json:"tags" pg:"tags,array"`
 	CreatedAt   string    `json:"created_at"`
 	UpdatedAt   string    `json:"updated_at"`
 	Subject     Subject   `json:"subject"`
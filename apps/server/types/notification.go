@@ -0,0 +1,21 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification types
+const (
+	NotificationSubmissionReceived = "submission.received"
+)
+
+// Notification is a message for a single recipient, delivered by a services.Notifier
+type Notification struct {
+	ID          uuid.UUID      `json:"id"`
+	Type        string         `json:"type"`
+	RecipientID uuid.UUID      `json:"recipient_id"`
+	Data        map[string]any `json:"data"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
@@ -3,8 +3,12 @@ package types
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/go-pg/pg/v10"
 )
 
 // QueryParams represents the parameters for building dynamic database queries.
@@ -24,12 +28,20 @@ type QueryParams struct {
 	// Entries contains multiple entries for bulk insert/update operations
 	Entries []any `json:"entries,omitempty"`
 
+	// MaxEntries caps the number of Entries a single query may insert, 0 uses DefaultMaxEntries
+	MaxEntries int `json:"max_entries,omitempty"`
+
 	// Select specifies which columns to select (for SELECT operations)
 	Select []string `json:"select,omitempty"`
 
-	// Where contains the WHERE clause conditions
+	// Where contains the WHERE clause conditions. A key may end with a comparison
+	// operator, e.g. "due_date >=", see ParseWhereKey
 	Where map[string]any `json:"where,omitempty"`
 
+	// WhereAny contains groups of WHERE conditions. Within a group the conditions are
+	// combined with OR, the groups themselves are ANDed with the other conditions
+	WhereAny []map[string]any `json:"where_any,omitempty"`
+
 	// WhereRaw allows for complex WHERE conditions with raw SQL
 	WhereRaw string `json:"where_raw,omitempty"`
 
@@ -69,6 +81,15 @@ type QueryParams struct {
 	// Transaction specifies if this operation should run in a transaction
 	UseTransaction bool `json:"use_transaction,omitempty"`
 
+	// Tx runs the operation on an open transaction instead of the shared connection pool (optional)
+	Tx *pg.Tx `json:"-"`
+
+	// DB runs the operation on another database instead of the primary one, ignored when Tx is set (optional)
+	DB *pg.DB `json:"-"`
+
+	// ForUpdate locks the selected rows until Tx ends (SELECT ... FOR UPDATE)
+	ForUpdate bool `json:"for_update,omitempty"`
+
 	// Returning specifies columns to return (for INSERT/UPDATE/DELETE with RETURNING)
 	Returning []string `json:"returning,omitempty"`
 
@@ -149,6 +170,19 @@ func (q *QueryParams) SetEntries(entries []any) *QueryParams {
 	return q
 }
 
+// SetForUpdate makes a select lock the rows it returns until the transaction set with WithTx ends,
+// so concurrent transactions selecting the same rows for update wait for it
+func (q *QueryParams) SetForUpdate(forUpdate bool) *QueryParams {
+	q.ForUpdate = forUpdate
+	return q
+}
+
+// SetMaxEntries sets the maximum number of entries a bulk insert may contain
+func (q *QueryParams) SetMaxEntries(max int) *QueryParams {
+	q.MaxEntries = max
+	return q
+}
+
 // SetSelect sets the columns to select
 func (q *QueryParams) SetSelect(columns []string) *QueryParams {
 	q.Select = columns
@@ -164,6 +198,53 @@ func (q *QueryParams) AddWhere(key string, value any) *QueryParams {
 	return q
 }
 
+// WhereOperators are the comparison operators a Where key may end with.
+// @>, <@ and && compare arrays: contains, is contained by and overlaps.
+var WhereOperators = []string{"=", "!=", "<>", "<", "<=", ">", ">=", "LIKE", "ILIKE", "@>", "<@", "&&"}
+
+// ParseWhereKey splits a Where key into its column and comparison operator.
+// "due_date >=" becomes "due_date" and ">=", a key without a known operator compares with "=".
+func ParseWhereKey(key string) (column, operator string) {
+	key = strings.TrimSpace(key)
+	if idx := strings.LastIndex(key, " "); idx > 0 {
+		operator = strings.ToUpper(key[idx+1:])
+		if slices.Contains(WhereOperators, operator) {
+			return strings.TrimSpace(key[:idx]), operator
+		}
+	}
+	return key, "="
+}
+
+// AddWhereArrayContains adds a WHERE condition matching rows whose array column contains every value
+func (q *QueryParams) AddWhereArrayContains(column string, values ...string) *QueryParams {
+	return q.AddWhere(column+" @>", pg.Array(values))
+}
+
+// AddWhereAny adds a group of WHERE conditions of which at least one must match.
+// Keys may end with a comparison operator just like in AddWhere.
+func (q *QueryParams) AddWhereAny(conditions map[string]any) *QueryParams {
+	if len(conditions) > 0 {
+		q.WhereAny = append(q.WhereAny, conditions)
+	}
+	return q
+}
+
+// AddWhereContainsAny adds a WHERE condition matching rows where any of the columns contains
+// the text, ignoring case. LIKE wildcards in the text are matched literally.
+func (q *QueryParams) AddWhereContainsAny(text string, columns ...string) *QueryParams {
+	pattern := "%" + EscapeLikePattern(text) + "%"
+	conditions := make(map[string]any, len(columns))
+	for _, column := range columns {
+		conditions[column+" ILIKE"] = pattern
+	}
+	return q.AddWhereAny(conditions)
+}
+
+// EscapeLikePattern escapes the LIKE wildcards so user input is matched literally
+func EscapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 // SetWhereRaw sets a raw WHERE clause
 func (q *QueryParams) SetWhereRaw(whereClause string, args ...any) *QueryParams {
 	q.WhereRaw = whereClause
@@ -221,6 +302,20 @@ func (q *QueryParams) WithTransaction() *QueryParams {
 	return q
 }
 
+// WithTx runs the operation on the given transaction, e.g. one started by database.Transaction,
+// so several builder queries commit or roll back together
+func (q *QueryParams) WithTx(tx *pg.Tx) *QueryParams {
+	q.Tx = tx
+	return q
+}
+
+// WithDB runs the operation on another database, e.g. the dedicated audit database.
+// A nil db keeps the primary database.
+func (q *QueryParams) WithDB(db *pg.DB) *QueryParams {
+	q.DB = db
+	return q
+}
+
 // SetReturning sets columns to return
 func (q *QueryParams) SetReturning(columns ...string) *QueryParams {
 	q.Returning = columns
@@ -233,11 +328,59 @@ func (q *QueryParams) SetOnConflict(strategy string) *QueryParams {
 	return q
 }
 
+// Clone returns a deep copy of the query so a variant (e.g. a count query derived
+// from a paginated select) can be built without mutating the original.
+// Maps and slices are copied, as are map entries in Entries. The context and transaction are shared.
+func (q *QueryParams) Clone() *QueryParams {
+	if q == nil {
+		return nil
+	}
+
+	clone := *q
+	clone.Data = maps.Clone(q.Data)
+	clone.Select = slices.Clone(q.Select)
+	clone.Where = maps.Clone(q.Where)
+	clone.WhereArgs = slices.Clone(q.WhereArgs)
+	if q.WhereAny != nil {
+		clone.WhereAny = make([]map[string]any, len(q.WhereAny))
+		for i, group := range q.WhereAny {
+			clone.WhereAny[i] = maps.Clone(group)
+		}
+	}
+	clone.Join = slices.Clone(q.Join)
+	clone.Order = slices.Clone(q.Order)
+	clone.GroupBy = slices.Clone(q.GroupBy)
+	clone.Having = maps.Clone(q.Having)
+	clone.RawArgs = slices.Clone(q.RawArgs)
+	clone.Returning = slices.Clone(q.Returning)
+	clone.Params = maps.Clone(q.Params)
+
+	if q.Entries != nil {
+		clone.Entries = make([]any, len(q.Entries))
+		for i, entry := range q.Entries {
+			if m, ok := entry.(map[string]any); ok {
+				clone.Entries[i] = maps.Clone(m)
+				continue
+			}
+			clone.Entries[i] = entry
+		}
+	}
+
+	return &clone
+}
+
 // Validate checks if the QueryParams is valid for the specified operation
 func (q *QueryParams) Validate() error {
+	if q.ForUpdate && !strings.EqualFold(q.Operation, "select") {
+		return ErrForUpdateNotSelect
+	}
+
 	switch strings.ToLower(q.Operation) {
 	case "select":
-		// Select operations don't require additional validation
+		// Row locks are released when the statement's implicit transaction ends, so they need a real one
+		if q.ForUpdate && q.Tx == nil {
+			return ErrForUpdateWithoutTx
+		}
 		return nil
 	case "insert":
 		if len(q.Data) == 0 && len(q.Entries) == 0 {
@@ -247,6 +390,14 @@ func (q *QueryParams) Validate() error {
 		if len(q.Data) > 0 && len(q.Entries) > 0 {
 			return ErrBothDataAndEntriesProvided
 		}
+		// Hard safety cap, callers with more rows must split them over several queries
+		maxEntries := q.MaxEntries
+		if maxEntries <= 0 {
+			maxEntries = DefaultMaxEntries
+		}
+		if len(q.Entries) > maxEntries {
+			return fmt.Errorf("%w: got %d entries, the maximum is %d", ErrTooManyEntries, len(q.Entries), maxEntries)
+		}
 		return nil
 	case "update":
 		if len(q.Data) == 0 {
@@ -271,6 +422,9 @@ func (q *QueryParams) Validate() error {
 	}
 }
 
+// DefaultMaxEntries is the bulk insert cap for queries that do not set MaxEntries
+const DefaultMaxEntries = 1000
+
 // Common query builder errors
 var (
 	ErrNoDataProvided             = fmt.Errorf("no data provided for insert/update operation")
@@ -278,4 +432,8 @@ var (
 	ErrNoRawSQL                   = fmt.Errorf("no raw SQL provided for raw operation")
 	ErrInvalidOperation           = fmt.Errorf("invalid operation specified")
 	ErrBothDataAndEntriesProvided = fmt.Errorf("cannot provide both Data and Entries for insert operation - use one or the other")
+	ErrTooManyEntries             = fmt.Errorf("too many entries for a single bulk insert")
+	ErrForUpdateNotSelect         = fmt.Errorf("FOR UPDATE can only be used with select operations")
+	ErrForUpdateWithoutTx         = fmt.Errorf("FOR UPDATE requires a transaction, set one with WithTx")
+	ErrInvalidIdentifier          = fmt.Errorf("invalid table or column name")
 )
@@ -2,6 +2,12 @@ package types
 
 import "github.com/google/uuid"
 
+// Limits on the fields of a subject
+const (
+	MaxSubjectNameLength = 100
+	MaxSubjectCodeLength = 20
+)
+
 type Subject struct {
 	Id          uuid.UUID `json:"id"`
 	Name        string    `json:"name"`
@@ -13,3 +19,25 @@ type Subject struct {
 	TeacherName string    `json:"teacher_name"`
 	IsActive    bool      `json:"is_active"`
 }
+
+// CreateSubjectRequest creates an active subject. The optional teacher becomes the main teacher and is assigned to the subject.
+type CreateSubjectRequest struct {
+	Name      string     `json:"name"`
+	Code      string     `json:"code"`
+	Color     string     `json:"color"`
+	TeacherID *uuid.UUID `json:"teacher_id"`
+}
+
+// UpdateSubjectRequest changes the fields that are set and leaves the others as they are
+type UpdateSubjectRequest struct {
+	Name      *string    `json:"name"`
+	Code      *string    `json:"code"`
+	Color     *string    `json:"color"`
+	TeacherID *uuid.UUID `json:"teacher_id"`
+	IsActive  *bool      `json:"is_active"`
+}
+
+// AssignTeacherRequest assigns a teacher to a subject through subject_teachers
+type AssignTeacherRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
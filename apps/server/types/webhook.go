@@ -0,0 +1,66 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types
+const (
+	WebhookEventSubmissionCreated = "submission.created"
+	WebhookEventSubmissionUpdated = "submission.updated"
+)
+
+// WebhookEventTypes lists every event type a webhook can subscribe to
+var WebhookEventTypes = []string{WebhookEventSubmissionCreated, WebhookEventSubmissionUpdated}
+
+// Webhook is a subscription of an external system to one or more event types.
+// The secret signs every delivery and is only returned when the webhook is created.
+type Webhook struct {
+	ID         uuid.UUID `json:"id" pg:"id,pk,type:uuid"`
+	URL        string    `json:"url" pg:"url"`
+	EventTypes []string  `json:"event_types" pg:"event_types,array"`
+	Secret     string    `json:"-" pg:"secret"`
+	Active     bool      `json:"active" pg:"active,use_zero"`
+	CreatedBy  uuid.UUID `json:"created_by" pg:"created_by,type:uuid"`
+	CreatedAt  time.Time `json:"created_at" pg:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" pg:"updated_at"`
+}
+
+// CreatedWebhook is returned once when a webhook is created, it is the only response that includes the secret
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookRequest subscribes a URL to event types. A secret is generated when none is given.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"`
+}
+
+// UpdateWebhookRequest changes the fields that are set and leaves the others as they are
+type UpdateWebhookRequest struct {
+	URL        *string   `json:"url"`
+	EventTypes *[]string `json:"event_types"`
+	Secret     *string   `json:"secret"`
+	Active     *bool     `json:"active"`
+}
+
+// WebhookEvent is the JSON body posted to every webhook subscribed to its type
+type WebhookEvent struct {
+	ID        uuid.UUID      `json:"id"`
+	Type      string         `json:"type"`
+	Data      map[string]any `json:"data"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// WebhookDelivery is a single event on its way to a single webhook
+type WebhookDelivery struct {
+	WebhookID uuid.UUID
+	URL       string
+	Secret    string
+	Event     WebhookEvent
+}
@@ -0,0 +1,80 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MonkyMars/PWS/types"
+)
+
+// ValidatePassword checks a password against the configured password policy and returns
+// every requirement it fails at once. The password itself is never included in the errors.
+// When AUTH_CHECK_PWNED is enabled a password that meets the policy is also checked for breaches,
+// a call that ctx cancels.
+func ValidatePassword(ctx context.Context, password string) []types.ValidationError {
+	return checkConfiguredPassword(ctx, "password", password)
+}
+
+// CheckPassword returns every requirement of the policy the password fails, reported under field
+func CheckPassword(policy types.PasswordPolicy, field, password string) []types.ValidationError {
+	var errs []types.ValidationError
+	fail := func(message string) {
+		errs = append(errs, types.ValidationError{Field: field, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		fail(fmt.Sprintf("%s must be at least %d characters long", field, policy.MinLength))
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		fail(fmt.Sprintf("%s must not exceed %d characters", field, policy.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+	if policy.RequireUpper && !hasUpper {
+		fail(fmt.Sprintf("%s must contain an uppercase letter", field))
+	}
+	if policy.RequireLower && !hasLower {
+		fail(fmt.Sprintf("%s must contain a lowercase letter", field))
+	}
+	if policy.RequireDigit && !hasDigit {
+		fail(fmt.Sprintf("%s must contain a digit", field))
+	}
+	if policy.RequireSpecial && !hasSpecial {
+		fail(fmt.Sprintf("%s must contain a special character", field))
+	}
+
+	if _, blocked := policy.Blocklist[strings.ToLower(password)]; blocked {
+		fail(fmt.Sprintf("%s is too common, choose a less predictable one", field))
+	}
+
+	return errs
+}
+
+// Password records every requirement of the configured password policy that value fails,
+// including the breach check when it is enabled, which runs on ctx so it stops when the request does.
+// Like the other rules it skips empty values and fields that already have an error.
+func (v *Validator) Password(ctx context.Context, field, value string) *Validator {
+	if value == "" || v.HasError(field) {
+		return v
+	}
+	for _, err := range checkConfiguredPassword(ctx, field, value) {
+		v.AddError(field, err.Message, "")
+	}
+	return v
+}
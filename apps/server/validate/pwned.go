@@ -0,0 +1,124 @@
+package validate
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+)
+
+// pwnedPrefixLength is the number of hash characters sent to the range API, the rest never leaves the server
+const pwnedPrefixLength = 5
+
+// PwnedChecker looks up passwords in the Have I Been Pwned range API using k-anonymity:
+// only the first five characters of the SHA-1 hash are sent, the matching suffixes are compared locally
+type PwnedChecker struct {
+	baseURL string
+	client  *http.Client
+	logger  *config.Logger
+}
+
+// NewPwnedChecker creates a checker for the range API at baseURL, e.g. https://api.pwnedpasswords.com/range/
+func NewPwnedChecker(baseURL string, timeout time.Duration, logger *config.Logger) *PwnedChecker {
+	return &PwnedChecker{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: timeout},
+		logger:  logger,
+	}
+}
+
+// IsPwned reports whether the password appears in a known data breach
+func (pc *PwnedChecker) IsPwned(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:pwnedPrefixLength], hash[pwnedPrefixLength:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pc.baseURL+prefix, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create pwned passwords request: %w", err)
+	}
+	// Padding hides the number of matching suffixes from anyone watching the response size
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "PWS")
+
+	resp, err := pc.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("pwned passwords request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("pwned passwords service returned status %d", resp.StatusCode)
+	}
+
+	// Every line is SUFFIX:COUNT, padding lines have a count of zero
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lineSuffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || !strings.EqualFold(lineSuffix, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		return err == nil && n > 0, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read pwned passwords response: %w", err)
+	}
+
+	return false, nil
+}
+
+// Check returns a validation error when the password is breached. It fails open: when the
+// service cannot be reached the failure is logged and the password is allowed.
+func (pc *PwnedChecker) Check(ctx context.Context, field, password string) []types.ValidationError {
+	pwned, err := pc.IsPwned(ctx, password)
+	if err != nil {
+		pc.logger.Warn("Pwned password check failed, allowing the password", "error", err)
+		return nil
+	}
+	if pwned {
+		return []types.ValidationError{{
+			Field:   field,
+			Message: fmt.Sprintf("%s has appeared in a data breach, choose another one", field),
+		}}
+	}
+	return nil
+}
+
+var (
+	configuredChecker     *PwnedChecker
+	configuredCheckerOnce sync.Once
+)
+
+// configuredPwnedChecker returns the checker for the configured breach API. It is created on
+// first use and shared, so every check reuses the same HTTP client and its connections.
+func configuredPwnedChecker() *PwnedChecker {
+	configuredCheckerOnce.Do(func() {
+		policy := config.Get().Auth.PasswordPolicy
+		configuredChecker = NewPwnedChecker(policy.PwnedAPIURL, policy.PwnedTimeout, config.SetupLogger())
+	})
+	return configuredChecker
+}
+
+// checkConfiguredPassword checks a password against the configured policy, including the
+// breach check when it is enabled. The breach check is skipped for passwords the policy already rejects,
+// and is cancelled together with ctx.
+func checkConfiguredPassword(ctx context.Context, field, password string) []types.ValidationError {
+	policy := config.Get().Auth.PasswordPolicy
+
+	errs := CheckPassword(policy, field, password)
+	if len(errs) > 0 || !policy.CheckPwned {
+		return errs
+	}
+
+	return configuredPwnedChecker().Check(ctx, field, password)
+}
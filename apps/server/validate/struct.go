@@ -0,0 +1,117 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/MonkyMars/PWS/types"
+)
+
+// ValidateStruct validates the fields of a struct, or pointer to struct, using their validate tags
+// and returns every failure at once. Fields are reported by their JSON name.
+//
+// Supported tag rules, separated by commas:
+//
+//	required      the value must not be blank
+//	min=N         at least N characters
+//	max=N         at most N characters
+//	email         a plain email address
+//	eqfield=Name  equal to the struct field Name
+//	secret        never echo the value back in errors
+//
+// An unknown rule is a programming error and panics.
+func ValidateStruct(data any) []types.ValidationError {
+	return Struct(data).Errors()
+}
+
+// Struct validates data like ValidateStruct but returns the Validator,
+// so handlers can add checks that do not fit in a tag before responding
+func Struct(data any) *Validator {
+	v := New()
+
+	val := reflect.ValueOf(data)
+	if val.Kind() == reflect.Pointer {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: expected a struct, got %s", val.Kind()))
+	}
+	typ := val.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		name := jsonName(field)
+		var rules []Rule
+		for _, spec := range strings.Split(tag, ",") {
+			spec = strings.TrimSpace(spec)
+			if spec == "secret" {
+				v.Secret(name)
+				continue
+			}
+			rules = append(rules, parseRule(val, typ, field.Name, spec))
+		}
+
+		v.Field(name, stringValue(val.Field(i)), rules...)
+	}
+
+	return v
+}
+
+// parseRule turns one tag rule such as min=3 into a Rule
+func parseRule(val reflect.Value, typ reflect.Type, fieldName, spec string) Rule {
+	name, arg, _ := strings.Cut(spec, "=")
+
+	switch name {
+	case "required":
+		return Required()
+	case "email":
+		return Email()
+	case "min", "max":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			panic(fmt.Sprintf("validate: invalid %s rule %q on field %s", name, spec, fieldName))
+		}
+		if name == "min" {
+			return MinLength(n)
+		}
+		return MaxLength(n)
+	case "eqfield":
+		other, ok := typ.FieldByName(arg)
+		if !ok {
+			panic(fmt.Sprintf("validate: eqfield refers to unknown field %q on field %s", arg, fieldName))
+		}
+		return EqualTo(stringValue(val.FieldByIndex(other.Index)), jsonName(other))
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q on field %s", spec, fieldName))
+	}
+}
+
+// jsonName returns the name a field has in request bodies
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// stringValue formats a field value for the rules, strings are used as they are
+func stringValue(value reflect.Value) string {
+	if value.Kind() == reflect.String {
+		return value.String()
+	}
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return ""
+		}
+		return stringValue(value.Elem())
+	}
+	return fmt.Sprint(value.Interface())
+}
@@ -0,0 +1,211 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MonkyMars/PWS/types"
+)
+
+// Rule checks a single value and returns a message describing why it is invalid,
+// or an empty string when the value passes. The field name is used in the message.
+type Rule func(field, value string) string
+
+// Validator collects validation errors for several fields in one pass.
+// Every field is checked even after an earlier one failed, and a field
+// reports only the first rule it fails.
+type Validator struct {
+	errors  []types.ValidationError
+	secrets map[string]bool
+}
+
+// New creates an empty Validator
+func New() *Validator {
+	return &Validator{}
+}
+
+// Field checks value against the rules in order and records the first failure under field.
+// Rules other than Required pass empty values, so optional fields are only checked when set.
+// A field that already has an error is not checked again.
+func (v *Validator) Field(field, value string, rules ...Rule) *Validator {
+	if v.HasError(field) {
+		return v
+	}
+	for _, rule := range rules {
+		if message := rule(field, value); message != "" {
+			v.AddError(field, message, value)
+			break
+		}
+	}
+	return v
+}
+
+// Secret marks fields whose values, such as passwords, are never echoed back in errors
+func (v *Validator) Secret(fields ...string) *Validator {
+	if v.secrets == nil {
+		v.secrets = make(map[string]bool, len(fields))
+	}
+	for _, field := range fields {
+		v.secrets[field] = true
+	}
+	return v
+}
+
+// AddError records a validation error that was detected outside of a rule
+func (v *Validator) AddError(field, message, value string) *Validator {
+	if v.secrets[field] {
+		value = ""
+	}
+	v.errors = append(v.errors, types.ValidationError{
+		Field:   field,
+		Message: message,
+		Value:   value,
+	})
+	return v
+}
+
+// HasError reports whether an error was recorded for field
+func (v *Validator) HasError(field string) bool {
+	for _, err := range v.errors {
+		if err.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether no validation errors were recorded
+func (v *Validator) Valid() bool {
+	return len(v.errors) == 0
+}
+
+// Errors returns the recorded validation errors, nil when the input is valid
+func (v *Validator) Errors() []types.ValidationError {
+	return v.errors
+}
+
+// Required fails when the value is empty or only whitespace
+func Required() Rule {
+	return func(field, value string) string {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Sprintf("%s is required", field)
+		}
+		return ""
+	}
+}
+
+// MinLength fails when the value has fewer than n characters
+func MinLength(n int) Rule {
+	return func(field, value string) string {
+		if value != "" && utf8.RuneCountInString(value) < n {
+			return fmt.Sprintf("%s must be at least %d characters long", field, n)
+		}
+		return ""
+	}
+}
+
+// MaxLength fails when the value has more than n characters
+func MaxLength(n int) Rule {
+	return func(field, value string) string {
+		if utf8.RuneCountInString(value) > n {
+			return fmt.Sprintf("%s must not exceed %d characters", field, n)
+		}
+		return ""
+	}
+}
+
+// Email fails when the value is not a plain email address such as user@example.com.
+// The address must parse as RFC 5322 and its domain must look like a public host name.
+func Email() Rule {
+	return func(field, value string) string {
+		if value == "" {
+			return ""
+		}
+		// ParseAddress also accepts "Name <user@example.com>", only the bare address is allowed
+		address, err := mail.ParseAddress(value)
+		if err != nil || address.Address != value {
+			return fmt.Sprintf("%s must be a valid email address", field)
+		}
+		domain := value[strings.LastIndex(value, "@")+1:]
+		if !validEmailDomain(domain) {
+			return fmt.Sprintf("%s must have a valid domain, such as example.com", field)
+		}
+		return ""
+	}
+}
+
+// NormalizeEmail trims and lowercases an email address so lookups and uniqueness ignore case
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// validEmailDomain reports whether domain is a dotted host name with letter-only top level domain,
+// which rules out addresses like user@localhost, user@[127.0.0.1] and user@-example.com
+func validEmailDomain(domain string) bool {
+	if len(domain) > 253 {
+		return false
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+
+	tld := labels[len(labels)-1]
+	if len(tld) < 2 {
+		return false
+	}
+	for _, r := range tld {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches fails when the value does not match pattern, reporting message
+func Matches(pattern *regexp.Regexp, message string) Rule {
+	return func(field, value string) string {
+		if value != "" && !pattern.MatchString(value) {
+			return message
+		}
+		return ""
+	}
+}
+
+// EqualTo fails when the value differs from other, for example a password confirmation
+func EqualTo(other, otherField string) Rule {
+	return func(field, value string) string {
+		if value != other {
+			return fmt.Sprintf("%s must match %s", field, otherField)
+		}
+		return ""
+	}
+}
+
+// Check adapts a function returning an error into a rule, the error text becomes the message
+func Check(check func(value string) error) Rule {
+	return func(field, value string) string {
+		if value == "" {
+			return ""
+		}
+		if err := check(value); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+}
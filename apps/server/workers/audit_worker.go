@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/MonkyMars/PWS/database"
-	"github.com/MonkyMars/PWS/services"
 	"github.com/MonkyMars/PWS/types"
 )
 
@@ -57,7 +55,9 @@ func (aw *AuditWorker) Stop(ctx context.Context) error {
 	}
 }
 
-// AddLog adds an audit log entry to the processing queue
+// AddLog adds an audit log entry to the processing queue.
+// When the queue is full the entry is dropped, or for levels with the block overflow policy
+// it waits up to the overflow timeout for room first.
 func (aw *AuditWorker) AddLog(entry types.AuditLog) {
 	if !aw.cfg.Audit.Enabled {
 		return
@@ -101,17 +101,31 @@ func (aw *AuditWorker) AddLog(entry types.AuditLog) {
 	select {
 	case aw.auditChan <- entry:
 		// Successfully added to queue
+		return
 	default:
-		// Channel is full, update dropped count and log warning
-		aw.mu.Lock()
-		aw.stats.TotalDropped++
-		aw.mu.Unlock()
+	}
 
-		aw.logger.Warn("Audit log channel is full, dropping log entry",
-			"level", entry.Level,
-			"message", entry.Message,
-			"queue_size", len(aw.auditChan))
+	// Channel is full, levels with the block policy wait briefly for room before dropping
+	policy := aw.cfg.Audit.OverflowPolicyFor(entry.Level)
+	if policy == types.AuditOverflowBlock && aw.cfg.Audit.OverflowTimeout > 0 {
+		select {
+		case aw.auditChan <- entry:
+			return
+		case <-time.After(aw.cfg.Audit.OverflowTimeout):
+		case <-aw.ctx.Done():
+		}
 	}
+
+	// Update dropped count and log warning
+	aw.mu.Lock()
+	aw.stats.TotalDropped++
+	aw.mu.Unlock()
+
+	aw.logger.Warn("Audit log channel is full, dropping log entry",
+		"level", entry.Level,
+		"message", entry.Message,
+		"overflow_policy", policy,
+		"queue_size", len(aw.auditChan))
 }
 
 // HealthStatus returns the current health status of the audit worker
@@ -153,6 +167,7 @@ func (aw *AuditWorker) HealthStatus() map[string]any {
 		"failure_count":   aw.stats.FailureCount,
 		"total_processed": aw.stats.TotalProcessed,
 		"total_dropped":   aw.stats.TotalDropped,
+		"total_deduped":   aw.stats.TotalDeduped,
 		"is_healthy":      isHealthy,
 		"configuration": map[string]any{
 			"batch_size":     aw.cfg.Audit.BatchSize,
@@ -160,6 +175,9 @@ func (aw *AuditWorker) HealthStatus() map[string]any {
 			"max_retries":    aw.cfg.Audit.MaxRetries,
 			"max_failures":   aw.cfg.Audit.MaxFailures,
 			"retention_days": aw.cfg.Audit.RetentionDays,
+
+			"overflow_policies": aw.cfg.Audit.OverflowPolicies,
+			"overflow_timeout":  aw.cfg.Audit.OverflowTimeout.String(),
 		},
 	}
 }
@@ -199,6 +217,7 @@ func (aw *AuditWorker) run() {
 			// Flush remaining entries before shutting down
 			if len(batch) > 0 {
 				aw.flushBatch(batch)
+				batch = batch[:0]
 			}
 			// Drain any remaining entries in channel
 			for {
@@ -220,27 +239,30 @@ func (aw *AuditWorker) run() {
 	}
 }
 
-// flushBatch writes a batch of audit logs to the database
+// flushBatch writes a batch of audit logs to the database.
+// A batch that still fails after the configured retries is moved to the dead letter queue.
 func (aw *AuditWorker) flushBatch(entries []types.AuditLog) {
 	if len(entries) == 0 {
 		return
 	}
 
 	var err error
-	var successfulInserts int64
+	var successfulInserts, deduped int64
 
 	for attempt := 0; attempt < aw.cfg.Audit.MaxRetries; attempt++ {
-		successfulInserts, err = aw.tryFlushBatchWithCount(entries)
+		successfulInserts, deduped, err = aw.tryFlushBatchWithCount(entries)
 		if err == nil {
 			aw.mu.Lock()
 			aw.stats.FailureCount = 0 // Reset failure count on success
 			aw.stats.LastFlushTime = time.Now()
 			aw.stats.TotalProcessed += successfulInserts
+			aw.stats.TotalDeduped += deduped
 			aw.mu.Unlock()
 
 			aw.logger.Debug("Flushed audit log batch",
 				"count", len(entries),
 				"successful_inserts", successfulInserts,
+				"deduped", deduped,
 				"attempt", attempt+1)
 			return
 		}
@@ -262,6 +284,7 @@ func (aw *AuditWorker) flushBatch(entries []types.AuditLog) {
 	// After all retries failed, update failure count
 	aw.mu.Lock()
 	aw.stats.FailureCount++
+	failures := aw.stats.FailureCount
 	aw.mu.Unlock()
 
 	// After all retries failed, log the error but don't crash
@@ -269,55 +292,44 @@ func (aw *AuditWorker) flushBatch(entries []types.AuditLog) {
 		"error", err,
 		"batch_size", len(entries),
 		"max_retries", aw.cfg.Audit.MaxRetries,
-		"total_failures", aw.stats.FailureCount)
+		"total_failures", failures)
+
+	// Keep the entries in the dead letter queue so the cleanup worker can retry them once the database is back
+	if aw.dlq != nil {
+		aw.dlq.AddFailedBatch(entries, err)
+	}
 }
 
 // tryFlushBatchWithCount attempts to flush a batch and returns the count of successful inserts
-func (aw *AuditWorker) tryFlushBatchWithCount(entries []types.AuditLog) (int64, error) {
-	// Convert AuditLog entries to the format expected by SetEntries
-	auditEntries := make([]any, 0, len(entries))
-	skippedEntries := 0
+// and the count of valid entries the database skipped as duplicates of an already stored entry
+func (aw *AuditWorker) tryFlushBatchWithCount(entries []types.AuditLog) (int64, int64, error) {
+	insert := aw.insert
+	if insert == nil {
+		insert = newAuditInserter(aw.db)
+	}
+
+	// The worker context is already cancelled while draining on shutdown, so don't tie inserts to it
+	successfulInserts, err := insert(context.Background(), entries)
+	if err != nil {
+		return 0, 0, err
+	}
 
+	skippedEntries := 0
 	for _, entry := range entries {
-		// Validate entry before adding
 		if entry.Message == "" {
 			skippedEntries++
-			continue // Skip invalid entries
 		}
-
-		auditEntry := map[string]any{
-			"timestamp":  entry.Timestamp,
-			"level":      entry.Level,
-			"message":    entry.Message,
-			"attrs":      entry.Attrs,
-			"entry_hash": entry.EntryHash,
-			"source":     entry.Source,
-		}
-
-		auditEntries = append(auditEntries, auditEntry)
 	}
-
-	if len(auditEntries) == 0 {
-		return 0, nil // Nothing to flush
-	}
-
-	query := services.Query().
-		SetOperation("insert").
-		SetTable("audit_logs").
-		SetEntries(auditEntries)
-
-	result, err := database.ExecuteQuery[types.AuditLog](query)
-	if err != nil {
-		return 0, fmt.Errorf("database insert failed: %w", err)
-	}
-
-	// Return the actual number of rows inserted (may be less than auditEntries due to duplicates)
-	successfulInserts := result.Count
 	if skippedEntries > 0 {
 		aw.logger.Debug("Skipped invalid audit entries during flush",
 			"skipped_count", skippedEntries,
 			"total_entries", len(entries))
 	}
 
-	return successfulInserts, nil
+	deduped := int64(len(entries)-skippedEntries) - successfulInserts
+	if deduped < 0 {
+		deduped = 0
+	}
+
+	return successfulInserts, deduped, nil
 }
@@ -0,0 +1,198 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+)
+
+// newFullAuditWorker creates a running audit worker whose queue is already full
+func newFullAuditWorker(policies map[string]types.AuditOverflowPolicy, timeout time.Duration) *AuditWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	aw := &AuditWorker{
+		ctx:       ctx,
+		cancel:    cancel,
+		auditChan: make(chan types.AuditLog, 1),
+		running:   true,
+		logger:    newDiscardLogger(),
+		cfg: &config.Config{
+			Audit: types.AuditConfig{
+				Enabled:          true,
+				ChannelSize:      1,
+				MaxFailures:      3,
+				OverflowPolicies: policies,
+				OverflowTimeout:  timeout,
+			},
+		},
+	}
+	aw.auditChan <- types.AuditLog{Level: "INFO", Message: "filler"}
+	return aw
+}
+
+func TestAuditOverflowDropPolicy(t *testing.T) {
+	aw := newFullAuditWorker(map[string]types.AuditOverflowPolicy{"ERROR": types.AuditOverflowBlock}, time.Second)
+	defer aw.cancel()
+
+	start := time.Now()
+	aw.AddLog(types.AuditLog{Level: "WARN", Message: "dropped right away"})
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected drop policy not to block, took %s", elapsed)
+	}
+	if aw.stats.TotalDropped != 1 {
+		t.Errorf("Expected 1 dropped entry, got %d", aw.stats.TotalDropped)
+	}
+}
+
+func TestAuditOverflowBlockPolicyWaitsForRoom(t *testing.T) {
+	aw := newFullAuditWorker(map[string]types.AuditOverflowPolicy{"ERROR": types.AuditOverflowBlock}, time.Second)
+	defer aw.cancel()
+
+	// Free up the queue shortly after the entry starts waiting
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-aw.auditChan
+	}()
+
+	aw.AddLog(types.AuditLog{Level: "ERROR", Message: "must not be lost"})
+
+	if aw.stats.TotalDropped != 0 {
+		t.Errorf("Expected no dropped entries, got %d", aw.stats.TotalDropped)
+	}
+	if entry := <-aw.auditChan; entry.Message != "must not be lost" {
+		t.Errorf("Expected blocked entry to be queued, got %q", entry.Message)
+	}
+}
+
+func TestAuditOverflowBlockPolicyTimesOut(t *testing.T) {
+	aw := newFullAuditWorker(map[string]types.AuditOverflowPolicy{"*": types.AuditOverflowBlock}, 30*time.Millisecond)
+	defer aw.cancel()
+
+	start := time.Now()
+	aw.AddLog(types.AuditLog{Level: "INFO", Message: "waits then drops"})
+	elapsed := time.Since(start)
+
+	if elapsed < 30*time.Millisecond {
+		t.Errorf("Expected block policy to wait for the timeout, returned after %s", elapsed)
+	}
+	if elapsed > time.Second {
+		t.Errorf("Expected block policy to give up after the timeout, took %s", elapsed)
+	}
+	if aw.stats.TotalDropped != 1 {
+		t.Errorf("Expected 1 dropped entry, got %d", aw.stats.TotalDropped)
+	}
+}
+
+func TestAuditOverflowFailureModeSkipsBlocking(t *testing.T) {
+	aw := newFullAuditWorker(map[string]types.AuditOverflowPolicy{"ERROR": types.AuditOverflowBlock}, time.Second)
+	defer aw.cancel()
+	aw.stats.FailureCount = aw.cfg.Audit.MaxFailures
+
+	start := time.Now()
+	aw.AddLog(types.AuditLog{Level: "ERROR", Message: "worker is failing"})
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected failing worker to drop without blocking, took %s", elapsed)
+	}
+	if aw.stats.TotalDropped != 1 {
+		t.Errorf("Expected 1 dropped entry, got %d", aw.stats.TotalDropped)
+	}
+}
+
+func TestAuditFlushCountsDedupedEntries(t *testing.T) {
+	aw := &AuditWorker{
+		logger: newDiscardLogger(),
+		cfg: &config.Config{
+			Audit: types.AuditConfig{Enabled: true, MaxRetries: 1, MaxFailures: 3},
+		},
+	}
+
+	// Mimic ON CONFLICT (entry_hash) DO NOTHING: only unseen hashes are inserted
+	stored := map[string]bool{}
+	aw.insert = func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+		var inserted int64
+		for _, entry := range entries {
+			if entry.Message == "" || stored[entry.EntryHash] {
+				continue
+			}
+			stored[entry.EntryHash] = true
+			inserted++
+		}
+		return inserted, nil
+	}
+
+	aw.flushBatch([]types.AuditLog{
+		{Level: "ERROR", Message: "first", EntryHash: "a"},
+		{Level: "ERROR", Message: "second", EntryHash: "b"},
+		{Level: "ERROR", Message: ""},
+	})
+	if aw.stats.TotalProcessed != 2 || aw.stats.TotalDeduped != 0 {
+		t.Fatalf("Expected 2 processed and 0 deduped, got %d and %d", aw.stats.TotalProcessed, aw.stats.TotalDeduped)
+	}
+
+	aw.flushBatch([]types.AuditLog{
+		{Level: "ERROR", Message: "first", EntryHash: "a"},
+		{Level: "ERROR", Message: "third", EntryHash: "c"},
+	})
+	if aw.stats.TotalProcessed != 3 {
+		t.Errorf("Expected 3 processed entries, got %d", aw.stats.TotalProcessed)
+	}
+	if aw.stats.TotalDeduped != 1 {
+		t.Errorf("Expected 1 deduped entry, got %d", aw.stats.TotalDeduped)
+	}
+	if deduped := aw.HealthStatus()["total_deduped"]; deduped != int64(1) {
+		t.Errorf("Expected total_deduped 1 in health status, got %v", deduped)
+	}
+}
+
+func TestAuditFlushMovesFailedBatchToDeadLetterQueue(t *testing.T) {
+	logger := newDiscardLogger()
+	dlq := NewDeadLetterQueue(10, 3, logger)
+	aw := &AuditWorker{
+		logger: logger,
+		cfg: &config.Config{
+			Audit: types.AuditConfig{Enabled: true, MaxRetries: 2, MaxFailures: 3},
+		},
+		dlq: dlq,
+	}
+
+	attempts := 0
+	aw.insert = func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+		attempts++
+		return 0, errors.New("connection refused")
+	}
+
+	aw.flushBatch([]types.AuditLog{
+		{Level: "ERROR", Message: "first", EntryHash: "a"},
+		{Level: "ERROR", Message: "second", EntryHash: "b"},
+	})
+
+	if attempts != 2 {
+		t.Errorf("Expected 2 insert attempts, got %d", attempts)
+	}
+	if aw.stats.FailureCount != 1 {
+		t.Errorf("Expected failure count 1, got %d", aw.stats.FailureCount)
+	}
+	if size := dlq.Size(); size != 2 {
+		t.Fatalf("Expected the failed batch in the dead letter queue, got %d entries", size)
+	}
+
+	// Once the database is back the queued entries are recovered
+	var recovered []string
+	dlq.insert = func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+		for _, entry := range entries {
+			recovered = append(recovered, entry.Message)
+		}
+		return int64(len(entries)), nil
+	}
+	if n, err := dlq.RetryFailedLogs(context.Background()); err != nil || n != 2 {
+		t.Fatalf("Expected 2 recovered entries, got %d (%v)", n, err)
+	}
+	if len(recovered) != 2 || recovered[0] != "first" || recovered[1] != "second" {
+		t.Errorf("Expected the failed entries to be retried in order, got %v", recovered)
+	}
+}
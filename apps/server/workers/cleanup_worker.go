@@ -101,7 +101,10 @@ func (cw *CleanupWorker) HealthStatus() map[string]any {
 		"worker_running": cw.running,
 		"is_healthy":     isHealthy,
 		"configuration": map[string]any{
-			"retention_days": cw.cfg.Audit.RetentionDays,
+			"retention_days":   cw.cfg.Audit.RetentionDays,
+			"cleanup_interval": cw.cfg.Audit.CleanupInterval.String(),
+			"cleanup_offset":   cw.cfg.Audit.CleanupOffset.String(),
+			"cleanup_timezone": cw.cfg.Audit.CleanupTimezone,
 		},
 	}
 }
@@ -117,55 +120,124 @@ func (cw *CleanupWorker) run() {
 
 	cw.logger.Info("Starting audit log cleanup scheduler")
 
+	// Periodically drain the dead letter queue so failed audit logs are not kept forever
+	var retryTick <-chan time.Time
+	if cw.dlq != nil && cw.cfg.Audit.DLQInterval > 0 {
+		retryTicker := time.NewTicker(cw.cfg.Audit.DLQInterval)
+		defer retryTicker.Stop()
+		retryTick = retryTicker.C
+	}
+
+	location, err := time.LoadLocation(cw.cfg.Audit.CleanupTimezone)
+	if err != nil {
+		// Configuration is validated on load, so fall back to local time just in case
+		cw.logger.Warn("Invalid cleanup timezone, using local time", "timezone", cw.cfg.Audit.CleanupTimezone, "error", err)
+		location = time.Local
+	}
+
 	for {
-		// Calculate time until next midnight (00:00)
-		now := time.Now()
-		nextMidnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
-		duration := nextMidnight.Sub(now)
+		// Calculate time until the next scheduled cleanup
+		now := time.Now().In(location)
+		nextRun := nextCleanupRun(now, cw.cfg.Audit.CleanupInterval, cw.cfg.Audit.CleanupOffset)
+		timer := time.NewTimer(nextRun.Sub(now))
 
-		// Wait until midnight or context cancellation
+		// Wait until the next cleanup, the next dead letter retry, or context cancellation
 		select {
-		case <-time.After(duration):
+		case <-timer.C:
 			// Run cleanup
 			if err := cw.cleanupOldAuditLogs(); err != nil {
 				cw.logger.Error("Scheduled cleanup failed", "error", err)
 			} else {
 				cw.logger.Info("Scheduled cleanup completed successfully")
 			}
+		case <-retryTick:
+			timer.Stop()
+			cw.retryDeadLetters()
 		case <-cw.ctx.Done():
+			timer.Stop()
 			cw.logger.Info("Cleanup scheduler stopped")
 			return
 		}
 	}
 }
 
-// cleanupOldAuditLogs removes audit logs older than the retention period
+// nextCleanupRun returns the first scheduled cleanup strictly after now.
+// Runs happen every interval starting offset after midnight in now's location.
+// A zero interval or one of 24h or more results in one run per day at midnight plus offset.
+func nextCleanupRun(now time.Time, interval, offset time.Duration) time.Time {
+	day := 24 * time.Hour
+	if interval <= 0 || interval > day {
+		interval = day
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for next := midnight.Add(offset); next.Before(midnight.Add(day)); next = next.Add(interval) {
+		if next.After(now) {
+			return next
+		}
+	}
+
+	// No runs left today, the first run of tomorrow is at midnight plus offset
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	return tomorrow.Add(offset)
+}
+
+// retryDeadLetters re-attempts the audit logs waiting in the dead letter queue
+func (cw *CleanupWorker) retryDeadLetters() {
+	if cw.dlq == nil || cw.dlq.Size() == 0 {
+		return
+	}
+
+	// Bound a retry round so a slow database cannot stall the scheduler
+	ctx, cancel := context.WithTimeout(cw.ctx, cw.cfg.Audit.DLQInterval)
+	defer cancel()
+
+	if _, err := cw.dlq.RetryFailedLogs(ctx); err != nil {
+		cw.logger.Warn("Dead letter retry interrupted", "error", err, "remaining", cw.dlq.Size())
+	}
+}
+
+// cleanupBatchSize is the number of audit logs deleted per statement, small enough for one
+// batch to finish well within DB_STATEMENT_TIMEOUT on a large table
+const cleanupBatchSize = 5000
+
+// cleanupOldAuditLogs removes audit logs older than the retention period. The rows are deleted in
+// batches of cleanupBatchSize, so every statement stays bounded no matter how far behind the cleanup is.
 func (cw *CleanupWorker) cleanupOldAuditLogs() error {
 	if !cw.cfg.Audit.Enabled || cw.cfg.Audit.RetentionDays <= 0 {
 		return nil // No cleanup needed
 	}
 
 	cutoff := time.Now().AddDate(0, 0, -cw.cfg.Audit.RetentionDays)
-	query := services.Query().
-		SetOperation("delete").
-		SetTable("audit_logs").
-		SetWhereRaw("audit_logs.timestamp < ?", cutoff)
+	var deleted int64
+	for {
+		query := services.Query().
+			SetOperation("delete").
+			SetTable("audit_logs").
+			SetWhereRaw("audit_logs.id IN (SELECT id FROM audit_logs WHERE timestamp < ? ORDER BY timestamp LIMIT ?)", cutoff, cleanupBatchSize).
+			WithDB(auditLogConn(cw.db))
+
+		result, err := database.ExecuteQuery[types.AuditLog](query)
+		if err != nil {
+			cw.logger.Error("Failed to clean up old audit logs", "error", err, "deleted_count", deleted)
+			return fmt.Errorf("cleanup failed: %w", err)
+		}
 
-	result, err := database.ExecuteQuery[types.AuditLog](query)
-	if err != nil {
-		cw.logger.Error("Failed to clean up old audit logs", "error", err)
-		return fmt.Errorf("cleanup failed: %w", err)
+		deleted += result.Count
+		if result.Count < cleanupBatchSize {
+			break
+		}
 	}
 
-	cw.logger.Info("Cleaned up old audit logs", "deleted_count", result.Count)
+	cw.logger.Info("Cleaned up old audit logs", "deleted_count", deleted)
 	return nil
 }
 
 // Backward compatibility function
 func CleanupOldAuditLogs() error {
 	manager := GetGlobalManager()
-	if manager.cleanupWorker != nil {
-		return manager.cleanupWorker.cleanupOldAuditLogs()
+	if cw := manager.cleanup(); cw != nil {
+		return cw.cleanupOldAuditLogs()
 	}
 	return fmt.Errorf("cleanup worker not available")
 }
@@ -0,0 +1,60 @@
+package workers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextCleanupRun(t *testing.T) {
+	loc := time.UTC
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.March, day, hour, minute, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		interval time.Duration
+		offset   time.Duration
+		want     time.Time
+	}{
+		{"default runs at next midnight", at(10, 15, 30), 24 * time.Hour, 0, at(11, 0, 0)},
+		{"exactly midnight schedules the next day", at(10, 0, 0), 24 * time.Hour, 0, at(11, 0, 0)},
+		{"daily with offset later today", at(10, 1, 0), 24 * time.Hour, 3 * time.Hour, at(10, 3, 0)},
+		{"daily with offset already passed", at(10, 4, 0), 24 * time.Hour, 3 * time.Hour, at(11, 3, 0)},
+		{"every six hours", at(10, 7, 15), 6 * time.Hour, 0, at(10, 12, 0)},
+		{"every six hours with offset", at(10, 7, 15), 6 * time.Hour, 30 * time.Minute, at(10, 12, 30)},
+		{"last slot of the day wraps", at(10, 22, 0), 6 * time.Hour, time.Hour, at(11, 1, 0)},
+		{"hourly", at(10, 7, 15), time.Hour, 0, at(10, 8, 0)},
+		{"zero interval falls back to daily", at(10, 7, 15), 0, 0, at(11, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextCleanupRun(tt.now, tt.interval, tt.offset)
+			if !got.Equal(tt.want) {
+				t.Errorf("nextCleanupRun(%s, %s, %s) = %s, want %s", tt.now, tt.interval, tt.offset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextCleanupRunKeepsWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skipf("Timezone data unavailable: %v", err)
+	}
+
+	// Clocks move forward at 02:00 on 31 March 2024, making that day 23 hours long
+	now := time.Date(2024, time.March, 30, 12, 0, 0, 0, loc)
+	want := time.Date(2024, time.March, 31, 0, 0, 0, 0, loc)
+	if got := nextCleanupRun(now, 24*time.Hour, 0); !got.Equal(want) {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	now = time.Date(2024, time.March, 31, 12, 0, 0, 0, loc)
+	want = time.Date(2024, time.April, 1, 0, 0, 0, 0, loc)
+	if got := nextCleanupRun(now, 24*time.Hour, 0); !got.Equal(want) {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
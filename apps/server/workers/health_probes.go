@@ -0,0 +1,117 @@
+package workers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/MonkyMars/PWS/services"
+)
+
+// Synthetic service names used to report dependency probes alongside the HTTP route services
+const (
+	ProbeServiceDatabase = "__database"
+	ProbeServiceRedis    = "__redis"
+)
+
+// probeTimeout bounds a single dependency check so a hanging dependency cannot stall the reporter
+const probeTimeout = 5 * time.Second
+
+// dependencyProbe periodically checks that an external dependency is reachable
+type dependencyProbe struct {
+	name     string
+	critical bool
+	check    func(ctx context.Context) error
+
+	up                  bool
+	consecutiveFailures int
+	lastLatency         time.Duration
+	lastError           string
+	lastChecked         time.Time
+}
+
+// defaultDependencyProbes returns the probes for the database and Redis
+func defaultDependencyProbes() []*dependencyProbe {
+	return []*dependencyProbe{
+		{
+			name:     ProbeServiceDatabase,
+			critical: true,
+			check:    services.PingWithContext,
+		},
+		{
+			name:     ProbeServiceRedis,
+			critical: true,
+			check: func(ctx context.Context) error {
+				return services.NewCacheService().PingContext(ctx)
+			},
+		},
+	}
+}
+
+// runProbes checks every dependency and records the result as a synthetic service request.
+// Probes run sequentially; each one is bounded by probeTimeout.
+func (hw *HealthWorker) runProbes() {
+	for _, probe := range hw.probes {
+		ctx, cancel := context.WithTimeout(hw.ctx, probeTimeout)
+		start := time.Now()
+		err := probe.check(ctx)
+		latency := time.Since(start)
+		cancel()
+
+		hw.mu.Lock()
+		probe.lastLatency = latency
+		probe.lastChecked = start
+		if err != nil {
+			probe.up = false
+			probe.consecutiveFailures++
+			probe.lastError = err.Error()
+		} else {
+			probe.up = true
+			probe.consecutiveFailures = 0
+			probe.lastError = ""
+		}
+		failures := probe.consecutiveFailures
+		hw.mu.Unlock()
+
+		status := http.StatusOK
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			hw.logger.Warn("Dependency probe failed",
+				"service", probe.name,
+				"consecutive_failures", failures,
+				"error", err)
+		}
+
+		hw.RegisterService(probe.name)
+		hw.RecordRequest(probe.name, status, latency)
+	}
+}
+
+// criticalDependencyDown reports whether a critical dependency has failed more than the
+// configured number of consecutive probes. Callers must hold hw.mu.
+func (hw *HealthWorker) criticalDependencyDown() bool {
+	threshold := max(hw.cfg.Health.ProbeFailureThreshold, 0)
+
+	for _, probe := range hw.probes {
+		if probe.critical && probe.consecutiveFailures > threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyStatus returns the latest probe results keyed by service name. Callers must hold hw.mu.
+func (hw *HealthWorker) dependencyStatus() map[string]any {
+	status := make(map[string]any, len(hw.probes))
+	for _, probe := range hw.probes {
+		status[probe.name] = map[string]any{
+			"up":                   probe.up,
+			"critical":             probe.critical,
+			"consecutive_failures": probe.consecutiveFailures,
+			"latency_ms":           durationToMilliseconds(probe.lastLatency),
+			"last_error":           probe.lastError,
+			"last_checked":         probe.lastChecked,
+		}
+	}
+	return status
+}
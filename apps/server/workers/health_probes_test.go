@@ -0,0 +1,97 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+)
+
+func newProbeTestWorker(threshold int, probes ...*dependencyProbe) *HealthWorker {
+	cfg := &config.Config{Health: types.HealthConfig{Enabled: true, ProbeFailureThreshold: threshold}}
+	return &HealthWorker{
+		ctx:      context.Background(),
+		services: make(map[string]*RouteService),
+		running:  true,
+		logger:   newDiscardLogger(),
+		cfg:      cfg,
+		probes:   probes,
+	}
+}
+
+func TestHealthProbesFlipHealthAfterThreshold(t *testing.T) {
+	var dbErr error
+	database := &dependencyProbe{
+		name:     ProbeServiceDatabase,
+		critical: true,
+		check:    func(ctx context.Context) error { return dbErr },
+	}
+	hw := newProbeTestWorker(2, database)
+
+	hw.runProbes()
+	if healthy := hw.HealthStatus()["is_healthy"]; healthy != true {
+		t.Fatalf("Expected healthy worker after a successful probe, got %v", healthy)
+	}
+
+	dbErr = errors.New("connection refused")
+	hw.runProbes()
+	hw.runProbes()
+	if healthy := hw.HealthStatus()["is_healthy"]; healthy != true {
+		t.Errorf("Expected worker to stay healthy up to the failure threshold, got %v", healthy)
+	}
+
+	hw.runProbes()
+	status := hw.HealthStatus()
+	if healthy := status["is_healthy"]; healthy != false {
+		t.Errorf("Expected unhealthy worker after exceeding the failure threshold, got %v", healthy)
+	}
+
+	deps := status["dependencies"].(map[string]any)
+	db := deps[ProbeServiceDatabase].(map[string]any)
+	if db["up"] != false || db["consecutive_failures"] != 3 || db["last_error"] != "connection refused" {
+		t.Errorf("Unexpected database probe status: %v", db)
+	}
+
+	// A single successful probe restores health
+	dbErr = nil
+	hw.runProbes()
+	if healthy := hw.HealthStatus()["is_healthy"]; healthy != true {
+		t.Errorf("Expected worker to recover after a successful probe, got %v", healthy)
+	}
+}
+
+func TestHealthProbesRecordSyntheticServices(t *testing.T) {
+	redis := &dependencyProbe{
+		name:     ProbeServiceRedis,
+		critical: true,
+		check:    func(ctx context.Context) error { return errors.New("i/o timeout") },
+	}
+	hw := newProbeTestWorker(3, redis)
+
+	hw.runProbes()
+	hw.runProbes()
+
+	stats := hw.GetServiceStats(ProbeServiceRedis)
+	if stats == nil {
+		t.Fatal("Expected probe results to be recorded as a synthetic service")
+	}
+	if stats.RequestCount != 2 || stats.ErrorCount != 2 || stats.LastStatus != 503 {
+		t.Errorf("Expected 2 failed probes with status 503, got %d requests, %d errors, status %d",
+			stats.RequestCount, stats.ErrorCount, stats.LastStatus)
+	}
+}
+
+func TestHealthProbesIgnoreNonCriticalFailures(t *testing.T) {
+	optional := &dependencyProbe{
+		name:  "__optional",
+		check: func(ctx context.Context) error { return errors.New("down") },
+	}
+	hw := newProbeTestWorker(1, optional)
+
+	hw.runProbes()
+	if healthy := hw.HealthStatus()["is_healthy"]; healthy != true {
+		t.Errorf("Expected non-critical probe failures not to affect health, got %v", healthy)
+	}
+}
@@ -0,0 +1,144 @@
+package workers
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RouteStats tracks metrics for a single registered route, identified by its method and path template
+// such as GET /deadlines/:id. The per-service RouteService counters are the rollup of these.
+type RouteStats struct {
+	Method       string
+	Path         string
+	Service      string
+	RequestCount int64
+	ErrorCount   int64
+	TotalLatency time.Duration
+	LastStatus   int
+	mutex        sync.Mutex
+
+	// latencies samples request latencies since the last health report, like RouteService does
+	latencies *latencyReservoir
+}
+
+// RouteMetrics is a point-in-time snapshot of the statistics of one route
+type RouteMetrics struct {
+	Method         string  `json:"method"`
+	Path           string  `json:"path"`
+	Service        string  `json:"service"`
+	RequestCount   int64   `json:"request_count"`
+	ErrorCount     int64   `json:"error_count"`
+	LastStatus     int     `json:"last_status"`
+	TotalLatencyMs float64 `json:"total_latency_ms"`
+	AverageLatency float64 `json:"average_latency_ms"`
+	P50Latency     float64 `json:"p50_latency_ms"`
+	P95Latency     float64 `json:"p95_latency_ms"`
+	P99Latency     float64 `json:"p99_latency_ms"`
+}
+
+// routeKey identifies a route by its method and path template
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// registerRoute starts tracking a route of the given service.
+// Only registered routes are recorded, so the number of tracked routes cannot grow with the request paths.
+func (hw *HealthWorker) registerRoute(method, path, serviceName string) {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+
+	key := routeKey(method, path)
+	if _, exists := hw.routes[key]; !exists {
+		hw.routes[key] = &RouteStats{
+			Method:    method,
+			Path:      path,
+			Service:   serviceName,
+			latencies: newLatencyReservoir(latencyReservoirSize),
+		}
+	}
+}
+
+// RecordRouteRequest records a request for a route registered by DiscoverRoutes, and for the
+// service it was discovered under. Requests that did not match a registered route, such as 404s
+// and the health and metrics routes, are ignored.
+func (hw *HealthWorker) RecordRouteRequest(method, path string, statusCode int, latency time.Duration) {
+	if !hw.cfg.Health.Enabled {
+		return
+	}
+
+	hw.mu.RLock()
+	route, exists := hw.routes[routeKey(method, path)]
+	hw.mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	route.mutex.Lock()
+	route.RequestCount++
+	route.TotalLatency += latency
+	route.LastStatus = statusCode
+	route.latencies.Add(latency)
+
+	if statusCode >= 400 {
+		route.ErrorCount++
+	}
+	route.mutex.Unlock()
+
+	hw.RecordRequest(route.Service, statusCode, latency)
+}
+
+// RouteMetrics returns a snapshot of every route that received requests, sorted by service, path and method.
+// Latency percentiles cover the requests since the last health report.
+func (hw *HealthWorker) RouteMetrics() []RouteMetrics {
+	hw.mu.RLock()
+	routes := make([]*RouteStats, 0, len(hw.routes))
+	for _, route := range hw.routes {
+		routes = append(routes, route)
+	}
+	hw.mu.RUnlock()
+
+	metrics := make([]RouteMetrics, 0, len(routes))
+	for _, route := range routes {
+		route.mutex.Lock()
+		if route.RequestCount == 0 {
+			route.mutex.Unlock()
+			continue
+		}
+
+		percentiles := route.latencies.Percentiles(50, 95, 99)
+		metrics = append(metrics, RouteMetrics{
+			Method:         route.Method,
+			Path:           route.Path,
+			Service:        route.Service,
+			RequestCount:   route.RequestCount,
+			ErrorCount:     route.ErrorCount,
+			LastStatus:     route.LastStatus,
+			TotalLatencyMs: durationToMilliseconds(route.TotalLatency),
+			AverageLatency: durationToMilliseconds(route.TotalLatency / time.Duration(route.RequestCount)),
+			P50Latency:     durationToMilliseconds(percentiles[0]),
+			P95Latency:     durationToMilliseconds(percentiles[1]),
+			P99Latency:     durationToMilliseconds(percentiles[2]),
+		})
+		route.mutex.Unlock()
+	}
+
+	slices.SortFunc(metrics, func(a, b RouteMetrics) int {
+		return cmp.Or(cmp.Compare(a.Service, b.Service), cmp.Compare(a.Path, b.Path), cmp.Compare(a.Method, b.Method))
+	})
+	return metrics
+}
+
+// resetRouteLatencies starts a new percentile window for every route, called with each health report
+func (hw *HealthWorker) resetRouteLatencies() {
+	hw.mu.RLock()
+	defer hw.mu.RUnlock()
+
+	for _, route := range hw.routes {
+		route.mutex.Lock()
+		route.latencies.Reset()
+		route.mutex.Unlock()
+	}
+}
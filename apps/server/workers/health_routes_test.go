@@ -0,0 +1,123 @@
+package workers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestRouteMetricsUseRouteTemplates(t *testing.T) {
+	manager := newGlobalTestManager(t, nil)
+	StartHealthLogWorker()
+	t.Cleanup(StopHealthLogWorker)
+
+	app := fiber.New()
+	// Records like the health middleware: the matched route is only known after routing
+	app.Use(func(c fiber.Ctx) error {
+		err := c.Next()
+		if c.Matched() {
+			route := c.Route()
+			manager.RecordRouteMetric(route.Method, route.Path, c.Response().StatusCode(), time.Millisecond)
+		}
+		return err
+	})
+	app.Get("/deadlines", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/deadlines/:id", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Delete("/deadlines/:id", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusForbidden) })
+	app.Get("/health", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	manager.DiscoverRoutes(app)
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/deadlines"},
+		{http.MethodGet, "/deadlines/1"},
+		{http.MethodGet, "/deadlines/2"},
+		{http.MethodDelete, "/deadlines/1"},
+		{http.MethodGet, "/health"},
+		{http.MethodGet, "/unknown/path"},
+	} {
+		resp, err := app.Test(httptest.NewRequest(req.method, req.path, nil))
+		if err != nil {
+			t.Fatalf("Request %s %s failed: %v", req.method, req.path, err)
+		}
+		resp.Body.Close()
+	}
+
+	expected := []RouteMetrics{
+		{Method: http.MethodGet, Path: "/deadlines", Service: "deadlines", RequestCount: 1, LastStatus: fiber.StatusOK},
+		{Method: http.MethodDelete, Path: "/deadlines/:id", Service: "deadlines", RequestCount: 1, ErrorCount: 1, LastStatus: fiber.StatusForbidden},
+		{Method: http.MethodGet, Path: "/deadlines/:id", Service: "deadlines", RequestCount: 2, LastStatus: fiber.StatusOK},
+	}
+
+	metrics := GetRouteMetrics()
+	if len(metrics) != len(expected) {
+		t.Fatalf("Expected %d routes, got %d: %+v", len(expected), len(metrics), metrics)
+	}
+	for i, want := range expected {
+		got := metrics[i]
+		if got.Method != want.Method || got.Path != want.Path || got.Service != want.Service ||
+			got.RequestCount != want.RequestCount || got.ErrorCount != want.ErrorCount || got.LastStatus != want.LastStatus {
+			t.Errorf("Route %d: expected %+v, got %+v", i, want, got)
+		}
+		if got.AverageLatency != 1 || got.P95Latency != 1 {
+			t.Errorf("Route %d: expected 1ms latencies, got average %v and p95 %v", i, got.AverageLatency, got.P95Latency)
+		}
+	}
+
+	// The routes roll up into the service they were discovered under
+	if services := manager.health().GetAllServices(); len(services) != 1 || services[0] != "deadlines" {
+		t.Errorf("Expected only the deadlines service, got %v", services)
+	}
+	stats, err := GetServiceStats("deadlines")
+	if err != nil || stats == nil {
+		t.Fatalf("Expected stats for the deadlines service, got %v, %v", stats, err)
+	}
+	if stats.RequestCount != 4 || stats.ErrorCount != 1 {
+		t.Errorf("Expected 4 requests and 1 error for the deadlines service, got %d and %d", stats.RequestCount, stats.ErrorCount)
+	}
+}
+
+func TestRouteMetricsDisabledHealth(t *testing.T) {
+	manager := newGlobalTestManager(t, nil)
+	manager.cfg.Health.Enabled = false
+	StartHealthLogWorker()
+	t.Cleanup(StopHealthLogWorker)
+
+	app := fiber.New()
+	app.Get("/deadlines/:id", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	manager.DiscoverRoutes(app)
+
+	manager.RecordRouteMetric(http.MethodGet, "/deadlines/:id", fiber.StatusOK, time.Millisecond)
+
+	if metrics := GetRouteMetrics(); len(metrics) != 0 {
+		t.Errorf("Expected no route metrics with health monitoring disabled, got %+v", metrics)
+	}
+	if services := GetAllServices(); len(services) != 0 {
+		t.Errorf("Expected no services with health monitoring disabled, got %v", services)
+	}
+}
+
+func TestRouteLatencyWindowResets(t *testing.T) {
+	manager := newGlobalTestManager(t, nil)
+	StartHealthLogWorker()
+	t.Cleanup(StopHealthLogWorker)
+
+	hw := manager.health()
+	hw.registerRoute(http.MethodGet, "/subjects/:id", "subjects")
+	hw.RecordRouteRequest(http.MethodGet, "/subjects/:id", fiber.StatusOK, 10*time.Millisecond)
+	hw.resetRouteLatencies()
+
+	metrics := hw.RouteMetrics()
+	if len(metrics) != 1 {
+		t.Fatalf("Expected 1 route, got %d", len(metrics))
+	}
+	if metrics[0].RequestCount != 1 || metrics[0].AverageLatency != 10 {
+		t.Errorf("Expected the counters to survive the reset, got %+v", metrics[0])
+	}
+	if metrics[0].P50Latency != 0 {
+		t.Errorf("Expected an empty percentile window after the reset, got p50 %v", metrics[0].P50Latency)
+	}
+}
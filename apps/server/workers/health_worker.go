@@ -4,11 +4,11 @@ import (
 	"context"
 	"fmt"
 	"maps"
-	"runtime"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/MonkyMars/PWS/config"
 	"github.com/MonkyMars/PWS/database"
 	"github.com/MonkyMars/PWS/lib"
 	"github.com/MonkyMars/PWS/services"
@@ -26,6 +26,9 @@ type RouteService struct {
 	LastStatus   int
 	StartTime    time.Time
 	mutex        sync.RWMutex
+
+	// latencies samples request latencies since the last health report for percentile tracking
+	latencies *latencyReservoir
 }
 
 // Start starts the health worker
@@ -81,26 +84,32 @@ func (hw *HealthWorker) Stop(ctx context.Context) error {
 	}
 }
 
-// DiscoverRoutes automatically discovers all base routes from the fiber app
+// DiscoverRoutes automatically discovers all base routes from the fiber app.
+// Each route is also tracked on its own, keyed by method and path template.
 func (hw *HealthWorker) DiscoverRoutes(app *fiber.App) {
 	if !hw.cfg.Health.Enabled {
 		return
 	}
 
 	// Get all routes from the fiber app
-	routes := app.GetRoutes()
+	routes := app.GetRoutes(true)
 	hw.logger.Info("Starting route discovery", "total_routes", len(routes))
 
 	discoveredServices := make(map[string]bool)
 
 	for _, route := range routes {
-		basePath := hw.extractBasePath(route.Path)
+		basePath := ServiceFromPath(route.Path)
+		if basePath == "" {
+			continue
+		}
 
-		if basePath != "" && !discoveredServices[basePath] {
+		if !discoveredServices[basePath] {
 			hw.RegisterService(basePath)
 			discoveredServices[basePath] = true
 			hw.logger.Info("Registered new service", "service", basePath)
 		}
+
+		hw.registerRoute(route.Method, route.Path, basePath)
 	}
 
 	services := make([]string, 0, len(discoveredServices))
@@ -120,6 +129,7 @@ func (hw *HealthWorker) RegisterService(serviceName string) {
 			Name:      serviceName,
 			BasePath:  "/" + serviceName,
 			StartTime: time.Now(),
+			latencies: newLatencyReservoir(latencyReservoirSize),
 		}
 	}
 }
@@ -144,6 +154,7 @@ func (hw *HealthWorker) RecordRequest(serviceName string, statusCode int, latenc
 	service.RequestCount++
 	service.TotalLatency += latency
 	service.LastStatus = statusCode
+	service.latencies.Add(latency)
 
 	if statusCode >= 400 {
 		service.ErrorCount++
@@ -179,7 +190,8 @@ func (hw *HealthWorker) HealthStatus() map[string]any {
 	}
 
 	serviceCount := len(hw.services)
-	isHealthy := hw.cfg.Health.Enabled && hw.running
+	dependenciesHealthy := !hw.criticalDependencyDown()
+	isHealthy := hw.cfg.Health.Enabled && hw.running && dependenciesHealthy
 
 	return map[string]any{
 		"enabled":         hw.cfg.Health.Enabled,
@@ -189,10 +201,12 @@ func (hw *HealthWorker) HealthStatus() map[string]any {
 		"last_flush_time": hw.lastFlushTime,
 		"service_count":   serviceCount,
 		"is_healthy":      isHealthy,
+		"dependencies":    hw.dependencyStatus(),
 		"configuration": map[string]any{
-			"report_interval": hw.cfg.Health.ReportInterval.String(),
-			"flush_time":      hw.cfg.Health.FlushTime.String(),
-			"channel_size":    hw.cfg.Health.ChannelSize,
+			"report_interval":         hw.cfg.Health.ReportInterval.String(),
+			"flush_time":              hw.cfg.Health.FlushTime.String(),
+			"channel_size":            hw.cfg.Health.ChannelSize,
+			"probe_failure_threshold": hw.cfg.Health.ProbeFailureThreshold,
 		},
 	}
 }
@@ -241,12 +255,17 @@ func (hw *HealthWorker) healthReporter() {
 	ticker := time.NewTicker(hw.cfg.Health.ReportInterval)
 	defer ticker.Stop()
 
+	// Probe dependencies right away so the status is known before the first report
+	hw.runProbes()
+
 	for {
 		select {
 		case <-hw.ctx.Done():
 			return
 		case <-ticker.C:
+			hw.runProbes()
 			hw.generateHealthReports()
+			hw.resetRouteLatencies()
 		}
 	}
 }
@@ -272,14 +291,22 @@ func (hw *HealthWorker) generateHealthReports() {
 	}
 }
 
-// createHealthLog creates a health log from service metrics
+// createHealthLog creates a health log from service metrics.
+// Latency percentiles cover the requests since the previous report, after which the samples are reset.
 func (hw *HealthWorker) createHealthLog(serviceName string, service *RouteService) types.HealthLog {
-	service.mutex.RLock()
-	defer service.mutex.RUnlock()
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
 
 	var averageLatency time.Duration
 	if service.RequestCount > 0 {
-		averageLatency = time.Duration(service.TotalLatency.Milliseconds() / service.RequestCount)
+		averageLatency = service.TotalLatency / time.Duration(service.RequestCount)
+	}
+
+	var p50, p95, p99 time.Duration
+	if service.latencies != nil {
+		percentiles := service.latencies.Percentiles(50, 95, 99)
+		p50, p95, p99 = percentiles[0], percentiles[1], percentiles[2]
+		service.latencies.Reset()
 	}
 
 	statusCode := service.LastStatus
@@ -295,14 +322,10 @@ func (hw *HealthWorker) createHealthLog(serviceName string, service *RouteServic
 		timeSpan = time.Since(hw.lastFlushTime)
 	}
 
-	// Capture source information (file:line)
+	// Capture source information (file:line) of the caller
 	source := ""
-	if _, file, line, ok := runtime.Caller(1); ok {
-		// Extract just the filename, not the full path
-		if idx := strings.LastIndex(file, "/"); idx >= 0 {
-			file = file[idx+1:]
-		}
-		source = fmt.Sprintf("%s:%d", file, line)
+	if hw.cfg != nil && hw.cfg.LogSource {
+		source = config.CallerSource(1)
 	}
 
 	return types.HealthLog{
@@ -312,6 +335,9 @@ func (hw *HealthWorker) createHealthLog(serviceName string, service *RouteServic
 		RequestCount:   service.RequestCount,
 		ErrorCount:     service.ErrorCount,
 		AverageLatency: averageLatency,
+		P50Latency:     p50,
+		P95Latency:     p95,
+		P99Latency:     p99,
 		TimeSpan:       timeSpan,
 		Source:         source,
 	}
@@ -363,7 +389,8 @@ func (hw *HealthWorker) flushLogs(logs []types.HealthLog) {
 	query := services.Query().
 		SetOperation("insert").
 		SetTable(lib.TableHealthLogs).
-		SetEntries(items)
+		SetEntries(items).
+		WithDB(auditLogConn(hw.db))
 
 	_, err := database.ExecuteQuery[any](query)
 	if err != nil {
@@ -389,13 +416,22 @@ func (hw *HealthWorker) convertHealthLogToMap(log types.HealthLog) map[string]an
 		"request_count":   log.RequestCount,
 		"error_count":     log.ErrorCount,
 		"average_latency": latencyMs,
+		"p50_latency":     durationToMilliseconds(log.P50Latency),
+		"p95_latency":     durationToMilliseconds(log.P95Latency),
+		"p99_latency":     durationToMilliseconds(log.P99Latency),
 		"time_span":       timeSpanSeconds,
 		"source":          log.Source,
 	}
 }
 
-// extractBasePath extracts the base path from a route path
-func (hw *HealthWorker) extractBasePath(path string) string {
+// durationToMilliseconds converts a duration to fractional milliseconds so sub-millisecond latencies are kept
+func durationToMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// ServiceFromPath returns the service a path belongs to, its first segment such as "deadlines" for
+// /deadlines/:id. Returns an empty string for the health and system routes, which are not monitored.
+func ServiceFromPath(path string) string {
 	// Remove leading slash and split by slash
 	trimmed := strings.TrimPrefix(path, "/")
 	if trimmed == "" {
@@ -426,13 +462,13 @@ func (hw *HealthWorker) extractBasePath(path string) string {
 
 // LogHealthEvent logs a single health event
 func LogHealthEvent(entry types.HealthLog) {
-	manager := GetGlobalManager()
-	if manager.healthWorker == nil {
+	hw := GetGlobalManager().health()
+	if hw == nil {
 		return
 	}
 
 	select {
-	case manager.healthWorker.healthChan <- entry:
+	case hw.healthChan <- entry:
 	default:
 		// Channel is full, drop the log entry
 	}
@@ -440,18 +476,24 @@ func LogHealthEvent(entry types.HealthLog) {
 
 // GetServiceStats returns current statistics for a service (backward compatibility)
 func GetServiceStats(serviceName string) (*RouteService, error) {
-	manager := GetGlobalManager()
-	if manager.healthWorker != nil {
-		return manager.healthWorker.GetServiceStats(serviceName), nil
+	if hw := GetGlobalManager().health(); hw != nil {
+		return hw.GetServiceStats(serviceName), nil
 	}
 	return nil, lib.ErrServiceUnavailable
 }
 
+// GetRouteMetrics returns the per-route statistics of the health worker
+func GetRouteMetrics() []RouteMetrics {
+	if hw := GetGlobalManager().health(); hw != nil {
+		return hw.RouteMetrics()
+	}
+	return nil
+}
+
 // GetAllServices returns a list of all registered services (backward compatibility)
 func GetAllServices() []string {
-	manager := GetGlobalManager()
-	if manager.healthWorker != nil {
-		return manager.healthWorker.GetAllServices()
+	if hw := GetGlobalManager().health(); hw != nil {
+		return hw.GetAllServices()
 	}
 	return nil
 }
@@ -0,0 +1,329 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/go-pg/pg/v10"
+)
+
+// auditInsertFunc writes audit log entries to storage and returns the number of inserted rows
+type auditInsertFunc func(ctx context.Context, entries []types.AuditLog) (int64, error)
+
+// DeadLetterEntry holds an item that could not be delivered, together with its retry state
+type DeadLetterEntry[T any] struct {
+	Item          T         `json:"item"`
+	LastError     string    `json:"last_error"`
+	Attempts      int       `json:"attempts"`
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	LastAttemptAt time.Time `json:"last_attempt_at"`
+}
+
+// deadLetterStore is the dead letter machinery shared by the audit log, notification and webhook queues.
+// It keeps failed items with their retry state, drops the oldest items when full and gives up
+// on an item after maxAttempts retries. The queue lives in memory only and is not persisted,
+// items still queued when the process stops are lost; the worker manager logs how many on Stop.
+// The notification queue can keep its items in a NotificationQueueStore instead.
+type deadLetterStore[T any] struct {
+	mu          sync.Mutex
+	entries     []*DeadLetterEntry[T]
+	maxSize     int
+	maxAttempts int
+	dropped     int64
+	recovered   int64
+	logger      *config.Logger
+
+	// label names the queued items in log messages, describe returns log attributes identifying an item
+	label    string
+	describe func(T) []any
+}
+
+func newDeadLetterStore[T any](label string, maxSize, maxAttempts int, describe func(T) []any, logger *config.Logger) *deadLetterStore[T] {
+	return &deadLetterStore[T]{
+		entries:     make([]*DeadLetterEntry[T], 0),
+		maxSize:     maxSize,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+		label:       label,
+		describe:    describe,
+	}
+}
+
+// add queues every item that failed with err.
+// When the queue is full the oldest entries are dropped to make room.
+func (s *deadLetterStore[T]) add(items []T, err error) {
+	if len(items) == 0 {
+		return
+	}
+
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	now := time.Now()
+	entries := make([]*DeadLetterEntry[T], 0, len(items))
+	for _, item := range items {
+		entries = append(entries, &DeadLetterEntry[T]{
+			Item:          item,
+			LastError:     errMsg,
+			FirstFailedAt: now,
+			LastAttemptAt: now,
+		})
+	}
+	s.restore(entries)
+}
+
+// restore queues entries that keep their retry state, such as entries loaded from persistent storage.
+// When the queue is full the oldest entries are dropped to make room.
+func (s *deadLetterStore[T]) restore(entries []*DeadLetterEntry[T]) {
+	if len(entries) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, entries...)
+	if overflow := len(s.entries) - s.maxSize; s.maxSize > 0 && overflow > 0 {
+		s.entries = s.entries[overflow:]
+		s.countDropped(overflow)
+	}
+}
+
+// countDropped records entries dropped because the queue was full. Callers must hold s.mu.
+func (s *deadLetterStore[T]) countDropped(dropped int) {
+	s.dropped += int64(dropped)
+	s.logger.Warn("Dead letter queue full, dropped oldest entries",
+		"queue", s.label,
+		"dropped", dropped,
+		"max_size", s.maxSize)
+}
+
+// take removes every queued entry and returns them, oldest first
+func (s *deadLetterStore[T]) take() []*DeadLetterEntry[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.entries
+	s.entries = make([]*DeadLetterEntry[T], 0)
+	return entries
+}
+
+// retry re-attempts every queued entry once with the given function.
+// Entries that succeed or exceed the maximum attempts are removed from the queue.
+// Returns the number of recovered entries and stops early if the context is cancelled.
+func (s *deadLetterStore[T]) retry(ctx context.Context, attempt func(context.Context, T) error) (int, error) {
+	// Take the current entries so new failures can be queued while retrying
+	pending := s.take()
+
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	recovered := 0
+	remaining := make([]*DeadLetterEntry[T], 0)
+	var ctxErr error
+
+	for i, entry := range pending {
+		if err := ctx.Err(); err != nil {
+			// Put back everything that has not been attempted yet
+			remaining = append(remaining, pending[i:]...)
+			ctxErr = err
+			break
+		}
+
+		entry.Attempts++
+		entry.LastAttemptAt = time.Now()
+
+		if err := attempt(ctx, entry.Item); err != nil {
+			entry.LastError = err.Error()
+			if entry.Attempts >= s.maxAttempts {
+				attrs := append([]any{"queue", s.label}, s.describe(entry.Item)...)
+				attrs = append(attrs,
+					"attempts", entry.Attempts,
+					"error", fmt.Errorf("dead letter retry failed: %w", err))
+				s.logger.Error("Dropping entry after exhausting dead letter retries", attrs...)
+				s.mu.Lock()
+				s.dropped++
+				s.mu.Unlock()
+				continue
+			}
+			remaining = append(remaining, entry)
+			continue
+		}
+
+		recovered++
+	}
+
+	s.mu.Lock()
+	s.entries = append(remaining, s.entries...)
+	s.recovered += int64(recovered)
+	s.mu.Unlock()
+
+	if recovered > 0 {
+		s.logger.Info("Recovered entries from dead letter queue",
+			"queue", s.label,
+			"recovered", recovered,
+			"remaining", len(remaining))
+	}
+
+	return recovered, ctxErr
+}
+
+// Size returns the number of entries waiting to be retried
+func (s *deadLetterStore[T]) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Entries returns a snapshot of the queued entries, oldest first
+func (s *deadLetterStore[T]) Entries() []DeadLetterEntry[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]DeadLetterEntry[T], len(s.entries))
+	for i, entry := range s.entries {
+		entries[i] = *entry
+	}
+	return entries
+}
+
+// warnUnpersisted logs the entries that are lost because the process stops while they are still queued
+func (s *deadLetterStore[T]) warnUnpersisted() {
+	if pending := s.Size(); pending > 0 {
+		s.logger.Warn("Dead letter queue is not persisted, discarding queued entries on shutdown",
+			"queue", s.label,
+			"discarded", pending)
+	}
+}
+
+// Clear removes every queued entry without retrying it and returns the number of removed entries
+func (s *deadLetterStore[T]) Clear() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cleared := len(s.entries)
+	s.entries = make([]*DeadLetterEntry[T], 0)
+	return cleared
+}
+
+// Stats returns the current dead letter queue statistics
+func (s *deadLetterStore[T]) Stats() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]any{
+		"size":         len(s.entries),
+		"max_size":     s.maxSize,
+		"max_attempts": s.maxAttempts,
+		"recovered":    s.recovered,
+		"dropped":      s.dropped,
+	}
+}
+
+// DeadLetterQueue keeps audit logs that failed to flush so they can be retried later
+type DeadLetterQueue struct {
+	*deadLetterStore[types.AuditLog]
+	insert auditInsertFunc
+}
+
+// NewDeadLetterQueue creates a dead letter queue that holds at most maxSize entries
+// and gives up on an entry after maxAttempts retries
+func NewDeadLetterQueue(maxSize, maxAttempts int, logger *config.Logger) *DeadLetterQueue {
+	describe := func(log types.AuditLog) []any {
+		return []any{"message", log.Message}
+	}
+	return &DeadLetterQueue{
+		deadLetterStore: newDeadLetterStore("audit log", maxSize, maxAttempts, describe, logger),
+		insert:          newAuditInserter(nil),
+	}
+}
+
+// AddFailedBatch adds every entry of a failed batch to the queue.
+// When the queue is full the oldest entries are dropped to make room.
+func (dlq *DeadLetterQueue) AddFailedBatch(entries []types.AuditLog, err error) {
+	dlq.add(entries, err)
+}
+
+// RetryFailedLogs re-attempts the database insert for every queued audit log once.
+// Entries that succeed or exceed the maximum attempts are removed from the queue.
+// Returns the number of recovered entries and stops early if the context is cancelled.
+func (dlq *DeadLetterQueue) RetryFailedLogs(ctx context.Context) (int, error) {
+	return dlq.retry(ctx, func(ctx context.Context, log types.AuditLog) error {
+		_, err := dlq.insert(ctx, []types.AuditLog{log})
+		return err
+	})
+}
+
+// auditLogConn returns the connection audit and health logs are written to: db when it is set,
+// otherwise the dedicated audit database, or nil for the primary database when none is configured
+func auditLogConn(db *database.DB) *pg.DB {
+	if db == nil {
+		db = database.GetAuditInstance()
+	}
+	return db.Conn()
+}
+
+// newAuditInserter returns an auditInsertFunc writing to db, see auditLogConn
+func newAuditInserter(db *database.DB) auditInsertFunc {
+	return func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+		query := auditLogsInsertQuery(db, entries)
+		if query == nil {
+			return 0, nil // Nothing to flush
+		}
+
+		result, err := database.ExecuteQuery[types.AuditLog](query.SetContext(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("database insert failed: %w", err)
+		}
+
+		// Return the actual number of rows inserted (may be less than the entries due to duplicates)
+		return result.Count, nil
+	}
+}
+
+// auditLogsInsertQuery builds the insert of audit log entries into the audit_logs table on db.
+// Entries without a message are skipped, as are entries whose entry_hash is already stored.
+// Returns nil when no entry is left to insert.
+func auditLogsInsertQuery(db *database.DB, entries []types.AuditLog) *types.QueryParams {
+	// Convert AuditLog entries to the format expected by SetEntries
+	auditEntries := make([]any, 0, len(entries))
+
+	for _, entry := range entries {
+		// Validate entry before adding
+		if entry.Message == "" {
+			continue // Skip invalid entries
+		}
+
+		auditEntry := map[string]any{
+			"timestamp":  entry.Timestamp,
+			"level":      entry.Level,
+			"message":    entry.Message,
+			"attrs":      entry.Attrs,
+			"entry_hash": entry.EntryHash,
+			"source":     entry.Source,
+			"request_id": entry.RequestID,
+		}
+
+		auditEntries = append(auditEntries, auditEntry)
+	}
+
+	if len(auditEntries) == 0 {
+		return nil
+	}
+
+	return services.Query().
+		SetOperation("insert").
+		SetTable("audit_logs").
+		SetEntries(auditEntries).
+		SetOnConflict("(entry_hash) WHERE entry_hash IS NOT NULL DO NOTHING").
+		WithDB(auditLogConn(db))
+}
@@ -0,0 +1,189 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/types"
+)
+
+// newDiscardLogger creates a logger that does not depend on the loaded configuration
+func newDiscardLogger() *config.Logger {
+	return &config.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+func TestDeadLetterQueueRetriesTransientFailure(t *testing.T) {
+	dlq := NewDeadLetterQueue(100, 3, newDiscardLogger())
+
+	calls := 0
+	var inserted []types.AuditLog
+	dlq.insert = func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+		calls++
+		// The first retry hits a transient database error, the next one succeeds
+		if calls == 1 {
+			return 0, errors.New("connection reset by peer")
+		}
+		inserted = append(inserted, entries...)
+		return int64(len(entries)), nil
+	}
+
+	entry := types.AuditLog{Timestamp: time.Now(), Level: "ERROR", Message: "dlq retry test"}
+	dlq.AddFailedBatch([]types.AuditLog{entry}, errors.New("database unavailable"))
+
+	recovered, err := dlq.RetryFailedLogs(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error on first retry: %v", err)
+	}
+	if recovered != 0 {
+		t.Errorf("Expected no recovered entries after transient failure, got %d", recovered)
+	}
+	if dlq.Size() != 1 {
+		t.Fatalf("Expected entry to stay queued after transient failure, queue size %d", dlq.Size())
+	}
+
+	recovered, err = dlq.RetryFailedLogs(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error on second retry: %v", err)
+	}
+	if recovered != 1 {
+		t.Errorf("Expected 1 recovered entry, got %d", recovered)
+	}
+	if dlq.Size() != 0 {
+		t.Errorf("Expected queue to be drained, queue size %d", dlq.Size())
+	}
+	if len(inserted) != 1 || inserted[0].Message != entry.Message {
+		t.Errorf("Expected original log to be inserted, got %+v", inserted)
+	}
+}
+
+func TestDeadLetterQueueDropsAfterMaxAttempts(t *testing.T) {
+	dlq := NewDeadLetterQueue(100, 2, newDiscardLogger())
+	dlq.insert = func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+		return 0, errors.New("permanent failure")
+	}
+
+	dlq.AddFailedBatch([]types.AuditLog{{Message: "always fails"}}, errors.New("insert failed"))
+
+	for range 2 {
+		if _, err := dlq.RetryFailedLogs(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if dlq.Size() != 0 {
+		t.Errorf("Expected entry to be dropped after max attempts, queue size %d", dlq.Size())
+	}
+	if dropped := dlq.Stats()["dropped"].(int64); dropped != 1 {
+		t.Errorf("Expected 1 dropped entry, got %d", dropped)
+	}
+}
+
+func TestDeadLetterQueueRespectsCancellation(t *testing.T) {
+	dlq := NewDeadLetterQueue(100, 3, newDiscardLogger())
+	dlq.insert = func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+		t.Error("Insert should not be attempted with a cancelled context")
+		return 0, nil
+	}
+
+	dlq.AddFailedBatch([]types.AuditLog{{Message: "first"}, {Message: "second"}}, errors.New("insert failed"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := dlq.RetryFailedLogs(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if dlq.Size() != 2 {
+		t.Errorf("Expected unattempted entries to stay queued, queue size %d", dlq.Size())
+	}
+}
+
+func TestWorkerManagerAuditDeadLetterAccessors(t *testing.T) {
+	logger := newDiscardLogger()
+	wm := &WorkerManager{logger: logger, dlq: NewDeadLetterQueue(100, 3, logger)}
+	wm.dlq.insert = func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+		return 0, errors.New("database still down")
+	}
+
+	wm.dlq.AddFailedBatch([]types.AuditLog{{Message: "first"}, {Message: "second"}}, errors.New("insert failed"))
+
+	entries := wm.AuditDeadLetterEntries()
+	if len(entries) != 2 || entries[0].Item.Message != "first" || entries[0].LastError != "insert failed" {
+		t.Fatalf("Expected both queued entries oldest first, got %+v", entries)
+	}
+
+	recovered, err := wm.RetryAuditDeadLetters(context.Background())
+	if err != nil || recovered != 0 {
+		t.Fatalf("Expected no recovered entries while the database is down, got %d (%v)", recovered, err)
+	}
+	if attempts := wm.AuditDeadLetterEntries()[0].Attempts; attempts != 1 {
+		t.Errorf("Expected the retry to be recorded on the entry, got %d attempts", attempts)
+	}
+
+	cleared, err := wm.ClearAuditDeadLetters()
+	if err != nil || cleared != 2 {
+		t.Fatalf("Expected 2 cleared entries, got %d (%v)", cleared, err)
+	}
+	if size := wm.AuditDeadLetterStats()["size"]; size != 0 {
+		t.Errorf("Expected an empty queue after clearing, got size %v", size)
+	}
+
+	// A manager built without a queue reports it as unavailable instead of panicking
+	empty := &WorkerManager{logger: logger}
+	if empty.AuditDeadLetterStats() != nil {
+		t.Error("Expected no stats without a dead letter queue")
+	}
+	if _, err := empty.RetryAuditDeadLetters(context.Background()); err == nil {
+		t.Error("Expected an error retrying without a dead letter queue")
+	}
+}
+
+func TestLogWritesUseDedicatedAuditDatabase(t *testing.T) {
+	cfg := &config.Config{
+		AuditDatabase: types.AuditDatabaseConfig{Host: "127.0.0.1", Port: 1, User: "audit", Name: "audit", MaxConns: 1},
+	}
+	// The pool connects lazily, so nothing has to listen on the address
+	auditDB := database.OpenAudit(cfg)
+	if auditDB == nil {
+		t.Fatal("Expected a dedicated audit database when AUDIT_DB_HOST is set")
+	}
+	defer auditDB.Close()
+
+	wm := &WorkerManager{cfg: cfg, logger: newDiscardLogger(), auditDB: auditDB}
+	entries := []types.AuditLog{{Level: "ERROR", Message: "stored in the audit database"}}
+
+	aw := wm.newAuditWorker()
+	if query := auditLogsInsertQuery(aw.db, entries); query == nil || query.DB != auditDB.DB {
+		t.Error("Expected the audit worker flush to use the dedicated audit database")
+	}
+	if conn := auditLogConn(wm.newHealthWorker().db); conn != auditDB.DB {
+		t.Error("Expected the health worker flush to use the dedicated audit database")
+	}
+	if conn := auditLogConn(wm.newCleanupWorker().db); conn != auditDB.DB {
+		t.Error("Expected the audit log cleanup to use the dedicated audit database")
+	}
+}
+
+func TestLogWritesFallBackToPrimaryDatabase(t *testing.T) {
+	cfg := &config.Config{}
+	if auditDB := database.OpenAudit(cfg); auditDB != nil {
+		t.Fatal("Expected no dedicated audit database without AUDIT_DB_HOST")
+	}
+
+	wm := &WorkerManager{cfg: cfg, logger: newDiscardLogger()}
+	entries := []types.AuditLog{{Level: "ERROR", Message: "stored in the primary database"}}
+
+	// A nil connection makes ExecuteQuery use the primary database
+	if query := auditLogsInsertQuery(wm.newAuditWorker().db, entries); query == nil || query.DB != nil {
+		t.Error("Expected the audit worker flush to use the primary database")
+	}
+	if query := auditLogsInsertQuery(nil, []types.AuditLog{{Level: "ERROR"}}); query != nil {
+		t.Error("Expected no query when every entry is skipped")
+	}
+}
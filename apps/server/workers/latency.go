@@ -0,0 +1,62 @@
+package workers
+
+import (
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// latencyReservoirSize caps the number of latency samples kept per service.
+// Memory stays constant regardless of request volume while percentiles remain
+// accurate to within a few percent for typical reporting windows.
+const latencyReservoirSize = 1024
+
+// latencyReservoir keeps a uniform random sample of observed latencies (Algorithm R).
+// It is not safe for concurrent use; callers hold the owning RouteService mutex.
+type latencyReservoir struct {
+	samples []time.Duration
+	seen    int64
+}
+
+// newLatencyReservoir creates an empty reservoir holding at most size samples
+func newLatencyReservoir(size int) *latencyReservoir {
+	return &latencyReservoir{samples: make([]time.Duration, 0, size)}
+}
+
+// Add records a latency, replacing a random sample once the reservoir is full
+func (lr *latencyReservoir) Add(latency time.Duration) {
+	lr.seen++
+	if len(lr.samples) < cap(lr.samples) {
+		lr.samples = append(lr.samples, latency)
+		return
+	}
+
+	if i := rand.Int64N(lr.seen); i < int64(len(lr.samples)) {
+		lr.samples[i] = latency
+	}
+}
+
+// Percentiles returns the requested percentiles (0-100) using the nearest-rank method.
+// All values are zero when no latencies have been recorded.
+func (lr *latencyReservoir) Percentiles(percentiles ...float64) []time.Duration {
+	results := make([]time.Duration, len(percentiles))
+	if len(lr.samples) == 0 {
+		return results
+	}
+
+	sorted := slices.Clone(lr.samples)
+	slices.Sort(sorted)
+
+	for i, p := range percentiles {
+		rank := int(p/100*float64(len(sorted))+0.5) - 1
+		rank = max(0, min(rank, len(sorted)-1))
+		results[i] = sorted[rank]
+	}
+	return results
+}
+
+// Reset discards all samples so the next window starts fresh
+func (lr *latencyReservoir) Reset() {
+	lr.samples = lr.samples[:0]
+	lr.seen = 0
+}
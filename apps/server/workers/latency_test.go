@@ -0,0 +1,87 @@
+package workers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyReservoirPercentiles(t *testing.T) {
+	lr := newLatencyReservoir(latencyReservoirSize)
+	for i := 1; i <= 100; i++ {
+		lr.Add(time.Duration(i) * time.Millisecond)
+	}
+
+	got := lr.Percentiles(50, 95, 99)
+	want := []time.Duration{50 * time.Millisecond, 95 * time.Millisecond, 99 * time.Millisecond}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Percentile %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestLatencyReservoirEmpty(t *testing.T) {
+	lr := newLatencyReservoir(latencyReservoirSize)
+	for _, p := range lr.Percentiles(50, 99) {
+		if p != 0 {
+			t.Errorf("Expected zero percentile for an empty reservoir, got %s", p)
+		}
+	}
+}
+
+func TestLatencyReservoirStaysBounded(t *testing.T) {
+	lr := newLatencyReservoir(64)
+	for i := 0; i < 100000; i++ {
+		lr.Add(time.Millisecond)
+	}
+	// A tail spike in a sample of uniform latencies
+	for i := 0; i < 5000; i++ {
+		lr.Add(time.Second)
+	}
+
+	if len(lr.samples) != 64 || cap(lr.samples) != 64 {
+		t.Fatalf("Expected 64 samples with capacity 64, got %d/%d", len(lr.samples), cap(lr.samples))
+	}
+	if lr.seen != 105000 {
+		t.Errorf("Expected 105000 seen latencies, got %d", lr.seen)
+	}
+
+	lr.Reset()
+	if len(lr.samples) != 0 || lr.seen != 0 {
+		t.Errorf("Expected reset reservoir to be empty, got %d samples and %d seen", len(lr.samples), lr.seen)
+	}
+}
+
+func TestCreateHealthLogIncludesPercentiles(t *testing.T) {
+	hw := &HealthWorker{logger: newDiscardLogger()}
+	service := &RouteService{
+		Name:      "auth",
+		StartTime: time.Now(),
+		latencies: newLatencyReservoir(latencyReservoirSize),
+	}
+
+	for i := 1; i <= 100; i++ {
+		latency := time.Duration(i) * time.Millisecond
+		service.RequestCount++
+		service.TotalLatency += latency
+		service.latencies.Add(latency)
+	}
+
+	log := hw.createHealthLog("auth", service)
+	if log.AverageLatency != 50500*time.Microsecond {
+		t.Errorf("Expected average latency 50.5ms, got %s", log.AverageLatency)
+	}
+	if log.P50Latency != 50*time.Millisecond || log.P95Latency != 95*time.Millisecond || log.P99Latency != 99*time.Millisecond {
+		t.Errorf("Unexpected percentiles p50=%s p95=%s p99=%s", log.P50Latency, log.P95Latency, log.P99Latency)
+	}
+
+	// The next report only covers new requests
+	if next := hw.createHealthLog("auth", service); next.P99Latency != 0 {
+		t.Errorf("Expected percentiles to reset after a report, got p99=%s", next.P99Latency)
+	}
+
+	row := hw.convertHealthLogToMap(log)
+	if row["p95_latency"] != 95.0 {
+		t.Errorf("Expected p95_latency 95.0 in insert map, got %v", row["p95_latency"])
+	}
+}
@@ -0,0 +1,147 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/types"
+)
+
+// newGlobalTestManager creates a manager without database or dependency probes and routes the
+// legacy package functions to it for the duration of the test. Audit batches are passed to insert,
+// nil discards them.
+func newGlobalTestManager(t *testing.T, insert auditInsertFunc) *WorkerManager {
+	t.Helper()
+
+	if insert == nil {
+		insert = func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+			return int64(len(entries)), nil
+		}
+	}
+
+	cfg := createTestConfig()
+	logger := newDiscardLogger()
+	// Built directly since NewWorkerManager needs the global config for the notifier
+	manager := &WorkerManager{
+		cfg:         cfg,
+		logger:      logger,
+		dlq:         NewDeadLetterQueue(cfg.Audit.DLQSize, cfg.Audit.MaxRetries, logger),
+		probes:      func() []*dependencyProbe { return nil },
+		auditInsert: insert,
+	}
+
+	// Mark the singleton as initialized so GetGlobalManager returns the test manager, and reset it
+	// afterwards so later tests build the real global manager on first use again
+	managerOnce.Do(func() {})
+	globalManager = manager
+	t.Cleanup(func() {
+		managerOnce = sync.Once{}
+		globalManager = nil
+	})
+
+	return manager
+}
+
+func TestLegacyAuditFunctionsUseManager(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inserted []types.AuditLog
+	)
+	manager := newGlobalTestManager(t, func(ctx context.Context, entries []types.AuditLog) (int64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		inserted = append(inserted, entries...)
+		return int64(len(entries)), nil
+	})
+
+	if status := AuditHealthStatus(); status["error"] != "audit worker not initialized" {
+		t.Errorf("Expected no audit worker before the legacy start, got %v", status)
+	}
+
+	StartAuditWorker()
+	if manager.audit() == nil {
+		t.Fatal("Expected StartAuditWorker to start the audit worker of the manager")
+	}
+	if status := AuditHealthStatus(); status["worker_running"] != true {
+		t.Errorf("Expected the audit worker to be running, got %v", status)
+	}
+	if audit, ok := manager.HealthStatus()["audit"].(map[string]any); !ok || audit["worker_running"] != true {
+		t.Errorf("Expected the manager to report the legacy audit worker, got %v", manager.HealthStatus()["audit"])
+	}
+
+	AddAuditLog(types.AuditLog{Level: "INFO", Message: "legacy entry"})
+
+	// Stopping flushes the queued entry through the manager's inserter
+	StopAuditWorker()
+	if manager.audit() != nil {
+		t.Error("Expected StopAuditWorker to detach the audit worker")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(inserted) != 1 || inserted[0].Message != "legacy entry" {
+		t.Errorf("Expected the legacy entry to be written, got %v", inserted)
+	}
+}
+
+func TestLegacyHealthFunctionsUseManager(t *testing.T) {
+	manager := newGlobalTestManager(t, nil)
+
+	if status := ServiceHealthStatus(); status["worker_running"] != false {
+		t.Errorf("Expected no health worker before the legacy start, got %v", status)
+	}
+	if _, err := GetServiceStats("users"); err == nil {
+		t.Error("Expected GetServiceStats to fail without a health worker")
+	}
+
+	StartHealthLogWorker()
+	t.Cleanup(StopHealthLogWorker)
+
+	hw := manager.health()
+	if hw == nil {
+		t.Fatal("Expected StartHealthLogWorker to start the health worker of the manager")
+	}
+	if status := ServiceHealthStatus(); status["worker_running"] != true {
+		t.Errorf("Expected the health worker to be running, got %v", status)
+	}
+
+	hw.RegisterService("users")
+	manager.RecordHealthMetric("users", 200, 10*time.Millisecond)
+	stats, err := GetServiceStats("users")
+	if err != nil || stats == nil {
+		t.Fatalf("Expected stats for the registered service, got %v, %v", stats, err)
+	}
+	if services := GetAllServices(); len(services) != 1 || services[0] != "users" {
+		t.Errorf("Expected the registered service to be listed, got %v", services)
+	}
+}
+
+func TestLegacyCleanupFunctionsUseManager(t *testing.T) {
+	manager := newGlobalTestManager(t, nil)
+
+	if err := TriggerCleanupNow(); err == nil {
+		t.Error("Expected TriggerCleanupNow to fail without a cleanup worker")
+	}
+	if err := CleanupOldAuditLogs(); err == nil {
+		t.Error("Expected CleanupOldAuditLogs to fail without a cleanup worker")
+	}
+
+	StartCleanupScheduler()
+	if manager.cleanup() == nil {
+		t.Fatal("Expected StartCleanupScheduler to start the cleanup worker of the manager")
+	}
+
+	// Starting again keeps the running worker
+	worker := manager.cleanup()
+	StartCleanupScheduler()
+	if manager.cleanup() != worker {
+		t.Error("Expected a second StartCleanupScheduler to keep the running cleanup worker")
+	}
+
+	StopAuditCleanupScheduler()
+	if manager.cleanup() != nil {
+		t.Error("Expected StopAuditCleanupScheduler to detach the cleanup worker")
+	}
+}
@@ -2,11 +2,14 @@ package workers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/services"
 	"github.com/MonkyMars/PWS/types"
 	"github.com/gofiber/fiber/v3"
 )
@@ -16,10 +19,26 @@ type WorkerManager struct {
 	auditWorker   *AuditWorker
 	healthWorker  *HealthWorker
 	cleanupWorker *CleanupWorker
+	dlq           *DeadLetterQueue
 	logger        *config.Logger
 	cfg           *config.Config
 	mu            sync.RWMutex
 	running       bool
+
+	// auditDB receives the audit and health logs, nil uses the dedicated audit database
+	// when one is configured and the primary database otherwise
+	auditDB *database.DB
+
+	// probes creates the dependency probes of the health worker, nil uses defaultDependencyProbes
+	probes func() []*dependencyProbe
+	// auditInsert writes the batches of the audit worker, nil writes them to auditDB
+	auditInsert auditInsertFunc
+
+	notificationWorker *NotificationWorker
+	notificationDLQ    *NotificationDeadLetterQueue
+
+	webhookWorker *WebhookWorker
+	webhookDLQ    *WebhookDeadLetterQueue
 }
 
 // AuditWorker handles audit log processing
@@ -33,7 +52,9 @@ type AuditWorker struct {
 	stats     AuditStats
 	logger    *config.Logger
 	cfg       *config.Config
-	// dlq       *DeadLetterQueue
+	dlq       *DeadLetterQueue
+	db        *database.DB
+	insert    auditInsertFunc
 }
 
 // HealthWorker handles health monitoring
@@ -43,11 +64,14 @@ type HealthWorker struct {
 	wg            sync.WaitGroup
 	healthChan    chan types.HealthLog
 	services      map[string]*RouteService
+	routes        map[string]*RouteStats
 	running       bool
 	mu            sync.RWMutex
 	lastFlushTime time.Time
 	logger        *config.Logger
 	cfg           *config.Config
+	db            *database.DB
+	probes        []*dependencyProbe
 }
 
 // CleanupWorker handles periodic cleanup tasks
@@ -59,16 +83,57 @@ type CleanupWorker struct {
 	mu      sync.RWMutex
 	logger  *config.Logger
 	cfg     *config.Config
+	dlq     *DeadLetterQueue
+	db      *database.DB
+}
+
+// NotificationWorker periodically retries failed notifications
+type NotificationWorker struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running bool
+	mu      sync.RWMutex
+	logger  *config.Logger
+	cfg     *config.Config
+	dlq     *NotificationDeadLetterQueue
+}
+
+// WebhookWorker delivers webhook events to their subscribers and retries failed deliveries
+type WebhookWorker struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	eventChan   chan types.WebhookEvent
+	running     bool
+	mu          sync.RWMutex
+	stats       WebhookStats
+	logger      *config.Logger
+	cfg         *config.Config
+	dlq         *WebhookDeadLetterQueue
+	sender      services.WebhookSender
+	subscribers webhookSubscribersFunc
+	deliveries  chan struct{} // one slot per delivery in flight, sized by WEBHOOK_MAX_CONCURRENCY
 }
 
 // AuditStats tracks audit worker statistics
 type AuditStats struct {
 	TotalProcessed int64
 	TotalDropped   int64
+	TotalDeduped   int64 // entries skipped because their entry_hash was already stored
 	FailureCount   int
 	LastFlushTime  time.Time
 }
 
+// WebhookStats tracks webhook worker statistics
+type WebhookStats struct {
+	TotalEvents      int64
+	TotalDelivered   int64
+	TotalFailed      int64 // deliveries handed to the dead letter queue after exhausting their attempts
+	TotalDropped     int64 // events dropped because the queue was full
+	LastDeliveryTime time.Time
+}
+
 // Global manager instance (maintained for backward compatibility)
 var (
 	globalManager *WorkerManager
@@ -80,9 +145,26 @@ func NewWorkerManager(cfg *config.Config, logger *config.Logger) *WorkerManager
 	return &WorkerManager{
 		cfg:    cfg,
 		logger: logger,
+		dlq:    NewDeadLetterQueue(cfg.Audit.DLQSize, cfg.Audit.MaxRetries, logger),
+
+		notificationDLQ: newNotificationDeadLetterQueue(cfg, logger),
+		webhookDLQ: NewWebhookDeadLetterQueue(cfg.Webhook.DLQSize, cfg.Webhook.DLQMaxRetries,
+			services.NewWebhookDeliverer(cfg.Webhook.Timeout), logger),
 	}
 }
 
+// newNotificationDeadLetterQueue creates the notification dead letter queue, kept in Redis
+// when NOTIFICATION_DLQ_PERSISTENT is set
+func newNotificationDeadLetterQueue(cfg *config.Config, logger *config.Logger) *NotificationDeadLetterQueue {
+	notifier := services.NewNotifier(cfg, logger)
+	if !cfg.Notification.DLQPersistent {
+		return NewNotificationDeadLetterQueue(cfg.Notification.DLQSize, cfg.Notification.MaxRetries, notifier, logger)
+	}
+
+	store := NewRedisNotificationStore(services.GetRedisClient(), NotificationQueueKey, cfg.Notification.DLQSize, logger)
+	return NewNotificationDeadLetterQueueWithStore(cfg.Notification.DLQSize, cfg.Notification.MaxRetries, notifier, store, logger)
+}
+
 // GetGlobalManager returns the global manager instance (for backward compatibility)
 func GetGlobalManager() *WorkerManager {
 	managerOnce.Do(func() {
@@ -128,74 +210,116 @@ func (wm *WorkerManager) Start() error {
 		return fmt.Errorf("worker manager already running")
 	}
 
-	// Initialize workers
-	wm.auditWorker = wm.newAuditWorker()
-	wm.healthWorker = wm.newHealthWorker()
-	wm.cleanupWorker = wm.newCleanupWorker()
+	// Workers already started through the legacy package functions are kept,
+	// the others are created and started here
+	startAudit := wm.auditWorker == nil
+	if startAudit {
+		wm.auditWorker = wm.newAuditWorker()
+	}
+	startHealth := wm.healthWorker == nil
+	if startHealth {
+		wm.healthWorker = wm.newHealthWorker()
+	}
+	startCleanup := wm.cleanupWorker == nil
+	if startCleanup {
+		wm.cleanupWorker = wm.newCleanupWorker()
+	}
+	wm.notificationWorker = wm.newNotificationWorker()
+	wm.webhookWorker = wm.newWebhookWorker()
 
 	// Start workers in dependency order
-	if wm.cfg.Audit.Enabled {
+	if wm.cfg.Audit.Enabled && startAudit {
 		if err := wm.auditWorker.Start(); err != nil {
 			return fmt.Errorf("failed to start audit worker: %w", err)
 		}
 		wm.logger.Info("Audit worker started")
 	}
 
-	if wm.cfg.Health.Enabled {
+	if wm.cfg.Health.Enabled && startHealth {
 		if err := wm.healthWorker.Start(); err != nil {
 			return fmt.Errorf("failed to start health worker: %w", err)
 		}
 		wm.logger.Info("Health worker started")
 	}
 
-	if wm.cfg.Audit.Enabled && wm.cfg.Audit.RetentionDays > 0 {
+	if wm.cfg.Audit.Enabled && wm.cfg.Audit.RetentionDays > 0 && startCleanup {
 		if err := wm.cleanupWorker.Start(); err != nil {
 			return fmt.Errorf("failed to start cleanup worker: %w", err)
 		}
 		wm.logger.Info("Cleanup worker started")
 	}
 
+	if wm.cfg.Notification.RetryEnabled {
+		// Failed notifications are queued here and retried by the notification worker
+		services.RegisterNotificationFailureHandler(wm.notificationDLQ.AddFailedNotification)
+		if err := wm.notificationWorker.Start(); err != nil {
+			return fmt.Errorf("failed to start notification worker: %w", err)
+		}
+		wm.logger.Info("Notification worker started")
+	}
+
+	if wm.cfg.Webhook.Enabled {
+		if err := wm.webhookWorker.Start(); err != nil {
+			return fmt.Errorf("failed to start webhook worker: %w", err)
+		}
+		// Published events are queued here and delivered by the webhook worker
+		services.RegisterWebhookEventHandler(wm.webhookWorker.AddEvent)
+		wm.logger.Info("Webhook worker started")
+	}
+
 	wm.running = true
 	wm.logger.Info("Worker manager started successfully")
 	return nil
 }
 
-// Stop gracefully shuts down all workers
+// Stop gracefully shuts down all workers, including those started through the legacy package functions.
+// The workers are detached under the lock and stopped after releasing it, so concurrent calls never stop
+// a worker twice and workers that log while draining cannot block on the manager.
 func (wm *WorkerManager) Stop(ctx context.Context) error {
 	wm.mu.Lock()
-	defer wm.mu.Unlock()
+	workers := map[string]managedWorker{}
+	if wm.auditWorker != nil {
+		workers["audit"] = wm.auditWorker
+	}
+	if wm.healthWorker != nil {
+		workers["health"] = wm.healthWorker
+	}
+	if wm.cleanupWorker != nil {
+		workers["cleanup"] = wm.cleanupWorker
+	}
+	if wm.notificationWorker != nil {
+		// Stop queueing failed notifications that nothing would retry anymore
+		services.RegisterNotificationFailureHandler(nil)
+		workers["notification"] = wm.notificationWorker
+	}
+	if wm.webhookWorker != nil {
+		// Stop publishing to the worker before it drains its queue
+		services.RegisterWebhookEventHandler(nil)
+		workers["webhook"] = wm.webhookWorker
+	}
+	wm.auditWorker = nil
+	wm.healthWorker = nil
+	wm.cleanupWorker = nil
+	wm.notificationWorker = nil
+	wm.webhookWorker = nil
+	wm.running = false
+	wm.mu.Unlock()
 
-	if !wm.running {
+	if len(workers) == 0 {
 		return nil
 	}
 
 	wm.logger.Info("Stopping worker manager...")
 
 	// Create a channel to collect errors
-	errChan := make(chan error, 3)
+	errChan := make(chan error, len(workers))
 	var wg sync.WaitGroup
 
 	// Stop workers concurrently with timeout
-	if wm.auditWorker != nil {
-		wg.Go(func() {
-			if err := wm.auditWorker.Stop(ctx); err != nil {
-				errChan <- fmt.Errorf("audit worker stop error: %w", err)
-			}
-		})
-	}
-
-	if wm.healthWorker != nil {
-		wg.Go(func() {
-			if err := wm.healthWorker.Stop(ctx); err != nil {
-				errChan <- fmt.Errorf("health worker stop error: %w", err)
-			}
-		})
-	}
-
-	if wm.cleanupWorker != nil {
+	for name, worker := range workers {
 		wg.Go(func() {
-			if err := wm.cleanupWorker.Stop(ctx); err != nil {
-				errChan <- fmt.Errorf("cleanup worker stop error: %w", err)
+			if err := worker.Stop(ctx); err != nil {
+				errChan <- fmt.Errorf("%s worker stop error: %w", name, err)
 			}
 		})
 	}
@@ -215,6 +339,19 @@ func (wm *WorkerManager) Stop(ctx context.Context) error {
 		return ctx.Err()
 	}
 
+	// The audit and webhook dead letter queues are in memory only, nothing retries what they hold after this.
+	// Notifications are moved to Redis when the queue is persistent, only the ones it cannot store are lost.
+	if wm.dlq != nil {
+		wm.dlq.warnUnpersisted()
+	}
+	if wm.notificationDLQ != nil {
+		wm.notificationDLQ.flush()
+		wm.notificationDLQ.warnUnpersisted()
+	}
+	if wm.webhookDLQ != nil {
+		wm.webhookDLQ.warnUnpersisted()
+	}
+
 	// Collect any errors
 	close(errChan)
 	var errors []error
@@ -222,8 +359,6 @@ func (wm *WorkerManager) Stop(ctx context.Context) error {
 		errors = append(errors, err)
 	}
 
-	wm.running = false
-
 	if len(errors) > 0 {
 		return fmt.Errorf("worker shutdown errors: %v", errors)
 	}
@@ -231,24 +366,58 @@ func (wm *WorkerManager) Stop(ctx context.Context) error {
 	return nil
 }
 
+// managedWorker is a background worker the manager starts and stops
+type managedWorker interface {
+	Start() error
+	Stop(ctx context.Context) error
+}
+
+// audit, health and cleanup return the current workers. Start, Stop and the legacy package functions
+// replace them under the lock, so they must not be read from the fields without holding it.
+func (wm *WorkerManager) audit() *AuditWorker {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+	return wm.auditWorker
+}
+
+func (wm *WorkerManager) health() *HealthWorker {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+	return wm.healthWorker
+}
+
+func (wm *WorkerManager) cleanup() *CleanupWorker {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+	return wm.cleanupWorker
+}
+
 // DiscoverRoutes auto-discovers routes for health monitoring
 func (wm *WorkerManager) DiscoverRoutes(app *fiber.App) {
-	if wm.healthWorker != nil {
-		wm.healthWorker.DiscoverRoutes(app)
+	if hw := wm.health(); hw != nil {
+		hw.DiscoverRoutes(app)
 	}
 }
 
 // AddAuditLog adds an audit log entry (backward compatibility)
 func (wm *WorkerManager) AddAuditLog(entry types.AuditLog) {
-	if wm.auditWorker != nil {
-		wm.auditWorker.AddLog(entry)
+	if aw := wm.audit(); aw != nil {
+		aw.AddLog(entry)
 	}
 }
 
 // RecordHealthMetric records a health metric (backward compatibility)
 func (wm *WorkerManager) RecordHealthMetric(serviceName string, statusCode int, latency time.Duration) {
-	if wm.healthWorker != nil {
-		wm.healthWorker.RecordRequest(serviceName, statusCode, latency)
+	if hw := wm.health(); hw != nil {
+		hw.RecordRequest(serviceName, statusCode, latency)
+	}
+}
+
+// RecordRouteMetric records a request for a route, identified by its method and path template,
+// and for the service the route was discovered under
+func (wm *WorkerManager) RecordRouteMetric(method, path string, statusCode int, latency time.Duration) {
+	if hw := wm.health(); hw != nil {
+		hw.RecordRouteRequest(method, path, statusCode, latency)
 	}
 }
 
@@ -310,6 +479,26 @@ func (wm *WorkerManager) HealthStatus() map[string]any {
 		}
 	}
 
+	if wm.notificationWorker != nil {
+		status["notifications"] = wm.notificationWorker.HealthStatus()
+	} else {
+		status["notifications"] = map[string]any{
+			"enabled":        false,
+			"worker_running": false,
+			"is_healthy":     false,
+		}
+	}
+
+	if wm.webhookWorker != nil {
+		status["webhooks"] = wm.webhookWorker.HealthStatus()
+	} else {
+		status["webhooks"] = map[string]any{
+			"enabled":        false,
+			"worker_running": false,
+			"is_healthy":     false,
+		}
+	}
+
 	// Overall health calculation
 	isHealthy := wm.running
 	if wm.cfg != nil && wm.cfg.Audit.Enabled && wm.auditWorker != nil {
@@ -344,14 +533,55 @@ func (wm *WorkerManager) HealthStatus() map[string]any {
 
 // TriggerCleanup manually triggers cleanup operations
 func (wm *WorkerManager) TriggerCleanup() error {
-	if wm.cleanupWorker != nil {
-		return wm.cleanupWorker.TriggerCleanup()
+	if cw := wm.cleanup(); cw != nil {
+		return cw.TriggerCleanup()
 	}
 	return fmt.Errorf("cleanup worker not available")
 }
 
+// errAuditDeadLetterUnavailable is returned when the manager was created without an audit dead letter queue
+var errAuditDeadLetterUnavailable = errors.New("audit dead letter queue not available")
+
+// AuditDeadLetterStats returns the statistics of the audit log dead letter queue
+func (wm *WorkerManager) AuditDeadLetterStats() map[string]any {
+	if wm.dlq == nil {
+		return nil
+	}
+	return wm.dlq.Stats()
+}
+
+// AuditDeadLetterEntries returns the audit logs waiting in the dead letter queue, oldest first
+func (wm *WorkerManager) AuditDeadLetterEntries() []DeadLetterEntry[types.AuditLog] {
+	if wm.dlq == nil {
+		return nil
+	}
+	return wm.dlq.Entries()
+}
+
+// RetryAuditDeadLetters re-attempts every audit log in the dead letter queue once and returns
+// the number of recovered entries. It can run alongside the scheduled retry of the cleanup worker.
+func (wm *WorkerManager) RetryAuditDeadLetters(ctx context.Context) (int, error) {
+	if wm.dlq == nil {
+		return 0, errAuditDeadLetterUnavailable
+	}
+	return wm.dlq.RetryFailedLogs(ctx)
+}
+
+// ClearAuditDeadLetters discards every audit log in the dead letter queue and returns the number of discarded entries
+func (wm *WorkerManager) ClearAuditDeadLetters() (int, error) {
+	if wm.dlq == nil {
+		return 0, errAuditDeadLetterUnavailable
+	}
+	return wm.dlq.Clear(), nil
+}
+
 // Worker factory methods
 func (wm *WorkerManager) newAuditWorker() *AuditWorker {
+	insert := wm.auditInsert
+	if insert == nil {
+		insert = newAuditInserter(wm.auditDB)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &AuditWorker{
 		ctx:       ctx,
@@ -359,6 +589,9 @@ func (wm *WorkerManager) newAuditWorker() *AuditWorker {
 		auditChan: make(chan types.AuditLog, wm.cfg.Audit.ChannelSize),
 		logger:    wm.logger,
 		cfg:       wm.cfg,
+		dlq:       wm.dlq,
+		db:        wm.auditDB,
+		insert:    insert,
 		stats: AuditStats{
 			LastFlushTime: time.Now(),
 		},
@@ -366,15 +599,23 @@ func (wm *WorkerManager) newAuditWorker() *AuditWorker {
 }
 
 func (wm *WorkerManager) newHealthWorker() *HealthWorker {
+	probes := defaultDependencyProbes
+	if wm.probes != nil {
+		probes = wm.probes
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	return &HealthWorker{
 		ctx:           ctx,
 		cancel:        cancel,
 		healthChan:    make(chan types.HealthLog, wm.cfg.Health.ChannelSize),
 		services:      make(map[string]*RouteService),
+		routes:        make(map[string]*RouteStats),
 		logger:        wm.logger,
 		cfg:           wm.cfg,
+		db:            wm.auditDB,
 		lastFlushTime: time.Now(),
+		probes:        probes(),
 	}
 }
 
@@ -385,76 +626,122 @@ func (wm *WorkerManager) newCleanupWorker() *CleanupWorker {
 		cancel: cancel,
 		logger: wm.logger,
 		cfg:    wm.cfg,
+		dlq:    wm.dlq,
+		db:     wm.auditDB,
+	}
+}
+
+func (wm *WorkerManager) newNotificationWorker() *NotificationWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &NotificationWorker{
+		ctx:    ctx,
+		cancel: cancel,
+		logger: wm.logger,
+		cfg:    wm.cfg,
+		dlq:    wm.notificationDLQ,
 	}
 }
 
-// Backward compatibility functions
+func (wm *WorkerManager) newWebhookWorker() *WebhookWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookWorker{
+		ctx:         ctx,
+		cancel:      cancel,
+		eventChan:   make(chan types.WebhookEvent, wm.cfg.Webhook.ChannelSize),
+		logger:      wm.logger,
+		cfg:         wm.cfg,
+		dlq:         wm.webhookDLQ,
+		sender:      services.NewWebhookDeliverer(wm.cfg.Webhook.Timeout),
+		subscribers: services.GetWebhookSubscribers,
+		deliveries:  make(chan struct{}, max(wm.cfg.Webhook.MaxConcurrency, 1)),
+	}
+}
+
+// legacyStopTimeout bounds how long the legacy stop functions wait for a worker to drain
+const legacyStopTimeout = 30 * time.Second
+
+// Backward compatibility functions. They start and stop single workers of the global manager
+// under its lock, so they can be mixed with Start and Stop without creating or stopping a worker twice.
 func StartAuditWorker() {
 	manager := GetGlobalManager()
-	if manager.auditWorker == nil && manager.cfg.Audit.Enabled {
-		manager.auditWorker = manager.newAuditWorker()
-		err := manager.auditWorker.Start()
-		if err != nil {
-			manager.logger.AuditError("Failed to start audit worker", err)
-		}
+	if manager.cfg == nil || !manager.cfg.Audit.Enabled {
+		return
 	}
+	startLegacyWorker(manager, "audit", &manager.auditWorker, manager.newAuditWorker)
 }
 
 func StopAuditWorker() {
 	manager := GetGlobalManager()
-	if manager.auditWorker != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		err := manager.auditWorker.Stop(ctx)
-		if err != nil {
-			manager.logger.AuditError("Failed to stop audit worker", err)
-		}
-	}
+	stopLegacyWorker(manager, "audit", &manager.auditWorker)
 }
 
 func StartHealthLogWorker() {
 	manager := GetGlobalManager()
-	if manager.healthWorker == nil && manager.cfg.Health.Enabled {
-		manager.healthWorker = manager.newHealthWorker()
-		err := manager.healthWorker.Start()
-		if err != nil {
-			manager.logger.AuditError("Failed to start health worker", err)
-		}
+	if manager.cfg == nil || !manager.cfg.Health.Enabled {
+		return
 	}
+	startLegacyWorker(manager, "health", &manager.healthWorker, manager.newHealthWorker)
 }
 
 func StopHealthLogWorker() {
 	manager := GetGlobalManager()
-	if manager.healthWorker != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		err := manager.healthWorker.Stop(ctx)
-		if err != nil {
-			manager.logger.AuditError("Failed to stop health worker", err)
-		}
-	}
+	stopLegacyWorker(manager, "health", &manager.healthWorker)
 }
 
 func StartCleanupScheduler() {
 	manager := GetGlobalManager()
-	if manager.cleanupWorker == nil && manager.cfg.Audit.Enabled {
-		manager.cleanupWorker = manager.newCleanupWorker()
-		err := manager.cleanupWorker.Start()
-		if err != nil {
-			manager.logger.AuditError("Failed to start cleanup worker", err)
-		}
+	if manager.cfg == nil || !manager.cfg.Audit.Enabled {
+		return
 	}
+	startLegacyWorker(manager, "cleanup", &manager.cleanupWorker, manager.newCleanupWorker)
 }
 
 func StopAuditCleanupScheduler() {
 	manager := GetGlobalManager()
-	if manager.cleanupWorker != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		err := manager.cleanupWorker.Stop(ctx)
-		if err != nil {
-			manager.logger.AuditError("Failed to stop cleanup worker", err)
-		}
+	stopLegacyWorker(manager, "cleanup", &manager.cleanupWorker)
+}
+
+// startLegacyWorker creates and starts the worker in the slot unless the manager already has one
+func startLegacyWorker[W interface {
+	*AuditWorker | *HealthWorker | *CleanupWorker
+	managedWorker
+}](manager *WorkerManager, name string, slot *W, create func() W) {
+	manager.mu.Lock()
+	if *slot != nil {
+		manager.mu.Unlock()
+		return
+	}
+	worker := create()
+	err := worker.Start()
+	if err == nil {
+		*slot = worker
+	}
+	manager.mu.Unlock()
+
+	if err != nil {
+		manager.logger.AuditError("Failed to start "+name+" worker", err)
+	}
+}
+
+// stopLegacyWorker detaches the worker in the slot from the manager and stops it.
+// A later start creates a new worker, since a stopped worker cannot be restarted.
+func stopLegacyWorker[W interface {
+	*AuditWorker | *HealthWorker | *CleanupWorker
+	managedWorker
+}](manager *WorkerManager, name string, slot *W) {
+	manager.mu.Lock()
+	worker := *slot
+	*slot = nil
+	manager.mu.Unlock()
+
+	if worker == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), legacyStopTimeout)
+	defer cancel()
+	if err := worker.Stop(ctx); err != nil {
+		manager.logger.AuditError("Failed to stop "+name+" worker", err)
 	}
 }
 
@@ -485,8 +772,8 @@ func AuditHealthStatus() map[string]any {
 		}
 	}
 
-	if manager.auditWorker != nil {
-		status := manager.auditWorker.HealthStatus()
+	if aw := manager.audit(); aw != nil {
+		status := aw.HealthStatus()
 		if status != nil {
 			return status
 		}
@@ -510,8 +797,8 @@ func ServiceHealthStatus() map[string]any {
 		}
 	}
 
-	if manager.healthWorker != nil {
-		status := manager.healthWorker.HealthStatus()
+	if hw := manager.health(); hw != nil {
+		status := hw.HealthStatus()
 		if status != nil {
 			return status
 		}
@@ -524,12 +811,19 @@ func ServiceHealthStatus() map[string]any {
 	}
 }
 
+var _ WorkerManagerInterface = (*WorkerManager)(nil)
+
 type WorkerManagerInterface interface {
 	Start() error
 	Stop(ctx context.Context) error
 	DiscoverRoutes(app *fiber.App)
 	AddAuditLog(entry types.AuditLog)
 	RecordHealthMetric(serviceName string, statusCode int, latency time.Duration)
+	RecordRouteMetric(method, path string, statusCode int, latency time.Duration)
 	HealthStatus() map[string]any
 	TriggerCleanup() error
+	AuditDeadLetterStats() map[string]any
+	AuditDeadLetterEntries() []DeadLetterEntry[types.AuditLog]
+	RetryAuditDeadLetters(ctx context.Context) (int, error)
+	ClearAuditDeadLetters() (int, error)
 }
@@ -0,0 +1,67 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/types"
+)
+
+func TestWorkerManagerConcurrentLegacyStartStop(t *testing.T) {
+	manager := newGlobalTestManager(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				switch i % 4 {
+				case 0:
+					_ = manager.Start()
+					_ = manager.Stop(ctx)
+				case 1:
+					StartAuditWorker()
+					StopAuditWorker()
+				case 2:
+					StartHealthLogWorker()
+					manager.AddAuditLog(types.AuditLog{Level: "info", Message: "concurrent test"})
+					StopHealthLogWorker()
+				case 3:
+					StartCleanupScheduler()
+					_ = manager.HealthStatus()
+					StopAuditCleanupScheduler()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := manager.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop worker manager: %v", err)
+	}
+
+	if manager.running {
+		t.Error("Manager should not be running after Stop()")
+	}
+	if manager.audit() != nil || manager.health() != nil || manager.cleanup() != nil {
+		t.Error("Expected all workers to be detached after Stop()")
+	}
+
+	// A legacy start after the manager stopped creates a fresh worker, which the manager stops again
+	StartAuditWorker()
+	if manager.audit() == nil {
+		t.Fatal("Expected the legacy start to create an audit worker")
+	}
+	if err := manager.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop the legacy audit worker: %v", err)
+	}
+	if manager.audit() != nil {
+		t.Error("Expected Stop() to detach the legacy audit worker")
+	}
+}
@@ -0,0 +1,112 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// NotificationQueueKey is the Redis list holding the failed notifications of every replica
+const NotificationQueueKey = "dead_letter:notifications"
+
+// NotificationQueueStore keeps failed notifications outside the process, so they survive a restart.
+// Entries are taken out with Pop, which lets several replicas share one queue without retrying
+// the same notification twice.
+type NotificationQueueStore interface {
+	// Push appends entries to the queue and returns how many of the oldest entries were dropped
+	// to stay within the size limit
+	Push(ctx context.Context, entries []*DeadLetterEntry[types.Notification]) (int, error)
+
+	// Pop removes and returns up to count entries, oldest first
+	Pop(ctx context.Context, count int) ([]*DeadLetterEntry[types.Notification], error)
+
+	// Len returns the number of stored entries
+	Len(ctx context.Context) (int, error)
+}
+
+// redisNotificationStore stores the queued notifications as JSON in a Redis list
+type redisNotificationStore struct {
+	client  *redis.Client
+	key     string
+	maxSize int
+	logger  *config.Logger
+}
+
+// NewRedisNotificationStore creates a NotificationQueueStore in the Redis list at key,
+// which holds at most maxSize entries
+func NewRedisNotificationStore(client *redis.Client, key string, maxSize int, logger *config.Logger) NotificationQueueStore {
+	return &redisNotificationStore{
+		client:  client,
+		key:     key,
+		maxSize: maxSize,
+		logger:  logger,
+	}
+}
+
+func (rs *redisNotificationStore) Push(ctx context.Context, entries []*DeadLetterEntry[types.Notification]) (int, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	values := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode notification: %w", err)
+		}
+		values = append(values, data)
+	}
+
+	// Append and trim in one transaction, so the list never holds more than maxSize entries
+	var length *redis.IntCmd
+	_, err := rs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		length = pipe.RPush(ctx, rs.key, values...)
+		if rs.maxSize > 0 {
+			pipe.LTrim(ctx, rs.key, int64(-rs.maxSize), -1)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store notifications: %w", err)
+	}
+
+	if rs.maxSize <= 0 {
+		return 0, nil
+	}
+	return max(int(length.Val())-rs.maxSize, 0), nil
+}
+
+func (rs *redisNotificationStore) Pop(ctx context.Context, count int) ([]*DeadLetterEntry[types.Notification], error) {
+	values, err := rs.client.LPopCount(ctx, rs.key, count).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notifications: %w", err)
+	}
+
+	entries := make([]*DeadLetterEntry[types.Notification], 0, len(values))
+	for _, value := range values {
+		var entry DeadLetterEntry[types.Notification]
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			// An entry that cannot be decoded can never be retried, putting it back would only take up space
+			rs.logger.Error("Discarding undecodable queued notification", "queue", "notification", "error", err)
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+func (rs *redisNotificationStore) Len(ctx context.Context) (int, error) {
+	length, err := rs.client.LLen(ctx, rs.key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return int(length), nil
+}
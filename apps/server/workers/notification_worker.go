@@ -0,0 +1,237 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+)
+
+// notificationStoreTimeout bounds a single call to the NotificationQueueStore
+const notificationStoreTimeout = 5 * time.Second
+
+// NotificationDeadLetterQueue keeps notifications that failed to deliver so they can be retried later
+type NotificationDeadLetterQueue struct {
+	*deadLetterStore[types.Notification]
+	notifier services.Notifier
+
+	// persist keeps the queued notifications across restarts, nil keeps them in memory only.
+	// Notifications only live in memory while a retry round runs or while persist is unreachable.
+	persist NotificationQueueStore
+}
+
+// NewNotificationDeadLetterQueue creates a dead letter queue that redelivers through the given
+// notifier, holds at most maxSize notifications and gives up on one after maxAttempts retries
+func NewNotificationDeadLetterQueue(maxSize, maxAttempts int, notifier services.Notifier, logger *config.Logger) *NotificationDeadLetterQueue {
+	describe := func(notification types.Notification) []any {
+		return []any{
+			"id", notification.ID,
+			"type", notification.Type,
+			"recipient_id", notification.RecipientID,
+		}
+	}
+	return &NotificationDeadLetterQueue{
+		deadLetterStore: newDeadLetterStore("notification", maxSize, maxAttempts, describe, logger),
+		notifier:        notifier,
+	}
+}
+
+// NewNotificationDeadLetterQueueWithStore creates a dead letter queue like NewNotificationDeadLetterQueue
+// that keeps the queued notifications in store, so they are retried after a restart as well
+func NewNotificationDeadLetterQueueWithStore(maxSize, maxAttempts int, notifier services.Notifier, store NotificationQueueStore, logger *config.Logger) *NotificationDeadLetterQueue {
+	q := NewNotificationDeadLetterQueue(maxSize, maxAttempts, notifier, logger)
+	q.persist = store
+	return q
+}
+
+// AddFailedNotification queues a notification whose delivery failed.
+// It satisfies services.NotificationFailureHandler.
+func (q *NotificationDeadLetterQueue) AddFailedNotification(notification types.Notification, err error) {
+	q.add([]types.Notification{notification}, err)
+	q.flush()
+}
+
+// RetryFailedNotifications re-attempts the delivery of every queued notification once.
+// Returns the number of delivered notifications and stops early if the context is cancelled.
+func (q *NotificationDeadLetterQueue) RetryFailedNotifications(ctx context.Context) (int, error) {
+	if q.persist != nil {
+		// Notifications that still fail are stored again once the round is over
+		defer q.flush()
+
+		storeCtx, cancel := context.WithTimeout(ctx, notificationStoreTimeout)
+		entries, err := q.persist.Pop(storeCtx, max(q.maxSize, 1))
+		cancel()
+		if err != nil {
+			q.logger.Warn("Failed to load queued notifications, retrying the ones in memory", "queue", q.label, "error", err)
+		}
+		q.restore(entries)
+	}
+
+	return q.retry(ctx, q.notifier.Notify)
+}
+
+// flush moves the notifications held in memory to the store. They stay in memory when the
+// store is unreachable, the next flush tries again.
+func (q *NotificationDeadLetterQueue) flush() {
+	if q.persist == nil {
+		return
+	}
+
+	entries := q.take()
+	if len(entries) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notificationStoreTimeout)
+	defer cancel()
+
+	dropped, err := q.persist.Push(ctx, entries)
+	if err != nil {
+		q.logger.Warn("Failed to store queued notifications, keeping them in memory",
+			"queue", q.label,
+			"pending", len(entries),
+			"error", err)
+		q.restore(entries)
+		return
+	}
+	if dropped > 0 {
+		q.mu.Lock()
+		q.countDropped(dropped)
+		q.mu.Unlock()
+	}
+}
+
+// Size returns the number of notifications waiting to be retried, in memory and in the store
+func (q *NotificationDeadLetterQueue) Size() int {
+	size := q.deadLetterStore.Size()
+	if q.persist == nil {
+		return size
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notificationStoreTimeout)
+	defer cancel()
+
+	stored, err := q.persist.Len(ctx)
+	if err != nil {
+		q.logger.Warn("Failed to count queued notifications", "queue", q.label, "error", err)
+		return size
+	}
+	return size + stored
+}
+
+// Stats returns the current dead letter queue statistics, counting the stored notifications as well
+func (q *NotificationDeadLetterQueue) Stats() map[string]any {
+	stats := q.deadLetterStore.Stats()
+	stats["size"] = q.Size()
+	stats["persistent"] = q.persist != nil
+	return stats
+}
+
+// Start starts the notification retry worker
+func (nw *NotificationWorker) Start() error {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	if nw.running {
+		return fmt.Errorf("notification worker already running")
+	}
+
+	if !nw.cfg.Notification.RetryEnabled || nw.cfg.Notification.RetryInterval <= 0 {
+		return nil // Failed notifications are not retried
+	}
+
+	nw.running = true
+	nw.wg.Add(1)
+	go nw.run()
+
+	return nil
+}
+
+// Stop gracefully stops the notification retry worker
+func (nw *NotificationWorker) Stop(ctx context.Context) error {
+	nw.mu.Lock()
+	if !nw.running {
+		nw.mu.Unlock()
+		return nil
+	}
+	nw.cancel()
+	nw.mu.Unlock()
+
+	// Wait for worker to finish with timeout
+	done := make(chan struct{})
+	go func() {
+		nw.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		nw.logger.Info("Notification worker stopped successfully")
+		return nil
+	case <-ctx.Done():
+		nw.logger.Warn("Notification worker stop timed out")
+		return ctx.Err()
+	}
+}
+
+// HealthStatus returns the current health status of the notification worker
+func (nw *NotificationWorker) HealthStatus() map[string]any {
+	nw.mu.RLock()
+	defer nw.mu.RUnlock()
+
+	enabled := nw.cfg.Notification.RetryEnabled
+	return map[string]any{
+		"enabled":        enabled,
+		"worker_running": nw.running,
+		"is_healthy":     enabled && nw.running,
+		"dead_letter":    nw.dlq.Stats(),
+		"configuration": map[string]any{
+			"retry_interval":    nw.cfg.Notification.RetryInterval.String(),
+			"max_retries":       nw.cfg.Notification.MaxRetries,
+			"webhook_enabled":   nw.cfg.Notification.WebhookURL != "",
+			"dead_letter_limit": nw.cfg.Notification.DLQSize,
+			"persistent":        nw.cfg.Notification.DLQPersistent,
+		},
+	}
+}
+
+// run retries the queued notifications every retry interval until the worker is stopped
+func (nw *NotificationWorker) run() {
+	defer nw.wg.Done()
+	defer func() {
+		nw.mu.Lock()
+		nw.running = false
+		nw.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(nw.cfg.Notification.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			nw.retryFailedNotifications()
+		case <-nw.ctx.Done():
+			nw.logger.Info("Notification retry worker stopped")
+			return
+		}
+	}
+}
+
+// retryFailedNotifications re-attempts the notifications waiting in the dead letter queue
+func (nw *NotificationWorker) retryFailedNotifications() {
+	if nw.dlq.Size() == 0 {
+		return
+	}
+
+	// Bound a retry round so a slow receiver cannot stall the worker
+	ctx, cancel := context.WithTimeout(nw.ctx, nw.cfg.Notification.RetryInterval)
+	defer cancel()
+
+	if _, err := nw.dlq.RetryFailedNotifications(ctx); err != nil {
+		nw.logger.Warn("Notification retry interrupted", "error", err, "remaining", nw.dlq.Size())
+	}
+}
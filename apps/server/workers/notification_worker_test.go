@@ -0,0 +1,218 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+func TestFailedNotificationIsQueuedAndRetried(t *testing.T) {
+	// The webhook is down for the first delivery and recovers afterwards
+	var calls atomic.Int32
+	var delivered atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var notification types.Notification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		delivered.Store(notification)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := services.NewWebhookNotifier(server.URL)
+	dlq := NewNotificationDeadLetterQueue(100, 3, notifier, newDiscardLogger())
+
+	services.RegisterNotificationFailureHandler(dlq.AddFailedNotification)
+	defer services.RegisterNotificationFailureHandler(nil)
+
+	sent := types.Notification{
+		Type:        types.NotificationSubmissionReceived,
+		RecipientID: uuid.New(),
+		Data:        map[string]any{"deadline_id": uuid.NewString()},
+	}
+	ns := services.NewNotificationServiceWithNotifier(notifier, newDiscardLogger())
+	if err := ns.Send(context.Background(), sent); err == nil {
+		t.Fatal("Expected the first delivery to fail")
+	}
+	if dlq.Size() != 1 {
+		t.Fatalf("Expected the failed notification to be queued, queue size %d", dlq.Size())
+	}
+
+	recovered, err := dlq.RetryFailedNotifications(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected retry error: %v", err)
+	}
+	if recovered != 1 || dlq.Size() != 0 {
+		t.Fatalf("Expected the notification to be delivered on retry, recovered %d, queue size %d", recovered, dlq.Size())
+	}
+
+	got, ok := delivered.Load().(types.Notification)
+	if !ok {
+		t.Fatal("Expected the webhook to receive the notification")
+	}
+	if got.ID == uuid.Nil || got.RecipientID != sent.RecipientID || got.Type != sent.Type {
+		t.Errorf("Expected the queued notification to be redelivered unchanged, got %+v", got)
+	}
+	if recoveredStat := dlq.Stats()["recovered"].(int64); recoveredStat != 1 {
+		t.Errorf("Expected 1 recovered notification in stats, got %d", recoveredStat)
+	}
+}
+
+// failingNotifier fails every delivery
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(ctx context.Context, notification types.Notification) error {
+	return errors.New("mail server unavailable")
+}
+
+func TestNotificationDeadLetterQueueDropsAfterMaxAttempts(t *testing.T) {
+	dlq := NewNotificationDeadLetterQueue(100, 2, failingNotifier{}, newDiscardLogger())
+	dlq.AddFailedNotification(types.Notification{ID: uuid.New()}, errors.New("mail server unavailable"))
+
+	for range 2 {
+		if _, err := dlq.RetryFailedNotifications(context.Background()); err != nil {
+			t.Fatalf("Unexpected retry error: %v", err)
+		}
+	}
+
+	if dlq.Size() != 0 {
+		t.Errorf("Expected notification to be dropped after max attempts, queue size %d", dlq.Size())
+	}
+	if dropped := dlq.Stats()["dropped"].(int64); dropped != 1 {
+		t.Errorf("Expected 1 dropped notification, got %d", dropped)
+	}
+}
+
+func TestNotificationServiceWithoutFailureHandler(t *testing.T) {
+	services.RegisterNotificationFailureHandler(nil)
+
+	ns := services.NewNotificationServiceWithNotifier(failingNotifier{}, newDiscardLogger())
+	if err := ns.Send(context.Background(), types.Notification{}); err == nil {
+		t.Error("Expected the delivery error to be returned")
+	}
+}
+
+// memoryNotificationStore is an in-process NotificationQueueStore that can be made unreachable
+type memoryNotificationStore struct {
+	mu      sync.Mutex
+	entries []*DeadLetterEntry[types.Notification]
+	down    bool
+}
+
+func (ms *memoryNotificationStore) Push(ctx context.Context, entries []*DeadLetterEntry[types.Notification]) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.down {
+		return 0, errors.New("connection refused")
+	}
+	ms.entries = append(ms.entries, entries...)
+	return 0, nil
+}
+
+func (ms *memoryNotificationStore) Pop(ctx context.Context, count int) ([]*DeadLetterEntry[types.Notification], error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.down {
+		return nil, errors.New("connection refused")
+	}
+	count = min(count, len(ms.entries))
+	popped := ms.entries[:count]
+	ms.entries = ms.entries[count:]
+	return popped, nil
+}
+
+func (ms *memoryNotificationStore) Len(ctx context.Context) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.down {
+		return 0, errors.New("connection refused")
+	}
+	return len(ms.entries), nil
+}
+
+// recordingNotifier delivers every notification and remembers it
+type recordingNotifier struct {
+	mu        sync.Mutex
+	delivered []types.Notification
+}
+
+func (rn *recordingNotifier) Notify(ctx context.Context, notification types.Notification) error {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	rn.delivered = append(rn.delivered, notification)
+	return nil
+}
+
+func TestPersistentNotificationQueueSurvivesRestart(t *testing.T) {
+	store := &memoryNotificationStore{}
+	sent := types.Notification{ID: uuid.New(), Type: types.NotificationSubmissionReceived, RecipientID: uuid.New()}
+
+	// The first process fails to redeliver the notification once and then stops
+	before := NewNotificationDeadLetterQueueWithStore(100, 3, failingNotifier{}, store, newDiscardLogger())
+	before.AddFailedNotification(sent, errors.New("mail server unavailable"))
+	if _, err := before.RetryFailedNotifications(context.Background()); err != nil {
+		t.Fatalf("Unexpected retry error: %v", err)
+	}
+	if before.deadLetterStore.Size() != 0 || len(store.entries) != 1 {
+		t.Fatalf("Expected the notification to be stored outside the process, %d in memory, %d stored",
+			before.deadLetterStore.Size(), len(store.entries))
+	}
+
+	// The next process picks it up from the store and delivers it
+	notifier := &recordingNotifier{}
+	after := NewNotificationDeadLetterQueueWithStore(100, 3, notifier, store, newDiscardLogger())
+	if size := after.Size(); size != 1 {
+		t.Fatalf("Expected the stored notification to be counted after a restart, got %d", size)
+	}
+	recovered, err := after.RetryFailedNotifications(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected retry error: %v", err)
+	}
+	if recovered != 1 || after.Size() != 0 {
+		t.Fatalf("Expected the notification to be delivered after a restart, recovered %d, queue size %d", recovered, after.Size())
+	}
+	if len(notifier.delivered) != 1 || notifier.delivered[0].ID != sent.ID {
+		t.Errorf("Expected the queued notification to be delivered, got %+v", notifier.delivered)
+	}
+}
+
+func TestPersistentNotificationQueueFallsBackToMemory(t *testing.T) {
+	store := &memoryNotificationStore{down: true}
+	dlq := NewNotificationDeadLetterQueueWithStore(100, 3, failingNotifier{}, store, newDiscardLogger())
+
+	// A failure while the store is down is kept in memory instead of being lost
+	dlq.AddFailedNotification(types.Notification{ID: uuid.New()}, errors.New("mail server unavailable"))
+	if dlq.deadLetterStore.Size() != 1 {
+		t.Fatalf("Expected the notification to be kept in memory, queue size %d", dlq.deadLetterStore.Size())
+	}
+
+	// Once the store is back the next retry round moves it there, keeping its retry state
+	store.mu.Lock()
+	store.down = false
+	store.mu.Unlock()
+	if _, err := dlq.RetryFailedNotifications(context.Background()); err != nil {
+		t.Fatalf("Unexpected retry error: %v", err)
+	}
+	if dlq.deadLetterStore.Size() != 0 || len(store.entries) != 1 {
+		t.Fatalf("Expected the notification to be moved to the store, %d in memory, %d stored",
+			dlq.deadLetterStore.Size(), len(store.entries))
+	}
+	if attempts := store.entries[0].Attempts; attempts != 1 {
+		t.Errorf("Expected 1 attempt to be kept with the stored notification, got %d", attempts)
+	}
+}
@@ -0,0 +1,252 @@
+package workers
+
+import (
+	"github.com/MonkyMars/PWS/database"
+	"github.com/MonkyMars/PWS/lib"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "pws"
+
+// MetricsCollector exports worker, database and Redis pool and circuit breaker statistics as Prometheus metrics.
+// Values are read from the live workers on every scrape, so no extra bookkeeping is needed.
+type MetricsCollector struct {
+	manager *WorkerManager
+
+	auditProcessed     *prometheus.Desc
+	auditDropped       *prometheus.Desc
+	auditDeduped       *prometheus.Desc
+	auditFailures      *prometheus.Desc
+	auditQueueSize     *prometheus.Desc
+	auditQueueCapacity *prometheus.Desc
+	auditRunning       *prometheus.Desc
+
+	healthQueueSize     *prometheus.Desc
+	healthQueueCapacity *prometheus.Desc
+	healthRunning       *prometheus.Desc
+
+	serviceRequests *prometheus.Desc
+	serviceErrors   *prometheus.Desc
+	serviceLatency  *prometheus.Desc
+	serviceStatus   *prometheus.Desc
+
+	routeRequests *prometheus.Desc
+	routeErrors   *prometheus.Desc
+	routeLatency  *prometheus.Desc
+
+	redisHits       *prometheus.Desc
+	redisMisses     *prometheus.Desc
+	redisTimeouts   *prometheus.Desc
+	redisTotalConns *prometheus.Desc
+	redisIdleConns  *prometheus.Desc
+	redisStaleConns *prometheus.Desc
+
+	dbWaits       *prometheus.Desc
+	dbSlowWaits   *prometheus.Desc
+	dbWaitSeconds *prometheus.Desc
+	dbMaxWait     *prometheus.Desc
+	dbInUse       *prometheus.Desc
+	dbCapacity    *prometheus.Desc
+
+	breakerState     *prometheus.Desc
+	breakerFailures  *prometheus.Desc
+	breakerRequests  *prometheus.Desc
+	breakerSuccesses *prometheus.Desc
+}
+
+// NewMetricsCollector creates a collector for the given worker manager
+func NewMetricsCollector(manager *WorkerManager) *MetricsCollector {
+	desc := func(subsystem, name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, subsystem, name), help, labels, nil)
+	}
+
+	return &MetricsCollector{
+		manager: manager,
+
+		auditProcessed:     desc("audit", "processed_total", "Total number of audit logs written to the database."),
+		auditDropped:       desc("audit", "dropped_total", "Total number of audit logs dropped."),
+		auditDeduped:       desc("audit", "deduped_total", "Total number of audit logs skipped as duplicates of a stored entry."),
+		auditFailures:      desc("audit", "failure_count", "Consecutive failed audit batch flushes."),
+		auditQueueSize:     desc("audit", "queue_size", "Number of audit logs waiting to be flushed."),
+		auditQueueCapacity: desc("audit", "queue_capacity", "Capacity of the audit log queue."),
+		auditRunning:       desc("audit", "worker_running", "Whether the audit worker is running (1) or not (0)."),
+
+		healthQueueSize:     desc("health", "queue_size", "Number of health reports waiting to be flushed."),
+		healthQueueCapacity: desc("health", "queue_capacity", "Capacity of the health report queue."),
+		healthRunning:       desc("health", "worker_running", "Whether the health worker is running (1) or not (0)."),
+
+		serviceRequests: desc("service", "requests_total", "Total number of requests handled per service.", "service"),
+		serviceErrors:   desc("service", "errors_total", "Total number of requests with a 4xx or 5xx status per service.", "service"),
+		serviceLatency:  desc("service", "latency_seconds_total", "Total time spent handling requests per service.", "service"),
+		serviceStatus:   desc("service", "last_status_code", "Status code of the most recent request per service.", "service"),
+
+		routeRequests: desc("route", "requests_total", "Total number of requests handled per route.", "method", "route", "service"),
+		routeErrors:   desc("route", "errors_total", "Total number of requests with a 4xx or 5xx status per route.", "method", "route", "service"),
+		routeLatency:  desc("route", "latency_seconds_total", "Total time spent handling requests per route.", "method", "route", "service"),
+
+		redisHits:       desc("redis_pool", "hits_total", "Number of times a free connection was found in the pool."),
+		redisMisses:     desc("redis_pool", "misses_total", "Number of times a free connection was not found in the pool."),
+		redisTimeouts:   desc("redis_pool", "timeouts_total", "Number of times a wait for a connection timed out."),
+		redisTotalConns: desc("redis_pool", "total_conns", "Number of connections in the pool."),
+		redisIdleConns:  desc("redis_pool", "idle_conns", "Number of idle connections in the pool."),
+		redisStaleConns: desc("redis_pool", "stale_conns", "Number of stale connections removed from the pool."),
+
+		dbWaits:       desc("db_pool", "waits_total", "Number of queries that had to wait for a database connection."),
+		dbSlowWaits:   desc("db_pool", "slow_waits_total", "Number of connection waits longer than DB_CONN_WAIT_WARN_THRESHOLD."),
+		dbWaitSeconds: desc("db_pool", "wait_seconds_total", "Total time queries spent waiting for a database connection."),
+		dbMaxWait:     desc("db_pool", "max_wait_seconds", "Longest time a query waited for a database connection."),
+		dbInUse:       desc("db_pool", "in_use", "Number of queries currently holding a database connection."),
+		dbCapacity:    desc("db_pool", "capacity", "Number of queries that may hold a database connection at once."),
+
+		breakerState:     desc("db_circuit_breaker", "state", "Database circuit breaker state (1 for the current state).", "state"),
+		breakerFailures:  desc("db_circuit_breaker", "failures", "Consecutive failures recorded by the database circuit breaker."),
+		breakerRequests:  desc("db_circuit_breaker", "requests", "Requests recorded by the database circuit breaker in the current state."),
+		breakerSuccesses: desc("db_circuit_breaker", "successes", "Successes recorded by the database circuit breaker in the current state."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (mc *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		mc.auditProcessed, mc.auditDropped, mc.auditDeduped, mc.auditFailures, mc.auditQueueSize, mc.auditQueueCapacity, mc.auditRunning,
+		mc.healthQueueSize, mc.healthQueueCapacity, mc.healthRunning,
+		mc.serviceRequests, mc.serviceErrors, mc.serviceLatency, mc.serviceStatus,
+		mc.routeRequests, mc.routeErrors, mc.routeLatency,
+		mc.redisHits, mc.redisMisses, mc.redisTimeouts, mc.redisTotalConns, mc.redisIdleConns, mc.redisStaleConns,
+		mc.dbWaits, mc.dbSlowWaits, mc.dbWaitSeconds, mc.dbMaxWait, mc.dbInUse, mc.dbCapacity,
+		mc.breakerState, mc.breakerFailures, mc.breakerRequests, mc.breakerSuccesses,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (mc *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	if mc.manager != nil {
+		mc.manager.mu.RLock()
+		auditWorker := mc.manager.auditWorker
+		healthWorker := mc.manager.healthWorker
+		mc.manager.mu.RUnlock()
+
+		mc.collectAudit(ch, auditWorker)
+		mc.collectHealth(ch, healthWorker)
+	}
+
+	mc.collectDatabase(ch)
+	mc.collectRedis(ch)
+	mc.collectCircuitBreaker(ch)
+}
+
+// collectAudit exports the audit worker statistics
+func (mc *MetricsCollector) collectAudit(ch chan<- prometheus.Metric, aw *AuditWorker) {
+	if aw == nil || aw.cfg == nil {
+		return
+	}
+
+	aw.mu.RLock()
+	stats := aw.stats
+	running := aw.running
+	queueSize := len(aw.auditChan)
+	aw.mu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(mc.auditProcessed, prometheus.CounterValue, float64(stats.TotalProcessed))
+	ch <- prometheus.MustNewConstMetric(mc.auditDropped, prometheus.CounterValue, float64(stats.TotalDropped))
+	ch <- prometheus.MustNewConstMetric(mc.auditDeduped, prometheus.CounterValue, float64(stats.TotalDeduped))
+	ch <- prometheus.MustNewConstMetric(mc.auditFailures, prometheus.GaugeValue, float64(stats.FailureCount))
+	ch <- prometheus.MustNewConstMetric(mc.auditQueueSize, prometheus.GaugeValue, float64(queueSize))
+	ch <- prometheus.MustNewConstMetric(mc.auditQueueCapacity, prometheus.GaugeValue, float64(aw.cfg.Audit.ChannelSize))
+	ch <- prometheus.MustNewConstMetric(mc.auditRunning, prometheus.GaugeValue, boolToFloat(running))
+}
+
+// collectHealth exports the health worker queue and the per-service and per-route request statistics
+func (mc *MetricsCollector) collectHealth(ch chan<- prometheus.Metric, hw *HealthWorker) {
+	if hw == nil || hw.cfg == nil {
+		return
+	}
+
+	hw.mu.RLock()
+	running := hw.running
+	queueSize := len(hw.healthChan)
+	hw.mu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(mc.healthQueueSize, prometheus.GaugeValue, float64(queueSize))
+	ch <- prometheus.MustNewConstMetric(mc.healthQueueCapacity, prometheus.GaugeValue, float64(hw.cfg.Health.ChannelSize))
+	ch <- prometheus.MustNewConstMetric(mc.healthRunning, prometheus.GaugeValue, boolToFloat(running))
+
+	for _, name := range hw.GetAllServices() {
+		stats := hw.GetServiceStats(name)
+		if stats == nil {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(mc.serviceRequests, prometheus.CounterValue, float64(stats.RequestCount), name)
+		ch <- prometheus.MustNewConstMetric(mc.serviceErrors, prometheus.CounterValue, float64(stats.ErrorCount), name)
+		ch <- prometheus.MustNewConstMetric(mc.serviceLatency, prometheus.CounterValue, stats.TotalLatency.Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(mc.serviceStatus, prometheus.GaugeValue, float64(stats.LastStatus), name)
+	}
+
+	for _, route := range hw.RouteMetrics() {
+		labels := []string{route.Method, route.Path, route.Service}
+		ch <- prometheus.MustNewConstMetric(mc.routeRequests, prometheus.CounterValue, float64(route.RequestCount), labels...)
+		ch <- prometheus.MustNewConstMetric(mc.routeErrors, prometheus.CounterValue, float64(route.ErrorCount), labels...)
+		ch <- prometheus.MustNewConstMetric(mc.routeLatency, prometheus.CounterValue, route.TotalLatencyMs/1000, labels...)
+	}
+}
+
+// collectDatabase exports how long queries wait for a connection of the primary database pool
+func (mc *MetricsCollector) collectDatabase(ch chan<- prometheus.Metric) {
+	guard := database.GetConnGuard()
+	if guard == nil {
+		return
+	}
+
+	stats := guard.Stats()
+	ch <- prometheus.MustNewConstMetric(mc.dbWaits, prometheus.CounterValue, float64(stats.Waits))
+	ch <- prometheus.MustNewConstMetric(mc.dbSlowWaits, prometheus.CounterValue, float64(stats.SlowWaits))
+	ch <- prometheus.MustNewConstMetric(mc.dbWaitSeconds, prometheus.CounterValue, stats.TotalWait.Seconds())
+	ch <- prometheus.MustNewConstMetric(mc.dbMaxWait, prometheus.GaugeValue, stats.MaxWait.Seconds())
+	ch <- prometheus.MustNewConstMetric(mc.dbInUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(mc.dbCapacity, prometheus.GaugeValue, float64(stats.Capacity))
+}
+
+// collectRedis exports the Redis connection pool statistics
+func (mc *MetricsCollector) collectRedis(ch chan<- prometheus.Metric) {
+	client := services.GetRedisClient()
+	if client == nil {
+		return
+	}
+
+	stats := client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(mc.redisHits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(mc.redisMisses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(mc.redisTimeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(mc.redisTotalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(mc.redisIdleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(mc.redisStaleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
+
+// collectCircuitBreaker exports the database circuit breaker state and counters
+func (mc *MetricsCollector) collectCircuitBreaker(ch chan<- prometheus.Metric) {
+	cb := services.GetCircuitBreaker()
+	if cb == nil {
+		return
+	}
+
+	current := cb.State()
+	for _, state := range []lib.CircuitState{lib.StateClosed, lib.StateOpen, lib.StateHalfOpen} {
+		ch <- prometheus.MustNewConstMetric(mc.breakerState, prometheus.GaugeValue, boolToFloat(state == current), state.String())
+	}
+
+	ch <- prometheus.MustNewConstMetric(mc.breakerFailures, prometheus.GaugeValue, float64(cb.Failures()))
+	ch <- prometheus.MustNewConstMetric(mc.breakerRequests, prometheus.GaugeValue, float64(cb.Requests()))
+	ch <- prometheus.MustNewConstMetric(mc.breakerSuccesses, prometheus.GaugeValue, float64(cb.Successes()))
+}
+
+// boolToFloat converts a boolean to the 1/0 convention used by Prometheus gauges
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,292 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/services"
+	"github.com/MonkyMars/PWS/types"
+)
+
+// webhookSubscribersFunc returns the active webhooks subscribed to an event type
+type webhookSubscribersFunc func(eventType string) ([]types.Webhook, error)
+
+// WebhookDeadLetterQueue keeps webhook deliveries that failed every attempt so they can be retried later
+type WebhookDeadLetterQueue struct {
+	*deadLetterStore[types.WebhookDelivery]
+	sender services.WebhookSender
+}
+
+// NewWebhookDeadLetterQueue creates a dead letter queue that redelivers through the given
+// sender, holds at most maxSize deliveries and gives up on one after maxAttempts retries
+func NewWebhookDeadLetterQueue(maxSize, maxAttempts int, sender services.WebhookSender, logger *config.Logger) *WebhookDeadLetterQueue {
+	describe := func(delivery types.WebhookDelivery) []any {
+		return []any{
+			"webhook_id", delivery.WebhookID,
+			"event_id", delivery.Event.ID,
+			"event_type", delivery.Event.Type,
+		}
+	}
+	return &WebhookDeadLetterQueue{
+		deadLetterStore: newDeadLetterStore("webhook delivery", maxSize, maxAttempts, describe, logger),
+		sender:          sender,
+	}
+}
+
+// AddFailedDelivery queues a delivery that failed all of its attempts
+func (q *WebhookDeadLetterQueue) AddFailedDelivery(delivery types.WebhookDelivery, err error) {
+	q.add([]types.WebhookDelivery{delivery}, err)
+}
+
+// RetryFailedDeliveries re-attempts every queued delivery once.
+// Returns the number of delivered events and stops early if the context is cancelled.
+func (q *WebhookDeadLetterQueue) RetryFailedDeliveries(ctx context.Context) (int, error) {
+	return q.retry(ctx, q.sender.Deliver)
+}
+
+// Start starts the webhook worker
+func (ww *WebhookWorker) Start() error {
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+
+	if ww.running {
+		return fmt.Errorf("webhook worker already running")
+	}
+
+	if !ww.cfg.Webhook.Enabled {
+		return nil
+	}
+
+	ww.running = true
+	ww.wg.Add(1)
+	go ww.run()
+
+	return nil
+}
+
+// Stop gracefully stops the webhook worker, events still in the queue are delivered once before it exits
+func (ww *WebhookWorker) Stop(ctx context.Context) error {
+	ww.mu.Lock()
+	if !ww.running {
+		ww.mu.Unlock()
+		return nil
+	}
+	ww.cancel()
+	ww.mu.Unlock()
+
+	// Wait for worker to finish with timeout
+	done := make(chan struct{})
+	go func() {
+		ww.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		ww.logger.Info("Webhook worker stopped successfully")
+		return nil
+	case <-ctx.Done():
+		ww.logger.Warn("Webhook worker stop timed out")
+		return ctx.Err()
+	}
+}
+
+// AddEvent queues an event for delivery to its subscribers, the event is dropped when the queue is full.
+// It satisfies services.WebhookEventHandler.
+func (ww *WebhookWorker) AddEvent(event types.WebhookEvent) {
+	ww.mu.RLock()
+	running := ww.running
+	ww.mu.RUnlock()
+
+	if !running {
+		ww.logger.Warn("Webhook worker not running, dropping event", "event_id", event.ID, "event_type", event.Type)
+		return
+	}
+
+	select {
+	case ww.eventChan <- event:
+		return
+	default:
+	}
+
+	ww.mu.Lock()
+	ww.stats.TotalDropped++
+	ww.mu.Unlock()
+
+	ww.logger.Warn("Webhook event channel is full, dropping event",
+		"event_id", event.ID,
+		"event_type", event.Type,
+		"queue_size", len(ww.eventChan))
+}
+
+// HealthStatus returns the current health status of the webhook worker
+func (ww *WebhookWorker) HealthStatus() map[string]any {
+	ww.mu.RLock()
+	defer ww.mu.RUnlock()
+
+	enabled := ww.cfg.Webhook.Enabled
+	return map[string]any{
+		"enabled":            enabled,
+		"worker_running":     ww.running,
+		"is_healthy":         enabled && ww.running,
+		"queue_size":         len(ww.eventChan),
+		"queue_capacity":     ww.cfg.Webhook.ChannelSize,
+		"total_events":       ww.stats.TotalEvents,
+		"total_delivered":    ww.stats.TotalDelivered,
+		"total_failed":       ww.stats.TotalFailed,
+		"total_dropped":      ww.stats.TotalDropped,
+		"last_delivery_time": ww.stats.LastDeliveryTime,
+		"dead_letter":        ww.dlq.Stats(),
+		"configuration": map[string]any{
+			"timeout":           ww.cfg.Webhook.Timeout.String(),
+			"max_attempts":      ww.cfg.Webhook.MaxAttempts,
+			"retry_backoff":     ww.cfg.Webhook.RetryBackoff.String(),
+			"max_concurrency":   ww.cfg.Webhook.MaxConcurrency,
+			"retry_interval":    ww.cfg.Webhook.RetryInterval.String(),
+			"dead_letter_limit": ww.cfg.Webhook.DLQSize,
+		},
+	}
+}
+
+// run delivers queued events and retries the dead letter queue every retry interval until the worker is stopped
+func (ww *WebhookWorker) run() {
+	defer ww.wg.Done()
+	defer func() {
+		ww.mu.Lock()
+		ww.running = false
+		ww.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(ww.cfg.Webhook.RetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-ww.eventChan:
+			ww.processEvent(ww.ctx, event)
+
+		case <-ticker.C:
+			ww.retryDeadLetters()
+
+		case <-ww.ctx.Done():
+			// The worker context is cancelled, so drain with a fresh one. Every remaining
+			// event gets a single attempt, failures end up in the dead letter queue.
+			for {
+				select {
+				case event := <-ww.eventChan:
+					ww.processEvent(context.Background(), event)
+				default:
+					ww.logger.Info("Webhook worker stopped")
+					return
+				}
+			}
+		}
+	}
+}
+
+// processEvent hands an event to every active subscriber of its type. Each delivery runs in its own
+// goroutine once a slot of WEBHOOK_MAX_CONCURRENCY is free, so a receiver that keeps failing only
+// holds up its own slot instead of every other subscriber. Stop waits for the deliveries in flight.
+func (ww *WebhookWorker) processEvent(ctx context.Context, event types.WebhookEvent) {
+	ww.mu.Lock()
+	ww.stats.TotalEvents++
+	ww.mu.Unlock()
+
+	webhooks, err := ww.subscribers(event.Type)
+	if err != nil {
+		ww.logger.Error("Failed to look up webhook subscribers, dropping event",
+			"event_id", event.ID,
+			"event_type", event.Type,
+			"error", err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		delivery := types.WebhookDelivery{
+			WebhookID: webhook.ID,
+			URL:       webhook.URL,
+			Secret:    webhook.Secret,
+			Event:     event,
+		}
+
+		ww.deliveries <- struct{}{}
+		ww.wg.Add(1)
+		go func() {
+			defer ww.wg.Done()
+			defer func() { <-ww.deliveries }()
+			ww.deliver(ctx, delivery)
+		}()
+	}
+}
+
+// deliver sends a delivery with retries, queueing it in the dead letter queue when every attempt failed
+func (ww *WebhookWorker) deliver(ctx context.Context, delivery types.WebhookDelivery) {
+	if err := ww.deliverWithRetry(ctx, delivery); err != nil {
+		ww.mu.Lock()
+		ww.stats.TotalFailed++
+		ww.mu.Unlock()
+
+		ww.logger.Warn("Webhook delivery failed, queued for retry",
+			"webhook_id", delivery.WebhookID,
+			"event_id", delivery.Event.ID,
+			"event_type", delivery.Event.Type,
+			"error", err)
+		ww.dlq.AddFailedDelivery(delivery, err)
+		return
+	}
+
+	ww.mu.Lock()
+	ww.stats.TotalDelivered++
+	ww.stats.LastDeliveryTime = time.Now()
+	ww.mu.Unlock()
+}
+
+// deliverWithRetry attempts a delivery up to the configured number of attempts, doubling the
+// backoff after every failure. Once the worker is stopping the remaining attempts are skipped.
+func (ww *WebhookWorker) deliverWithRetry(ctx context.Context, delivery types.WebhookDelivery) error {
+	backoff := ww.cfg.Webhook.RetryBackoff
+
+	var err error
+	for attempt := 0; attempt < ww.cfg.Webhook.MaxAttempts; attempt++ {
+		if err = ww.sender.Deliver(ctx, delivery); err == nil {
+			return nil
+		}
+
+		if attempt == ww.cfg.Webhook.MaxAttempts-1 || ww.ctx.Err() != nil {
+			break
+		}
+
+		ww.logger.Debug("Webhook delivery failed, retrying",
+			"webhook_id", delivery.WebhookID,
+			"event_id", delivery.Event.ID,
+			"attempt", attempt+1,
+			"backoff", backoff,
+			"error", err)
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ww.ctx.Done():
+			return err
+		}
+	}
+
+	return err
+}
+
+// retryDeadLetters re-attempts the deliveries waiting in the dead letter queue
+func (ww *WebhookWorker) retryDeadLetters() {
+	if ww.dlq.Size() == 0 {
+		return
+	}
+
+	// Bound a retry round so a slow receiver cannot stall new deliveries
+	ctx, cancel := context.WithTimeout(ww.ctx, ww.cfg.Webhook.RetryInterval)
+	defer cancel()
+
+	if _, err := ww.dlq.RetryFailedDeliveries(ctx); err != nil {
+		ww.logger.Warn("Webhook retry interrupted", "error", err, "remaining", ww.dlq.Size())
+	}
+}
@@ -0,0 +1,75 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MonkyMars/PWS/config"
+	"github.com/MonkyMars/PWS/types"
+	"github.com/google/uuid"
+)
+
+// webhookSenderFunc adapts a function to services.WebhookSender
+type webhookSenderFunc func(ctx context.Context, delivery types.WebhookDelivery) error
+
+func (f webhookSenderFunc) Deliver(ctx context.Context, delivery types.WebhookDelivery) error {
+	return f(ctx, delivery)
+}
+
+func TestWebhookDeadReceiverDoesNotBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	delivered := make(chan string, 1)
+	sender := webhookSenderFunc(func(ctx context.Context, delivery types.WebhookDelivery) error {
+		if delivery.URL == "https://dead.example.com" {
+			// Hangs until the test lets it fail, like a receiver that never answers
+			<-release
+			return errors.New("receiver timed out")
+		}
+		delivered <- delivery.URL
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ww := &WebhookWorker{
+		ctx:        ctx,
+		cancel:     cancel,
+		logger:     newDiscardLogger(),
+		cfg:        &config.Config{Webhook: types.WebhookConfig{Enabled: true, MaxAttempts: 1, MaxConcurrency: 2}},
+		dlq:        NewWebhookDeadLetterQueue(10, 3, sender, newDiscardLogger()),
+		sender:     sender,
+		deliveries: make(chan struct{}, 2),
+		subscribers: func(eventType string) ([]types.Webhook, error) {
+			return []types.Webhook{
+				{ID: uuid.New(), URL: "https://dead.example.com"},
+				{ID: uuid.New(), URL: "https://alive.example.com"},
+			}, nil
+		},
+	}
+
+	ww.processEvent(ctx, types.WebhookEvent{ID: uuid.New(), Type: "deadline.created"})
+
+	select {
+	case url := <-delivered:
+		if url != "https://alive.example.com" {
+			t.Errorf("Expected the live receiver to be delivered to, got %s", url)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the live receiver to be delivered to while the dead one hangs")
+	}
+
+	close(release)
+	ww.wg.Wait()
+
+	if ww.dlq.Size() != 1 {
+		t.Errorf("Expected the failed delivery in the dead letter queue, queue size %d", ww.dlq.Size())
+	}
+	if ww.stats.TotalDelivered != 1 || ww.stats.TotalFailed != 1 {
+		t.Errorf("Expected 1 delivered and 1 failed, got %+v", ww.stats)
+	}
+	if len(ww.deliveries) != 0 {
+		t.Errorf("Expected every delivery slot to be freed, %d still taken", len(ww.deliveries))
+	}
+}
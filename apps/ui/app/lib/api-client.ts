@@ -6,6 +6,11 @@ import { env } from './env';
  */
 const API_URL = env.apiUrl;
 
+/**
+ * Header carrying the CSRF token. The server sends it on reads and expects it back on writes
+ */
+const CSRF_HEADER = 'X-CSRF-Token';
+
 /**
  * API client class for making HTTP requests to the ELO backend with cookie-based auth
  */
@@ -13,11 +18,21 @@ export class ApiClient {
   private baseUrl: string;
   private isRefreshing = false;
   private refreshPromise: Promise<boolean> | null = null;
+  private csrfToken: string | null = null;
 
   constructor(baseUrl: string = API_URL) {
     this.baseUrl = baseUrl;
   }
 
+  /**
+   * Remember the latest CSRF token sent by the server
+   */
+  private storeCsrfToken(token: string | null): void {
+    if (token) {
+      this.csrfToken = token;
+    }
+  }
+
   /**
    * Refresh the access token using the refresh token from cookies
    */
@@ -33,6 +48,7 @@ export class ApiClient {
 
       if (response.ok) {
         // New tokens are automatically set as cookies by the server
+        this.storeCsrfToken(response.headers.get(CSRF_HEADER));
         return true;
       }
 
@@ -79,6 +95,12 @@ export class ApiClient {
       ...((options.headers as Record<string, string>) || {}),
     };
 
+    // The cookie session requires the CSRF token on every write
+    const method = (options.method || 'GET').toUpperCase();
+    if (method !== 'GET' && method !== 'HEAD' && this.csrfToken) {
+      headers[CSRF_HEADER] = this.csrfToken;
+    }
+
     const requestOptions: RequestInit = {
       ...options,
       headers,
@@ -87,6 +109,7 @@ export class ApiClient {
 
     try {
       const response = await fetch(url, requestOptions);
+      this.storeCsrfToken(response.headers.get(CSRF_HEADER));
       const data = await response.json();
 
       // Handle 401 Unauthorized responses
@@ -222,6 +245,7 @@ export class ApiClient {
       }
 
       xhr.onload = () => {
+        this.storeCsrfToken(xhr.getResponseHeader(CSRF_HEADER));
         try {
           const response = JSON.parse(xhr.responseText);
           resolve(response);
@@ -234,6 +258,9 @@ export class ApiClient {
 
       xhr.open('POST', `${this.baseUrl}${endpoint}`);
       xhr.withCredentials = true; // Include cookies for uploads
+      if (this.csrfToken) {
+        xhr.setRequestHeader(CSRF_HEADER, this.csrfToken);
+      }
       xhr.send(formData);
     });
   }